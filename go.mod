@@ -4,11 +4,12 @@ go 1.12
 
 require (
 	github.com/TarsCloud/TarsGo v0.0.0-20181112071624-2d42457f2025
+	github.com/andybalholm/brotli v1.0.2
 	github.com/apache/dubbo-go-hessian2 v1.3.0
 	github.com/beorn7/perks v1.0.1 // indirect
 	github.com/c2h5oh/datasize v0.0.0-20171227191756-4eba002a5eae
 	github.com/envoyproxy/go-control-plane v0.6.9
-	github.com/gogo/googleapis v1.2.0 // indirect
+	github.com/gogo/googleapis v1.2.0
 	github.com/gogo/protobuf v1.2.1
 	github.com/golang/protobuf v1.3.2
 	github.com/hashicorp/go-plugin v1.0.1
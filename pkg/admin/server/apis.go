@@ -179,6 +179,26 @@ func disableLogger(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprint(w, "disable logger success\n")
 }
 
+// returns the xds sync state keyed by type url, contains
+// the accepted version, the latest nonce and the latest error
+func xdsStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "xds status", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if buf, err := store.DumpXdsStatus(); err == nil {
+		log.DefaultLogger.Infof("[admin api] [xds status] xds status dump")
+		w.WriteHeader(200)
+		w.Write(buf)
+	} else {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: %v", "xds status", err)
+		w.WriteHeader(500)
+		msg := fmt.Sprintf(errMsgFmt, "internal error")
+		fmt.Fprint(w, msg)
+	}
+}
+
 // returns data
 // pid=xxx&state=xxx
 func getState(w http.ResponseWriter, r *http.Request) {
@@ -355,6 +355,42 @@ func TestGetState(t *testing.T) {
 	}
 }
 
+func TestXdsStatus(t *testing.T) {
+	time.Sleep(time.Second)
+	server := Server{}
+	config := &mockMOSNConfig{
+		Name: "mock",
+		Port: 8889,
+	}
+	server.Start(config)
+	store.StartService(nil)
+	defer store.StopService()
+	defer store.ResetXdsStatus()
+
+	store.SetXdsStatus("type.googleapis.com/envoy.api.v2.Cluster", store.XdsStatus{
+		VersionInfo: "1",
+		Nonce:       "2",
+		LastError:   "cluster name is empty",
+	})
+
+	time.Sleep(time.Second) //wait server start
+
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d/api/v1/xds_status", config.Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	status := map[string]store.XdsStatus{}
+	if err := rawjson.Unmarshal(b, &status); err != nil {
+		t.Fatalf("unexpected response: %s, %v", string(b), err)
+	}
+	s, ok := status["type.googleapis.com/envoy.api.v2.Cluster"]
+	if !ok || s.VersionInfo != "1" || s.Nonce != "2" || s.LastError != "cluster name is empty" {
+		t.Errorf("unexpected xds status: %s", string(b))
+	}
+}
+
 func TestRegisterNewAPI(t *testing.T) {
 	// register api before start
 	newAPI := func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package store
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// XdsStatus represents the latest sync state of a xds resource type
+type XdsStatus struct {
+	// VersionInfo is the version of the last accepted response, which is taking effect
+	VersionInfo string `json:"version_info"`
+	// Nonce is the nonce of the latest received response
+	Nonce string `json:"nonce"`
+	// LastError is the reason why the latest response is rejected, empty if accepted
+	LastError  string    `json:"last_error,omitempty"`
+	LastUpdate time.Time `json:"last_update"`
+}
+
+var (
+	xdsStatus      = make(map[string]XdsStatus)
+	xdsStatusMutex sync.RWMutex
+)
+
+// SetXdsStatus sets the sync state of the type url
+func SetXdsStatus(typeURL string, status XdsStatus) {
+	xdsStatusMutex.Lock()
+	defer xdsStatusMutex.Unlock()
+	xdsStatus[typeURL] = status
+}
+
+// GetXdsStatus returns the sync state of the type url
+func GetXdsStatus(typeURL string) (XdsStatus, bool) {
+	xdsStatusMutex.RLock()
+	defer xdsStatusMutex.RUnlock()
+	status, ok := xdsStatus[typeURL]
+	return status, ok
+}
+
+// ResetXdsStatus clears all of the xds states
+func ResetXdsStatus() {
+	xdsStatusMutex.Lock()
+	defer xdsStatusMutex.Unlock()
+	xdsStatus = make(map[string]XdsStatus)
+}
+
+// DumpXdsStatus dumps all of the xds states, keyed by type url
+func DumpXdsStatus() ([]byte, error) {
+	xdsStatusMutex.RLock()
+	defer xdsStatusMutex.RUnlock()
+	return json.Marshal(xdsStatus)
+}
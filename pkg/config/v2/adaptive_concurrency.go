@@ -0,0 +1,56 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package v2
+
+import (
+	"mosn.io/api"
+)
+
+// Adaptive concurrency limit keys
+const (
+	AdaptiveConcurrencyKeyCluster = "cluster"
+	AdaptiveConcurrencyKeyRoute   = "route"
+)
+
+// AdaptiveConcurrencyConfig is the config of the adaptive concurrency stream filter.
+// The concurrency limit is adjusted by the gradient of the min rtt and the sampled rtt,
+// limit = limit * (min_rtt + buffer) / sample_rtt + sqrt(limit)
+type AdaptiveConcurrencyConfig struct {
+	// Key is cluster (default) or route, the limit is calculated per upstream cluster or per route.
+	// The cluster name is used if the route has no name
+	Key string `json:"key,omitempty"`
+	// SampleAggregatePercentile is the percentile of the latency samples used as the sample rtt, default is 50
+	SampleAggregatePercentile float64 `json:"sample_aggregate_percentile,omitempty"`
+	// MaxConcurrencyLimit is the upper bound of the concurrency limit, default is 1000
+	MaxConcurrencyLimit uint32 `json:"max_concurrency_limit,omitempty"`
+	// ConcurrencyUpdateInterval is the interval the sample rtt and the limit are updated, default is 100ms
+	ConcurrencyUpdateInterval api.DurationConfig `json:"concurrency_update_interval,omitempty"`
+	// MinRTTCalcInterval is the interval the min rtt is recalculated, default is 60s
+	MinRTTCalcInterval api.DurationConfig `json:"min_rtt_calc_interval,omitempty"`
+	// MinRTTCalcRequestCount is the number of the requests sampled to calculate the min rtt, default is 50
+	MinRTTCalcRequestCount uint32 `json:"min_rtt_calc_request_count,omitempty"`
+	// MinRTTCalcJitter is the max percentage of the min rtt interval added randomly, default is 15
+	MinRTTCalcJitter *float64 `json:"min_rtt_calc_jitter,omitempty"`
+	// MinRTTBuffer is the percentage of the min rtt added as the tolerance of the latency, default is 25
+	MinRTTBuffer *float64 `json:"min_rtt_buffer,omitempty"`
+	// MinConcurrency is the limit used when the min rtt is calculated, default is 3
+	MinConcurrency uint32 `json:"min_concurrency,omitempty"`
+	// StatusOnBlocked is the status code replied if the limit is exceeded, default is 503
+	StatusOnBlocked int `json:"status_on_blocked,omitempty"`
+	// StatPrefix is the name of the metrics, default is adaptive_concurrency
+	StatPrefix string `json:"stat_prefix,omitempty"`
+}
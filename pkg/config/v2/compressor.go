@@ -0,0 +1,48 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package v2
+
+// Compressor encodings
+const (
+	EncodingGzip   = "gzip"
+	EncodingBrotli = "br"
+)
+
+// CompressorConfig is the config of the compressor stream filter
+type CompressorConfig struct {
+	// Encodings are the encodings supported in the order of preference, default is gzip.
+	// The encoding with the highest quality in the accept-encoding of the request is chosen
+	Encodings []string `json:"encodings,omitempty"`
+	// GzipLevel is the compression level of gzip, from 1 to 9, default is 6
+	GzipLevel int `json:"gzip_level,omitempty"`
+	// BrotliQuality is the compression quality of brotli, from 0 to 11, default is 6
+	BrotliQuality int `json:"brotli_quality,omitempty"`
+	// MinContentLength is the min size of the response compressed, default is 30
+	MinContentLength int `json:"min_content_length,omitempty"`
+	// ContentTypes are the content types of the response compressed, a default list of text types is used if it is empty
+	ContentTypes []string `json:"content_types,omitempty"`
+	// DisableOnETag skips the response with etag, or the strong etag is converted to a weak one
+	DisableOnETag bool `json:"disable_on_etag,omitempty"`
+	// RemoveAcceptEncoding removes the accept-encoding of the request, so the upstream will not compress the response
+	RemoveAcceptEncoding bool `json:"remove_accept_encoding,omitempty"`
+	// DecompressRequest decompresses the gzip request body for the upstreams can not
+	DecompressRequest bool `json:"decompress_request,omitempty"`
+	// MaxDecompressedBytes limits the size of the request body decompressed, default is 4MB
+	MaxDecompressedBytes int `json:"max_decompressed_bytes,omitempty"`
+	// StatPrefix is the name of the metrics, default is compressor
+	StatPrefix string `json:"stat_prefix,omitempty"`
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/c2h5oh/datasize"
 	xdsboot "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v2"
 	"github.com/gogo/protobuf/jsonpb"
+	"mosn.io/api"
 )
 
 // MOSNConfig make up mosn to start the mosn project
@@ -41,6 +42,18 @@ type MOSNConfig struct {
 	Debug               PProfConfig     `json:"pprof,omitempty"`
 	Pid                 string          `json:"pid,omitempty"`    // pid file
 	Plugin              PluginConfig    `json:"plugin,omitempty"` // plugin config
+	// FileSource watches the resource files, and applies the changes dynamically
+	FileSource *FileSourceConfig `json:"file_source,omitempty"`
+	// OverloadManager sheds the load when the process is overloaded
+	OverloadManager *OverloadManagerConfig `json:"overload_manager,omitempty"`
+}
+
+// FileSourceConfig is a dynamic config source based on files.
+// The clusters in the cluster manager's ClusterConfigPath and the virtual hosts in the routers' RouterConfigPath are watched,
+// the listeners file contains a json array of listeners, an empty path means the listeners are not watched
+type FileSourceConfig struct {
+	ListenersFile string             `json:"listeners_file,omitempty"`
+	WatchInterval api.DurationConfig `json:"watch_interval,omitempty"` // default is 1s
 }
 
 // PProfConfig is used to start a pprof server for debug
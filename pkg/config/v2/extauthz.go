@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package v2
+
+import (
+	"mosn.io/api"
+)
+
+// ExtAuthz protocols
+const (
+	ExtAuthzHTTP = "http"
+	ExtAuthzGRPC = "grpc"
+)
+
+// ExtAuthzConfig is the config of the external authorization stream filter
+type ExtAuthzConfig struct {
+	// Cluster is the upstream cluster of the authorization service
+	Cluster string `json:"cluster,omitempty"`
+	// Protocol is http (default) or grpc. The http check request is sent with the protocol of the cluster,
+	// and the grpc check request is the envoy.service.auth.v2.Authorization/Check over http2
+	Protocol string `json:"protocol,omitempty"`
+	// PathPrefix is prepended to the path of the http check request
+	PathPrefix string `json:"path_prefix,omitempty"`
+	// Timeout of the check request, default is 200ms
+	Timeout api.DurationConfig `json:"timeout,omitempty"`
+	// FailureModeAllow allows the request if the authorization service is failed, or replies StatusOnError
+	FailureModeAllow bool `json:"failure_mode_allow,omitempty"`
+	// StatusOnError is the status code replied if the authorization service is failed, default is 403
+	StatusOnError int `json:"status_on_error,omitempty"`
+	// AllowedHeaders are the request headers sent in the check request.
+	// Method, path, host and authorization are always sent with http, all headers are sent with grpc if it is empty
+	AllowedHeaders []string `json:"allowed_headers,omitempty"`
+	// AllowedUpstreamHeaders are the headers of the http ok response set to the request.
+	// All the headers from the grpc ok response are set
+	AllowedUpstreamHeaders []string `json:"allowed_upstream_headers,omitempty"`
+	// AllowedClientHeaders are the headers of the http denied response replied to the client.
+	// All the headers from the grpc denied response are replied
+	AllowedClientHeaders []string `json:"allowed_client_headers,omitempty"`
+	// WithRequestBody sends the request body in the check request
+	WithRequestBody *ExtAuthzBufferSettings `json:"with_request_body,omitempty"`
+	// IncludePeerCertificate sends the pem of the downstream peer certificate with grpc
+	IncludePeerCertificate bool `json:"include_peer_certificate,omitempty"`
+	// StatPrefix is the name of the metrics, default is ext_authz
+	StatPrefix string `json:"stat_prefix,omitempty"`
+}
+
+// ExtAuthzBufferSettings limits the request body sent
+type ExtAuthzBufferSettings struct {
+	// MaxRequestBytes is the max size of the body sent, the body is truncated if it exceeds the limit
+	MaxRequestBytes uint32 `json:"max_request_bytes,omitempty"`
+}
+
+// ExtAuthzPerRoute is the config of the filter in the PerFilterConfig of routers
+type ExtAuthzPerRoute struct {
+	// Disabled skips the check of the requests
+	Disabled bool `json:"disabled,omitempty"`
+	// ContextExtensions are sent in the check request, as the context extensions with grpc,
+	// or the headers prefixed with x-ext-authz-context- with http
+	ContextExtensions map[string]string `json:"context_extensions,omitempty"`
+}
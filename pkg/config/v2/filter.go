@@ -40,6 +40,8 @@ type FaultInjectConfig struct {
 type DelayInjectConfig struct {
 	Percent             uint32             `json:"percentage,omitempty"`
 	DelayDurationConfig api.DurationConfig `json:"fixed_delay,omitempty"`
+	// HeaderDelay makes the delay controlled by the request headers
+	HeaderDelay bool `json:"header_delay,omitempty"`
 }
 
 // Network Filter's Type
@@ -51,13 +53,21 @@ const (
 	RPC_PROXY                   = "rpc_proxy"
 	X_PROXY                     = "x_proxy"
 	Transcoder                  = "transcoder"
+	RBAC_NETWORK_FILTER         = "rbac"
 )
 
 // Stream Filter's Type
 const (
-	MIXER        = "mixer"
-	FaultStream  = "fault"
-	PayloadLimit = "payload_limit"
+	MIXER               = "mixer"
+	FaultStream         = "fault"
+	PayloadLimit        = "payload_limit"
+	RBACFilter          = "rbac"
+	JWTAuthn            = "jwt_authn"
+	ExtAuthz            = "ext_authz"
+	Compressor          = "compressor"
+	Cors                = "cors"
+	AdaptiveConcurrency = "adaptive_concurrency"
+	HeaderToMetadata    = "header_to_metadata"
 )
 
 // HealthCheckFilter
@@ -111,10 +121,17 @@ func (f *FaultInject) UnmarshalJSON(b []byte) error {
 
 // StreamFaultInject
 type StreamFaultInject struct {
-	Delay           *DelayInject    `json:"delay,omitempty"`
-	Abort           *AbortInject    `json:"abort,omitempty"`
-	UpstreamCluster string          `json:"upstream_cluster,omitempty"`
-	Headers         []HeaderMatcher `json:"headers,omitempty"`
+	Delay             *DelayInject     `json:"delay,omitempty"`
+	Abort             *AbortInject     `json:"abort,omitempty"`
+	Reset             *ResetInject     `json:"reset,omitempty"`
+	ResponseDelay     *DelayInject     `json:"response_delay,omitempty"`
+	ResponseBody      *BodyInject      `json:"response_body,omitempty"`
+	ResponseRateLimit *RateLimitInject `json:"response_rate_limit,omitempty"`
+	UpstreamCluster   string           `json:"upstream_cluster,omitempty"`
+	Headers           []HeaderMatcher  `json:"headers,omitempty"`
+	// MaxActiveFaults limits the streams injected faults at the same time, 0 means no limit
+	MaxActiveFaults uint32 `json:"max_active_faults,omitempty"`
+	StatPrefix      string `json:"stat_prefix,omitempty"`
 }
 
 type DelayInject struct {
@@ -138,6 +155,27 @@ func (d *DelayInject) UnmarshalJSON(b []byte) error {
 type AbortInject struct {
 	Status  int    `json:"status,omitempty"`
 	Percent uint32 `json:"percentage,omitempty"`
+	// HeaderAbort makes the abort controlled by the request headers
+	HeaderAbort bool `json:"header_abort,omitempty"`
+}
+
+// ResetInject resets the downstream stream instead of forwarding the request
+type ResetInject struct {
+	Percent uint32 `json:"percentage,omitempty"`
+}
+
+// BodyInject replaces the response body received from the upstream
+type BodyInject struct {
+	Body    string `json:"body,omitempty"`
+	Percent uint32 `json:"percentage,omitempty"`
+}
+
+// RateLimitInject limits the bandwidth of the response body sent to the downstream
+type RateLimitInject struct {
+	KiBPerSecond uint64 `json:"kib_per_second,omitempty"`
+	Percent      uint32 `json:"percentage,omitempty"`
+	// HeaderLimit makes the limit controlled by the request headers
+	HeaderLimit bool `json:"header_limit,omitempty"`
 }
 
 type Mixer struct {
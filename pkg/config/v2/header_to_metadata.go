@@ -0,0 +1,58 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package v2
+
+// HeaderToMetadataConfig is the config of the header_to_metadata stream filter, which runs before the route matching.
+// The rules are applied first, then the headers are renamed, added and removed, and the path is rewritten at last
+type HeaderToMetadataConfig struct {
+	Rules                  []HeaderToMetadataRule `json:"rules,omitempty"`
+	RequestHeadersToRename []HeaderRename         `json:"request_headers_to_rename,omitempty"`
+	RequestHeadersToAdd    []*HeaderValueOption   `json:"request_headers_to_add,omitempty"`
+	RequestHeadersToRemove []string               `json:"request_headers_to_remove,omitempty"`
+	PathRewrite            *RegexRewrite          `json:"path_rewrite,omitempty"`
+}
+
+// HeaderToMetadataRule maps a request header or query parameter to the dynamic metadata and a variable.
+// The header fields of the xprotocol requests are read as headers
+type HeaderToMetadataRule struct {
+	// one of the Header and the QueryParameter is the source of the value
+	Header         string `json:"header,omitempty"`
+	QueryParameter string `json:"query_parameter,omitempty"`
+	// ValueRewrite rewrites the value, the source is treated as absent if the value does not match
+	ValueRewrite *RegexRewrite `json:"value_rewrite,omitempty"`
+	// DefaultValue is used if the source is absent, the rule is skipped if there is no default value
+	DefaultValue string `json:"default_value,omitempty"`
+	// MetadataKey is the key of the dynamic metadata, which is matched by the subset load balancer
+	MetadataKey string `json:"metadata_key,omitempty"`
+	// Variable is the name of an indexed variable with a setter
+	Variable string `json:"variable,omitempty"`
+	// Remove removes the source header once it is mapped
+	Remove bool `json:"remove,omitempty"`
+}
+
+// HeaderRename renames the header From to To, the existing To is overwritten
+type HeaderRename struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// RegexRewrite replaces the matches of the Pattern with the Substitution,
+// which can refer to the capture groups like $1 or ${name}
+type RegexRewrite struct {
+	Pattern      string `json:"pattern,omitempty"`
+	Substitution string `json:"substitution,omitempty"`
+}
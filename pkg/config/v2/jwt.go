@@ -0,0 +1,98 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package v2
+
+import (
+	"mosn.io/api"
+)
+
+// JWTAuthnConfig is the config of the jwt authentication stream filter
+type JWTAuthnConfig struct {
+	// Providers are the issuers of the tokens, keyed by the provider name
+	Providers map[string]*JWTProvider `json:"providers,omitempty"`
+	// Rules select the providers that verify the request, the first rule matched is used.
+	// If no rules are configured, all the requests are verified by any of the providers
+	Rules []*JWTRule `json:"rules,omitempty"`
+}
+
+// JWTProvider describes how the tokens are extracted and verified
+type JWTProvider struct {
+	// Issuer is matched with the iss claim if it is not empty
+	Issuer string `json:"issuer,omitempty"`
+	// Audiences is matched with the aud claim if it is not empty, any of them matched is ok
+	Audiences []string `json:"audiences,omitempty"`
+	// LocalJWKS or RemoteJWKS provides the keys to verify the tokens
+	LocalJWKS  *LocalJWKS  `json:"local_jwks,omitempty"`
+	RemoteJWKS *RemoteJWKS `json:"remote_jwks,omitempty"`
+	// The locations of the token. If none of them is configured, the token is extracted from
+	// the authorization header with Bearer prefix and the access_token query parameter
+	FromHeaders []*JWTHeader `json:"from_headers,omitempty"`
+	FromParams  []string     `json:"from_params,omitempty"`
+	FromCookies []string     `json:"from_cookies,omitempty"`
+	// Forward keeps the token in the request, the token header is removed by default
+	Forward bool `json:"forward,omitempty"`
+	// ForwardPayloadHeader is the header to forward the base64url encoded payload
+	ForwardPayloadHeader string `json:"forward_payload_header,omitempty"`
+	// ClaimToHeaders sets the claims to the request headers
+	ClaimToHeaders []*JWTClaimToHeader `json:"claim_to_headers,omitempty"`
+	// ClockSkew is the tolerance of exp and nbf claims, default is 60s
+	ClockSkew *api.DurationConfig `json:"clock_skew,omitempty"`
+}
+
+// LocalJWKS loads the keys from a file or an inline string
+type LocalJWKS struct {
+	Filename     string `json:"filename,omitempty"`
+	InlineString string `json:"inline_string,omitempty"`
+}
+
+// RemoteJWKS fetches the keys with http get, the keys are cached
+type RemoteJWKS struct {
+	URI string `json:"uri,omitempty"`
+	// Cluster is the upstream cluster that the keys are fetched from, if it is not empty,
+	// the host of the uri is replaced with the host of the cluster
+	Cluster string `json:"cluster,omitempty"`
+	// Timeout of fetching, default is 1s
+	Timeout api.DurationConfig `json:"timeout,omitempty"`
+	// CacheDuration is the lifetime of the keys fetched, default is 5m
+	CacheDuration api.DurationConfig `json:"cache_duration,omitempty"`
+}
+
+// JWTHeader is a header contains the token
+type JWTHeader struct {
+	Name string `json:"name,omitempty"`
+	// ValuePrefix is removed from the header value, like "Bearer "
+	ValuePrefix string `json:"value_prefix,omitempty"`
+}
+
+// JWTClaimToHeader sets the claim to the header, the nested claim is separated with dot, like "a.b"
+type JWTClaimToHeader struct {
+	HeaderName string `json:"header_name,omitempty"`
+	ClaimName  string `json:"claim_name,omitempty"`
+}
+
+// JWTRule matches the requests with the path and the headers like the router match
+type JWTRule struct {
+	Prefix  string          `json:"prefix,omitempty"`
+	Path    string          `json:"path,omitempty"`
+	Regex   string          `json:"regex,omitempty"`
+	Headers []HeaderMatcher `json:"headers,omitempty"`
+	// Requires lists the providers, the request is allowed if any of them verified the token.
+	// No providers means the request is not verified
+	Requires []string `json:"requires,omitempty"`
+	// AllowMissing allows the request without a token, but the token present must be valid
+	AllowMissing bool `json:"allow_missing,omitempty"`
+}
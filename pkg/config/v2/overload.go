@@ -0,0 +1,52 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package v2
+
+import "mosn.io/api"
+
+// OverloadManagerConfig configures the overload manager.
+// The resource monitors are refreshed periodically, an action is active if any of its triggers is active
+type OverloadManagerConfig struct {
+	RefreshInterval  api.DurationConfig      `json:"refresh_interval,omitempty"` // default is 1s
+	ResourceMonitors []ResourceMonitorConfig `json:"resource_monitors,omitempty"`
+	Actions          []OverloadActionConfig  `json:"actions,omitempty"`
+	// ShrunkBufferLimitBytes is the buffer limit of the streams when the shrink_buffer_limits action is active, default is 4KB
+	ShrunkBufferLimitBytes uint32 `json:"shrunk_buffer_limit_bytes,omitempty"`
+}
+
+// ResourceMonitorConfig watches a resource of the process: heap_size, goroutines or downstream_connections.
+// The pressure of the resource is the ratio of the current usage to the max
+type ResourceMonitorConfig struct {
+	Name string `json:"name,omitempty"`
+	Max  uint64 `json:"max,omitempty"`
+}
+
+// OverloadActionConfig is an action of the overload manager:
+// stop_accepting_connections, disable_http_keepalive, shrink_buffer_limits or reject_streams
+type OverloadActionConfig struct {
+	Name     string                  `json:"name,omitempty"`
+	Triggers []OverloadTriggerConfig `json:"triggers,omitempty"`
+}
+
+// OverloadTriggerConfig activates the action when the pressure of the resource reaches the threshold,
+// and deactivates it when the pressure drops below the recovery.
+// The thresholds are in (0, 1], the recovery is 90% of the threshold by default
+type OverloadTriggerConfig struct {
+	Resource  string  `json:"resource,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+	Recovery  float64 `json:"recovery,omitempty"`
+}
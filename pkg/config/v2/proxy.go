@@ -45,6 +45,15 @@ type Proxy struct {
 	ExtendConfig       map[string]interface{} `json:"extend_config,omitempty"`
 }
 
+// ProxyGeneralExtendConfig is the extend config used by all the protocols
+type ProxyGeneralExtendConfig struct {
+	// Http1UseStream forwards the HTTP/1 request and response bodies in data frames instead of buffering them
+	Http1UseStream bool `json:"http1_use_stream,omitempty"`
+	// EnableH2C serves cleartext HTTP/2 on the HTTP/1 connections,
+	// both the 'Upgrade: h2c' requests and the connections start with the HTTP/2 preface are accepted
+	EnableH2C bool `json:"enable_h2c,omitempty"`
+}
+
 // XProxyExtendConfig
 type XProxyExtendConfig struct {
 	SubProtocol string `json:"sub_protocol,omitempty"`
@@ -0,0 +1,91 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+// RBAC is the config of the rbac stream filter and network filter.
+// The network filter only matches the connection, the request properties like headers never match in it
+type RBAC struct {
+	// Rules are enforced, the denied requests and connections are rejected. No rules means allow all
+	Rules *RBACRules `json:"rules,omitempty"`
+	// ShadowRules are evaluated and counted, but never enforced
+	ShadowRules *RBACRules `json:"shadow_rules,omitempty"`
+	// StatPrefix is the name of the metrics, default is rbac
+	StatPrefix string `json:"stat_prefix,omitempty"`
+}
+
+// RBAC actions
+const (
+	RBACAllow = "ALLOW"
+	RBACDeny  = "DENY"
+)
+
+// RBACRules is a set of policies.
+// If the action is ALLOW (default), the request is allowed only if a policy matched,
+// if the action is DENY, the request is denied if a policy matched
+type RBACRules struct {
+	Action   string                 `json:"action,omitempty"`
+	Policies map[string]*RBACPolicy `json:"policies,omitempty"`
+}
+
+// RBACPolicy matches if any of the permissions and any of the principals matched
+type RBACPolicy struct {
+	Permissions []*RBACPermission `json:"permissions,omitempty"`
+	Principals  []*RBACPrincipal  `json:"principals,omitempty"`
+}
+
+// RBACPermission matches the action of the request, only one of the fields is used
+type RBACPermission struct {
+	Any             bool              `json:"any,omitempty"`
+	AndRules        []*RBACPermission `json:"and_rules,omitempty"`
+	OrRules         []*RBACPermission `json:"or_rules,omitempty"`
+	NotRule         *RBACPermission   `json:"not_rule,omitempty"`
+	Header          *HeaderMatcher    `json:"header,omitempty"`
+	Path            *StringMatcher    `json:"path,omitempty"`
+	Method          *StringMatcher    `json:"method,omitempty"`
+	Service         *StringMatcher    `json:"service,omitempty"`
+	RPCMethod       *StringMatcher    `json:"rpc_method,omitempty"`
+	DestinationIP   *CidrRange        `json:"destination_ip,omitempty"`
+	DestinationPort uint32            `json:"destination_port,omitempty"`
+	ServerName      *StringMatcher    `json:"requested_server_name,omitempty"`
+}
+
+// RBACPrincipal matches the downstream of the request, only one of the fields is used
+type RBACPrincipal struct {
+	Any      bool             `json:"any,omitempty"`
+	AndIDs   []*RBACPrincipal `json:"and_ids,omitempty"`
+	OrIDs    []*RBACPrincipal `json:"or_ids,omitempty"`
+	NotID    *RBACPrincipal   `json:"not_id,omitempty"`
+	SourceIP *CidrRange       `json:"source_ip,omitempty"`
+	Header   *HeaderMatcher   `json:"header,omitempty"`
+	// Authenticated matches the mTLS peer certificate, the principal name is matched with
+	// the URI SANs, the DNS SANs and the subject in order. An empty principal name matches any peer certificate
+	Authenticated *RBACAuthenticated `json:"authenticated,omitempty"`
+}
+
+// RBACAuthenticated matches the authenticated downstream
+type RBACAuthenticated struct {
+	PrincipalName *StringMatcher `json:"principal_name,omitempty"`
+}
+
+// StringMatcher matches a string, only one of the fields is used
+type StringMatcher struct {
+	Exact  string `json:"exact,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+	Suffix string `json:"suffix,omitempty"`
+	Regex  string `json:"regex,omitempty"`
+}
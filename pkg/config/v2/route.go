@@ -40,6 +40,7 @@ type RouterConfigurationConfig struct {
 }
 
 type RouterConfig struct {
+	Name            string                 `json:"name,omitempty"`
 	Match           RouterMatch            `json:"match,omitempty"`
 	Route           RouteAction            `json:"route,omitempty"`
 	DirectResponse  *DirectResponseAction  `json:"direct_response,omitempty"`
@@ -61,6 +62,13 @@ type RouterActionConfig struct {
 	RequestHeadersToAdd     []*HeaderValueOption `json:"request_headers_to_add,omitempty"`
 	ResponseHeadersToAdd    []*HeaderValueOption `json:"response_headers_to_add,omitempty"`
 	ResponseHeadersToRemove []string             `json:"response_headers_to_remove,omitempty"`
+	// Http1UseStream forwards the HTTP/1 bodies of the upstream requests and responses in data frames.
+	// The downstream connection reads the request bodies before the route is matched, so the request bodies
+	// from the HTTP/1 clients are streamed only if http1_use_stream is set in the extend config of the proxy
+	Http1UseStream bool        `json:"http1_use_stream,omitempty"`
+	Cors           *CorsPolicy `json:"cors,omitempty"`
+	// Priority is the routing priority, default or high, the requests are limited by the circuit breakers of the priority
+	Priority string `json:"priority,omitempty"`
 }
 
 type ClusterWeightConfig struct {
@@ -265,6 +273,22 @@ type VirtualHost struct {
 	RequestHeadersToAdd     []*HeaderValueOption `json:"request_headers_to_add,omitempty"`
 	ResponseHeadersToAdd    []*HeaderValueOption `json:"response_headers_to_add,omitempty"`
 	ResponseHeadersToRemove []string             `json:"response_headers_to_remove,omitempty"`
+	Cors                    *CorsPolicy          `json:"cors,omitempty"`
+}
+
+// CorsPolicy is the cross-origin resource sharing policy of the virtual host,
+// the policy of the route takes precedence over the virtual host's
+type CorsPolicy struct {
+	// AllowOrigins are the origins allowed in exact match, "*" allows any origin
+	AllowOrigins     []string            `json:"allow_origins,omitempty"`
+	AllowOriginRegex []string            `json:"allow_origin_regex,omitempty"`
+	AllowMethods     []string            `json:"allow_methods,omitempty"`
+	AllowHeaders     []string            `json:"allow_headers,omitempty"`
+	ExposeHeaders    []string            `json:"expose_headers,omitempty"`
+	MaxAge           *api.DurationConfig `json:"max_age,omitempty"`
+	AllowCredentials bool                `json:"allow_credentials,omitempty"`
+	// Disabled disables the policy of the virtual host on the route
+	Disabled bool `json:"disabled,omitempty"`
 }
 
 // RouterMatch represents the route matching parameters
@@ -273,6 +297,42 @@ type RouterMatch struct {
 	Path    string          `json:"path,omitempty"`    // Match request's Path with Exact Comparing
 	Regex   string          `json:"regex,omitempty"`   // Match request's Path with Regex Comparing
 	Headers []HeaderMatcher `json:"headers,omitempty"` // Match request's Headers
+	// CaseSensitive indicates the prefix or path matching is case sensitive or not.
+	// if it is not set, prefix matching is case sensitive and path matching is case insensitive.
+	CaseSensitive   *bool                     `json:"case_sensitive,omitempty"`
+	QueryParameters []HeaderMatcher           `json:"query_parameters,omitempty"` // Match request's query parameters
+	Cookies         []HeaderMatcher           `json:"cookies,omitempty"`          // Match request's cookies
+	Methods         []string                  `json:"methods,omitempty"`          // Match request's method, any of the methods matched is ok
+	RuntimeFraction *RuntimeFractionalPercent `json:"runtime_fraction,omitempty"` // Match request in a fractional percent
+}
+
+// RuntimeFractionalPercent makes a route matches only a percentage of requests, the route is ignored
+// for other requests even if all of the other match conditions are matched.
+type RuntimeFractionalPercent struct {
+	Numerator   uint32                `json:"numerator,omitempty"`
+	Denominator FractionalDenominator `json:"denominator,omitempty"`
+}
+
+// FractionalDenominator is the denominator of RuntimeFractionalPercent
+type FractionalDenominator string
+
+// Group of fractional denominator
+const (
+	HUNDRED      FractionalDenominator = "HUNDRED" // default
+	TEN_THOUSAND FractionalDenominator = "TEN_THOUSAND"
+	MILLION      FractionalDenominator = "MILLION"
+)
+
+// Value returns the number of the denominator
+func (d FractionalDenominator) Value() uint32 {
+	switch d {
+	case TEN_THOUSAND:
+		return 10000
+	case MILLION:
+		return 1000000
+	default:
+		return 100
+	}
 }
 
 // DirectResponseAction represents the direct response parameters
@@ -290,10 +350,23 @@ type WeightedCluster struct {
 }
 
 // HeaderMatcher specifies a set of headers that the route should match on.
+// it is also used to match query parameters and cookies.
 type HeaderMatcher struct {
 	Name  string `json:"name,omitempty"`
 	Value string `json:"value,omitempty"`
 	Regex bool   `json:"regex,omitempty"`
+	// RangeMatch matches if the value is an integer in [start, end)
+	RangeMatch *Int64Range `json:"range_match,omitempty"`
+	// PresentMatch matches if the header is present (true) or absent (false), the value is ignored
+	PresentMatch *bool `json:"present_match,omitempty"`
+	// InvertMatch inverts the match result
+	InvertMatch bool `json:"invert_match,omitempty"`
+}
+
+// Int64Range specifies a range [start, end)
+type Int64Range struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
 }
 
 // TCP Proxy Route
@@ -85,6 +85,55 @@ func (l Listener) MarshalJSON() (b []byte, err error) {
 type AccessLog struct {
 	Path   string `json:"log_path,omitempty"`
 	Format string `json:"log_format,omitempty"`
+	// FormatType is the record encoding: text (default), json or logfmt.
+	// json and logfmt records use Fields, or the variables of Format if no fields are configured
+	FormatType AccessLogFormatType `json:"log_format_type,omitempty"`
+	Fields     []AccessLogField    `json:"log_fields,omitempty"`
+	Filter     *AccessLogFilter    `json:"filter,omitempty"`
+	// Outputs are written with the same record as Path, an output can be a file path
+	// or a syslog address such as syslog+udp://127.0.0.1:514
+	Outputs []string        `json:"outputs,omitempty"`
+	Sinks   []AccessLogSink `json:"sinks,omitempty"`
+}
+
+// AccessLogFormatType is the record encoding of an access log
+type AccessLogFormatType string
+
+// Group of access log format types
+const (
+	AccessLogFormatText   AccessLogFormatType = "text"
+	AccessLogFormatJSON   AccessLogFormatType = "json"
+	AccessLogFormatLogfmt AccessLogFormatType = "logfmt"
+)
+
+// AccessLogField is a named field of a structured access log record.
+// The value comes from one of Variable, RequestHeader or ResponseHeader
+type AccessLogField struct {
+	Name           string `json:"name,omitempty"`
+	Variable       string `json:"variable,omitempty"`
+	RequestHeader  string `json:"request_header,omitempty"`
+	ResponseHeader string `json:"response_header,omitempty"`
+	// Type is the value type in json records: string (default), int, float or bool.
+	// A value that cannot be parsed as the type is written as a string
+	Type string `json:"type,omitempty"`
+}
+
+// AccessLogFilter decides which requests are logged, all the configured conditions must be matched
+type AccessLogFilter struct {
+	// StatusCodes matches the response code in any of the ranges
+	StatusCodes []Int64Range `json:"status_codes,omitempty"`
+	// MinDuration matches requests that take at least the duration
+	MinDuration *api.DurationConfig `json:"min_duration,omitempty"`
+	// ResponseFlags matches requests with any of the flags, such as NoHealthyUpstream
+	ResponseFlags []string `json:"response_flags,omitempty"`
+	// Sampling logs a fractional percent of the requests
+	Sampling *RuntimeFractionalPercent `json:"sampling,omitempty"`
+}
+
+// AccessLogSink is an access log output registered by type, such as grpc
+type AccessLogSink struct {
+	Type   string                 `json:"type,omitempty"`
+	Config map[string]interface{} `json:"config,omitempty"`
 }
 
 // FilterChain wraps a set of match criteria, an option TLS context,
@@ -47,6 +47,11 @@ type HostConfig struct {
 	Weight         uint32          `json:"weight,omitempty"`
 	MetaDataConfig *MetadataConfig `json:"metadata,omitempty"`
 	TLSDisable     bool            `json:"tls_disable,omitempty"`
+	// Priority is the host priority, 0 is the highest priority.
+	// the hosts with lower priority are used only if the higher priority hosts are not healthy enough.
+	Priority uint32 `json:"priority,omitempty"`
+	// Zone is the locality zone of the host, the hosts in the same zone as MOSN are preferred.
+	Zone string `json:"zone,omitempty"`
 }
 
 // ClusterType
@@ -58,6 +63,20 @@ const (
 	SIMPLE_CLUSTER  ClusterType = "SIMPLE"
 	DYNAMIC_CLUSTER ClusterType = "DYNAMIC"
 	EDS_CLUSTER     ClusterType = "EDS"
+	// the hosts of dns clusters are resolved periodically
+	STRICT_DNS_CLUSTER  ClusterType = "STRICT_DNS"
+	LOGICAL_DNS_CLUSTER ClusterType = "LOGICAL_DNS"
+)
+
+// DnsLookupFamily is the ip family used by dns clusters
+type DnsLookupFamily string
+
+// Group of dns lookup family
+const (
+	// AUTO resolves ipv6 addresses first, and falls back to ipv4 if no ipv6 address is found
+	DNS_AUTO    DnsLookupFamily = "AUTO"
+	DNS_V4_ONLY DnsLookupFamily = "V4_ONLY"
+	DNS_V6_ONLY DnsLookupFamily = "V6_ONLY"
 )
 
 // LbType
@@ -85,6 +104,39 @@ type Cluster struct {
 	TLS                  TLSConfig           `json:"tls_context,omitempty"`
 	Hosts                []Host              `json:"hosts,omitempty"`
 	ConnectTimeout       *api.DurationConfig `json:"connect_timeout,omitempty"`
+	// dns configs, only used by STRICT_DNS and LOGICAL_DNS clusters
+	DnsRefreshRate  *api.DurationConfig `json:"dns_refresh_rate,omitempty"`
+	RespectDnsTTL   bool                `json:"respect_dns_ttl,omitempty"`
+	DnsLookupFamily DnsLookupFamily     `json:"dns_lookup_family,omitempty"`
+	DnsResolvers    []string            `json:"dns_resolvers,omitempty"` // ip:port of dns servers, default is the nameservers in /etc/resolv.conf
+	// OverprovisioningFactor is a percentage used when load is spilled over between priorities and zones,
+	// a priority (or zone) takes all of its traffic while healthy hosts * factor / 100 >= total hosts.
+	// default is 140
+	OverprovisioningFactor uint32 `json:"overprovisioning_factor,omitempty"`
+	// ConnPool configures the upstream connection pools of the cluster
+	ConnPool ConnPoolConfig `json:"conn_pool,omitempty"`
+	// UpstreamProtocol overrides the upstream protocol of the HTTP requests routed to the cluster, Http1 or Http2.
+	// Http2 is cleartext (h2c with prior knowledge) if TLS is disabled
+	UpstreamProtocol string `json:"upstream_protocol,omitempty"`
+}
+
+// ConnPoolConfig is the connection pool config of a cluster.
+// The max requests of a connection is configured by MaxRequestPerConn in cluster,
+// a connection reaches the max requests is closed after the active requests finished
+type ConnPoolConfig struct {
+	// MaxConnectionsPerHost is the max connections to a host of multiplexing protocols, default is 1
+	MaxConnectionsPerHost uint32 `json:"max_connections_per_host,omitempty"`
+	// MaxStreamsPerConnection is the max active streams of a multiplexing connection, 0 means no limit
+	MaxStreamsPerConnection uint32 `json:"max_streams_per_connection,omitempty"`
+	// MaxConnectionAge is the max age of a connection, an aged connection is drained and reconnected.
+	// 0 means no limit
+	MaxConnectionAge *api.DurationConfig `json:"max_connection_age,omitempty"`
+	// IdleTimeout closes the connection without active requests for the duration, 0 means no timeout
+	IdleTimeout *api.DurationConfig `json:"idle_timeout,omitempty"`
+	// MaxIdleConnectionsPerHost is the max idle connections kept to a host, 0 means no limit
+	MaxIdleConnectionsPerHost uint32 `json:"max_idle_connections_per_host,omitempty"`
+	// UnlimitedRequestsPerConnection ignores the MaxRequestPerConn in cluster, the connections are not rotated by the requests
+	UnlimitedRequestsPerConnection bool `json:"unlimited_requests_per_connection,omitempty"`
 }
 
 // HealthCheck is a configuration of health check
@@ -147,11 +199,31 @@ func (cb *CircuitBreakers) UnmarshalJSON(b []byte) (err error) {
 	return json.Unmarshal(b, &cb.Thresholds)
 }
 
+// Routing priorities, the circuit breakers thresholds are configured by priority
+const (
+	RoutingPriorityDefault = "default"
+	RoutingPriorityHigh    = "high"
+)
+
 type Thresholds struct {
+	// Priority is the routing priority the thresholds apply to, default or high, empty means default
+	Priority           string `json:"priority,omitempty"`
 	MaxConnections     uint32 `json:"max_connections,omitempty"`
 	MaxPendingRequests uint32 `json:"max_pending_requests,omitempty"`
 	MaxRequests        uint32 `json:"max_requests,omitempty"`
 	MaxRetries         uint32 `json:"max_retries,omitempty"`
+	// MaxConnectionPools limits the connection pools of the hosts in the cluster
+	MaxConnectionPools uint32 `json:"max_connection_pools,omitempty"`
+	// RetryBudget limits the retries by the active requests, the MaxRetries is ignored if it is set
+	RetryBudget *RetryBudget `json:"retry_budget,omitempty"`
+}
+
+// RetryBudget limits the concurrent retries to a percentage of the active and pending requests
+type RetryBudget struct {
+	// BudgetPercent is the percentage of the active and pending requests allowed to retry, default is 20
+	BudgetPercent *float64 `json:"budget_percent,omitempty"`
+	// MinRetryConcurrency is the concurrent retries always allowed regardless of the budget, default is 3
+	MinRetryConcurrency *uint32 `json:"min_retry_concurrency,omitempty"`
 }
 
 // ClusterSpecInfo is a configuration of subscribe
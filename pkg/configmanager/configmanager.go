@@ -274,6 +274,7 @@ func ResetServiceRegistryInfo(appInfo v2.ApplicationInfo, subServiceList []strin
 		AntShareCloud:    appInfo.AntShareCloud,
 		DataCenter:       appInfo.DataCenter,
 		AppName:          appInfo.AppName,
+		Zone:             appInfo.Zone,
 		DeployMode:       appInfo.DeployMode,
 		MasterSystem:     appInfo.MasterSystem,
 		CloudName:        appInfo.CloudName,
@@ -44,6 +44,8 @@ const (
 	MaxHostWeight               = uint32(128)
 	DefaultMaxRequestPerConn    = uint32(1024)
 	DefaultConnBufferLimitBytes = uint32(16 * 1024)
+	// DefaultPerConnBufferLimitBytes is the buffer limit of the listener connections
+	DefaultPerConnBufferLimitBytes = uint32(1 << 15)
 )
 
 // RegisterProtocolParser
@@ -96,16 +98,7 @@ func ParseClusterConfig(clusters []v2.Cluster) ([]v2.Cluster, map[string][]v2.Ho
 		if c.Name == "" {
 			log.StartLogger.Fatalf("[config] [parse cluster] name is required in cluster config")
 		}
-		if c.MaxRequestPerConn == 0 {
-			c.MaxRequestPerConn = DefaultMaxRequestPerConn
-			log.StartLogger.Infof("[config] [parse cluster] max_request_per_conn is not specified, use default value %d",
-				DefaultMaxRequestPerConn)
-		}
-		if c.ConnBufferLimitBytes == 0 {
-			c.ConnBufferLimitBytes = DefaultConnBufferLimitBytes
-			log.StartLogger.Infof("[config] [parse cluster] conn_buffer_limit_bytes is not specified, use default value %d",
-				DefaultConnBufferLimitBytes)
-		}
+		c = ParseClusterDefaultValue(c)
 		if c.LBSubSetConfig.FallBackPolicy > 2 {
 			log.StartLogger.Fatalf("[config] [parse cluster] lb subset config 's fall back policy set error. " +
 				"For 0, represent NO_FALLBACK" +
@@ -115,7 +108,6 @@ func ParseClusterConfig(clusters []v2.Cluster) ([]v2.Cluster, map[string][]v2.Ho
 		if _, ok := ProtocolsSupported[c.HealthCheck.Protocol]; !ok && c.HealthCheck.Protocol != "" {
 			log.StartLogger.Fatalf("[config] [parse cluster] unsupported health check protocol: %v", c.HealthCheck.Protocol)
 		}
-		c.Hosts = parseHostConfig(c.Hosts)
 		clusterV2Map[c.Name] = c.Hosts
 		pClusters = append(pClusters, c)
 	}
@@ -129,6 +121,22 @@ func ParseClusterConfig(clusters []v2.Cluster) ([]v2.Cluster, map[string][]v2.Ho
 	return pClusters, clusterV2Map
 }
 
+// ParseClusterDefaultValue sets the default values of a cluster config, the config is not verified
+func ParseClusterDefaultValue(c v2.Cluster) v2.Cluster {
+	if c.MaxRequestPerConn == 0 {
+		c.MaxRequestPerConn = DefaultMaxRequestPerConn
+		log.StartLogger.Infof("[config] [parse cluster] max_request_per_conn is not specified, use default value %d",
+			DefaultMaxRequestPerConn)
+	}
+	if c.ConnBufferLimitBytes == 0 {
+		c.ConnBufferLimitBytes = DefaultConnBufferLimitBytes
+		log.StartLogger.Infof("[config] [parse cluster] conn_buffer_limit_bytes is not specified, use default value %d",
+			DefaultConnBufferLimitBytes)
+	}
+	c.Hosts = parseHostConfig(c.Hosts)
+	return c
+}
+
 func parseHostConfig(hosts []v2.Host) (hs []v2.Host) {
 	for _, host := range hosts {
 		host.Weight = transHostWeight(host.Weight)
@@ -200,7 +208,7 @@ func ParseListenerConfig(lc *v2.Listener, inheritListeners []net.Listener) *v2.L
 	}
 
 	lc.Addr = addr
-	lc.PerConnBufferLimitBytes = 1 << 15
+	lc.PerConnBufferLimitBytes = DefaultPerConnBufferLimitBytes
 	lc.InheritListener = old
 	return lc
 }
@@ -0,0 +1,257 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filesource
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/configmanager"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/router"
+	"mosn.io/mosn/pkg/server"
+	"mosn.io/mosn/pkg/upstream/cluster"
+)
+
+// resources are the resources in a file or a directory, indexed by name
+type resources struct {
+	names []string
+	raw   map[string][]byte
+}
+
+func newResources() *resources {
+	return &resources{
+		raw: map[string][]byte{},
+	}
+}
+
+// parseResources parses a json array, the nameKey is the json key of the resource name.
+func parseResources(content []byte, nameKey string) (*resources, error) {
+	res := newResources()
+	if len(bytes.TrimSpace(content)) == 0 {
+		return res, nil
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(content, &items); err != nil {
+		return nil, err
+	}
+	for i, item := range items {
+		if err := res.add(item, nameKey); err != nil {
+			return nil, fmt.Errorf("resource %d is invalid: %v", i, err)
+		}
+	}
+	return res, nil
+}
+
+// add adds a json object resource, the nameKey is the json key of the resource name.
+// The raw bytes are compacted, so the format changes will not be treated as config changes
+func (res *resources) add(item []byte, nameKey string) error {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(item, &fields); err != nil {
+		return err
+	}
+	var name string
+	if raw, ok := fields[nameKey]; ok {
+		if err := json.Unmarshal(raw, &name); err != nil {
+			return fmt.Errorf("invalid %s: %v", nameKey, err)
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("no %s", nameKey)
+	}
+	if _, ok := res.raw[name]; ok {
+		return fmt.Errorf("duplicate resource %s", name)
+	}
+	buf := &bytes.Buffer{}
+	if err := json.Compact(buf, item); err != nil {
+		return err
+	}
+	res.names = append(res.names, name)
+	res.raw[name] = buf.Bytes()
+	return nil
+}
+
+// diff returns the resources that are added or updated, and the names of the resources that are removed
+func (res *resources) diff(applied map[string][]byte) (updated []string, removed []string) {
+	for _, name := range res.names {
+		if !bytes.Equal(applied[name], res.raw[name]) {
+			updated = append(updated, name)
+		}
+	}
+	for name := range applied {
+		if _, ok := res.raw[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	return updated, removed
+}
+
+var errApplyFailed = errors.New("some resources are failed to apply")
+
+// clusterApplier applies the clusters by the cluster manager
+type clusterApplier struct {
+	applied map[string][]byte
+}
+
+// newClusterApplier creates a clusterApplier, the applied clusters are loaded by the static config already
+func newClusterApplier(applied map[string][]byte) *clusterApplier {
+	return &clusterApplier{
+		applied: applied,
+	}
+}
+
+func (a *clusterApplier) apply(res *resources) error {
+	updated, removed := res.diff(a.applied)
+	// all the changed clusters should be valid before apply
+	clusters := make([]v2.Cluster, 0, len(updated))
+	for _, name := range updated {
+		c := v2.Cluster{}
+		if err := json.Unmarshal(res.raw[name], &c); err != nil {
+			return fmt.Errorf("cluster %s is invalid: %v", name, err)
+		}
+		clusters = append(clusters, configmanager.ParseClusterDefaultValue(c))
+	}
+	adapter := cluster.GetClusterMngAdapterInstance()
+	var failed bool
+	for _, c := range clusters {
+		if err := adapter.TriggerClusterAndHostsAddOrUpdate(c, c.Hosts); err != nil {
+			log.DefaultLogger.Errorf("[filesource] update cluster %s failed: %v", c.Name, err)
+			failed = true
+			continue
+		}
+		a.applied[c.Name] = res.raw[c.Name]
+		log.DefaultLogger.Infof("[filesource] update cluster %s success", c.Name)
+	}
+	for _, name := range removed {
+		if err := adapter.TriggerClusterDel(name); err != nil {
+			log.DefaultLogger.Errorf("[filesource] delete cluster %s failed: %v", name, err)
+			failed = true
+			continue
+		}
+		delete(a.applied, name)
+		log.DefaultLogger.Infof("[filesource] delete cluster %s success", name)
+	}
+	if failed {
+		return errApplyFailed
+	}
+	return nil
+}
+
+// routerApplier applies the virtual hosts of a router by the router manager
+type routerApplier struct {
+	config  v2.RouterConfigurationConfig
+	applied map[string][]byte
+}
+
+// newRouterApplier creates a routerApplier of the router config, the applied virtual hosts are loaded by the static config already
+func newRouterApplier(config v2.RouterConfigurationConfig, applied map[string][]byte) *routerApplier {
+	return &routerApplier{
+		config:  config,
+		applied: applied,
+	}
+}
+
+func (a *routerApplier) apply(res *resources) error {
+	updated, removed := res.diff(a.applied)
+	if len(updated) == 0 && len(removed) == 0 {
+		return nil
+	}
+	routersMng := router.GetRoutersMangerInstance()
+	if routersMng == nil {
+		return errors.New("router manager is nil")
+	}
+	// the router is updated as a whole
+	r := &v2.RouterConfiguration{
+		RouterConfigurationConfig: a.config,
+		VirtualHosts:              make([]*v2.VirtualHost, 0, len(res.names)),
+	}
+	for _, name := range res.names {
+		vh := &v2.VirtualHost{}
+		if err := json.Unmarshal(res.raw[name], vh); err != nil {
+			return fmt.Errorf("virtual host %s is invalid: %v", name, err)
+		}
+		r.VirtualHosts = append(r.VirtualHosts, vh)
+	}
+	if err := routersMng.AddOrUpdateRouters(r); err != nil {
+		log.DefaultLogger.Errorf("[filesource] update router %s failed: %v", r.RouterConfigName, err)
+		return errApplyFailed
+	}
+	a.applied = res.raw
+	log.DefaultLogger.Infof("[filesource] update router %s success", r.RouterConfigName)
+	return nil
+}
+
+// listenerApplier applies the listeners by the listener adapter
+type listenerApplier struct {
+	applied map[string][]byte
+}
+
+func newListenerApplier() *listenerApplier {
+	return &listenerApplier{
+		applied: map[string][]byte{},
+	}
+}
+
+func (a *listenerApplier) apply(res *resources) error {
+	adapter := server.GetListenerAdapterInstance()
+	if adapter == nil {
+		return errors.New("listener adapter is nil")
+	}
+	updated, removed := res.diff(a.applied)
+	listeners := make([]*v2.Listener, 0, len(updated))
+	for _, name := range updated {
+		l := &v2.Listener{}
+		if err := json.Unmarshal(res.raw[name], l); err != nil {
+			return fmt.Errorf("listener %s is invalid: %v", name, err)
+		}
+		addr, err := net.ResolveTCPAddr("tcp", l.AddrConfig)
+		if err != nil {
+			return fmt.Errorf("listener %s has an invalid address: %v", name, err)
+		}
+		l.Addr = addr
+		l.PerConnBufferLimitBytes = configmanager.DefaultPerConnBufferLimitBytes
+		listeners = append(listeners, l)
+	}
+	var failed bool
+	for _, l := range listeners {
+		if err := adapter.AddOrUpdateListener("", l, true, true); err != nil {
+			log.DefaultLogger.Errorf("[filesource] update listener %s failed: %v", l.Name, err)
+			failed = true
+			continue
+		}
+		a.applied[l.Name] = res.raw[l.Name]
+		log.DefaultLogger.Infof("[filesource] update listener %s success", l.Name)
+	}
+	for _, name := range removed {
+		if err := adapter.DeleteListener("", name); err != nil {
+			log.DefaultLogger.Errorf("[filesource] delete listener %s failed: %v", name, err)
+			failed = true
+			continue
+		}
+		delete(a.applied, name)
+		log.DefaultLogger.Infof("[filesource] delete listener %s success", name)
+	}
+	if failed {
+		return errApplyFailed
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package filesource is a dynamic config source that watches the resource files.
+// The clusters and routers are watched in the directories configured by ClusterConfigPath and RouterConfigPath,
+// which are loaded by the static config when mosn starts, and the listeners are watched in a file.
+// When the files change, the differences are applied by the cluster manager, router manager and listener adapter,
+// the same as the xds does, so no reload is needed.
+package filesource
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/pkg/utils"
+)
+
+const defaultWatchInterval = time.Second
+
+// Source watches the resource files of a mosn config
+type Source struct {
+	interval time.Duration
+	// the watchers are checked in order, so the clusters are applied before routers and listeners
+	watchers []watcher
+	stopChan chan struct{}
+	once     sync.Once
+}
+
+// NewSource creates a Source by the config, the FileSource of the config should not be nil
+func NewSource(config *v2.MOSNConfig) *Source {
+	s := &Source{
+		interval: config.FileSource.WatchInterval.Duration,
+		stopChan: make(chan struct{}),
+	}
+	if s.interval <= 0 {
+		s.interval = defaultWatchInterval
+	}
+	if dir := config.ClusterManager.ClusterConfigPath; dir != "" {
+		s.watchers = append(s.watchers, newDirWatcher(dir, "name", newClusterApplier(loadDir(dir, "name"))))
+	}
+	for _, server := range config.Servers {
+		for _, r := range server.Routers {
+			if r == nil || r.RouterConfigPath == "" {
+				continue
+			}
+			dir := r.RouterConfigPath
+			s.watchers = append(s.watchers, newDirWatcher(dir, "name", newRouterApplier(r.RouterConfigurationConfig, loadDir(dir, "name"))))
+		}
+	}
+	if config.FileSource.ListenersFile != "" {
+		s.watchers = append(s.watchers, newFileWatcher(config.FileSource.ListenersFile, "name", newListenerApplier()))
+	}
+	return s
+}
+
+// loadDir returns the resources in the directory that are loaded by the static config
+func loadDir(dir string, nameKey string) map[string][]byte {
+	res, err := readDir(dir, nameKey)
+	if err != nil {
+		log.DefaultLogger.Errorf("[filesource] read directory %s failed: %v", dir, err)
+		return map[string][]byte{}
+	}
+	return res.raw
+}
+
+// readDir reads the resources in the directory, each json file contains a resource, other files are ignored.
+// The same as the static config, the sub directories are not supported
+func readDir(dir string, nameKey string) (*resources, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	res := newResources()
+	for _, f := range files {
+		if f.IsDir() || path.Ext(f.Name()) != utils.JsonExt {
+			continue
+		}
+		content, err := ioutil.ReadFile(path.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(content)) == 0 {
+			continue
+		}
+		if err := res.add(content, nameKey); err != nil {
+			return nil, fmt.Errorf("file %s is invalid: %v", f.Name(), err)
+		}
+	}
+	return res, nil
+}
+
+// Start applies the files and starts watching
+func (s *Source) Start() {
+	s.check()
+	utils.GoWithRecover(func() {
+		s.watch()
+	}, nil)
+}
+
+// Stop stops watching, the applied configs are kept
+func (s *Source) Stop() {
+	s.once.Do(func() {
+		close(s.stopChan)
+	})
+}
+
+func (s *Source) watch() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			s.check()
+		}
+	}
+}
+
+func (s *Source) check() {
+	for _, w := range s.watchers {
+		w.check()
+	}
+}
+
+// applier applies the resources
+type applier interface {
+	// apply applies the differences between the resources and the last applied resources
+	apply(res *resources) error
+}
+
+// watcher checks whether the resources are changed, and applies the changes
+type watcher interface {
+	check()
+}
+
+// fileWatcher checks whether a file is changed.
+// The file is read only if the modify time or the size changes, and applied only if the content changes
+type fileWatcher struct {
+	path    string
+	nameKey string
+	applier applier
+	modTime time.Time
+	size    int64
+	content []byte
+}
+
+func newFileWatcher(path string, nameKey string, applier applier) *fileWatcher {
+	return &fileWatcher{
+		path:    path,
+		nameKey: nameKey,
+		applier: applier,
+	}
+}
+
+func (w *fileWatcher) check() {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		// keep the applied resources if the file is removed or moved temporarily
+		if !w.modTime.IsZero() {
+			log.DefaultLogger.Warnf("[filesource] stat file %s failed: %v", w.path, err)
+			w.modTime = time.Time{}
+		}
+		return
+	}
+	if info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return
+	}
+	w.modTime = info.ModTime()
+	w.size = info.Size()
+	content, err := ioutil.ReadFile(w.path)
+	if err != nil {
+		log.DefaultLogger.Errorf("[filesource] read file %s failed: %v", w.path, err)
+		return
+	}
+	if w.content != nil && bytes.Equal(content, w.content) {
+		return
+	}
+	// the content is recorded even if it is invalid, so the same error will not be reported repeatedly
+	w.content = content
+	res, err := parseResources(content, w.nameKey)
+	if err != nil {
+		log.DefaultLogger.Errorf("[filesource] parse file %s failed: %v", w.path, err)
+		return
+	}
+	if err := w.applier.apply(res); err != nil {
+		log.DefaultLogger.Errorf("[filesource] apply file %s failed: %v", w.path, err)
+		return
+	}
+	log.DefaultLogger.Infof("[filesource] apply file %s success", w.path)
+}
+
+// dirWatcher checks whether the files in a directory are changed.
+// The directory is read only if the files, their modify time or size change
+type dirWatcher struct {
+	dir     string
+	nameKey string
+	applier applier
+	stamp   string
+}
+
+func newDirWatcher(dir string, nameKey string, applier applier) *dirWatcher {
+	return &dirWatcher{
+		dir:     dir,
+		nameKey: nameKey,
+		applier: applier,
+	}
+}
+
+func (w *dirWatcher) check() {
+	files, err := ioutil.ReadDir(w.dir)
+	if err != nil {
+		// keep the applied resources if the directory is removed or moved temporarily
+		if w.stamp != "" {
+			log.DefaultLogger.Warnf("[filesource] read directory %s failed: %v", w.dir, err)
+			w.stamp = ""
+		}
+		return
+	}
+	stamp := &bytes.Buffer{}
+	for _, f := range files {
+		fmt.Fprintf(stamp, "%s:%d:%d;", f.Name(), f.ModTime().UnixNano(), f.Size())
+	}
+	if stamp.String() == w.stamp {
+		return
+	}
+	// the stamp is recorded even if the files are invalid, so the same error will not be reported repeatedly
+	w.stamp = stamp.String()
+	res, err := readDir(w.dir, w.nameKey)
+	if err != nil {
+		log.DefaultLogger.Errorf("[filesource] read directory %s failed: %v", w.dir, err)
+		return
+	}
+	if err := w.applier.apply(res); err != nil {
+		log.DefaultLogger.Errorf("[filesource] apply directory %s failed: %v", w.dir, err)
+		return
+	}
+	log.DefaultLogger.Infof("[filesource] apply directory %s success", w.dir)
+}
@@ -0,0 +1,314 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filesource
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/router"
+	"mosn.io/mosn/pkg/server"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+)
+
+type mockCMF struct{}
+
+func (cmf *mockCMF) OnCreated(cccb types.ClusterConfigFactoryCb, chcb types.ClusterHostFactoryCb) {}
+
+func TestMain(m *testing.M) {
+	router.NewRouterManager()
+	cm := cluster.NewClusterManagerSingleton(nil, nil)
+	sc := server.NewConfig(&v2.ServerConfig{
+		ServerName:      "test_file_source",
+		DefaultLogPath:  "stdout",
+		DefaultLogLevel: "FATAL",
+	})
+	server.NewServer(sc, &mockCMF{}, cm)
+	os.Exit(m.Run())
+}
+
+func TestParseResources(t *testing.T) {
+	res, err := parseResources([]byte(`[{"name":"a","v":1}, {"name":"b"}]`), "name")
+	if err != nil {
+		t.Fatalf("parse resources failed: %v", err)
+	}
+	// format changes are ignored
+	updated, removed := res.diff(map[string][]byte{
+		"a": []byte(`{"name":"a","v":1}`),
+		"c": []byte(`{"name":"c"}`),
+	})
+	if len(updated) != 1 || updated[0] != "b" {
+		t.Errorf("updated expected [b], but got %v", updated)
+	}
+	if len(removed) != 1 || removed[0] != "c" {
+		t.Errorf("removed expected [c], but got %v", removed)
+	}
+	for _, content := range []string{
+		`{"name":"a"}`,
+		`[{"v":1}]`,
+		`[{"name":"a"},{"name":"a"}]`,
+	} {
+		if _, err := parseResources([]byte(content), "name"); err == nil {
+			t.Errorf("%s expected to be invalid", content)
+		}
+	}
+	if res, err := parseResources(nil, "name"); err != nil || len(res.names) != 0 {
+		t.Errorf("empty content expected no resources, but got %v, %v", res, err)
+	}
+}
+
+func mustParseResources(t *testing.T, content string) *resources {
+	res, err := parseResources([]byte(content), "name")
+	if err != nil {
+		t.Fatalf("parse resources failed: %v", err)
+	}
+	return res
+}
+
+func TestClusterApplier(t *testing.T) {
+	a := newClusterApplier(map[string][]byte{})
+	content := `[
+		{"name":"file_cluster1","type":"SIMPLE","lb_type":"LB_RANDOM","hosts":[{"address":"127.0.0.1:8080"}]},
+		{"name":"file_cluster2","type":"SIMPLE","lb_type":"LB_RANDOM","hosts":[{"address":"127.0.0.1:8081"}]}
+	]`
+	if err := a.apply(mustParseResources(t, content)); err != nil {
+		t.Fatalf("apply clusters failed: %v", err)
+	}
+	cm := cluster.GetClusterMngAdapterInstance()
+	snap := cm.GetClusterSnapshot(context.Background(), "file_cluster1")
+	if snap == nil || len(snap.HostSet().Hosts()) != 1 {
+		t.Fatal("file_cluster1 expected to be added")
+	}
+	cm.PutClusterSnapshot(snap)
+	// update hosts of file_cluster1 and remove file_cluster2
+	content = `[
+		{"name":"file_cluster1","type":"SIMPLE","lb_type":"LB_RANDOM","hosts":[{"address":"127.0.0.1:8080"},{"address":"127.0.0.1:8082"}]}
+	]`
+	if err := a.apply(mustParseResources(t, content)); err != nil {
+		t.Fatalf("apply clusters failed: %v", err)
+	}
+	snap = cm.GetClusterSnapshot(context.Background(), "file_cluster1")
+	if snap == nil || len(snap.HostSet().Hosts()) != 2 {
+		t.Fatal("file_cluster1 expected to be updated")
+	}
+	cm.PutClusterSnapshot(snap)
+	if cm.ClusterExist("file_cluster2") {
+		t.Error("file_cluster2 expected to be removed")
+	}
+	// invalid clusters are not applied
+	if err := a.apply(mustParseResources(t, `[{"name":"file_cluster1","hosts":"invalid"}]`)); err == nil {
+		t.Error("apply invalid clusters expected failed")
+	}
+	if len(a.applied) != 1 {
+		t.Errorf("applied clusters expected to be kept, but got %v", a.applied)
+	}
+}
+
+func TestRouterApplier(t *testing.T) {
+	config := v2.RouterConfigurationConfig{
+		RouterConfigName: "file_router",
+	}
+	// the virtual host loaded by the static config is not applied again
+	loaded := mustParseResources(t, `[{"name":"vh","domains":["*"]}]`)
+	a := newRouterApplier(config, loaded.raw)
+	if err := a.apply(mustParseResources(t, `[{"name":"vh","domains":["*"]}]`)); err != nil {
+		t.Fatalf("apply routers failed: %v", err)
+	}
+	if router.GetRoutersMangerInstance().GetRouterWrapperByName("file_router") != nil {
+		t.Fatal("unchanged virtual hosts expected not to be applied")
+	}
+	content := `[
+		{"name":"vh","domains":["*"],"routers":[{"match":{"prefix":"/"},"route":{"cluster_name":"file_cluster1"}}]},
+		{"name":"vh2","domains":["test"]}
+	]`
+	if err := a.apply(mustParseResources(t, content)); err != nil {
+		t.Fatalf("apply routers failed: %v", err)
+	}
+	rw := router.GetRoutersMangerInstance().GetRouterWrapperByName("file_router")
+	if rw == nil || len(rw.GetRoutersConfig().VirtualHosts) != 2 {
+		t.Fatal("file_router expected to be added")
+	}
+	if err := a.apply(mustParseResources(t, `[{"name":"vh2","domains":["test"]}]`)); err != nil {
+		t.Fatalf("apply routers failed: %v", err)
+	}
+	rw = router.GetRoutersMangerInstance().GetRouterWrapperByName("file_router")
+	if len(rw.GetRoutersConfig().VirtualHosts) != 1 || len(a.applied) != 1 {
+		t.Errorf("removed virtual host expected to be deleted, but got %v", a.applied)
+	}
+}
+
+func TestListenerApplier(t *testing.T) {
+	a := newListenerApplier()
+	content := `[{
+		"name":"file_listener",
+		"address":"127.0.0.1:0",
+		"bind_port":true,
+		"filter_chains":[{"filters":[]}]
+	}]`
+	if err := a.apply(mustParseResources(t, content)); err != nil {
+		t.Fatalf("apply listeners failed: %v", err)
+	}
+	adapter := server.GetListenerAdapterInstance()
+	if adapter.FindListenerByName("", "file_listener") == nil {
+		t.Fatal("file_listener expected to be added")
+	}
+	if err := a.apply(mustParseResources(t, `[{"name":"bad_listener","address":"invalid"}]`)); err == nil {
+		t.Error("apply invalid listeners expected failed")
+	}
+	if adapter.FindListenerByName("", "file_listener") == nil {
+		t.Fatal("file_listener expected to be kept when the file is invalid")
+	}
+	if err := a.apply(mustParseResources(t, `[]`)); err != nil {
+		t.Fatalf("apply listeners failed: %v", err)
+	}
+	if adapter.FindListenerByName("", "file_listener") != nil {
+		t.Error("file_listener expected to be removed")
+	}
+}
+
+type mockApplier struct {
+	applied [][]string
+}
+
+func (a *mockApplier) apply(res *resources) error {
+	a.applied = append(a.applied, res.names)
+	return nil
+}
+
+func TestFileWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filesource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "listeners.json")
+	a := &mockApplier{}
+	w := newFileWatcher(path, "name", a)
+	// file not exists
+	w.check()
+	if len(a.applied) != 0 {
+		t.Fatalf("no file expected not to be applied, but got %v", a.applied)
+	}
+	ioutil.WriteFile(path, []byte(`[]`), 0644)
+	w.check()
+	w.check()
+	if len(a.applied) != 1 {
+		t.Fatalf("file expected to be applied once, but got %v", a.applied)
+	}
+	// touch without changes
+	now := time.Now().Add(time.Second)
+	os.Chtimes(path, now, now)
+	w.check()
+	if len(a.applied) != 1 {
+		t.Fatalf("unchanged file expected not to be applied, but got %v", a.applied)
+	}
+	ioutil.WriteFile(path, []byte(`[{"name":"a"}]`), 0644)
+	w.check()
+	if len(a.applied) != 2 || len(a.applied[1]) != 1 || a.applied[1][0] != "a" {
+		t.Fatalf("changed file expected to be applied, but got %v", a.applied)
+	}
+	// invalid file is not applied
+	ioutil.WriteFile(path, []byte(`[{"v":1}]`), 0644)
+	w.check()
+	if len(a.applied) != 2 {
+		t.Fatalf("invalid file expected not to be applied, but got %v", a.applied)
+	}
+	// removed file keeps the applied resources
+	os.Remove(path)
+	w.check()
+	if len(a.applied) != 2 {
+		t.Fatalf("removed file expected not to be applied, but got %v", a.applied)
+	}
+}
+
+func TestDirWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filesource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	a := &mockApplier{}
+	w := newDirWatcher(dir, "name", a)
+	ioutil.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"name":"a"}`), 0644)
+	// not json files are ignored
+	ioutil.WriteFile(filepath.Join(dir, "b.json.bak"), []byte(`{"name":"b"}`), 0644)
+	w.check()
+	w.check()
+	if len(a.applied) != 1 || len(a.applied[0]) != 1 || a.applied[0][0] != "a" {
+		t.Fatalf("directory expected to be applied once, but got %v", a.applied)
+	}
+	ioutil.WriteFile(filepath.Join(dir, "c.json"), []byte(`{"name":"c"}`), 0644)
+	w.check()
+	if len(a.applied) != 2 || len(a.applied[1]) != 2 {
+		t.Fatalf("added file expected to be applied, but got %v", a.applied)
+	}
+	// duplicate names are invalid
+	ioutil.WriteFile(filepath.Join(dir, "d.json"), []byte(`{"name":"c"}`), 0644)
+	w.check()
+	if len(a.applied) != 2 {
+		t.Fatalf("invalid directory expected not to be applied, but got %v", a.applied)
+	}
+	os.Remove(filepath.Join(dir, "d.json"))
+	os.Remove(filepath.Join(dir, "a.json"))
+	w.check()
+	if len(a.applied) != 3 || len(a.applied[2]) != 1 || a.applied[2][0] != "c" {
+		t.Fatalf("removed file expected to be applied, but got %v", a.applied)
+	}
+}
+
+func TestSourceStartStop(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filesource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "vh.json"), []byte(`{"name":"vh","domains":["*"]}`), 0644)
+	routerConfig := &v2.RouterConfiguration{}
+	routerConfig.RouterConfigName = "watch_router"
+	routerConfig.RouterConfigPath = dir
+	s := NewSource(&v2.MOSNConfig{
+		Servers: []v2.ServerConfig{
+			{Routers: []*v2.RouterConfiguration{routerConfig}},
+		},
+		FileSource: &v2.FileSourceConfig{},
+	})
+	s.interval = 10 * time.Millisecond
+	s.Start()
+	defer s.Stop()
+	// the virtual hosts are loaded by the static config
+	if router.GetRoutersMangerInstance().GetRouterWrapperByName("watch_router") != nil {
+		t.Fatal("loaded virtual hosts expected not to be applied when source started")
+	}
+	ioutil.WriteFile(filepath.Join(dir, "vh2.json"), []byte(`{"name":"vh2","domains":["test"]}`), 0644)
+	for i := 0; i < 100; i++ {
+		if rw := router.GetRoutersMangerInstance().GetRouterWrapperByName("watch_router"); rw != nil {
+			if len(rw.GetRoutersConfig().VirtualHosts) != 2 {
+				t.Fatalf("virtual hosts expected to be updated, but got %v", rw.GetRoutersConfig().VirtualHosts)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("changed router directory expected to be applied")
+}
@@ -0,0 +1,68 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rbac
+
+import (
+	"context"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/rbac"
+	"mosn.io/mosn/pkg/types"
+)
+
+// rbacFilter checks the connection once, the connections denied are closed
+type rbacFilter struct {
+	ctx           context.Context
+	authorizer    *rbac.Authorizer
+	readCallbacks api.ReadFilterCallbacks
+	checked       bool
+	denied        bool
+}
+
+// NewRBACFilter makes a rbac filter as types.ReadFilter
+func NewRBACFilter(ctx context.Context, authorizer *rbac.Authorizer) api.ReadFilter {
+	return &rbacFilter{
+		ctx:        ctx,
+		authorizer: authorizer,
+	}
+}
+
+// OnData checks the connection with the first data received, the mTLS handshake is finished then
+func (f *rbacFilter) OnData(buffer types.IoBuffer) api.FilterStatus {
+	if !f.checked {
+		f.checked = true
+		conn := f.readCallbacks.Connection()
+		if !f.authorizer.Check(f.ctx, &rbac.Request{Connection: conn}) {
+			f.denied = true
+			conn.Close(api.NoFlush, api.LocalClose)
+		}
+	}
+	if f.denied {
+		buffer.Drain(buffer.Len())
+		return api.Stop
+	}
+	return api.Continue
+}
+
+func (f *rbacFilter) OnNewConnection() api.FilterStatus {
+	return api.Continue
+}
+
+func (f *rbacFilter) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {
+	f.readCallbacks = cb
+}
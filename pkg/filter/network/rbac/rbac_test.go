@@ -0,0 +1,92 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rbac
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"mosn.io/api"
+	"mosn.io/pkg/buffer"
+)
+
+type mockConnection struct {
+	api.Connection
+	remote net.Addr
+	closed bool
+}
+
+func (c *mockConnection) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func (c *mockConnection) Close(ccType api.ConnectionCloseType, eventType api.ConnectionEvent) error {
+	c.closed = true
+	return nil
+}
+
+type mockReadFilterCallbacks struct {
+	api.ReadFilterCallbacks
+	conn api.Connection
+}
+
+func (cb *mockReadFilterCallbacks) Connection() api.Connection {
+	return cb.conn
+}
+
+func TestRBACFilter(t *testing.T) {
+	factory, err := CreateRBACFactory(map[string]interface{}{
+		"stat_prefix": t.Name(),
+		"rules": map[string]interface{}{
+			"policies": map[string]interface{}{
+				"local": map[string]interface{}{
+					"permissions": []interface{}{
+						map[string]interface{}{"any": true},
+					},
+					"principals": []interface{}{
+						map[string]interface{}{"source_ip": map[string]interface{}{"Address": "127.0.0.1", "Length": 32}},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorizer := factory.(*rbacConfigFactory).authorizer
+	for _, tc := range []struct {
+		ip     string
+		status api.FilterStatus
+	}{
+		{"127.0.0.1", api.Continue},
+		{"10.0.0.1", api.Stop},
+	} {
+		conn := &mockConnection{remote: &net.TCPAddr{IP: net.ParseIP(tc.ip), Port: 12345}}
+		filter := NewRBACFilter(context.Background(), authorizer)
+		filter.InitializeReadFilterCallbacks(&mockReadFilterCallbacks{conn: conn})
+		for i := 0; i < 2; i++ {
+			data := buffer.NewIoBufferString("data")
+			if status := filter.OnData(data); status != tc.status {
+				t.Fatalf("ip %s expected status %v, but got %v", tc.ip, tc.status, status)
+			}
+			if denied := tc.status == api.Stop; conn.closed != denied || (denied && data.Len() != 0) {
+				t.Fatalf("ip %s unexpected connection closed %v, data %d", tc.ip, conn.closed, data.Len())
+			}
+		}
+	}
+}
@@ -0,0 +1,218 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package adaptiveconcurrency
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	mosnmetrics "mosn.io/mosn/pkg/metrics"
+)
+
+const (
+	minGradient = 0.5
+	maxGradient = 2.0
+
+	// the latency samples in an interval are kept by reservoir sampling if there are more than maxSamples
+	maxSamples = 1024
+)
+
+// gradientController adjusts the concurrency limit of a cluster or route by the gradient of the latency.
+// The min rtt is calculated periodically with the limit set to the min concurrency,
+// then the sample rtt is aggregated from the latency samples in each update interval and
+// limit = limit * (min_rtt + buffer) / sample_rtt + sqrt(limit).
+// The limit and the rtts are updated by the samples recorded, there is no timer
+type gradientController struct {
+	config *adaptiveConfig
+
+	// inFlight and limit are accessed atomically
+	inFlight int64
+	limit    int64
+
+	mux sync.Mutex
+	// at most maxSamples are kept, sampleCount is the number of the samples recorded
+	samples     []time.Duration
+	sampleCount int
+	rand        *rand.Rand
+	minRTT      time.Duration
+	sampleRTT   time.Duration
+	// inMinRTTCalc is true when the min rtt is calculating,
+	// the limit before is deferred and restored after the calculation
+	inMinRTTCalc   bool
+	deferredLimit  int64
+	nextMinRTTCalc time.Time
+	lastUpdate     time.Time
+
+	limitGauge     metrics.Gauge
+	minRTTGauge    metrics.Gauge
+	sampleRTTGauge metrics.Gauge
+	minRTTActive   metrics.Gauge
+	blocked        metrics.Counter
+}
+
+func newGradientController(config *adaptiveConfig, key string, now time.Time) *gradientController {
+	stats := mosnmetrics.NewAdaptiveConcurrencyStats(config.statPrefix, key)
+	c := &gradientController{
+		config:         config,
+		limit:          config.minConcurrency,
+		rand:           rand.New(rand.NewSource(now.UnixNano())),
+		limitGauge:     stats.Gauge(mosnmetrics.AdaptiveConcurrencyLimit),
+		minRTTGauge:    stats.Gauge(mosnmetrics.AdaptiveConcurrencyMinRTT),
+		sampleRTTGauge: stats.Gauge(mosnmetrics.AdaptiveConcurrencySampleRTT),
+		minRTTActive:   stats.Gauge(mosnmetrics.AdaptiveConcurrencyMinRTTActive),
+		blocked:        stats.Counter(mosnmetrics.AdaptiveConcurrencyBlocked),
+	}
+	c.startMinRTTCalc()
+	return c
+}
+
+// tryAcquire returns true if the request is allowed by the limit, the release must be called after the request is finished
+func (c *gradientController) tryAcquire() bool {
+	for {
+		inFlight := atomic.LoadInt64(&c.inFlight)
+		if inFlight >= atomic.LoadInt64(&c.limit) {
+			c.blocked.Inc(1)
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&c.inFlight, inFlight, inFlight+1) {
+			return true
+		}
+	}
+}
+
+func (c *gradientController) release() {
+	atomic.AddInt64(&c.inFlight, -1)
+}
+
+func (c *gradientController) concurrencyLimit() int64 {
+	return atomic.LoadInt64(&c.limit)
+}
+
+// recordSample records the latency of a request, the limit is updated if the update interval is reached.
+// The samples are aggregated out of the lock
+func (c *gradientController) recordSample(rtt time.Duration, now time.Time) {
+	c.mux.Lock()
+	c.addSample(rtt)
+	inMinRTTCalc := c.inMinRTTCalc
+	if inMinRTTCalc {
+		if c.sampleCount < c.config.minRTTRequestCount {
+			c.mux.Unlock()
+			return
+		}
+		c.inMinRTTCalc = false
+	} else if now.Sub(c.lastUpdate) < c.config.updateInterval {
+		c.mux.Unlock()
+		return
+	}
+	c.lastUpdate = now
+	samples := c.takeSamples()
+	c.mux.Unlock()
+
+	rtt = aggregateSamples(samples, c.config.percentile)
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if inMinRTTCalc {
+		c.minRTT = rtt
+		c.minRTTGauge.Update(c.minRTT.Nanoseconds() / int64(time.Millisecond))
+		c.minRTTActive.Update(0)
+		c.nextMinRTTCalc = now.Add(c.minRTTCalcInterval())
+		c.setLimit(c.deferredLimit)
+		return
+	}
+	c.sampleRTT = rtt
+	c.sampleRTTGauge.Update(c.sampleRTT.Nanoseconds() / int64(time.Millisecond))
+	c.setLimit(c.calculateLimit())
+	if !now.Before(c.nextMinRTTCalc) {
+		c.startMinRTTCalc()
+	}
+}
+
+// addSample keeps a sample by reservoir sampling, so each of the samples recorded is kept with the same probability
+func (c *gradientController) addSample(rtt time.Duration) {
+	c.sampleCount++
+	if len(c.samples) < maxSamples {
+		c.samples = append(c.samples, rtt)
+		return
+	}
+	if i := c.rand.Intn(c.sampleCount); i < maxSamples {
+		c.samples[i] = rtt
+	}
+}
+
+// takeSamples returns the samples kept and clears them
+func (c *gradientController) takeSamples() []time.Duration {
+	samples := c.samples
+	c.samples = make([]time.Duration, 0, len(samples))
+	c.sampleCount = 0
+	return samples
+}
+
+// startMinRTTCalc limits the concurrency to the min concurrency, so the min rtt is sampled without queueing
+func (c *gradientController) startMinRTTCalc() {
+	c.inMinRTTCalc = true
+	c.minRTTActive.Update(1)
+	c.deferredLimit = c.concurrencyLimit()
+	c.samples = c.samples[:0]
+	c.sampleCount = 0
+	c.setLimit(c.config.minConcurrency)
+}
+
+func (c *gradientController) calculateLimit() int64 {
+	gradient := maxGradient
+	if c.sampleRTT > 0 {
+		buffer := float64(c.minRTT) * c.config.buffer
+		gradient = (float64(c.minRTT) + buffer) / float64(c.sampleRTT)
+		gradient = math.Max(minGradient, math.Min(maxGradient, gradient))
+	}
+	limit := float64(c.concurrencyLimit()) * gradient
+	newLimit := int64(limit + math.Sqrt(limit))
+	if newLimit < c.config.minConcurrency {
+		newLimit = c.config.minConcurrency
+	}
+	if newLimit > c.config.maxLimit {
+		newLimit = c.config.maxLimit
+	}
+	return newLimit
+}
+
+func (c *gradientController) setLimit(limit int64) {
+	atomic.StoreInt64(&c.limit, limit)
+	c.limitGauge.Update(limit)
+}
+
+// aggregateSamples returns the percentile of the samples
+func aggregateSamples(samples []time.Duration, percentile float64) time.Duration {
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i] < samples[j]
+	})
+	i := int(math.Ceil(percentile/100*float64(len(samples)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return samples[i]
+}
+
+// minRTTCalcInterval returns the interval with a random jitter
+func (c *gradientController) minRTTCalcInterval() time.Duration {
+	return c.config.minRTTInterval + time.Duration(c.rand.Float64()*c.config.jitter*float64(c.config.minRTTInterval))
+}
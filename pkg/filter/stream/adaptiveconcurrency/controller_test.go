@@ -0,0 +1,187 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package adaptiveconcurrency
+
+import (
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+func newTestConfig(t *testing.T, cfg *v2.AdaptiveConcurrencyConfig) *adaptiveConfig {
+	config, err := newAdaptiveConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestControllerMinRTTCalc(t *testing.T) {
+	jitter := float64(0)
+	config := newTestConfig(t, &v2.AdaptiveConcurrencyConfig{
+		MinRTTCalcRequestCount: 3,
+		MinRTTCalcJitter:       &jitter,
+		StatPrefix:             t.Name(),
+	})
+	now := time.Now()
+	c := newGradientController(config, "test", now)
+	if limit := c.concurrencyLimit(); limit != defaultMinConcurrency {
+		t.Fatalf("expected the min concurrency before the min rtt is calculated, but got %d", limit)
+	}
+	for i := 0; i < defaultMinConcurrency; i++ {
+		if !c.tryAcquire() {
+			t.Fatalf("request %d is expected to be allowed", i)
+		}
+	}
+	if c.tryAcquire() {
+		t.Fatal("expected the request is blocked by the min concurrency")
+	}
+	if cnt := c.blocked.Count(); cnt != 1 {
+		t.Errorf("expected blocked 1, but got %d", cnt)
+	}
+	c.recordSample(30*time.Millisecond, now)
+	c.recordSample(10*time.Millisecond, now)
+	c.recordSample(20*time.Millisecond, now)
+	if c.inMinRTTCalc || c.minRTT != 20*time.Millisecond {
+		t.Fatalf("expected min rtt 20ms, but got %v", c.minRTT)
+	}
+	if v := c.minRTTGauge.Value(); v != 20 {
+		t.Errorf("expected min rtt gauge 20, but got %d", v)
+	}
+	if v := c.minRTTActive.Value(); v != 0 {
+		t.Errorf("expected min rtt calculation inactive")
+	}
+	if !c.nextMinRTTCalc.Equal(now.Add(defaultMinRTTCalcInterval)) {
+		t.Errorf("unexpected next min rtt calculation %v", c.nextMinRTTCalc)
+	}
+}
+
+func TestControllerGradient(t *testing.T) {
+	config := newTestConfig(t, &v2.AdaptiveConcurrencyConfig{
+		MinRTTCalcRequestCount: 1,
+		MaxConcurrencyLimit:    100,
+		StatPrefix:             t.Name(),
+	})
+	now := time.Now()
+	c := newGradientController(config, "test", now)
+	c.recordSample(100*time.Millisecond, now)
+
+	// the samples in the update interval are aggregated
+	now = now.Add(50 * time.Millisecond)
+	c.recordSample(100*time.Millisecond, now)
+	if limit := c.concurrencyLimit(); limit != defaultMinConcurrency {
+		t.Fatalf("expected the limit is not updated in the update interval, but got %d", limit)
+	}
+	// gradient is 125/100, limit = 3 * 1.25 + sqrt(3.75)
+	now = now.Add(50 * time.Millisecond)
+	c.recordSample(100*time.Millisecond, now)
+	if limit := c.concurrencyLimit(); limit != 5 {
+		t.Fatalf("expected limit 5, but got %d", limit)
+	}
+	if v := c.sampleRTTGauge.Value(); v != 100 {
+		t.Errorf("expected sample rtt gauge 100, but got %d", v)
+	}
+	// the gradient is 2 at most
+	for i := 0; i < 5; i++ {
+		now = now.Add(100 * time.Millisecond)
+		c.recordSample(time.Millisecond, now)
+	}
+	if limit := c.concurrencyLimit(); limit != 100 {
+		t.Fatalf("expected the max concurrency limit, but got %d", limit)
+	}
+	if v := c.limitGauge.Value(); v != 100 {
+		t.Errorf("expected limit gauge 100, but got %d", v)
+	}
+	// the gradient is 0.5 at least, limit = 100 * 0.5 + sqrt(50)
+	now = now.Add(100 * time.Millisecond)
+	c.recordSample(time.Second, now)
+	if limit := c.concurrencyLimit(); limit != 57 {
+		t.Fatalf("expected limit 57, but got %d", limit)
+	}
+}
+
+func TestControllerRecalculateMinRTT(t *testing.T) {
+	jitter := float64(0)
+	config := newTestConfig(t, &v2.AdaptiveConcurrencyConfig{
+		MinRTTCalcRequestCount: 1,
+		MinRTTCalcInterval:     api.DurationConfig{Duration: time.Second},
+		MinRTTCalcJitter:       &jitter,
+		StatPrefix:             t.Name(),
+	})
+	now := time.Now()
+	c := newGradientController(config, "test", now)
+	c.recordSample(100*time.Millisecond, now)
+	for i := 0; i < 3; i++ {
+		now = now.Add(100 * time.Millisecond)
+		c.recordSample(100*time.Millisecond, now)
+	}
+	limit := c.concurrencyLimit()
+	if limit <= defaultMinConcurrency {
+		t.Fatalf("expected the limit is increased, but got %d", limit)
+	}
+	now = now.Add(time.Second)
+	c.recordSample(100*time.Millisecond, now)
+	if !c.inMinRTTCalc || c.concurrencyLimit() != defaultMinConcurrency {
+		t.Fatalf("expected the min rtt is recalculating with the min concurrency")
+	}
+	// the limit is restored after the min rtt is calculated
+	deferred := c.deferredLimit
+	c.recordSample(50*time.Millisecond, now)
+	if c.minRTT != 50*time.Millisecond || c.concurrencyLimit() != deferred {
+		t.Fatalf("unexpected min rtt %v and limit %d", c.minRTT, c.concurrencyLimit())
+	}
+}
+
+func TestAdaptiveConfigInvalid(t *testing.T) {
+	for _, cfg := range []*v2.AdaptiveConcurrencyConfig{
+		{Key: "host"},
+		{SampleAggregatePercentile: 101},
+		{MinConcurrency: 10, MaxConcurrencyLimit: 5},
+	} {
+		if _, err := newAdaptiveConfig(cfg); err == nil {
+			t.Errorf("expected config %+v is invalid", cfg)
+		}
+	}
+}
+
+func TestControllerSamplesLimited(t *testing.T) {
+	config := newTestConfig(t, &v2.AdaptiveConcurrencyConfig{
+		MinRTTCalcRequestCount: 1,
+		StatPrefix:             t.Name(),
+	})
+	now := time.Now()
+	c := newGradientController(config, "test", now)
+	c.recordSample(10*time.Millisecond, now)
+	for i := 0; i < 10*maxSamples; i++ {
+		c.recordSample(time.Duration(i)*time.Microsecond, now)
+	}
+	if len(c.samples) != maxSamples || c.sampleCount != 10*maxSamples {
+		t.Fatalf("expected %d samples kept, but got %d of %d", maxSamples, len(c.samples), c.sampleCount)
+	}
+	// the samples kept are spread over all the samples recorded
+	var later int
+	for _, rtt := range c.samples {
+		if rtt >= time.Duration(maxSamples)*time.Microsecond {
+			later++
+		}
+	}
+	if later < maxSamples/2 {
+		t.Errorf("expected most of the samples are replaced by the later ones, but got %d", later)
+	}
+}
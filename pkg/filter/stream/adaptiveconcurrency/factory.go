@@ -0,0 +1,177 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package adaptiveconcurrency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+)
+
+func init() {
+	api.RegisterStream(v2.AdaptiveConcurrency, CreateAdaptiveConcurrencyFilterFactory)
+}
+
+const (
+	defaultSampleAggregatePercentile = 50
+	defaultMaxConcurrencyLimit       = 1000
+	defaultConcurrencyUpdateInterval = 100 * time.Millisecond
+	defaultMinRTTCalcInterval        = 60 * time.Second
+	defaultMinRTTCalcRequestCount    = 50
+	defaultMinRTTCalcJitter          = 15
+	defaultMinRTTBuffer              = 25
+	defaultMinConcurrency            = 3
+	defaultStatPrefix                = "adaptive_concurrency"
+)
+
+// FilterConfigFactory shares the config and the controllers with the filters created
+type FilterConfigFactory struct {
+	config *adaptiveConfig
+}
+
+func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	filter := newAdaptiveConcurrencyFilter(context, f.config)
+	callbacks.AddStreamReceiverFilter(filter, api.AfterRoute)
+	callbacks.AddStreamSenderFilter(filter)
+}
+
+func CreateAdaptiveConcurrencyFilterFactory(conf map[string]interface{}) (api.StreamFilterChainFactory, error) {
+	log.DefaultLogger.Debugf("create adaptive concurrency stream filter factory")
+	cfg, err := ParseAdaptiveConcurrencyFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+	config, err := newAdaptiveConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &FilterConfigFactory{config}, nil
+}
+
+// ParseAdaptiveConcurrencyFilter
+func ParseAdaptiveConcurrencyFilter(cfg map[string]interface{}) (*v2.AdaptiveConcurrencyConfig, error) {
+	filterConfig := &v2.AdaptiveConcurrencyConfig{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	return filterConfig, nil
+}
+
+// adaptiveConfig is the config compiled from v2.AdaptiveConcurrencyConfig,
+// it keeps a controller for each cluster or route
+type adaptiveConfig struct {
+	keyByRoute         bool
+	percentile         float64
+	maxLimit           int64
+	minConcurrency     int64
+	updateInterval     time.Duration
+	minRTTInterval     time.Duration
+	minRTTRequestCount int
+	// jitter and buffer are the ratios of the percentages configured
+	jitter          float64
+	buffer          float64
+	statusOnBlocked int
+	statPrefix      string
+
+	controllers sync.Map // key -> *gradientController
+}
+
+func newAdaptiveConfig(cfg *v2.AdaptiveConcurrencyConfig) (*adaptiveConfig, error) {
+	c := &adaptiveConfig{
+		percentile:         cfg.SampleAggregatePercentile,
+		maxLimit:           int64(cfg.MaxConcurrencyLimit),
+		minConcurrency:     int64(cfg.MinConcurrency),
+		updateInterval:     cfg.ConcurrencyUpdateInterval.Duration,
+		minRTTInterval:     cfg.MinRTTCalcInterval.Duration,
+		minRTTRequestCount: int(cfg.MinRTTCalcRequestCount),
+		jitter:             defaultMinRTTCalcJitter,
+		buffer:             defaultMinRTTBuffer,
+		statusOnBlocked:    cfg.StatusOnBlocked,
+		statPrefix:         cfg.StatPrefix,
+	}
+	switch cfg.Key {
+	case "", v2.AdaptiveConcurrencyKeyCluster:
+	case v2.AdaptiveConcurrencyKeyRoute:
+		c.keyByRoute = true
+	default:
+		return nil, fmt.Errorf("invalid adaptive concurrency key %s", cfg.Key)
+	}
+	if c.percentile == 0 {
+		c.percentile = defaultSampleAggregatePercentile
+	} else if c.percentile < 0 || c.percentile > 100 {
+		return nil, fmt.Errorf("invalid sample aggregate percentile %v", c.percentile)
+	}
+	if c.maxLimit == 0 {
+		c.maxLimit = defaultMaxConcurrencyLimit
+	}
+	if c.minConcurrency == 0 {
+		c.minConcurrency = defaultMinConcurrency
+	}
+	if c.minConcurrency > c.maxLimit {
+		return nil, fmt.Errorf("min concurrency %d is larger than the max concurrency limit %d", c.minConcurrency, c.maxLimit)
+	}
+	if c.updateInterval == 0 {
+		c.updateInterval = defaultConcurrencyUpdateInterval
+	}
+	if c.minRTTInterval == 0 {
+		c.minRTTInterval = defaultMinRTTCalcInterval
+	}
+	if c.minRTTRequestCount == 0 {
+		c.minRTTRequestCount = defaultMinRTTCalcRequestCount
+	}
+	if cfg.MinRTTCalcJitter != nil {
+		c.jitter = *cfg.MinRTTCalcJitter
+	}
+	if cfg.MinRTTBuffer != nil {
+		c.buffer = *cfg.MinRTTBuffer
+	}
+	if c.jitter < 0 || c.jitter > 100 {
+		return nil, fmt.Errorf("invalid min rtt calc jitter %v", c.jitter)
+	}
+	if c.buffer < 0 || c.buffer > 100 {
+		return nil, fmt.Errorf("invalid min rtt buffer %v", c.buffer)
+	}
+	c.jitter /= 100
+	c.buffer /= 100
+	if c.statusOnBlocked == 0 {
+		c.statusOnBlocked = http.StatusServiceUnavailable
+	}
+	if c.statPrefix == "" {
+		c.statPrefix = defaultStatPrefix
+	}
+	return c, nil
+}
+
+// controller returns the controller of the key, a new one is created if it does not exist
+func (c *adaptiveConfig) controller(key string) *gradientController {
+	if v, ok := c.controllers.Load(key); ok {
+		return v.(*gradientController)
+	}
+	v, _ := c.controllers.LoadOrStore(key, newGradientController(c, key, time.Now()))
+	return v.(*gradientController)
+}
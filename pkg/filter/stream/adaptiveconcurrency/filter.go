@@ -0,0 +1,105 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package adaptiveconcurrency
+
+import (
+	"context"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// adaptiveConcurrencyFilter is an implement of api.StreamReceiverFilter and api.StreamSenderFilter,
+// the requests exceeding the concurrency limit of the cluster or route are replied directly,
+// and the latency of the forwarded requests is sampled when the response is appended
+type adaptiveConcurrencyFilter struct {
+	ctx            context.Context
+	config         *adaptiveConfig
+	receiveHandler api.StreamReceiverFilterHandler
+	sendHandler    api.StreamSenderFilterHandler
+	// controller is set if the request is acquired
+	controller *gradientController
+	start      time.Time
+	sampled    bool
+}
+
+func newAdaptiveConcurrencyFilter(ctx context.Context, config *adaptiveConfig) *adaptiveConcurrencyFilter {
+	return &adaptiveConcurrencyFilter{
+		ctx:    ctx,
+		config: config,
+	}
+}
+
+func (f *adaptiveConcurrencyFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.receiveHandler = handler
+}
+
+func (f *adaptiveConcurrencyFilter) SetSenderFilterHandler(handler api.StreamSenderFilterHandler) {
+	f.sendHandler = handler
+}
+
+// limitKey returns the cluster or route name the limit is calculated for, empty if there is no route
+func (f *adaptiveConcurrencyFilter) limitKey() string {
+	route := f.receiveHandler.Route()
+	if route == nil || route.RouteRule() == nil {
+		return ""
+	}
+	rule := route.RouteRule()
+	if f.config.keyByRoute {
+		if named, ok := rule.(types.NamedRouteRule); ok && named.RouteName() != "" {
+			return named.RouteName()
+		}
+	}
+	return rule.ClusterName()
+}
+
+func (f *adaptiveConcurrencyFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	key := f.limitKey()
+	if key == "" {
+		return api.StreamFilterContinue
+	}
+	controller := f.config.controller(key)
+	if !controller.tryAcquire() {
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(ctx, "[stream filter] [adaptive concurrency] request to %s is blocked, concurrency limit %d", key, controller.concurrencyLimit())
+		}
+		f.receiveHandler.SendHijackReply(f.config.statusOnBlocked, headers)
+		return api.StreamFilterStop
+	}
+	f.controller = controller
+	f.start = time.Now()
+	return api.StreamFilterContinue
+}
+
+func (f *adaptiveConcurrencyFilter) Append(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if f.controller != nil && !f.sampled {
+		f.sampled = true
+		now := time.Now()
+		f.controller.recordSample(now.Sub(f.start), now)
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *adaptiveConcurrencyFilter) OnDestroy() {
+	if f.controller != nil {
+		f.controller.release()
+		f.controller = nil
+	}
+}
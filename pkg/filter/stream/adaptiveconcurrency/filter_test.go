@@ -0,0 +1,146 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package adaptiveconcurrency
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/router"
+)
+
+type mockHandler struct {
+	api.StreamReceiverFilterHandler
+	route api.Route
+	code  int
+}
+
+func (h *mockHandler) Route() api.Route {
+	return h.route
+}
+
+func (h *mockHandler) SendHijackReply(code int, headers api.HeaderMap) {
+	h.code = code
+}
+
+func newTestRoute(t *testing.T, name, cluster string) api.Route {
+	r := v2.Router{}
+	r.Name = name
+	r.Match = v2.RouterMatch{Prefix: "/"}
+	r.Route = v2.RouteAction{RouterActionConfig: v2.RouterActionConfig{ClusterName: cluster}}
+	vh, err := router.NewVirtualHostImpl(&v2.VirtualHost{
+		Name:    "test",
+		Domains: []string{"*"},
+		Routers: []v2.Router{r},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vh.GetRouteFromEntries(protocol.CommonHeader{protocol.MosnHeaderPathKey: "/"}, 1)
+}
+
+func newFilter(config *adaptiveConfig, route api.Route) (*adaptiveConcurrencyFilter, *mockHandler) {
+	handler := &mockHandler{route: route}
+	f := newAdaptiveConcurrencyFilter(context.Background(), config)
+	f.SetReceiveFilterHandler(handler)
+	return f, handler
+}
+
+func TestFilterBlocked(t *testing.T) {
+	config := newTestConfig(t, &v2.AdaptiveConcurrencyConfig{
+		MinConcurrency:         1,
+		MinRTTCalcRequestCount: 1,
+		StatusOnBlocked:        http.StatusTooManyRequests,
+		StatPrefix:             t.Name(),
+	})
+	route := newTestRoute(t, "", "cluster1")
+	f1, _ := newFilter(config, route)
+	if status := f1.OnReceive(context.Background(), protocol.CommonHeader{}, nil, nil); status != api.StreamFilterContinue {
+		t.Fatal("expected the first request is allowed")
+	}
+	f2, handler := newFilter(config, route)
+	if status := f2.OnReceive(context.Background(), protocol.CommonHeader{}, nil, nil); status != api.StreamFilterStop {
+		t.Fatal("expected the request is blocked")
+	}
+	if handler.code != http.StatusTooManyRequests {
+		t.Errorf("expected status 429, but got %d", handler.code)
+	}
+	f2.OnDestroy()
+
+	// the other clusters are limited separately
+	f3, _ := newFilter(config, newTestRoute(t, "", "cluster2"))
+	if status := f3.OnReceive(context.Background(), protocol.CommonHeader{}, nil, nil); status != api.StreamFilterContinue {
+		t.Fatal("expected the request to another cluster is allowed")
+	}
+	f3.OnDestroy()
+
+	time.Sleep(10 * time.Millisecond)
+	f1.Append(context.Background(), protocol.CommonHeader{}, nil, nil)
+	f1.OnDestroy()
+	c := config.controller("cluster1")
+	if c.inFlight != 0 {
+		t.Errorf("expected no request in flight, but got %d", c.inFlight)
+	}
+	if c.minRTT < 10*time.Millisecond {
+		t.Errorf("expected the latency is sampled, but got min rtt %v", c.minRTT)
+	}
+	f4, _ := newFilter(config, route)
+	if status := f4.OnReceive(context.Background(), protocol.CommonHeader{}, nil, nil); status != api.StreamFilterContinue {
+		t.Fatal("expected the request is allowed after the first one is finished")
+	}
+}
+
+func TestFilterKeyByRoute(t *testing.T) {
+	config := newTestConfig(t, &v2.AdaptiveConcurrencyConfig{
+		Key:        v2.AdaptiveConcurrencyKeyRoute,
+		StatPrefix: t.Name(),
+	})
+	f, _ := newFilter(config, newTestRoute(t, "route1", "cluster1"))
+	if key := f.limitKey(); key != "route1" {
+		t.Errorf("expected key route1, but got %s", key)
+	}
+	f, _ = newFilter(config, newTestRoute(t, "", "cluster1"))
+	if key := f.limitKey(); key != "cluster1" {
+		t.Errorf("expected the cluster name if the route has no name, but got %s", key)
+	}
+	f, _ = newFilter(config, nil)
+	if status := f.OnReceive(context.Background(), protocol.CommonHeader{}, nil, nil); status != api.StreamFilterContinue {
+		t.Error("expected the request without route is not limited")
+	}
+}
+
+func TestCreateFilterFactory(t *testing.T) {
+	factory, err := CreateAdaptiveConcurrencyFilterFactory(map[string]interface{}{
+		"key":                         "route",
+		"sample_aggregate_percentile": 90,
+		"concurrency_update_interval": "200ms",
+		"min_rtt_buffer":              0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := factory.(*FilterConfigFactory).config
+	if !config.keyByRoute || config.percentile != 90 || config.updateInterval != 200*time.Millisecond ||
+		config.buffer != 0 || config.jitter != 0.15 || config.statusOnBlocked != http.StatusServiceUnavailable {
+		t.Errorf("unexpected config %+v", config)
+	}
+}
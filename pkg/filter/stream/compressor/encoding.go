@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package compressor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+const (
+	gzipBestSpeed       = gzip.BestSpeed
+	gzipBestCompression = gzip.BestCompression
+	gzipDefaultLevel    = 6
+)
+
+var errBodyTooLarge = errors.New("decompressed body too large")
+
+// compressWriter is the writer of an encoding, Flush writes the data compressed so far
+type compressWriter interface {
+	io.WriteCloser
+	Flush() error
+}
+
+func (c *compressorConfig) newWriter(encoding string, w io.Writer) compressWriter {
+	if encoding == v2.EncodingBrotli {
+		return brotli.NewWriterLevel(w, c.brotliQuality)
+	}
+	// the level is validated
+	gw, _ := gzip.NewWriterLevel(w, c.gzipLevel)
+	return gw
+}
+
+// compress encodes the whole body
+func (c *compressorConfig) compress(encoding string, data []byte) ([]byte, error) {
+	out := &bytes.Buffer{}
+	w := c.newWriter(encoding, out)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// gunzip decodes the gzip body, the body decoded should not exceed the limit
+func gunzip(data []byte, limit int) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	out, err := ioutil.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > limit {
+		return nil, errBodyTooLarge
+	}
+	return out, nil
+}
+
+// chooseEncoding returns the encoding accepted with the highest quality,
+// the order of the encodings supported breaks the tie
+func chooseEncoding(acceptEncoding string, encodings []string) string {
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, q := parseQuality(part)
+		if name != "" {
+			qualities[name] = q
+		}
+	}
+	best, bestQ := "", 0.0
+	for _, e := range encodings {
+		q, ok := qualities[e]
+		if !ok {
+			if q, ok = qualities["*"]; !ok {
+				continue
+			}
+		}
+		if q > bestQ {
+			best, bestQ = e, q
+		}
+	}
+	return best
+}
+
+// parseQuality parses a coding of the accept-encoding, the quality is 1 if it is not specified
+func parseQuality(s string) (string, float64) {
+	params := strings.Split(s, ";")
+	name := strings.ToLower(strings.TrimSpace(params[0]))
+	q := 1.0
+	for _, p := range params[1:] {
+		p = strings.TrimSpace(p)
+		if strings.HasPrefix(p, "q=") {
+			v, err := strconv.ParseFloat(p[2:], 64)
+			if err != nil {
+				return "", 0
+			}
+			q = v
+		}
+	}
+	return name, q
+}
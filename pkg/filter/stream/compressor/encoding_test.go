@@ -0,0 +1,41 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package compressor
+
+import "testing"
+
+func TestChooseEncoding(t *testing.T) {
+	encodings := []string{"br", "gzip"}
+	for _, tc := range []struct {
+		accept   string
+		expected string
+	}{
+		{"gzip, deflate, br", "br"},
+		{"gzip", "gzip"},
+		{"GZIP;q=1.0, br;q=0.8", "gzip"},
+		{"br;q=0, gzip;q=0.1", "gzip"},
+		{"*", "br"},
+		{"*;q=0.5, gzip", "gzip"},
+		{"gzip;q=0, *", "br"},
+		{"deflate, identity", ""},
+		{"gzip;q=invalid", ""},
+	} {
+		if encoding := chooseEncoding(tc.accept, encodings); encoding != tc.expected {
+			t.Errorf("accept %s: expected %s, but got %s", tc.accept, tc.expected, encoding)
+		}
+	}
+}
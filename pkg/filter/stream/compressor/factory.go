@@ -0,0 +1,177 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package compressor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	metrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	mosnmetrics "mosn.io/mosn/pkg/metrics"
+)
+
+func init() {
+	api.RegisterStream(v2.Compressor, CreateCompressorFilterFactory)
+}
+
+const (
+	defaultMinContentLength     = 30
+	defaultMaxDecompressedBytes = 4 * 1024 * 1024
+	defaultStatPrefix           = "compressor"
+)
+
+// defaultContentTypes are the text types compressed if the content types are not configured
+var defaultContentTypes = []string{
+	"text/html",
+	"text/plain",
+	"text/css",
+	"text/xml",
+	"text/javascript",
+	"application/javascript",
+	"application/json",
+	"application/xml",
+	"application/xhtml+xml",
+	"image/svg+xml",
+}
+
+// FilterConfigFactory shares the config with the filters created
+type FilterConfigFactory struct {
+	config *compressorConfig
+}
+
+func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	filter := newCompressorFilter(context, f.config)
+	callbacks.AddStreamReceiverFilter(filter, api.BeforeRoute)
+	callbacks.AddStreamSenderFilter(filter)
+}
+
+func CreateCompressorFilterFactory(conf map[string]interface{}) (api.StreamFilterChainFactory, error) {
+	log.DefaultLogger.Debugf("create compressor stream filter factory")
+	cfg, err := ParseCompressorFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+	config, err := newCompressorConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &FilterConfigFactory{config}, nil
+}
+
+// ParseCompressorFilter
+func ParseCompressorFilter(cfg map[string]interface{}) (*v2.CompressorConfig, error) {
+	filterConfig := &v2.CompressorConfig{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	return filterConfig, nil
+}
+
+// compressorConfig is the config compiled from v2.CompressorConfig
+type compressorConfig struct {
+	encodings            []string
+	gzipLevel            int
+	brotliQuality        int
+	minContentLength     int
+	contentTypes         map[string]struct{}
+	disableOnETag        bool
+	removeAcceptEncoding bool
+	decompressRequest    bool
+	maxDecompressedBytes int
+
+	compressed        metrics.Counter
+	notCompressed     metrics.Counter
+	uncompressedBytes metrics.Counter
+	compressedBytes   metrics.Counter
+	decompressed      metrics.Counter
+}
+
+func newCompressorConfig(cfg *v2.CompressorConfig) (*compressorConfig, error) {
+	c := &compressorConfig{
+		encodings:            cfg.Encodings,
+		gzipLevel:            cfg.GzipLevel,
+		brotliQuality:        cfg.BrotliQuality,
+		minContentLength:     cfg.MinContentLength,
+		contentTypes:         make(map[string]struct{}),
+		disableOnETag:        cfg.DisableOnETag,
+		removeAcceptEncoding: cfg.RemoveAcceptEncoding,
+		decompressRequest:    cfg.DecompressRequest,
+		maxDecompressedBytes: cfg.MaxDecompressedBytes,
+	}
+	if len(c.encodings) == 0 {
+		c.encodings = []string{v2.EncodingGzip}
+	}
+	for _, e := range c.encodings {
+		if e != v2.EncodingGzip && e != v2.EncodingBrotli {
+			return nil, fmt.Errorf("compressor encoding %s is not supported", e)
+		}
+	}
+	if c.gzipLevel == 0 {
+		c.gzipLevel = gzipDefaultLevel
+	} else if c.gzipLevel < gzipBestSpeed || c.gzipLevel > gzipBestCompression {
+		return nil, fmt.Errorf("invalid gzip level %d", c.gzipLevel)
+	}
+	if c.brotliQuality == 0 {
+		c.brotliQuality = brotli.DefaultCompression
+	} else if c.brotliQuality < brotli.BestSpeed || c.brotliQuality > brotli.BestCompression {
+		return nil, fmt.Errorf("invalid brotli quality %d", c.brotliQuality)
+	}
+	if c.minContentLength == 0 {
+		c.minContentLength = defaultMinContentLength
+	}
+	if c.maxDecompressedBytes == 0 {
+		c.maxDecompressedBytes = defaultMaxDecompressedBytes
+	}
+	contentTypes := cfg.ContentTypes
+	if len(contentTypes) == 0 {
+		contentTypes = defaultContentTypes
+	}
+	for _, t := range contentTypes {
+		c.contentTypes[strings.ToLower(t)] = struct{}{}
+	}
+
+	statPrefix := cfg.StatPrefix
+	if statPrefix == "" {
+		statPrefix = defaultStatPrefix
+	}
+	stats := mosnmetrics.NewCompressorStats(statPrefix)
+	c.compressed = stats.Counter(mosnmetrics.CompressorCompressed)
+	c.notCompressed = stats.Counter(mosnmetrics.CompressorNotCompressed)
+	c.uncompressedBytes = stats.Counter(mosnmetrics.CompressorUncompressedBytes)
+	c.compressedBytes = stats.Counter(mosnmetrics.CompressorCompressedBytes)
+	c.decompressed = stats.Counter(mosnmetrics.CompressorDecompressed)
+	return c, nil
+}
+
+// contentTypeAllowed returns true if the media type of the content type is compressed
+func (c *compressorConfig) contentTypeAllowed(contentType string) bool {
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	_, ok := c.contentTypes[strings.ToLower(strings.TrimSpace(contentType))]
+	return ok
+}
@@ -0,0 +1,241 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package compressor
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+const (
+	headerAcceptEncoding  = "Accept-Encoding"
+	headerContentEncoding = "Content-Encoding"
+	headerContentLength   = "Content-Length"
+	headerContentType     = "Content-Type"
+	headerCacheControl    = "Cache-Control"
+	headerETag            = "ETag"
+	headerVary            = "Vary"
+)
+
+// compressorFilter is an implement of api.StreamReceiverFilter and api.StreamSenderFilter,
+// the request body is decompressed in receiver and the response body is compressed in sender.
+// The response body sent in data frames is compressed by types.StreamSenderDataFilter
+type compressorFilter struct {
+	ctx            context.Context
+	config         *compressorConfig
+	receiveHandler api.StreamReceiverFilterHandler
+	sendHandler    api.StreamSenderFilterHandler
+	// encoding is the encoding negotiated with the accept-encoding of the request
+	encoding string
+	head     bool
+	// writer compresses the data frames of the response
+	writer compressWriter
+	out    *bytes.Buffer
+}
+
+func newCompressorFilter(ctx context.Context, config *compressorConfig) *compressorFilter {
+	return &compressorFilter{
+		ctx:    ctx,
+		config: config,
+	}
+}
+
+func (f *compressorFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.receiveHandler = handler
+}
+
+func (f *compressorFilter) SetSenderFilterHandler(handler api.StreamSenderFilterHandler) {
+	f.sendHandler = handler
+}
+
+func (f *compressorFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if method, _ := headers.Get(protocol.MosnHeaderMethod); method == http.MethodHead {
+		f.head = true
+	}
+	if accept, _ := headers.Get(headerAcceptEncoding); accept != "" {
+		f.encoding = chooseEncoding(accept, f.config.encodings)
+		if f.config.removeAcceptEncoding {
+			headers.Del(headerAcceptEncoding)
+		}
+	}
+	if f.config.decompressRequest && buf != nil && buf.Len() > 0 {
+		encoding, _ := headers.Get(headerContentEncoding)
+		if strings.EqualFold(strings.TrimSpace(encoding), v2.EncodingGzip) {
+			return f.decompressRequest(headers, buf)
+		}
+	}
+	return api.StreamFilterContinue
+}
+
+// decompressRequest replaces the gzip request body with the body decoded for the upstreams do not support it
+func (f *compressorFilter) decompressRequest(headers api.HeaderMap, buf buffer.IoBuffer) api.StreamFilterStatus {
+	data, err := gunzip(buf.Bytes(), f.config.maxDecompressedBytes)
+	if err != nil {
+		log.Proxy.Warnf(f.ctx, "[stream filter] [compressor] decompress request body failed: %v", err)
+		code := http.StatusBadRequest
+		if err == errBodyTooLarge {
+			code = http.StatusRequestEntityTooLarge
+		}
+		f.receiveHandler.SendHijackReply(code, headers)
+		return api.StreamFilterStop
+	}
+	f.receiveHandler.SetRequestData(buffer.NewIoBufferBytes(data))
+	headers.Del(headerContentEncoding)
+	if length, _ := headers.Get(headerContentLength); length != "" {
+		headers.Set(headerContentLength, strconv.Itoa(len(data)))
+	}
+	f.config.decompressed.Inc(1)
+	return api.StreamFilterContinue
+}
+
+func (f *compressorFilter) Append(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if !f.compressible(ctx, headers, buf) {
+		return api.StreamFilterContinue
+	}
+	// the response varies with the accept-encoding even if it is not compressed
+	addVary(headers)
+	if f.encoding == "" {
+		f.config.notCompressed.Inc(1)
+		return api.StreamFilterContinue
+	}
+	if etag, _ := headers.Get(headerETag); etag != "" {
+		if f.config.disableOnETag {
+			f.config.notCompressed.Inc(1)
+			return api.StreamFilterContinue
+		}
+		// the compressed body is not byte-for-byte identical to the original
+		if !strings.HasPrefix(etag, "W/") {
+			headers.Set(headerETag, "W/"+etag)
+		}
+	}
+
+	if buf == nil {
+		// the body is compressed in AppendData
+		headers.Set(headerContentEncoding, f.encoding)
+		headers.Del(headerContentLength)
+		f.out = &bytes.Buffer{}
+		f.writer = f.config.newWriter(f.encoding, f.out)
+		f.config.compressed.Inc(1)
+		return api.StreamFilterContinue
+	}
+
+	data, err := f.config.compress(f.encoding, buf.Bytes())
+	if err != nil {
+		log.Proxy.Errorf(f.ctx, "[stream filter] [compressor] compress response body failed: %v", err)
+		f.config.notCompressed.Inc(1)
+		return api.StreamFilterContinue
+	}
+	f.config.uncompressedBytes.Inc(int64(buf.Len()))
+	f.config.compressedBytes.Inc(int64(len(data)))
+	f.config.compressed.Inc(1)
+	headers.Set(headerContentEncoding, f.encoding)
+	if length, _ := headers.Get(headerContentLength); length != "" {
+		headers.Set(headerContentLength, strconv.Itoa(len(data)))
+	}
+	f.sendHandler.SetResponseData(buffer.NewIoBufferBytes(data))
+	return api.StreamFilterContinue
+}
+
+// compressible returns true if the response can be compressed
+func (f *compressorFilter) compressible(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer) bool {
+	if f.head {
+		return false
+	}
+	if code := f.sendHandler.RequestInfo().ResponseCode(); code < http.StatusOK ||
+		code == http.StatusNoContent || code == http.StatusNotModified {
+		return false
+	}
+	if encoding, _ := headers.Get(headerContentEncoding); encoding != "" && !strings.EqualFold(encoding, "identity") {
+		return false
+	}
+	if cacheControl, _ := headers.Get(headerCacheControl); strings.Contains(strings.ToLower(cacheControl), "no-transform") {
+		return false
+	}
+	contentType, _ := headers.Get(headerContentType)
+	if !f.config.contentTypeAllowed(contentType) {
+		return false
+	}
+	length := -1
+	if buf != nil {
+		length = buf.Len()
+	} else if value, _ := headers.Get(headerContentLength); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			length = n
+		}
+	} else if use, _ := mosnctx.Get(ctx, types.ContextKeyUseStream).(bool); !use {
+		// no body is sent
+		return false
+	}
+	// the length of the streamed body without content-length is unknown
+	return length < 0 || length >= f.config.minContentLength
+}
+
+// AppendData compresses a data frame of the response body
+func (f *compressorFilter) AppendData(ctx context.Context, data buffer.IoBuffer, endStream bool) buffer.IoBuffer {
+	if f.writer == nil {
+		return data
+	}
+	if data != nil && data.Len() > 0 {
+		f.config.uncompressedBytes.Inc(int64(data.Len()))
+		if _, err := f.writer.Write(data.Bytes()); err != nil {
+			log.Proxy.Errorf(f.ctx, "[stream filter] [compressor] compress response data failed: %v", err)
+		}
+	}
+	var err error
+	if endStream {
+		err = f.writer.Close()
+		f.writer = nil
+	} else {
+		err = f.writer.Flush()
+	}
+	if err != nil {
+		log.Proxy.Errorf(f.ctx, "[stream filter] [compressor] flush compressed response data failed: %v", err)
+	}
+	f.config.compressedBytes.Inc(int64(f.out.Len()))
+	out := buffer.NewIoBufferBytes(append([]byte(nil), f.out.Bytes()...))
+	f.out.Reset()
+	return out
+}
+
+func (f *compressorFilter) OnDestroy() {}
+
+// addVary adds the accept-encoding to the vary header
+func addVary(headers api.HeaderMap) {
+	vary, _ := headers.Get(headerVary)
+	if vary == "" {
+		headers.Set(headerVary, headerAcceptEncoding)
+		return
+	}
+	for _, v := range strings.Split(vary, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.EqualFold(v, headerAcceptEncoding) {
+			return
+		}
+	}
+	headers.Set(headerVary, vary+", "+headerAcceptEncoding)
+}
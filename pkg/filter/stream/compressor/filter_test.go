@@ -0,0 +1,281 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package compressor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+type mockReceiveHandler struct {
+	api.StreamReceiverFilterHandler
+	code int
+	data buffer.IoBuffer
+}
+
+func (h *mockReceiveHandler) SendHijackReply(code int, headers api.HeaderMap) {
+	h.code = code
+}
+
+func (h *mockReceiveHandler) SetRequestData(data buffer.IoBuffer) {
+	h.data = data
+}
+
+type mockSendHandler struct {
+	api.StreamSenderFilterHandler
+	info api.RequestInfo
+	data buffer.IoBuffer
+}
+
+func (h *mockSendHandler) RequestInfo() api.RequestInfo {
+	return h.info
+}
+
+func (h *mockSendHandler) SetResponseData(data buffer.IoBuffer) {
+	h.data = data
+}
+
+func newTestFilter(t *testing.T, cfg map[string]interface{}) (*compressorFilter, *mockReceiveHandler, *mockSendHandler) {
+	factory, err := CreateCompressorFilterFactory(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := newCompressorFilter(context.Background(), factory.(*FilterConfigFactory).config)
+	rh := &mockReceiveHandler{}
+	sh := &mockSendHandler{info: network.NewRequestInfo()}
+	sh.info.SetResponseCode(http.StatusOK)
+	f.SetReceiveFilterHandler(rh)
+	f.SetSenderFilterHandler(sh)
+	return f, rh, sh
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	out := &bytes.Buffer{}
+	w := gzip.NewWriter(out)
+	w.Write(data)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func decode(t *testing.T, encoding string, data []byte) string {
+	var out []byte
+	var err error
+	switch encoding {
+	case v2.EncodingGzip:
+		var r *gzip.Reader
+		if r, err = gzip.NewReader(bytes.NewReader(data)); err == nil {
+			out, err = ioutil.ReadAll(r)
+		}
+	case v2.EncodingBrotli:
+		out, err = ioutil.ReadAll(brotli.NewReader(bytes.NewReader(data)))
+	default:
+		t.Fatalf("unexpected encoding %s", encoding)
+	}
+	if err != nil {
+		t.Fatalf("decode %s failed: %v", encoding, err)
+	}
+	return string(out)
+}
+
+var testBody = strings.Repeat("hello mosn compressor ", 20)
+
+func TestCompressResponse(t *testing.T) {
+	for _, tc := range []struct {
+		accept   string
+		encoding string
+	}{
+		{"gzip", v2.EncodingGzip},
+		{"gzip;q=0.5, br", v2.EncodingBrotli},
+		{"*", v2.EncodingGzip},
+	} {
+		f, _, sh := newTestFilter(t, map[string]interface{}{
+			"encodings": []string{"gzip", "br"},
+		})
+		f.OnReceive(context.Background(), protocol.CommonHeader{"Accept-Encoding": tc.accept}, nil, nil)
+		headers := protocol.CommonHeader{
+			"Content-Type":   "text/plain; charset=utf-8",
+			"Content-Length": "440",
+			"ETag":           `"abc"`,
+		}
+		f.Append(context.Background(), headers, buffer.NewIoBufferString(testBody), nil)
+		if headers["Content-Encoding"] != tc.encoding {
+			t.Fatalf("accept %s: expected encoding %s, but got %s", tc.accept, tc.encoding, headers["Content-Encoding"])
+		}
+		if sh.data == nil || decode(t, tc.encoding, sh.data.Bytes()) != testBody {
+			t.Fatalf("accept %s: unexpected response body", tc.accept)
+		}
+		if headers["Content-Length"] != strconv.Itoa(sh.data.Len()) {
+			t.Fatalf("accept %s: unexpected content length %s", tc.accept, headers["Content-Length"])
+		}
+		if headers["Vary"] != "Accept-Encoding" || headers["ETag"] != `W/"abc"` {
+			t.Fatalf("accept %s: unexpected headers %v", tc.accept, headers)
+		}
+	}
+}
+
+func TestNotCompressed(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		method  string
+		accept  string
+		code    int
+		headers protocol.CommonHeader
+		body    string
+		vary    bool
+	}{
+		{"no accept encoding", "GET", "", 200, protocol.CommonHeader{"Content-Type": "text/html"}, testBody, true},
+		{"identity only", "GET", "identity, gzip;q=0", 200, protocol.CommonHeader{"Content-Type": "text/html"}, testBody, true},
+		{"head", "HEAD", "gzip", 200, protocol.CommonHeader{"Content-Type": "text/html"}, testBody, false},
+		{"not modified", "GET", "gzip", 304, protocol.CommonHeader{"Content-Type": "text/html"}, testBody, false},
+		{"content type", "GET", "gzip", 200, protocol.CommonHeader{"Content-Type": "image/png"}, testBody, false},
+		{"too small", "GET", "gzip", 200, protocol.CommonHeader{"Content-Type": "text/html"}, "small", false},
+		{"encoded", "GET", "gzip", 200, protocol.CommonHeader{"Content-Type": "text/html", "Content-Encoding": "br"}, testBody, false},
+		{"no transform", "GET", "gzip", 200, protocol.CommonHeader{"Content-Type": "text/html", "Cache-Control": "no-cache, no-transform"}, testBody, false},
+	} {
+		f, _, sh := newTestFilter(t, map[string]interface{}{})
+		sh.info.SetResponseCode(tc.code)
+		f.OnReceive(context.Background(), protocol.CommonHeader{
+			protocol.MosnHeaderMethod: tc.method,
+			"Accept-Encoding":         tc.accept,
+		}, nil, nil)
+		f.Append(context.Background(), tc.headers, buffer.NewIoBufferString(tc.body), nil)
+		if sh.data != nil || tc.headers["Content-Encoding"] == v2.EncodingGzip {
+			t.Fatalf("%s: expected not compressed", tc.name)
+		}
+		if _, ok := tc.headers["Vary"]; ok != tc.vary {
+			t.Fatalf("%s: unexpected vary %v", tc.name, tc.headers)
+		}
+	}
+}
+
+func TestDisableOnETag(t *testing.T) {
+	f, _, sh := newTestFilter(t, map[string]interface{}{
+		"disable_on_etag": true,
+	})
+	f.OnReceive(context.Background(), protocol.CommonHeader{"Accept-Encoding": "gzip"}, nil, nil)
+	headers := protocol.CommonHeader{
+		"Content-Type": "application/json",
+		"ETag":         `"abc"`,
+		"Vary":         "Origin",
+	}
+	f.Append(context.Background(), headers, buffer.NewIoBufferString(testBody), nil)
+	if sh.data != nil || headers["ETag"] != `"abc"` {
+		t.Fatalf("expected the response with etag is not compressed")
+	}
+	if headers["Vary"] != "Origin, Accept-Encoding" {
+		t.Fatalf("unexpected vary %s", headers["Vary"])
+	}
+}
+
+func TestCompressDataFrames(t *testing.T) {
+	f, _, _ := newTestFilter(t, map[string]interface{}{})
+	f.OnReceive(context.Background(), protocol.CommonHeader{"Accept-Encoding": "gzip"}, nil, nil)
+	ctx := mosnctx.WithValue(context.Background(), types.ContextKeyUseStream, true)
+	headers := protocol.CommonHeader{"Content-Type": "text/html"}
+	f.Append(ctx, headers, nil, nil)
+	if headers["Content-Encoding"] != v2.EncodingGzip {
+		t.Fatalf("expected the streamed response is compressed, headers: %v", headers)
+	}
+	compressed := &bytes.Buffer{}
+	for i, frame := range []string{testBody[:100], "", testBody[100:]} {
+		out := f.AppendData(ctx, buffer.NewIoBufferString(frame), i == 2)
+		if out == nil {
+			t.Fatal("expected the compressed data frame is not nil")
+		}
+		compressed.Write(out.Bytes())
+	}
+	if body := decode(t, v2.EncodingGzip, compressed.Bytes()); body != testBody {
+		t.Fatalf("unexpected body %s", body)
+	}
+
+	// the response without body is not compressed
+	f, _, _ = newTestFilter(t, map[string]interface{}{})
+	f.OnReceive(context.Background(), protocol.CommonHeader{"Accept-Encoding": "gzip"}, nil, nil)
+	headers = protocol.CommonHeader{"Content-Type": "text/html"}
+	f.Append(context.Background(), headers, nil, nil)
+	if _, ok := headers["Content-Encoding"]; ok {
+		t.Fatalf("expected the response without body is not compressed")
+	}
+}
+
+func TestDecompressRequest(t *testing.T) {
+	f, rh, _ := newTestFilter(t, map[string]interface{}{
+		"decompress_request": true,
+	})
+	data := gzipBytes(t, []byte(testBody))
+	headers := protocol.CommonHeader{
+		"Content-Encoding": "gzip",
+		"Content-Length":   strconv.Itoa(len(data)),
+	}
+	if status := f.OnReceive(context.Background(), headers, buffer.NewIoBufferBytes(data), nil); status != api.StreamFilterContinue {
+		t.Fatalf("unexpected status %v", status)
+	}
+	if rh.data == nil || rh.data.String() != testBody {
+		t.Fatal("expected the request body is decompressed")
+	}
+	if _, ok := headers["Content-Encoding"]; ok || headers["Content-Length"] != strconv.Itoa(len(testBody)) {
+		t.Fatalf("unexpected headers %v", headers)
+	}
+
+	// invalid gzip body
+	f, rh, _ = newTestFilter(t, map[string]interface{}{
+		"decompress_request": true,
+	})
+	headers = protocol.CommonHeader{"Content-Encoding": "gzip"}
+	if status := f.OnReceive(context.Background(), headers, buffer.NewIoBufferString(testBody), nil); status != api.StreamFilterStop || rh.code != http.StatusBadRequest {
+		t.Fatalf("expected bad request, but got %d", rh.code)
+	}
+
+	// the body decompressed is too large
+	f, rh, _ = newTestFilter(t, map[string]interface{}{
+		"decompress_request":     true,
+		"max_decompressed_bytes": 100,
+	})
+	headers = protocol.CommonHeader{"Content-Encoding": "gzip"}
+	if status := f.OnReceive(context.Background(), headers, buffer.NewIoBufferBytes(data), nil); status != api.StreamFilterStop || rh.code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected request entity too large, but got %d", rh.code)
+	}
+}
+
+func TestCreateCompressorFilterFactory(t *testing.T) {
+	for _, cfg := range []map[string]interface{}{
+		{"encodings": []string{"deflate"}},
+		{"gzip_level": 10},
+		{"brotli_quality": 12},
+	} {
+		if _, err := CreateCompressorFilterFactory(cfg); err == nil {
+			t.Fatalf("expected invalid config %v", cfg)
+		}
+	}
+}
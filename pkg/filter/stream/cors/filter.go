@@ -0,0 +1,156 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cors
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+const (
+	headerOrigin                     = "Origin"
+	headerVary                       = "Vary"
+	headerAccessControlRequestMethod = "Access-Control-Request-Method"
+	headerAccessControlAllowOrigin   = "Access-Control-Allow-Origin"
+	headerAccessControlAllowMethods  = "Access-Control-Allow-Methods"
+	headerAccessControlAllowHeaders  = "Access-Control-Allow-Headers"
+	headerAccessControlExposeHeaders = "Access-Control-Expose-Headers"
+	headerAccessControlMaxAge        = "Access-Control-Max-Age"
+	headerAccessControlAllowCreds    = "Access-Control-Allow-Credentials"
+)
+
+// corsFilter is an implement of api.StreamReceiverFilter and api.StreamSenderFilter,
+// the preflight requests are replied directly and the responses of the allowed origins are decorated
+type corsFilter struct {
+	ctx            context.Context
+	receiveHandler api.StreamReceiverFilterHandler
+	sendHandler    api.StreamSenderFilterHandler
+	// policy is the policy of the route, it is set if the origin is allowed
+	policy    types.CorsPolicy
+	origin    string
+	preflight bool
+}
+
+func newCorsFilter(ctx context.Context) *corsFilter {
+	return &corsFilter{
+		ctx: ctx,
+	}
+}
+
+func (f *corsFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.receiveHandler = handler
+}
+
+func (f *corsFilter) SetSenderFilterHandler(handler api.StreamSenderFilterHandler) {
+	f.sendHandler = handler
+}
+
+// routePolicy returns the cors policy of the route, nil if it is not configured
+func (f *corsFilter) routePolicy() types.CorsPolicy {
+	route := f.receiveHandler.Route()
+	if route == nil || route.RouteRule() == nil {
+		return nil
+	}
+	if rule, ok := route.RouteRule().(types.CorsRouteRule); ok {
+		return rule.CorsPolicy()
+	}
+	return nil
+}
+
+func (f *corsFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	origin, _ := headers.Get(headerOrigin)
+	if origin == "" {
+		return api.StreamFilterContinue
+	}
+	policy := f.routePolicy()
+	if policy == nil {
+		return api.StreamFilterContinue
+	}
+	if !policy.AllowOrigin(origin) {
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(f.ctx, "[stream filter] [cors] origin %s is not allowed", origin)
+		}
+		return api.StreamFilterContinue
+	}
+	f.policy = policy
+	f.origin = origin
+
+	method, _ := headers.Get(protocol.MosnHeaderMethod)
+	if requestMethod, _ := headers.Get(headerAccessControlRequestMethod); method != http.MethodOptions || requestMethod == "" {
+		return api.StreamFilterContinue
+	}
+	// the preflight request is not forwarded
+	f.preflight = true
+	respHeaders := protocol.CommonHeader{
+		types.HeaderStatus: strconv.Itoa(http.StatusOK),
+	}
+	f.setAllowOrigin(respHeaders)
+	if methods := policy.AllowMethods(); methods != "" {
+		respHeaders.Set(headerAccessControlAllowMethods, methods)
+	}
+	if allowHeaders := policy.AllowHeaders(); allowHeaders != "" {
+		respHeaders.Set(headerAccessControlAllowHeaders, allowHeaders)
+	}
+	if maxAge := policy.MaxAge(); maxAge != "" {
+		respHeaders.Set(headerAccessControlMaxAge, maxAge)
+	}
+	f.receiveHandler.RequestInfo().SetResponseCode(http.StatusOK)
+	f.receiveHandler.SendDirectResponse(respHeaders, nil, nil)
+	return api.StreamFilterStop
+}
+
+func (f *corsFilter) Append(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	// the preflight response is decorated already
+	if f.policy == nil || f.preflight {
+		return api.StreamFilterContinue
+	}
+	f.setAllowOrigin(headers)
+	if exposeHeaders := f.policy.ExposeHeaders(); exposeHeaders != "" {
+		headers.Set(headerAccessControlExposeHeaders, exposeHeaders)
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *corsFilter) OnDestroy() {}
+
+// setAllowOrigin sets the allowed origin, the response varies with the origin as it is echoed
+func (f *corsFilter) setAllowOrigin(headers api.HeaderMap) {
+	headers.Set(headerAccessControlAllowOrigin, f.origin)
+	if f.policy.AllowCredentials() {
+		headers.Set(headerAccessControlAllowCreds, "true")
+	}
+	vary, _ := headers.Get(headerVary)
+	if vary == "" {
+		headers.Set(headerVary, headerOrigin)
+		return
+	}
+	for _, v := range strings.Split(vary, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.EqualFold(v, headerOrigin) {
+			return
+		}
+	}
+	headers.Set(headerVary, vary+", "+headerOrigin)
+}
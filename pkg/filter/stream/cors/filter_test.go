@@ -0,0 +1,158 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cors
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/router"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+type mockHandler struct {
+	api.StreamReceiverFilterHandler
+	route   api.Route
+	info    api.RequestInfo
+	headers api.HeaderMap
+}
+
+func (h *mockHandler) Route() api.Route {
+	return h.route
+}
+
+func (h *mockHandler) RequestInfo() api.RequestInfo {
+	return h.info
+}
+
+func (h *mockHandler) SendDirectResponse(headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) {
+	h.headers = headers
+}
+
+func newTestFilter(t *testing.T, policy *v2.CorsPolicy) (*corsFilter, *mockHandler) {
+	r := v2.Router{}
+	r.Match = v2.RouterMatch{Prefix: "/"}
+	r.Route = v2.RouteAction{RouterActionConfig: v2.RouterActionConfig{ClusterName: "test"}}
+	vh, err := router.NewVirtualHostImpl(&v2.VirtualHost{
+		Name:    "test",
+		Domains: []string{"*"},
+		Routers: []v2.Router{r},
+		Cors:    policy,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := &mockHandler{
+		route: vh.GetRouteFromEntries(protocol.CommonHeader{protocol.MosnHeaderPathKey: "/"}, 1),
+		info:  network.NewRequestInfo(),
+	}
+	f := newCorsFilter(context.Background())
+	f.SetReceiveFilterHandler(handler)
+	return f, handler
+}
+
+var testPolicy = &v2.CorsPolicy{
+	AllowOrigins:     []string{"https://a.example.com"},
+	AllowMethods:     []string{"GET", "PUT"},
+	AllowHeaders:     []string{"x-token"},
+	ExposeHeaders:    []string{"x-request-id"},
+	AllowCredentials: true,
+}
+
+func TestPreflight(t *testing.T) {
+	f, handler := newTestFilter(t, testPolicy)
+	status := f.OnReceive(context.Background(), protocol.CommonHeader{
+		protocol.MosnHeaderMethod:       http.MethodOptions,
+		"Origin":                        "https://a.example.com",
+		"Access-Control-Request-Method": "PUT",
+	}, nil, nil)
+	if status != api.StreamFilterStop || handler.headers == nil {
+		t.Fatal("expected the preflight request is replied directly")
+	}
+	expected := map[string]string{
+		types.HeaderStatus:                 "200",
+		"Access-Control-Allow-Origin":      "https://a.example.com",
+		"Access-Control-Allow-Methods":     "GET,PUT",
+		"Access-Control-Allow-Headers":     "x-token",
+		"Access-Control-Allow-Credentials": "true",
+		"Vary":                             "Origin",
+	}
+	for k, v := range expected {
+		if value, _ := handler.headers.Get(k); value != v {
+			t.Errorf("header %s expected %s, but got %s", k, v, value)
+		}
+	}
+	if code := handler.info.ResponseCode(); code != http.StatusOK {
+		t.Errorf("expected response code 200, but got %d", code)
+	}
+	// the direct response is not decorated again
+	f.Append(context.Background(), handler.headers, nil, nil)
+	if value, _ := handler.headers.Get("Access-Control-Expose-Headers"); value != "" {
+		t.Errorf("unexpected expose headers in preflight response")
+	}
+}
+
+func TestActualRequest(t *testing.T) {
+	f, _ := newTestFilter(t, testPolicy)
+	status := f.OnReceive(context.Background(), protocol.CommonHeader{
+		protocol.MosnHeaderMethod: http.MethodGet,
+		"Origin":                  "https://a.example.com",
+	}, nil, nil)
+	if status != api.StreamFilterContinue {
+		t.Fatal("expected the actual request is forwarded")
+	}
+	headers := protocol.CommonHeader{"Vary": "Accept-Encoding"}
+	f.Append(context.Background(), headers, nil, nil)
+	if headers["Access-Control-Allow-Origin"] != "https://a.example.com" ||
+		headers["Access-Control-Expose-Headers"] != "x-request-id" ||
+		headers["Access-Control-Allow-Credentials"] != "true" ||
+		headers["Vary"] != "Accept-Encoding, Origin" {
+		t.Fatalf("unexpected response headers %v", headers)
+	}
+}
+
+func TestNotAllowed(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		policy  *v2.CorsPolicy
+		headers protocol.CommonHeader
+	}{
+		{"no origin", testPolicy, protocol.CommonHeader{protocol.MosnHeaderMethod: http.MethodGet}},
+		{"origin not allowed", testPolicy, protocol.CommonHeader{
+			protocol.MosnHeaderMethod:       http.MethodOptions,
+			"Origin":                        "https://b.example.com",
+			"Access-Control-Request-Method": "PUT",
+		}},
+		{"no policy", nil, protocol.CommonHeader{protocol.MosnHeaderMethod: http.MethodGet, "Origin": "https://a.example.com"}},
+	} {
+		f, handler := newTestFilter(t, tc.policy)
+		if status := f.OnReceive(context.Background(), tc.headers, nil, nil); status != api.StreamFilterContinue || handler.headers != nil {
+			t.Fatalf("%s: expected the request is forwarded", tc.name)
+		}
+		headers := protocol.CommonHeader{}
+		f.Append(context.Background(), headers, nil, nil)
+		if len(headers) != 0 {
+			t.Fatalf("%s: unexpected response headers %v", tc.name, headers)
+		}
+	}
+}
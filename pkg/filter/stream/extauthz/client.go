@@ -0,0 +1,226 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package extauthz
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"mosn.io/api"
+	mbuffer "mosn.io/mosn/pkg/buffer"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/buffer"
+)
+
+var (
+	errUnknownCluster    = errors.New("unknown cluster")
+	errNoHealthyUpstream = errors.New("no healthy upstream")
+	errCheckTimeout      = errors.New("check request timeout")
+	errCheckReset        = errors.New("check request reset")
+	errCheckCanceled     = errors.New("check request canceled")
+)
+
+// checkStatus is the decision of the authorization service
+type checkStatus int
+
+const (
+	checkOK checkStatus = iota
+	checkDenied
+)
+
+// checkResponse is the response of the authorization service
+type checkResponse struct {
+	status checkStatus
+	// httpStatus is the status code replied to the client if the request is denied
+	httpStatus int
+	// headers are set to the request if it is allowed, or replied to the client if it is denied
+	headers []headerValue
+}
+
+type headerValue struct {
+	key    string
+	value  string
+	append bool
+}
+
+// checkService encodes the check request and decodes the response of the authorization service protocol
+type checkService interface {
+	// protocol returns the protocol of the stream to the cluster
+	protocol(info types.ClusterInfo) types.ProtocolName
+	encode(req *checkRequest, proto types.ProtocolName) (api.HeaderMap, buffer.IoBuffer)
+	decode(headers api.HeaderMap, data buffer.IoBuffer, trailers api.HeaderMap) (*checkResponse, error)
+}
+
+// checkClient sends the check requests to the cluster with the upstream connection pools
+type checkClient struct {
+	cluster        string
+	timeout        time.Duration
+	service        checkService
+	clusterManager types.ClusterManager
+}
+
+func newCheckClient(clusterName string, timeout time.Duration, service checkService) *checkClient {
+	return &checkClient{
+		cluster:        clusterName,
+		timeout:        timeout,
+		service:        service,
+		clusterManager: cluster.GetClusterMngAdapterInstance(),
+	}
+}
+
+// check sends the request and waits for the response until timeout, or the stop channel is closed
+func (c *checkClient) check(ctx context.Context, conn api.Connection, req *checkRequest, stop <-chan struct{}) (*checkResponse, error) {
+	snapshot := c.clusterManager.GetClusterSnapshot(ctx, c.cluster)
+	if snapshot == nil {
+		return nil, errUnknownCluster
+	}
+	proto := c.service.protocol(snapshot.ClusterInfo())
+	lbCtx := &lbContext{
+		ctx:     ctx,
+		conn:    conn,
+		cluster: snapshot.ClusterInfo(),
+	}
+	pool, _ := c.clusterManager.ConnPoolForCluster(lbCtx, snapshot, proto)
+	if pool == nil {
+		return nil, errNoHealthyUpstream
+	}
+	headers, data := c.service.encode(req, proto)
+	s := &checkStream{
+		// the response is received with a buffer pool context of its own
+		ctx:     mbuffer.NewBufferPoolContext(mosnctx.Clone(ctx)),
+		headers: headers,
+		data:    data,
+		result:  make(chan checkResult, 1),
+	}
+	pool.NewStream(s.ctx, s, s)
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	select {
+	case r := <-s.result:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return c.service.decode(r.headers, r.data, r.trailers)
+	case <-timer.C:
+		s.reset()
+		return nil, errCheckTimeout
+	case <-stop:
+		s.reset()
+		return nil, errCheckCanceled
+	}
+}
+
+type checkResult struct {
+	headers  api.HeaderMap
+	data     buffer.IoBuffer
+	trailers api.HeaderMap
+	err      error
+}
+
+// checkStream is the stream of a check request, the result is sent to the channel once
+type checkStream struct {
+	ctx     context.Context
+	headers api.HeaderMap
+	data    buffer.IoBuffer
+	sender  types.StreamSender
+	result  chan checkResult
+}
+
+func (s *checkStream) done(r checkResult) {
+	select {
+	case s.result <- r:
+	default:
+	}
+}
+
+func (s *checkStream) reset() {
+	// the sender is set in OnReady called by the NewStream synchronously
+	if s.sender != nil {
+		s.sender.GetStream().ResetStream(types.StreamLocalReset)
+	}
+}
+
+// types.PoolEventListener
+func (s *checkStream) OnFailure(reason types.PoolFailureReason, host types.Host) {
+	s.done(checkResult{err: errNoHealthyUpstream})
+}
+
+func (s *checkStream) OnReady(sender types.StreamSender, host types.Host) {
+	s.sender = sender
+	sender.GetStream().AddEventListener(s)
+	endStream := s.data == nil
+	sender.AppendHeaders(s.ctx, s.headers, endStream)
+	if !endStream {
+		sender.AppendData(s.ctx, s.data, true)
+	}
+}
+
+// types.StreamReceiveListener
+func (s *checkStream) OnReceive(ctx context.Context, headers api.HeaderMap, data buffer.IoBuffer, trailers api.HeaderMap) {
+	s.done(checkResult{
+		headers:  headers,
+		data:     data,
+		trailers: trailers,
+	})
+}
+
+func (s *checkStream) OnDecodeError(ctx context.Context, err error, headers api.HeaderMap) {
+	s.done(checkResult{err: err})
+}
+
+// types.StreamEventListener
+func (s *checkStream) OnResetStream(reason types.StreamResetReason) {
+	s.done(checkResult{err: errCheckReset})
+}
+
+func (s *checkStream) OnDestroyStream() {}
+
+// lbContext is a types.LoadBalancerContext implementation for choosing the host of the authorization service
+type lbContext struct {
+	ctx     context.Context
+	conn    api.Connection
+	cluster types.ClusterInfo
+}
+
+func (c *lbContext) MetadataMatchCriteria() api.MetadataMatchCriteria {
+	return nil
+}
+
+func (c *lbContext) DownstreamConnection() net.Conn {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.RawConn()
+}
+
+// the headers of the downstream request are not used for the authorization service
+func (c *lbContext) DownstreamHeaders() api.HeaderMap {
+	return nil
+}
+
+func (c *lbContext) DownstreamContext() context.Context {
+	return c.ctx
+}
+
+func (c *lbContext) DownstreamCluster() types.ClusterInfo {
+	return c.cluster
+}
@@ -0,0 +1,178 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package extauthz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	mosnmetrics "mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/router"
+)
+
+func init() {
+	api.RegisterStream(v2.ExtAuthz, CreateExtAuthzFilterFactory)
+	// the per route config is parsed once when the route is built
+	router.RegisterPerFilterConfigParser(v2.ExtAuthz, func(cfg interface{}) (interface{}, error) {
+		return parsePerRouteConfig(cfg)
+	})
+}
+
+const (
+	defaultTimeout    = 200 * time.Millisecond
+	defaultStatPrefix = "ext_authz"
+)
+
+// FilterConfigFactory shares the config and the check client with the filters created
+type FilterConfigFactory struct {
+	config *extAuthzConfig
+}
+
+func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	filter := newExtAuthzFilter(context, f.config)
+	// the route is required for the per route config
+	callbacks.AddStreamReceiverFilter(filter, api.AfterRoute)
+}
+
+func CreateExtAuthzFilterFactory(conf map[string]interface{}) (api.StreamFilterChainFactory, error) {
+	log.DefaultLogger.Debugf("create ext_authz stream filter factory")
+	cfg, err := ParseExtAuthzFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+	config, err := newExtAuthzConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &FilterConfigFactory{config}, nil
+}
+
+// ParseExtAuthzFilter
+func ParseExtAuthzFilter(cfg map[string]interface{}) (*v2.ExtAuthzConfig, error) {
+	filterConfig := &v2.ExtAuthzConfig{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	return filterConfig, nil
+}
+
+// parsePerRouteConfig parses the config in the PerFilterConfig of routers
+func parsePerRouteConfig(cfg interface{}) (*v2.ExtAuthzPerRoute, error) {
+	perRoute := &v2.ExtAuthzPerRoute{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, perRoute); err != nil {
+		return nil, err
+	}
+	return perRoute, nil
+}
+
+// extAuthzConfig is the config compiled from v2.ExtAuthzConfig
+type extAuthzConfig struct {
+	client                 *checkClient
+	failureModeAllow       bool
+	statusOnError          int
+	allowedHeaders         map[string]struct{}
+	allowAllHeaders        bool
+	withBody               bool
+	maxRequestBytes        uint32
+	includePeerCertificate bool
+
+	ok                 metrics.Counter
+	denied             metrics.Counter
+	errors             metrics.Counter
+	failureModeAllowed metrics.Counter
+	disabled           metrics.Counter
+}
+
+func newExtAuthzConfig(cfg *v2.ExtAuthzConfig) (*extAuthzConfig, error) {
+	if cfg.Cluster == "" {
+		return nil, errors.New("ext_authz cluster is required")
+	}
+	c := &extAuthzConfig{
+		failureModeAllow:       cfg.FailureModeAllow,
+		statusOnError:          cfg.StatusOnError,
+		allowedHeaders:         make(map[string]struct{}),
+		includePeerCertificate: cfg.IncludePeerCertificate,
+	}
+	if c.statusOnError == 0 {
+		c.statusOnError = http.StatusForbidden
+	}
+	for _, h := range cfg.AllowedHeaders {
+		c.allowedHeaders[strings.ToLower(h)] = struct{}{}
+	}
+	if cfg.WithRequestBody != nil {
+		c.withBody = true
+		c.maxRequestBytes = cfg.WithRequestBody.MaxRequestBytes
+	}
+	var service checkService
+	switch cfg.Protocol {
+	case v2.ExtAuthzHTTP, "":
+		// the authorization header is always sent
+		c.allowedHeaders["authorization"] = struct{}{}
+		service = &httpService{
+			pathPrefix:      cfg.PathPrefix,
+			upstreamHeaders: cfg.AllowedUpstreamHeaders,
+			clientHeaders:   cfg.AllowedClientHeaders,
+		}
+	case v2.ExtAuthzGRPC:
+		c.allowAllHeaders = len(c.allowedHeaders) == 0
+		service = &grpcService{}
+	default:
+		return nil, fmt.Errorf("ext_authz protocol %s is not supported", cfg.Protocol)
+	}
+	timeout := cfg.Timeout.Duration
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	c.client = newCheckClient(cfg.Cluster, timeout, service)
+
+	statPrefix := cfg.StatPrefix
+	if statPrefix == "" {
+		statPrefix = defaultStatPrefix
+	}
+	stats := mosnmetrics.NewExtAuthzStats(statPrefix)
+	c.ok = stats.Counter(mosnmetrics.ExtAuthzOK)
+	c.denied = stats.Counter(mosnmetrics.ExtAuthzDenied)
+	c.errors = stats.Counter(mosnmetrics.ExtAuthzError)
+	c.failureModeAllowed = stats.Counter(mosnmetrics.ExtAuthzFailureModeAllowed)
+	c.disabled = stats.Counter(mosnmetrics.ExtAuthzDisabled)
+	return c, nil
+}
+
+func (c *extAuthzConfig) allowHeader(key string) bool {
+	if c.allowAllHeaders {
+		return true
+	}
+	_, ok := c.allowedHeaders[key]
+	return ok
+}
@@ -0,0 +1,117 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package extauthz
+
+import (
+	"context"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/pkg/buffer"
+)
+
+// extAuthzFilter is an implement of api.StreamReceiverFilter, the request is blocked until the authorization service replies
+type extAuthzFilter struct {
+	ctx     context.Context
+	handler api.StreamReceiverFilterHandler
+	config  *extAuthzConfig
+	stop    chan struct{}
+}
+
+func newExtAuthzFilter(ctx context.Context, config *extAuthzConfig) *extAuthzFilter {
+	return &extAuthzFilter{
+		ctx:    ctx,
+		config: config,
+		stop:   make(chan struct{}),
+	}
+}
+
+func (f *extAuthzFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.handler = handler
+}
+
+// readPerRouteConfig returns the config of the route, nil if it is not configured
+func (f *extAuthzFilter) readPerRouteConfig() *v2.ExtAuthzPerRoute {
+	route := f.handler.Route()
+	if route == nil || route.RouteRule() == nil {
+		return nil
+	}
+	cfg, ok := route.RouteRule().PerFilterConfig()[v2.ExtAuthz]
+	if !ok {
+		return nil
+	}
+	// the config is parsed when the route is built
+	if perRoute, ok := cfg.(*v2.ExtAuthzPerRoute); ok {
+		return perRoute
+	}
+	perRoute, err := parsePerRouteConfig(cfg)
+	if err != nil {
+		log.Proxy.Errorf(f.ctx, "[stream filter] [ext_authz] invalid per route config: %v", err)
+		return nil
+	}
+	return perRoute
+}
+
+func (f *extAuthzFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	req := f.config.newCheckRequest(f.handler.Connection(), f.handler.RequestInfo(), headers, buf)
+	if perRoute := f.readPerRouteConfig(); perRoute != nil {
+		if perRoute.Disabled {
+			f.config.disabled.Inc(1)
+			return api.StreamFilterContinue
+		}
+		req.contextExtensions = perRoute.ContextExtensions
+	}
+	resp, err := f.config.client.check(ctx, f.handler.Connection(), req, f.stop)
+	if err == errCheckCanceled {
+		return api.StreamFilterStop
+	}
+	if err != nil {
+		f.config.errors.Inc(1)
+		log.Proxy.Errorf(f.ctx, "[stream filter] [ext_authz] check request failed: %v", err)
+		if f.config.failureModeAllow {
+			f.config.failureModeAllowed.Inc(1)
+			return api.StreamFilterContinue
+		}
+		f.handler.SendHijackReply(f.config.statusOnError, headers)
+		return api.StreamFilterStop
+	}
+	// the headers are set to the request if it is allowed,
+	// or set to the headers of the direct response if it is denied
+	for _, h := range resp.headers {
+		// not all the header maps support Add, the values appended are joined by comma
+		if old, ok := headers.Get(h.key); ok && old != "" && h.append {
+			headers.Set(h.key, old+","+h.value)
+		} else {
+			headers.Set(h.key, h.value)
+		}
+	}
+	if resp.status == checkOK {
+		f.config.ok.Inc(1)
+		return api.StreamFilterContinue
+	}
+	f.config.denied.Inc(1)
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(f.ctx, "[stream filter] [ext_authz] request denied with status %d", resp.httpStatus)
+	}
+	f.handler.SendHijackReply(resp.httpStatus, headers)
+	return api.StreamFilterStop
+}
+
+func (f *extAuthzFilter) OnDestroy() {
+	close(f.stop)
+}
@@ -0,0 +1,290 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package extauthz
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/router"
+	_ "mosn.io/mosn/pkg/stream/http"
+	_ "mosn.io/mosn/pkg/stream/http2"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/buffer"
+)
+
+type mockHandler struct {
+	api.StreamReceiverFilterHandler
+	route   *mockRoute
+	code    int
+	headers api.HeaderMap
+}
+
+func (h *mockHandler) Route() api.Route {
+	if h.route == nil {
+		return nil
+	}
+	return h.route
+}
+
+func (h *mockHandler) Connection() api.Connection {
+	return nil
+}
+
+func (h *mockHandler) RequestInfo() api.RequestInfo {
+	return network.NewRequestInfo()
+}
+
+func (h *mockHandler) SendHijackReply(code int, headers api.HeaderMap) {
+	h.code = code
+	h.headers = headers
+}
+
+type mockRoute struct {
+	api.Route
+	config map[string]interface{}
+}
+
+func (r *mockRoute) RouteRule() api.RouteRule {
+	return &mockRouteRule{config: r.config}
+}
+
+type mockRouteRule struct {
+	api.RouteRule
+	config map[string]interface{}
+}
+
+func (r *mockRouteRule) PerFilterConfig() map[string]interface{} {
+	return r.config
+}
+
+// addCluster adds a cluster with the host to the cluster manager
+func addCluster(t *testing.T, name string, addr string) {
+	cluster.NewClusterManagerSingleton(nil, nil)
+	if err := cluster.GetClusterMngAdapterInstance().TriggerClusterAndHostsAddOrUpdate(v2.Cluster{
+		Name:                 name,
+		ClusterType:          v2.SIMPLE_CLUSTER,
+		LbType:               v2.LB_RANDOM,
+		MaxRequestPerConn:    1024,
+		ConnBufferLimitBytes: 16 * 1024,
+	}, []v2.Host{
+		{HostConfig: v2.HostConfig{Address: addr}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runFilter(t *testing.T, cfg *v2.ExtAuthzConfig, route *mockRoute, headers api.HeaderMap, body string) (*mockHandler, api.StreamFilterStatus) {
+	config, err := newExtAuthzConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := &mockHandler{route: route}
+	f := newExtAuthzFilter(context.Background(), config)
+	f.SetReceiveFilterHandler(handler)
+	defer f.OnDestroy()
+	var buf buffer.IoBuffer
+	if body != "" {
+		buf = buffer.NewIoBufferString(body)
+	}
+	return handler, f.OnReceive(context.Background(), headers, buf, nil)
+}
+
+func TestFilterHTTP(t *testing.T) {
+	received := make(chan *http.Request, 10)
+	bodies := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- r
+		bodies <- string(body)
+		switch r.Header.Get("Authorization") {
+		case "Bearer ok":
+			w.Header().Set("x-auth-user", "alice")
+			w.Header().Set("x-auth-internal", "secret")
+		case "Bearer slow":
+			time.Sleep(300 * time.Millisecond)
+		default:
+			w.Header().Set("www-authenticate", "Bearer")
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+	addCluster(t, "ext_authz_http", strings.TrimPrefix(srv.URL, "http://"))
+
+	cfg := &v2.ExtAuthzConfig{
+		Cluster:                "ext_authz_http",
+		PathPrefix:             "/authz",
+		Timeout:                api.DurationConfig{Duration: 100 * time.Millisecond},
+		AllowedHeaders:         []string{"x-request-id"},
+		AllowedUpstreamHeaders: []string{"x-auth-user"},
+		AllowedClientHeaders:   []string{"www-authenticate"},
+		WithRequestBody:        &v2.ExtAuthzBufferSettings{MaxRequestBytes: 4},
+	}
+	route := &mockRoute{config: map[string]interface{}{
+		v2.ExtAuthz: map[string]interface{}{
+			"context_extensions": map[string]interface{}{"tenant": "mosn"},
+		},
+	}}
+	newHeaders := func(authorization string) protocol.CommonHeader {
+		return protocol.CommonHeader{
+			protocol.MosnHeaderMethod:         http.MethodPost,
+			protocol.MosnHeaderPathKey:        "/api",
+			protocol.MosnHeaderQueryStringKey: "a=b",
+			protocol.MosnHeaderHostKey:        "mosn.io",
+			"authorization":                   authorization,
+			"x-request-id":                    "1",
+			"cookie":                          "session",
+		}
+	}
+
+	// allowed, the upstream headers are set to the request
+	headers := newHeaders("Bearer ok")
+	handler, status := runFilter(t, cfg, route, headers, "abcdefg")
+	if status != api.StreamFilterContinue || handler.code != 0 {
+		t.Fatalf("expected the request is allowed, but got %v %d", status, handler.code)
+	}
+	if user, _ := headers.Get("x-auth-user"); user != "alice" {
+		t.Errorf("unexpected upstream header: %s", user)
+	}
+	if _, ok := headers.Get("x-auth-internal"); ok {
+		t.Error("expected the header not allowed is not set")
+	}
+	r := <-received
+	if r.Method != http.MethodPost || r.URL.Path != "/authz/api" || r.URL.RawQuery != "a=b" || r.Host != "mosn.io" {
+		t.Errorf("unexpected check request: %s %s %s", r.Method, r.URL, r.Host)
+	}
+	if r.Header.Get("x-request-id") != "1" || r.Header.Get("cookie") != "" {
+		t.Errorf("unexpected check request headers: %v", r.Header)
+	}
+	if tenant := r.Header.Get(HeaderContextPrefix + "tenant"); tenant != "mosn" {
+		t.Errorf("unexpected context extension: %s", tenant)
+	}
+	if body := <-bodies; body != "abcd" {
+		t.Errorf("expected the body prefix is sent, but got %s", body)
+	}
+
+	// denied with the status and the client headers
+	handler, status = runFilter(t, cfg, route, newHeaders("Bearer invalid"), "")
+	if status != api.StreamFilterStop || handler.code != http.StatusUnauthorized {
+		t.Fatalf("expected the request is denied, but got %v %d", status, handler.code)
+	}
+	if challenge, _ := handler.headers.Get("www-authenticate"); challenge != "Bearer" {
+		t.Errorf("unexpected client header: %s", challenge)
+	}
+
+	// disabled by the route
+	disabled := &mockRoute{config: map[string]interface{}{
+		v2.ExtAuthz: map[string]interface{}{"disabled": true},
+	}}
+	if handler, status := runFilter(t, cfg, disabled, newHeaders("Bearer invalid"), ""); status != api.StreamFilterContinue || handler.code != 0 {
+		t.Fatalf("expected the check is disabled, but got %v %d", status, handler.code)
+	}
+}
+
+func TestPerRouteConfigParsedByRouter(t *testing.T) {
+	route := &v2.Router{}
+	route.Route.ClusterName = "test"
+	route.PerFilterConfig = map[string]interface{}{
+		v2.ExtAuthz: map[string]interface{}{"disabled": true},
+	}
+	rule, err := router.NewRouteRuleImplBase(nil, route)
+	if err != nil {
+		t.Fatal(err)
+	}
+	perRoute, ok := rule.PerFilterConfig()[v2.ExtAuthz].(*v2.ExtAuthzPerRoute)
+	if !ok || !perRoute.Disabled {
+		t.Fatalf("expected the per route config is parsed, but got %v", rule.PerFilterConfig()[v2.ExtAuthz])
+	}
+	// the route config is not changed
+	if _, ok := route.PerFilterConfig[v2.ExtAuthz].(map[string]interface{}); !ok {
+		t.Error("expected the raw per route config is kept")
+	}
+	// the route with an invalid per route config is rejected
+	route.PerFilterConfig = map[string]interface{}{
+		v2.ExtAuthz: map[string]interface{}{"disabled": "invalid"},
+	}
+	if _, err := router.NewRouteRuleImplBase(nil, route); err == nil {
+		t.Error("expected the invalid per route config is rejected")
+	}
+}
+
+func TestFilterFailureMode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+	}))
+	defer srv.Close()
+	addCluster(t, "ext_authz_slow", strings.TrimPrefix(srv.URL, "http://"))
+
+	cfg := &v2.ExtAuthzConfig{
+		Cluster:       "ext_authz_slow",
+		Timeout:       api.DurationConfig{Duration: 50 * time.Millisecond},
+		StatusOnError: http.StatusServiceUnavailable,
+	}
+	headers := protocol.CommonHeader{protocol.MosnHeaderPathKey: "/"}
+	// fail closed
+	handler, status := runFilter(t, cfg, nil, headers, "")
+	if status != api.StreamFilterStop || handler.code != http.StatusServiceUnavailable {
+		t.Fatalf("expected the request is rejected on timeout, but got %v %d", status, handler.code)
+	}
+	// fail open
+	cfg.FailureModeAllow = true
+	if handler, status := runFilter(t, cfg, nil, headers, ""); status != api.StreamFilterContinue || handler.code != 0 {
+		t.Fatalf("expected the request is allowed on timeout, but got %v %d", status, handler.code)
+	}
+	// the cluster is not found
+	cfg = &v2.ExtAuthzConfig{Cluster: "ext_authz_unknown"}
+	if handler, status := runFilter(t, cfg, nil, headers, ""); status != api.StreamFilterStop || handler.code != http.StatusForbidden {
+		t.Fatalf("expected the request is rejected on unknown cluster, but got %v %d", status, handler.code)
+	}
+}
+
+func TestCreateExtAuthzFilterFactory(t *testing.T) {
+	if _, err := CreateExtAuthzFilterFactory(map[string]interface{}{}); err == nil {
+		t.Error("expected the config without cluster is invalid")
+	}
+	if _, err := CreateExtAuthzFilterFactory(map[string]interface{}{
+		"cluster":  "authz",
+		"protocol": "thrift",
+	}); err == nil {
+		t.Error("expected the unknown protocol is invalid")
+	}
+	f, err := CreateExtAuthzFilterFactory(map[string]interface{}{
+		"cluster":         "authz",
+		"protocol":        "grpc",
+		"timeout":         "1s",
+		"allowed_headers": []interface{}{"X-Request-Id"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := f.(*FilterConfigFactory).config
+	if config.client.timeout != time.Second || config.statusOnError != http.StatusForbidden {
+		t.Errorf("unexpected config: %+v", config)
+	}
+	if !config.allowHeader("x-request-id") || config.allowHeader("authorization") {
+		t.Error("unexpected allowed headers")
+	}
+}
@@ -0,0 +1,207 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package extauthz
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// grpcCheckPath is the method of the envoy.service.auth.v2 authorization service
+const grpcCheckPath = "/envoy.service.auth.v2.Authorization/Check"
+
+var errGRPCMalformed = errors.New("malformed grpc message")
+
+// grpcService sends the envoy.service.auth.v2.CheckRequest over http2
+type grpcService struct{}
+
+func (s *grpcService) protocol(info types.ClusterInfo) types.ProtocolName {
+	return protocol.HTTP2
+}
+
+func (s *grpcService) encode(req *checkRequest, proto types.ProtocolName) (api.HeaderMap, buffer.IoBuffer) {
+	headers := protocol.CommonHeader{
+		protocol.MosnHeaderMethod:  http.MethodPost,
+		protocol.MosnHeaderPathKey: grpcCheckPath,
+		"content-type":             "application/grpc",
+		"te":                       "trailers",
+	}
+	msg := encodeCheckRequest(req)
+	// the message is not compressed
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	return headers, buffer.NewIoBufferBytes(append(frame, msg...))
+}
+
+func (s *grpcService) decode(headers api.HeaderMap, data buffer.IoBuffer, trailers api.HeaderMap) (*checkResponse, error) {
+	if status, _ := headers.Get(types.HeaderStatus); status != "200" {
+		return nil, fmt.Errorf("unexpected http status %s", status)
+	}
+	// the status is in the headers if the response is trailers only
+	grpcStatus, _ := headers.Get("grpc-status")
+	if grpcStatus == "" && trailers != nil {
+		grpcStatus, _ = trailers.Get("grpc-status")
+	}
+	if grpcStatus != "0" {
+		return nil, fmt.Errorf("unexpected grpc status %s", grpcStatus)
+	}
+	if data == nil {
+		return nil, errGRPCMalformed
+	}
+	frame := data.Bytes()
+	if len(frame) < 5 || frame[0] != 0 {
+		return nil, errGRPCMalformed
+	}
+	size := binary.BigEndian.Uint32(frame[1:5])
+	if uint64(size) > uint64(len(frame)-5) {
+		return nil, errGRPCMalformed
+	}
+	return decodeCheckResponse(frame[5 : 5+size])
+}
+
+// encodeCheckRequest encodes the envoy.service.auth.v2.CheckRequest
+func encodeCheckRequest(req *checkRequest) []byte {
+	// AttributeContext.HttpRequest
+	httpReq := &pbEncoder{}
+	httpReq.string(2, req.method)
+	httpReq.stringMap(3, req.headers)
+	httpReq.string(4, req.path)
+	httpReq.string(5, req.host)
+	httpReq.string(6, req.scheme)
+	httpReq.string(7, req.query)
+	httpReq.varint(9, uint64(req.size))
+	httpReq.string(10, req.protocol)
+	httpReq.bytes(11, req.body)
+	// google.protobuf.Timestamp
+	ts := &pbEncoder{}
+	ts.varint(1, uint64(req.time.Unix()))
+	ts.varint(2, uint64(req.time.Nanosecond()))
+	// AttributeContext.Request
+	request := &pbEncoder{}
+	request.message(1, ts.buf)
+	request.message(2, httpReq.buf)
+	// AttributeContext
+	attrs := &pbEncoder{}
+	attrs.message(1, encodePeer(req.source))
+	attrs.message(2, encodePeer(req.destination))
+	attrs.message(4, request.buf)
+	attrs.stringMap(10, req.contextExtensions)
+	// CheckRequest
+	check := &pbEncoder{}
+	check.message(1, attrs.buf)
+	return check.buf
+}
+
+// encodePeer encodes the AttributeContext.Peer
+func encodePeer(p peer) []byte {
+	// envoy.api.v2.core.SocketAddress
+	sock := &pbEncoder{}
+	sock.string(2, p.address)
+	sock.varint(3, uint64(p.port))
+	// envoy.api.v2.core.Address
+	addr := &pbEncoder{}
+	addr.message(1, sock.buf)
+	e := &pbEncoder{}
+	e.message(1, addr.buf)
+	e.string(4, p.principal)
+	e.string(5, p.certificate)
+	return e.buf
+}
+
+// decodeCheckResponse decodes the envoy.service.auth.v2.CheckResponse,
+// the request is denied with 403 if the denied response has no status
+func decodeCheckResponse(b []byte) (*checkResponse, error) {
+	var code uint64
+	var httpResponse []byte
+	if err := pbRange(b, func(field int, v uint64, b []byte) error {
+		switch field {
+		case 1: // google.rpc.Status
+			return pbRange(b, func(field int, v uint64, b []byte) error {
+				if field == 1 {
+					code = v
+				}
+				return nil
+			})
+		case 2, 3: // DeniedHttpResponse, OkHttpResponse
+			httpResponse = b
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	resp := &checkResponse{status: checkOK}
+	if code != 0 {
+		resp.status = checkDenied
+		resp.httpStatus = http.StatusForbidden
+	}
+	if err := pbRange(httpResponse, func(field int, v uint64, b []byte) error {
+		switch field {
+		case 1: // DeniedHttpResponse.status, envoy.type.HttpStatus
+			return pbRange(b, func(field int, v uint64, b []byte) error {
+				if field == 1 && v != 0 && resp.status == checkDenied {
+					resp.httpStatus = int(v)
+				}
+				return nil
+			})
+		case 2: // headers
+			h, err := decodeHeaderValueOption(b)
+			if err != nil {
+				return err
+			}
+			resp.headers = append(resp.headers, h)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// decodeHeaderValueOption decodes the envoy.api.v2.core.HeaderValueOption
+func decodeHeaderValueOption(b []byte) (headerValue, error) {
+	h := headerValue{}
+	err := pbRange(b, func(field int, v uint64, b []byte) error {
+		switch field {
+		case 1: // HeaderValue
+			return pbRange(b, func(field int, v uint64, b []byte) error {
+				switch field {
+				case 1:
+					h.key = string(b)
+				case 2:
+					h.value = string(b)
+				}
+				return nil
+			})
+		case 2: // google.protobuf.BoolValue
+			return pbRange(b, func(field int, v uint64, b []byte) error {
+				if field == 1 {
+					h.append = v != 0
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	return h, err
+}
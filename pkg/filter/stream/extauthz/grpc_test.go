@@ -0,0 +1,219 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package extauthz
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc"
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+)
+
+// rawCodec passes the messages encoded by hand
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	return *(v.(*[]byte)), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*(v.(*[]byte)) = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) String() string {
+	return "raw"
+}
+
+// checkAttributes are the attributes decoded from the CheckRequest
+type checkAttributes struct {
+	path       string
+	headers    map[string]string
+	extensions map[string]string
+}
+
+func decodeStringMapEntry(b []byte, m map[string]string) error {
+	var k, v string
+	err := pbRange(b, func(field int, _ uint64, b []byte) error {
+		switch field {
+		case 1:
+			k = string(b)
+		case 2:
+			v = string(b)
+		}
+		return nil
+	})
+	m[k] = v
+	return err
+}
+
+func decodeCheckRequest(b []byte) (*checkAttributes, error) {
+	attrs := &checkAttributes{
+		headers:    map[string]string{},
+		extensions: map[string]string{},
+	}
+	err := pbRange(b, func(field int, _ uint64, b []byte) error {
+		// CheckRequest.attributes
+		return pbRange(b, func(field int, _ uint64, b []byte) error {
+			switch field {
+			case 4: // request
+				return pbRange(b, func(field int, _ uint64, b []byte) error {
+					if field != 2 {
+						return nil
+					}
+					// http
+					return pbRange(b, func(field int, _ uint64, b []byte) error {
+						switch field {
+						case 3:
+							return decodeStringMapEntry(b, attrs.headers)
+						case 4:
+							attrs.path = string(b)
+						}
+						return nil
+					})
+				})
+			case 10: // context_extensions
+				return decodeStringMapEntry(b, attrs.extensions)
+			}
+			return nil
+		})
+	})
+	return attrs, err
+}
+
+func encodeHeaderValueOption(key, value string, append bool) []byte {
+	h := &pbEncoder{}
+	h.string(1, key)
+	h.string(2, value)
+	opt := &pbEncoder{}
+	opt.message(1, h.buf)
+	if append {
+		b := &pbEncoder{}
+		b.varint(1, 1)
+		opt.message(2, b.buf)
+	}
+	return opt.buf
+}
+
+// checkHandler allows the request with the path /allow, and denies others with 401
+func checkHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	var req []byte
+	if err := dec(&req); err != nil {
+		return nil, err
+	}
+	attrs, err := decodeCheckRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	status := &pbEncoder{}
+	httpResponse := &pbEncoder{}
+	resp := &pbEncoder{}
+	if attrs.path == "/allow" && attrs.headers["x-request-id"] == "1" && attrs.extensions["tenant"] == "mosn" {
+		httpResponse.message(2, encodeHeaderValueOption("x-auth-user", "alice", false))
+		httpResponse.message(2, encodeHeaderValueOption("x-auth-group", "admin", true))
+		resp.message(1, status.buf)
+		resp.message(3, httpResponse.buf)
+	} else {
+		status.varint(1, 7) // PERMISSION_DENIED
+		code := &pbEncoder{}
+		code.varint(1, http.StatusUnauthorized)
+		httpResponse.message(1, code.buf)
+		httpResponse.message(2, encodeHeaderValueOption("www-authenticate", "Bearer", false))
+		resp.message(1, status.buf)
+		resp.message(2, httpResponse.buf)
+	}
+	return &resp.buf, nil
+}
+
+func TestFilterGRPC(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(grpc.CustomCodec(rawCodec{}))
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "envoy.service.auth.v2.Authorization",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			{MethodName: "Check", Handler: checkHandler},
+		},
+	}, struct{}{})
+	go srv.Serve(ln)
+	defer srv.Stop()
+	addCluster(t, "ext_authz_grpc", ln.Addr().String())
+
+	cfg := &v2.ExtAuthzConfig{
+		Cluster:  "ext_authz_grpc",
+		Protocol: v2.ExtAuthzGRPC,
+	}
+	route := &mockRoute{config: map[string]interface{}{
+		v2.ExtAuthz: map[string]interface{}{
+			"context_extensions": map[string]interface{}{"tenant": "mosn"},
+		},
+	}}
+
+	headers := protocol.CommonHeader{
+		protocol.MosnHeaderPathKey: "/allow",
+		"x-request-id":             "1",
+		"x-auth-group":             "user",
+	}
+	handler, status := runFilter(t, cfg, route, headers, "")
+	if status != api.StreamFilterContinue || handler.code != 0 {
+		t.Fatalf("expected the request is allowed, but got %v %d", status, handler.code)
+	}
+	if user, _ := headers.Get("x-auth-user"); user != "alice" {
+		t.Errorf("unexpected header set: %s", user)
+	}
+	if group, _ := headers.Get("x-auth-group"); group != "user,admin" {
+		t.Errorf("unexpected header appended: %s", group)
+	}
+
+	headers = protocol.CommonHeader{protocol.MosnHeaderPathKey: "/deny"}
+	handler, status = runFilter(t, cfg, route, headers, "")
+	if status != api.StreamFilterStop || handler.code != http.StatusUnauthorized {
+		t.Fatalf("expected the request is denied, but got %v %d", status, handler.code)
+	}
+	if challenge, _ := handler.headers.Get("www-authenticate"); challenge != "Bearer" {
+		t.Errorf("unexpected client header: %s", challenge)
+	}
+}
+
+func TestDecodeCheckResponse(t *testing.T) {
+	// the denied response without http status is replied with 403
+	status := &pbEncoder{}
+	status.varint(1, 7)
+	resp := &pbEncoder{}
+	resp.message(1, status.buf)
+	r, err := decodeCheckResponse(resp.buf)
+	if err != nil || r.status != checkDenied || r.httpStatus != http.StatusForbidden {
+		t.Fatalf("unexpected response: %+v, %v", r, err)
+	}
+	// the header value option with append
+	h, err := decodeHeaderValueOption(encodeHeaderValueOption("k", "v", true))
+	if err != nil || h != (headerValue{key: "k", value: "v", append: true}) {
+		t.Fatalf("unexpected header value: %+v, %v", h, err)
+	}
+	// truncated
+	if _, err := decodeCheckResponse(resp.buf[:len(resp.buf)-1]); err != errPBMalformed {
+		t.Fatalf("expected malformed message, but got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package extauthz
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/protocol"
+	mosnhttp "mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// the headers of the http check request for the attributes not in the downstream headers
+const (
+	HeaderPeerAddress   = "x-ext-authz-peer-address"
+	HeaderPeerPrincipal = "x-ext-authz-peer-principal"
+	// the context extensions of the route are sent with the prefix
+	HeaderContextPrefix = "x-ext-authz-context-"
+)
+
+// httpService sends the downstream request headers to the authorization service, with the body prefix if configured.
+// The request is allowed if the status code of the response is 200
+type httpService struct {
+	pathPrefix string
+	// upstreamHeaders are the headers of the ok response set to the request
+	upstreamHeaders []string
+	// clientHeaders are the headers of the denied response replied to the client
+	clientHeaders []string
+}
+
+func (s *httpService) protocol(info types.ClusterInfo) types.ProtocolName {
+	if info.UpstreamProtocol() == protocol.HTTP2 {
+		return protocol.HTTP2
+	}
+	return protocol.HTTP1
+}
+
+func (s *httpService) encode(req *checkRequest, proto types.ProtocolName) (api.HeaderMap, buffer.IoBuffer) {
+	var headers api.HeaderMap
+	if proto == protocol.HTTP1 {
+		headers = mosnhttp.RequestHeader{RequestHeader: &fasthttp.RequestHeader{}}
+	} else {
+		headers = protocol.CommonHeader{}
+	}
+	for k, v := range req.headers {
+		switch k {
+		// the length of the body is changed
+		case "content-length", "transfer-encoding":
+		default:
+			headers.Set(k, v)
+		}
+	}
+	path := s.pathPrefix + req.path
+	if req.query != "" {
+		path += "?" + req.query
+	}
+	headers.Set(protocol.MosnHeaderPathKey, path)
+	if req.method != "" {
+		headers.Set(protocol.MosnHeaderMethod, req.method)
+	}
+	if req.host != "" {
+		headers.Set(protocol.MosnHeaderHostKey, req.host)
+	}
+	if req.source.address != "" {
+		headers.Set(HeaderPeerAddress, net.JoinHostPort(req.source.address, strconv.Itoa(int(req.source.port))))
+	}
+	if req.source.principal != "" {
+		headers.Set(HeaderPeerPrincipal, req.source.principal)
+	}
+	for k, v := range req.contextExtensions {
+		headers.Set(HeaderContextPrefix+k, v)
+	}
+	if len(req.body) == 0 {
+		return headers, nil
+	}
+	return headers, buffer.NewIoBufferBytes(req.body)
+}
+
+func (s *httpService) decode(headers api.HeaderMap, data buffer.IoBuffer, trailers api.HeaderMap) (*checkResponse, error) {
+	status, _ := headers.Get(types.HeaderStatus)
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return nil, err
+	}
+	if code == http.StatusOK {
+		return &checkResponse{
+			status:  checkOK,
+			headers: copyHeaders(headers, s.upstreamHeaders),
+		}, nil
+	}
+	return &checkResponse{
+		status:     checkDenied,
+		httpStatus: code,
+		headers:    copyHeaders(headers, s.clientHeaders),
+	}, nil
+}
+
+func copyHeaders(headers api.HeaderMap, keys []string) []headerValue {
+	var values []headerValue
+	for _, key := range keys {
+		// the http2 headers get returns ok for the missing keys
+		if value, ok := headers.Get(key); ok && value != "" {
+			values = append(values, headerValue{
+				key:   strings.ToLower(key),
+				value: value,
+			})
+		}
+	}
+	return values
+}
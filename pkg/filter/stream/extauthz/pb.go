@@ -0,0 +1,137 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package extauthz
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// the protobuf messages of the authorization service are encoded by hand, only the fields used are supported
+
+// protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errPBMalformed = errors.New("malformed protobuf message")
+
+// pbEncoder appends the fields of a protobuf message, the fields with zero values are omitted
+type pbEncoder struct {
+	buf []byte
+}
+
+func (e *pbEncoder) tag(field int, wire uint64) {
+	e.buf = appendUvarint(e.buf, uint64(field)<<3|wire)
+}
+
+func (e *pbEncoder) varint(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	e.tag(field, wireVarint)
+	e.buf = appendUvarint(e.buf, v)
+}
+
+// message appends the embedded message, it is appended even if it is empty
+func (e *pbEncoder) message(field int, b []byte) {
+	e.tag(field, wireBytes)
+	e.buf = appendUvarint(e.buf, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *pbEncoder) bytes(field int, b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	e.message(field, b)
+}
+
+func (e *pbEncoder) string(field int, s string) {
+	if s == "" {
+		return
+	}
+	e.tag(field, wireBytes)
+	e.buf = appendUvarint(e.buf, uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// stringMap appends map<string, string> as the repeated entries
+func (e *pbEncoder) stringMap(field int, m map[string]string) {
+	for k, v := range m {
+		entry := &pbEncoder{}
+		entry.string(1, k)
+		entry.string(2, v)
+		e.message(field, entry.buf)
+	}
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+// pbRange calls f for each field of the message, v is the value of the varint field,
+// and b is the value of the length-delimited field. The fixed fields are skipped
+func pbRange(buf []byte, f func(field int, v uint64, b []byte) error) error {
+	for len(buf) > 0 {
+		key, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return errPBMalformed
+		}
+		buf = buf[n:]
+		field := int(key >> 3)
+		switch key & 7 {
+		case wireVarint:
+			v, n := binary.Uvarint(buf)
+			if n <= 0 {
+				return errPBMalformed
+			}
+			buf = buf[n:]
+			if err := f(field, v, nil); err != nil {
+				return err
+			}
+		case wireBytes:
+			l, n := binary.Uvarint(buf)
+			if n <= 0 || l > uint64(len(buf)-n) {
+				return errPBMalformed
+			}
+			b := buf[n : n+int(l)]
+			buf = buf[n+int(l):]
+			if err := f(field, 0, b); err != nil {
+				return err
+			}
+		case wireFixed64:
+			if len(buf) < 8 {
+				return errPBMalformed
+			}
+			buf = buf[8:]
+		case wireFixed32:
+			if len(buf) < 4 {
+				return errPBMalformed
+			}
+			buf = buf[4:]
+		default:
+			return errPBMalformed
+		}
+	}
+	return nil
+}
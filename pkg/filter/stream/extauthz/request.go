@@ -0,0 +1,131 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package extauthz
+
+import (
+	"encoding/pem"
+	"net"
+	"strings"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/mtls"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// internalHeaderPrefix is the prefix of the mosn internal headers, which are not sent as the request headers
+const internalHeaderPrefix = "x-mosn-"
+
+// checkRequest is the attributes of the downstream request sent to the authorization service
+type checkRequest struct {
+	time     time.Time
+	method   string
+	path     string
+	query    string
+	host     string
+	scheme   string
+	protocol string
+	// headers are the downstream headers allowed, the keys are in lower case
+	headers map[string]string
+	// body is the prefix of the request body, and size is the length of the whole body
+	body []byte
+	size int64
+
+	source      peer
+	destination peer
+
+	contextExtensions map[string]string
+}
+
+// peer is the address and the identity of the downstream or the local side
+type peer struct {
+	address     string
+	port        uint32
+	principal   string
+	certificate string
+}
+
+// newCheckRequest collects the attributes of the request with the headers allowed by the config
+func (c *extAuthzConfig) newCheckRequest(conn api.Connection, info api.RequestInfo, headers api.HeaderMap, buf buffer.IoBuffer) *checkRequest {
+	req := &checkRequest{
+		time:     time.Now(),
+		scheme:   "http",
+		headers:  make(map[string]string),
+		protocol: string(info.Protocol()),
+	}
+	req.method, _ = headers.Get(protocol.MosnHeaderMethod)
+	req.path, _ = headers.Get(protocol.MosnHeaderPathKey)
+	req.query, _ = headers.Get(protocol.MosnHeaderQueryStringKey)
+	req.host, _ = headers.Get(protocol.MosnHeaderHostKey)
+	// the rpc requests are checked as the path /service/method
+	if req.path == "" {
+		if service, ok := headers.Get(types.HeaderRPCService); ok {
+			method, _ := headers.Get(types.HeaderRPCMethod)
+			req.path = "/" + service + "/" + method
+		}
+	}
+	headers.Range(func(key, value string) bool {
+		key = strings.ToLower(key)
+		if !strings.HasPrefix(key, internalHeaderPrefix) && c.allowHeader(key) {
+			req.headers[key] = value
+		}
+		return true
+	})
+	if buf != nil {
+		req.size = int64(buf.Len())
+		if c.withBody {
+			req.body = buf.Bytes()
+			if c.maxRequestBytes > 0 && len(req.body) > int(c.maxRequestBytes) {
+				req.body = req.body[:c.maxRequestBytes]
+			}
+		}
+	}
+	if conn != nil {
+		req.source = newPeer(conn.RemoteAddr())
+		req.destination = newPeer(conn.LocalAddr())
+		if tlsConn, ok := conn.RawConn().(*mtls.TLSConn); ok {
+			req.scheme = "https"
+			if certs := tlsConn.ConnectionState().PeerCertificates; len(certs) > 0 {
+				cert := certs[0]
+				req.source.principal = cert.Subject.String()
+				if len(cert.URIs) > 0 {
+					req.source.principal = cert.URIs[0].String()
+				}
+				if c.includePeerCertificate {
+					req.source.certificate = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+				}
+			}
+		}
+	}
+	return req
+}
+
+func newPeer(addr net.Addr) peer {
+	p := peer{}
+	if addr == nil {
+		return p
+	}
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		p.address = tcpAddr.IP.String()
+		p.port = uint32(tcpAddr.Port)
+	} else {
+		p.address = addr.String()
+	}
+	return p
+}
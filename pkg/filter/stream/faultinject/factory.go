@@ -32,11 +32,13 @@ func init() {
 
 type FilterConfigFactory struct {
 	Config *v2.StreamFaultInject
+	state  *faultState
 }
 
 func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
-	filter := NewFilter(context, f.Config)
+	filter := newStreamFaultInjectFilter(context, f.Config, f.state)
 	callbacks.AddStreamReceiverFilter(filter, api.AfterRoute)
+	callbacks.AddStreamSenderFilter(filter)
 }
 
 func CreateFaultInjectFilterFactory(conf map[string]interface{}) (api.StreamFilterChainFactory, error) {
@@ -45,7 +47,10 @@ func CreateFaultInjectFilterFactory(conf map[string]interface{}) (api.StreamFilt
 	if err != nil {
 		return nil, err
 	}
-	return &FilterConfigFactory{cfg}, nil
+	return &FilterConfigFactory{
+		Config: cfg,
+		state:  newFaultState(cfg),
+	}, nil
 }
 
 // ParseStreamFaultInjectFilter
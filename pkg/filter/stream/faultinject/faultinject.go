@@ -21,42 +21,116 @@ import (
 	"context"
 	"encoding/json"
 	"math/rand"
+	"strconv"
+	"sync/atomic"
 	"time"
 
+	metrics "github.com/rcrowley/go-metrics"
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
 	"mosn.io/mosn/pkg/log"
+	mosnmetrics "mosn.io/mosn/pkg/metrics"
 	"mosn.io/mosn/pkg/router"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/pkg/buffer"
 )
 
+const defaultStatPrefix = "fault"
+
+// the headers control the faults if the header faults are enabled
+const (
+	HeaderFaultDelayRequest                 = "x-mosn-fault-delay-request"
+	HeaderFaultDelayRequestPercentage       = "x-mosn-fault-delay-request-percentage"
+	HeaderFaultAbortRequest                 = "x-mosn-fault-abort-request"
+	HeaderFaultAbortRequestPercentage       = "x-mosn-fault-abort-request-percentage"
+	HeaderFaultThroughputResponse           = "x-mosn-fault-throughput-response"
+	HeaderFaultThroughputResponsePercentage = "x-mosn-fault-throughput-response-percentage"
+)
+
 // faultInjectConfig is parsed from v2.StreamFaultInject
 type faultInjectConfig struct {
-	fixedDelay   time.Duration
-	delayPercent uint32
-	abortStatus  int
-	abortPercent uint32
-	upstream     string
-	headers      []*types.HeaderData
+	fixedDelay           time.Duration
+	delayPercent         uint32
+	headerDelay          bool
+	abortStatus          int
+	abortPercent         uint32
+	headerAbort          bool
+	resetPercent         uint32
+	responseDelay        time.Duration
+	responseDelayPercent uint32
+	responseBody         string
+	responseBodyPercent  uint32
+	rateLimit            uint64 // bytes per second
+	rateLimitPercent     uint32
+	headerRateLimit      bool
+	maxActiveFaults      uint32
+	upstream             string
+	headers              []*types.HeaderData
 }
 
 func makefaultInjectConfig(cfg *v2.StreamFaultInject) *faultInjectConfig {
 	faultConfig := &faultInjectConfig{
-		upstream: cfg.UpstreamCluster,
-		headers:  router.GetRouterHeaders(cfg.Headers),
+		upstream:        cfg.UpstreamCluster,
+		headers:         router.GetRouterHeaders(cfg.Headers),
+		maxActiveFaults: cfg.MaxActiveFaults,
 	}
 	if cfg.Delay != nil {
 		faultConfig.fixedDelay = cfg.Delay.Delay
 		faultConfig.delayPercent = cfg.Delay.Percent
+		faultConfig.headerDelay = cfg.Delay.HeaderDelay
 	}
 	if cfg.Abort != nil {
 		faultConfig.abortStatus = cfg.Abort.Status
 		faultConfig.abortPercent = cfg.Abort.Percent
+		faultConfig.headerAbort = cfg.Abort.HeaderAbort
+	}
+	if cfg.Reset != nil {
+		faultConfig.resetPercent = cfg.Reset.Percent
+	}
+	if cfg.ResponseDelay != nil {
+		faultConfig.responseDelay = cfg.ResponseDelay.Delay
+		faultConfig.responseDelayPercent = cfg.ResponseDelay.Percent
+	}
+	if cfg.ResponseBody != nil {
+		faultConfig.responseBody = cfg.ResponseBody.Body
+		faultConfig.responseBodyPercent = cfg.ResponseBody.Percent
+	}
+	if cfg.ResponseRateLimit != nil {
+		faultConfig.rateLimit = cfg.ResponseRateLimit.KiBPerSecond * 1024
+		faultConfig.rateLimitPercent = cfg.ResponseRateLimit.Percent
+		faultConfig.headerRateLimit = cfg.ResponseRateLimit.HeaderLimit
 	}
 	return faultConfig
 }
 
+// faultState is shared by the filters created by a factory
+type faultState struct {
+	activeFaults   int64
+	delays         metrics.Counter
+	aborts         metrics.Counter
+	resets         metrics.Counter
+	responseFaults metrics.Counter
+	activeGauge    metrics.Gauge
+	faultsOverflow metrics.Counter
+}
+
+func newFaultState(cfg *v2.StreamFaultInject) *faultState {
+	statPrefix := cfg.StatPrefix
+	if statPrefix == "" {
+		statPrefix = defaultStatPrefix
+	}
+	stats := mosnmetrics.NewFaultInjectStats(statPrefix)
+	return &faultState{
+		delays:         stats.Counter(mosnmetrics.FaultDelaysInjected),
+		aborts:         stats.Counter(mosnmetrics.FaultAbortsInjected),
+		resets:         stats.Counter(mosnmetrics.FaultResetsInjected),
+		responseFaults: stats.Counter(mosnmetrics.FaultResponseFaultsInjected),
+		activeGauge:    stats.Gauge(mosnmetrics.FaultActiveFaults),
+		faultsOverflow: stats.Counter(mosnmetrics.FaultFaultsOverflow),
+	}
+}
+
 // TODO: this is a hack for per route config parse
 // delete it later, when per route config changes to map[string]interface{}
 func parseStreamFaultInjectConfig(c interface{}) (*faultInjectConfig, bool) {
@@ -75,23 +149,39 @@ func parseStreamFaultInjectConfig(c interface{}) (*faultInjectConfig, bool) {
 	return makefaultInjectConfig(cfg), true
 }
 
-// streamFaultInjectFilter is an implement of api.StreamReceiverFilter
+// streamFaultInjectFilter is an implement of api.StreamReceiverFilter and api.StreamSenderFilter,
+// the response faults are injected in the sender
 type streamFaultInjectFilter struct {
-	ctx     context.Context
-	handler api.StreamReceiverFilterHandler
-	config  *faultInjectConfig
-	stop    chan struct{}
-	rander  *rand.Rand
-	headers api.HeaderMap
+	ctx           context.Context
+	handler       api.StreamReceiverFilterHandler
+	senderHandler api.StreamSenderFilterHandler
+	config        *faultInjectConfig
+	state         *faultState
+	stop          chan struct{}
+	rander        *rand.Rand
+	headers       api.HeaderMap
+	// active is true if the stream is counted in the active faults
+	active bool
+	// the response faults selected on receiving the request
+	responseDelay time.Duration
+	replaceBody   bool
+	rateLimit     uint64
+	// replaceStream is true if the body replaced is sent in data frames
+	replaceStream bool
 }
 
 func NewFilter(ctx context.Context, cfg *v2.StreamFaultInject) api.StreamReceiverFilter {
+	return newStreamFaultInjectFilter(ctx, cfg, newFaultState(cfg))
+}
+
+func newStreamFaultInjectFilter(ctx context.Context, cfg *v2.StreamFaultInject, state *faultState) *streamFaultInjectFilter {
 	if log.Proxy.GetLogLevel() >= log.DEBUG {
 		log.Proxy.Debugf(ctx, "[stream filter] [fault inject] create a new fault inject filter")
 	}
 	return &streamFaultInjectFilter{
 		ctx:    ctx,
 		config: makefaultInjectConfig(cfg),
+		state:  state,
 		stop:   make(chan struct{}),
 		rander: rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
@@ -140,30 +230,131 @@ func (f *streamFaultInjectFilter) OnReceive(ctx context.Context, headers api.Hea
 		}
 		return api.StreamFilterContinue
 	}
-	// TODO: some parameters can get from request header
-	if delay := f.getDelayDuration(); delay > 0 {
+	if delay := f.requestDelay(headers); delay > 0 && f.acquire() {
 		if log.Proxy.GetLogLevel() >= log.DEBUG {
 			log.Proxy.Debugf(f.ctx, "[stream filter] [fault inject] start a delay timer")
 		}
+		f.state.delays.Inc(1)
 		f.handler.RequestInfo().SetResponseFlag(api.DelayInjected)
-		select {
-		case <-time.After(delay):
-		case <-f.stop:
+		if !f.wait(delay) {
+			return api.StreamFilterStop
+		}
+	}
+	if status, ok := f.abortStatus(headers); ok && f.acquire() {
+		f.state.aborts.Inc(1)
+		f.abort(status, headers)
+		return api.StreamFilterStop
+	}
+	if f.isReset() && f.acquire() {
+		if h, ok := f.handler.(types.StreamResetHandler); ok {
 			if log.Proxy.GetLogLevel() >= log.DEBUG {
-				log.Proxy.Debugf(f.ctx, "[stream filter] [fault inject] timer is stopped")
+				log.Proxy.Debugf(f.ctx, "[stream filter] [fault inject] reset inject")
 			}
+			f.state.resets.Inc(1)
+			f.handler.RequestInfo().SetResponseFlag(api.FaultInjected)
+			h.ResetStream()
 			return api.StreamFilterStop
 		}
 	}
-	if f.isAbort() {
-		f.abort(headers)
+	f.selectResponseFaults(headers)
+	return api.StreamFilterContinue
+}
+
+func (f *streamFaultInjectFilter) SetSenderFilterHandler(handler api.StreamSenderFilterHandler) {
+	f.senderHandler = handler
+}
+
+func (f *streamFaultInjectFilter) Append(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if f.responseDelay > 0 {
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(f.ctx, "[stream filter] [fault inject] delay the response")
+		}
+		f.senderHandler.RequestInfo().SetResponseFlag(api.DelayInjected)
+		if !f.wait(f.responseDelay) {
+			return api.StreamFilterStop
+		}
+	}
+	if f.replaceBody {
+		f.senderHandler.RequestInfo().SetResponseFlag(api.FaultInjected)
+		if use, _ := mosnctx.Get(ctx, types.ContextKeyUseStream).(bool); buf == nil && use {
+			// the body is replaced at the end of the data frames
+			f.replaceStream = true
+			headers.Del("Content-Length")
+		} else {
+			f.senderHandler.SetResponseData(buffer.NewIoBufferString(f.config.responseBody))
+			if length, _ := headers.Get("Content-Length"); length != "" {
+				headers.Set("Content-Length", strconv.Itoa(len(f.config.responseBody)))
+			}
+			buf = f.senderHandler.GetResponseData()
+		}
+	}
+	if f.rateLimit > 0 && buf != nil && !f.limit(buf.Len()) {
 		return api.StreamFilterStop
 	}
 	return api.StreamFilterContinue
 }
 
+// AppendData injects the response faults to the data frames
+func (f *streamFaultInjectFilter) AppendData(ctx context.Context, data buffer.IoBuffer, endStream bool) buffer.IoBuffer {
+	if f.replaceStream {
+		if !endStream {
+			return buffer.NewIoBuffer(0)
+		}
+		data = buffer.NewIoBufferString(f.config.responseBody)
+	}
+	if f.rateLimit > 0 && data != nil {
+		f.limit(data.Len())
+	}
+	return data
+}
+
 func (f *streamFaultInjectFilter) OnDestroy() {
 	close(f.stop)
+	if f.active {
+		f.state.activeGauge.Update(atomic.AddInt64(&f.state.activeFaults, -1))
+	}
+}
+
+// acquire counts the stream in the active faults, returns false if the max active faults is reached
+func (f *streamFaultInjectFilter) acquire() bool {
+	if f.active {
+		return true
+	}
+	active := atomic.AddInt64(&f.state.activeFaults, 1)
+	if max := f.config.maxActiveFaults; max > 0 && active > int64(max) {
+		atomic.AddInt64(&f.state.activeFaults, -1)
+		f.state.faultsOverflow.Inc(1)
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(f.ctx, "[stream filter] [fault inject] max active faults %d is reached", max)
+		}
+		return false
+	}
+	f.active = true
+	f.state.activeGauge.Update(active)
+	return true
+}
+
+// wait returns false if the stream is destroyed before the delay expires
+func (f *streamFaultInjectFilter) wait(delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-f.stop:
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(f.ctx, "[stream filter] [fault inject] timer is stopped")
+		}
+		return false
+	}
+}
+
+// limit waits for the time of sending the bytes at the rate limited
+func (f *streamFaultInjectFilter) limit(n int) bool {
+	if n == 0 {
+		return true
+	}
+	return f.wait(time.Duration(uint64(n) * uint64(time.Second) / f.rateLimit))
 }
 
 // matches and inject
@@ -218,11 +409,103 @@ func (f *streamFaultInjectFilter) isAbort() bool {
 	return true
 }
 
-// TODO: make a header
-func (f *streamFaultInjectFilter) abort(headers api.HeaderMap) {
+func (f *streamFaultInjectFilter) abort(status int, headers api.HeaderMap) {
 	if log.Proxy.GetLogLevel() >= log.DEBUG {
 		log.Proxy.Debugf(f.ctx, "[stream filter] [fault inject] abort inject")
 	}
 	f.handler.RequestInfo().SetResponseFlag(api.FaultInjected)
-	f.handler.SendHijackReply(f.config.abortStatus, headers)
+	// the xprotocol streams map the status by Hijacker
+	f.handler.SendHijackReply(status, headers)
+}
+
+// hit returns true with the probability of the percent
+func (f *streamFaultInjectFilter) hit(percent uint32) bool {
+	return percent > 0 && (f.rander.Uint32()%100) < percent
+}
+
+// headerValue returns the value of the fault header, the headers may be nil
+func headerValue(headers api.HeaderMap, key string) string {
+	if headers == nil {
+		return ""
+	}
+	value, _ := headers.Get(key)
+	return value
+}
+
+// headerPercent returns the percent of the fault header, 100 if it is not set
+func headerPercent(headers api.HeaderMap, key string) uint32 {
+	value := headerValue(headers, key)
+	if value == "" {
+		return 100
+	}
+	percent, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || percent > 100 {
+		return 0
+	}
+	return uint32(percent)
+}
+
+// requestDelay returns the delay in the headers if the header delay is enabled, or the fixed delay
+func (f *streamFaultInjectFilter) requestDelay(headers api.HeaderMap) time.Duration {
+	if f.config.headerDelay {
+		if value := headerValue(headers, HeaderFaultDelayRequest); value != "" {
+			ms, err := strconv.ParseUint(value, 10, 32)
+			if err != nil || !f.hit(headerPercent(headers, HeaderFaultDelayRequestPercentage)) {
+				return 0
+			}
+			return time.Duration(ms) * time.Millisecond
+		}
+	}
+	return f.getDelayDuration()
+}
+
+// abortStatus returns the status in the headers if the header abort is enabled, or the status configured
+func (f *streamFaultInjectFilter) abortStatus(headers api.HeaderMap) (int, bool) {
+	if f.config.headerAbort {
+		if value := headerValue(headers, HeaderFaultAbortRequest); value != "" {
+			status, err := strconv.Atoi(value)
+			if err != nil || status < 200 || status >= 600 || !f.hit(headerPercent(headers, HeaderFaultAbortRequestPercentage)) {
+				return 0, false
+			}
+			return status, true
+		}
+	}
+	if f.isAbort() {
+		return f.config.abortStatus, true
+	}
+	return 0, false
+}
+
+func (f *streamFaultInjectFilter) isReset() bool {
+	return f.hit(f.config.resetPercent)
+}
+
+// selectResponseFaults selects the faults injected to the response
+func (f *streamFaultInjectFilter) selectResponseFaults(headers api.HeaderMap) {
+	var injected bool
+	if f.config.responseDelay > 0 && f.hit(f.config.responseDelayPercent) && f.acquire() {
+		f.responseDelay = f.config.responseDelay
+		injected = true
+	}
+	if f.hit(f.config.responseBodyPercent) && f.acquire() {
+		f.replaceBody = true
+		injected = true
+	}
+	rateLimit, percent := f.config.rateLimit, f.config.rateLimitPercent
+	if f.config.headerRateLimit {
+		if value := headerValue(headers, HeaderFaultThroughputResponse); value != "" {
+			kib, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				kib = 0
+			}
+			rateLimit, percent = kib*1024, headerPercent(headers, HeaderFaultThroughputResponsePercentage)
+		}
+	}
+	if rateLimit > 0 && f.hit(percent) && f.acquire() {
+		f.rateLimit = rateLimit
+		injected = true
+	}
+	if injected {
+		f.state.responseFaults.Inc(1)
+	}
 }
@@ -26,7 +26,10 @@ import (
 
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
 	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
 )
 
 func TestMatchUpstream(t *testing.T) {
@@ -387,3 +390,188 @@ func TestFaultInject_RouteConfigOverride(t *testing.T) {
 		t.Error("timeout")
 	}
 }
+
+func newTestFilter(cfg *v2.StreamFaultInject) (*streamFaultInjectFilter, *mockStreamReceiverFilterCallbacks, *mockStreamSenderFilterCallbacks) {
+	cb := &mockStreamReceiverFilterCallbacks{
+		info: &mockRequestInfo{},
+		route: &mockRoute{
+			rule: &mockRouteRule{},
+		},
+		called: make(chan int, 1),
+	}
+	sender := &mockStreamSenderFilterCallbacks{
+		info: cb.info,
+	}
+	f := NewFilter(context.Background(), cfg).(*streamFaultInjectFilter)
+	f.SetReceiveFilterHandler(cb)
+	f.SetSenderFilterHandler(sender)
+	return f, cb, sender
+}
+
+func TestFaultInject_HeaderControlled(t *testing.T) {
+	cfg := &v2.StreamFaultInject{
+		Delay: &v2.DelayInject{
+			DelayInjectConfig: v2.DelayInjectConfig{
+				HeaderDelay: true,
+			},
+		},
+		Abort: &v2.AbortInject{
+			HeaderAbort: true,
+		},
+	}
+	// no fault without the headers
+	f, _, _ := newTestFilter(cfg)
+	if status := f.OnReceive(context.TODO(), protocol.CommonHeader{}, nil, nil); status != api.StreamFilterContinue {
+		t.Fatal("expected no fault without headers")
+	}
+
+	f, cb, _ := newTestFilter(cfg)
+	headers := protocol.CommonHeader{
+		HeaderFaultDelayRequest: "200",
+		HeaderFaultAbortRequest: "503",
+	}
+	start := time.Now()
+	if status := f.OnReceive(context.TODO(), headers, nil, nil); status != api.StreamFilterStop {
+		t.Fatal("expected the request is aborted")
+	}
+	if cost := time.Since(start); cost < 200*time.Millisecond {
+		t.Errorf("expected delay at least 200ms, but got %v", cost)
+	}
+	if cb.hijackCode != 503 {
+		t.Errorf("expected abort with 503, but got %d", cb.hijackCode)
+	}
+
+	// percentage 0 in headers
+	f, _, _ = newTestFilter(cfg)
+	headers = protocol.CommonHeader{
+		HeaderFaultAbortRequest:           "503",
+		HeaderFaultAbortRequestPercentage: "0",
+	}
+	if status := f.OnReceive(context.TODO(), headers, nil, nil); status != api.StreamFilterContinue {
+		t.Fatal("expected no abort with percentage 0")
+	}
+}
+
+func TestFaultInject_Reset(t *testing.T) {
+	f, cb, _ := newTestFilter(&v2.StreamFaultInject{
+		Reset: &v2.ResetInject{Percent: 100},
+	})
+	if status := f.OnReceive(context.TODO(), nil, nil, nil); status != api.StreamFilterStop {
+		t.Fatal("expected the stream is reset")
+	}
+	select {
+	case <-cb.called:
+	default:
+		t.Fatal("reset is not called")
+	}
+	if cb.info.flag != api.FaultInjected {
+		t.Errorf("unexpected response flag %v", cb.info.flag)
+	}
+}
+
+func TestFaultInject_ResponseFaults(t *testing.T) {
+	f, _, sender := newTestFilter(&v2.StreamFaultInject{
+		ResponseDelay: &v2.DelayInject{
+			Delay:             100 * time.Millisecond,
+			DelayInjectConfig: v2.DelayInjectConfig{Percent: 100},
+		},
+		ResponseBody: &v2.BodyInject{
+			Body:    "fault body",
+			Percent: 100,
+		},
+	})
+	f.OnReceive(context.TODO(), nil, nil, nil)
+	headers := protocol.CommonHeader{"Content-Length": "8"}
+	start := time.Now()
+	if status := f.Append(context.TODO(), headers, buffer.NewIoBufferString("original"), nil); status != api.StreamFilterContinue {
+		t.Fatal("unexpected append status")
+	}
+	if cost := time.Since(start); cost < 100*time.Millisecond {
+		t.Errorf("expected response delay at least 100ms, but got %v", cost)
+	}
+	if sender.data == nil || sender.data.String() != "fault body" || headers["Content-Length"] != "10" {
+		t.Fatalf("unexpected response body or headers %v", headers)
+	}
+
+	// the body sent in data frames is replaced at the end
+	f, _, _ = newTestFilter(&v2.StreamFaultInject{
+		ResponseBody: &v2.BodyInject{
+			Body:    "fault body",
+			Percent: 100,
+		},
+	})
+	f.OnReceive(context.TODO(), nil, nil, nil)
+	ctx := mosnctx.WithValue(context.Background(), types.ContextKeyUseStream, true)
+	f.Append(ctx, protocol.CommonHeader{}, nil, nil)
+	if data := f.AppendData(ctx, buffer.NewIoBufferString("frame"), false); data.Len() != 0 {
+		t.Fatalf("expected the frame is dropped, but got %s", data.String())
+	}
+	if data := f.AppendData(ctx, buffer.NewIoBufferString("last"), true); data.String() != "fault body" {
+		t.Fatalf("unexpected last frame %s", data.String())
+	}
+}
+
+func TestFaultInject_ResponseRateLimit(t *testing.T) {
+	f, _, _ := newTestFilter(&v2.StreamFaultInject{
+		ResponseRateLimit: &v2.RateLimitInject{
+			HeaderLimit: true,
+		},
+	})
+	f.OnReceive(context.TODO(), protocol.CommonHeader{HeaderFaultThroughputResponse: "10"}, nil, nil)
+	start := time.Now()
+	// 2KiB at 10KiB/s takes 200ms
+	f.Append(context.TODO(), protocol.CommonHeader{}, buffer.NewIoBufferBytes(make([]byte, 2048)), nil)
+	if cost := time.Since(start); cost < 200*time.Millisecond {
+		t.Errorf("expected the response is limited at least 200ms, but got %v", cost)
+	}
+}
+
+func TestFaultInject_MaxActiveFaults(t *testing.T) {
+	cfg := &v2.StreamFaultInject{
+		Abort: &v2.AbortInject{
+			Status:  500,
+			Percent: 100,
+		},
+		MaxActiveFaults: 1,
+	}
+	factory, err := CreateFaultInjectFilterFactory(map[string]interface{}{
+		"abort": map[string]interface{}{
+			"status":     500,
+			"percentage": 100,
+		},
+		"max_active_faults": 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := factory.(*FilterConfigFactory).state
+	newFilter := func() (*streamFaultInjectFilter, *mockStreamReceiverFilterCallbacks) {
+		cb := &mockStreamReceiverFilterCallbacks{
+			info: &mockRequestInfo{},
+			route: &mockRoute{
+				rule: &mockRouteRule{},
+			},
+			called: make(chan int, 1),
+		}
+		f := newStreamFaultInjectFilter(context.Background(), cfg, state)
+		f.SetReceiveFilterHandler(cb)
+		return f, cb
+	}
+	f1, _ := newFilter()
+	if status := f1.OnReceive(context.TODO(), nil, nil, nil); status != api.StreamFilterStop {
+		t.Fatal("expected the first request is aborted")
+	}
+	f2, _ := newFilter()
+	if status := f2.OnReceive(context.TODO(), nil, nil, nil); status != api.StreamFilterContinue {
+		t.Fatal("expected no fault after the max active faults is reached")
+	}
+	if cnt := state.faultsOverflow.Count(); cnt != 1 {
+		t.Errorf("expected faults overflow 1, but got %d", cnt)
+	}
+	f1.OnDestroy()
+	f2.OnDestroy()
+	f3, _ := newFilter()
+	if status := f3.OnReceive(context.TODO(), nil, nil, nil); status != api.StreamFilterStop {
+		t.Fatal("expected the request is aborted after the active fault is finished")
+	}
+}
@@ -17,7 +17,10 @@
 
 package faultinject
 
-import "mosn.io/api"
+import (
+	"mosn.io/api"
+	"mosn.io/pkg/buffer"
+)
 
 // this file mocks the interface that used for test
 // only implement the function that used in test
@@ -43,6 +46,26 @@ func (cb *mockStreamReceiverFilterCallbacks) SendHijackReply(code int, headers a
 	cb.called <- 1
 }
 
+func (cb *mockStreamReceiverFilterCallbacks) ResetStream() {
+	cb.called <- 1
+}
+
+type mockStreamSenderFilterCallbacks struct {
+	api.StreamSenderFilterHandler
+	info *mockRequestInfo
+	data buffer.IoBuffer
+}
+
+func (cb *mockStreamSenderFilterCallbacks) RequestInfo() api.RequestInfo {
+	return cb.info
+}
+func (cb *mockStreamSenderFilterCallbacks) GetResponseData() buffer.IoBuffer {
+	return cb.data
+}
+func (cb *mockStreamSenderFilterCallbacks) SetResponseData(data buffer.IoBuffer) {
+	cb.data = data
+}
+
 type mockRoute struct {
 	api.Route
 	rule *mockRouteRule
@@ -0,0 +1,179 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package headertometadata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/router"
+	"mosn.io/mosn/pkg/variable"
+)
+
+func init() {
+	api.RegisterStream(v2.HeaderToMetadata, CreateHeaderToMetadataFilterFactory)
+}
+
+// FilterConfigFactory shares the config with the filters created
+type FilterConfigFactory struct {
+	config *headerToMetadataConfig
+}
+
+func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	filter := newHeaderToMetadataFilter(f.config)
+	// the metadata and the headers are used by the route matching
+	callbacks.AddStreamReceiverFilter(filter, api.BeforeRoute)
+}
+
+func CreateHeaderToMetadataFilterFactory(conf map[string]interface{}) (api.StreamFilterChainFactory, error) {
+	log.DefaultLogger.Debugf("create header to metadata stream filter factory")
+	cfg, err := ParseHeaderToMetadataFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+	config, err := newHeaderToMetadataConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &FilterConfigFactory{config}, nil
+}
+
+// ParseHeaderToMetadataFilter
+func ParseHeaderToMetadataFilter(cfg map[string]interface{}) (*v2.HeaderToMetadataConfig, error) {
+	filterConfig := &v2.HeaderToMetadataConfig{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	return filterConfig, nil
+}
+
+// headerToMetadataConfig is the config compiled from v2.HeaderToMetadataConfig
+type headerToMetadataConfig struct {
+	rules       []*rule
+	renames     []v2.HeaderRename
+	headers     router.HeadersEvaluator
+	pathRewrite *regexRewrite
+}
+
+type rule struct {
+	header         string
+	queryParameter string
+	valueRewrite   *regexRewrite
+	defaultValue   string
+	metadataKey    string
+	variable       string
+	remove         bool
+}
+
+type regexRewrite struct {
+	pattern      *regexp.Regexp
+	substitution string
+}
+
+func newHeaderToMetadataConfig(cfg *v2.HeaderToMetadataConfig) (*headerToMetadataConfig, error) {
+	c := &headerToMetadataConfig{
+		headers: router.NewHeadersEvaluator(cfg.RequestHeadersToAdd, cfg.RequestHeadersToRemove),
+	}
+	for i := range cfg.Rules {
+		r, err := newRule(&cfg.Rules[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid header to metadata rule %d: %v", i, err)
+		}
+		c.rules = append(c.rules, r)
+	}
+	for _, rename := range cfg.RequestHeadersToRename {
+		if rename.From == "" || rename.To == "" {
+			return nil, errors.New("the header to rename is empty")
+		}
+		c.renames = append(c.renames, rename)
+	}
+	if cfg.PathRewrite != nil {
+		rewrite, err := newRegexRewrite(cfg.PathRewrite)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path rewrite: %v", err)
+		}
+		c.pathRewrite = rewrite
+	}
+	return c, nil
+}
+
+func newRule(cfg *v2.HeaderToMetadataRule) (*rule, error) {
+	r := &rule{
+		header:         cfg.Header,
+		queryParameter: cfg.QueryParameter,
+		defaultValue:   cfg.DefaultValue,
+		metadataKey:    cfg.MetadataKey,
+		variable:       cfg.Variable,
+		remove:         cfg.Remove,
+	}
+	if (r.header == "") == (r.queryParameter == "") {
+		return nil, errors.New("one of the header and the query parameter should be configured")
+	}
+	if r.remove && r.header == "" {
+		return nil, errors.New("only the header can be removed")
+	}
+	if r.metadataKey == "" && r.variable == "" {
+		return nil, errors.New("neither the metadata key nor the variable is configured")
+	}
+	if r.variable != "" {
+		// the value is set to the variable in the stream context, so it should be indexed
+		v, err := variable.AddVariable(r.variable)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := v.(variable.Indexer); !ok || v.Setter() == nil {
+			return nil, fmt.Errorf("variable %s can not be set", r.variable)
+		}
+	}
+	if cfg.ValueRewrite != nil {
+		rewrite, err := newRegexRewrite(cfg.ValueRewrite)
+		if err != nil {
+			return nil, err
+		}
+		r.valueRewrite = rewrite
+	}
+	return r, nil
+}
+
+func newRegexRewrite(cfg *v2.RegexRewrite) (*regexRewrite, error) {
+	pattern, err := regexp.Compile(cfg.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &regexRewrite{
+		pattern:      pattern,
+		substitution: cfg.Substitution,
+	}, nil
+}
+
+// rewrite replaces the matches of the pattern with the substitution, returns false if the value does not match
+func (r *regexRewrite) rewrite(value string) (string, bool) {
+	if !r.pattern.MatchString(value) {
+		return value, false
+	}
+	return r.pattern.ReplaceAllString(value, r.substitution), true
+}
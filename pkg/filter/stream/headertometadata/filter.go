@@ -0,0 +1,124 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package headertometadata
+
+import (
+	"context"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	mosnhttp "mosn.io/mosn/pkg/protocol/http"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/variable"
+	"mosn.io/pkg/buffer"
+)
+
+// headerToMetadataFilter maps the request data to the dynamic metadata and the variables, and transforms the request headers
+type headerToMetadataFilter struct {
+	config  *headerToMetadataConfig
+	handler api.StreamReceiverFilterHandler
+}
+
+func newHeaderToMetadataFilter(config *headerToMetadataConfig) *headerToMetadataFilter {
+	return &headerToMetadataFilter{
+		config: config,
+	}
+}
+
+func (f *headerToMetadataFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	f.applyRules(ctx, headers)
+	for _, rename := range f.config.renames {
+		if v, ok := headers.Get(rename.From); ok {
+			headers.Del(rename.From)
+			headers.Set(rename.To, v)
+		}
+	}
+	if f.config.headers != nil {
+		f.config.headers.EvaluateHeaders(ctx, headers, f.handler.RequestInfo())
+	}
+	if f.config.pathRewrite != nil {
+		f.rewritePath(ctx, headers)
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *headerToMetadataFilter) applyRules(ctx context.Context, headers api.HeaderMap) {
+	var query types.QueryParams
+	for _, r := range f.config.rules {
+		var value string
+		var ok bool
+		if r.header != "" {
+			value, ok = headers.Get(r.header)
+			if ok && r.remove {
+				headers.Del(r.header)
+			}
+		} else {
+			// the query string is parsed once for all the rules
+			if query == nil {
+				query = types.QueryParams{}
+				if qs, exists := headers.Get(protocol.MosnHeaderQueryStringKey); exists {
+					query = mosnhttp.ParseQueryString(qs)
+				}
+			}
+			value, ok = query[r.queryParameter]
+		}
+		if ok && r.valueRewrite != nil {
+			value, ok = r.valueRewrite.rewrite(value)
+		}
+		if !ok {
+			if r.defaultValue == "" {
+				continue
+			}
+			value = r.defaultValue
+		}
+		if r.metadataKey != "" {
+			setDynamicMetadata(ctx, r.metadataKey, value)
+		}
+		if r.variable != "" {
+			if err := variable.SetVariableValue(ctx, r.variable, value); err != nil {
+				log.Proxy.Warnf(ctx, "[stream filter] [header_to_metadata] set variable %s failed: %v", r.variable, err)
+			}
+		}
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(ctx, "[stream filter] [header_to_metadata] mapped value %s, metadata key: %s, variable: %s", value, r.metadataKey, r.variable)
+		}
+	}
+}
+
+// rewritePath rewrites the path before the route matching, the original path is kept like the prefix rewrite of the route
+func (f *headerToMetadataFilter) rewritePath(ctx context.Context, headers api.HeaderMap) {
+	path, ok := headers.Get(protocol.MosnHeaderPathKey)
+	if !ok {
+		return
+	}
+	rewritten, ok := f.config.pathRewrite.rewrite(path)
+	if !ok || rewritten == path {
+		return
+	}
+	headers.Set(protocol.MosnOriginalHeaderPathKey, path)
+	headers.Set(protocol.MosnHeaderPathKey, rewritten)
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(ctx, "[stream filter] [header_to_metadata] rewrite path %s to %s", path, rewritten)
+	}
+}
+
+func (f *headerToMetadataFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.handler = handler
+}
+
+func (f *headerToMetadataFilter) OnDestroy() {}
@@ -0,0 +1,198 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package headertometadata
+
+import (
+	"context"
+	"testing"
+
+	"mosn.io/api"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/variable"
+)
+
+const testVariable = "test_header_to_metadata"
+
+func init() {
+	variable.RegisterVariable(variable.NewIndexedVariable(testVariable, nil, nil, variable.BasicSetter, 0))
+}
+
+type mockHandler struct {
+	api.StreamReceiverFilterHandler
+}
+
+func (h *mockHandler) RequestInfo() api.RequestInfo {
+	return network.NewRequestInfo()
+}
+
+func newTestFilter(t *testing.T, conf map[string]interface{}) *headerToMetadataFilter {
+	factory, err := CreateHeaderToMetadataFilterFactory(conf)
+	if err != nil {
+		t.Fatalf("create filter factory failed: %v", err)
+	}
+	f := newHeaderToMetadataFilter(factory.(*FilterConfigFactory).config)
+	f.SetReceiveFilterHandler(&mockHandler{})
+	return f
+}
+
+func newTestContext() context.Context {
+	ctx := mosnctx.WithValue(context.Background(), types.ContextKeyStreamID, uint64(1))
+	return variable.NewVariableContext(ctx)
+}
+
+func TestHeaderToMetadataRules(t *testing.T) {
+	f := newTestFilter(t, map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{
+				"header":        "x-version",
+				"value_rewrite": map[string]interface{}{"pattern": `^(v\d+)-.*$`, "substitution": "$1"},
+				"metadata_key":  "version",
+				"variable":      testVariable,
+				"remove":        true,
+			},
+			map[string]interface{}{
+				"query_parameter": "zone",
+				"metadata_key":    "zone",
+			},
+			map[string]interface{}{
+				"header":        "x-stage",
+				"default_value": "prod",
+				"metadata_key":  "stage",
+			},
+			map[string]interface{}{
+				"header":       "x-absent",
+				"metadata_key": "absent",
+			},
+		},
+	})
+	ctx := newTestContext()
+	headers := protocol.CommonHeader{
+		"x-version":                       "v2-canary",
+		protocol.MosnHeaderQueryStringKey: "zone=gz&id=1",
+	}
+	if status := f.OnReceive(ctx, headers, nil, nil); status != api.StreamFilterContinue {
+		t.Fatalf("expected the filter continues, but got %v", status)
+	}
+	metadata, _ := mosnctx.Get(ctx, types.ContextKeyDynamicMetadata).(map[string]string)
+	expected := map[string]string{"version": "v2", "zone": "gz", "stage": "prod"}
+	if len(metadata) != len(expected) {
+		t.Fatalf("expected metadata %v, but got %v", expected, metadata)
+	}
+	for k, v := range expected {
+		if metadata[k] != v {
+			t.Errorf("expected metadata %s=%s, but got %s", k, v, metadata[k])
+		}
+	}
+	if _, ok := headers.Get("x-version"); ok {
+		t.Error("expected the source header is removed")
+	}
+	if v, err := variable.GetVariableValue(ctx, testVariable); err != nil || v != "v2" {
+		t.Errorf("expected variable value v2, but got %s, error: %v", v, err)
+	}
+	if v, err := variable.GetVariableValue(ctx, "dynamic_metadata_zone"); err != nil || v != "gz" {
+		t.Errorf("expected dynamic metadata variable gz, but got %s, error: %v", v, err)
+	}
+}
+
+func TestHeaderToMetadataValueNotMatched(t *testing.T) {
+	f := newTestFilter(t, map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{
+				"header":        "x-version",
+				"value_rewrite": map[string]interface{}{"pattern": `^v\d+$`, "substitution": "$0"},
+				"default_value": "v1",
+				"metadata_key":  "version",
+			},
+		},
+	})
+	ctx := newTestContext()
+	f.OnReceive(ctx, protocol.CommonHeader{"x-version": "latest"}, nil, nil)
+	metadata, _ := mosnctx.Get(ctx, types.ContextKeyDynamicMetadata).(map[string]string)
+	if metadata["version"] != "v1" {
+		t.Errorf("expected the default value is used, but got %v", metadata)
+	}
+}
+
+func TestHeaderTransform(t *testing.T) {
+	f := newTestFilter(t, map[string]interface{}{
+		"request_headers_to_rename": []interface{}{
+			map[string]interface{}{"from": "x-old", "to": "x-new"},
+		},
+		"request_headers_to_add": []interface{}{
+			map[string]interface{}{"header": map[string]interface{}{"key": "x-added", "value": "1"}},
+		},
+		"request_headers_to_remove": []interface{}{"x-removed"},
+		"path_rewrite":              map[string]interface{}{"pattern": `^/api/v(\d+)/(.*)$`, "substitution": "/$2?version=$1"},
+	})
+	headers := protocol.CommonHeader{
+		"x-old":                    "value",
+		"x-removed":                "value",
+		protocol.MosnHeaderPathKey: "/api/v2/users",
+	}
+	f.OnReceive(newTestContext(), headers, nil, nil)
+	if _, ok := headers.Get("x-old"); ok {
+		t.Error("expected the renamed header is removed")
+	}
+	if v, _ := headers.Get("x-new"); v != "value" {
+		t.Errorf("expected the renamed header value, but got %s", v)
+	}
+	if v, _ := headers.Get("x-added"); v != "1" {
+		t.Errorf("expected the added header, but got %s", v)
+	}
+	if _, ok := headers.Get("x-removed"); ok {
+		t.Error("expected the header is removed")
+	}
+	if v, _ := headers.Get(protocol.MosnHeaderPathKey); v != "/users?version=2" {
+		t.Errorf("expected the path is rewritten, but got %s", v)
+	}
+	if v, _ := headers.Get(protocol.MosnOriginalHeaderPathKey); v != "/api/v2/users" {
+		t.Errorf("expected the original path is kept, but got %s", v)
+	}
+
+	// the path not matched is not rewritten
+	headers = protocol.CommonHeader{protocol.MosnHeaderPathKey: "/health"}
+	f.OnReceive(newTestContext(), headers, nil, nil)
+	if _, ok := headers.Get(protocol.MosnOriginalHeaderPathKey); ok {
+		t.Error("expected the path is not rewritten")
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	for _, conf := range []map[string]interface{}{
+		// no source
+		{"rules": []interface{}{map[string]interface{}{"metadata_key": "version"}}},
+		// both sources
+		{"rules": []interface{}{map[string]interface{}{"header": "x-version", "query_parameter": "version", "metadata_key": "version"}}},
+		// no target
+		{"rules": []interface{}{map[string]interface{}{"header": "x-version"}}},
+		// query parameter can not be removed
+		{"rules": []interface{}{map[string]interface{}{"query_parameter": "version", "metadata_key": "version", "remove": true}}},
+		// undefined variable
+		{"rules": []interface{}{map[string]interface{}{"header": "x-version", "variable": "undefined_variable"}}},
+		// invalid regex
+		{"rules": []interface{}{map[string]interface{}{"header": "x-version", "metadata_key": "version", "value_rewrite": map[string]interface{}{"pattern": "("}}}},
+		{"path_rewrite": map[string]interface{}{"pattern": "["}},
+		{"request_headers_to_rename": []interface{}{map[string]interface{}{"from": "x-old"}}},
+	} {
+		if _, err := CreateHeaderToMetadataFilterFactory(conf); err == nil {
+			t.Errorf("expected an error for config %v", conf)
+		}
+	}
+}
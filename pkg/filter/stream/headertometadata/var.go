@@ -0,0 +1,55 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package headertometadata
+
+import (
+	"context"
+
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/variable"
+)
+
+const (
+	// the prefix of a dynamic metadata, like dynamic_metadata_version
+	dynamicMetadataPrefix = "dynamic_metadata_"
+	dynamicMetadataIndex  = len(dynamicMetadataPrefix)
+)
+
+func init() {
+	variable.RegisterPrefixVariable(dynamicMetadataPrefix, variable.NewBasicVariable(dynamicMetadataPrefix, nil, dynamicMetadataGetter, nil, 0))
+}
+
+func dynamicMetadataGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	metadata, _ := mosnctx.Get(ctx, types.ContextKeyDynamicMetadata).(map[string]string)
+	name := data.(string)
+	if v, ok := metadata[name[dynamicMetadataIndex:]]; ok {
+		return v, nil
+	}
+	return variable.ValueNotFound, nil
+}
+
+// setDynamicMetadata sets the metadata in the stream context, which is matched by the subset load balancer
+func setDynamicMetadata(ctx context.Context, key, value string) {
+	metadata, _ := mosnctx.Get(ctx, types.ContextKeyDynamicMetadata).(map[string]string)
+	if metadata == nil {
+		metadata = make(map[string]string)
+		// the stream context is updated in place
+		mosnctx.WithValue(ctx, types.ContextKeyDynamicMetadata, metadata)
+	}
+	metadata[key] = value
+}
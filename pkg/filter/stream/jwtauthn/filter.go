@@ -0,0 +1,205 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package jwtauthn
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/protocol/xprotocol"
+	"mosn.io/mosn/pkg/router"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/variable"
+	"mosn.io/pkg/buffer"
+)
+
+const wwwAuthenticate = "www-authenticate"
+
+// rule selects the providers for the requests matched
+type rule struct {
+	prefix       string
+	path         string
+	regex        *regexp.Regexp
+	headers      []*types.HeaderData
+	providers    []*provider
+	allowMissing bool
+}
+
+func (r *rule) match(headers api.HeaderMap) bool {
+	if r.prefix != "" || r.path != "" || r.regex != nil {
+		path, _ := headers.Get(protocol.MosnHeaderPathKey)
+		switch {
+		case r.path != "" && path != r.path:
+			return false
+		case r.prefix != "" && !strings.HasPrefix(path, r.prefix):
+			return false
+		case r.regex != nil && !r.regex.MatchString(path):
+			return false
+		}
+	}
+	return router.ConfigUtilityInst.MatchHeaders(headers, r.headers)
+}
+
+// authenticator is shared by the filters created by a factory
+type authenticator struct {
+	providers map[string]*provider
+	rules     []*rule
+}
+
+func newAuthenticator(cfg *v2.JWTAuthnConfig) (*authenticator, error) {
+	a := &authenticator{
+		providers: make(map[string]*provider, len(cfg.Providers)),
+	}
+	for name, pc := range cfg.Providers {
+		if pc == nil {
+			return nil, fmt.Errorf("jwt provider %s is empty", name)
+		}
+		p, err := newProvider(name, pc)
+		if err != nil {
+			return nil, fmt.Errorf("jwt provider %s: %v", name, err)
+		}
+		a.providers[name] = p
+	}
+	if len(cfg.Rules) == 0 {
+		names := make([]string, 0, len(a.providers))
+		for name := range a.providers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		all := &rule{}
+		for _, name := range names {
+			all.providers = append(all.providers, a.providers[name])
+		}
+		a.rules = []*rule{all}
+		return a, nil
+	}
+	for _, rc := range cfg.Rules {
+		r := &rule{
+			prefix:       rc.Prefix,
+			path:         rc.Path,
+			headers:      router.GetRouterHeaders(rc.Headers),
+			allowMissing: rc.AllowMissing,
+		}
+		if rc.Regex != "" {
+			regex, err := regexp.Compile(rc.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid jwt rule regex %s: %v", rc.Regex, err)
+			}
+			r.regex = regex
+		}
+		for _, name := range rc.Requires {
+			p, ok := a.providers[name]
+			if !ok {
+				return nil, fmt.Errorf("jwt provider %s is not found", name)
+			}
+			r.providers = append(r.providers, p)
+		}
+		a.rules = append(a.rules, r)
+	}
+	return a, nil
+}
+
+func (a *authenticator) match(headers api.HeaderMap) *rule {
+	for _, r := range a.rules {
+		if r.match(headers) {
+			return r
+		}
+	}
+	return nil
+}
+
+// jwtAuthnFilter is an implement of api.StreamReceiverFilter, verifies the jwt of the requests
+type jwtAuthnFilter struct {
+	handler       api.StreamReceiverFilterHandler
+	authenticator *authenticator
+}
+
+func newJWTAuthnFilter(authenticator *authenticator) api.StreamReceiverFilter {
+	return &jwtAuthnFilter{
+		authenticator: authenticator,
+	}
+}
+
+func (f *jwtAuthnFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.handler = handler
+}
+
+func (f *jwtAuthnFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	r := f.authenticator.match(headers)
+	if r == nil || len(r.providers) == 0 {
+		return api.StreamFilterContinue
+	}
+	for _, p := range r.providers {
+		p.sanitize(headers)
+	}
+	err := errJWTMissing
+	now := time.Now()
+	for _, p := range r.providers {
+		tk := p.extract(headers)
+		if tk == nil {
+			continue
+		}
+		t, verr := p.verify(tk.value, now)
+		if verr != nil {
+			if log.Proxy.GetLogLevel() >= log.DEBUG {
+				log.Proxy.Debugf(ctx, "[stream filter] [jwt_authn] provider %s verify failed: %v", p.name, verr)
+			}
+			err = verr
+			continue
+		}
+		p.forward(t, tk, headers)
+		variable.SetVariableValue(ctx, VarJWTPayload, t.payload())
+		return api.StreamFilterContinue
+	}
+	if err == errJWTMissing && r.allowMissing {
+		return api.StreamFilterContinue
+	}
+	f.reject(ctx, headers, r, err)
+	return api.StreamFilterStop
+}
+
+// reject replies 401, or 403 if the audiences are not allowed. The hijack reply is built
+// from the request headers by the protocols, so the token headers are removed first
+func (f *jwtAuthnFilter) reject(ctx context.Context, headers api.HeaderMap, r *rule, err error) {
+	log.Proxy.Infof(ctx, "[stream filter] [jwt_authn] request rejected: %v", err)
+	code := types.UnauthorizedCode
+	if err == errJWTBadAudience {
+		code = types.PermissionDeniedCode
+	}
+	for _, p := range r.providers {
+		p.removeTokenHeaders(headers)
+	}
+	if _, ok := headers.(xprotocol.XFrame); !ok {
+		host, _ := headers.Get(protocol.MosnHeaderHostKey)
+		challenge := fmt.Sprintf(`Bearer realm="%s"`, host)
+		if err != errJWTMissing {
+			challenge += fmt.Sprintf(`, error="invalid_token", error_description="%s"`, err)
+		}
+		headers.Set(wwwAuthenticate, challenge)
+	}
+	f.handler.SendHijackReply(code, headers)
+}
+
+func (f *jwtAuthnFilter) OnDestroy() {}
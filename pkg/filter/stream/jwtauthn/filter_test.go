@@ -0,0 +1,194 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package jwtauthn
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/variable"
+)
+
+type mockHandler struct {
+	api.StreamReceiverFilterHandler
+	code    int
+	headers api.HeaderMap
+}
+
+func (h *mockHandler) SendHijackReply(code int, headers api.HeaderMap) {
+	h.code = code
+	h.headers = headers
+}
+
+func newTestFilter(t *testing.T, cfg *v2.JWTAuthnConfig) func(headers protocol.CommonHeader) (context.Context, *mockHandler, api.StreamFilterStatus) {
+	for _, p := range cfg.Providers {
+		p.LocalJWKS = &v2.LocalJWKS{InlineString: testJWKS()}
+	}
+	a, err := newAuthenticator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func(headers protocol.CommonHeader) (context.Context, *mockHandler, api.StreamFilterStatus) {
+		ctx := variable.NewVariableContext(context.Background())
+		handler := &mockHandler{}
+		f := newJWTAuthnFilter(a)
+		f.SetReceiveFilterHandler(handler)
+		return ctx, handler, f.OnReceive(ctx, headers, nil, nil)
+	}
+}
+
+func TestFilterDefaultLocations(t *testing.T) {
+	run := newTestFilter(t, &v2.JWTAuthnConfig{
+		Providers: map[string]*v2.JWTProvider{
+			"mosn": {
+				Issuer: "https://issuer.mosn.io",
+				ClaimToHeaders: []*v2.JWTClaimToHeader{
+					{HeaderName: "x-jwt-sub", ClaimName: "sub"},
+					{HeaderName: "x-jwt-org", ClaimName: "org.name"},
+				},
+				ForwardPayloadHeader: "x-jwt-payload",
+			},
+		},
+	})
+	token := signJWT(t, "RS256", "rsa", validClaims())
+
+	// from the authorization header, the forged claim header is replaced
+	headers := protocol.CommonHeader{"authorization": "Bearer " + token, "x-jwt-sub": "admin"}
+	ctx, handler, status := run(headers)
+	if status != api.StreamFilterContinue || handler.code != 0 {
+		t.Fatalf("expected the request is allowed, but got %v %d", status, handler.code)
+	}
+	if _, ok := headers.Get("authorization"); ok {
+		t.Error("expected the token header is removed")
+	}
+	if sub, _ := headers.Get("x-jwt-sub"); sub != "user@mosn.io" {
+		t.Errorf("unexpected claim header: %s", sub)
+	}
+	if org, _ := headers.Get("x-jwt-org"); org != "mosn" {
+		t.Errorf("unexpected nested claim header: %s", org)
+	}
+	if payload, _ := headers.Get("x-jwt-payload"); payload != strings.Split(token, ".")[1] {
+		t.Errorf("unexpected payload header: %s", payload)
+	}
+	if sub, err := variable.GetVariableValue(ctx, "jwt_claim_sub"); err != nil || sub != "user@mosn.io" {
+		t.Errorf("unexpected claim variable: %s, %v", sub, err)
+	}
+
+	// from the query parameter
+	headers = protocol.CommonHeader{protocol.MosnHeaderQueryStringKey: "access_token=" + token + "&a=b"}
+	if _, _, status := run(headers); status != api.StreamFilterContinue {
+		t.Fatal("expected the request with token in query is allowed")
+	}
+	if query, _ := headers.Get(protocol.MosnHeaderQueryStringKey); query != "a=b" {
+		t.Errorf("expected the token parameter is removed, but got %s", query)
+	}
+
+	// the request without token is rejected
+	_, handler, status = run(protocol.CommonHeader{protocol.MosnHeaderHostKey: "mosn.io"})
+	if status != api.StreamFilterStop || handler.code != types.UnauthorizedCode {
+		t.Fatalf("expected the request is rejected, but got %v %d", status, handler.code)
+	}
+	if challenge, _ := handler.headers.Get(wwwAuthenticate); challenge != `Bearer realm="mosn.io"` {
+		t.Errorf("unexpected challenge: %s", challenge)
+	}
+
+	// the invalid token is not replied
+	_, handler, _ = run(protocol.CommonHeader{"authorization": "Bearer invalid"})
+	if handler.code != types.UnauthorizedCode {
+		t.Fatalf("expected the request is rejected, but got %d", handler.code)
+	}
+	if _, ok := handler.headers.Get("authorization"); ok {
+		t.Error("expected the token header is removed from the reply")
+	}
+	if challenge, _ := handler.headers.Get(wwwAuthenticate); !strings.Contains(challenge, `error="invalid_token"`) {
+		t.Errorf("unexpected challenge: %s", challenge)
+	}
+}
+
+func TestFilterRules(t *testing.T) {
+	run := newTestFilter(t, &v2.JWTAuthnConfig{
+		Providers: map[string]*v2.JWTProvider{
+			"api": {
+				Audiences:   []string{"api"},
+				FromHeaders: []*v2.JWTHeader{{Name: "x-api-token"}},
+				Forward:     true,
+			},
+			"web": {
+				Audiences:   []string{"web"},
+				FromCookies: []string{"session"},
+			},
+		},
+		Rules: []*v2.JWTRule{
+			{Prefix: "/health"},
+			{Prefix: "/public", Requires: []string{"web"}, AllowMissing: true},
+			{Prefix: "/", Requires: []string{"api", "web"}},
+		},
+	})
+	token := signJWT(t, "ES256", "ec", validClaims())
+	other := validClaims()
+	other["aud"] = "other"
+	otherToken := signJWT(t, "ES256", "ec", other)
+	for i, tc := range []struct {
+		headers protocol.CommonHeader
+		code    int
+	}{
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/health"}, 0},
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/public/index"}, 0},
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/public/index", "cookie": "a=b; session=invalid"}, types.UnauthorizedCode},
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/api", "x-api-token": token}, 0},
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/api", "cookie": "session=" + token}, 0},
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/api"}, types.UnauthorizedCode},
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/api", "x-api-token": otherToken}, types.PermissionDeniedCode},
+	} {
+		_, handler, _ := run(tc.headers)
+		if handler.code != tc.code {
+			t.Errorf("#%d expected code %d, but got %d", i, tc.code, handler.code)
+		}
+		if tc.code == 0 && tc.headers["x-api-token"] != "" && tc.headers["x-api-token"] != token {
+			t.Errorf("#%d expected the token is forwarded", i)
+		}
+	}
+}
+
+func TestCreateJWTAuthnFilterFactory(t *testing.T) {
+	for i, conf := range []map[string]interface{}{
+		{"providers": map[string]interface{}{"mosn": map[string]interface{}{}}},
+		{"providers": map[string]interface{}{"mosn": map[string]interface{}{"local_jwks": map[string]interface{}{"inline_string": "invalid"}}}},
+		{"rules": []interface{}{map[string]interface{}{"requires": []interface{}{"unknown"}}}},
+	} {
+		if _, err := CreateJWTAuthnFilterFactory(conf); err == nil {
+			t.Errorf("#%d expected an error", i)
+		}
+	}
+	conf := map[string]interface{}{
+		"providers": map[string]interface{}{
+			"mosn": map[string]interface{}{
+				"issuer":     "https://issuer.mosn.io",
+				"local_jwks": map[string]interface{}{"inline_string": testJWKS()},
+				"clock_skew": "10s",
+			},
+		},
+	}
+	if _, err := CreateJWTAuthnFilterFactory(conf); err != nil {
+		t.Fatalf("create factory failed: %v", err)
+	}
+}
@@ -0,0 +1,341 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package jwtauthn
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/utils"
+)
+
+const (
+	defaultFetchTimeout  = time.Second
+	defaultCacheDuration = 5 * time.Minute
+	// the fetching is not retried in the interval after a failure, if no keys are fetched
+	fetchRetryInterval = time.Second
+	maxJWKSSize        = 1 << 20
+)
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// rsa
+	N string `json:"n"`
+	E string `json:"e"`
+	// ec
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// oct
+	K string `json:"k"`
+}
+
+// parseJWKS parses the keys for signature, the keys not supported are ignored
+func parseJWKS(data []byte) ([]*jwk, error) {
+	var set struct {
+		Keys []*jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid jwks: %v", err)
+	}
+	keys := make([]*jwk, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := parseJWK(k)
+		if err != nil {
+			log.DefaultLogger.Warnf("[stream filter] [jwt_authn] ignore the key %s: %v", k.Kid, err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no valid keys in jwks")
+	}
+	return keys, nil
+}
+
+func parseJWK(k *jsonWebKey) (*jwk, error) {
+	key := &jwk{kid: k.Kid, alg: k.Alg}
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeSegment(k.N)
+		if err != nil || len(n) == 0 {
+			return nil, errors.New("invalid rsa modulus")
+		}
+		e, err := decodeSegment(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid rsa exponent")
+		}
+		key.rsa = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeSegment(k.X)
+		if err != nil {
+			return nil, errors.New("invalid ec x")
+		}
+		y, err := decodeSegment(k.Y)
+		if err != nil {
+			return nil, errors.New("invalid ec y")
+		}
+		key.ecdsa = &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !curve.IsOnCurve(key.ecdsa.X, key.ecdsa.Y) {
+			return nil, errors.New("invalid ec point")
+		}
+	case "oct":
+		secret, err := decodeSegment(k.K)
+		if err != nil || len(secret) == 0 {
+			return nil, errors.New("invalid oct key")
+		}
+		key.secret = secret
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+	return key, nil
+}
+
+// keySource provides the keys of a provider
+type keySource interface {
+	keys() ([]*jwk, error)
+}
+
+type localJWKS []*jwk
+
+func newLocalJWKS(cfg *v2.LocalJWKS) (localJWKS, error) {
+	data := []byte(cfg.InlineString)
+	if cfg.Filename != "" {
+		var err error
+		if data, err = ioutil.ReadFile(cfg.Filename); err != nil {
+			return nil, err
+		}
+	}
+	return parseJWKS(data)
+}
+
+func (k localJWKS) keys() ([]*jwk, error) {
+	return k, nil
+}
+
+// remoteJWKS fetches the keys and caches them. The expired keys are still used
+// until the keys are refreshed in background
+type remoteJWKS struct {
+	uri           *url.URL
+	cluster       string
+	cacheDuration time.Duration
+	client        *http.Client
+	// chooseHost chooses the address of the cluster to dial
+	chooseHost func(cluster string) (string, error)
+
+	mux      sync.Mutex
+	cached   []*jwk
+	expireAt time.Time
+	failedAt time.Time
+	// inflight is closed when the fetching for the first time is finished
+	inflight chan struct{}
+	fetching uint32
+}
+
+func newRemoteJWKS(cfg *v2.RemoteJWKS) (*remoteJWKS, error) {
+	uri, err := url.Parse(cfg.URI)
+	if err != nil {
+		return nil, err
+	}
+	if uri.Scheme != "http" && uri.Scheme != "https" {
+		return nil, fmt.Errorf("invalid jwks uri %s", cfg.URI)
+	}
+	r := &remoteJWKS{
+		uri:           uri,
+		cluster:       cfg.Cluster,
+		cacheDuration: cfg.CacheDuration.Duration,
+		chooseHost:    chooseClusterHost,
+	}
+	// the request keeps the original host, so the tls server name is verified by it,
+	// only the connection is dialed to the host of the cluster
+	r.client = &http.Client{
+		Timeout: cfg.Timeout.Duration,
+		Transport: &http.Transport{
+			DialContext:     r.dial,
+			TLSClientConfig: &tls.Config{ServerName: uri.Hostname()},
+		},
+	}
+	if r.cacheDuration <= 0 {
+		r.cacheDuration = defaultCacheDuration
+	}
+	if r.client.Timeout <= 0 {
+		r.client.Timeout = defaultFetchTimeout
+	}
+	return r, nil
+}
+
+func (r *remoteJWKS) keys() ([]*jwk, error) {
+	r.mux.Lock()
+	cached, expired := r.cached, time.Now().After(r.expireAt)
+	r.mux.Unlock()
+	if cached == nil {
+		return r.fetchSync()
+	}
+	if expired && atomic.CompareAndSwapUint32(&r.fetching, 0, 1) {
+		utils.GoWithRecover(func() {
+			defer atomic.StoreUint32(&r.fetching, 0)
+			r.refresh()
+		}, nil)
+	}
+	return cached, nil
+}
+
+// fetchSync fetches the keys for the first time, the requests are waiting for it.
+// the concurrent requests share one fetching, and the lock is not held during it
+func (r *remoteJWKS) fetchSync() ([]*jwk, error) {
+	r.mux.Lock()
+	if r.cached != nil {
+		keys := r.cached
+		r.mux.Unlock()
+		return keys, nil
+	}
+	if time.Since(r.failedAt) < fetchRetryInterval {
+		r.mux.Unlock()
+		return nil, errJWKSUnavailable
+	}
+	wait := r.inflight
+	if wait != nil {
+		r.mux.Unlock()
+		<-wait
+	} else {
+		wait = make(chan struct{})
+		r.inflight = wait
+		r.mux.Unlock()
+
+		keys, err := r.fetch()
+
+		r.mux.Lock()
+		if err != nil {
+			log.DefaultLogger.Errorf("[stream filter] [jwt_authn] fetch jwks from %s failed: %v", r.uri, err)
+			r.failedAt = time.Now()
+		} else {
+			r.cached = keys
+			r.expireAt = time.Now().Add(r.cacheDuration)
+		}
+		r.inflight = nil
+		r.mux.Unlock()
+		close(wait)
+	}
+	r.mux.Lock()
+	keys := r.cached
+	r.mux.Unlock()
+	if keys == nil {
+		return nil, errJWKSUnavailable
+	}
+	return keys, nil
+}
+
+func (r *remoteJWKS) refresh() {
+	keys, err := r.fetch()
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if err != nil {
+		// keep the stale keys, and retry later
+		log.DefaultLogger.Errorf("[stream filter] [jwt_authn] refresh jwks from %s failed: %v", r.uri, err)
+		r.expireAt = time.Now().Add(fetchRetryInterval)
+		return
+	}
+	r.cached = keys
+	r.expireAt = time.Now().Add(r.cacheDuration)
+}
+
+// dial connects to a host of the cluster instead of the host of the uri, if the cluster is specified
+func (r *remoteJWKS) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	if r.cluster != "" {
+		host, err := r.chooseHost(r.cluster)
+		if err != nil {
+			return nil, err
+		}
+		addr = host
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, network, addr)
+}
+
+func (r *remoteJWKS) fetch() ([]*jwk, error) {
+	resp, err := r.client.Get(r.uri.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return nil, err
+	}
+	return parseJWKS(data)
+}
+
+func chooseClusterHost(clusterName string) (string, error) {
+	adapter := cluster.GetClusterMngAdapterInstance()
+	if adapter == nil {
+		return "", errors.New("cluster manager is not initialized")
+	}
+	snapshot := adapter.GetClusterSnapshot(context.Background(), clusterName)
+	if snapshot == nil {
+		return "", fmt.Errorf("cluster %s is not found", clusterName)
+	}
+	host := snapshot.LoadBalancer().ChooseHost(nil)
+	if host == nil {
+		return "", fmt.Errorf("no available hosts in cluster %s", clusterName)
+	}
+	return host.AddressString(), nil
+}
@@ -0,0 +1,275 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package jwtauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	errJWTMissing      = errors.New("jwt is missing")
+	errJWTMalformed    = errors.New("jwt is malformed")
+	errJWTUnsupportAlg = errors.New("jwt algorithm is not supported")
+	errJWTExpired      = errors.New("jwt is expired")
+	errJWTNotYetValid  = errors.New("jwt is not yet valid")
+	errJWTBadIssuer    = errors.New("jwt issuer is not configured")
+	errJWTBadAudience  = errors.New("audiences in jwt are not allowed")
+	errJWTUnknownKey   = errors.New("jwt verification key is not found")
+	errJWTBadSignature = errors.New("jwt verification fails")
+	errJWKSUnavailable = errors.New("jwks is not available")
+)
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwt is a token parsed, the signature is not verified
+type jwt struct {
+	header     jwtHeader
+	claims     map[string]interface{}
+	rawPayload string
+	signed     string
+	signature  []byte
+}
+
+func parseJWT(token string) (*jwt, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errJWTMalformed
+	}
+	t := &jwt{
+		rawPayload: parts[1],
+		signed:     token[:len(parts[0])+len(parts[1])+1],
+	}
+	header, err := decodeSegment(parts[0])
+	if err != nil {
+		return nil, errJWTMalformed
+	}
+	if err := json.Unmarshal(header, &t.header); err != nil {
+		return nil, errJWTMalformed
+	}
+	payload, err := decodeSegment(parts[1])
+	if err != nil {
+		return nil, errJWTMalformed
+	}
+	// the numbers are kept as is, which may be forwarded in headers
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&t.claims); err != nil {
+		return nil, errJWTMalformed
+	}
+	if t.signature, err = decodeSegment(parts[2]); err != nil {
+		return nil, errJWTMalformed
+	}
+	return t, nil
+}
+
+// decodeSegment decodes the base64url segment, the padding is optional
+func decodeSegment(seg string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(seg, "="))
+}
+
+// payload returns the json of the claims
+func (t *jwt) payload() string {
+	b, _ := decodeSegment(t.rawPayload)
+	return string(b)
+}
+
+// validate checks the registered claims
+func (t *jwt) validate(issuer string, audiences map[string]bool, skew time.Duration, now time.Time) error {
+	if exp, ok := t.numericDate("exp"); ok && now.After(exp.Add(skew)) {
+		return errJWTExpired
+	}
+	if nbf, ok := t.numericDate("nbf"); ok && now.Before(nbf.Add(-skew)) {
+		return errJWTNotYetValid
+	}
+	if issuer != "" {
+		if iss, _ := t.claims["iss"].(string); iss != issuer {
+			return errJWTBadIssuer
+		}
+	}
+	if len(audiences) > 0 {
+		matched := false
+		switch aud := t.claims["aud"].(type) {
+		case string:
+			matched = audiences[aud]
+		case []interface{}:
+			for _, a := range aud {
+				if s, ok := a.(string); ok && audiences[s] {
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			return errJWTBadAudience
+		}
+	}
+	return nil
+}
+
+func (t *jwt) numericDate(name string) (time.Time, bool) {
+	n, ok := t.claims[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// verify checks the signature with the keys, the key id is used to select the keys if it is present
+func (t *jwt) verify(keys []*jwk) error {
+	hash, ok := algHash[t.header.Alg]
+	if !ok {
+		return errJWTUnsupportAlg
+	}
+	found := false
+	for _, key := range keys {
+		if t.header.Kid != "" && key.kid != t.header.Kid {
+			continue
+		}
+		if key.alg != "" && key.alg != t.header.Alg {
+			continue
+		}
+		if !key.support(t.header.Alg) {
+			continue
+		}
+		found = true
+		if key.verify(t.header.Alg, hash, t.signed, t.signature) {
+			return nil
+		}
+	}
+	if !found {
+		return errJWTUnknownKey
+	}
+	return errJWTBadSignature
+}
+
+var algHash = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"PS256": crypto.SHA256,
+	"PS384": crypto.SHA384,
+	"PS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+	"HS256": crypto.SHA256,
+	"HS384": crypto.SHA384,
+	"HS512": crypto.SHA512,
+}
+
+// jwk is a key of the jwks
+type jwk struct {
+	kid    string
+	alg    string
+	rsa    *rsa.PublicKey
+	ecdsa  *ecdsa.PublicKey
+	secret []byte
+}
+
+func (k *jwk) support(alg string) bool {
+	switch alg[0] {
+	case 'R', 'P':
+		return k.rsa != nil
+	case 'E':
+		return k.ecdsa != nil
+	case 'H':
+		return k.secret != nil
+	}
+	return false
+}
+
+func (k *jwk) verify(alg string, hash crypto.Hash, signed string, signature []byte) bool {
+	if alg[0] == 'H' {
+		mac := hmac.New(hash.New, k.secret)
+		mac.Write([]byte(signed))
+		return hmac.Equal(mac.Sum(nil), signature)
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+	switch alg[0] {
+	case 'R':
+		return rsa.VerifyPKCS1v15(k.rsa, hash, digest, signature) == nil
+	case 'P':
+		return rsa.VerifyPSS(k.rsa, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+	case 'E':
+		// the signature is r and s in fixed size
+		size := (k.ecdsa.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(k.ecdsa, digest, r, s)
+	}
+	return false
+}
+
+// claim returns the claim value, the nested claim is separated with dot
+func claim(claims map[string]interface{}, name string) (interface{}, bool) {
+	var value interface{} = claims
+	for _, key := range strings.Split(name, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// claimString formats the claim as a header value, the string array is joined with comma
+func claimString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			} else {
+				b, _ := json.Marshal(item)
+				values = append(values, string(b))
+			}
+		}
+		return strings.Join(values, ",")
+	}
+	b, _ := json.Marshal(value)
+	return string(b)
+}
@@ -0,0 +1,363 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package jwtauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+var (
+	testRSAKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	testECKey, _  = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testSecret    = []byte("test-secret")
+)
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func bigSegment(i *big.Int) string {
+	return encodeSegment(i.Bytes())
+}
+
+// testJWKS returns the jwks contains the test keys
+func testJWKS() string {
+	keys := []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "n": bigSegment(testRSAKey.N), "e": bigSegment(big.NewInt(int64(testRSAKey.E)))},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": bigSegment(testECKey.X), "y": bigSegment(testECKey.Y)},
+		{"kty": "oct", "kid": "hmac", "k": encodeSegment(testSecret)},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": bigSegment(testRSAKey.N), "e": "AQAB"},
+	}
+	b, _ := json.Marshal(map[string]interface{}{"keys": keys})
+	return string(b)
+}
+
+// signJWT signs the claims with the test keys
+func signJWT(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := encodeSegment(header) + "." + encodeSegment(payload)
+	hash := algHash[alg]
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+	var sig []byte
+	var err error
+	switch alg[0] {
+	case 'R':
+		sig, err = rsa.SignPKCS1v15(rand.Reader, testRSAKey, hash, digest)
+	case 'P':
+		sig, err = rsa.SignPSS(rand.Reader, testRSAKey, hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case 'E':
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, testECKey, digest)
+		// r and s are padded to the key size
+		sig = make([]byte, 64)
+		rb, sb := r.Bytes(), s.Bytes()
+		copy(sig[32-len(rb):32], rb)
+		copy(sig[64-len(sb):], sb)
+	case 'H':
+		mac := hmac.New(hash.New, testSecret)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + encodeSegment(sig)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss": "https://issuer.mosn.io",
+		"sub": "user@mosn.io",
+		"aud": []string{"api", "web"},
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"org": map[string]interface{}{"name": "mosn"},
+	}
+}
+
+func newTestProvider(t *testing.T, cfg *v2.JWTProvider) *provider {
+	if cfg.LocalJWKS == nil && cfg.RemoteJWKS == nil {
+		cfg.LocalJWKS = &v2.LocalJWKS{InlineString: testJWKS()}
+	}
+	p, err := newProvider("test", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestVerifyAlgorithms(t *testing.T) {
+	p := newTestProvider(t, &v2.JWTProvider{})
+	for _, tc := range []struct {
+		alg string
+		kid string
+	}{
+		{"RS256", "rsa"}, {"RS384", "rsa"}, {"RS512", "rsa"},
+		{"PS256", "rsa"}, {"ES256", "ec"}, {"HS256", "hmac"}, {"HS512", "hmac"},
+		// no key id, all the keys are tried
+		{"RS256", ""}, {"ES256", ""},
+	} {
+		token := signJWT(t, tc.alg, tc.kid, validClaims())
+		if _, err := p.verify(token, time.Now()); err != nil {
+			t.Errorf("%s %s verify failed: %v", tc.alg, tc.kid, err)
+		}
+	}
+}
+
+func TestVerifyErrors(t *testing.T) {
+	p := newTestProvider(t, &v2.JWTProvider{
+		Issuer:    "https://issuer.mosn.io",
+		Audiences: []string{"api"},
+	})
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-2 * time.Minute).Unix()
+	skewed := validClaims()
+	skewed["exp"] = time.Now().Add(-30 * time.Second).Unix()
+	notYet := validClaims()
+	notYet["nbf"] = time.Now().Add(time.Hour).Unix()
+	badIssuer := validClaims()
+	badIssuer["iss"] = "https://other.mosn.io"
+	badAudience := validClaims()
+	badAudience["aud"] = "other"
+	token := signJWT(t, "RS256", "rsa", validClaims())
+	for i, tc := range []struct {
+		token string
+		err   error
+	}{
+		{token, nil},
+		{signJWT(t, "RS256", "rsa", skewed), nil},
+		{"invalid", errJWTMalformed},
+		{"a.b.c", errJWTMalformed},
+		{signJWT(t, "RS256", "rsa", expired), errJWTExpired},
+		{signJWT(t, "RS256", "rsa", notYet), errJWTNotYetValid},
+		{signJWT(t, "RS256", "rsa", badIssuer), errJWTBadIssuer},
+		{signJWT(t, "RS256", "rsa", badAudience), errJWTBadAudience},
+		{signJWT(t, "RS256", "unknown", validClaims()), errJWTUnknownKey},
+		// the key used for encryption is ignored
+		{signJWT(t, "RS256", "enc", validClaims()), errJWTUnknownKey},
+		// the signature is signed by the other key
+		{signJWT(t, "HS256", "rsa", validClaims()), errJWTUnknownKey},
+		{token[:len(token)-4] + "AAAA", errJWTBadSignature},
+	} {
+		if _, err := p.verify(tc.token, time.Now()); err != tc.err {
+			t.Errorf("#%d expected error %v, but got %v", i, tc.err, err)
+		}
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	keys, err := parseJWKS([]byte(testJWKS()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the encryption key is ignored
+	if len(keys) != 3 {
+		t.Fatalf("expected 3 keys, but got %d", len(keys))
+	}
+	for _, data := range []string{
+		"invalid",
+		`{"keys": []}`,
+		`{"keys": [{"kty": "EC", "crv": "P-256", "x": "AQ", "y": "AQ"}]}`,
+		`{"keys": [{"kty": "unknown"}]}`,
+	} {
+		if _, err := parseJWKS([]byte(data)); err == nil {
+			t.Errorf("expected error of jwks %s", data)
+		}
+	}
+}
+
+func TestRemoteJWKS(t *testing.T) {
+	var requests int32
+	var fail int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, testJWKS())
+	}))
+	defer server.Close()
+
+	p := newTestProvider(t, &v2.JWTProvider{
+		RemoteJWKS: &v2.RemoteJWKS{
+			URI:           server.URL + "/jwks",
+			CacheDuration: api.DurationConfig{Duration: 100 * time.Millisecond},
+		},
+	})
+	token := signJWT(t, "ES256", "ec", validClaims())
+	for i := 0; i < 3; i++ {
+		if _, err := p.verify(token, time.Now()); err != nil {
+			t.Fatalf("verify failed: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Fatalf("expected the keys are cached, but fetched %d times", n)
+	}
+	// the stale keys are used if the refreshing failed
+	atomic.StoreInt32(&fail, 1)
+	time.Sleep(150 * time.Millisecond)
+	if _, err := p.verify(token, time.Now()); err != nil {
+		t.Fatalf("verify with stale keys failed: %v", err)
+	}
+	for i := 0; i < 50 && atomic.LoadInt32(&requests) != 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Fatalf("expected the keys are refreshed, but fetched %d times", n)
+	}
+}
+
+func TestRemoteJWKSUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	p := newTestProvider(t, &v2.JWTProvider{
+		RemoteJWKS: &v2.RemoteJWKS{URI: server.URL},
+	})
+	if _, err := p.verify(signJWT(t, "RS256", "rsa", validClaims()), time.Now()); err != errJWKSUnavailable {
+		t.Fatalf("expected jwks unavailable, but got %v", err)
+	}
+}
+
+func TestRemoteJWKSHTTPSCluster(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "example.com" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, testJWKS())
+	}))
+	defer server.Close()
+
+	// the certificate of the test server is valid for example.com
+	r, err := newRemoteJWKS(&v2.RemoteJWKS{
+		URI:     "https://example.com/jwks",
+		Cluster: "jwks_cluster",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.chooseHost = func(cluster string) (string, error) {
+		if cluster != "jwks_cluster" {
+			return "", fmt.Errorf("unexpected cluster %s", cluster)
+		}
+		return server.Listener.Addr().String(), nil
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	r.client.Transport.(*http.Transport).TLSClientConfig.RootCAs = roots
+	if keys, err := r.keys(); err != nil || len(keys) != 3 {
+		t.Fatalf("fetch jwks from https cluster failed, keys: %d, error: %v", len(keys), err)
+	}
+}
+
+func TestRemoteJWKSConcurrentFetch(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		fmt.Fprint(w, testJWKS())
+	}))
+	defer server.Close()
+
+	r, err := newRemoteJWKS(&v2.RemoteJWKS{URI: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	var failed int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := r.keys(); err != nil {
+				atomic.AddInt32(&failed, 1)
+			}
+		}()
+	}
+	for i := 0; i < 50 && atomic.LoadInt32(&requests) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// the lock is not held during the fetching
+	locked := make(chan struct{})
+	go func() {
+		r.mux.Lock()
+		r.mux.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("the lock is held during the fetching")
+	}
+	close(release)
+	wg.Wait()
+	if n := atomic.LoadInt32(&failed); n != 0 {
+		t.Fatalf("expected all the requests get the keys, but %d failed", n)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Fatalf("expected the concurrent fetching is merged, but fetched %d times", n)
+	}
+}
+
+func TestClaim(t *testing.T) {
+	tk, err := parseJWT(signJWT(t, "HS256", "hmac", map[string]interface{}{
+		"sub":    "user",
+		"scopes": []string{"read", "write"},
+		"org":    map[string]interface{}{"id": 1234567890},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]string{
+		"sub":    "user",
+		"scopes": "read,write",
+		"org.id": "1234567890",
+		"org":    `{"id":1234567890}`,
+	} {
+		value, ok := claim(tk.claims, name)
+		if !ok || claimString(value) != expected {
+			t.Errorf("claim %s expected %s, but got %v", name, expected, value)
+		}
+	}
+	if _, ok := claim(tk.claims, "sub.name"); ok {
+		t.Error("expected nested claim of a string is not found")
+	}
+}
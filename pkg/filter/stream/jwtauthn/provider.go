@@ -0,0 +1,200 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package jwtauthn
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+)
+
+const (
+	defaultClockSkew = 60 * time.Second
+	authorizationKey = "authorization"
+	bearerPrefix     = "Bearer "
+	accessTokenParam = "access_token"
+	cookieKey        = "cookie"
+)
+
+// provider verifies the tokens issued by an issuer
+type provider struct {
+	name      string
+	config    *v2.JWTProvider
+	audiences map[string]bool
+	clockSkew time.Duration
+	source    keySource
+}
+
+func newProvider(name string, cfg *v2.JWTProvider) (*provider, error) {
+	p := &provider{
+		name:      name,
+		config:    cfg,
+		audiences: make(map[string]bool, len(cfg.Audiences)),
+		clockSkew: defaultClockSkew,
+	}
+	for _, aud := range cfg.Audiences {
+		p.audiences[aud] = true
+	}
+	if cfg.ClockSkew != nil {
+		p.clockSkew = cfg.ClockSkew.Duration
+	}
+	var err error
+	switch {
+	case cfg.LocalJWKS != nil:
+		p.source, err = newLocalJWKS(cfg.LocalJWKS)
+	case cfg.RemoteJWKS != nil:
+		p.source, err = newRemoteJWKS(cfg.RemoteJWKS)
+	default:
+		err = errJWKSUnavailable
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// token is a token extracted from the request, remove deletes it from the request
+type token struct {
+	value  string
+	remove func(headers api.HeaderMap)
+}
+
+// locations returns the headers and the query parameters that contain the token
+func (p *provider) locations() ([]*v2.JWTHeader, []string) {
+	cfg := p.config
+	if len(cfg.FromHeaders) == 0 && len(cfg.FromParams) == 0 && len(cfg.FromCookies) == 0 {
+		return []*v2.JWTHeader{{Name: authorizationKey, ValuePrefix: bearerPrefix}}, []string{accessTokenParam}
+	}
+	return cfg.FromHeaders, cfg.FromParams
+}
+
+// removeTokenHeaders removes the headers that may contain the token
+func (p *provider) removeTokenHeaders(headers api.HeaderMap) {
+	fromHeaders, _ := p.locations()
+	for _, h := range fromHeaders {
+		headers.Del(h.Name)
+	}
+}
+
+// extract finds the token in the configured locations, the first found is returned
+func (p *provider) extract(headers api.HeaderMap) *token {
+	cfg := p.config
+	fromHeaders, fromParams := p.locations()
+	for _, h := range fromHeaders {
+		value, ok := headers.Get(h.Name)
+		if !ok || !strings.HasPrefix(value, h.ValuePrefix) {
+			continue
+		}
+		if value = strings.TrimSpace(value[len(h.ValuePrefix):]); value == "" {
+			continue
+		}
+		name := h.Name
+		return &token{
+			value: value,
+			remove: func(headers api.HeaderMap) {
+				headers.Del(name)
+			},
+		}
+	}
+	if len(fromParams) > 0 {
+		if query, ok := headers.Get(protocol.MosnHeaderQueryStringKey); ok && query != "" {
+			if values, err := url.ParseQuery(query); err == nil {
+				for _, param := range fromParams {
+					if value := values.Get(param); value != "" {
+						name := param
+						return &token{
+							value: value,
+							remove: func(headers api.HeaderMap) {
+								values.Del(name)
+								headers.Set(protocol.MosnHeaderQueryStringKey, values.Encode())
+							},
+						}
+					}
+				}
+			}
+		}
+	}
+	if len(cfg.FromCookies) > 0 {
+		if cookies, ok := headers.Get(cookieKey); ok {
+			for _, name := range cfg.FromCookies {
+				if value := cookieValue(cookies, name); value != "" {
+					// the cookies are not removed, as they may be used by the upstream
+					return &token{value: value, remove: func(api.HeaderMap) {}}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func cookieValue(cookies, name string) string {
+	for _, cookie := range strings.Split(cookies, ";") {
+		cookie = strings.TrimSpace(cookie)
+		if i := strings.IndexByte(cookie, '='); i > 0 && cookie[:i] == name {
+			return strings.Trim(cookie[i+1:], `"`)
+		}
+	}
+	return ""
+}
+
+// verify parses and verifies the token
+func (p *provider) verify(value string, now time.Time) (*jwt, error) {
+	t, err := parseJWT(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.validate(p.config.Issuer, p.audiences, p.clockSkew, now); err != nil {
+		return nil, err
+	}
+	keys, err := p.source.keys()
+	if err != nil {
+		return nil, err
+	}
+	if err := t.verify(keys); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// sanitize removes the headers set by the provider, so they are never forged by the downstream
+func (p *provider) sanitize(headers api.HeaderMap) {
+	if p.config.ForwardPayloadHeader != "" {
+		headers.Del(p.config.ForwardPayloadHeader)
+	}
+	for _, c := range p.config.ClaimToHeaders {
+		headers.Del(c.HeaderName)
+	}
+}
+
+// forward sets the payload and the claims to the request headers
+func (p *provider) forward(t *jwt, tk *token, headers api.HeaderMap) {
+	if !p.config.Forward {
+		tk.remove(headers)
+	}
+	if p.config.ForwardPayloadHeader != "" {
+		headers.Set(p.config.ForwardPayloadHeader, t.rawPayload)
+	}
+	for _, c := range p.config.ClaimToHeaders {
+		if value, ok := claim(t.claims, c.ClaimName); ok {
+			headers.Set(c.HeaderName, claimString(value))
+		}
+	}
+}
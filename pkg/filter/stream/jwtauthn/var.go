@@ -0,0 +1,56 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package jwtauthn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"mosn.io/mosn/pkg/variable"
+)
+
+const (
+	// VarJWTPayload is the json payload of the jwt verified
+	VarJWTPayload string = "jwt_payload"
+	// the prefix of a claim of the jwt verified, like jwt_claim_sub, the nested claim is separated with dot
+	jwtClaimPrefix string = "jwt_claim_"
+	jwtClaimIndex         = len(jwtClaimPrefix)
+)
+
+func init() {
+	variable.RegisterVariable(variable.NewIndexedVariable(VarJWTPayload, nil, nil, variable.BasicSetter, 0))
+	variable.RegisterPrefixVariable(jwtClaimPrefix, variable.NewBasicVariable(jwtClaimPrefix, nil, jwtClaimGetter, nil, 0))
+}
+
+func jwtClaimGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	payload, err := variable.GetVariableValue(ctx, VarJWTPayload)
+	if err != nil || payload == "" {
+		return variable.ValueNotFound, nil
+	}
+	var claims map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(payload)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&claims); err != nil {
+		return variable.ValueNotFound, nil
+	}
+	name := data.(string)
+	if c, ok := claim(claims, name[jwtClaimIndex:]); ok {
+		return claimString(c), nil
+	}
+	return variable.ValueNotFound, nil
+}
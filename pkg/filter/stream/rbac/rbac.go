@@ -0,0 +1,65 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rbac
+
+import (
+	"context"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/rbac"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// rbacFilter is an implement of api.StreamReceiverFilter, the requests denied are replied with 403
+type rbacFilter struct {
+	ctx        context.Context
+	handler    api.StreamReceiverFilterHandler
+	authorizer *rbac.Authorizer
+}
+
+// NewFilter creates a rbac filter with the authorizer shared by the factory
+func NewFilter(ctx context.Context, authorizer *rbac.Authorizer) api.StreamReceiverFilter {
+	return &rbacFilter{
+		ctx:        ctx,
+		authorizer: authorizer,
+	}
+}
+
+func (f *rbacFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.handler = handler
+}
+
+func (f *rbacFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	req := &rbac.Request{
+		Connection: f.handler.Connection(),
+		Headers:    headers,
+		Context:    ctx,
+	}
+	if !f.authorizer.Check(ctx, req) {
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(f.ctx, "[stream filter] [rbac] request denied")
+		}
+		f.handler.SendHijackReply(types.PermissionDeniedCode, headers)
+		return api.StreamFilterStop
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *rbacFilter) OnDestroy() {}
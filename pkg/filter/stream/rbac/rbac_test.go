@@ -0,0 +1,118 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rbac
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestParseRBACFilter(t *testing.T) {
+	m := map[string]interface{}{
+		"stat_prefix": "test",
+		"rules": map[string]interface{}{
+			"action": "DENY",
+			"policies": map[string]interface{}{
+				"deny-delete": map[string]interface{}{
+					"permissions": []interface{}{
+						map[string]interface{}{"method": map[string]interface{}{"exact": "DELETE"}},
+					},
+					"principals": []interface{}{
+						map[string]interface{}{"any": true},
+					},
+				},
+			},
+		},
+	}
+	cfg, err := ParseRBACFilter(m)
+	if err != nil {
+		t.Fatalf("parse rbac filter failed: %v", err)
+	}
+	if cfg.StatPrefix != "test" || cfg.Rules == nil || cfg.Rules.Action != v2.RBACDeny {
+		t.Fatalf("parse rbac filter unexpected: %+v", cfg)
+	}
+	policy := cfg.Rules.Policies["deny-delete"]
+	if policy == nil || len(policy.Permissions) != 1 || policy.Permissions[0].Method.Exact != "DELETE" ||
+		len(policy.Principals) != 1 || !policy.Principals[0].Any {
+		t.Fatalf("parse rbac policy unexpected: %+v", policy)
+	}
+}
+
+type mockConnection struct {
+	api.Connection
+}
+
+func (c *mockConnection) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 12345}
+}
+
+type mockHandler struct {
+	api.StreamReceiverFilterHandler
+	hijackCode int
+}
+
+func (h *mockHandler) Connection() api.Connection {
+	return &mockConnection{}
+}
+
+func (h *mockHandler) SendHijackReply(code int, headers api.HeaderMap) {
+	h.hijackCode = code
+}
+
+func TestRBACFilter(t *testing.T) {
+	factory, err := CreateRBACFilterFactory(map[string]interface{}{
+		"stat_prefix": t.Name(),
+		"rules": map[string]interface{}{
+			"action": "DENY",
+			"policies": map[string]interface{}{
+				"deny-delete": map[string]interface{}{
+					"permissions": []interface{}{
+						map[string]interface{}{"method": map[string]interface{}{"exact": "DELETE"}},
+					},
+					"principals": []interface{}{
+						map[string]interface{}{"any": true},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		method string
+		status api.StreamFilterStatus
+		code   int
+	}{
+		{"GET", api.StreamFilterContinue, 0},
+		{"DELETE", api.StreamFilterStop, types.PermissionDeniedCode},
+	} {
+		handler := &mockHandler{}
+		filter := NewFilter(context.Background(), factory.(*FilterConfigFactory).Authorizer)
+		filter.SetReceiveFilterHandler(handler)
+		headers := protocol.CommonHeader{protocol.MosnHeaderMethod: tc.method}
+		if status := filter.OnReceive(context.Background(), headers, nil, nil); status != tc.status || handler.hijackCode != tc.code {
+			t.Errorf("method %s expected status %v code %d, but got %v %d", tc.method, tc.status, tc.code, status, handler.hijackCode)
+		}
+	}
+}
@@ -20,8 +20,11 @@ package log
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 
 	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/mosn/pkg/variable"
 	"mosn.io/pkg/buffer"
@@ -36,6 +39,7 @@ var (
 	ErrLogFormatUndefined = errors.New("access log format undefined")
 	ErrEmptyVarDef        = errors.New("access log format error: empty variable definition")
 	ErrUnclosedVarDef     = errors.New("access log format error: unclosed variable definition")
+	ErrEmptyFields        = errors.New("access log format error: no fields for structured format")
 )
 
 const AccessLogLen = 1 << 8
@@ -48,6 +52,9 @@ func DisableAllAccessLog() {
 	DefaultDisableAccessLog = true
 	for _, lg := range accessLogs {
 		lg.logger.Toggle(true)
+		for _, out := range lg.outputs {
+			out.Toggle(true)
+		}
 	}
 }
 
@@ -56,10 +63,17 @@ type accesslog struct {
 	output  string
 	entries []*logEntry
 	logger  *log.Logger
+	// fields is not empty if the records are encoded as json or logfmt
+	fields     []*logField
+	formatType v2.AccessLogFormatType
+	filter     *logFilter
+	outputs    []*log.Logger
+	sinks      []api.AccessLog
 }
 
 type logEntry struct {
 	text     string
+	name     string
 	variable variable.Variable
 }
 
@@ -67,7 +81,8 @@ func (le *logEntry) log(ctx context.Context, buf buffer.IoBuffer) {
 	if le.text != "" {
 		buf.WriteString(le.text)
 	} else {
-		value, err := variable.GetVariableValue(ctx, le.variable.Name())
+		// use the name in format, the name of a prefix variable is the prefix only
+		value, err := variable.GetVariableValue(ctx, le.name)
 		if err != nil {
 			buf.WriteString(variable.ValueNotFound)
 		} else {
@@ -78,24 +93,68 @@ func (le *logEntry) log(ctx context.Context, buf buffer.IoBuffer) {
 
 // NewAccessLog
 func NewAccessLog(output string, format string) (api.AccessLog, error) {
-	lg, err := log.GetOrCreateLogger(output, nil)
+	return NewAccessLogWithConfig(v2.AccessLog{
+		Path:   output,
+		Format: format,
+	})
+}
+
+// NewAccessLogWithConfig creates an access log with the record format, filter and outputs in config
+func NewAccessLogWithConfig(config v2.AccessLog) (api.AccessLog, error) {
+	lg, err := log.GetOrCreateLogger(config.Path, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	entries, err := parseFormat(format)
+	entries, err := parseFormat(config.Format)
 	if err != nil {
 		return nil, err
 	}
 
 	l := &accesslog{
-		output:  output,
-		entries: entries,
-		logger:  lg,
+		output:     config.Path,
+		entries:    entries,
+		logger:     lg,
+		formatType: config.FormatType,
+	}
+
+	switch config.FormatType {
+	case "", v2.AccessLogFormatText:
+	case v2.AccessLogFormatJSON, v2.AccessLogFormatLogfmt:
+		if l.fields, err = parseFields(config.Fields, entries); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("access log format error: unknown format type %s", config.FormatType)
+	}
+
+	if config.Filter != nil {
+		if l.filter, err = newLogFilter(config.Filter); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, output := range config.Outputs {
+		out, err := log.GetOrCreateLogger(output, nil)
+		if err != nil {
+			return nil, err
+		}
+		l.outputs = append(l.outputs, out)
+	}
+
+	for _, cfg := range config.Sinks {
+		sink, err := CreateAccessLogSink(cfg.Type, cfg.Config)
+		if err != nil {
+			return nil, err
+		}
+		l.sinks = append(l.sinks, sink)
 	}
 
 	if DefaultDisableAccessLog {
 		lg.Toggle(true) // disable accesslog by default
+		for _, out := range l.outputs {
+			out.Toggle(true)
+		}
 	}
 	// save all access logs
 	accessLogs = append(accessLogs, l)
@@ -103,18 +162,50 @@ func NewAccessLog(output string, format string) (api.AccessLog, error) {
 	return l, nil
 }
 
+// Close closes the sinks of the access log, it is called when the access log is not used any more
+func (l *accesslog) Close() error {
+	for _, sink := range l.sinks {
+		if closer, ok := sink.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+	return nil
+}
+
 func (l *accesslog) Log(ctx context.Context, reqHeaders api.HeaderMap, respHeaders api.HeaderMap, requestInfo api.RequestInfo) {
 	// return directly
 	if l.logger.Disable() {
 		return
 	}
 
+	if l.filter != nil && !l.filter.match(requestInfo) {
+		return
+	}
+
 	buf := buffer.GetIoBuffer(AccessLogLen)
-	for idx := range l.entries {
-		l.entries[idx].log(ctx, buf)
+	switch l.formatType {
+	case v2.AccessLogFormatJSON:
+		formatJSON(ctx, l.fields, reqHeaders, respHeaders, buf)
+	case v2.AccessLogFormatLogfmt:
+		formatLogfmt(ctx, l.fields, reqHeaders, respHeaders, buf)
+	default:
+		for idx := range l.entries {
+			l.entries[idx].log(ctx, buf)
+		}
 	}
 	buf.WriteString("\n")
+
+	for _, out := range l.outputs {
+		// each logger releases the buffer it prints
+		copied := buffer.GetIoBuffer(buf.Len())
+		copied.Write(buf.Bytes())
+		out.Print(copied, true)
+	}
 	l.logger.Print(buf, true)
+
+	for _, sink := range l.sinks {
+		sink.Log(ctx, reqHeaders, respHeaders, requestInfo)
+	}
 }
 
 func parseFormat(format string) ([]*logEntry, error) {
@@ -149,7 +240,7 @@ func parseFormat(format string) ([]*logEntry, error) {
 					if err != nil {
 						return nil, err
 					}
-					entries = append(entries, &logEntry{variable: varEntry})
+					entries = append(entries, &logEntry{name: format[lastMark+1 : pos], variable: varEntry})
 				} else {
 					// ignore empty text
 					if pos > lastMark+1 {
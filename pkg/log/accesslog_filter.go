@@ -0,0 +1,114 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package log
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+var responseFlagsByName = map[string]api.ResponseFlag{
+	"NoHealthyUpstream":             api.NoHealthyUpstream,
+	"UpstreamRequestTimeout":        api.UpstreamRequestTimeout,
+	"UpstreamLocalReset":            api.UpstreamLocalReset,
+	"UpstreamRemoteReset":           api.UpstreamRemoteReset,
+	"UpstreamConnectionFailure":     api.UpstreamConnectionFailure,
+	"UpstreamConnectionTermination": api.UpstreamConnectionTermination,
+	"UpstreamOverflow":              api.UpstreamOverflow,
+	"NoRouteFound":                  api.NoRouteFound,
+	"DelayInjected":                 api.DelayInjected,
+	"FaultInjected":                 api.FaultInjected,
+	"RateLimited":                   api.RateLimited,
+	"ReqEntityTooLarge":             api.ReqEntityTooLarge,
+}
+
+// logFilter decides whether a request is logged
+type logFilter struct {
+	statusCodes   []v2.Int64Range
+	minDuration   time.Duration
+	responseFlags []api.ResponseFlag
+	// sampling is enabled if denominator is not zero
+	numerator   uint32
+	denominator uint32
+}
+
+func newLogFilter(config *v2.AccessLogFilter) (*logFilter, error) {
+	f := &logFilter{
+		statusCodes: config.StatusCodes,
+	}
+	if config.MinDuration != nil {
+		f.minDuration = config.MinDuration.Duration
+	}
+	for _, name := range config.ResponseFlags {
+		flag, ok := responseFlagsByName[name]
+		if !ok {
+			return nil, fmt.Errorf("access log filter error: unknown response flag %s", name)
+		}
+		f.responseFlags = append(f.responseFlags, flag)
+	}
+	if config.Sampling != nil {
+		f.numerator = config.Sampling.Numerator
+		f.denominator = config.Sampling.Denominator.Value()
+	}
+	return f, nil
+}
+
+// match returns true if all the conditions are matched.
+// Conditions on the request info are never matched if the request info is nil
+func (f *logFilter) match(requestInfo api.RequestInfo) bool {
+	if len(f.statusCodes) > 0 || f.minDuration > 0 || len(f.responseFlags) > 0 {
+		if requestInfo == nil {
+			return false
+		}
+	}
+	if len(f.statusCodes) > 0 && !f.matchStatusCode(int64(requestInfo.ResponseCode())) {
+		return false
+	}
+	if f.minDuration > 0 && requestInfo.Duration() < f.minDuration {
+		return false
+	}
+	if len(f.responseFlags) > 0 && !f.matchResponseFlags(requestInfo) {
+		return false
+	}
+	if f.denominator > 0 && uint32(rand.Int63n(int64(f.denominator))) >= f.numerator {
+		return false
+	}
+	return true
+}
+
+func (f *logFilter) matchStatusCode(code int64) bool {
+	for _, r := range f.statusCodes {
+		if code >= r.Start && code < r.End {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *logFilter) matchResponseFlags(requestInfo api.RequestInfo) bool {
+	for _, flag := range f.responseFlags {
+		if requestInfo.GetResponseFlag(flag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package log
+
+import (
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+func TestLogFilter(t *testing.T) {
+	slowRequest := &mock_requestInfo{
+		startTime:    time.Now().Add(-time.Second),
+		responseCode: 503,
+		responseFlag: api.UpstreamRequestTimeout,
+	}
+	fastRequest := &mock_requestInfo{
+		startTime:    time.Now(),
+		responseCode: 200,
+	}
+	testCases := []struct {
+		name     string
+		config   *v2.AccessLogFilter
+		info     api.RequestInfo
+		expected bool
+	}{
+		{"empty", &v2.AccessLogFilter{}, nil, true},
+		{"status code matched", &v2.AccessLogFilter{StatusCodes: []v2.Int64Range{{Start: 200, End: 300}, {Start: 500, End: 600}}}, slowRequest, true},
+		{"status code not matched", &v2.AccessLogFilter{StatusCodes: []v2.Int64Range{{Start: 500, End: 600}}}, fastRequest, false},
+		{"status code without request info", &v2.AccessLogFilter{StatusCodes: []v2.Int64Range{{Start: 500, End: 600}}}, nil, false},
+		{"duration matched", &v2.AccessLogFilter{MinDuration: &api.DurationConfig{Duration: 500 * time.Millisecond}}, slowRequest, true},
+		{"duration not matched", &v2.AccessLogFilter{MinDuration: &api.DurationConfig{Duration: 500 * time.Millisecond}}, fastRequest, false},
+		{"response flags matched", &v2.AccessLogFilter{ResponseFlags: []string{"NoHealthyUpstream", "UpstreamRequestTimeout"}}, slowRequest, true},
+		{"response flags not matched", &v2.AccessLogFilter{ResponseFlags: []string{"UpstreamRequestTimeout"}}, fastRequest, false},
+		{"sampling all", &v2.AccessLogFilter{Sampling: &v2.RuntimeFractionalPercent{Numerator: 100}}, nil, true},
+		{"sampling none", &v2.AccessLogFilter{Sampling: &v2.RuntimeFractionalPercent{Numerator: 0, Denominator: v2.MILLION}}, nil, false},
+		{"all conditions", &v2.AccessLogFilter{
+			StatusCodes:   []v2.Int64Range{{Start: 500, End: 600}},
+			ResponseFlags: []string{"UpstreamRequestTimeout"},
+			MinDuration:   &api.DurationConfig{Duration: 2 * time.Second},
+		}, slowRequest, false},
+	}
+	for _, tc := range testCases {
+		f, err := newLogFilter(tc.config)
+		if err != nil {
+			t.Fatalf("%s: create filter error: %v", tc.name, err)
+		}
+		if f.match(tc.info) != tc.expected {
+			t.Errorf("%s: expected %v", tc.name, tc.expected)
+		}
+	}
+}
+
+func TestLogFilterSampling(t *testing.T) {
+	f, _ := newLogFilter(&v2.AccessLogFilter{
+		Sampling: &v2.RuntimeFractionalPercent{Numerator: 25},
+	})
+	matched := 0
+	for i := 0; i < 10000; i++ {
+		if f.match(nil) {
+			matched++
+		}
+	}
+	if matched < 2000 || matched > 3000 {
+		t.Errorf("expected about 25%% sampled, but got %d/10000", matched)
+	}
+}
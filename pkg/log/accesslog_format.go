@@ -0,0 +1,247 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/variable"
+	"mosn.io/pkg/buffer"
+)
+
+const hex = "0123456789abcdef"
+
+// field value types in json records
+const (
+	fieldTypeString = "string"
+	fieldTypeInt    = "int"
+	fieldTypeFloat  = "float"
+	fieldTypeBool   = "bool"
+)
+
+// logField is a named field of json and logfmt records
+type logField struct {
+	name           string
+	typ            string
+	variable       string
+	requestHeader  string
+	responseHeader string
+}
+
+func (f *logField) value(ctx context.Context, reqHeaders api.HeaderMap, respHeaders api.HeaderMap) (string, bool) {
+	switch {
+	case f.requestHeader != "":
+		if reqHeaders == nil {
+			return "", false
+		}
+		return reqHeaders.Get(f.requestHeader)
+	case f.responseHeader != "":
+		if respHeaders == nil {
+			return "", false
+		}
+		return respHeaders.Get(f.responseHeader)
+	default:
+		value, err := variable.GetVariableValue(ctx, f.variable)
+		if err != nil {
+			return "", false
+		}
+		return value, true
+	}
+}
+
+// parseFields makes the fields of structured records. The variables in text format
+// are used as fields if no fields are configured
+func parseFields(configs []v2.AccessLogField, entries []*logEntry) ([]*logField, error) {
+	if len(configs) == 0 {
+		fields := make([]*logField, 0, len(entries))
+		for _, entry := range entries {
+			if entry.variable != nil {
+				fields = append(fields, &logField{
+					name:     entry.name,
+					typ:      fieldTypeString,
+					variable: entry.name,
+				})
+			}
+		}
+		if len(fields) == 0 {
+			return nil, ErrEmptyFields
+		}
+		return fields, nil
+	}
+
+	fields := make([]*logField, 0, len(configs))
+	for _, cfg := range configs {
+		if cfg.Name == "" {
+			return nil, fmt.Errorf("access log format error: field name is empty")
+		}
+		sources := 0
+		for _, source := range []string{cfg.Variable, cfg.RequestHeader, cfg.ResponseHeader} {
+			if source != "" {
+				sources++
+			}
+		}
+		if sources != 1 {
+			return nil, fmt.Errorf("access log format error: field %s should have exactly one of variable, request_header and response_header", cfg.Name)
+		}
+		if cfg.Variable != "" {
+			if _, err := variable.AddVariable(cfg.Variable); err != nil {
+				return nil, err
+			}
+		}
+		typ := cfg.Type
+		switch typ {
+		case "":
+			typ = fieldTypeString
+		case fieldTypeString, fieldTypeInt, fieldTypeFloat, fieldTypeBool:
+		default:
+			return nil, fmt.Errorf("access log format error: unknown type %s of field %s", cfg.Type, cfg.Name)
+		}
+		fields = append(fields, &logField{
+			name:           cfg.Name,
+			typ:            typ,
+			variable:       cfg.Variable,
+			requestHeader:  cfg.RequestHeader,
+			responseHeader: cfg.ResponseHeader,
+		})
+	}
+	return fields, nil
+}
+
+// formatJSON writes a json object, a field not found is written as null
+func formatJSON(ctx context.Context, fields []*logField, reqHeaders api.HeaderMap, respHeaders api.HeaderMap, buf buffer.IoBuffer) {
+	buf.WriteString("{")
+	for idx, field := range fields {
+		if idx > 0 {
+			buf.WriteString(",")
+		}
+		writeJSONString(buf, field.name)
+		buf.WriteString(":")
+		value, ok := field.value(ctx, reqHeaders, respHeaders)
+		if !ok {
+			buf.WriteString("null")
+			continue
+		}
+		writeJSONValue(buf, field.typ, value)
+	}
+	buf.WriteString("}")
+}
+
+func writeJSONValue(buf buffer.IoBuffer, typ string, value string) {
+	switch typ {
+	case fieldTypeInt:
+		if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+			buf.WriteString(value)
+			return
+		}
+	case fieldTypeFloat:
+		// NaN and Inf are not json numbers
+		if f, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+			buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+			return
+		}
+	case fieldTypeBool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			buf.WriteString(strconv.FormatBool(b))
+			return
+		}
+	}
+	writeJSONString(buf, value)
+}
+
+func writeJSONString(buf buffer.IoBuffer, s string) {
+	buf.WriteString(`"`)
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= 0x20 && c != '"' && c != '\\' && c < utf8.RuneSelf {
+			i++
+			continue
+		}
+		if c < utf8.RuneSelf {
+			buf.WriteString(s[start:i])
+			switch c {
+			case '"', '\\':
+				buf.WriteString("\\")
+				buf.WriteString(string(c))
+			case '\n':
+				buf.WriteString("\\n")
+			case '\r':
+				buf.WriteString("\\r")
+			case '\t':
+				buf.WriteString("\\t")
+			default:
+				buf.WriteString("\\u00")
+				buf.WriteString(string(hex[c>>4]))
+				buf.WriteString(string(hex[c&0xf]))
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf.WriteString(s[start:i])
+			buf.WriteString("\\ufffd")
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf.WriteString(s[start:])
+	buf.WriteString(`"`)
+}
+
+// formatLogfmt writes key=value pairs, a field not found is written as the variable.ValueNotFound
+func formatLogfmt(ctx context.Context, fields []*logField, reqHeaders api.HeaderMap, respHeaders api.HeaderMap, buf buffer.IoBuffer) {
+	for idx, field := range fields {
+		if idx > 0 {
+			buf.WriteString(" ")
+		}
+		buf.WriteString(field.name)
+		buf.WriteString("=")
+		value, ok := field.value(ctx, reqHeaders, respHeaders)
+		if !ok {
+			buf.WriteString(variable.ValueNotFound)
+			continue
+		}
+		if needsQuote(value) {
+			buf.WriteString(strconv.Quote(value))
+		} else {
+			buf.WriteString(value)
+		}
+	}
+}
+
+func needsQuote(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, c := range value {
+		if c <= ' ' || c == '=' || c == '"' || c == utf8.RuneError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,269 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/pkg/buffer"
+)
+
+type testHeaderMap map[string]string
+
+func (h testHeaderMap) Get(key string) (string, bool) {
+	v, ok := h[key]
+	return v, ok
+}
+
+func (h testHeaderMap) Set(key, value string) { h[key] = value }
+
+func (h testHeaderMap) Add(key, value string) { h[key] = value }
+
+func (h testHeaderMap) Del(key string) { delete(h, key) }
+
+func (h testHeaderMap) Range(f func(key, value string) bool) {
+	for k, v := range h {
+		if !f(k, v) {
+			return
+		}
+	}
+}
+
+func (h testHeaderMap) Clone() api.HeaderMap {
+	copied := testHeaderMap{}
+	for k, v := range h {
+		copied[k] = v
+	}
+	return copied
+}
+
+func (h testHeaderMap) ByteSize() uint64 {
+	var size uint64
+	for k, v := range h {
+		size += uint64(len(k) + len(v))
+	}
+	return size
+}
+
+func readAccessLog(t *testing.T, path string) string {
+	for i := 0; i < 30; i++ {
+		time.Sleep(100 * time.Millisecond)
+		if b, err := ioutil.ReadFile(path); err == nil && len(b) > 0 {
+			return string(b)
+		}
+	}
+	t.Fatalf("read access log %s timeout", path)
+	return ""
+}
+
+func TestAccessLogJSONFormat(t *testing.T) {
+	registerTestVarDefs()
+
+	logName := "/tmp/mosn_accesslog/json_access.log"
+	os.Remove(logName)
+	accessLog, err := NewAccessLogWithConfig(v2.AccessLog{
+		Path:       logName,
+		FormatType: v2.AccessLogFormatJSON,
+		Fields: []v2.AccessLogField{
+			{Name: "bytes_sent", Variable: varBytesSent, Type: "int"},
+			{Name: "upstream_local", Variable: varUpstreamLocalAddress},
+			{Name: "service", RequestHeader: "service"},
+			{Name: "server", ResponseHeader: "Server"},
+			{Name: "missing", RequestHeader: "not-exists"},
+			{Name: "quoted", RequestHeader: "quoted", Type: "int"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reqHeaders := testHeaderMap{"service": "test", "quoted": "a \"b\"\n"}
+	respHeaders := testHeaderMap{"Server": "MOSN"}
+	accessLog.Log(prepareLocalIpv4Ctx(), reqHeaders, respHeaders, nil)
+
+	line := readAccessLog(t, logName)
+	record := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(line), &record); err != nil {
+		t.Fatalf("unmarshal json record %s error: %v", line, err)
+	}
+	expected := map[string]interface{}{
+		"bytes_sent":     float64(2048),
+		"upstream_local": "127.0.0.1:23456",
+		"service":        "test",
+		"server":         "MOSN",
+		"missing":        nil,
+		"quoted":         "a \"b\"\n",
+	}
+	for k, v := range expected {
+		if record[k] != v {
+			t.Errorf("field %s expected %v, but got %v", k, v, record[k])
+		}
+	}
+	// fields keep the configured order
+	if !strings.HasPrefix(line, `{"bytes_sent":2048,"upstream_local":`) {
+		t.Errorf("unexpected record %s", line)
+	}
+}
+
+func TestAccessLogStructuredDefaultFields(t *testing.T) {
+	registerTestVarDefs()
+
+	logName := "/tmp/mosn_accesslog/logfmt_access.log"
+	os.Remove(logName)
+	accessLog, err := NewAccessLogWithConfig(v2.AccessLog{
+		Path:       logName,
+		Format:     "local %upstream_local_address% sent %bytes_sent%",
+		FormatType: v2.AccessLogFormatLogfmt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessLog.Log(prepareLocalIpv4Ctx(), nil, nil, nil)
+
+	line := readAccessLog(t, logName)
+	if line != "upstream_local_address=127.0.0.1:23456 bytes_sent=2048\n" {
+		t.Errorf("unexpected record %s", line)
+	}
+
+	// no variables for fields
+	_, err = NewAccessLogWithConfig(v2.AccessLog{
+		Path:       logName,
+		Format:     "text only",
+		FormatType: v2.AccessLogFormatJSON,
+	})
+	if err != ErrEmptyFields {
+		t.Errorf("expected empty fields error, but got %v", err)
+	}
+}
+
+func TestAccessLogInvalidConfig(t *testing.T) {
+	registerTestVarDefs()
+
+	logName := "/tmp/mosn_accesslog/invalid_access.log"
+	for _, cfg := range []v2.AccessLog{
+		{Path: logName, FormatType: "xml"},
+		{Path: logName, FormatType: v2.AccessLogFormatJSON, Fields: []v2.AccessLogField{{Variable: varBytesSent}}},
+		{Path: logName, FormatType: v2.AccessLogFormatJSON, Fields: []v2.AccessLogField{{Name: "a"}}},
+		{Path: logName, FormatType: v2.AccessLogFormatJSON, Fields: []v2.AccessLogField{{Name: "a", Variable: varBytesSent, RequestHeader: "a"}}},
+		{Path: logName, FormatType: v2.AccessLogFormatJSON, Fields: []v2.AccessLogField{{Name: "a", Variable: "not_registered"}}},
+		{Path: logName, FormatType: v2.AccessLogFormatJSON, Fields: []v2.AccessLogField{{Name: "a", Variable: varBytesSent, Type: "time"}}},
+		{Path: logName, Filter: &v2.AccessLogFilter{ResponseFlags: []string{"Unknown"}}},
+		{Path: logName, Sinks: []v2.AccessLogSink{{Type: "unknown"}}},
+	} {
+		if _, err := NewAccessLogWithConfig(cfg); err == nil {
+			t.Errorf("config %+v should be invalid", cfg)
+		}
+	}
+}
+
+func TestWriteJSONValue(t *testing.T) {
+	testCases := []struct {
+		typ      string
+		value    string
+		expected string
+	}{
+		{fieldTypeString, "plain", `"plain"`},
+		{fieldTypeString, "tab\tand\\slash\x01", `"tab\tand\\slash\u0001"`},
+		{fieldTypeString, "中文", `"中文"`},
+		{fieldTypeString, "bad\xffutf8", `"bad\ufffdutf8"`},
+		{fieldTypeInt, "-12", `-12`},
+		{fieldTypeInt, "1.5", `"1.5"`},
+		{fieldTypeFloat, "1.50", `1.5`},
+		{fieldTypeFloat, "NaN", `"NaN"`},
+		{fieldTypeBool, "TRUE", `true`},
+		{fieldTypeBool, "yes", `"yes"`},
+	}
+	for _, tc := range testCases {
+		buf := buffer.NewIoBuffer(16)
+		writeJSONValue(buf, tc.typ, tc.value)
+		if buf.String() != tc.expected {
+			t.Errorf("%s value %q expected %s, but got %s", tc.typ, tc.value, tc.expected, buf.String())
+		}
+	}
+}
+
+type testSink struct {
+	count int
+}
+
+func (s *testSink) Log(ctx context.Context, reqHeaders api.HeaderMap, respHeaders api.HeaderMap, requestInfo api.RequestInfo) {
+	s.count++
+}
+
+func TestAccessLogOutputsAndSinks(t *testing.T) {
+	registerTestVarDefs()
+
+	// a syslog server stub
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sink := &testSink{}
+	RegisterAccessLogSink("test", func(config map[string]interface{}) (api.AccessLog, error) {
+		return sink, nil
+	})
+
+	logName := "/tmp/mosn_accesslog/outputs_access.log"
+	os.Remove(logName)
+	accessLog, err := NewAccessLogWithConfig(v2.AccessLog{
+		Path:    logName,
+		Format:  "sent %bytes_sent%",
+		Outputs: []string{"syslog+udp://" + conn.LocalAddr().String()},
+		Sinks:   []v2.AccessLogSink{{Type: "test"}},
+		Filter: &v2.AccessLogFilter{
+			StatusCodes: []v2.Int64Range{{Start: 500, End: 600}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := prepareLocalIpv4Ctx()
+	info := newRequestInfo()
+	info.SetResponseCode(200)
+	accessLog.Log(ctx, nil, nil, info)
+	info.SetResponseCode(503)
+	accessLog.Log(ctx, nil, nil, info)
+
+	if line := readAccessLog(t, logName); line != "sent 2048\n" {
+		t.Errorf("unexpected record %s", line)
+	}
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	b := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(b)
+	if err != nil {
+		t.Fatalf("read syslog error: %v", err)
+	}
+	if !strings.Contains(string(b[:n]), "sent 2048") {
+		t.Errorf("unexpected syslog message %s", string(b[:n]))
+	}
+	if sink.count != 1 {
+		t.Errorf("sink should receive 1 record, but got %d", sink.count)
+	}
+}
@@ -0,0 +1,47 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package log
+
+import (
+	"fmt"
+
+	"mosn.io/api"
+)
+
+// AccessLogSinkCreator creates an access log sink according to config.
+// A sink receives the requests that pass the access log filter, and formats them by itself
+type AccessLogSinkCreator func(config map[string]interface{}) (api.AccessLog, error)
+
+var accessLogSinkFactory = make(map[string]AccessLogSinkCreator)
+
+// RegisterAccessLogSink registers the sinkType as AccessLogSinkCreator
+func RegisterAccessLogSink(sinkType string, creator AccessLogSinkCreator) {
+	accessLogSinkFactory[sinkType] = creator
+}
+
+// CreateAccessLogSink creates an access log sink according to sinkType
+func CreateAccessLogSink(sinkType string, config map[string]interface{}) (api.AccessLog, error) {
+	if creator, ok := accessLogSinkFactory[sinkType]; ok {
+		sink, err := creator(config)
+		if err != nil {
+			return nil, fmt.Errorf("create access log sink failed: %v", err)
+		}
+		return sink, nil
+	}
+	return nil, fmt.Errorf("unsupported access log sink type: %v", sinkType)
+}
@@ -0,0 +1,376 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package als sends access logs to a gRPC access log service (ALS)
+package als
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	alsdata "github.com/envoyproxy/go-control-plane/envoy/data/accesslog/v2"
+	alsservice "github.com/envoyproxy/go-control-plane/envoy/service/accesslog/v2"
+	gogotypes "github.com/gogo/protobuf/types"
+	"google.golang.org/grpc"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
+)
+
+// SinkType is the access log sink type of gRPC ALS
+const SinkType = "grpc"
+
+const (
+	defaultBufferSize = 1024
+	defaultBatchSize  = 64
+)
+
+func init() {
+	log.RegisterAccessLogSink(SinkType, NewGrpcSink)
+}
+
+// Config is the config of gRPC ALS sink
+type Config struct {
+	// Address is the ALS address, such as 127.0.0.1:9000
+	Address string `json:"address"`
+	// LogName identifies the log in the stream
+	LogName string `json:"log_name"`
+	// BufferSize is the max entries waiting to be sent, new entries are dropped if the buffer is full.
+	// The sinks with the same address and log name share the buffer created by the first sink
+	BufferSize int `json:"buffer_size,omitempty"`
+	// AdditionalRequestHeaders and AdditionalResponseHeaders are the headers added to the entries
+	AdditionalRequestHeaders  []string `json:"additional_request_headers,omitempty"`
+	AdditionalResponseHeaders []string `json:"additional_response_headers,omitempty"`
+}
+
+// grpcSink creates http access log entries, and sends them by the streamer of its address and log name
+type grpcSink struct {
+	config    *Config
+	streamer  *grpcStreamer
+	closeOnce sync.Once
+}
+
+// NewGrpcSink creates a gRPC ALS access log sink
+func NewGrpcSink(cfg map[string]interface{}) (api.AccessLog, error) {
+	config := &Config{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("parsing grpc access log sink error, err: %v, cfg: %v", err, cfg)
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("parsing grpc access log sink error, err: %v, cfg: %v", err, cfg)
+	}
+	if config.Address == "" {
+		return nil, errors.New("grpc access log sink's address is not specified")
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = defaultBufferSize
+	}
+	streamer, err := getOrCreateStreamer(config)
+	if err != nil {
+		return nil, err
+	}
+	return &grpcSink{
+		config:   config,
+		streamer: streamer,
+	}, nil
+}
+
+// Close releases the streamer of the sink, the streamer is closed if it is not used by any sinks.
+// The entries logged after the sink is closed may be dropped
+func (s *grpcSink) Close() error {
+	s.closeOnce.Do(func() {
+		releaseStreamer(s.streamer)
+	})
+	return nil
+}
+
+func (s *grpcSink) Log(ctx context.Context, reqHeaders api.HeaderMap, respHeaders api.HeaderMap, requestInfo api.RequestInfo) {
+	entry := s.newEntry(reqHeaders, respHeaders, requestInfo)
+	select {
+	case s.streamer.entries <- entry:
+	default:
+		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+			log.DefaultLogger.Debugf("[accesslog] [grpc sink] buffer is full, drop entry of %s", s.config.Address)
+		}
+	}
+}
+
+// streamers are indexed by address and log name, and counted by the sinks using them
+var (
+	streamersMutex sync.Mutex
+	streamers      = map[string]*grpcStreamer{}
+)
+
+// grpcStreamer streams http access log entries to ALS.
+// Entries are sent in batches by a goroutine, the stream is created again on the next batch if it fails.
+// The access logs are created again when the listeners are updated, so a streamer is shared by the sinks
+// with the same address and log name, instead of a connection and a goroutine for each sink.
+// The streamer is closed when all the sinks using it are closed
+type grpcStreamer struct {
+	key     string
+	address string
+	logName string
+	conn    *grpc.ClientConn
+	client  alsservice.AccessLogServiceClient
+	stream  alsservice.AccessLogService_StreamAccessLogsClient
+	entries chan *alsdata.HTTPAccessLogEntry
+	// refs is the count of the sinks, guarded by streamersMutex
+	refs int
+	done chan struct{}
+}
+
+func getOrCreateStreamer(config *Config) (*grpcStreamer, error) {
+	key := config.Address + "/" + config.LogName
+	streamersMutex.Lock()
+	defer streamersMutex.Unlock()
+	if s, ok := streamers[key]; ok {
+		s.refs++
+		return s, nil
+	}
+	// the connection is established in background
+	conn, err := grpc.Dial(config.Address, grpc.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+	s := &grpcStreamer{
+		key:     key,
+		address: config.Address,
+		logName: config.LogName,
+		conn:    conn,
+		client:  alsservice.NewAccessLogServiceClient(conn),
+		entries: make(chan *alsdata.HTTPAccessLogEntry, config.BufferSize),
+		refs:    1,
+		done:    make(chan struct{}),
+	}
+	streamers[key] = s
+	utils.GoWithRecover(s.run, nil)
+	return s, nil
+}
+
+// releaseStreamer closes the streamer if it is released by all the sinks
+func releaseStreamer(s *grpcStreamer) {
+	streamersMutex.Lock()
+	defer streamersMutex.Unlock()
+	s.refs--
+	if s.refs > 0 {
+		return
+	}
+	delete(streamers, s.key)
+	// the entries channel is not closed, so the sinks still in use do not panic.
+	// the connection is closed to stop the blocking sending
+	close(s.done)
+	s.conn.Close()
+	log.DefaultLogger.Infof("[accesslog] [grpc sink] close the streamer of %s, log name: %s", s.address, s.logName)
+}
+
+func (s *grpcStreamer) run() {
+	defer func() {
+		if s.stream != nil {
+			s.stream.CloseSend()
+		}
+	}()
+	batch := make([]*alsdata.HTTPAccessLogEntry, 0, defaultBatchSize)
+	for {
+		var entry *alsdata.HTTPAccessLogEntry
+		select {
+		case entry = <-s.entries:
+		case <-s.done:
+			return
+		}
+		batch = append(batch[:0], entry)
+	collect:
+		for len(batch) < defaultBatchSize {
+			select {
+			case entry := <-s.entries:
+				batch = append(batch, entry)
+			default:
+				break collect
+			}
+		}
+		if err := s.send(batch); err != nil {
+			log.DefaultLogger.Errorf("[accesslog] [grpc sink] send %d entries to %s failed: %v", len(batch), s.address, err)
+			// the broken stream is closed, a new stream is created on the next batch
+			if s.stream != nil {
+				s.stream.CloseSend()
+				s.stream = nil
+			}
+		}
+		// the entries are referenced by the batch only
+		for i := range batch {
+			batch[i] = nil
+		}
+	}
+}
+
+func (s *grpcStreamer) send(batch []*alsdata.HTTPAccessLogEntry) error {
+	msg := &alsservice.StreamAccessLogsMessage{
+		LogEntries: &alsservice.StreamAccessLogsMessage_HttpLogs{
+			HttpLogs: &alsservice.StreamAccessLogsMessage_HTTPAccessLogEntries{
+				LogEntry: batch,
+			},
+		},
+	}
+	// the identifier is sent in the first message of a stream only
+	if s.stream == nil {
+		stream, err := s.client.StreamAccessLogs(context.Background())
+		if err != nil {
+			return err
+		}
+		s.stream = stream
+		msg.Identifier = &alsservice.StreamAccessLogsMessage_Identifier{
+			Node: &core.Node{
+				Id:      types.GetGlobalXdsInfo().ServiceNode,
+				Cluster: types.GetGlobalXdsInfo().ServiceCluster,
+			},
+			LogName: s.logName,
+		}
+	}
+	return s.stream.Send(msg)
+}
+
+func (s *grpcSink) newEntry(reqHeaders api.HeaderMap, respHeaders api.HeaderMap, requestInfo api.RequestInfo) *alsdata.HTTPAccessLogEntry {
+	entry := &alsdata.HTTPAccessLogEntry{
+		CommonProperties: &alsdata.AccessLogCommon{},
+		Request:          &alsdata.HTTPRequestProperties{},
+		Response:         &alsdata.HTTPResponseProperties{},
+	}
+
+	if reqHeaders != nil {
+		req := entry.Request
+		if method, ok := reqHeaders.Get(types.HeaderMethod); ok {
+			req.RequestMethod = core.RequestMethod(core.RequestMethod_value[strings.ToUpper(method)])
+		}
+		req.Path, _ = reqHeaders.Get(types.HeaderPath)
+		req.Authority, _ = reqHeaders.Get(types.HeaderHost)
+		req.UserAgent, _ = reqHeaders.Get("User-Agent")
+		req.Referer, _ = reqHeaders.Get("Referer")
+		req.ForwardedFor, _ = reqHeaders.Get("X-Forwarded-For")
+		req.RequestId, _ = reqHeaders.Get("X-Request-Id")
+		req.RequestHeadersBytes = reqHeaders.ByteSize()
+		req.RequestHeaders = headersToLog(reqHeaders, s.config.AdditionalRequestHeaders)
+	}
+	if respHeaders != nil {
+		entry.Response.ResponseHeadersBytes = respHeaders.ByteSize()
+		entry.Response.ResponseHeaders = headersToLog(respHeaders, s.config.AdditionalResponseHeaders)
+	}
+
+	if requestInfo == nil {
+		return entry
+	}
+
+	switch requestInfo.Protocol() {
+	case protocol.HTTP1:
+		entry.ProtocolVersion = alsdata.HTTPAccessLogEntry_HTTP11
+	case protocol.HTTP2:
+		entry.ProtocolVersion = alsdata.HTTPAccessLogEntry_HTTP2
+	}
+
+	common := entry.CommonProperties
+	startTime := requestInfo.StartTime()
+	common.StartTime = &startTime
+	common.TimeToLastRxByte = durationOf(requestInfo.RequestReceivedDuration())
+	common.TimeToFirstUpstreamRxByte = durationOf(requestInfo.ResponseReceivedDuration())
+	common.TimeToLastDownstreamTxByte = durationOf(requestInfo.RequestFinishedDuration())
+	common.DownstreamLocalAddress = convertAddr(requestInfo.DownstreamLocalAddress())
+	common.DownstreamRemoteAddress = convertAddr(requestInfo.DownstreamRemoteAddress())
+	common.UpstreamLocalAddress = convertAddress(requestInfo.UpstreamLocalAddress())
+	if host := requestInfo.UpstreamHost(); host != nil {
+		common.UpstreamRemoteAddress = convertAddress(host.AddressString())
+	}
+	if route := requestInfo.RouteEntry(); route != nil {
+		common.UpstreamCluster = route.ClusterName()
+	}
+	common.ResponseFlags = &alsdata.ResponseFlags{
+		NoHealthyUpstream:             requestInfo.GetResponseFlag(api.NoHealthyUpstream),
+		UpstreamRequestTimeout:        requestInfo.GetResponseFlag(api.UpstreamRequestTimeout),
+		LocalReset:                    requestInfo.GetResponseFlag(api.UpstreamLocalReset),
+		UpstreamRemoteReset:           requestInfo.GetResponseFlag(api.UpstreamRemoteReset),
+		UpstreamConnectionFailure:     requestInfo.GetResponseFlag(api.UpstreamConnectionFailure),
+		UpstreamConnectionTermination: requestInfo.GetResponseFlag(api.UpstreamConnectionTermination),
+		UpstreamOverflow:              requestInfo.GetResponseFlag(api.UpstreamOverflow),
+		NoRouteFound:                  requestInfo.GetResponseFlag(api.NoRouteFound),
+		DelayInjected:                 requestInfo.GetResponseFlag(api.DelayInjected),
+		FaultInjected:                 requestInfo.GetResponseFlag(api.FaultInjected),
+		RateLimited:                   requestInfo.GetResponseFlag(api.RateLimited),
+	}
+
+	entry.Request.RequestBodyBytes = requestInfo.BytesReceived()
+	entry.Response.ResponseBodyBytes = requestInfo.BytesSent()
+	if code := requestInfo.ResponseCode(); code > 0 {
+		entry.Response.ResponseCode = &gogotypes.UInt32Value{Value: uint32(code)}
+	}
+	return entry
+}
+
+func headersToLog(headers api.HeaderMap, keys []string) map[string]string {
+	if len(keys) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if value, ok := headers.Get(key); ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+func durationOf(d time.Duration) *time.Duration {
+	if d <= 0 {
+		return nil
+	}
+	return &d
+}
+
+func convertAddr(addr net.Addr) *core.Address {
+	if addr == nil {
+		return nil
+	}
+	return convertAddress(addr.String())
+}
+
+func convertAddress(address string) *core.Address {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil
+	}
+	portValue, err := strconv.ParseUint(port, 10, 32)
+	if err != nil {
+		return nil
+	}
+	return &core.Address{
+		Address: &core.Address_SocketAddress{
+			SocketAddress: &core.SocketAddress{
+				Address: host,
+				PortSpecifier: &core.SocketAddress_PortValue{
+					PortValue: uint32(portValue),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,269 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package als
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	alsservice "github.com/envoyproxy/go-control-plane/envoy/service/accesslog/v2"
+	"google.golang.org/grpc"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+// alsServer is an ALS stub that records all the messages
+type alsServer struct {
+	messages chan *alsservice.StreamAccessLogsMessage
+}
+
+func (s *alsServer) StreamAccessLogs(stream alsservice.AccessLogService_StreamAccessLogsServer) error {
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&alsservice.StreamAccessLogsResponse{})
+		}
+		if err != nil {
+			return err
+		}
+		s.messages <- msg
+	}
+}
+
+func startALSServer(t *testing.T) (*alsServer, *grpc.Server, string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &alsServer{
+		messages: make(chan *alsservice.StreamAccessLogsMessage, 16),
+	}
+	gs := grpc.NewServer()
+	alsservice.RegisterAccessLogServiceServer(gs, srv)
+	go gs.Serve(ln)
+	return srv, gs, ln.Addr().String()
+}
+
+func (s *alsServer) receive(t *testing.T) *alsservice.StreamAccessLogsMessage {
+	select {
+	case msg := <-s.messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("receive access log timeout")
+		return nil
+	}
+}
+
+func TestGrpcSink(t *testing.T) {
+	srv, gs, addr := startALSServer(t)
+	defer gs.Stop()
+
+	sink, err := log.CreateAccessLogSink(SinkType, map[string]interface{}{
+		"address":                     addr,
+		"log_name":                    "test_log",
+		"additional_request_headers":  []string{"service"},
+		"additional_response_headers": []string{"Server"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reqHeaders := protocol.CommonHeader{
+		types.HeaderMethod: "post",
+		types.HeaderPath:   "/test",
+		types.HeaderHost:   "mosn.io",
+		"service":          "test",
+		"ignored":          "ignored",
+	}
+	respHeaders := protocol.CommonHeader{
+		"Server": "MOSN",
+	}
+	info := network.NewRequestInfo()
+	info.SetResponseCode(503)
+	info.SetBytesSent(1024)
+	info.SetResponseFlag(api.UpstreamRequestTimeout)
+	info.SetUpstreamLocalAddress("127.0.0.1:23456")
+	info.SetDownstreamRemoteAddress(&net.TCPAddr{IP: net.ParseIP("127.0.0.2"), Port: 53242})
+
+	sink.Log(context.Background(), reqHeaders, respHeaders, info)
+	msg := srv.receive(t)
+	if msg.Identifier == nil || msg.Identifier.LogName != "test_log" {
+		t.Fatalf("first message should have the identifier, but got %v", msg.Identifier)
+	}
+	entries := msg.GetHttpLogs().GetLogEntry()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, but got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Request.RequestMethod != core.POST || entry.Request.Path != "/test" || entry.Request.Authority != "mosn.io" {
+		t.Errorf("unexpected request properties: %v", entry.Request)
+	}
+	if len(entry.Request.RequestHeaders) != 1 || entry.Request.RequestHeaders["service"] != "test" {
+		t.Errorf("unexpected request headers: %v", entry.Request.RequestHeaders)
+	}
+	if entry.Response.ResponseHeaders["Server"] != "MOSN" {
+		t.Errorf("unexpected response headers: %v", entry.Response.ResponseHeaders)
+	}
+	if entry.Response.ResponseCode.GetValue() != 503 || entry.Response.ResponseBodyBytes != 1024 {
+		t.Errorf("unexpected response properties: %v", entry.Response)
+	}
+	common := entry.CommonProperties
+	if !common.ResponseFlags.UpstreamRequestTimeout || common.ResponseFlags.NoHealthyUpstream {
+		t.Errorf("unexpected response flags: %v", common.ResponseFlags)
+	}
+	if common.UpstreamLocalAddress.GetSocketAddress().GetPortValue() != 23456 ||
+		common.DownstreamRemoteAddress.GetSocketAddress().GetAddress() != "127.0.0.2" {
+		t.Errorf("unexpected addresses: %v", common)
+	}
+
+	// the identifier is sent only once in a stream
+	sink.Log(context.Background(), reqHeaders, respHeaders, nil)
+	msg = srv.receive(t)
+	if msg.Identifier != nil {
+		t.Errorf("identifier should be sent only once, but got %v", msg.Identifier)
+	}
+}
+
+func TestGrpcSinkReconnect(t *testing.T) {
+	srv, gs, addr := startALSServer(t)
+
+	sink, err := log.CreateAccessLogSink(SinkType, map[string]interface{}{
+		"address":  addr,
+		"log_name": "test_log",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Log(context.Background(), nil, nil, nil)
+	srv.receive(t)
+	gs.Stop()
+
+	// restart the server on the same address
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("listen %s again failed: %v", addr, err)
+	}
+	gs = grpc.NewServer()
+	alsservice.RegisterAccessLogServiceServer(gs, srv)
+	go gs.Serve(ln)
+	defer gs.Stop()
+
+	// entries are dropped until the stream is created again
+	deadline := time.After(10 * time.Second)
+	for {
+		sink.Log(context.Background(), nil, nil, nil)
+		select {
+		case msg := <-srv.messages:
+			if msg.Identifier == nil {
+				t.Fatal("new stream should send the identifier")
+			}
+			return
+		case <-time.After(100 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("sink does not reconnect")
+		}
+	}
+}
+
+func TestGrpcSinkShareStreamer(t *testing.T) {
+	_, gs, addr := startALSServer(t)
+	defer gs.Stop()
+
+	newSink := func(logName string, headers []string) *grpcSink {
+		sink, err := NewGrpcSink(map[string]interface{}{
+			"address":                    addr,
+			"log_name":                   logName,
+			"additional_request_headers": headers,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sink.(*grpcSink)
+	}
+	// the access logs are created again when the listener is updated
+	sink1 := newSink("shared_log", nil)
+	sink2 := newSink("shared_log", []string{"service"})
+	if sink1.streamer != sink2.streamer {
+		t.Error("sinks with the same address and log name should share the streamer")
+	}
+	if len(sink2.config.AdditionalRequestHeaders) != 1 {
+		t.Errorf("sink should keep its own config, but got %v", sink2.config)
+	}
+	if sink3 := newSink("other_log", nil); sink3.streamer == sink1.streamer {
+		t.Error("sinks with different log names should not share the streamer")
+	}
+}
+
+func TestGrpcSinkCloseStreamer(t *testing.T) {
+	_, gs, addr := startALSServer(t)
+	defer gs.Stop()
+
+	newSink := func() *grpcSink {
+		sink, err := NewGrpcSink(map[string]interface{}{
+			"address":  addr,
+			"log_name": "closed_log",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sink.(*grpcSink)
+	}
+	isClosed := func(s *grpcStreamer) bool {
+		select {
+		case <-s.done:
+			return true
+		default:
+			return false
+		}
+	}
+	sink1, sink2 := newSink(), newSink()
+	streamer := sink1.streamer
+	// the streamer is kept until all the sinks are closed, closing a sink twice releases it once
+	sink1.Close()
+	sink1.Close()
+	if isClosed(streamer) {
+		t.Fatal("expected the streamer is kept for the sink in use")
+	}
+	sink2.Close()
+	if !isClosed(streamer) {
+		t.Fatal("expected the streamer is closed after all the sinks closed")
+	}
+	// the closed sink drops the entries
+	sink2.Log(context.Background(), nil, nil, nil)
+	if sink3 := newSink(); sink3.streamer == streamer {
+		t.Error("expected a new streamer is created for the new sink")
+	} else {
+		sink3.Close()
+	}
+}
+
+func TestNewGrpcSinkInvalidConfig(t *testing.T) {
+	if _, err := NewGrpcSink(map[string]interface{}{}); err == nil {
+		t.Error("address is required")
+	}
+	if _, err := NewGrpcSink(map[string]interface{}{"address": 1}); err == nil {
+		t.Error("invalid address type should fail")
+	}
+}
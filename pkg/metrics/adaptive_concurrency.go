@@ -0,0 +1,40 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package metrics
+
+import (
+	"mosn.io/mosn/pkg/types"
+)
+
+// AdaptiveConcurrencyType represents adaptive concurrency metrics type
+const AdaptiveConcurrencyType = "adaptive_concurrency"
+
+// adaptive concurrency metrics key
+const (
+	AdaptiveConcurrencyLimit        = "concurrency_limit"
+	AdaptiveConcurrencyMinRTT       = "min_rtt_msecs"
+	AdaptiveConcurrencySampleRTT    = "sample_rtt_msecs"
+	AdaptiveConcurrencyMinRTTActive = "min_rtt_calculation_active"
+	AdaptiveConcurrencyBlocked      = "rq_blocked"
+)
+
+// NewAdaptiveConcurrencyStats returns a stats with namespace prefix adaptive_concurrency,
+// the key is the cluster or route name the limit is calculated for
+func NewAdaptiveConcurrencyStats(statPrefix, key string) types.Metrics {
+	metrics, _ := NewMetrics(AdaptiveConcurrencyType, map[string]string{"adaptive_concurrency": statPrefix, "key": key})
+	return metrics
+}
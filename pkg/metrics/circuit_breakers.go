@@ -0,0 +1,45 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package metrics
+
+import (
+	"mosn.io/mosn/pkg/types"
+)
+
+// CircuitBreakersType represents circuit breakers metrics type
+const CircuitBreakersType = "circuit_breakers"
+
+// circuit breakers metrics key, the remaining gauges are the capacity before the resources overflow,
+// and the open gauges are 1 if the resources are tripping
+const (
+	CircuitBreakersRemainingConnections     = "remaining_cx"
+	CircuitBreakersRemainingPendingRequests = "remaining_pending"
+	CircuitBreakersRemainingRequests        = "remaining_rq"
+	CircuitBreakersRemainingRetries         = "remaining_retries"
+	CircuitBreakersRemainingConnectionPools = "remaining_cx_pools"
+	CircuitBreakersConnectionsOpen          = "cx_open"
+	CircuitBreakersPendingRequestsOpen      = "rq_pending_open"
+	CircuitBreakersRequestsOpen             = "rq_open"
+	CircuitBreakersRetriesOpen              = "rq_retry_open"
+	CircuitBreakersConnectionPoolsOpen      = "cx_pool_open"
+)
+
+// NewCircuitBreakersStats returns a stats with namespace prefix circuit_breakers
+func NewCircuitBreakersStats(clusterName, priority string) types.Metrics {
+	metrics, _ := NewMetrics(CircuitBreakersType, map[string]string{"cluster": clusterName, "priority": priority})
+	return metrics
+}
@@ -0,0 +1,47 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package metrics
+
+import (
+	"mosn.io/mosn/pkg/types"
+)
+
+// OverloadType represents overload manager metrics type
+const OverloadType = "overload"
+
+// overload manager metrics key
+const (
+	// the resource usage and its pressure in percent
+	OverloadResourceValue    = "value"
+	OverloadResourcePressure = "pressure"
+	// the action is active or not, the times it is activated, and the connections or streams it rejects
+	OverloadActionActive    = "active"
+	OverloadActionActivated = "activated"
+	OverloadActionRejected  = "rejected"
+)
+
+// NewOverloadResourceStats returns a stats of the resource monitor with namespace prefix overload
+func NewOverloadResourceStats(resource string) types.Metrics {
+	metrics, _ := NewMetrics(OverloadType, map[string]string{"resource": resource})
+	return metrics
+}
+
+// NewOverloadActionStats returns a stats of the action with namespace prefix overload
+func NewOverloadActionStats(action string) types.Metrics {
+	metrics, _ := NewMetrics(OverloadType, map[string]string{"action": action})
+	return metrics
+}
@@ -0,0 +1,42 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"mosn.io/mosn/pkg/types"
+)
+
+// RBACType represents rbac metrics type
+const RBACType = "rbac"
+
+// rbac metrics key
+const (
+	RBACAllowed       = "allowed"
+	RBACDenied        = "denied"
+	RBACShadowAllowed = "shadow_allowed"
+	RBACShadowDenied  = "shadow_denied"
+	// the policies matched are counted with the prefix and the policy name
+	RBACPolicyPrefix       = "policy_"
+	RBACShadowPolicyPrefix = "shadow_policy_"
+)
+
+// NewRBACStats returns a stats with namespace prefix rbac
+func NewRBACStats(statPrefix string) types.Metrics {
+	metrics, _ := NewMetrics(RBACType, map[string]string{"rbac": statPrefix})
+	return metrics
+}
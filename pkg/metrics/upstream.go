@@ -36,6 +36,12 @@ const (
 	UpstreamConnectionLocalCloseWithActiveRequest  = "connection_local_close_with_active_request"
 	UpstreamConnectionRemoteCloseWithActiveRequest = "connection_remote_close_with_active_request"
 	UpstreamConnectionCloseNotify                  = "connection_close_notify"
+	UpstreamConnectionCloseMaxRequests             = "connection_close_max_requests"
+	UpstreamConnectionPoolOverflow                 = "connection_pool_overflow"
+	UpstreamConnectionCloseGoAway                  = "connection_close_goaway"
+	UpstreamConnectionCloseMaxAge                  = "connection_close_max_age"
+	UpstreamConnectionCloseIdle                    = "connection_close_idle"
+	UpstreamConnectionCloseMaxIdle                 = "connection_close_max_idle"
 	UpstreamRequestTotal                           = "request_total"
 	UpstreamRequestActive                          = "request_active"
 	UpstreamRequestLocalReset                      = "request_local_reset"
@@ -7,6 +7,7 @@ package http2
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -147,7 +148,8 @@ func (ms *MStream) Reset() {
 
 type MServerConn struct {
 	serverConn
-	mu sync.Mutex
+	mu     sync.Mutex
+	inited bool
 
 	Framer *MFramer
 	api.Connection
@@ -185,6 +187,11 @@ func NewServerConn(conn api.Connection) *MServerConn {
 
 // Init send settings frame and window update
 func (sc *MServerConn) Init() error {
+	if sc.inited {
+		return nil
+	}
+	sc.inited = true
+
 	settings := writeSettings{
 		{SettingMaxFrameSize, defaultMaxReadFrameSize},
 		{SettingMaxConcurrentStreams, defaultMaxStreams},
@@ -207,6 +214,48 @@ func (sc *MServerConn) Init() error {
 	return nil
 }
 
+// UpgradeStream creates the stream 1 for the request upgraded from HTTP/1.1 (RFC 7540 Section 3.2),
+// the settings are decoded from the HTTP2-Settings header of the request
+func (sc *MServerConn) UpgradeStream(req *http.Request, settings []Setting) (*MStream, error) {
+	for _, s := range settings {
+		if err := sc.processSetting(s); err != nil {
+			return nil, err
+		}
+	}
+	if sc.maxClientStreamID != 0 {
+		return nil, ConnectionError(ErrCodeProtocol)
+	}
+	sc.maxClientStreamID = 1
+
+	// the request is sent already, the stream is half closed (remote)
+	st := sc.newStream(1, 0, stateHalfClosedRemote)
+	return &MStream{
+		stream:  st,
+		conn:    sc,
+		Request: req,
+	}, nil
+}
+
+// DecodeSettings decodes the HTTP2-Settings header value of a h2c upgrade request,
+// which is the base64url encoded payload of a SETTINGS frame
+func DecodeSettings(value string) ([]Setting, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+	if len(payload)%6 != 0 {
+		return nil, ConnectionError(ErrCodeFrameSize)
+	}
+	settings := make([]Setting, 0, len(payload)/6)
+	for i := 0; i < len(payload); i += 6 {
+		settings = append(settings, Setting{
+			ID:  SettingID(binary.BigEndian.Uint16(payload[i:])),
+			Val: binary.BigEndian.Uint32(payload[i+2:]),
+		})
+	}
+	return settings, nil
+}
+
 func (sc *MServerConn) getStream(id uint32) *stream {
 	sc.mu.Lock()
 	defer sc.mu.Unlock()
@@ -1422,9 +1471,36 @@ func (cc *MClientConn) processResetStream(f *RSTStreamFrame) error {
 
 // processGoAway processes GoAway Frame for Http2 Client
 func (cc *MClientConn) processGoAway(f *GoAwayFrame) error {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	cc.goAway = f
+	if cc.goAwayDebug == "" {
+		cc.goAwayDebug = string(f.DebugData())
+	}
 	return nil
 }
 
+// MaxConcurrentStreams returns the max concurrent streams in the peer's settings
+func (cc *MClientConn) MaxConcurrentStreams() uint32 {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	return cc.maxConcurrentStreams
+}
+
+// GoAwayLastStreamID returns the last stream id in the received GoAway frame.
+// The streams with greater ids are not processed by the peer
+func (cc *MClientConn) GoAwayLastStreamID() (uint32, bool) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if cc.goAway == nil {
+		return 0, false
+	}
+	return cc.goAway.LastStreamID, true
+}
+
 func (sc *MClientConn) resetStream(se StreamError) error {
 	if st := sc.streamByID(se.StreamID, true); st != nil {
 
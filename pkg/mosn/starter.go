@@ -26,11 +26,13 @@ import (
 	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/configmanager"
 	"mosn.io/mosn/pkg/featuregate"
+	"mosn.io/mosn/pkg/filesource"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/metrics"
 	"mosn.io/mosn/pkg/metrics/shm"
 	"mosn.io/mosn/pkg/metrics/sink"
 	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/overload"
 	"mosn.io/mosn/pkg/plugin"
 	"mosn.io/mosn/pkg/router"
 	"mosn.io/mosn/pkg/server"
@@ -50,6 +52,8 @@ type Mosn struct {
 	config         *v2.MOSNConfig
 	adminServer    admin.Server
 	xdsClient      *xds.Client
+	fileSource     *filesource.Source
+	overload       *overload.Manager
 	wg             sync.WaitGroup
 	// for smooth upgrade. reconfigure
 	inheritListeners []net.Listener
@@ -105,13 +109,21 @@ func NewMosn(c *v2.MOSNConfig) *Mosn {
 		c.Servers = servers
 	} else {
 		if c.ClusterManager.Clusters == nil || len(c.ClusterManager.Clusters) == 0 {
-			if !c.ClusterManager.AutoDiscovery {
+			if !c.ClusterManager.AutoDiscovery && (c.FileSource == nil || c.ClusterManager.ClusterConfigPath == "") {
 				log.StartLogger.Fatalf("[mosn] [NewMosn] no cluster found and cluster manager doesn't support auto discovery")
 			}
 
 		}
 	}
 
+	if c.OverloadManager != nil {
+		manager, err := overload.NewManager(c.OverloadManager)
+		if err != nil {
+			log.StartLogger.Fatalf("[mosn] [NewMosn] create overload manager failed: %v", err)
+		}
+		m.overload = manager
+	}
+
 	srvNum := len(c.Servers)
 
 	if srvNum == 0 {
@@ -154,7 +166,7 @@ func NewMosn(c *v2.MOSNConfig) *Mosn {
 			srv = server.NewServer(sc, cmf, m.clustermanager)
 
 			//add listener
-			if serverConfig.Listeners == nil || len(serverConfig.Listeners) == 0 {
+			if len(serverConfig.Listeners) == 0 && (m.config.FileSource == nil || m.config.FileSource.ListenersFile == "") {
 				log.StartLogger.Fatalf("[mosn] [NewMosn] no listener found")
 			}
 
@@ -259,6 +271,12 @@ func (m *Mosn) Start() {
 	log.StartLogger.Infof("mosn prepare for start")
 	m.beforeStart()
 
+	// the overload manager starts before the listeners accept connections
+	if m.overload != nil {
+		log.StartLogger.Infof("mosn start overload manager")
+		m.overload.Start()
+	}
+
 	// start mosn server
 	log.StartLogger.Infof("mosn start server")
 	for _, srv := range m.servers {
@@ -274,6 +292,13 @@ func (m *Mosn) Start() {
 			srv.Start()
 		}, nil)
 	}
+
+	// start file source if configured, the listeners are added after servers started
+	if m.config.FileSource != nil {
+		log.StartLogger.Infof("mosn start file source")
+		m.fileSource = filesource.NewSource(m.config)
+		m.fileSource.Start()
+	}
 }
 
 // Close mosn's server
@@ -289,6 +314,12 @@ func (m *Mosn) Close() {
 		srv.Close()
 	}
 	m.xdsClient.Stop()
+	if m.fileSource != nil {
+		m.fileSource.Stop()
+	}
+	if m.overload != nil {
+		m.overload.Stop()
+	}
 	m.clustermanager.Destroy()
 	m.wg.Done()
 }
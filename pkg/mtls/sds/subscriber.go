@@ -12,6 +12,7 @@ import (
 	"google.golang.org/grpc"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/types"
+	xdsv3 "mosn.io/mosn/pkg/xds/v3"
 	"mosn.io/pkg/utils"
 )
 
@@ -117,6 +118,7 @@ func (subscribe *SdsSubscriber) sendRequestLoop(sdsStreamClient *SdsStreamClient
 			return
 		case name := <-subscribe.reqQueue:
 			discoveryReq := &xdsapi.DiscoveryRequest{
+				TypeUrl:       subscribe.typeURL(),
 				ResourceNames: []string{name},
 				Node: &core.Node{
 					Id: subscribe.serviceNode,
@@ -167,6 +169,15 @@ func (subscribe *SdsSubscriber) sendRequest(request *xdsapi.DiscoveryRequest) er
 func (subscribe *SdsSubscriber) handleSecretResp(response *xdsapi.DiscoveryResponse) {
 	log.DefaultLogger.Debugf("handle secret response %v", response)
 	for _, res := range response.Resources {
+		if res.GetTypeUrl() == xdsv3.EnvoySecret {
+			secret, err := xdsv3.ConvertSecret(res.GetValue())
+			if err != nil {
+				log.DefaultLogger.Errorf("[xds] [sds subscriber] convert v3 secret failed: %v", err)
+				continue
+			}
+			subscribe.provider.SetSecret(secret.Name, secret)
+			continue
+		}
 		secret := auth.Secret{}
 		secret.Unmarshal(res.GetValue())
 		subscribe.provider.SetSecret(secret.Name, &secret)
@@ -188,14 +199,18 @@ func (subscribe *SdsSubscriber) getSdsStreamClient(sdsStreamConfig *SdsStreamCon
 	if err != nil {
 		return nil, err
 	}
-	sdsServiceClient := v2.NewSecretDiscoveryServiceClient(conn)
 	sdsStreamClient := &SdsStreamClient{
 		sdsStreamConfig: sdsStreamConfig,
 		conn:            conn,
 	}
 	ctx, cancel := context.WithCancel(context.Background())
 	sdsStreamClient.cancel = cancel
-	streamSecretsClient, err := sdsServiceClient.StreamSecrets(ctx)
+	var streamSecretsClient v2.SecretDiscoveryService_StreamSecretsClient
+	if xdsv3.IsV3ConfigSource(subscribe.sdsConfig) {
+		streamSecretsClient, err = xdsv3.NewSecretDiscoveryStream(ctx, conn)
+	} else {
+		streamSecretsClient, err = v2.NewSecretDiscoveryServiceClient(conn).StreamSecrets(ctx)
+	}
 	if err != nil {
 		conn.Close()
 		return nil, err
@@ -204,6 +219,14 @@ func (subscribe *SdsSubscriber) getSdsStreamClient(sdsStreamConfig *SdsStreamCon
 	return sdsStreamClient, nil
 }
 
+// typeURL returns the secret type url matching the transport api version of the sds config
+func (subscribe *SdsSubscriber) typeURL() string {
+	if xdsv3.IsV3ConfigSource(subscribe.sdsConfig) {
+		return xdsv3.EnvoySecret
+	}
+	return "type.googleapis.com/envoy.api.v2.auth.Secret"
+}
+
 func (subscribe *SdsSubscriber) reconnect() {
 	subscribe.sdsStreamClient.cancel()
 	if subscribe.sdsStreamClient.conn != nil {
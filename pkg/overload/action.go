@@ -0,0 +1,104 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package overload
+
+import (
+	"sync/atomic"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+)
+
+// Overloaded is the response flag of the streams rejected by the overload manager
+const Overloaded api.ResponseFlag = 0x4000
+
+// Action is taken by the connections and streams when it is activated by the overload manager
+type Action int
+
+// Group of overload actions
+const (
+	// StopAcceptingConnections closes the new downstream connections once they are accepted
+	StopAcceptingConnections Action = iota
+	// DisableHTTPKeepAlive closes the HTTP/1 downstream connections after the responses are sent
+	DisableHTTPKeepAlive
+	// ShrinkBufferLimits shrinks the buffer limits of the new streams
+	ShrinkBufferLimits
+	// RejectStreams replies the new streams with 503
+	RejectStreams
+	actionCount
+)
+
+var actionNames = [actionCount]string{
+	StopAcceptingConnections: "stop_accepting_connections",
+	DisableHTTPKeepAlive:     "disable_http_keepalive",
+	ShrinkBufferLimits:       "shrink_buffer_limits",
+	RejectStreams:            "reject_streams",
+}
+
+func (a Action) String() string {
+	return actionNames[a]
+}
+
+func parseAction(name string) (Action, bool) {
+	for a, n := range actionNames {
+		if n == name {
+			return Action(a), true
+		}
+	}
+	return 0, false
+}
+
+// DefaultShrunkBufferLimitBytes is the buffer limit of the streams when the ShrinkBufferLimits is active
+const DefaultShrunkBufferLimitBytes = 4 * 1024
+
+// the states are shared by all the connections and streams, and updated by the running manager
+var (
+	actionStates [actionCount]uint32
+	// the counters of the running manager, stored in rejectedCounter
+	actionRejected    [actionCount]atomic.Value
+	shrunkBufferLimit uint32 = DefaultShrunkBufferLimitBytes
+)
+
+// rejectedCounter wraps the counter, as the atomic.Value requires the values stored are in the same type
+type rejectedCounter struct {
+	gometrics.Counter
+}
+
+// IsActive returns true if the action is active
+func IsActive(a Action) bool {
+	return atomic.LoadUint32(&actionStates[a]) == 1
+}
+
+// RecordRejected counts a connection or stream rejected by the action
+func RecordRejected(a Action) {
+	if c, ok := actionRejected[a].Load().(rejectedCounter); ok {
+		c.Inc(1)
+	}
+}
+
+// BufferLimit returns the buffer limit of a new stream or connection, which is shrunk if the ShrinkBufferLimits is active.
+// zero limit means no limit
+func BufferLimit(limit uint32) uint32 {
+	if !IsActive(ShrinkBufferLimits) {
+		return limit
+	}
+	shrunk := atomic.LoadUint32(&shrunkBufferLimit)
+	if limit == 0 || limit > shrunk {
+		return shrunk
+	}
+	return limit
+}
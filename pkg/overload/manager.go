@@ -0,0 +1,242 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package overload protects the process from overload.
+// The overload manager watches the resources of the process periodically, such as the heap size,
+// the goroutines and the downstream connections, and activates the actions when the resources are under pressure.
+// The actions are taken by the listeners, the HTTP/1 server connections and the proxy.
+package overload
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/pkg/utils"
+)
+
+const (
+	defaultRefreshInterval = time.Second
+	// the recovery of a trigger is 90% of the threshold by default
+	defaultRecoveryRatio = 0.9
+)
+
+// Manager refreshes the resource monitors, and updates the states of the actions
+type Manager struct {
+	interval    time.Duration
+	monitors    []*resourceMonitor
+	actions     []*overloadAction
+	bufferLimit uint32
+	stopChan    chan struct{}
+	once        sync.Once
+	wg          sync.WaitGroup
+}
+
+// overloadAction is active if any of its triggers is active
+type overloadAction struct {
+	action    Action
+	triggers  []*trigger
+	active    bool
+	gauge     gometrics.Gauge
+	activated gometrics.Counter
+	rejected  gometrics.Counter
+}
+
+// trigger is activated when the pressure reaches the threshold, and deactivated when the pressure drops below the recovery
+type trigger struct {
+	monitor   *resourceMonitor
+	threshold float64
+	recovery  float64
+	active    bool
+}
+
+// NewManager creates a Manager by the config
+func NewManager(config *v2.OverloadManagerConfig) (*Manager, error) {
+	m := &Manager{
+		interval:    config.RefreshInterval.Duration,
+		bufferLimit: config.ShrunkBufferLimitBytes,
+		stopChan:    make(chan struct{}),
+	}
+	if m.interval <= 0 {
+		m.interval = defaultRefreshInterval
+	}
+	if m.bufferLimit == 0 {
+		m.bufferLimit = DefaultShrunkBufferLimitBytes
+	}
+	monitors := make(map[string]*resourceMonitor, len(config.ResourceMonitors))
+	for _, mc := range config.ResourceMonitors {
+		reader, ok := resourceReaders[mc.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown overload resource %s", mc.Name)
+		}
+		if mc.Max == 0 {
+			return nil, fmt.Errorf("the max of overload resource %s is zero", mc.Name)
+		}
+		if _, ok := monitors[mc.Name]; ok {
+			return nil, fmt.Errorf("duplicate overload resource %s", mc.Name)
+		}
+		monitor := newResourceMonitor(mc.Name, reader, mc.Max)
+		monitors[mc.Name] = monitor
+		m.monitors = append(m.monitors, monitor)
+	}
+	configured := make(map[Action]bool, len(config.Actions))
+	for _, ac := range config.Actions {
+		a, ok := parseAction(ac.Name)
+		if !ok {
+			return nil, fmt.Errorf("unknown overload action %s", ac.Name)
+		}
+		if configured[a] {
+			return nil, fmt.Errorf("duplicate overload action %s", ac.Name)
+		}
+		configured[a] = true
+		if len(ac.Triggers) == 0 {
+			return nil, fmt.Errorf("overload action %s has no triggers", ac.Name)
+		}
+		s := metrics.NewOverloadActionStats(ac.Name)
+		oa := &overloadAction{
+			action:    a,
+			gauge:     s.Gauge(metrics.OverloadActionActive),
+			activated: s.Counter(metrics.OverloadActionActivated),
+			rejected:  s.Counter(metrics.OverloadActionRejected),
+		}
+		for _, tc := range ac.Triggers {
+			monitor, ok := monitors[tc.Resource]
+			if !ok {
+				return nil, fmt.Errorf("overload action %s is triggered by an unmonitored resource %s", ac.Name, tc.Resource)
+			}
+			t, err := newTrigger(monitor, tc)
+			if err != nil {
+				return nil, fmt.Errorf("overload action %s: %v", ac.Name, err)
+			}
+			oa.triggers = append(oa.triggers, t)
+		}
+		m.actions = append(m.actions, oa)
+	}
+	return m, nil
+}
+
+func newTrigger(monitor *resourceMonitor, config v2.OverloadTriggerConfig) (*trigger, error) {
+	if config.Threshold <= 0 || config.Threshold > 1 {
+		return nil, errors.New("the threshold should be in (0, 1]")
+	}
+	recovery := config.Recovery
+	if recovery == 0 {
+		recovery = config.Threshold * defaultRecoveryRatio
+	}
+	if recovery < 0 || recovery > config.Threshold {
+		return nil, errors.New("the recovery should be in [0, threshold]")
+	}
+	return &trigger{
+		monitor:   monitor,
+		threshold: config.Threshold,
+		recovery:  recovery,
+	}, nil
+}
+
+// Start refreshes the resources, and keeps refreshing in the interval until it is stopped
+func (m *Manager) Start() {
+	atomic.StoreUint32(&shrunkBufferLimit, m.bufferLimit)
+	for _, oa := range m.actions {
+		actionRejected[oa.action].Store(rejectedCounter{oa.rejected})
+	}
+	m.refresh()
+	m.wg.Add(1)
+	utils.GoWithRecover(func() {
+		defer m.wg.Done()
+		m.run()
+	}, nil)
+}
+
+// Stop stops refreshing, and deactivates all the actions
+func (m *Manager) Stop() {
+	m.once.Do(func() {
+		close(m.stopChan)
+	})
+	m.wg.Wait()
+}
+
+func (m *Manager) run() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopChan:
+			for _, oa := range m.actions {
+				m.setActive(oa, false)
+			}
+			return
+		case <-ticker.C:
+			m.refresh()
+		}
+	}
+}
+
+func (m *Manager) refresh() {
+	for _, monitor := range m.monitors {
+		monitor.refresh()
+	}
+	for _, oa := range m.actions {
+		active := false
+		for _, t := range oa.triggers {
+			// all the triggers are updated to keep their states
+			if t.update() {
+				active = true
+			}
+		}
+		m.setActive(oa, active)
+	}
+}
+
+func (t *trigger) update() bool {
+	pressure := t.monitor.pressure
+	if t.active {
+		t.active = pressure >= t.recovery
+	} else {
+		t.active = pressure >= t.threshold
+	}
+	return t.active
+}
+
+func (m *Manager) setActive(oa *overloadAction, active bool) {
+	if oa.active == active {
+		return
+	}
+	oa.active = active
+	if active {
+		atomic.StoreUint32(&actionStates[oa.action], 1)
+		oa.gauge.Update(1)
+		oa.activated.Inc(1)
+		log.DefaultLogger.Warnf("[overload] action %s is activated, %s", oa.action, m.pressures())
+	} else {
+		atomic.StoreUint32(&actionStates[oa.action], 0)
+		oa.gauge.Update(0)
+		log.DefaultLogger.Infof("[overload] action %s is deactivated, %s", oa.action, m.pressures())
+	}
+}
+
+func (m *Manager) pressures() string {
+	s := "pressures:"
+	for _, monitor := range m.monitors {
+		s += fmt.Sprintf(" %s=%.2f", monitor.name, monitor.pressure)
+	}
+	return s
+}
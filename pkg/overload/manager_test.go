@@ -0,0 +1,201 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package overload
+
+import (
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+type mockResource struct {
+	value uint64
+}
+
+func (r *mockResource) read() uint64 {
+	return r.value
+}
+
+func TestManagerHysteresis(t *testing.T) {
+	resource := &mockResource{}
+	RegisterResourceReader("mock", resource.read)
+	m, err := NewManager(&v2.OverloadManagerConfig{
+		ResourceMonitors: []v2.ResourceMonitorConfig{
+			{Name: "mock", Max: 100},
+		},
+		Actions: []v2.OverloadActionConfig{
+			{
+				Name:     "reject_streams",
+				Triggers: []v2.OverloadTriggerConfig{{Resource: "mock", Threshold: 0.8, Recovery: 0.5}},
+			},
+			{
+				Name:     "shrink_buffer_limits",
+				Triggers: []v2.OverloadTriggerConfig{{Resource: "mock", Threshold: 0.6}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create manager failed: %v", err)
+	}
+	defer func() {
+		m.Stop()
+		actionStates = [actionCount]uint32{}
+	}()
+	for _, tc := range []struct {
+		value  uint64
+		reject bool
+		shrink bool
+	}{
+		{value: 50, reject: false, shrink: false},
+		{value: 70, reject: false, shrink: true},
+		{value: 85, reject: true, shrink: true},
+		// the actions are kept until the pressure drops below the recovery
+		{value: 60, reject: true, shrink: true},
+		{value: 53, reject: true, shrink: false},
+		{value: 40, reject: false, shrink: false},
+		{value: 79, reject: false, shrink: true},
+	} {
+		resource.value = tc.value
+		m.refresh()
+		if IsActive(RejectStreams) != tc.reject || IsActive(ShrinkBufferLimits) != tc.shrink {
+			t.Errorf("value %d: expected reject %t shrink %t, but got reject %t shrink %t",
+				tc.value, tc.reject, tc.shrink, IsActive(RejectStreams), IsActive(ShrinkBufferLimits))
+		}
+	}
+	if IsActive(StopAcceptingConnections) || IsActive(DisableHTTPKeepAlive) {
+		t.Error("expected the unconfigured actions are inactive")
+	}
+	if v := m.actions[0].activated.Count(); v != 1 {
+		t.Errorf("expected reject_streams is activated once, but got %d", v)
+	}
+	if v := m.monitors[0].pressureGauge.Value(); v != 79 {
+		t.Errorf("expected pressure gauge 79, but got %d", v)
+	}
+}
+
+func TestBufferLimit(t *testing.T) {
+	if limit := BufferLimit(32 * 1024); limit != 32*1024 {
+		t.Errorf("expected the limit is not shrunk, but got %d", limit)
+	}
+	actionStates[ShrinkBufferLimits] = 1
+	defer func() {
+		actionStates[ShrinkBufferLimits] = 0
+	}()
+	for _, tc := range []struct {
+		limit    uint32
+		expected uint32
+	}{
+		{limit: 32 * 1024, expected: DefaultShrunkBufferLimitBytes},
+		{limit: 0, expected: DefaultShrunkBufferLimitBytes},
+		{limit: 1024, expected: 1024},
+	} {
+		if limit := BufferLimit(tc.limit); limit != tc.expected {
+			t.Errorf("limit %d: expected %d, but got %d", tc.limit, tc.expected, limit)
+		}
+	}
+}
+
+func TestManagerStop(t *testing.T) {
+	resource := &mockResource{value: 100}
+	RegisterResourceReader("mock", resource.read)
+	m, err := NewManager(&v2.OverloadManagerConfig{
+		RefreshInterval:  api.DurationConfig{Duration: 10 * time.Millisecond},
+		ResourceMonitors: []v2.ResourceMonitorConfig{{Name: "mock", Max: 100}},
+		Actions: []v2.OverloadActionConfig{
+			{Name: "stop_accepting_connections", Triggers: []v2.OverloadTriggerConfig{{Resource: "mock", Threshold: 1}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create manager failed: %v", err)
+	}
+	m.Start()
+	if !IsActive(StopAcceptingConnections) {
+		t.Fatal("expected the action is active once started")
+	}
+	RecordRejected(StopAcceptingConnections)
+	if v := m.actions[0].rejected.Count(); v != 1 {
+		t.Errorf("expected the rejected is counted by the running manager, but got %d", v)
+	}
+	m.Stop()
+	if IsActive(StopAcceptingConnections) {
+		t.Error("expected the action is deactivated once stopped")
+	}
+}
+
+func TestNewManagerInvalidConfig(t *testing.T) {
+	monitors := []v2.ResourceMonitorConfig{{Name: ResourceGoroutines, Max: 10000}}
+	for _, tc := range []struct {
+		name   string
+		config v2.OverloadManagerConfig
+	}{
+		{
+			name:   "unknown resource",
+			config: v2.OverloadManagerConfig{ResourceMonitors: []v2.ResourceMonitorConfig{{Name: "unknown", Max: 1}}},
+		},
+		{
+			name:   "zero max",
+			config: v2.OverloadManagerConfig{ResourceMonitors: []v2.ResourceMonitorConfig{{Name: ResourceHeapSize}}},
+		},
+		{
+			name: "unknown action",
+			config: v2.OverloadManagerConfig{
+				ResourceMonitors: monitors,
+				Actions:          []v2.OverloadActionConfig{{Name: "unknown"}},
+			},
+		},
+		{
+			name: "no triggers",
+			config: v2.OverloadManagerConfig{
+				ResourceMonitors: monitors,
+				Actions:          []v2.OverloadActionConfig{{Name: "reject_streams"}},
+			},
+		},
+		{
+			name: "unmonitored resource",
+			config: v2.OverloadManagerConfig{
+				ResourceMonitors: monitors,
+				Actions: []v2.OverloadActionConfig{
+					{Name: "reject_streams", Triggers: []v2.OverloadTriggerConfig{{Resource: ResourceHeapSize, Threshold: 0.9}}},
+				},
+			},
+		},
+		{
+			name: "invalid threshold",
+			config: v2.OverloadManagerConfig{
+				ResourceMonitors: monitors,
+				Actions: []v2.OverloadActionConfig{
+					{Name: "reject_streams", Triggers: []v2.OverloadTriggerConfig{{Resource: ResourceGoroutines, Threshold: 1.5}}},
+				},
+			},
+		},
+		{
+			name: "recovery above threshold",
+			config: v2.OverloadManagerConfig{
+				ResourceMonitors: monitors,
+				Actions: []v2.OverloadActionConfig{
+					{Name: "reject_streams", Triggers: []v2.OverloadTriggerConfig{{Resource: ResourceGoroutines, Threshold: 0.8, Recovery: 0.9}}},
+				},
+			},
+		},
+	} {
+		if _, err := NewManager(&tc.config); err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+}
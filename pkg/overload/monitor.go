@@ -0,0 +1,83 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package overload
+
+import (
+	"runtime"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/mosn/pkg/metrics"
+)
+
+// ResourceReader reads the current usage of a resource
+type ResourceReader func() uint64
+
+// Group of the built-in resources, the downstream connections are registered by the server
+const (
+	ResourceHeapSize              = "heap_size"
+	ResourceGoroutines            = "goroutines"
+	ResourceDownstreamConnections = "downstream_connections"
+)
+
+var resourceReaders = map[string]ResourceReader{
+	ResourceHeapSize:   readHeapSize,
+	ResourceGoroutines: readGoroutines,
+}
+
+// RegisterResourceReader registers a resource, which can be watched by the resource monitors
+func RegisterResourceReader(name string, reader ResourceReader) {
+	resourceReaders[name] = reader
+}
+
+func readHeapSize() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+func readGoroutines() uint64 {
+	return uint64(runtime.NumGoroutine())
+}
+
+// resourceMonitor calculates the pressure of a resource, the pressure is the ratio of the usage to the max
+type resourceMonitor struct {
+	name     string
+	reader   ResourceReader
+	max      uint64
+	pressure float64
+
+	value         gometrics.Gauge
+	pressureGauge gometrics.Gauge
+}
+
+func newResourceMonitor(name string, reader ResourceReader, max uint64) *resourceMonitor {
+	s := metrics.NewOverloadResourceStats(name)
+	return &resourceMonitor{
+		name:          name,
+		reader:        reader,
+		max:           max,
+		value:         s.Gauge(metrics.OverloadResourceValue),
+		pressureGauge: s.Gauge(metrics.OverloadResourcePressure),
+	}
+}
+
+func (m *resourceMonitor) refresh() {
+	value := m.reader()
+	m.pressure = float64(value) / float64(m.max)
+	m.value.Update(int64(value))
+	m.pressureGauge.Update(int64(m.pressure * 100))
+}
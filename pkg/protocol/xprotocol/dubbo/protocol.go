@@ -21,10 +21,14 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"net/http"
 
+	hessian "github.com/apache/dubbo-go-hessian2"
 	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/protocol/xprotocol"
 	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
 )
 
 /**
@@ -111,11 +115,44 @@ func (proto *dubboProtocol) Reply(requestId uint64) xprotocol.XRespFrame {
 
 // hijacker
 func (proto *dubboProtocol) Hijack(statusCode uint32) xprotocol.XRespFrame {
-	// not support
-	return nil
+	// the error response carries a hessian string of the error message
+	encoder := hessian.NewEncoder()
+	if uint16(statusCode) == ResponseStatusSuccess {
+		encoder.Encode(hessian.RESPONSE_NULL_VALUE)
+	} else {
+		encoder.Encode(fmt.Sprintf("mosn hijack response with status %d", statusCode))
+	}
+	payload := encoder.Buffer()
+	return &Frame{
+		Header: Header{
+			Magic:           MagicTag,
+			Flag:            HessianSerialize,
+			Status:          byte(statusCode),
+			DataLen:         uint32(len(payload)),
+			Direction:       EventResponse,
+			SerializationId: int(HessianSerialize),
+			CommonHeader:    protocol.CommonHeader{},
+		},
+		payload: payload,
+		content: buffer.NewIoBufferBytes(payload),
+	}
 }
 
 func (proto *dubboProtocol) Mapping(httpStatusCode uint32) uint32 {
-	// not support
-	return 0
+	switch httpStatusCode {
+	case http.StatusOK:
+		return uint32(ResponseStatusSuccess)
+	case http.StatusBadRequest:
+		return uint32(ResponseStatusBadRequest)
+	case types.RouterUnavailableCode:
+		return uint32(ResponseStatusServiceNotFound)
+	case types.UpstreamOverFlowCode:
+		return uint32(ResponseStatusServerThreadpoolExhausted)
+	case types.TimeoutExceptionCode:
+		return uint32(ResponseStatusServerTimeout)
+	case types.CodecExceptionCode, types.DeserialExceptionCode:
+		return uint32(ResponseStatusBadRequest)
+	default:
+		return uint32(ResponseStatusServerError)
+	}
 }
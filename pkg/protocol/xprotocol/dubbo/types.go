@@ -52,5 +52,17 @@ const (
 )
 
 const (
-	ResponseStatusSuccess uint16 = 0x14 // 0x14 response status
+	ResponseStatusSuccess                   uint16 = 0x14 // 0x14 response status
+	ResponseStatusClientTimeout             uint16 = 30
+	ResponseStatusServerTimeout             uint16 = 31
+	ResponseStatusBadRequest                uint16 = 40
+	ResponseStatusBadResponse               uint16 = 50
+	ResponseStatusServiceNotFound           uint16 = 60
+	ResponseStatusServiceError              uint16 = 70
+	ResponseStatusServerError               uint16 = 80
+	ResponseStatusClientError               uint16 = 90
+	ResponseStatusServerThreadpoolExhausted uint16 = 100
 )
+
+// hessian2 serialization id
+const HessianSerialize byte = 2
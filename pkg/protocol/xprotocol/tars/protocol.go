@@ -20,10 +20,14 @@ package tars
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"github.com/TarsCloud/TarsGo/tars"
 	"github.com/TarsCloud/TarsGo/tars/protocol/codec"
+	"github.com/TarsCloud/TarsGo/tars/protocol/res/basef"
+	"github.com/TarsCloud/TarsGo/tars/protocol/res/requestf"
 	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/protocol/xprotocol"
 	"mosn.io/mosn/pkg/types"
 )
@@ -82,13 +86,38 @@ func (proto *tarsProtocol) Reply(requestId uint64) xprotocol.XRespFrame {
 
 // hijacker
 func (proto *tarsProtocol) Hijack(statusCode uint32) xprotocol.XRespFrame {
-	// not support
-	return nil
+	// the tars return codes are negative
+	ret := int32(statusCode)
+	return &Response{
+		cmd: &requestf.ResponsePacket{
+			IVersion:    basef.TARSVERSION,
+			CPacketType: basef.TARSNORMAL,
+			IRet:        ret,
+			SResultDesc: fmt.Sprintf("mosn hijack response with return code %d", ret),
+		},
+		CommonHeader: protocol.CommonHeader{},
+	}
 }
 
 func (proto *tarsProtocol) Mapping(httpStatusCode uint32) uint32 {
-	// not support
-	return 0
+	var ret int32
+	switch httpStatusCode {
+	case http.StatusOK:
+		ret = basef.TARSSERVERSUCCESS
+	case types.RouterUnavailableCode:
+		ret = basef.TARSSERVERNOSERVANTERR
+	case types.NoHealthUpstreamCode:
+		ret = basef.TARSPROXYCONNECTERR
+	case types.UpstreamOverFlowCode:
+		ret = basef.TARSSERVEROVERLOAD
+	case types.TimeoutExceptionCode:
+		ret = basef.TARSINVOKETIMEOUT
+	case types.CodecExceptionCode, types.DeserialExceptionCode:
+		ret = basef.TARSSERVERDECODEERR
+	default:
+		ret = basef.TARSSERVERUNKNOWNERR
+	}
+	return uint32(ret)
 }
 
 //判断packet的类型，resonse Packet的包tag=5是字段iRet,int类型；request packet的包tag=5是字段sServantName,string类型
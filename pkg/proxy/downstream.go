@@ -33,6 +33,7 @@ import (
 	"mosn.io/mosn/pkg/config/v2"
 	mosnctx "mosn.io/mosn/pkg/context"
 	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/overload"
 	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/protocol/http"
 	"mosn.io/mosn/pkg/router"
@@ -76,6 +77,10 @@ type downStream struct {
 	downstreamRespDataBuf  types.IoBuffer
 	downstreamRespTrailers types.HeaderMap
 
+	// ~~~ bodies received in data frames
+	reqBody  *streamBody
+	respBody *streamBody
+
 	// ~~~ state
 	// starts to send back downstream response, set on upstream response detected
 	downstreamResponseStarted bool
@@ -98,6 +103,8 @@ type downStream struct {
 	downstreamCleaned uint32
 	upstreamReset     uint32
 	reuseBuffer       uint32
+	// set on upstream response received, the request body in data frames is forwarded until then
+	upstreamResponded uint32
 
 	resetReason types.StreamResetReason
 
@@ -192,6 +199,14 @@ func (s *downStream) cleanStream() {
 	// clean up timers
 	s.cleanUp()
 
+	// drop the data frames not forwarded
+	if s.reqBody != nil {
+		s.reqBody.stop()
+	}
+	if s.respBody != nil {
+		s.respBody.stop()
+	}
+
 	// tell filters it's time to destroy
 	for _, ef := range s.senderFilters {
 		ef.filter.OnDestroy()
@@ -360,11 +375,36 @@ func (s *downStream) OnReceive(ctx context.Context, headers types.HeaderMap, dat
 	})
 }
 
+// types.StreamDataReceiveListener
+func (s *downStream) OnReceiveHeaders(ctx context.Context, headers types.HeaderMap, endStream bool) {
+	if !endStream {
+		// the buffers are used by the data frames out of the proxy flow, so they are not reused
+		atomic.StoreUint32(&s.reuseBuffer, 0)
+		s.reqBody = newStreamBody(s.responseSender.GetStream(), s.proxy.readCallbacks.Connection().BufferLimit(), s.sendNotify)
+	}
+	s.OnReceive(ctx, headers, nil, nil)
+}
+
+func (s *downStream) OnReceiveData(ctx context.Context, data types.IoBuffer, endStream bool) {
+	if s.reqBody != nil {
+		s.reqBody.receive(data, endStream)
+	}
+}
+
 func (s *downStream) receive(ctx context.Context, id uint32, phase types.Phase) types.Phase {
 	for i := 0; i <= int(types.End-types.InitPhase); i++ {
 		switch phase {
 		// init phase
 		case types.InitPhase:
+			// the new streams are rejected when the process is overloaded
+			if overload.IsActive(overload.RejectStreams) {
+				s.rejectOverloaded(overload.RejectStreams)
+			} else if s.exceedBufferLimit() {
+				s.rejectOverloaded(overload.ShrinkBufferLimits)
+			}
+			if p, err := s.processError(id); err != nil {
+				return p
+			}
 			phase++
 
 			// downstream filter before route
@@ -408,7 +448,7 @@ func (s *downStream) receive(ctx context.Context, id uint32, phase types.Phase)
 				if log.Proxy.GetLogLevel() >= log.DEBUG {
 					log.Proxy.Debugf(s.context, "[proxy] [downstream] enter phase %d, proxyId = %d  ", phase, id)
 				}
-				s.receiveHeaders(s.downstreamReqDataBuf == nil && s.downstreamReqTrailers == nil)
+				s.receiveHeaders(s.downstreamReqDataBuf == nil && s.downstreamReqTrailers == nil && s.reqBody == nil)
 
 				if p, err := s.processError(id); err != nil {
 					return p
@@ -521,7 +561,7 @@ func (s *downStream) receive(ctx context.Context, id uint32, phase types.Phase)
 				if log.Proxy.GetLogLevel() >= log.DEBUG {
 					log.Proxy.Debugf(s.context, "[proxy] [downstream] enter phase %d, proxyId = %d  ", phase, id)
 				}
-				s.upstreamRequest.receiveHeaders(s.downstreamRespDataBuf == nil && s.downstreamRespTrailers == nil)
+				s.upstreamRequest.receiveHeaders(s.downstreamRespDataBuf == nil && s.downstreamRespTrailers == nil && !s.streamingResponse())
 
 				if p, err := s.processError(id); err != nil {
 					return p
@@ -540,6 +580,13 @@ func (s *downStream) receive(ctx context.Context, id uint32, phase types.Phase)
 				if p, err := s.processError(id); err != nil {
 					return p
 				}
+			} else if s.streamingResponse() {
+				if log.Proxy.GetLogLevel() >= log.DEBUG {
+					log.Proxy.Debugf(s.context, "[proxy] [downstream] enter phase %d, proxyId = %d  ", phase, id)
+				}
+				if p, err := s.waitResponseBody(id); err != nil {
+					return p
+				}
 			}
 			phase++
 
@@ -624,6 +671,13 @@ func (s *downStream) getUpstreamProtocol() (currentProtocol types.ProtocolName)
 		currentProtocol = types.ProtocolName(configProtocol)
 	}
 
+	// the cluster may talk HTTP/1 or HTTP/2 with the hosts regardless of the downstream protocol
+	if s.cluster != nil && (currentProtocol == protocol.HTTP1 || currentProtocol == protocol.HTTP2) {
+		if prot := s.cluster.UpstreamProtocol(); prot != "" {
+			currentProtocol = prot
+		}
+	}
+
 	return currentProtocol
 }
 
@@ -672,8 +726,28 @@ func (s *downStream) receiveHeaders(endStream bool) {
 	s.cluster = s.snapshot.ClusterInfo()
 	s.requestInfo.SetRouteEntry(s.route.RouteRule())
 
+	// the route forwards the bodies in data frames
+	if rule, ok := s.route.RouteRule().(types.StreamRouteRule); ok && rule.Http1UseStream() {
+		s.context = mosnctx.WithValue(s.context, types.ContextKeyUseStream, true)
+	}
+	// the requests are limited by the circuit breakers of the routing priority
+	priority := types.DefaultPriority
+	if rule, ok := s.route.RouteRule().(types.PriorityRouteRule); ok {
+		priority = rule.Priority()
+	}
+	if priority != types.DefaultPriority {
+		s.context = mosnctx.WithValue(s.context, types.ContextKeyRoutePriority, priority)
+	}
+
 	pool, err := s.initializeUpstreamConnectionPool(s)
 	if err != nil {
+		if err == types.ErrConnPoolOverflow {
+			log.Proxy.Errorf(s.context, "[proxy] [downstream] connection pools of cluster %s overflow", s.cluster.Name())
+			s.requestInfo.SetResponseFlag(api.UpstreamOverflow)
+			s.sendHijackReply(types.UpstreamOverFlowCode, s.downstreamReqHeaders)
+			s.downstreamRespHeaders.Set(types.HeaderOverloaded, "true")
+			return
+		}
 		log.Proxy.Alertf(s.context, types.ErrorKeyUpstreamConn, "initialize Upstream Connection Pool error, request can't be proxyed, error = %v", err)
 		s.requestInfo.SetResponseFlag(api.NoHealthyUpstream)
 		s.sendHijackReply(types.NoHealthUpstreamCode, s.downstreamReqHeaders)
@@ -687,7 +761,10 @@ func (s *downStream) receiveHeaders(endStream bool) {
 
 	prot := s.getUpstreamProtocol()
 
-	s.retryState = newRetryState(s.route.RouteRule().Policy().RetryPolicy(), s.downstreamReqHeaders, s.cluster, prot)
+	// the body received in data frames can not be sent again
+	if s.reqBody == nil {
+		s.retryState = newRetryState(s.route.RouteRule().Policy().RetryPolicy(), s.downstreamReqHeaders, s.cluster, prot, priority)
+	}
 
 	//Build Request
 	proxyBuffers := proxyBuffersByContext(s.context)
@@ -696,7 +773,11 @@ func (s *downStream) receiveHeaders(endStream bool) {
 	s.upstreamRequest.proxy = s.proxy
 	s.upstreamRequest.protocol = prot
 	s.upstreamRequest.connPool = pool
-	s.route.RouteRule().FinalizeRequestHeaders(s.downstreamReqHeaders, s.requestInfo)
+	if finalizer, ok := s.route.RouteRule().(types.HeaderFinalizer); ok {
+		finalizer.FinalizeRequestHeadersWithContext(s.context, s.downstreamReqHeaders, s.requestInfo)
+	} else {
+		s.route.RouteRule().FinalizeRequestHeaders(s.downstreamReqHeaders, s.requestInfo)
+	}
 
 	//Call upstream's append header method to build upstream's request
 	s.upstreamRequest.appendHeaders(endStream)
@@ -704,6 +785,10 @@ func (s *downStream) receiveHeaders(endStream bool) {
 	if endStream {
 		s.onUpstreamRequestSent()
 	}
+
+	if s.reqBody != nil {
+		s.reqBody.start(s.receiveDataFrame)
+	}
 }
 
 func (s *downStream) receiveData(endStream bool) {
@@ -731,6 +816,25 @@ func (s *downStream) receiveData(endStream bool) {
 	}
 }
 
+// receiveDataFrame forwards a data frame of the request body
+func (s *downStream) receiveDataFrame(data types.IoBuffer, endStream bool) {
+	if s.processDone() {
+		return
+	}
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(s.context, "[proxy] [downstream] receive data frame, length = %d, endStream = %t", data.Len(), endStream)
+	}
+
+	s.requestInfo.SetBytesReceived(s.requestInfo.BytesReceived() + uint64(data.Len()))
+	s.downstreamRecvDone = endStream
+
+	if endStream {
+		s.onUpstreamRequestSent()
+	}
+
+	s.upstreamRequest.appendDataFrame(data, endStream)
+}
+
 func (s *downStream) receiveTrailers() {
 	// if active stream finished the lifecycle, just ignore further data
 	if s.processDone() {
@@ -885,11 +989,12 @@ func (s *downStream) onPerReqTimeout() {
 }
 
 func (s *downStream) initializeUpstreamConnectionPool(lbCtx types.LoadBalancerContext) (types.ConnectionPool, error) {
-	var connPool types.ConnectionPool
-
 	currentProtocol := s.getUpstreamProtocol()
 
-	connPool = s.proxy.clusterManager.ConnPoolForCluster(lbCtx, s.snapshot, currentProtocol)
+	connPool, err := s.proxy.clusterManager.ConnPoolForCluster(lbCtx, s.snapshot, currentProtocol)
+	if err == types.ErrConnPoolOverflow {
+		return nil, err
+	}
 
 	if connPool == nil {
 		return nil, fmt.Errorf("[proxy] [downstream] no healthy upstream in cluster %s", s.cluster.Name())
@@ -1026,6 +1131,9 @@ func (s *downStream) onUpstreamReset(reason types.StreamResetReason) {
 		if reason == types.UpstreamGlobalTimeout || reason == types.UpstreamPerTryTimeout {
 			s.requestInfo.SetResponseFlag(api.UpstreamRequestTimeout)
 			code = types.TimeoutExceptionCode
+		} else if reason == types.StreamOverflow {
+			s.requestInfo.SetResponseFlag(api.UpstreamOverflow)
+			code = types.UpstreamOverFlowCode
 		} else {
 			reasonFlag := s.proxy.streamResetReasonToResponseFlag(reason)
 			s.requestInfo.SetResponseFlag(reasonFlag)
@@ -1040,6 +1148,10 @@ func (s *downStream) onUpstreamReset(reason types.StreamResetReason) {
 		log.Proxy.Infof(s.context, "[proxy] [downstream] onUpstreamReset, send hijack, reason %v", reason)
 		atomic.CompareAndSwapUint32(&s.upstreamReset, 1, 0)
 		s.sendHijackReply(code, s.downstreamReqHeaders)
+		// the request or its retry is rejected by the circuit breakers
+		if s.requestInfo.GetResponseFlag(api.UpstreamOverflow) {
+			s.downstreamRespHeaders.Set(types.HeaderOverloaded, "true")
+		}
 	}
 }
 
@@ -1070,7 +1182,11 @@ func (s *downStream) onUpstreamHeaders(endStream bool) {
 
 	// directResponse for no route should be nil
 	if s.route != nil {
-		s.route.RouteRule().FinalizeResponseHeaders(headers, s.requestInfo)
+		if finalizer, ok := s.route.RouteRule().(types.HeaderFinalizer); ok {
+			finalizer.FinalizeResponseHeadersWithContext(s.context, headers, s.requestInfo)
+		} else {
+			s.route.RouteRule().FinalizeResponseHeaders(headers, s.requestInfo)
+		}
 	}
 
 	if endStream {
@@ -1079,6 +1195,10 @@ func (s *downStream) onUpstreamHeaders(endStream bool) {
 
 	// todo: insert proxy headers
 	s.appendHeaders(endStream)
+
+	if !endStream && s.streamingResponse() {
+		s.respBody.start(s.onUpstreamDataFrame)
+	}
 }
 
 func (s *downStream) handleUpstreamStatusCode() {
@@ -1102,6 +1222,16 @@ func (s *downStream) onUpstreamData(endStream bool) {
 	s.appendData(endStream)
 }
 
+// onUpstreamDataFrame forwards a data frame of the response body
+func (s *downStream) onUpstreamDataFrame(data types.IoBuffer, endStream bool) {
+	if s.processDone() {
+		return
+	}
+
+	s.downstreamRespDataBuf = s.runAppendDataFilters(data, endStream)
+	s.onUpstreamData(endStream)
+}
+
 func (s *downStream) finishTracing() {
 	if trace.IsEnabled() {
 		if s.context == nil {
@@ -1220,12 +1350,31 @@ func (s *downStream) sendHijackReply(code int, headers types.HeaderMap) {
 
 	headers.Set(types.HeaderStatus, strconv.Itoa(code))
 	atomic.StoreUint32(&s.reuseBuffer, 0)
+	s.dropResponseBody()
 	s.downstreamRespHeaders = headers
 	s.downstreamRespDataBuf = nil
 	s.downstreamRespTrailers = nil
 	s.directResponse = true
 }
 
+// exceedBufferLimit returns true if the buffered request body exceeds the buffer limit shrunk by the overload manager
+func (s *downStream) exceedBufferLimit() bool {
+	if s.downstreamReqDataBuf == nil || !overload.IsActive(overload.ShrinkBufferLimits) {
+		return false
+	}
+	limit := overload.BufferLimit(s.proxy.readCallbacks.Connection().BufferLimit())
+	return uint32(s.downstreamReqDataBuf.Len()) > limit
+}
+
+// rejectOverloaded replies the stream rejected by the overload action with 503 and the overloaded header
+func (s *downStream) rejectOverloaded(action overload.Action) {
+	log.Proxy.Warnf(s.context, "[proxy] [downstream] process overloaded, reject the stream, proxyId = %d", s.ID)
+	overload.RecordRejected(action)
+	s.requestInfo.SetResponseFlag(overload.Overloaded)
+	s.sendHijackReply(types.UpstreamOverFlowCode, s.downstreamReqHeaders)
+	s.downstreamRespHeaders.Set(types.HeaderOverloaded, "true")
+}
+
 // TODO: rpc status code may be not matched
 // TODO: rpc content(body) is not matched the headers, rpc should not hijack with body, use sendHijackReply instead
 func (s *downStream) sendHijackReplyWithBody(code int, headers types.HeaderMap, body string) {
@@ -1237,12 +1386,25 @@ func (s *downStream) sendHijackReplyWithBody(code int, headers types.HeaderMap,
 	s.requestInfo.SetResponseCode(code)
 	headers.Set(types.HeaderStatus, strconv.Itoa(code))
 	atomic.StoreUint32(&s.reuseBuffer, 0)
+	s.dropResponseBody()
 	s.downstreamRespHeaders = headers
 	s.downstreamRespDataBuf = buffer.NewIoBufferString(body)
 	s.downstreamRespTrailers = nil
 	s.directResponse = true
 }
 
+// dropResponseBody drops the upstream response body received in data frames, which is replaced by a direct response
+func (s *downStream) dropResponseBody() {
+	if s.respBody != nil {
+		s.respBody.stop()
+	}
+}
+
+// streamingResponse returns true if the upstream response body is forwarded in data frames
+func (s *downStream) streamingResponse() bool {
+	return s.respBody != nil && !s.respBody.isStopped()
+}
+
 func (s *downStream) cleanUp() {
 	// reset retry state
 	// if  a downstream filter ends downstream before send to upstream, retryState will be nil
@@ -1300,11 +1462,23 @@ func (s *downStream) AddStreamAccessLog(accessLog api.AccessLog) {
 
 // types.LoadBalancerContext
 func (s *downStream) MetadataMatchCriteria() api.MetadataMatchCriteria {
+	var criteria api.MetadataMatchCriteria
 	if nil != s.requestInfo.RouteEntry() {
-		return s.requestInfo.RouteEntry().MetadataMatchCriteria(s.cluster.Name())
+		criteria = s.requestInfo.RouteEntry().MetadataMatchCriteria(s.cluster.Name())
 	}
-
-	return nil
+	// the dynamic metadata set by the stream filters overrides the metadata of the route
+	metadata, _ := mosnctx.Get(s.context, types.ContextKeyDynamicMetadata).(map[string]string)
+	if len(metadata) == 0 {
+		return criteria
+	}
+	if criteria == nil || reflect.ValueOf(criteria).IsNil() {
+		return router.NewMetadataMatchCriteriaImpl(metadata)
+	}
+	matches := make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		matches[k] = v
+	}
+	return criteria.MergeMatchCriteria(matches)
 }
 
 func (s *downStream) DownstreamConnection() net.Conn {
@@ -1375,8 +1549,35 @@ func (s *downStream) waitNotify(id uint32) (phase types.Phase, err error) {
 	if log.Proxy.GetLogLevel() >= log.DEBUG {
 		log.Proxy.Debugf(s.context, "[proxy] [downstream] waitNotify begin %p, proxyId = %d", s, s.ID)
 	}
-	select {
-	case <-s.notify:
+	if s.reqBody == nil {
+		<-s.notify
+		return s.processError(id)
+	}
+
+	// the request body is forwarded until the upstream response received or reset
+	for {
+		// the frames received before the response are forwarded before it
+		done := atomic.LoadUint32(&s.upstreamResponded) == 1 || s.processDone() || atomic.LoadUint32(&s.downstreamCleaned) == 1
+		s.reqBody.flush()
+		if done {
+			break
+		}
+		<-s.notify
+	}
+	return s.processError(id)
+}
+
+// waitResponseBody forwards the upstream response body received in data frames until the end of it,
+// the rest of the request body is forwarded meanwhile
+func (s *downStream) waitResponseBody(id uint32) (phase types.Phase, err error) {
+	for !s.respBody.flush() {
+		if s.reqBody != nil {
+			s.reqBody.flush()
+		}
+		<-s.notify
+		if phase, err = s.processError(id); err != nil {
+			return
+		}
 	}
 	return s.processError(id)
 }
@@ -19,12 +19,15 @@ package proxy
 
 import (
 	"context"
+	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/overload"
 	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/trace"
 	"mosn.io/mosn/pkg/types"
@@ -298,3 +301,188 @@ func TestProcessError(t *testing.T) {
 		t.Errorf("TestprocessError Error")
 	}
 }
+
+func TestWaitNotifyForwardRequestBody(t *testing.T) {
+	s := &downStream{
+		ID:     1,
+		notify: make(chan struct{}, 1),
+	}
+	s.reqBody = newStreamBody(&mockStream{}, 0, s.sendNotify)
+	var received string
+	s.reqBody.start(func(data buffer.IoBuffer, endStream bool) {
+		received += data.String()
+	})
+
+	done := make(chan error)
+	go func() {
+		_, err := s.waitNotify(s.ID)
+		done <- err
+	}()
+	// the frames are forwarded by the worker waiting for the response
+	s.reqBody.receive(buffer.NewIoBufferString("123"), false)
+	s.reqBody.receive(buffer.NewIoBufferString("456"), false)
+	atomic.StoreUint32(&s.upstreamResponded, 1)
+	s.sendNotify()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wait notify not returned after the response received")
+	}
+	if received != "123456" {
+		t.Errorf("unexpected forwarded data: %s", received)
+	}
+}
+
+func TestUpstreamOverflowReply(t *testing.T) {
+	s := &downStream{
+		context:              context.Background(),
+		proxy:                &proxy{},
+		requestInfo:          network.NewRequestInfo(),
+		downstreamReqHeaders: protocol.CommonHeader{},
+	}
+	s.onUpstreamReset(types.StreamOverflow)
+	if code := s.requestInfo.ResponseCode(); code != types.UpstreamOverFlowCode {
+		t.Errorf("expected response code %d, but got %d", types.UpstreamOverFlowCode, code)
+	}
+	if !s.requestInfo.GetResponseFlag(api.UpstreamOverflow) {
+		t.Error("expected the upstream overflow flag is set")
+	}
+	if v, ok := s.downstreamRespHeaders.Get(types.HeaderOverloaded); !ok || v != "true" {
+		t.Errorf("expected the overloaded header in the response, but got %s", v)
+	}
+}
+
+func TestRejectExceedShrunkBufferLimit(t *testing.T) {
+	overload.RegisterResourceReader("mock_buffer", func() uint64 {
+		return 100
+	})
+	m, err := overload.NewManager(&v2.OverloadManagerConfig{
+		ShrunkBufferLimitBytes: 16,
+		ResourceMonitors:       []v2.ResourceMonitorConfig{{Name: "mock_buffer", Max: 100}},
+		Actions: []v2.OverloadActionConfig{
+			{Name: "shrink_buffer_limits", Triggers: []v2.OverloadTriggerConfig{{Resource: "mock_buffer", Threshold: 0.9}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create overload manager failed: %v", err)
+	}
+	m.Start()
+	defer m.Stop()
+
+	newStream := func(body string) *downStream {
+		return &downStream{
+			ID:                   1,
+			context:              context.Background(),
+			proxy:                &proxy{readCallbacks: &mockReadFilterCallbacks{}},
+			requestInfo:          network.NewRequestInfo(),
+			downstreamReqHeaders: protocol.CommonHeader{},
+			downstreamReqDataBuf: buffer.NewIoBufferString(body),
+		}
+	}
+	// the buffered body within the shrunk limit is not rejected
+	s := newStream("small body")
+	if s.exceedBufferLimit() {
+		t.Error("expected the small body does not exceed the shrunk buffer limit")
+	}
+	s = newStream("the buffered request body exceeds the shrunk limit")
+	if phase := s.receive(s.context, s.ID, types.InitPhase); phase != types.UpFilter {
+		t.Errorf("expected the stream is replied directly, but got phase %d", phase)
+	}
+	if code := s.requestInfo.ResponseCode(); code != types.UpstreamOverFlowCode {
+		t.Errorf("expected response code %d, but got %d", types.UpstreamOverFlowCode, code)
+	}
+	if !s.requestInfo.GetResponseFlag(overload.Overloaded) {
+		t.Error("expected the stream is flagged as overloaded")
+	}
+}
+
+type mockPoolClusterManager struct {
+	types.ClusterManager
+	err error
+}
+
+func (m *mockPoolClusterManager) ConnPoolForCluster(types.LoadBalancerContext, types.ClusterSnapshot, api.Protocol) (types.ConnectionPool, error) {
+	return nil, m.err
+}
+
+func TestInitializeUpstreamConnectionPoolOverflow(t *testing.T) {
+	for _, tc := range []struct {
+		err      error
+		overflow bool
+	}{
+		{types.ErrConnPoolOverflow, true},
+		{errors.New("no health hosts"), false},
+	} {
+		s := &downStream{
+			context: context.Background(),
+			proxy: &proxy{
+				config:         &v2.Proxy{UpstreamProtocol: string(mockProtocol)},
+				clusterManager: &mockPoolClusterManager{err: tc.err},
+			},
+			cluster: &namedClusterInfo{name: "test"},
+		}
+		pool, err := s.initializeUpstreamConnectionPool(s)
+		if pool != nil || err == nil {
+			t.Fatalf("expected the error of %v", tc.err)
+		}
+		if overflow := err == types.ErrConnPoolOverflow; overflow != tc.overflow {
+			t.Errorf("error %v, expected overflow %v, but got %v", tc.err, tc.overflow, overflow)
+		}
+	}
+}
+
+func TestRejectOverloaded(t *testing.T) {
+	overload.RegisterResourceReader("mock", func() uint64 {
+		return 100
+	})
+	m, err := overload.NewManager(&v2.OverloadManagerConfig{
+		ResourceMonitors: []v2.ResourceMonitorConfig{{Name: "mock", Max: 100}},
+		Actions: []v2.OverloadActionConfig{
+			{Name: "reject_streams", Triggers: []v2.OverloadTriggerConfig{{Resource: "mock", Threshold: 0.9}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create overload manager failed: %v", err)
+	}
+	m.Start()
+	defer m.Stop()
+
+	s := &downStream{
+		ID:                   1,
+		context:              context.Background(),
+		proxy:                &proxy{},
+		requestInfo:          network.NewRequestInfo(),
+		downstreamReqHeaders: protocol.CommonHeader{},
+	}
+	if phase := s.receive(s.context, s.ID, types.InitPhase); phase != types.UpFilter {
+		t.Errorf("expected the stream is replied directly, but got phase %d", phase)
+	}
+	if code := s.requestInfo.ResponseCode(); code != types.UpstreamOverFlowCode {
+		t.Errorf("expected response code %d, but got %d", types.UpstreamOverFlowCode, code)
+	}
+	if v, ok := s.downstreamRespHeaders.Get(types.HeaderOverloaded); !ok || v != "true" {
+		t.Errorf("expected the overloaded header in the response, but got %s", v)
+	}
+	if !s.requestInfo.GetResponseFlag(overload.Overloaded) || s.requestInfo.GetResponseFlag(api.UpstreamOverflow) {
+		t.Error("expected the stream is flagged as overloaded only")
+	}
+}
+
+func TestDynamicMetadataMatchCriteria(t *testing.T) {
+	s := &downStream{
+		context:     mosnctx.WithValue(context.Background(), types.ContextKeyDynamicMetadata, map[string]string{"version": "v2"}),
+		requestInfo: network.NewRequestInfo(),
+	}
+	criteria := s.MetadataMatchCriteria()
+	if criteria == nil {
+		t.Fatal("expected the criteria of the dynamic metadata")
+	}
+	matches := criteria.MetadataMatchCriteria()
+	if len(matches) != 1 || matches[0].MetadataKeyName() != "version" || matches[0].MetadataValue() != "v2" {
+		t.Errorf("unexpected criteria %v", matches)
+	}
+}
@@ -174,6 +174,10 @@ func (c *mockConnection) ID() uint64 {
 	return 0
 }
 
+func (c *mockConnection) BufferLimit() uint32 {
+	return 0
+}
+
 func (c *mockConnection) LocalAddr() net.Addr {
 	addr, _ := net.ResolveTCPAddr("tcp", "127.0.0.1")
 	return addr
@@ -115,6 +115,16 @@ func NewProxy(ctx context.Context, config *v2.Proxy) Proxy {
 		} else {
 			log.DefaultLogger.Tracef("[proxy] extend config subprotocol is empty")
 		}
+		var generalExtendConfig v2.ProxyGeneralExtendConfig
+		json.Unmarshal([]byte(extJSON), &generalExtendConfig)
+		if generalExtendConfig.Http1UseStream {
+			proxy.context = mosnctx.WithValue(proxy.context, types.ContextKeyUseStream, true)
+			log.DefaultLogger.Tracef("[proxy] extend config http1 use stream")
+		}
+		if generalExtendConfig.EnableH2C {
+			proxy.context = mosnctx.WithValue(proxy.context, types.ContextKeyH2C, true)
+			log.DefaultLogger.Tracef("[proxy] extend config enable h2c")
+		}
 	} else {
 		log.DefaultLogger.Errorf("[proxy] get proxy extend config fail = %v", err)
 	}
@@ -31,10 +31,14 @@ type retryState struct {
 	retryOn          bool
 	retiesRemaining  uint32
 	upstreamProtocol types.ProtocolName
+	// resources are the cluster resources of the routing priority
+	resources types.ResourceManager
+	// retrying is true if the retry is counted in the resources
+	retrying bool
 }
 
 func newRetryState(retryPolicy api.RetryPolicy,
-	requestHeaders api.HeaderMap, cluster types.ClusterInfo, proto api.Protocol) *retryState {
+	requestHeaders api.HeaderMap, cluster types.ClusterInfo, proto api.Protocol, priority types.RoutingPriority) *retryState {
 	rs := &retryState{
 		retryPolicy:      retryPolicy,
 		requestHeaders:   requestHeaders,
@@ -42,6 +46,10 @@ func newRetryState(retryPolicy api.RetryPolicy,
 		retryOn:          retryPolicy.RetryOn(),
 		retiesRemaining:  3,
 		upstreamProtocol: proto,
+		resources:        cluster.ResourceManager(),
+	}
+	if priority != types.DefaultPriority {
+		rs.resources = rs.resources.ForPriority(priority)
 	}
 
 	if retryPolicy.NumRetries() > rs.retiesRemaining {
@@ -60,7 +68,8 @@ func (r *retryState) retry(headers api.HeaderMap, reason types.StreamResetReason
 		return check
 	}
 
-	r.cluster.ResourceManager().Retries().Increase()
+	r.resources.Retries().Increase()
+	r.retrying = true
 	r.cluster.Stats().UpstreamRequestRetry.Inc(1)
 
 	return 0
@@ -77,7 +86,7 @@ func (r *retryState) shouldRetry(headers api.HeaderMap, reason types.StreamReset
 		return api.NoRetry
 	}
 
-	if !r.cluster.ResourceManager().Retries().CanCreate() {
+	if !r.resources.Retries().CanCreate() {
 		r.cluster.Stats().UpstreamRequestRetryOverflow.Inc(1)
 
 		return api.RetryOverflow
@@ -124,5 +133,8 @@ func (r *retryState) doRetryCheck(headers types.HeaderMap, reason types.StreamRe
 }
 
 func (r *retryState) reset() {
-	r.cluster.ResourceManager().Retries().Decrease()
+	if r.retrying {
+		r.retrying = false
+		r.resources.Retries().Decrease()
+	}
 }
@@ -27,6 +27,7 @@ import (
 	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/router"
 	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
 )
 
 func doNothing() {}
@@ -79,7 +80,7 @@ func TestRetryState(t *testing.T) {
 	clusterInfo := &fakeClusterInfo{
 		mgr: &fakeResourceManager{},
 	}
-	rs := newRetryState(policy, nil, clusterInfo, protocol.HTTP1)
+	rs := newRetryState(policy, nil, clusterInfo, protocol.HTTP1, types.DefaultPriority)
 	headerException := protocol.CommonHeader{
 		types.HeaderStatus: "500",
 	}
@@ -118,7 +119,7 @@ func TestRetryConnetionFailed(t *testing.T) {
 	clusterInfo := &fakeClusterInfo{
 		mgr: &fakeResourceManager{},
 	}
-	rs := newRetryState(policy, nil, clusterInfo, protocol.HTTP1)
+	rs := newRetryState(policy, nil, clusterInfo, protocol.HTTP1, types.DefaultPriority)
 	testcases := []struct {
 		Header   types.HeaderMap
 		Reason   types.StreamResetReason
@@ -132,3 +133,47 @@ func TestRetryConnetionFailed(t *testing.T) {
 		}
 	}
 }
+
+func TestRetryStatePriority(t *testing.T) {
+	rcfg := &v2.Router{}
+	rcfg.Route = v2.RouteAction{}
+	rcfg.Route.RetryPolicy = &v2.RetryPolicy{
+		RetryPolicyConfig: v2.RetryPolicyConfig{
+			RetryOn:    true,
+			NumRetries: 10,
+		},
+	}
+	r, _ := router.NewRouteRuleImplBase(nil, rcfg)
+	mgr := cluster.NewResourceManager(v2.CircuitBreakers{
+		Thresholds: []v2.Thresholds{
+			{MaxRetries: 5},
+			{Priority: v2.RoutingPriorityHigh, MaxRetries: 1},
+		},
+	})
+	clusterInfo := &fakeClusterInfo{
+		mgr: mgr,
+	}
+	rs := newRetryState(r.Policy().RetryPolicy(), nil, clusterInfo, protocol.HTTP1, types.HighPriority)
+	if rs.retry(nil, types.StreamConnectionFailed) != api.ShouldRetry {
+		t.Fatal("expected the first retry")
+	}
+	// the retry of another request with the high priority overflows
+	other := newRetryState(r.Policy().RetryPolicy(), nil, clusterInfo, protocol.HTTP1, types.HighPriority)
+	if other.retry(nil, types.StreamConnectionFailed) != api.RetryOverflow {
+		t.Fatal("expected the retry overflows")
+	}
+	// the default priority is not affected
+	if !mgr.Retries().CanCreate() {
+		t.Fatal("expected the retries of the default priority are available")
+	}
+	// the retry is released once
+	rs.reset()
+	rs.reset()
+	if !mgr.ForPriority(types.HighPriority).Retries().CanCreate() {
+		t.Fatal("expected the retry is released")
+	}
+	mgr.ForPriority(types.HighPriority).Retries().Increase()
+	if mgr.ForPriority(types.HighPriority).Retries().CanCreate() {
+		t.Fatal("expected the retries are not released twice")
+	}
+}
@@ -0,0 +1,145 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"mosn.io/mosn/pkg/overload"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// streamBody forwards a body received in data frames.
+// The frames are received by the stream connection, and buffered until the worker of the proxy stream forwards them,
+// the source stream is read disabled while the buffered data exceeds the limit
+type streamBody struct {
+	// source is nil if the stream has no flow control
+	source types.ReadDisableStream
+	limit  uint32
+	// notify wakes up the worker to forward the received frames
+	notify func()
+
+	mux     sync.Mutex
+	buf     types.IoBuffer
+	end     bool
+	started bool
+
+	// send and finished are only used by the worker
+	send     func(data types.IoBuffer, endStream bool)
+	finished bool
+
+	disabled uint32
+	stopped  uint32
+}
+
+func newStreamBody(stream types.Stream, limit uint32, notify func()) *streamBody {
+	limit = overload.BufferLimit(limit)
+	source, _ := stream.(types.ReadDisableStream)
+	if source == nil {
+		limit = 0
+	}
+	return &streamBody{
+		source: source,
+		limit:  limit,
+		notify: notify,
+	}
+}
+
+// receive is called with the data frames of the source stream in order
+func (b *streamBody) receive(data types.IoBuffer, endStream bool) {
+	if atomic.LoadUint32(&b.stopped) == 1 {
+		return
+	}
+
+	b.mux.Lock()
+	if b.buf == nil {
+		b.buf = buffer.GetIoBuffer(data.Len())
+	}
+	b.buf.Write(data.Bytes())
+	b.end = endStream
+	if b.limit > 0 && uint32(b.buf.Len()) > b.limit && atomic.CompareAndSwapUint32(&b.disabled, 0, 1) {
+		b.source.ReadDisable(true)
+	}
+	started := b.started
+	b.mux.Unlock()
+
+	// stopped meanwhile
+	if atomic.LoadUint32(&b.stopped) == 1 {
+		b.enableRead()
+		return
+	}
+	if started {
+		b.notify()
+	}
+}
+
+// start forwards the buffered data by send, the following frames are forwarded by flush.
+// It is called by the worker
+func (b *streamBody) start(send func(data types.IoBuffer, endStream bool)) {
+	b.send = send
+
+	b.mux.Lock()
+	b.started = true
+	b.mux.Unlock()
+
+	b.flush()
+}
+
+// flush forwards the data received since the last flush, it is called by the worker.
+// It returns true if the whole body is forwarded or the body is stopped
+func (b *streamBody) flush() bool {
+	if b.finished || atomic.LoadUint32(&b.stopped) == 1 {
+		return true
+	}
+	if b.send == nil {
+		return false
+	}
+
+	b.mux.Lock()
+	buf, end := b.buf, b.end
+	b.buf = nil
+	b.mux.Unlock()
+
+	// the buffered data is taken, so the source stream can be read again
+	b.enableRead()
+
+	if buf == nil {
+		return false
+	}
+	b.finished = end
+	b.send(buf, end)
+	return end
+}
+
+// stop drops the following frames, the source stream is read enabled so the rest of the body can be drained
+func (b *streamBody) stop() {
+	atomic.StoreUint32(&b.stopped, 1)
+	b.enableRead()
+}
+
+func (b *streamBody) isStopped() bool {
+	return atomic.LoadUint32(&b.stopped) == 1
+}
+
+func (b *streamBody) enableRead() {
+	if atomic.CompareAndSwapUint32(&b.disabled, 1, 0) {
+		b.source.ReadDisable(false)
+	}
+}
@@ -0,0 +1,144 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"testing"
+
+	"mosn.io/pkg/buffer"
+)
+
+type readDisableStream struct {
+	mockStream
+	disabled bool
+}
+
+func (s *readDisableStream) ReadDisable(disable bool) {
+	s.disabled = disable
+}
+
+func TestStreamBodyBuffered(t *testing.T) {
+	source := &readDisableStream{}
+	notified := 0
+	body := newStreamBody(source, 8, func() { notified++ })
+	body.receive(buffer.NewIoBufferString("12345"), false)
+	if source.disabled {
+		t.Fatal("read disabled under the limit")
+	}
+	body.receive(buffer.NewIoBufferString("67890"), false)
+	if !source.disabled {
+		t.Fatal("read not disabled over the limit")
+	}
+	if notified != 0 {
+		t.Fatal("notified before start")
+	}
+
+	var received string
+	var end bool
+	body.start(func(data buffer.IoBuffer, endStream bool) {
+		received += data.String()
+		end = endStream
+	})
+	if source.disabled {
+		t.Fatal("read not enabled after start")
+	}
+	if received != "1234567890" || end {
+		t.Fatalf("unexpected buffered data: %s, %v", received, end)
+	}
+
+	// the frames received after start are forwarded by flush
+	body.receive(buffer.NewIoBufferString("abc"), false)
+	body.receive(buffer.NewIoBufferString("def"), true)
+	if notified != 2 {
+		t.Fatalf("unexpected notified times: %d", notified)
+	}
+	if received != "1234567890" {
+		t.Fatalf("data sent out of flush: %s", received)
+	}
+	if !body.flush() {
+		t.Fatal("body not finished")
+	}
+	if received != "1234567890abcdef" || !end {
+		t.Fatalf("unexpected data: %s, %v", received, end)
+	}
+	if !body.flush() {
+		t.Fatal("body not finished")
+	}
+}
+
+func TestStreamBodyFlowControl(t *testing.T) {
+	source := &readDisableStream{}
+	body := newStreamBody(source, 4, func() {})
+	var received string
+	body.start(func(data buffer.IoBuffer, endStream bool) {
+		received += data.String()
+	})
+	if body.flush() {
+		t.Fatal("body finished without data")
+	}
+
+	body.receive(buffer.NewIoBufferString("12345"), false)
+	if !source.disabled {
+		t.Fatal("read not disabled over the limit")
+	}
+	if body.flush() {
+		t.Fatal("body finished before the last frame")
+	}
+	if source.disabled {
+		t.Fatal("read not enabled after flush")
+	}
+	if received != "12345" {
+		t.Fatalf("unexpected data: %s", received)
+	}
+}
+
+func TestStreamBodyStop(t *testing.T) {
+	source := &readDisableStream{}
+	body := newStreamBody(source, 4, func() {})
+	body.receive(buffer.NewIoBufferString("12345"), false)
+	if !source.disabled {
+		t.Fatal("read not disabled over the limit")
+	}
+	body.stop()
+	if source.disabled {
+		t.Fatal("read not enabled after stop")
+	}
+	sent := false
+	body.start(func(data buffer.IoBuffer, endStream bool) {
+		sent = true
+	})
+	body.receive(buffer.NewIoBufferString("678"), true)
+	if !body.flush() {
+		t.Fatal("stopped body not finished")
+	}
+	if sent {
+		t.Fatal("data sent after stop")
+	}
+}
+
+func TestStreamBodyNoFlowControl(t *testing.T) {
+	body := newStreamBody(&mockStream{}, 4, func() {})
+	body.receive(buffer.NewIoBufferString("12345"), true)
+	var received string
+	body.start(func(data buffer.IoBuffer, endStream bool) {
+		received = data.String()
+	})
+	if received != "12345" {
+		t.Fatalf("unexpected data: %s", received)
+	}
+}
@@ -39,6 +39,16 @@ func (s *downStream) runAppendFilters(p types.Phase, headers types.HeaderMap, da
 	return false
 }
 
+// run stream append filters on the data frames of the response body
+func (s *downStream) runAppendDataFilters(data types.IoBuffer, endStream bool) types.IoBuffer {
+	for _, f := range s.senderFilters {
+		if df, ok := f.filter.(types.StreamSenderDataFilter); ok {
+			data = df.AppendData(s.context, data, endStream)
+		}
+	}
+	return data
+}
+
 // run stream receive filters
 func (s *downStream) runReceiveFilters(p types.Phase, headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap) bool {
 	for ; s.receiverFiltersIndex < len(s.receiverFilters); s.receiverFiltersIndex++ {
@@ -139,6 +149,10 @@ func (f *activeStreamReceiverFilter) SetConvert(on bool) {
 	f.activeStream.noConvert = !on
 }
 
+func (f *activeStreamReceiverFilter) ResetStream() {
+	f.activeStream.resetStream()
+}
+
 // types.StreamSenderFilterHandler
 type activeStreamSenderFilter struct {
 	activeStreamFilter
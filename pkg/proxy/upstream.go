@@ -118,9 +118,35 @@ func (r *upstreamRequest) OnReceive(ctx context.Context, headers types.HeaderMap
 		log.Proxy.Debugf(r.downStream.context, "[proxy] [upstream] OnReceive headers: %+v, data: %+v, trailers: %+v", headers, data, trailers)
 	}
 
+	atomic.StoreUint32(&r.downStream.upstreamResponded, 1)
 	r.downStream.sendNotify()
 }
 
+// types.StreamDataReceiveListener
+func (r *upstreamRequest) OnReceiveHeaders(ctx context.Context, headers types.HeaderMap, endStream bool) {
+	if r.downStream.processDone() || r.setupRetry {
+		return
+	}
+
+	r.downStream.respBody = nil
+	if !endStream {
+		// the buffers are used by the data frames out of the proxy flow, so they are not reused
+		atomic.StoreUint32(&r.downStream.reuseBuffer, 0)
+		r.downStream.respBody = newStreamBody(r.requestSender.GetStream(), r.host.ClusterInfo().ConnBufferLimitBytes(), r.downStream.sendNotify)
+	}
+	r.OnReceive(ctx, headers, nil, nil)
+}
+
+func (r *upstreamRequest) OnReceiveData(ctx context.Context, data types.IoBuffer, endStream bool) {
+	if r.setupRetry {
+		return
+	}
+
+	if body := r.downStream.respBody; body != nil {
+		body.receive(data, endStream)
+	}
+}
+
 func (r *upstreamRequest) receiveHeaders(endStream bool) {
 	if r.downStream.processDone() || r.setupRetry {
 		return
@@ -201,6 +227,17 @@ func (r *upstreamRequest) appendData(endStream bool) {
 	r.requestSender.AppendData(r.downStream.context, r.convertData(data), endStream)
 }
 
+// appendDataFrame sends a data frame of the request body received in data frames
+func (r *upstreamRequest) appendDataFrame(data types.IoBuffer, endStream bool) {
+	if r.downStream.processDone() || r.requestSender == nil {
+		return
+	}
+
+	r.sendComplete = endStream
+	r.dataSent = true
+	r.requestSender.AppendData(r.downStream.context, r.convertData(data), endStream)
+}
+
 func (r *upstreamRequest) convertData(data types.IoBuffer) types.IoBuffer {
 	if r.downStream.noConvert {
 		return data
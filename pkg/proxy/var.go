@@ -21,6 +21,11 @@ import (
 	"context"
 	"strconv"
 
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/mtls"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/trace"
+	"mosn.io/mosn/pkg/types"
 	"mosn.io/mosn/pkg/variable"
 )
 
@@ -40,6 +45,19 @@ const (
 	VarDownstreamLocalAddress   string = "downstream_local_address"
 	VarDownstreamRemoteAddress  string = "downstream_remote_address"
 	VarUpstreamHost             string = "upstream_host"
+	VarRouteName                string = "route_name"
+	VarClusterName              string = "cluster_name"
+	VarDownstreamTLSPeerSubject string = "downstream_tls_peer_subject"
+	VarDownstreamTLSSNI         string = "downstream_tls_sni"
+	VarTraceId                  string = "trace_id"
+	VarSpanId                   string = "span_id"
+	// protocol-neutral request fields, the http1 and http2 streams inject them into the request headers
+	VarRequestMethod string = "request_method"
+	VarRequestPath   string = "request_path"
+	VarRequestHost   string = "request_host"
+	// service and method of xprotocol frames that are ServiceAware, set by the xprotocol streams
+	VarRPCService string = types.VarRPCService
+	VarRPCMethod  string = types.VarRPCMethod
 
 	// ReqHeaderPrefix is the prefix of request header's formatter
 	reqHeaderPrefix string = "request_header_"
@@ -72,7 +90,18 @@ var (
 		variable.NewBasicVariable(VarDownstreamLocalAddress, nil, downstreamLocalAddressGetter, nil, 0),
 		variable.NewBasicVariable(VarDownstreamRemoteAddress, nil, downstreamRemoteAddressGetter, nil, 0),
 		variable.NewBasicVariable(VarUpstreamHost, nil, upstreamHostGetter, nil, 0),
-
+		variable.NewBasicVariable(VarRouteName, nil, routeNameGetter, nil, 0),
+		variable.NewBasicVariable(VarClusterName, nil, clusterNameGetter, nil, 0),
+		variable.NewBasicVariable(VarDownstreamTLSPeerSubject, nil, downstreamTLSPeerSubjectGetter, nil, 0),
+		variable.NewBasicVariable(VarDownstreamTLSSNI, nil, downstreamTLSSNIGetter, nil, 0),
+		variable.NewBasicVariable(VarTraceId, nil, traceIdGetter, nil, 0),
+		variable.NewBasicVariable(VarSpanId, nil, spanIdGetter, nil, 0),
+		variable.NewBasicVariable(VarRequestMethod, protocol.MosnHeaderMethod, requestHeaderGetter, nil, 0),
+		variable.NewBasicVariable(VarRequestPath, protocol.MosnHeaderPathKey, requestHeaderGetter, nil, 0),
+		variable.NewBasicVariable(VarRequestHost, protocol.MosnHeaderHostKey, requestHeaderGetter, nil, 0),
+
+		variable.NewIndexedVariable(VarRPCService, nil, notFoundGetter, variable.BasicSetter, 0),
+		variable.NewIndexedVariable(VarRPCMethod, nil, notFoundGetter, variable.BasicSetter, 0),
 		variable.NewIndexedVariable(VarTryTimeout, nil, nil, variable.BasicSetter, 0),
 		variable.NewIndexedVariable(VarGlobalTimeout, nil, nil, variable.BasicSetter, 0),
 		variable.NewIndexedVariable(VarHijackStatus, nil, nil, variable.BasicSetter, 0),
@@ -232,28 +261,129 @@ func upstreamHostGetter(ctx context.Context, value *variable.IndexedValue, data
 	return variable.ValueNotFound, nil
 }
 
-func requestHeaderMapGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+// routeNameGetter
+// get the name of the matched route
+func routeNameGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
 	proxyBuffers := proxyBuffersByContext(ctx)
-	headers := proxyBuffers.stream.downstreamReqHeaders
+	route := proxyBuffers.stream.route
 
-	headerName := data.(string)
-	headerValue, ok := headers.Get(headerName[reqHeaderIndex:])
-	if !ok {
-		return variable.ValueNotFound, nil
+	if route != nil && route.RouteRule() != nil {
+		if named, ok := route.RouteRule().(types.NamedRouteRule); ok && named.RouteName() != "" {
+			return named.RouteName(), nil
+		}
+	}
+
+	return variable.ValueNotFound, nil
+}
+
+// clusterNameGetter
+// get the name of the upstream cluster
+func clusterNameGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	proxyBuffers := proxyBuffersByContext(ctx)
+	cluster := proxyBuffers.stream.cluster
+
+	if cluster != nil {
+		return cluster.Name(), nil
+	}
+
+	return variable.ValueNotFound, nil
+}
+
+// downstreamTLSConn returns the tls connection of downstream, or nil if the downstream is not tls
+func downstreamTLSConn(ctx context.Context) *mtls.TLSConn {
+	proxyBuffers := proxyBuffersByContext(ctx)
+	p := proxyBuffers.stream.proxy
+
+	if p == nil || p.readCallbacks == nil || p.readCallbacks.Connection() == nil {
+		return nil
+	}
+	conn, _ := p.readCallbacks.Connection().RawConn().(*mtls.TLSConn)
+	return conn
+}
+
+// downstreamTLSPeerSubjectGetter
+// get the subject of downstream's peer certificate
+func downstreamTLSPeerSubjectGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	if conn := downstreamTLSConn(ctx); conn != nil {
+		if certs := conn.ConnectionState().PeerCertificates; len(certs) > 0 {
+			return certs[0].Subject.String(), nil
+		}
+	}
+
+	return variable.ValueNotFound, nil
+}
+
+// downstreamTLSSNIGetter
+// get the server name requested by downstream
+func downstreamTLSSNIGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	if conn := downstreamTLSConn(ctx); conn != nil {
+		if sni := conn.ConnectionState().ServerName; sni != "" {
+			return sni, nil
+		}
+	}
+
+	return variable.ValueNotFound, nil
+}
+
+// traceIdGetter
+// get the trace id of the active span
+func traceIdGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	if span := trace.SpanFromContext(ctx); span != nil && span.TraceId() != "" {
+		return span.TraceId(), nil
+	}
+
+	return variable.ValueNotFound, nil
+}
+
+// spanIdGetter
+// get the span id of the active span
+func spanIdGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	if span := trace.SpanFromContext(ctx); span != nil && span.SpanId() != "" {
+		return span.SpanId(), nil
 	}
 
-	return string(headerValue), nil
+	return variable.ValueNotFound, nil
 }
 
+// notFoundGetter
+// get the variables that are only set by the streams, like the rpc service of the xprotocol frames
+func notFoundGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	return variable.ValueNotFound, nil
+}
+
+// requestHeaderGetter
+// get the request header of data, the header is injected by the stream of each protocol
+func requestHeaderGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	proxyBuffers := proxyBuffersByContext(ctx)
+
+	return headerValue(proxyBuffers.stream.downstreamReqHeaders, data.(string)), nil
+}
+
+// requestHeaderMapGetter
+// get the request header of any protocol, the header name is the variable name without prefix
+func requestHeaderMapGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	proxyBuffers := proxyBuffersByContext(ctx)
+
+	headerName := data.(string)
+	return headerValue(proxyBuffers.stream.downstreamReqHeaders, headerName[reqHeaderIndex:]), nil
+}
+
+// responseHeaderMapGetter
+// get the header of the response received from upstream, the direct responses are not included
 func responseHeaderMapGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
 	proxyBuffers := proxyBuffersByContext(ctx)
-	headers := proxyBuffers.request.upstreamRespHeaders
 
 	headerName := data.(string)
-	headerValue, ok := headers.Get(headerName[respHeaderIndex:])
+	return headerValue(proxyBuffers.request.upstreamRespHeaders, headerName[respHeaderIndex:]), nil
+}
+
+func headerValue(headers api.HeaderMap, key string) string {
+	if headers == nil {
+		return variable.ValueNotFound
+	}
+	value, ok := headers.Get(key)
 	if !ok {
-		return variable.ValueNotFound, nil
+		return variable.ValueNotFound
 	}
-
-	return string(headerValue), nil
+	return value
 }
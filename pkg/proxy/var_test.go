@@ -0,0 +1,176 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/buffer"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/variable"
+)
+
+type namedRouteRule struct {
+	mockRouteRule
+	name string
+}
+
+func (r *namedRouteRule) RouteName() string {
+	return r.name
+}
+
+type namedClusterInfo struct {
+	types.ClusterInfo
+	name string
+}
+
+func (ci *namedClusterInfo) Name() string {
+	return ci.name
+}
+
+type rawConnCallbacks struct {
+	api.ReadFilterCallbacks
+	conn net.Conn
+}
+
+func (cb *rawConnCallbacks) Connection() api.Connection {
+	return &rawConnConnection{conn: cb.conn}
+}
+
+type rawConnConnection struct {
+	mockConnection
+	conn net.Conn
+}
+
+func (c *rawConnConnection) RawConn() net.Conn {
+	return c.conn
+}
+
+type idSpan struct {
+	mockSpan
+}
+
+func (s *idSpan) TraceId() string {
+	return "0a1b2c"
+}
+
+func (s *idSpan) SpanId() string {
+	return "0.1"
+}
+
+func newVariableContext() (context.Context, *proxyBuffers) {
+	ctx := buffer.NewBufferPoolContext(context.Background())
+	ctx = variable.NewVariableContext(ctx)
+	return ctx, proxyBuffersByContext(ctx)
+}
+
+func assertVariable(t *testing.T, ctx context.Context, name, expected string) {
+	t.Helper()
+	value, err := variable.GetVariableValue(ctx, name)
+	if err != nil {
+		t.Errorf("get variable %s failed: %v", name, err)
+		return
+	}
+	if value != expected {
+		t.Errorf("variable %s expected %q, but got %q", name, expected, value)
+	}
+}
+
+func TestHeaderVariables(t *testing.T) {
+	ctx, buffers := newVariableContext()
+
+	// nil headers
+	assertVariable(t, ctx, "request_header_service", variable.ValueNotFound)
+	assertVariable(t, ctx, "response_header_server", variable.ValueNotFound)
+	assertVariable(t, ctx, VarRequestMethod, variable.ValueNotFound)
+
+	buffers.stream.downstreamReqHeaders = protocol.CommonHeader{
+		"service":                  "test",
+		protocol.MosnHeaderMethod:  "GET",
+		protocol.MosnHeaderPathKey: "/path",
+		protocol.MosnHeaderHostKey: "mosn.io",
+		types.HeaderRPCService:     "com.alipay.test.TestService:1.0",
+		types.HeaderRPCMethod:      "sayHello",
+	}
+	// the direct response is not received from upstream
+	buffers.stream.downstreamRespHeaders = protocol.CommonHeader{
+		"server": "MOSN",
+	}
+	assertVariable(t, ctx, "response_header_server", variable.ValueNotFound)
+	// the proxied response
+	buffers.request.upstreamRespHeaders = protocol.CommonHeader{
+		"server": "upstream",
+	}
+	assertVariable(t, ctx, "request_header_service", "test")
+	assertVariable(t, ctx, "request_header_not_exists", variable.ValueNotFound)
+	assertVariable(t, ctx, "response_header_server", "upstream")
+	assertVariable(t, ctx, VarRequestMethod, "GET")
+	assertVariable(t, ctx, VarRequestPath, "/path")
+	assertVariable(t, ctx, VarRequestHost, "mosn.io")
+	// the rpc variables are set by the xprotocol streams, not read from the headers
+	assertVariable(t, ctx, VarRPCService, variable.ValueNotFound)
+	assertVariable(t, ctx, VarRPCMethod, variable.ValueNotFound)
+	variable.SetVariableValue(ctx, VarRPCService, "com.alipay.test.TestService:1.0")
+	variable.SetVariableValue(ctx, VarRPCMethod, "sayHello")
+	assertVariable(t, ctx, VarRPCService, "com.alipay.test.TestService:1.0")
+	assertVariable(t, ctx, VarRPCMethod, "sayHello")
+}
+
+func TestRouteAndClusterVariables(t *testing.T) {
+	ctx, buffers := newVariableContext()
+	assertVariable(t, ctx, VarRouteName, variable.ValueNotFound)
+	assertVariable(t, ctx, VarClusterName, variable.ValueNotFound)
+
+	// route rule without name
+	buffers.stream.route = &mockRoute{}
+	assertVariable(t, ctx, VarRouteName, variable.ValueNotFound)
+
+	buffers.stream.route = &mockRoute{rule: &namedRouteRule{name: "test_route"}}
+	buffers.stream.cluster = &namedClusterInfo{name: "test_cluster"}
+	assertVariable(t, ctx, VarRouteName, "test_route")
+	assertVariable(t, ctx, VarClusterName, "test_cluster")
+}
+
+func TestTLSVariables(t *testing.T) {
+	ctx, buffers := newVariableContext()
+	assertVariable(t, ctx, VarDownstreamTLSPeerSubject, variable.ValueNotFound)
+
+	// not a tls connection
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	buffers.stream.proxy = &proxy{
+		readCallbacks: &rawConnCallbacks{conn: server},
+	}
+	assertVariable(t, ctx, VarDownstreamTLSPeerSubject, variable.ValueNotFound)
+	assertVariable(t, ctx, VarDownstreamTLSSNI, variable.ValueNotFound)
+}
+
+func TestTraceVariables(t *testing.T) {
+	ctx, _ := newVariableContext()
+	assertVariable(t, ctx, VarTraceId, variable.ValueNotFound)
+
+	ctx = mosnctx.WithValue(ctx, types.ContextKeyActiveSpan, &idSpan{})
+	assertVariable(t, ctx, VarTraceId, "0a1b2c")
+	assertVariable(t, ctx, VarSpanId, "0.1")
+}
@@ -0,0 +1,367 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/mtls"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/router"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/variable"
+)
+
+// matcher matches a permission or a principal with the request
+type matcher interface {
+	match(req *Request) bool
+}
+
+var errEmptyRule = errors.New("rbac rule is empty")
+
+func newPermissionMatchers(permissions []*v2.RBACPermission) ([]matcher, error) {
+	matchers := make([]matcher, 0, len(permissions))
+	for _, p := range permissions {
+		m, err := newPermissionMatcher(p)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+func newPermissionMatcher(p *v2.RBACPermission) (matcher, error) {
+	if p == nil {
+		return nil, errEmptyRule
+	}
+	switch {
+	case p.Any:
+		return anyMatcher{}, nil
+	case len(p.AndRules) > 0:
+		rules, err := newPermissionMatchers(p.AndRules)
+		return andMatcher(rules), err
+	case len(p.OrRules) > 0:
+		rules, err := newPermissionMatchers(p.OrRules)
+		return orMatcher(rules), err
+	case p.NotRule != nil:
+		rule, err := newPermissionMatcher(p.NotRule)
+		return notMatcher{rule}, err
+	case p.Header != nil:
+		return newHeaderMatcher(p.Header), nil
+	case p.Path != nil:
+		return newRequestHeaderMatcher(protocol.MosnHeaderPathKey, p.Path)
+	case p.Method != nil:
+		return newRequestHeaderMatcher(protocol.MosnHeaderMethod, p.Method)
+	case p.Service != nil:
+		return newVariableMatcher(types.VarRPCService, p.Service)
+	case p.RPCMethod != nil:
+		return newVariableMatcher(types.VarRPCMethod, p.RPCMethod)
+	case p.DestinationIP != nil:
+		return newIPMatcher(p.DestinationIP, true)
+	case p.DestinationPort != 0:
+		return destinationPortMatcher(p.DestinationPort), nil
+	case p.ServerName != nil:
+		m, err := newStringMatcher(p.ServerName)
+		return &serverNameMatcher{m}, err
+	}
+	return nil, errEmptyRule
+}
+
+func newPrincipalMatchers(principals []*v2.RBACPrincipal) ([]matcher, error) {
+	matchers := make([]matcher, 0, len(principals))
+	for _, p := range principals {
+		m, err := newPrincipalMatcher(p)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+func newPrincipalMatcher(p *v2.RBACPrincipal) (matcher, error) {
+	if p == nil {
+		return nil, errEmptyRule
+	}
+	switch {
+	case p.Any:
+		return anyMatcher{}, nil
+	case len(p.AndIDs) > 0:
+		ids, err := newPrincipalMatchers(p.AndIDs)
+		return andMatcher(ids), err
+	case len(p.OrIDs) > 0:
+		ids, err := newPrincipalMatchers(p.OrIDs)
+		return orMatcher(ids), err
+	case p.NotID != nil:
+		id, err := newPrincipalMatcher(p.NotID)
+		return notMatcher{id}, err
+	case p.SourceIP != nil:
+		return newIPMatcher(p.SourceIP, false)
+	case p.Header != nil:
+		return newHeaderMatcher(p.Header), nil
+	case p.Authenticated != nil:
+		m := &authenticatedMatcher{}
+		if p.Authenticated.PrincipalName != nil {
+			name, err := newStringMatcher(p.Authenticated.PrincipalName)
+			if err != nil {
+				return nil, err
+			}
+			m.name = name
+		}
+		return m, nil
+	}
+	return nil, errEmptyRule
+}
+
+type anyMatcher struct{}
+
+func (anyMatcher) match(req *Request) bool {
+	return true
+}
+
+type andMatcher []matcher
+
+func (m andMatcher) match(req *Request) bool {
+	for _, rule := range m {
+		if !rule.match(req) {
+			return false
+		}
+	}
+	return true
+}
+
+type orMatcher []matcher
+
+func (m orMatcher) match(req *Request) bool {
+	for _, rule := range m {
+		if rule.match(req) {
+			return true
+		}
+	}
+	return false
+}
+
+type notMatcher struct {
+	matcher
+}
+
+func (m notMatcher) match(req *Request) bool {
+	return !m.matcher.match(req)
+}
+
+// headerMatcher matches a request header, it never matches a connection
+type headerMatcher struct {
+	headers []*types.HeaderData
+}
+
+func newHeaderMatcher(h *v2.HeaderMatcher) *headerMatcher {
+	return &headerMatcher{
+		headers: router.GetRouterHeaders([]v2.HeaderMatcher{*h}),
+	}
+}
+
+func (m *headerMatcher) match(req *Request) bool {
+	if req.Headers == nil {
+		return false
+	}
+	return router.ConfigUtilityInst.MatchHeaders(req.Headers, m.headers)
+}
+
+// requestHeaderMatcher matches the request properties set in the headers by the streams, like path and method
+type requestHeaderMatcher struct {
+	key     string
+	matcher *stringMatcher
+}
+
+func newRequestHeaderMatcher(key string, s *v2.StringMatcher) (matcher, error) {
+	m, err := newStringMatcher(s)
+	if err != nil {
+		return nil, err
+	}
+	return &requestHeaderMatcher{key: key, matcher: m}, nil
+}
+
+func (m *requestHeaderMatcher) match(req *Request) bool {
+	if req.Headers == nil {
+		return false
+	}
+	value, ok := req.Headers.Get(m.key)
+	return ok && m.matcher.match(value)
+}
+
+// variableMatcher matches the request properties set in the variables by the streams, like the rpc service,
+// they are decoded from the protocols and can not be spoofed by the headers
+type variableMatcher struct {
+	name    string
+	matcher *stringMatcher
+}
+
+func newVariableMatcher(name string, s *v2.StringMatcher) (matcher, error) {
+	m, err := newStringMatcher(s)
+	if err != nil {
+		return nil, err
+	}
+	return &variableMatcher{name: name, matcher: m}, nil
+}
+
+func (m *variableMatcher) match(req *Request) bool {
+	if req.Context == nil {
+		return false
+	}
+	value, err := variable.GetVariableValue(req.Context, m.name)
+	return err == nil && value != variable.ValueNotFound && m.matcher.match(value)
+}
+
+// ipMatcher matches the source ip, or the destination ip if destination is true
+type ipMatcher struct {
+	cidr        *v2.CidrRange
+	destination bool
+}
+
+func newIPMatcher(c *v2.CidrRange, destination bool) (matcher, error) {
+	// the ip net is parsed here, as CidrRange parses it lazily without a lock
+	cidr := v2.Create(c.Address, c.Length)
+	if cidr == nil {
+		return nil, fmt.Errorf("invalid cidr range %s/%d", c.Address, c.Length)
+	}
+	return &ipMatcher{cidr: cidr, destination: destination}, nil
+}
+
+func (m *ipMatcher) match(req *Request) bool {
+	addr := req.Connection.RemoteAddr()
+	if m.destination {
+		addr = req.Connection.LocalAddr()
+	}
+	ip := addrIP(addr)
+	return ip != nil && m.cidr.IsInRange(ip)
+}
+
+type destinationPortMatcher uint32
+
+func (m destinationPortMatcher) match(req *Request) bool {
+	if addr, ok := req.Connection.LocalAddr().(*net.TCPAddr); ok {
+		return uint32(addr.Port) == uint32(m)
+	}
+	return false
+}
+
+type serverNameMatcher struct {
+	matcher *stringMatcher
+}
+
+func (m *serverNameMatcher) match(req *Request) bool {
+	conn := tlsConn(req.Connection)
+	return conn != nil && m.matcher.match(conn.ConnectionState().ServerName)
+}
+
+// authenticatedMatcher matches the peer certificate of the mTLS connection
+type authenticatedMatcher struct {
+	// name is nil means any peer certificate
+	name *stringMatcher
+}
+
+func (m *authenticatedMatcher) match(req *Request) bool {
+	conn := tlsConn(req.Connection)
+	if conn == nil {
+		return false
+	}
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return false
+	}
+	if m.name == nil {
+		return true
+	}
+	cert := certs[0]
+	if len(cert.URIs) > 0 {
+		for _, uri := range cert.URIs {
+			if m.name.match(uri.String()) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(cert.DNSNames) > 0 {
+		for _, name := range cert.DNSNames {
+			if m.name.match(name) {
+				return true
+			}
+		}
+		return false
+	}
+	return m.name.match(cert.Subject.String())
+}
+
+// stringMatcher matches a string exactly, by prefix, by suffix or by regex
+type stringMatcher struct {
+	exact  string
+	prefix string
+	suffix string
+	regex  *regexp.Regexp
+}
+
+func newStringMatcher(s *v2.StringMatcher) (*stringMatcher, error) {
+	m := &stringMatcher{
+		exact:  s.Exact,
+		prefix: s.Prefix,
+		suffix: s.Suffix,
+	}
+	if s.Regex != "" {
+		// the regex matches the whole string
+		regex, err := regexp.Compile("^(?:" + s.Regex + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %s: %v", s.Regex, err)
+		}
+		m.regex = regex
+	}
+	return m, nil
+}
+
+func (m *stringMatcher) match(value string) bool {
+	switch {
+	case m.regex != nil:
+		return m.regex.MatchString(value)
+	case m.prefix != "":
+		return strings.HasPrefix(value, m.prefix)
+	case m.suffix != "":
+		return strings.HasSuffix(value, m.suffix)
+	}
+	return value == m.exact
+}
+
+func addrIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	}
+	return nil
+}
+
+func tlsConn(conn api.Connection) *mtls.TLSConn {
+	tls, _ := conn.RawConn().(*mtls.TLSConn)
+	return tls
+}
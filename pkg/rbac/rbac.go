@@ -0,0 +1,174 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	mosnmetrics "mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+)
+
+const defaultStatPrefix = "rbac"
+
+// Request is the information matched by the rbac policies
+type Request struct {
+	Connection api.Connection
+	// Headers is nil if a connection is matched
+	Headers api.HeaderMap
+	// Context holds the variables of the stream, it is nil if a connection is matched
+	Context context.Context
+}
+
+type policy struct {
+	name        string
+	permissions []matcher
+	principals  []matcher
+	matched     metrics.Counter
+}
+
+// match returns true if any of the permissions and any of the principals matched
+func (p *policy) match(req *Request) bool {
+	return orMatcher(p.permissions).match(req) && orMatcher(p.principals).match(req)
+}
+
+// Engine evaluates the rbac rules
+type Engine struct {
+	deny     bool
+	policies []*policy
+}
+
+// NewEngine creates an engine with the rules, the policies matched are counted in stats with the prefix
+func NewEngine(rules *v2.RBACRules, stats types.Metrics, prefix string) (*Engine, error) {
+	e := &Engine{}
+	switch rules.Action {
+	case "", v2.RBACAllow:
+	case v2.RBACDeny:
+		e.deny = true
+	default:
+		return nil, fmt.Errorf("unknown rbac action: %s", rules.Action)
+	}
+	// the policies are evaluated in order of name, so the policy matched is determined
+	names := make([]string, 0, len(rules.Policies))
+	for name := range rules.Policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cfg := rules.Policies[name]
+		if cfg == nil {
+			return nil, fmt.Errorf("rbac policy %s is empty", name)
+		}
+		permissions, err := newPermissionMatchers(cfg.Permissions)
+		if err != nil {
+			return nil, fmt.Errorf("rbac policy %s permissions: %v", name, err)
+		}
+		principals, err := newPrincipalMatchers(cfg.Principals)
+		if err != nil {
+			return nil, fmt.Errorf("rbac policy %s principals: %v", name, err)
+		}
+		e.policies = append(e.policies, &policy{
+			name:        name,
+			permissions: permissions,
+			principals:  principals,
+			matched:     stats.Counter(prefix + name),
+		})
+	}
+	return e, nil
+}
+
+// Allowed returns true if the request is allowed, and the name of the policy matched
+func (e *Engine) Allowed(req *Request) (bool, string) {
+	for _, p := range e.policies {
+		if p.match(req) {
+			p.matched.Inc(1)
+			return !e.deny, p.name
+		}
+	}
+	return e.deny, ""
+}
+
+// Authorizer enforces the rules and evaluates the shadow rules
+type Authorizer struct {
+	engine *Engine
+	shadow *Engine
+
+	allowed       metrics.Counter
+	denied        metrics.Counter
+	shadowAllowed metrics.Counter
+	shadowDenied  metrics.Counter
+}
+
+// NewAuthorizer creates an authorizer with the config of rbac filters
+func NewAuthorizer(cfg *v2.RBAC) (*Authorizer, error) {
+	statPrefix := cfg.StatPrefix
+	if statPrefix == "" {
+		statPrefix = defaultStatPrefix
+	}
+	stats := mosnmetrics.NewRBACStats(statPrefix)
+	a := &Authorizer{
+		allowed:       stats.Counter(mosnmetrics.RBACAllowed),
+		denied:        stats.Counter(mosnmetrics.RBACDenied),
+		shadowAllowed: stats.Counter(mosnmetrics.RBACShadowAllowed),
+		shadowDenied:  stats.Counter(mosnmetrics.RBACShadowDenied),
+	}
+	var err error
+	if cfg.Rules != nil {
+		if a.engine, err = NewEngine(cfg.Rules, stats, mosnmetrics.RBACPolicyPrefix); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.ShadowRules != nil {
+		if a.shadow, err = NewEngine(cfg.ShadowRules, stats, mosnmetrics.RBACShadowPolicyPrefix); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// Check returns false if the request should be rejected
+func (a *Authorizer) Check(ctx context.Context, req *Request) bool {
+	if a.shadow != nil {
+		allowed, policy := a.shadow.Allowed(req)
+		if allowed {
+			a.shadowAllowed.Inc(1)
+		} else {
+			a.shadowDenied.Inc(1)
+			log.Proxy.Infof(ctx, "[rbac] shadow rules denied, remote address = %s, policy = %s", req.Connection.RemoteAddr(), policy)
+		}
+	}
+	if a.engine == nil {
+		return true
+	}
+	allowed, policy := a.engine.Allowed(req)
+	if allowed {
+		a.allowed.Inc(1)
+	} else {
+		a.denied.Inc(1)
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(ctx, "[rbac] denied, remote address = %s, policy = %s", req.Connection.RemoteAddr(), policy)
+		}
+	}
+	return allowed
+}
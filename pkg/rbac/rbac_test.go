@@ -0,0 +1,279 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rbac
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	mosnmetrics "mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/variable"
+)
+
+type mockConnection struct {
+	api.Connection
+	remote net.Addr
+	local  net.Addr
+}
+
+func (c *mockConnection) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func (c *mockConnection) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *mockConnection) RawConn() net.Conn {
+	return nil
+}
+
+func newRequest(remote string, headers protocol.CommonHeader) *Request {
+	r, _ := net.ResolveTCPAddr("tcp", remote)
+	l, _ := net.ResolveTCPAddr("tcp", "10.0.0.1:8080")
+	req := &Request{
+		Connection: &mockConnection{remote: r, local: l},
+	}
+	if headers != nil {
+		req.Headers = headers
+	}
+	return req
+}
+
+func newTestEngine(t *testing.T, rules *v2.RBACRules) *Engine {
+	e, err := NewEngine(rules, mosnmetrics.NewRBACStats(t.Name()), mosnmetrics.RBACPolicyPrefix)
+	if err != nil {
+		t.Fatalf("create engine failed: %v", err)
+	}
+	return e
+}
+
+var anyPrincipal = []*v2.RBACPrincipal{{Any: true}}
+
+func TestEngineAllow(t *testing.T) {
+	e := newTestEngine(t, &v2.RBACRules{
+		Policies: map[string]*v2.RBACPolicy{
+			"read": {
+				Permissions: []*v2.RBACPermission{
+					{
+						AndRules: []*v2.RBACPermission{
+							{Method: &v2.StringMatcher{Exact: "GET"}},
+							{Path: &v2.StringMatcher{Prefix: "/api/"}},
+						},
+					},
+				},
+				Principals: []*v2.RBACPrincipal{
+					{SourceIP: &v2.CidrRange{Address: "192.168.1.0", Length: 24}},
+				},
+			},
+		},
+	})
+	testCases := []struct {
+		remote  string
+		headers protocol.CommonHeader
+		allowed bool
+		policy  string
+	}{
+		{"192.168.1.10:1234", protocol.CommonHeader{protocol.MosnHeaderMethod: "GET", protocol.MosnHeaderPathKey: "/api/users"}, true, "read"},
+		{"192.168.2.10:1234", protocol.CommonHeader{protocol.MosnHeaderMethod: "GET", protocol.MosnHeaderPathKey: "/api/users"}, false, ""},
+		{"192.168.1.10:1234", protocol.CommonHeader{protocol.MosnHeaderMethod: "POST", protocol.MosnHeaderPathKey: "/api/users"}, false, ""},
+		// the request properties never match a connection
+		{"192.168.1.10:1234", nil, false, ""},
+	}
+	for i, tc := range testCases {
+		allowed, policy := e.Allowed(newRequest(tc.remote, tc.headers))
+		if allowed != tc.allowed || policy != tc.policy {
+			t.Errorf("#%d expected allowed %v policy %q, but got %v %q", i, tc.allowed, tc.policy, allowed, policy)
+		}
+	}
+}
+
+func newRPCRequest(service, method string, headers protocol.CommonHeader) *Request {
+	// the variables are registered by the proxy, and set by the xprotocol streams
+	variable.RegisterVariable(variable.NewIndexedVariable(types.VarRPCService, nil, nil, variable.BasicSetter, 0))
+	variable.RegisterVariable(variable.NewIndexedVariable(types.VarRPCMethod, nil, nil, variable.BasicSetter, 0))
+	ctx := variable.NewVariableContext(context.Background())
+	if service != "" {
+		variable.SetVariableValue(ctx, types.VarRPCService, service)
+		variable.SetVariableValue(ctx, types.VarRPCMethod, method)
+	}
+	req := newRequest("192.168.1.10:1234", headers)
+	req.Context = ctx
+	return req
+}
+
+func TestEngineRPC(t *testing.T) {
+	e := newTestEngine(t, &v2.RBACRules{
+		Policies: map[string]*v2.RBACPolicy{
+			"rpc": {
+				Permissions: []*v2.RBACPermission{
+					{
+						AndRules: []*v2.RBACPermission{
+							{Service: &v2.StringMatcher{Regex: `com\.alipay\..*`}},
+							{NotRule: &v2.RBACPermission{RPCMethod: &v2.StringMatcher{Exact: "delete"}}},
+						},
+					},
+				},
+				Principals: anyPrincipal,
+			},
+		},
+	})
+	testCases := []struct {
+		req     *Request
+		allowed bool
+	}{
+		{newRPCRequest("com.alipay.TestService", "get", nil), true},
+		{newRPCRequest("com.alipay.TestService", "delete", nil), false},
+		{newRPCRequest("xcom.alipay.TestService", "get", nil), false},
+		// the headers set by the http clients are not matched
+		{newRPCRequest("", "", protocol.CommonHeader{types.HeaderRPCService: "com.alipay.TestService", types.HeaderRPCMethod: "get"}), false},
+		{newRequest("192.168.1.10:1234", protocol.CommonHeader{types.HeaderRPCService: "com.alipay.TestService", types.HeaderRPCMethod: "get"}), false},
+	}
+	for i, tc := range testCases {
+		if allowed, _ := e.Allowed(tc.req); allowed != tc.allowed {
+			t.Errorf("#%d expected allowed %v, but got %v", i, tc.allowed, allowed)
+		}
+	}
+}
+
+func TestEngineDeny(t *testing.T) {
+	e := newTestEngine(t, &v2.RBACRules{
+		Action: v2.RBACDeny,
+		Policies: map[string]*v2.RBACPolicy{
+			"deny-admin": {
+				Permissions: []*v2.RBACPermission{
+					{Header: &v2.HeaderMatcher{Name: "x-role", Value: "admin"}},
+					{DestinationPort: 9090},
+				},
+				Principals: []*v2.RBACPrincipal{
+					{NotID: &v2.RBACPrincipal{SourceIP: &v2.CidrRange{Address: "127.0.0.1", Length: 32}}},
+				},
+			},
+		},
+	})
+	testCases := []struct {
+		remote  string
+		headers protocol.CommonHeader
+		allowed bool
+	}{
+		{"10.0.0.2:1234", protocol.CommonHeader{"x-role": "admin"}, false},
+		{"10.0.0.2:1234", protocol.CommonHeader{"x-role": "user"}, true},
+		{"127.0.0.1:1234", protocol.CommonHeader{"x-role": "admin"}, true},
+	}
+	for i, tc := range testCases {
+		if allowed, _ := e.Allowed(newRequest(tc.remote, tc.headers)); allowed != tc.allowed {
+			t.Errorf("#%d expected allowed %v, but got %v", i, tc.allowed, allowed)
+		}
+	}
+	// the policy matched is counted
+	stats := mosnmetrics.NewRBACStats(t.Name())
+	if cnt := stats.Counter(mosnmetrics.RBACPolicyPrefix + "deny-admin").Count(); cnt != 1 {
+		t.Errorf("expected policy matched count 1, but got %d", cnt)
+	}
+}
+
+func TestEngineConnection(t *testing.T) {
+	e := newTestEngine(t, &v2.RBACRules{
+		Policies: map[string]*v2.RBACPolicy{
+			"port": {
+				Permissions: []*v2.RBACPermission{
+					{
+						AndRules: []*v2.RBACPermission{
+							{DestinationPort: 8080},
+							{DestinationIP: &v2.CidrRange{Address: "10.0.0.0", Length: 8}},
+						},
+					},
+				},
+				Principals: []*v2.RBACPrincipal{
+					{
+						OrIDs: []*v2.RBACPrincipal{
+							{SourceIP: &v2.CidrRange{Address: "172.16.0.0", Length: 12}},
+							// not a tls connection
+							{Authenticated: &v2.RBACAuthenticated{}},
+						},
+					},
+				},
+			},
+		},
+	})
+	if allowed, _ := e.Allowed(newRequest("172.16.0.1:1234", nil)); !allowed {
+		t.Error("expected the connection is allowed")
+	}
+	if allowed, _ := e.Allowed(newRequest("192.168.0.1:1234", nil)); allowed {
+		t.Error("expected the connection is denied")
+	}
+}
+
+func TestEngineInvalid(t *testing.T) {
+	stats := mosnmetrics.NewRBACStats(t.Name())
+	for i, rules := range []*v2.RBACRules{
+		{Action: "LOG"},
+		{Policies: map[string]*v2.RBACPolicy{"empty": {
+			Permissions: []*v2.RBACPermission{{}},
+			Principals:  anyPrincipal,
+		}}},
+		{Policies: map[string]*v2.RBACPolicy{"regex": {
+			Permissions: []*v2.RBACPermission{{Path: &v2.StringMatcher{Regex: "("}}},
+			Principals:  anyPrincipal,
+		}}},
+		{Policies: map[string]*v2.RBACPolicy{"cidr": {
+			Permissions: []*v2.RBACPermission{{Any: true}},
+			Principals:  []*v2.RBACPrincipal{{SourceIP: &v2.CidrRange{Address: "invalid", Length: 8}}},
+		}}},
+	} {
+		if _, err := NewEngine(rules, stats, mosnmetrics.RBACPolicyPrefix); err == nil {
+			t.Errorf("#%d expected an error", i)
+		}
+	}
+}
+
+func TestAuthorizerShadow(t *testing.T) {
+	a, err := NewAuthorizer(&v2.RBAC{
+		StatPrefix: t.Name(),
+		ShadowRules: &v2.RBACRules{
+			Policies: map[string]*v2.RBACPolicy{
+				"local": {
+					Permissions: []*v2.RBACPermission{{Any: true}},
+					Principals:  []*v2.RBACPrincipal{{SourceIP: &v2.CidrRange{Address: "127.0.0.1", Length: 32}}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the shadow rules are never enforced
+	if !a.Check(context.Background(), newRequest("127.0.0.1:1234", nil)) ||
+		!a.Check(context.Background(), newRequest("10.0.0.2:1234", nil)) {
+		t.Fatal("expected the requests are allowed")
+	}
+	stats := mosnmetrics.NewRBACStats(t.Name())
+	if allowed, denied := stats.Counter(mosnmetrics.RBACShadowAllowed).Count(), stats.Counter(mosnmetrics.RBACShadowDenied).Count(); allowed != 1 || denied != 1 {
+		t.Errorf("expected shadow allowed 1 and denied 1, but got %d %d", allowed, denied)
+	}
+	if cnt := stats.Counter(mosnmetrics.RBACShadowPolicyPrefix + "local").Count(); cnt != 1 {
+		t.Errorf("expected shadow policy matched count 1, but got %d", cnt)
+	}
+	if cnt := stats.Counter(mosnmetrics.RBACAllowed).Count(); cnt != 0 {
+		t.Errorf("expected no enforced stats without rules, but got %d", cnt)
+	}
+}
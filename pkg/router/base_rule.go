@@ -18,6 +18,8 @@
 package router
 
 import (
+	"context"
+	"fmt"
 	"math/rand"
 	"strings"
 	"sync"
@@ -36,7 +38,10 @@ type RouteRuleImplBase struct {
 	vHost                 *VirtualHostImpl
 	routerMatch           v2.RouterMatch
 	configHeaders         []*types.HeaderData
-	configQueryParameters []types.QueryParameterMatcher //TODO: not implement yet
+	configQueryParameters []types.QueryParameterMatcher
+	configCookies         []*types.HeaderData
+	methods               []string
+	runtimeFraction       *v2.RuntimeFractionalPercent
 	// rewrite
 	prefixRewrite         string
 	hostRewrite           string
@@ -44,12 +49,15 @@ type RouteRuleImplBase struct {
 	requestHeadersParser  *headerParser
 	responseHeadersParser *headerParser
 	// information
+	routeName        string
 	upstreamProtocol string
 	perFilterConfig  map[string]interface{}
 	// policy
 	policy *policy
 	// direct response
 	directResponseRule *directResponseImpl
+	corsPolicy         *corsPolicyImpl
+	priority           types.RoutingPriority
 	// action
 	routerAction       v2.RouteAction
 	defaultCluster     *weightedClusterEntry // cluster name and metadata
@@ -62,15 +70,19 @@ type RouteRuleImplBase struct {
 func NewRouteRuleImplBase(vHost *VirtualHostImpl, route *v2.Router) (*RouteRuleImplBase, error) {
 	base := &RouteRuleImplBase{
 		vHost:                 vHost,
+		routeName:             route.Name,
 		routerMatch:           route.Match,
 		configHeaders:         getRouterHeaders(route.Match.Headers),
+		configQueryParameters: getQueryParameters(route.Match.QueryParameters),
+		configCookies:         getRouterHeaders(route.Match.Cookies),
+		methods:               route.Match.Methods,
+		runtimeFraction:       route.Match.RuntimeFraction,
 		prefixRewrite:         route.Route.PrefixRewrite,
 		hostRewrite:           route.Route.HostRewrite,
 		autoHostRewrite:       route.Route.AutoHostRewrite,
 		requestHeadersParser:  getHeaderParser(route.Route.RequestHeadersToAdd, nil),
 		responseHeadersParser: getHeaderParser(route.Route.ResponseHeadersToAdd, route.Route.ResponseHeadersToRemove),
 		upstreamProtocol:      route.Route.UpstreamProtocol,
-		perFilterConfig:       route.PerFilterConfig,
 		policy:                &policy{},
 		routerAction:          route.Route,
 		defaultCluster: &weightedClusterEntry{
@@ -91,6 +103,22 @@ func NewRouteRuleImplBase(vHost *VirtualHostImpl, route *v2.Router) (*RouteRuleI
 			numRetries:   route.Route.RetryPolicy.NumRetries,
 		}
 	}
+	corsPolicy, err := newCorsPolicy(route.Route.Cors)
+	if err != nil {
+		return nil, err
+	}
+	if base.perFilterConfig, err = parsePerFilterConfig(route.PerFilterConfig); err != nil {
+		return nil, err
+	}
+	base.corsPolicy = corsPolicy
+	switch route.Route.Priority {
+	case "", v2.RoutingPriorityDefault:
+		base.priority = types.DefaultPriority
+	case v2.RoutingPriorityHigh:
+		base.priority = types.HighPriority
+	default:
+		return nil, fmt.Errorf("invalid route priority %s", route.Route.Priority)
+	}
 	// add direct repsonse rule
 	if route.DirectResponse != nil {
 		base.directResponseRule = &directResponseImpl{
@@ -106,6 +134,33 @@ func (rri *RouteRuleImplBase) DirectResponseRule() api.DirectResponseRule {
 }
 
 // types.RouteRule
+// RouteName returns the configured route name
+func (rri *RouteRuleImplBase) RouteName() string {
+	return rri.routeName
+}
+
+// Http1UseStream returns whether the HTTP/1 bodies are forwarded to and from the upstream in data frames
+func (rri *RouteRuleImplBase) Http1UseStream() bool {
+	return rri.routerAction.Http1UseStream
+}
+
+// CorsPolicy returns the cors policy of the route, or the virtual host's if it is not configured on the route
+func (rri *RouteRuleImplBase) CorsPolicy() types.CorsPolicy {
+	policy := rri.corsPolicy
+	if policy == nil && rri.vHost != nil {
+		policy = rri.vHost.corsPolicy
+	}
+	if policy == nil || policy.disabled {
+		return nil
+	}
+	return policy
+}
+
+// Priority returns the routing priority of the route
+func (rri *RouteRuleImplBase) Priority() types.RoutingPriority {
+	return rri.priority
+}
+
 // Select Cluster for Routing
 // if weighted cluster is nil, return clusterName directly, else
 // select cluster from weighted-clusters
@@ -160,31 +215,86 @@ func (rri *RouteRuleImplBase) PerFilterConfig() map[string]interface{} {
 
 // matchRoute is a common matched for http
 func (rri *RouteRuleImplBase) matchRoute(headers api.HeaderMap, randomValue uint64) bool {
-	// 1. match headers' KV
+	// 1. match method
+	if len(rri.methods) > 0 && !rri.matchMethod(headers) {
+		log.DefaultLogger.Debugf(RouterLogFormat, "routerule", "match method", headers)
+		return false
+	}
+	// 2. match headers' KV
 	if !ConfigUtilityInst.MatchHeaders(headers, rri.configHeaders) {
 		log.DefaultLogger.Debugf(RouterLogFormat, "routerule", "match header", headers)
 		return false
 	}
-	// 2. match query parameters
-	var queryParams types.QueryParams
-	if QueryString, ok := headers.Get(protocol.MosnHeaderQueryStringKey); ok {
-		queryParams = httpmosn.ParseQueryString(QueryString)
-	}
-	if len(queryParams) != 0 {
+	// 3. match query parameters
+	if len(rri.configQueryParameters) != 0 {
+		var queryParams types.QueryParams
+		if QueryString, ok := headers.Get(protocol.MosnHeaderQueryStringKey); ok {
+			queryParams = httpmosn.ParseQueryString(QueryString)
+		}
 		if !ConfigUtilityInst.MatchQueryParams(queryParams, rri.configQueryParameters) {
 			log.DefaultLogger.Debugf(RouterLogFormat, "routerule", "match query params", queryParams)
 			return false
 		}
 	}
+	// 4. match cookies
+	if len(rri.configCookies) != 0 {
+		var cookies map[string]string
+		if cookie, ok := headers.Get(cookieHeaderKey); ok {
+			cookies = parseCookies(cookie)
+		}
+		if !ConfigUtilityInst.MatchCookies(cookies, rri.configCookies) {
+			log.DefaultLogger.Debugf(RouterLogFormat, "routerule", "match cookies", cookies)
+			return false
+		}
+	}
+	// 5. match runtime fraction, it should be the last one
+	if rri.runtimeFraction != nil && !rri.matchRuntimeFraction(randomValue) {
+		log.DefaultLogger.Debugf(RouterLogFormat, "routerule", "match runtime fraction", rri.runtimeFraction)
+		return false
+	}
 	return true
 }
 
+func (rri *RouteRuleImplBase) matchMethod(headers api.HeaderMap) bool {
+	method, ok := headers.Get(protocol.MosnHeaderMethod)
+	if !ok {
+		return false
+	}
+	for _, m := range rri.methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRuntimeFraction matches the random value of the request, so the routes are matched consistently in a request
+func (rri *RouteRuleImplBase) matchRuntimeFraction(randomValue uint64) bool {
+	denominator := uint64(rri.runtimeFraction.Denominator.Value())
+	return randomValue%denominator < uint64(rri.runtimeFraction.Numerator)
+}
+
+func (rri *RouteRuleImplBase) hasPrefix(path, prefix string) bool {
+	if rri.caseSensitive(true) {
+		return strings.HasPrefix(path, prefix)
+	}
+	return len(path) >= len(prefix) && strings.EqualFold(path[:len(prefix)], prefix)
+}
+
+// caseSensitive returns the case sensitive config, or the default value if it is not configured
+func (rri *RouteRuleImplBase) caseSensitive(defaultValue bool) bool {
+	if rri.routerMatch.CaseSensitive == nil {
+		return defaultValue
+	}
+	return *rri.routerMatch.CaseSensitive
+}
+
 func (rri *RouteRuleImplBase) finalizePathHeader(headers api.HeaderMap, matchedPath string) {
 	if len(rri.prefixRewrite) < 1 {
 		return
 	}
 	if path, ok := headers.Get(protocol.MosnHeaderPathKey); ok {
-		if strings.HasPrefix(path, matchedPath) {
+		if rri.hasPrefix(path, matchedPath) {
 			headers.Set(protocol.MosnOriginalHeaderPathKey, path)
 			headers.Set(protocol.MosnHeaderPathKey, rri.prefixRewrite+path[len(matchedPath):])
 			log.DefaultLogger.Infof(RouterLogFormat, "routerule", "finalizePathHeader", "add prefix to path, prefix is "+rri.prefixRewrite)
@@ -193,20 +303,30 @@ func (rri *RouteRuleImplBase) finalizePathHeader(headers api.HeaderMap, matchedP
 }
 
 func (rri *RouteRuleImplBase) FinalizeRequestHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
-	rri.finalizeRequestHeaders(headers, requestInfo)
+	rri.finalizeRequestHeaders(context.Background(), headers, requestInfo)
+}
+
+// FinalizeRequestHeadersWithContext implements types.HeaderFinalizer
+func (rri *RouteRuleImplBase) FinalizeRequestHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	rri.finalizeRequestHeaders(ctx, headers, requestInfo)
 }
 
-func (rri *RouteRuleImplBase) finalizeRequestHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
-	rri.requestHeadersParser.evaluateHeaders(headers, requestInfo)
-	rri.vHost.requestHeadersParser.evaluateHeaders(headers, requestInfo)
-	rri.vHost.globalRouteConfig.requestHeadersParser.evaluateHeaders(headers, requestInfo)
+func (rri *RouteRuleImplBase) finalizeRequestHeaders(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	rri.requestHeadersParser.evaluateHeaders(ctx, headers, requestInfo)
+	rri.vHost.requestHeadersParser.evaluateHeaders(ctx, headers, requestInfo)
+	rri.vHost.globalRouteConfig.requestHeadersParser.evaluateHeaders(ctx, headers, requestInfo)
 	if len(rri.hostRewrite) > 0 {
 		headers.Set(protocol.IstioHeaderHostKey, rri.hostRewrite)
 	}
 }
 
 func (rri *RouteRuleImplBase) FinalizeResponseHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
-	rri.responseHeadersParser.evaluateHeaders(headers, requestInfo)
-	rri.vHost.responseHeadersParser.evaluateHeaders(headers, requestInfo)
-	rri.vHost.globalRouteConfig.responseHeadersParser.evaluateHeaders(headers, requestInfo)
+	rri.FinalizeResponseHeadersWithContext(context.Background(), headers, requestInfo)
+}
+
+// FinalizeResponseHeadersWithContext implements types.HeaderFinalizer
+func (rri *RouteRuleImplBase) FinalizeResponseHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	rri.responseHeadersParser.evaluateHeaders(ctx, headers, requestInfo)
+	rri.vHost.responseHeadersParser.evaluateHeaders(ctx, headers, requestInfo)
+	rri.vHost.globalRouteConfig.responseHeadersParser.evaluateHeaders(ctx, headers, requestInfo)
 }
@@ -49,6 +49,53 @@ func TestNilMetadataMatchCriteria(t *testing.T) {
 	}
 }
 
+func TestRouteName(t *testing.T) {
+	route := &v2.Router{}
+	route.Name = "test_route"
+	route.Route = v2.RouteAction{
+		RouterActionConfig: v2.RouterActionConfig{
+			ClusterName: "test",
+		},
+	}
+	rule, err := NewRouteRuleImplBase(nil, route)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var named interface{} = rule
+	if n, ok := named.(types.NamedRouteRule); !ok || n.RouteName() != "test_route" {
+		t.Errorf("route name is not expected, got %v", named)
+	}
+}
+
+func TestRoutePriority(t *testing.T) {
+	for priority, expected := range map[string]types.RoutingPriority{
+		"":                        types.DefaultPriority,
+		v2.RoutingPriorityDefault: types.DefaultPriority,
+		v2.RoutingPriorityHigh:    types.HighPriority,
+	} {
+		route := &v2.Router{}
+		route.Route = v2.RouteAction{
+			RouterActionConfig: v2.RouterActionConfig{
+				ClusterName: "test",
+				Priority:    priority,
+			},
+		}
+		rule, err := NewRouteRuleImplBase(nil, route)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r interface{} = rule
+		if p, ok := r.(types.PriorityRouteRule); !ok || p.Priority() != expected {
+			t.Errorf("priority %s is not expected", priority)
+		}
+	}
+	route := &v2.Router{}
+	route.Route.Priority = "low"
+	if _, err := NewRouteRuleImplBase(nil, route); err == nil {
+		t.Error("expected the invalid priority is rejected")
+	}
+}
+
 func TestWeightedClusterSelect(t *testing.T) {
 	routerMock1 := &v2.Router{}
 	routerMock1.Route = v2.RouteAction{
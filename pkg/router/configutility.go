@@ -18,8 +18,8 @@
 package router
 
 import (
-	"regexp"
 	"sort"
+	"strconv"
 
 	"mosn.io/api"
 	v2 "mosn.io/mosn/pkg/config/v2"
@@ -40,23 +40,12 @@ func (cu *configUtility) MatchHeaders(requestHeaders api.HeaderMap, configHeader
 		log.DefaultLogger.Debugf(RouterLogFormat, "config utility", "try match header", requestHeaders)
 	}
 	for _, cfgHeaderData := range configHeaders {
-		cfgName := cfgHeaderData.Name.Get()
-		cfgValue := cfgHeaderData.Value
 		// if a condition is not matched, return false
 		// all condition matched, return true
-		value, ok := requestHeaders.Get(cfgName)
-		if !ok {
+		value, ok := requestHeaders.Get(cfgHeaderData.Name.Get())
+		if !matchHeaderData(cfgHeaderData, value, ok) {
 			return false
 		}
-		if cfgHeaderData.IsRegex {
-			if !cfgHeaderData.RegexPattern.MatchString(value) {
-				return false
-			}
-		} else {
-			if cfgValue != value {
-				return false
-			}
-		}
 	}
 	return true
 }
@@ -76,25 +65,45 @@ func (cu *configUtility) MatchQueryParams(queryParams types.QueryParams, configQ
 	return true
 }
 
+// MatchCookies checks the cookies in the request, the cookie name is case sensitive
+func (cu *configUtility) MatchCookies(cookies map[string]string, configCookies []*types.HeaderData) bool {
+	for _, cfgCookie := range configCookies {
+		value, ok := cookies[cfgCookie.Name.Get()]
+		if !matchHeaderData(cfgCookie, value, ok) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchHeaderData checks a value with the config, exists means the value is found in the request or not
+func matchHeaderData(cfg *types.HeaderData, value string, exists bool) bool {
+	var matched bool
+	switch {
+	case cfg.PresentMatch != nil:
+		matched = exists == *cfg.PresentMatch
+	case !exists:
+		matched = false
+	case cfg.RangeMatch != nil:
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			matched = v >= cfg.RangeMatch.Start && v < cfg.RangeMatch.End
+		}
+	case cfg.IsRegex:
+		matched = cfg.RegexPattern.MatchString(value)
+	default:
+		matched = cfg.Value == value
+	}
+	return matched != cfg.InvertMatch
+}
+
+// queryParameterMatcher implements types.QueryParameterMatcher
 type queryParameterMatcher struct {
-	name         string
-	value        string
-	isRegex      bool
-	regexPattern regexp.Regexp
+	types.HeaderData
 }
 
 func (qpm *queryParameterMatcher) Matches(requestQueryParams types.QueryParams) bool {
-	requestQueryValue, ok := requestQueryParams[qpm.name]
-	if !ok {
-		return false
-	}
-	if qpm.isRegex {
-		return qpm.regexPattern.MatchString(requestQueryValue)
-	}
-	if qpm.value == "" {
-		return true
-	}
-	return qpm.value == requestQueryValue
+	requestQueryValue, ok := requestQueryParams[qpm.Name.Get()]
+	return matchHeaderData(&qpm.HeaderData, requestQueryValue, ok)
 }
 
 // NewConfigImpl return an configImpl instance contains requestHeadersParser and responseHeadersParser
@@ -132,10 +141,18 @@ func (mmcti *MetadataMatchCriteriaImpl) MetadataMatchCriteria() []api.MetadataMa
 	return mmcti.MatchCriteriaArray
 }
 
-// MergeMatchCriteria
-// No usage currently
+// MergeMatchCriteria returns a new criteria merged with the metadata matches, the existing values are overridden.
+// The values that are not strings are ignored
 func (mmcti *MetadataMatchCriteriaImpl) MergeMatchCriteria(metadataMatches map[string]interface{}) api.MetadataMatchCriteria {
-	return nil
+	matches := make(map[string]string, len(metadataMatches))
+	for k, v := range metadataMatches {
+		if s, ok := v.(string); ok {
+			matches[k] = s
+		}
+	}
+	merged := &MetadataMatchCriteriaImpl{}
+	merged.extractMetadataMatchCriteria(mmcti, matches)
+	return merged
 }
 
 func (mmcti *MetadataMatchCriteriaImpl) Len() int {
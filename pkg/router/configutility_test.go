@@ -215,3 +215,24 @@ func TestMetadataMatchCriteriaImplSort(t *testing.T) {
 		}
 	}
 }
+
+func TestMetadataMatchCriteriaImplMerge(t *testing.T) {
+	criteria := NewMetadataMatchCriteriaImpl(map[string]string{"label": "green", "version": "v1"})
+	merged := criteria.MergeMatchCriteria(map[string]interface{}{
+		"version": "v2",
+		"zone":    "a",
+		"ignored": 1,
+	})
+	expected := []string{"label=green", "version=v2", "zone=a"}
+	var got []string
+	for _, c := range merged.MetadataMatchCriteria() {
+		got = append(got, c.MetadataKeyName()+"="+c.MetadataValue())
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, but got %v", expected, got)
+	}
+	// the origin criteria is not changed
+	if v := criteria.MetadataMatchCriteria()[1].MetadataValue(); v != "v1" {
+		t.Errorf("expected the origin criteria is kept, but got %s", v)
+	}
+}
@@ -0,0 +1,105 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package router
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+type corsPolicyImpl struct {
+	disabled         bool
+	allowAnyOrigin   bool
+	allowOrigins     map[string]struct{}
+	allowOriginRegex []*regexp.Regexp
+	allowMethods     string
+	allowHeaders     string
+	exposeHeaders    string
+	maxAge           string
+	allowCredentials bool
+}
+
+func newCorsPolicy(cfg *v2.CorsPolicy) (*corsPolicyImpl, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	policy := &corsPolicyImpl{
+		disabled:         cfg.Disabled,
+		allowOrigins:     make(map[string]struct{}, len(cfg.AllowOrigins)),
+		allowMethods:     strings.Join(cfg.AllowMethods, ","),
+		allowHeaders:     strings.Join(cfg.AllowHeaders, ","),
+		exposeHeaders:    strings.Join(cfg.ExposeHeaders, ","),
+		allowCredentials: cfg.AllowCredentials,
+	}
+	for _, origin := range cfg.AllowOrigins {
+		if origin == "*" {
+			policy.allowAnyOrigin = true
+		}
+		policy.allowOrigins[origin] = struct{}{}
+	}
+	// the regex should match the whole origin
+	for _, expr := range cfg.AllowOriginRegex {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid cors allow origin regex %s: %v", expr, err)
+		}
+		policy.allowOriginRegex = append(policy.allowOriginRegex, re)
+	}
+	if cfg.MaxAge != nil {
+		policy.maxAge = strconv.FormatInt(int64(cfg.MaxAge.Seconds()), 10)
+	}
+	return policy, nil
+}
+
+func (p *corsPolicyImpl) AllowOrigin(origin string) bool {
+	if p.allowAnyOrigin {
+		return true
+	}
+	if _, ok := p.allowOrigins[origin]; ok {
+		return true
+	}
+	for _, re := range p.allowOriginRegex {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *corsPolicyImpl) AllowMethods() string {
+	return p.allowMethods
+}
+
+func (p *corsPolicyImpl) AllowHeaders() string {
+	return p.allowHeaders
+}
+
+func (p *corsPolicyImpl) ExposeHeaders() string {
+	return p.exposeHeaders
+}
+
+func (p *corsPolicyImpl) MaxAge() string {
+	return p.maxAge
+}
+
+func (p *corsPolicyImpl) AllowCredentials() bool {
+	return p.allowCredentials
+}
@@ -0,0 +1,90 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package router
+
+import (
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestCorsPolicy(t *testing.T) {
+	vh, err := NewVirtualHostImpl(&v2.VirtualHost{
+		Name:    "test",
+		Domains: []string{"*"},
+		Cors: &v2.CorsPolicy{
+			AllowOrigins:     []string{"https://a.example.com"},
+			AllowOriginRegex: []string{`https://.*\.test\.com`},
+			AllowMethods:     []string{"GET", "POST"},
+			MaxAge:           &api.DurationConfig{Duration: time.Hour},
+		},
+		Routers: []v2.Router{
+			newTestRouter("/vhost", nil),
+			newTestRouter("/route", &v2.CorsPolicy{AllowOrigins: []string{"*"}, AllowCredentials: true}),
+			newTestRouter("/disabled", &v2.CorsPolicy{Disabled: true}),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	policyOf := func(path string) types.CorsPolicy {
+		route := vh.GetRouteFromEntries(protocol.CommonHeader{protocol.MosnHeaderPathKey: path}, 1)
+		if route == nil {
+			t.Fatalf("no route matched %s", path)
+		}
+		return route.RouteRule().(types.CorsRouteRule).CorsPolicy()
+	}
+
+	policy := policyOf("/vhost")
+	if policy == nil || policy.AllowMethods() != "GET,POST" || policy.MaxAge() != "3600" || policy.AllowCredentials() {
+		t.Fatalf("unexpected virtual host policy %+v", policy)
+	}
+	for origin, allowed := range map[string]bool{
+		"https://a.example.com":         true,
+		"https://b.example.com":         false,
+		"https://x.test.com":            true,
+		"https://x.test.com.attack.com": false,
+	} {
+		if policy.AllowOrigin(origin) != allowed {
+			t.Errorf("origin %s expected allowed %t", origin, allowed)
+		}
+	}
+	if policy := policyOf("/route"); policy == nil || !policy.AllowOrigin("https://any.com") || !policy.AllowCredentials() {
+		t.Fatalf("unexpected route policy %+v", policy)
+	}
+	if policy := policyOf("/disabled"); policy != nil {
+		t.Fatalf("expected the policy is disabled, but got %+v", policy)
+	}
+
+	if _, err := NewVirtualHostImpl(&v2.VirtualHost{
+		Name: "invalid",
+		Cors: &v2.CorsPolicy{AllowOriginRegex: []string{"("}},
+	}); err == nil {
+		t.Fatal("expected invalid origin regex")
+	}
+}
+
+func newTestRouter(prefix string, cors *v2.CorsPolicy) v2.Router {
+	router := v2.Router{}
+	router.Match = v2.RouterMatch{Prefix: prefix}
+	router.Route = v2.RouteAction{RouterActionConfig: v2.RouterActionConfig{ClusterName: "test", Cors: cors}}
+	return router
+}
@@ -25,6 +25,7 @@ import (
 	"mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/utils"
 )
 
 func init() {
@@ -34,6 +35,9 @@ func init() {
 
 var defaultRouterRuleFactoryOrder routerRuleFactoryOrder
 
+// routeRandom generates the random values for the route matching
+var routeRandom = utils.NewFastRandom()
+
 func RegisterRouterRule(f RouterRuleFactory, order uint32) {
 	if defaultRouterRuleFactoryOrder.order < order {
 		log.DefaultLogger.Infof(RouterLogFormat, "Extend", "RegisterRouterRule", fmt.Sprintf("order is %d", order))
@@ -57,6 +61,34 @@ func DefaultSofaRouterRuleFactory(base *RouteRuleImplBase, headers []v2.HeaderMa
 	return nil
 }
 
+// perFilterConfigParsers is the parsers of the per filter configs, keyed by the filter name
+var perFilterConfigParsers = map[string]PerFilterConfigParser{}
+
+// RegisterPerFilterConfigParser registers the parser of a filter's per route config, it should be called in init
+func RegisterPerFilterConfigParser(name string, parser PerFilterConfigParser) {
+	perFilterConfigParsers[name] = parser
+}
+
+// parsePerFilterConfig returns the per filter configs with the registered parsers applied,
+// the configs are copied, so the route config is not changed
+func parsePerFilterConfig(configs map[string]interface{}) (map[string]interface{}, error) {
+	if len(configs) == 0 {
+		return configs, nil
+	}
+	parsed := make(map[string]interface{}, len(configs))
+	for name, cfg := range configs {
+		if parser, ok := perFilterConfigParsers[name]; ok {
+			v, err := parser(cfg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid per filter config of %s: %v", name, err)
+			}
+			cfg = v
+		}
+		parsed[name] = cfg
+	}
+	return parsed, nil
+}
+
 var makeHandlerChainOrder handlerChainOrder
 
 func RegisterMakeHandlerChain(f MakeHandlerChain, order uint32) {
@@ -89,7 +121,7 @@ func (h *simpleHandler) Route() api.Route {
 
 func DefaultMakeHandlerChain(ctx context.Context, headers api.HeaderMap, routers types.Routers, clusterManager types.ClusterManager) *RouteHandlerChain {
 	var handlers []types.RouteHandler
-	if r := routers.MatchRoute(headers, 1); r != nil {
+	if r := routers.MatchRoute(headers, routeRandom.Uint64()); r != nil {
 		if log.Proxy.GetLogLevel() >= log.DEBUG {
 			log.Proxy.Debugf(ctx, RouterLogFormat, "DefaultHandklerChain", "MatchRoute", fmt.Sprintf("matched a route: %v", r))
 		}
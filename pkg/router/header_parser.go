@@ -18,22 +18,43 @@
 package router
 
 import (
+	"context"
 	"fmt"
 
+	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/types"
 )
 
+// HeadersEvaluator adds and removes the headers, which is used by the stream filters transforming the headers
+type HeadersEvaluator interface {
+	EvaluateHeaders(ctx context.Context, headers types.HeaderMap, requestInfo types.RequestInfo)
+}
+
+// NewHeadersEvaluator creates a HeadersEvaluator, the values of the headers to add can contain variables like the route config.
+// nil is returned if there is no header configured
+func NewHeadersEvaluator(headersToAdd []*v2.HeaderValueOption, headersToRemove []string) HeadersEvaluator {
+	if h := getHeaderParser(headersToAdd, headersToRemove); h != nil {
+		return h
+	}
+	return nil
+}
+
 type headerParser struct {
 	headersToAdd    []*headerPair
 	headersToRemove []*lowerCaseString
 }
 
-func (h *headerParser) evaluateHeaders(headers types.HeaderMap, requestInfo types.RequestInfo) {
+// EvaluateHeaders implements HeadersEvaluator
+func (h *headerParser) EvaluateHeaders(ctx context.Context, headers types.HeaderMap, requestInfo types.RequestInfo) {
+	h.evaluateHeaders(ctx, headers, requestInfo)
+}
+
+func (h *headerParser) evaluateHeaders(ctx context.Context, headers types.HeaderMap, requestInfo types.RequestInfo) {
 	if h == nil {
 		return
 	}
 	for _, toAdd := range h.headersToAdd {
-		value := toAdd.headerFormatter.format(requestInfo)
+		value := toAdd.headerFormatter.format(ctx, requestInfo)
 		if v, ok := headers.Get(toAdd.headerName.Get()); ok && len(v) > 0 && toAdd.headerFormatter.append() {
 			value = fmt.Sprintf("%s,%s", v, value)
 		}
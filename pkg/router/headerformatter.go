@@ -18,21 +18,34 @@
 package router
 
 import (
+	"context"
+	"errors"
 	"strings"
 
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/variable"
+)
+
+var (
+	ErrUnclosedHeaderVariable = errors.New("header value format error: unclosed variable definition")
 )
 
 func getHeaderFormatter(value string, append bool) headerFormatter {
-	// TODO: variable headers would be support very soon
-	if strings.Index(value, "%") != -1 {
-		log.DefaultLogger.Warnf("variable headers not support yet, skip, value: %s", value)
+	if strings.Index(value, "%") == -1 {
+		return &plainHeaderFormatter{
+			isAppend:    append,
+			staticValue: value,
+		}
+	}
+	entries, err := parseHeaderValue(value)
+	if err != nil {
+		log.DefaultLogger.Errorf(RouterLogFormat, "header formatter", "parse header value", "skip value: "+value+", error: "+err.Error())
 		return nil
 	}
-	return &plainHeaderFormatter{
-		isAppend:    append,
-		staticValue: value,
+	return &variableHeaderFormatter{
+		isAppend: append,
+		entries:  entries,
 	}
 }
 
@@ -45,6 +58,81 @@ func (f *plainHeaderFormatter) append() bool {
 	return f.isAppend
 }
 
-func (f *plainHeaderFormatter) format(requestInfo types.RequestInfo) string {
+func (f *plainHeaderFormatter) format(ctx context.Context, requestInfo types.RequestInfo) string {
 	return f.staticValue
 }
+
+// headerValueEntry is a part of the header value, either a text or a variable name
+type headerValueEntry struct {
+	text     string
+	variable string
+}
+
+// variableHeaderFormatter makes the header value from variables,
+// the value format is like "prefix-%downstream_remote_address%", and "%%" is an escaped "%".
+type variableHeaderFormatter struct {
+	isAppend bool
+	entries  []headerValueEntry
+}
+
+func (f *variableHeaderFormatter) append() bool {
+	return f.isAppend
+}
+
+func (f *variableHeaderFormatter) format(ctx context.Context, requestInfo types.RequestInfo) string {
+	// variables can be evaluated only in a stream context
+	withVariables := ctx != nil && ctx.Value(types.ContextKeyVariables) != nil
+	var sb strings.Builder
+	for _, entry := range f.entries {
+		if entry.variable == "" {
+			sb.WriteString(entry.text)
+			continue
+		}
+		value := variable.ValueNotFound
+		if withVariables {
+			if v, err := variable.GetVariableValue(ctx, entry.variable); err == nil {
+				value = v
+			} else if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+				log.DefaultLogger.Debugf(RouterLogFormat, "header formatter", "get variable", err)
+			}
+		}
+		sb.WriteString(value)
+	}
+	return sb.String()
+}
+
+// parseHeaderValue splits the value into texts and variables,
+// the variables should be registered in the variable package.
+func parseHeaderValue(value string) ([]headerValueEntry, error) {
+	var entries []headerValueEntry
+	var text strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '%' {
+			text.WriteByte(value[i])
+			continue
+		}
+		end := strings.IndexByte(value[i+1:], '%')
+		if end == -1 {
+			return nil, ErrUnclosedHeaderVariable
+		}
+		name := value[i+1 : i+1+end]
+		i += end + 1
+		// "%%" means a "%"
+		if name == "" {
+			text.WriteByte('%')
+			continue
+		}
+		if _, err := variable.AddVariable(name); err != nil {
+			return nil, err
+		}
+		if text.Len() > 0 {
+			entries = append(entries, headerValueEntry{text: text.String()})
+			text.Reset()
+		}
+		entries = append(entries, headerValueEntry{variable: name})
+	}
+	if text.Len() > 0 {
+		entries = append(entries, headerValueEntry{text: text.String()})
+	}
+	return entries, nil
+}
@@ -18,10 +18,24 @@
 package router
 
 import (
+	"context"
 	"reflect"
 	"testing"
+
+	"mosn.io/mosn/pkg/variable"
 )
 
+func init() {
+	variable.RegisterVariable(variable.NewBasicVariable("router_test_remote", nil,
+		func(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+			return "127.0.0.1:12345", nil
+		}, nil, 0))
+	variable.RegisterPrefixVariable("router_test_header_", variable.NewBasicVariable("router_test_header_", nil,
+		func(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+			return data.(string)[len("router_test_header_"):], nil
+		}, nil, 0))
+}
+
 func Test_getHeaderFormatter(t *testing.T) {
 	type args struct {
 		value  string
@@ -104,9 +118,53 @@ func Test_plainHeaderFormatter_format(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := formatter.format(nil); !reflect.DeepEqual(got, tt.want) {
+			if got := formatter.format(context.Background(), nil); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("(f *plainHeaderFormatter) format(requestInfo types.RequestInfo) = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_variableHeaderFormatter_format(t *testing.T) {
+	ctx := variable.NewVariableContext(context.Background())
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{
+			value: "%router_test_remote%",
+			want:  "127.0.0.1:12345",
+		},
+		{
+			value: "for=%router_test_remote%;by=%router_test_header_mosn%",
+			want:  "for=127.0.0.1:12345;by=mosn",
+		},
+		{
+			value: "100%%-%router_test_header_ok%",
+			want:  "100%-ok",
+		},
+	}
+	for _, tt := range tests {
+		formatter := getHeaderFormatter(tt.value, true)
+		if formatter == nil {
+			t.Fatalf("get formatter for %s failed", tt.value)
+		}
+		if _, ok := formatter.(*variableHeaderFormatter); !ok {
+			t.Fatalf("expected variable header formatter, but got %T", formatter)
+		}
+		if got := formatter.format(ctx, nil); got != tt.want {
+			t.Errorf("format %s, expected %s, but got %s", tt.value, tt.want, got)
+		}
+	}
+	// no variables in context
+	formatter := getHeaderFormatter("for=%router_test_remote%", false)
+	if got := formatter.format(context.Background(), nil); got != "for="+variable.ValueNotFound {
+		t.Errorf("expected variables not found, but got %s", got)
+	}
+	// invalid values
+	for _, value := range []string{"%router_test_remote", "%router_test_undefined%"} {
+		if formatter := getHeaderFormatter(value, false); formatter != nil {
+			t.Errorf("expected invalid value %s, but got a formatter", value)
+		}
+	}
+}
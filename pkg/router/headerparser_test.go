@@ -18,6 +18,7 @@
 package router
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
@@ -59,7 +60,7 @@ func Test_headerParser_evaluateHeaders(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			parser.evaluateHeaders(tt.args.headers, tt.args.requestInfo)
+			parser.evaluateHeaders(context.Background(), tt.args.headers, tt.args.requestInfo)
 			if !reflect.DeepEqual(tt.args.headers, tt.want) {
 				t.Errorf("(h *headerParser) evaluateHeaders(headers map[string]string, requestInfo types.RequestInfo) = %v, want %v", tt.args.headers, tt.want)
 			}
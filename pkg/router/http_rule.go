@@ -18,6 +18,7 @@
 package router
 
 import (
+	"context"
 	"regexp"
 	"strings"
 
@@ -51,16 +52,23 @@ func (prri *PathRouteRuleImpl) MatchType() api.PathMatchType {
 // types.RouteRule
 // override Base
 func (prri *PathRouteRuleImpl) FinalizeRequestHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
-	prri.finalizeRequestHeaders(headers, requestInfo)
+	prri.FinalizeRequestHeadersWithContext(context.Background(), headers, requestInfo)
+}
+
+func (prri *PathRouteRuleImpl) FinalizeRequestHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	prri.finalizeRequestHeaders(ctx, headers, requestInfo)
 	prri.finalizePathHeader(headers, prri.path)
 }
 
 func (prri *PathRouteRuleImpl) Match(headers api.HeaderMap, randomValue uint64) api.Route {
 	if prri.matchRoute(headers, randomValue) {
 		if headerPathValue, ok := headers.Get(protocol.MosnHeaderPathKey); ok {
-			// TODO: config to support case sensitive
-			// case insensitive
-			if strings.EqualFold(headerPathValue, prri.path) {
+			// case insensitive by default
+			if prri.caseSensitive(false) {
+				if headerPathValue == prri.path {
+					return prri
+				}
+			} else if strings.EqualFold(headerPathValue, prri.path) {
 				return prri
 			}
 		}
@@ -95,14 +103,18 @@ func (prei *PrefixRouteRuleImpl) MatchType() api.PathMatchType {
 // types.RouteRule
 // override Base
 func (prei *PrefixRouteRuleImpl) FinalizeRequestHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
-	prei.finalizeRequestHeaders(headers, requestInfo)
+	prei.FinalizeRequestHeadersWithContext(context.Background(), headers, requestInfo)
+}
+
+func (prei *PrefixRouteRuleImpl) FinalizeRequestHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	prei.finalizeRequestHeaders(ctx, headers, requestInfo)
 	prei.finalizePathHeader(headers, prei.prefix)
 }
 
 func (prei *PrefixRouteRuleImpl) Match(headers api.HeaderMap, randomValue uint64) api.Route {
 	if prei.matchRoute(headers, randomValue) {
 		if headerPathValue, ok := headers.Get(protocol.MosnHeaderPathKey); ok {
-			if strings.HasPrefix(headerPathValue, prei.prefix) {
+			if prei.hasPrefix(headerPathValue, prei.prefix) {
 				return prei
 			}
 		}
@@ -135,7 +147,11 @@ func (rrei *RegexRouteRuleImpl) MatchType() api.PathMatchType {
 }
 
 func (rrei *RegexRouteRuleImpl) FinalizeRequestHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
-	rrei.finalizeRequestHeaders(headers, requestInfo)
+	rrei.FinalizeRequestHeadersWithContext(context.Background(), headers, requestInfo)
+}
+
+func (rrei *RegexRouteRuleImpl) FinalizeRequestHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	rrei.finalizeRequestHeaders(ctx, headers, requestInfo)
 	rrei.finalizePathHeader(headers, rrei.regexStr)
 }
 
@@ -154,3 +154,130 @@ func TestRegexRouteRuleImpl(t *testing.T) {
 		}
 	}
 }
+
+func TestCaseSensitiveRouteRule(t *testing.T) {
+	virtualHostImpl := &VirtualHostImpl{virtualHostName: "test"}
+	caseSensitive, caseInsensitive := true, false
+	testCases := []struct {
+		match      v2.RouterMatch
+		headerpath string
+		expected   bool
+	}{
+		{v2.RouterMatch{Prefix: "/foo"}, "/FOO/test", false},
+		{v2.RouterMatch{Prefix: "/foo", CaseSensitive: &caseInsensitive}, "/FOO/test", true},
+		{v2.RouterMatch{Prefix: "/foo", CaseSensitive: &caseInsensitive}, "/FO", false},
+		{v2.RouterMatch{Path: "/test", CaseSensitive: &caseSensitive}, "/Test", false},
+		{v2.RouterMatch{Path: "/test", CaseSensitive: &caseSensitive}, "/test", true},
+	}
+	for i, tc := range testCases {
+		route := &v2.Router{
+			RouterConfig: v2.RouterConfig{
+				Match: tc.match,
+				Route: v2.RouteAction{
+					RouterActionConfig: v2.RouterActionConfig{
+						ClusterName: "test",
+					},
+				},
+			},
+		}
+		base, _ := NewRouteRuleImplBase(virtualHostImpl, route)
+		var rr RouteBase
+		if tc.match.Prefix != "" {
+			rr = &PrefixRouteRuleImpl{base, tc.match.Prefix}
+		} else {
+			rr = &PathRouteRuleImpl{base, tc.match.Path}
+		}
+		headers := protocol.CommonHeader(map[string]string{protocol.MosnHeaderPathKey: tc.headerpath})
+		if result := rr.Match(headers, 1); (result != nil) != tc.expected {
+			t.Errorf("#%d want matched %v, but get matched %v\n", i, tc.expected, result)
+		}
+	}
+}
+
+func TestRouteRuleMatchConditions(t *testing.T) {
+	virtualHostImpl := &VirtualHostImpl{virtualHostName: "test"}
+	present, absent := true, false
+	testCases := []struct {
+		match    v2.RouterMatch
+		headers  map[string]string
+		expected bool
+	}{
+		// methods
+		{v2.RouterMatch{Methods: []string{"GET", "HEAD"}}, map[string]string{protocol.MosnHeaderMethod: "get"}, true},
+		{v2.RouterMatch{Methods: []string{"GET", "HEAD"}}, map[string]string{protocol.MosnHeaderMethod: "POST"}, false},
+		{v2.RouterMatch{Methods: []string{"GET"}}, map[string]string{}, false},
+		// headers
+		{v2.RouterMatch{Headers: []v2.HeaderMatcher{{Name: "x-canary", PresentMatch: &present}}}, map[string]string{"x-canary": "1"}, true},
+		{v2.RouterMatch{Headers: []v2.HeaderMatcher{{Name: "x-canary", PresentMatch: &present}}}, map[string]string{}, false},
+		{v2.RouterMatch{Headers: []v2.HeaderMatcher{{Name: "x-canary", PresentMatch: &absent}}}, map[string]string{}, true},
+		{v2.RouterMatch{Headers: []v2.HeaderMatcher{{Name: "x-uid", RangeMatch: &v2.Int64Range{Start: 100, End: 200}}}}, map[string]string{"x-uid": "100"}, true},
+		{v2.RouterMatch{Headers: []v2.HeaderMatcher{{Name: "x-uid", RangeMatch: &v2.Int64Range{Start: 100, End: 200}}}}, map[string]string{"x-uid": "200"}, false},
+		{v2.RouterMatch{Headers: []v2.HeaderMatcher{{Name: "x-uid", RangeMatch: &v2.Int64Range{Start: 100, End: 200}}}}, map[string]string{"x-uid": "abc"}, false},
+		{v2.RouterMatch{Headers: []v2.HeaderMatcher{{Name: "x-env", Value: "prod", InvertMatch: true}}}, map[string]string{"x-env": "test"}, true},
+		{v2.RouterMatch{Headers: []v2.HeaderMatcher{{Name: "x-env", Value: "prod", InvertMatch: true}}}, map[string]string{"x-env": "prod"}, false},
+		{v2.RouterMatch{Headers: []v2.HeaderMatcher{{Name: "x-env", Value: "^pr", Regex: true, InvertMatch: true}}}, map[string]string{}, true},
+		// query parameters
+		{v2.RouterMatch{QueryParameters: []v2.HeaderMatcher{{Name: "version", Value: "v2"}}}, map[string]string{protocol.MosnHeaderQueryStringKey: "a=b&version=v2"}, true},
+		{v2.RouterMatch{QueryParameters: []v2.HeaderMatcher{{Name: "version", Value: "v2"}}}, map[string]string{protocol.MosnHeaderQueryStringKey: "version=v1"}, false},
+		{v2.RouterMatch{QueryParameters: []v2.HeaderMatcher{{Name: "version", Value: "v2"}}}, map[string]string{}, false},
+		{v2.RouterMatch{QueryParameters: []v2.HeaderMatcher{{Name: "debug"}}}, map[string]string{protocol.MosnHeaderQueryStringKey: "debug=1"}, true},
+		{v2.RouterMatch{QueryParameters: []v2.HeaderMatcher{{Name: "version", Value: "^v[2-3]$", Regex: true}}}, map[string]string{protocol.MosnHeaderQueryStringKey: "version=v3"}, true},
+		// cookies
+		{v2.RouterMatch{Cookies: []v2.HeaderMatcher{{Name: "group", Value: "beta"}}}, map[string]string{"cookie": "uid=1; group=beta"}, true},
+		{v2.RouterMatch{Cookies: []v2.HeaderMatcher{{Name: "group", Value: "beta"}}}, map[string]string{"cookie": "uid=1; group=alpha"}, false},
+		{v2.RouterMatch{Cookies: []v2.HeaderMatcher{{Name: "group", PresentMatch: &absent}}}, map[string]string{}, true},
+		// runtime fraction
+		{v2.RouterMatch{RuntimeFraction: &v2.RuntimeFractionalPercent{Numerator: 100}}, map[string]string{}, true},
+		{v2.RouterMatch{RuntimeFraction: &v2.RuntimeFractionalPercent{Numerator: 0, Denominator: v2.MILLION}}, map[string]string{}, false},
+	}
+	for i, tc := range testCases {
+		tc.match.Prefix = "/"
+		route := &v2.Router{
+			RouterConfig: v2.RouterConfig{
+				Match: tc.match,
+				Route: v2.RouteAction{
+					RouterActionConfig: v2.RouterActionConfig{
+						ClusterName: "test",
+					},
+				},
+			},
+		}
+		base, _ := NewRouteRuleImplBase(virtualHostImpl, route)
+		rr := &PrefixRouteRuleImpl{base, "/"}
+		tc.headers[protocol.MosnHeaderPathKey] = "/"
+		if result := rr.Match(protocol.CommonHeader(tc.headers), 1); (result != nil) != tc.expected {
+			t.Errorf("#%d want matched %v, but get matched %v\n", i, tc.expected, result)
+		}
+	}
+}
+
+func TestRuntimeFractionMatch(t *testing.T) {
+	route := &v2.Router{
+		RouterConfig: v2.RouterConfig{
+			Match: v2.RouterMatch{
+				Prefix: "/",
+				RuntimeFraction: &v2.RuntimeFractionalPercent{
+					Numerator:   2000,
+					Denominator: v2.TEN_THOUSAND,
+				},
+			},
+		},
+	}
+	base, _ := NewRouteRuleImplBase(&VirtualHostImpl{virtualHostName: "test"}, route)
+	rr := &PrefixRouteRuleImpl{base, "/"}
+	headers := protocol.CommonHeader(map[string]string{protocol.MosnHeaderPathKey: "/"})
+	matched := 0
+	for i := 0; i < 10000; i++ {
+		if rr.Match(headers, routeRandom.Uint64()) != nil {
+			matched++
+		}
+	}
+	// the result is decided by the random value
+	if rr.Match(headers, 1999) == nil || rr.Match(headers, 2000) != nil {
+		t.Error("runtime fraction expected to match the random value")
+	}
+	// 20% expected
+	if matched < 1500 || matched > 2500 {
+		t.Errorf("runtime fraction matched %d times in 10000 requests", matched)
+	}
+}
@@ -0,0 +1,212 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"regexp/syntax"
+	"sort"
+	"strings"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+// routeIndex is a prefilter of the routes in a virtual host.
+// each route is indexed by one of the conditions that must be satisfied if the route is matched,
+// so the candidates found by the index are a superset of the matched routes.
+// the candidates are returned in the config order, so the first-match-wins semantics is kept.
+type routeIndex struct {
+	// exactPaths indexes the path routes, the key is lower case
+	exactPaths map[string][]int
+	// prefixes indexes the prefix routes and the regex routes with a literal prefix, the key is lower case
+	prefixes *radixNode
+	// headers indexes the routes with an exact header matcher, header name -> header value -> routes
+	headers map[string]map[string][]int
+	// always contains the routes that can not be indexed
+	always []int
+}
+
+func newRouteIndex() *routeIndex {
+	return &routeIndex{
+		exactPaths: make(map[string][]int),
+		prefixes:   &radixNode{},
+		headers:    make(map[string]map[string][]int),
+	}
+}
+
+// add indexes the route at the position
+func (idx *routeIndex) add(pos int, router RouteBase, route *v2.Router) {
+	if sofa, ok := router.(*SofaRouteRuleImpl); ok {
+		if sofa.matchValue == ".*" {
+			idx.always = append(idx.always, pos)
+		} else {
+			idx.addHeader(types.SofaRouteMatchKey, sofa.matchValue, pos)
+		}
+		return
+	}
+	switch r := router.(type) {
+	case *PathRouteRuleImpl:
+		key := strings.ToLower(r.path)
+		idx.exactPaths[key] = append(idx.exactPaths[key], pos)
+		return
+	case *PrefixRouteRuleImpl, *RegexRouteRuleImpl:
+		// an exact header is more selective than a path prefix usually
+		for _, header := range route.Match.Headers {
+			if isExactHeaderMatcher(header) {
+				idx.addHeader(header.Name, header.Value, pos)
+				return
+			}
+		}
+		if prefix, ok := r.(*PrefixRouteRuleImpl); ok {
+			idx.prefixes.insert(strings.ToLower(prefix.prefix), pos)
+			return
+		}
+		if prefix, ok := regexLiteralPrefix(r.(*RegexRouteRuleImpl).regexStr); ok {
+			idx.prefixes.insert(strings.ToLower(prefix), pos)
+			return
+		}
+	}
+	idx.always = append(idx.always, pos)
+}
+
+func (idx *routeIndex) addHeader(name, value string, pos int) {
+	values, ok := idx.headers[name]
+	if !ok {
+		values = make(map[string][]int)
+		idx.headers[name] = values
+	}
+	values[value] = append(values[value], pos)
+}
+
+// candidates returns the positions of the routes that may be matched, in ascending order
+func (idx *routeIndex) candidates(headers api.HeaderMap) []int {
+	result := make([]int, 0, len(idx.always)+4)
+	result = append(result, idx.always...)
+	if path, ok := headers.Get(protocol.MosnHeaderPathKey); ok {
+		path = strings.ToLower(path)
+		result = append(result, idx.exactPaths[path]...)
+		idx.prefixes.walk(path, func(values []int) {
+			result = append(result, values...)
+		})
+	}
+	for name, values := range idx.headers {
+		if value, ok := headers.Get(name); ok {
+			result = append(result, values[value]...)
+		}
+	}
+	sort.Ints(result)
+	return result
+}
+
+// regexLiteralPrefix returns the literal prefix that a string must start with if it matches the regex
+func regexLiteralPrefix(expr string) (string, bool) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	re = re.Simplify()
+	if re.Op != syntax.OpConcat || len(re.Sub) < 2 || re.Sub[0].Op != syntax.OpBeginText {
+		return "", false
+	}
+	var prefix []rune
+	for _, sub := range re.Sub[1:] {
+		if sub.Op != syntax.OpLiteral || sub.Flags&syntax.FoldCase != 0 {
+			break
+		}
+		prefix = append(prefix, sub.Rune...)
+	}
+	if len(prefix) == 0 {
+		return "", false
+	}
+	return string(prefix), true
+}
+
+// radixNode is a node of a radix tree, the values of a node are the routes whose key is
+// the concatenation of the labels from the root to the node
+type radixNode struct {
+	label    string
+	children []*radixNode
+	values   []int
+}
+
+func (n *radixNode) insert(key string, value int) {
+	for {
+		if key == "" {
+			n.values = append(n.values, value)
+			return
+		}
+		var child *radixNode
+		for _, c := range n.children {
+			if c.label[0] == key[0] {
+				child = c
+				break
+			}
+		}
+		if child == nil {
+			n.children = append(n.children, &radixNode{
+				label:  key,
+				values: []int{value},
+			})
+			return
+		}
+		common := commonPrefixLength(child.label, key)
+		if common < len(child.label) {
+			// split the child
+			split := &radixNode{
+				label:    child.label[common:],
+				children: child.children,
+				values:   child.values,
+			}
+			child.label = child.label[:common]
+			child.children = []*radixNode{split}
+			child.values = nil
+		}
+		n = child
+		key = key[common:]
+	}
+}
+
+// walk calls f with the values of all nodes whose key is a prefix of the path
+func (n *radixNode) walk(path string, f func(values []int)) {
+	for n != nil {
+		if len(n.values) > 0 {
+			f(n.values)
+		}
+		var next *radixNode
+		for _, c := range n.children {
+			if strings.HasPrefix(path, c.label) {
+				next = c
+				break
+			}
+		}
+		if next != nil {
+			path = path[len(next.label):]
+		}
+		n = next
+	}
+}
+
+func commonPrefixLength(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,255 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestRadixTree(t *testing.T) {
+	root := &radixNode{}
+	keys := []string{"/", "/api", "/api/v1", "/apix", "/app", "/api/v2/users", "/api"}
+	for i, key := range keys {
+		root.insert(key, i)
+	}
+	testCases := []struct {
+		path     string
+		expected []int
+	}{
+		{"/", []int{0}},
+		{"/api/v1/users", []int{0, 1, 6, 2}},
+		{"/apixyz", []int{0, 1, 6, 3}},
+		{"/app/foo", []int{0, 4}},
+		{"/api/v2/users/1", []int{0, 1, 6, 5}},
+		{"/ap", []int{0}},
+		{"foo", nil},
+	}
+	for _, tc := range testCases {
+		var got []int
+		root.walk(tc.path, func(values []int) {
+			got = append(got, values...)
+		})
+		if fmt.Sprint(got) != fmt.Sprint(tc.expected) {
+			t.Errorf("walk %s expected %v, but got %v", tc.path, tc.expected, got)
+		}
+	}
+}
+
+func TestRegexLiteralPrefix(t *testing.T) {
+	testCases := []struct {
+		expr   string
+		prefix string
+		ok     bool
+	}{
+		{"^/api/v[0-9]+", "/api/v", true},
+		{"^/users/.*", "/users/", true},
+		{"/api/.*", "", false},
+		{"^/a|^/b", "", false},
+		{"(?i)^/api", "", false},
+		{"^.*", "", false},
+	}
+	for _, tc := range testCases {
+		prefix, ok := regexLiteralPrefix(tc.expr)
+		if prefix != tc.prefix || ok != tc.ok {
+			t.Errorf("regex %s expected prefix %s(%v), but got %s(%v)", tc.expr, tc.prefix, tc.ok, prefix, ok)
+		}
+	}
+}
+
+func newIndexTestRouter(match v2.RouterMatch, cluster string) v2.Router {
+	return v2.Router{
+		RouterConfig: v2.RouterConfig{
+			Match: match,
+			Route: v2.RouteAction{
+				RouterActionConfig: v2.RouterActionConfig{
+					ClusterName: cluster,
+				},
+			},
+		},
+	}
+}
+
+// linearMatch is the route matching without index
+func linearMatch(vh *VirtualHostImpl, headers api.HeaderMap) api.Route {
+	for _, route := range vh.routes {
+		if r := route.Match(headers, 1); r != nil {
+			return r
+		}
+	}
+	return nil
+}
+
+func TestRouteIndexFirstMatch(t *testing.T) {
+	caseSensitive := false
+	routers := []v2.Router{
+		newIndexTestRouter(v2.RouterMatch{Path: "/exact"}, "exact"),
+		newIndexTestRouter(v2.RouterMatch{Prefix: "/api", Headers: []v2.HeaderMatcher{{Name: "x-canary", Value: "true"}}}, "canary"),
+		newIndexTestRouter(v2.RouterMatch{Regex: "^/api/v[0-9]+/users"}, "regex-users"),
+		newIndexTestRouter(v2.RouterMatch{Prefix: "/api/v1"}, "v1"),
+		newIndexTestRouter(v2.RouterMatch{Prefix: "/API/V2", CaseSensitive: &caseSensitive}, "v2"),
+		newIndexTestRouter(v2.RouterMatch{Regex: ".*/health$"}, "health"),
+		newIndexTestRouter(v2.RouterMatch{Headers: []v2.HeaderMatcher{{Name: types.SofaRouteMatchKey, Value: "com.foo.Service"}}}, "sofa"),
+		newIndexTestRouter(v2.RouterMatch{Prefix: "/"}, "default"),
+		newIndexTestRouter(v2.RouterMatch{Headers: []v2.HeaderMatcher{{Name: types.SofaRouteMatchKey, Value: ".*"}}}, "sofa-default"),
+	}
+	vh, err := NewVirtualHostImpl(&v2.VirtualHost{
+		Name:    "index",
+		Domains: []string{"*"},
+		Routers: routers,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		headers  map[string]string
+		expected string
+	}{
+		{map[string]string{protocol.MosnHeaderPathKey: "/EXACT"}, "exact"},
+		{map[string]string{protocol.MosnHeaderPathKey: "/api/v1/users", "x-canary": "true"}, "canary"},
+		{map[string]string{protocol.MosnHeaderPathKey: "/api/v1/users"}, "regex-users"},
+		{map[string]string{protocol.MosnHeaderPathKey: "/api/v1/orders"}, "v1"},
+		{map[string]string{protocol.MosnHeaderPathKey: "/api/v2/orders"}, "v2"},
+		{map[string]string{protocol.MosnHeaderPathKey: "/foo/health"}, "health"},
+		{map[string]string{protocol.MosnHeaderPathKey: "/foo"}, "default"},
+		{map[string]string{types.SofaRouteMatchKey: "com.foo.Service"}, "sofa"},
+		{map[string]string{types.SofaRouteMatchKey: "com.bar.Service"}, "sofa-default"},
+		{map[string]string{"x-other": "1"}, ""},
+	}
+	for i, tc := range testCases {
+		headers := protocol.CommonHeader(tc.headers)
+		route := vh.GetRouteFromEntries(headers, 1)
+		var got string
+		if route != nil {
+			got = route.RouteRule().ClusterName()
+		}
+		if got != tc.expected {
+			t.Errorf("#%d expected route %s, but got %s", i, tc.expected, got)
+		}
+		if linear := linearMatch(vh, headers); linear != route {
+			t.Errorf("#%d indexed route is different from linear matched route", i)
+		}
+	}
+	// all routes
+	all := vh.GetAllRoutesFromEntries(protocol.CommonHeader{protocol.MosnHeaderPathKey: "/api/v1/users"}, 1)
+	var clusters []string
+	for _, r := range all {
+		clusters = append(clusters, r.RouteRule().ClusterName())
+	}
+	if fmt.Sprint(clusters) != "[regex-users v1 default]" {
+		t.Errorf("unexpected all routes: %v", clusters)
+	}
+	// remove all routes
+	vh.RemoveAllRoutes()
+	if r := vh.GetRouteFromEntries(protocol.CommonHeader{protocol.MosnHeaderPathKey: "/foo"}, 1); r != nil {
+		t.Errorf("expected no route after removed")
+	}
+	vh.AddRoute(&routers[7])
+	if r := vh.GetRouteFromEntries(protocol.CommonHeader{protocol.MosnHeaderPathKey: "/foo"}, 1); r == nil || r.RouteRule().ClusterName() != "default" {
+		t.Errorf("expected default route after added")
+	}
+}
+
+func TestRouteIndexCandidatesSorted(t *testing.T) {
+	vh, err := NewVirtualHostImpl(&v2.VirtualHost{
+		Name:    "sorted",
+		Domains: []string{"*"},
+		Routers: []v2.Router{
+			newIndexTestRouter(v2.RouterMatch{Prefix: "/a/b"}, "0"),
+			newIndexTestRouter(v2.RouterMatch{Regex: "b$"}, "1"),
+			newIndexTestRouter(v2.RouterMatch{Prefix: "/a"}, "2"),
+			newIndexTestRouter(v2.RouterMatch{Path: "/a/b"}, "3"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	candidates := vh.index.candidates(protocol.CommonHeader{protocol.MosnHeaderPathKey: "/a/b"})
+	if !sort.IntsAreSorted(candidates) || len(candidates) != 4 {
+		t.Errorf("unexpected candidates: %v", candidates)
+	}
+}
+
+// makeLargeVirtualHost makes a virtual host with n path prefix routes and n sofa service routes
+func makeLargeVirtualHost(b *testing.B, n int) *VirtualHostImpl {
+	routers := make([]v2.Router, 0, 2*n+1)
+	for i := 0; i < n; i++ {
+		routers = append(routers, newIndexTestRouter(v2.RouterMatch{Prefix: fmt.Sprintf("/service%d/", i)}, fmt.Sprintf("path%d", i)))
+		routers = append(routers, newIndexTestRouter(v2.RouterMatch{
+			Headers: []v2.HeaderMatcher{{Name: types.SofaRouteMatchKey, Value: fmt.Sprintf("com.foo.Service%d", i)}},
+		}, fmt.Sprintf("sofa%d", i)))
+	}
+	routers = append(routers, newIndexTestRouter(v2.RouterMatch{Regex: "^/static/.*"}, "static"))
+	vh, err := NewVirtualHostImpl(&v2.VirtualHost{
+		Name:    "large",
+		Domains: []string{"*"},
+		Routers: routers,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return vh
+}
+
+func benchmarkRouteMatch(b *testing.B, n int, indexed bool) {
+	level := log.DefaultLogger.GetLogLevel()
+	log.DefaultLogger.SetLogLevel(log.FATAL)
+	defer log.DefaultLogger.SetLogLevel(level)
+	vh := makeLargeVirtualHost(b, n)
+	pathHeaders := protocol.CommonHeader{protocol.MosnHeaderPathKey: fmt.Sprintf("/service%d/foo", n-1)}
+	sofaHeaders := protocol.CommonHeader{types.SofaRouteMatchKey: fmt.Sprintf("com.foo.Service%d", n-1)}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		headers := pathHeaders
+		if i%2 == 1 {
+			headers = sofaHeaders
+		}
+		var r api.Route
+		if indexed {
+			r = vh.GetRouteFromEntries(headers, 1)
+		} else {
+			r = linearMatch(vh, headers)
+		}
+		if r == nil {
+			b.Fatal("no route matched")
+		}
+	}
+}
+
+func BenchmarkRouteMatchLinear100(b *testing.B) {
+	benchmarkRouteMatch(b, 100, false)
+}
+
+func BenchmarkRouteMatchIndexed100(b *testing.B) {
+	benchmarkRouteMatch(b, 100, true)
+}
+
+func BenchmarkRouteMatchLinear10000(b *testing.B) {
+	benchmarkRouteMatch(b, 10000, false)
+}
+
+func BenchmarkRouteMatchIndexed10000(b *testing.B) {
+	benchmarkRouteMatch(b, 10000, true)
+}
@@ -18,6 +18,8 @@
 package router
 
 import (
+	"context"
+
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
@@ -62,6 +64,9 @@ func (srri *SofaRouteRuleImpl) MatchType() api.PathMatchType {
 func (srri *SofaRouteRuleImpl) FinalizeRequestHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
 }
 
+func (srri *SofaRouteRuleImpl) FinalizeRequestHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+}
+
 func (srri *SofaRouteRuleImpl) Match(headers api.HeaderMap, randomValue uint64) api.Route {
 	if value, ok := headers.Get(types.SofaRouteMatchKey); ok {
 		if value == srri.matchValue || srri.matchValue == ".*" {
@@ -31,6 +31,8 @@ import (
 // [sub module] & [function] & msg
 const RouterLogFormat = "[router] [%s] [%s] %v"
 
+const cookieHeaderKey = "cookie"
+
 var (
 	ErrNilRouterConfig      = errors.New("router config is nil")
 	ErrNoVirtualHost        = errors.New("virtual host is nil")
@@ -41,7 +43,7 @@ var (
 )
 
 type headerFormatter interface {
-	format(requestInfo api.RequestInfo) string
+	format(ctx context.Context, requestInfo api.RequestInfo) string
 	append() bool
 }
 
@@ -142,6 +144,10 @@ type RouterRuleFactory func(base *RouteRuleImplBase, header []v2.HeaderMatcher)
 // MakeHandlerChain creates a RouteHandlerChain, should not returns a nil handler chain, or the stream filters will be ignored
 type MakeHandlerChain func(context.Context, api.HeaderMap, types.Routers, types.ClusterManager) *RouteHandlerChain
 
+// PerFilterConfigParser parses the per filter config of a route when the route is built,
+// the parsed config replaces the raw config in the PerFilterConfig of the route rule
+type PerFilterConfigParser func(cfg interface{}) (interface{}, error)
+
 // The reigister order, is a wrapper of registered factory
 // We register a factory with order, a new factory can replace old registered factory only if the register order
 // ig greater than the old one.
@@ -19,6 +19,7 @@ package router
 
 import (
 	"regexp"
+	"strings"
 
 	"mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
@@ -52,8 +53,11 @@ func getRouterHeaders(headers []v2.HeaderMatcher) []*types.HeaderData {
 			Name: &lowerCaseString{
 				header.Name,
 			},
-			Value:   header.Value,
-			IsRegex: header.Regex,
+			Value:        header.Value,
+			IsRegex:      header.Regex,
+			RangeMatch:   header.RangeMatch,
+			PresentMatch: header.PresentMatch,
+			InvertMatch:  header.InvertMatch,
 		}
 
 		if header.Regex {
@@ -72,6 +76,38 @@ func getRouterHeaders(headers []v2.HeaderMatcher) []*types.HeaderData {
 	return headerDatas
 }
 
+func getQueryParameters(params []v2.HeaderMatcher) []types.QueryParameterMatcher {
+	var matchers []types.QueryParameterMatcher
+	for _, data := range getRouterHeaders(params) {
+		// an empty value matches the query parameter's presence
+		if data.Value == "" && !data.IsRegex && data.RangeMatch == nil && data.PresentMatch == nil {
+			present := true
+			data.PresentMatch = &present
+		}
+		matchers = append(matchers, &queryParameterMatcher{
+			HeaderData: *data,
+		})
+	}
+	return matchers
+}
+
+// parseCookies parses the cookie header value, like "k1=v1; k2=v2"
+func parseCookies(cookie string) map[string]string {
+	cookies := make(map[string]string)
+	for _, pair := range strings.Split(cookie, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		if idx := strings.IndexByte(pair, '='); idx > 0 {
+			cookies[pair[:idx]] = strings.Trim(pair[idx+1:], "\"")
+		} else {
+			cookies[pair] = ""
+		}
+	}
+	return cookies
+}
+
 func getHeaderParser(headersToAdd []*v2.HeaderValueOption, headersToRemove []string) *headerParser {
 	if headersToAdd == nil && headersToRemove == nil {
 		return nil
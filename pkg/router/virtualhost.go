@@ -31,9 +31,11 @@ type VirtualHostImpl struct {
 	mutex                 sync.RWMutex
 	routes                []RouteBase
 	fastIndex             map[string]map[string]api.Route
+	index                 *routeIndex
 	globalRouteConfig     *configImpl
 	requestHeadersParser  *headerParser
 	responseHeadersParser *headerParser
+	corsPolicy            *corsPolicyImpl
 }
 
 func (vh *VirtualHostImpl) Name() string {
@@ -76,10 +78,14 @@ func (vh *VirtualHostImpl) addRouteBase(route *v2.Router) error {
 	}
 	if router != nil {
 		vh.mutex.Lock()
+		if vh.index == nil {
+			vh.index = newRouteIndex()
+		}
+		vh.index.add(len(vh.routes), router, route)
 		vh.routes = append(vh.routes, router)
 		// make fast index, used in certain scenarios
 		// TODO: rule can be extended
-		if len(route.Match.Headers) == 1 && !route.Match.Headers[0].Regex {
+		if len(route.Match.Headers) == 1 && isExactHeaderMatcher(route.Match.Headers[0]) {
 			key := route.Match.Headers[0].Name
 			value := route.Match.Headers[0].Value
 			valueMap, ok := vh.fastIndex[key]
@@ -98,11 +104,15 @@ func (vh *VirtualHostImpl) addRouteBase(route *v2.Router) error {
 
 }
 
+// GetRouteFromEntries returns the first matched route in the candidates found by the route index
 func (vh *VirtualHostImpl) GetRouteFromEntries(headers api.HeaderMap, randomValue uint64) api.Route {
 	vh.mutex.RLock()
 	defer vh.mutex.RUnlock()
-	for _, route := range vh.routes {
-		if routeEntry := route.Match(headers, randomValue); routeEntry != nil {
+	if vh.index == nil {
+		return nil
+	}
+	for _, pos := range vh.index.candidates(headers) {
+		if routeEntry := vh.routes[pos].Match(headers, randomValue); routeEntry != nil {
 			return routeEntry
 		}
 	}
@@ -112,9 +122,12 @@ func (vh *VirtualHostImpl) GetRouteFromEntries(headers api.HeaderMap, randomValu
 func (vh *VirtualHostImpl) GetAllRoutesFromEntries(headers api.HeaderMap, randomValue uint64) []api.Route {
 	vh.mutex.RLock()
 	defer vh.mutex.RUnlock()
+	if vh.index == nil {
+		return nil
+	}
 	var routes []api.Route
-	for _, route := range vh.routes {
-		if r := route.Match(headers, randomValue); r != nil {
+	for _, pos := range vh.index.candidates(headers) {
+		if r := vh.routes[pos].Match(headers, randomValue); r != nil {
 			routes = append(routes, r)
 		}
 	}
@@ -143,6 +156,7 @@ func (vh *VirtualHostImpl) RemoveAllRoutes() {
 	vh.fastIndex = make(map[string]map[string]api.Route)
 	// clear the routes
 	vh.routes = vh.routes[:0]
+	vh.index = newRouteIndex()
 	return
 }
 
@@ -153,6 +167,11 @@ func NewVirtualHostImpl(virtualHost *v2.VirtualHost) (*VirtualHostImpl, error) {
 		requestHeadersParser:  getHeaderParser(virtualHost.RequestHeadersToAdd, nil),
 		responseHeadersParser: getHeaderParser(virtualHost.ResponseHeadersToAdd, virtualHost.ResponseHeadersToRemove),
 	}
+	corsPolicy, err := newCorsPolicy(virtualHost.Cors)
+	if err != nil {
+		return nil, err
+	}
+	vhImpl.corsPolicy = corsPolicy
 	for _, route := range virtualHost.Routers {
 		if err := vhImpl.addRouteBase(&route); err != nil {
 			return nil, err
@@ -160,3 +179,8 @@ func NewVirtualHostImpl(virtualHost *v2.VirtualHost) (*VirtualHostImpl, error) {
 	}
 	return vhImpl, nil
 }
+
+// isExactHeaderMatcher returns true if the header matcher only matches an exact value
+func isExactHeaderMatcher(header v2.HeaderMatcher) bool {
+	return !header.Regex && header.RangeMatch == nil && header.PresentMatch == nil && !header.InvertMatch
+}
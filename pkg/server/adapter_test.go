@@ -12,6 +12,7 @@ import (
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/network"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/pkg/buffer"
 )
@@ -412,3 +413,19 @@ func TestFindListenerByName(t *testing.T) {
 		t.Fatal("expected find listener, but not")
 	}
 }
+
+func TestRemoveListenerCloseAccessLogs(t *testing.T) {
+	ch := NewHandler(&mockClusterManagerFilter{}, &mockClusterManager{}).(*connHandler)
+	accessLog := &mockAccessLog{}
+	ch.listeners = append(ch.listeners, &activeListener{
+		listener:   network.NewListener(&v2.Listener{ListenerConfig: v2.ListenerConfig{Name: "removed"}}),
+		accessLogs: []api.AccessLog{accessLog},
+	})
+	ch.RemoveListeners("removed")
+	if len(ch.listeners) != 0 {
+		t.Fatal("expected the listener is removed")
+	}
+	if !accessLog.closed {
+		t.Error("expected the access log of the removed listener is closed")
+	}
+}
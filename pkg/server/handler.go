@@ -22,6 +22,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"strconv"
@@ -42,6 +43,7 @@ import (
 	"mosn.io/mosn/pkg/metrics"
 	"mosn.io/mosn/pkg/mtls"
 	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/overload"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/pkg/utils"
 )
@@ -185,7 +187,7 @@ func (ch *connHandler) AddOrUpdateListener(lc *v2.Listener, updateNetworkFilter
 				alConfig.Path = types.MosnLogBasePath + string(os.PathSeparator) + lc.Name + "_access.log"
 			}
 
-			if al, err := log.NewAccessLog(alConfig.Path, alConfig.Format); err == nil {
+			if al, err := log.NewAccessLogWithConfig(alConfig); err == nil {
 				als = append(als, al)
 			} else {
 				return nil, fmt.Errorf("initialize listener access logger %s failed: %v", alConfig.Path, err.Error())
@@ -248,6 +250,12 @@ func (ch *connHandler) RemoveListeners(name string) {
 		if l.listener.Name() == name {
 			log.DefaultLogger.Infof("[server] [conn handler] remove listener name: %s", name)
 			ch.listeners = append(ch.listeners[:i], ch.listeners[i+1:]...)
+			// the access logs of the removed listener release their sinks
+			for _, al := range l.accessLogs {
+				if closer, ok := al.(io.Closer); ok {
+					closer.Close()
+				}
+			}
 		}
 	}
 }
@@ -401,6 +409,16 @@ func (al *activeListener) GoStart(lctx context.Context) {
 func (al *activeListener) OnAccept(rawc net.Conn, useOriginalDst bool, oriRemoteAddr net.Addr, ch chan api.Connection, buf []byte) {
 	var rawf *os.File
 
+	// the connections transferred from the old mosn are not rejected
+	if ch == nil && overload.IsActive(overload.StopAcceptingConnections) {
+		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+			log.DefaultLogger.Debugf("[server] [listener] overloaded, close the connection from %s", rawc.RemoteAddr())
+		}
+		overload.RecordRejected(overload.StopAcceptingConnections)
+		rawc.Close()
+		return
+	}
+
 	// only store fd and tls conn handshake in final working listener
 	if !useOriginalDst {
 		if network.UseNetpollMode {
@@ -515,7 +533,8 @@ func (al *activeListener) newConnection(ctx context.Context, rawc net.Conn) {
 	}
 	newCtx := mosnctx.WithValue(ctx, types.ContextKeyConnectionID, conn.ID())
 
-	conn.SetBufferLimit(al.listener.PerConnBufferLimitBytes())
+	// the buffer limit is shrunk if the process is overloaded
+	conn.SetBufferLimit(overload.BufferLimit(al.listener.PerConnBufferLimitBytes()))
 
 	al.OnNewConnection(newCtx, conn)
 }
@@ -142,3 +142,13 @@ Zk7xEQKBgDh4B7wTVi6eKRDYJpgWiDgT5uV0gu60mJ0CHYmjUtX9RGBsGS85zJmx
 2JMjNOmrivQ3dvL/rNKIx0ULx+iTQr3Y6B8A2xCme3yr665arvE0HOFG0hLVE1a0
 wD08P7/0q7yk5M4dDUwerGaTIRKK8RRFy1Ak9kU6EtHsbUKNo9s5
 -----END RSA PRIVATE KEY-----`
+
+type mockAccessLog struct {
+	api.AccessLog
+	closed bool
+}
+
+func (l *mockAccessLog) Close() error {
+	l.closed = true
+	return nil
+}
@@ -26,12 +26,17 @@ import (
 	"mosn.io/mosn/pkg/configmanager"
 	mlog "mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/overload"
 	"mosn.io/mosn/pkg/server/keeper"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/pkg/buffer"
 	"mosn.io/pkg/log"
 )
 
+func init() {
+	overload.RegisterResourceReader(overload.ResourceDownstreamConnections, downstreamConnections)
+}
+
 // currently, only one server supported
 func GetServer() Server {
 	if len(servers) == 0 {
@@ -143,6 +148,17 @@ func StopConnection() {
 	}
 }
 
+// downstreamConnections returns the downstream connections of all the servers
+func downstreamConnections() uint64 {
+	var n uint64
+	for _, server := range servers {
+		if ch, ok := server.handler.(*connHandler); ok {
+			n += ch.NumConnections()
+		}
+	}
+	return n
+}
+
 func ListListenersFile() []*os.File {
 	var files []*os.File
 	for _, server := range servers {
@@ -101,6 +101,14 @@ func (c *client) ActiveRequestsNum() int {
 	return c.ClientStreamConnection.ActiveStreamsNum()
 }
 
+// MaxConcurrentStreams returns the max concurrent streams of the stream connection, 0 means no limit
+func (c *client) MaxConcurrentStreams() uint32 {
+	if limit, ok := c.ClientStreamConnection.(types.StreamLimitConnection); ok {
+		return limit.MaxConcurrentStreams()
+	}
+	return 0
+}
+
 func (c *client) SetConnectionCollector(read, write metrics.Counter) {
 	c.Connection.SetCollector(read, write)
 }
@@ -120,6 +128,17 @@ func (c *client) NewStream(context context.Context, respReceiver types.StreamRec
 		streamReceiver: respReceiver,
 	}
 
+	// keep the optional interface for the streaming codecs
+	if dataReceiver, ok := respReceiver.(types.StreamDataReceiveListener); ok {
+		dataWrapper := &clientStreamDataReceiverWrapper{
+			clientStreamReceiverWrapper: wrapper,
+			dataReceiver:                dataReceiver,
+		}
+		streamSender := c.ClientStreamConnection.NewStream(context, dataWrapper)
+		wrapper.stream = streamSender.GetStream()
+		return streamSender
+	}
+
 	streamSender := c.ClientStreamConnection.NewStream(context, wrapper)
 	wrapper.stream = streamSender.GetStream()
 
@@ -177,3 +196,23 @@ func (w *clientStreamReceiverWrapper) OnReceive(ctx context.Context, headers typ
 func (w *clientStreamReceiverWrapper) OnDecodeError(ctx context.Context, err error, headers types.HeaderMap) {
 	w.streamReceiver.OnDecodeError(ctx, err, headers)
 }
+
+// clientStreamDataReceiverWrapper destroys the stream at client side after the last data frame received
+type clientStreamDataReceiverWrapper struct {
+	*clientStreamReceiverWrapper
+	dataReceiver types.StreamDataReceiveListener
+}
+
+func (w *clientStreamDataReceiverWrapper) OnReceiveHeaders(ctx context.Context, headers types.HeaderMap, endStream bool) {
+	if endStream {
+		w.stream.DestroyStream()
+	}
+	w.dataReceiver.OnReceiveHeaders(ctx, headers, endStream)
+}
+
+func (w *clientStreamDataReceiverWrapper) OnReceiveData(ctx context.Context, data types.IoBuffer, endStream bool) {
+	if endStream {
+		w.stream.DestroyStream()
+	}
+	w.dataReceiver.OnReceiveData(ctx, data, endStream)
+}
@@ -22,6 +22,7 @@ import (
 	"sync"
 	"time"
 
+	metrics "github.com/rcrowley/go-metrics"
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/network"
@@ -46,6 +47,13 @@ type connPool struct {
 
 	statReport bool
 
+	// the clients are rotated after maxRequests or maxAge, and the clients not in use are closed after idleTimeout,
+	// or if there are more than maxIdle idle clients
+	maxRequests uint32
+	maxAge      time.Duration
+	idleTimeout time.Duration
+	maxIdle     uint32
+
 	clientMux        sync.Mutex
 	availableClients []*activeClient // available clients
 	totalClientCount uint64          // total clients
@@ -55,6 +63,20 @@ func NewConnPool(host types.Host) types.ConnectionPool {
 	pool := &connPool{
 		host: host,
 	}
+	if info := host.ClusterInfo(); info != nil {
+		cfg := info.ConnPoolConfig()
+		// the connections are not rotated by the requests if it is unlimited, 0 means no limit
+		if !cfg.UnlimitedRequestsPerConnection {
+			pool.maxRequests = info.MaxRequestsPerConn()
+		}
+		if cfg.MaxConnectionAge != nil {
+			pool.maxAge = cfg.MaxConnectionAge.Duration
+		}
+		if cfg.IdleTimeout != nil {
+			pool.idleTimeout = cfg.IdleTimeout.Duration
+		}
+		pool.maxIdle = cfg.MaxIdleConnectionsPerHost
+	}
 
 	if pool.statReport {
 		pool.report()
@@ -84,7 +106,8 @@ func (p *connPool) NewStream(ctx context.Context, receiver types.StreamReceiveLi
 		return
 	}
 
-	if !p.host.ClusterInfo().ResourceManager().Requests().CanCreate() {
+	requests := str.RequestsResource(ctx, p.host)
+	if !requests.CanCreate() {
 		listener.OnFailure(types.Overflow, p.host)
 		p.host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
@@ -93,10 +116,12 @@ func (p *connPool) NewStream(ctx context.Context, receiver types.StreamReceiveLi
 		p.host.HostStats().UpstreamRequestActive.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamRequestTotal.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamRequestActive.Inc(1)
-		p.host.ClusterInfo().ResourceManager().Requests().Increase()
+		requests.Increase()
 
+		c.totalStream++
 		streamEncoder := c.client.NewStream(ctx, receiver)
 		streamEncoder.GetStream().AddEventListener(c)
+		streamEncoder.GetStream().AddEventListener(&str.ResourceReleaser{Resource: requests})
 		listener.OnReady(streamEncoder, p.host)
 	}
 
@@ -104,32 +129,94 @@ func (p *connPool) NewStream(ctx context.Context, receiver types.StreamReceiveLi
 }
 
 func (p *connPool) getAvailableClient(ctx context.Context) (*activeClient, types.PoolFailureReason) {
+	// the expired clients are closed without the lock held, the close event locks the pool
+	var expired []*activeClient
+	defer func() {
+		for _, c := range expired {
+			p.closeClient(c, p.host.HostStats().UpstreamConnectionCloseMaxAge, p.host.ClusterInfo().Stats().UpstreamConnectionCloseMaxAge)
+		}
+	}()
+
 	p.clientMux.Lock()
 	defer p.clientMux.Unlock()
 
-	n := len(p.availableClients)
-	// no available client
-	if n == 0 {
-		// max conns is 0 means no limit
-		maxConns := p.host.ClusterInfo().ResourceManager().Connections().Max()
-		if maxConns == 0 || p.totalClientCount < maxConns {
-			ac, reason := newActiveClient(ctx, p)
-			if ac != nil && reason == "" {
-				p.totalClientCount++
-			}
-			return ac, reason
-		} else {
-			p.host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
-			p.host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
-			return nil, types.Overflow
-		}
-	} else {
+	for n := len(p.availableClients); n > 0; n = len(p.availableClients) {
 		n--
 		c := p.availableClients[n]
 		p.availableClients[n] = nil
 		p.availableClients = p.availableClients[:n]
+		if p.expired(c) {
+			expired = append(expired, c)
+			continue
+		}
 		return c, ""
 	}
+
+	// no available client
+	// max conns is 0 means no limit
+	maxConns := p.host.ClusterInfo().ResourceManager().Connections().Max()
+	if maxConns == 0 || p.totalClientCount < maxConns {
+		ac, reason := newActiveClient(ctx, p)
+		if ac != nil && reason == "" {
+			p.totalClientCount++
+		}
+		return ac, reason
+	} else {
+		p.host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
+		p.host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
+		return nil, types.Overflow
+	}
+}
+
+// expired returns true if the client reaches the max age
+func (p *connPool) expired(client *activeClient) bool {
+	return p.maxAge > 0 && time.Since(client.createdAt) >= p.maxAge
+}
+
+// removeAvailableClient removes the client from the available clients, returns false if the client is not available.
+// The caller must hold the clientMux
+func (p *connPool) removeAvailableClient(client *activeClient) bool {
+	for i, c := range p.availableClients {
+		if c == client {
+			p.availableClients[i] = nil
+			p.availableClients = append(p.availableClients[:i], p.availableClients[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// closeClient closes a client not in use, the reason is counted by the counters of the host and the cluster
+func (p *connPool) closeClient(client *activeClient, closed, clusterClosed metrics.Counter) {
+	closed.Inc(1)
+	clusterClosed.Inc(1)
+	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+		log.DefaultLogger.Debugf("[stream] [http] [connpool] close connection to %s after %d requests",
+			p.host.AddressString(), client.totalStream)
+	}
+	client.client.Close()
+}
+
+// onIdleTimeout closes the client if it is not used for the idle timeout, or rearms the timer
+func (p *connPool) onIdleTimeout(client *activeClient) {
+	p.clientMux.Lock()
+	if client.closed {
+		p.clientMux.Unlock()
+		return
+	}
+	if idle := time.Since(client.idleAt); idle < p.idleTimeout {
+		client.idleTimer.Reset(p.idleTimeout - idle)
+		p.clientMux.Unlock()
+		return
+	}
+	// the client in use rearms the timer after returned to the pool
+	if !p.removeAvailableClient(client) {
+		p.clientMux.Unlock()
+		return
+	}
+	p.clientMux.Unlock()
+
+	p.closeClient(client, p.host.HostStats().UpstreamConnectionCloseIdle, p.host.ClusterInfo().Stats().UpstreamConnectionCloseIdle)
 }
 
 func (p *connPool) Close() {
@@ -147,6 +234,10 @@ func (p *connPool) Shutdown() {
 
 func (p *connPool) onConnectionEvent(client *activeClient, event api.ConnectionEvent) {
 	if event.IsClose() {
+		p.host.HostStats().UpstreamConnectionClose.Inc(1)
+		p.host.HostStats().UpstreamConnectionActive.Dec(1)
+		p.host.ClusterInfo().Stats().UpstreamConnectionClose.Inc(1)
+		p.host.ClusterInfo().Stats().UpstreamConnectionActive.Dec(1)
 
 		if client.closeWithActiveReq {
 			if event == api.LocalClose {
@@ -164,16 +255,13 @@ func (p *connPool) onConnectionEvent(client *activeClient, event api.ConnectionE
 
 		p.totalClientCount--
 
-		for i, c := range p.availableClients {
-			if c == client {
-				p.availableClients[i] = nil
-				p.availableClients = append(p.availableClients[:i], p.availableClients[i+1:]...)
-				break
-			}
-		}
+		p.removeAvailableClient(client)
 
 		// set closed flag if not available
 		client.closed = true
+		if client.idleTimer != nil {
+			client.idleTimer.Stop()
+		}
 	} else if event == api.ConnectTimeout {
 		p.host.HostStats().UpstreamRequestTimeout.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamRequestTimeout.Inc(1)
@@ -187,14 +275,39 @@ func (p *connPool) onConnectionEvent(client *activeClient, event api.ConnectionE
 func (p *connPool) onStreamDestroy(client *activeClient) {
 	p.host.HostStats().UpstreamRequestActive.Dec(1)
 	p.host.ClusterInfo().Stats().UpstreamRequestActive.Dec(1)
-	p.host.ClusterInfo().ResourceManager().Requests().Decrease()
 
 	// return to pool
 	p.clientMux.Lock()
-	if !client.closed {
+	if client.closed {
+		p.clientMux.Unlock()
+		return
+	}
+	var closed, clusterClosed metrics.Counter
+	switch {
+	case p.maxRequests > 0 && client.totalStream >= uint64(p.maxRequests):
+		closed, clusterClosed = p.host.HostStats().UpstreamConnectionCloseMaxRequests, p.host.ClusterInfo().Stats().UpstreamConnectionCloseMaxRequests
+	case p.expired(client):
+		closed, clusterClosed = p.host.HostStats().UpstreamConnectionCloseMaxAge, p.host.ClusterInfo().Stats().UpstreamConnectionCloseMaxAge
+	case p.maxIdle > 0 && uint32(len(p.availableClients)) >= p.maxIdle:
+		closed, clusterClosed = p.host.HostStats().UpstreamConnectionCloseMaxIdle, p.host.ClusterInfo().Stats().UpstreamConnectionCloseMaxIdle
+	default:
 		p.availableClients = append(p.availableClients, client)
+		if p.idleTimeout > 0 {
+			client.idleAt = time.Now()
+			if client.idleTimer == nil {
+				client.idleTimer = time.AfterFunc(p.idleTimeout, func() {
+					p.onIdleTimeout(client)
+				})
+			} else {
+				client.idleTimer.Reset(p.idleTimeout)
+			}
+		}
 	}
 	p.clientMux.Unlock()
+
+	if closed != nil {
+		p.closeClient(client, closed, clusterClosed)
+	}
 }
 
 func (p *connPool) onStreamReset(client *activeClient, reason types.StreamResetReason) {
@@ -238,11 +351,16 @@ type activeClient struct {
 	closeWithActiveReq bool
 	closed             bool
 	closeConn          bool
+	createdAt          time.Time
+	// idleAt is the time returned to the pool, protected by the clientMux of the pool
+	idleAt    time.Time
+	idleTimer *time.Timer
 }
 
 func newActiveClient(ctx context.Context, pool *connPool) (*activeClient, types.PoolFailureReason) {
 	ac := &activeClient{
-		pool: pool,
+		pool:      pool,
+		createdAt: time.Now(),
 	}
 
 	data := pool.host.CreateConnection(ctx)
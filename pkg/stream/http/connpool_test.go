@@ -0,0 +1,223 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/configmanager"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/buffer"
+)
+
+// discardServer accepts the connections and discards the data received
+func discardServer(t *testing.T) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(ioutil.Discard, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	return ln
+}
+
+type poolListener struct {
+	reason types.PoolFailureReason
+	sender types.StreamSender
+}
+
+func (l *poolListener) OnFailure(reason types.PoolFailureReason, host types.Host) {
+	l.reason = reason
+}
+
+func (l *poolListener) OnReady(sender types.StreamSender, host types.Host) {
+	l.sender = sender
+}
+
+type noopReceiver struct{}
+
+func (r *noopReceiver) OnReceive(ctx context.Context, headers api.HeaderMap, data buffer.IoBuffer, trailers api.HeaderMap) {
+}
+
+func (r *noopReceiver) OnDecodeError(ctx context.Context, err error, headers api.HeaderMap) {}
+
+func newTestPool(t *testing.T, cfg v2.Cluster) (*connPool, net.Listener) {
+	ln := discardServer(t)
+	cfg.Name = "test"
+	cfg.ClusterType = v2.SIMPLE_CLUSTER
+	cfg.LbType = v2.LB_ROUNDROBIN
+	c := cluster.NewCluster(cfg)
+	host := cluster.NewSimpleHost(v2.Host{
+		HostConfig: v2.HostConfig{
+			Address:    ln.Addr().String(),
+			TLSDisable: true,
+		},
+	}, c.Snapshot().ClusterInfo())
+	return NewConnPool(host).(*connPool), ln
+}
+
+// doStream creates a stream and finishes it, returns the connection id used
+func doStream(t *testing.T, p *connPool) uint64 {
+	l := &poolListener{}
+	p.NewStream(context.Background(), &noopReceiver{}, l)
+	if l.sender == nil {
+		t.Fatalf("stream is not created, failure reason: %s", l.reason)
+	}
+	id := l.sender.GetStream().(*clientStream).connection.conn.ID()
+	l.sender.GetStream().DestroyStream()
+	return id
+}
+
+func waitCount(t *testing.T, counter metrics.Counter, count int64) {
+	for i := 0; i < 100; i++ {
+		if counter.Count() == count {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("expected count %d, but got %d", count, counter.Count())
+}
+
+func availableClients(p *connPool) int {
+	p.clientMux.Lock()
+	defer p.clientMux.Unlock()
+	return len(p.availableClients)
+}
+
+func TestConnPoolUnlimitedRequests(t *testing.T) {
+	// the parsed cluster has the default max requests
+	p, ln := newTestPool(t, configmanager.ParseClusterDefaultValue(v2.Cluster{}))
+	ln.Close()
+	if p.maxRequests != configmanager.DefaultMaxRequestPerConn {
+		t.Fatalf("expected the default max requests, but got %d", p.maxRequests)
+	}
+	// the connections are not rotated if the requests are unlimited
+	p, ln = newTestPool(t, configmanager.ParseClusterDefaultValue(v2.Cluster{
+		ConnPool: v2.ConnPoolConfig{UnlimitedRequestsPerConnection: true},
+	}))
+	defer ln.Close()
+	if p.maxRequests != 0 {
+		t.Fatalf("expected no max requests, but got %d", p.maxRequests)
+	}
+	first := doStream(t, p)
+	if id := doStream(t, p); id != first {
+		t.Fatal("expected the connection is reused")
+	}
+}
+
+func TestConnPoolMaxRequests(t *testing.T) {
+	p, ln := newTestPool(t, v2.Cluster{
+		MaxRequestPerConn: 2,
+	})
+	defer ln.Close()
+
+	first := doStream(t, p)
+	if id := doStream(t, p); id != first {
+		t.Fatal("expected the connection is reused")
+	}
+	waitCount(t, p.host.HostStats().UpstreamConnectionCloseMaxRequests, 1)
+	if availableClients(p) != 0 {
+		t.Fatal("expected the connection reaches max requests is not reused")
+	}
+	if id := doStream(t, p); id == first {
+		t.Fatal("expected a new connection after max requests")
+	}
+}
+
+func TestConnPoolMaxConnectionAge(t *testing.T) {
+	p, ln := newTestPool(t, v2.Cluster{
+		ConnPool: v2.ConnPoolConfig{
+			MaxConnectionAge: &api.DurationConfig{Duration: 100 * time.Millisecond},
+		},
+	})
+	defer ln.Close()
+
+	first := doStream(t, p)
+	time.Sleep(150 * time.Millisecond)
+	// the aged connection is closed when it is selected
+	if id := doStream(t, p); id == first {
+		t.Fatal("expected a new connection after max age")
+	}
+	waitCount(t, p.host.HostStats().UpstreamConnectionCloseMaxAge, 1)
+}
+
+func TestConnPoolIdleTimeout(t *testing.T) {
+	p, ln := newTestPool(t, v2.Cluster{
+		ConnPool: v2.ConnPoolConfig{
+			IdleTimeout: &api.DurationConfig{Duration: 200 * time.Millisecond},
+		},
+	})
+	defer ln.Close()
+
+	first := doStream(t, p)
+	time.Sleep(100 * time.Millisecond)
+	// the idle time restarts after the connection used
+	if id := doStream(t, p); id != first {
+		t.Fatal("expected the connection is reused")
+	}
+	time.Sleep(150 * time.Millisecond)
+	if availableClients(p) != 1 {
+		t.Fatal("expected the connection is not idle timeout")
+	}
+	waitCount(t, p.host.HostStats().UpstreamConnectionCloseIdle, 1)
+	if availableClients(p) != 0 {
+		t.Fatal("expected the idle connection is removed")
+	}
+}
+
+func TestConnPoolMaxIdle(t *testing.T) {
+	p, ln := newTestPool(t, v2.Cluster{
+		ConnPool: v2.ConnPoolConfig{
+			MaxIdleConnectionsPerHost: 1,
+		},
+	})
+	defer ln.Close()
+
+	// two connections are used at the same time
+	l1, l2 := &poolListener{}, &poolListener{}
+	p.NewStream(context.Background(), &noopReceiver{}, l1)
+	p.NewStream(context.Background(), &noopReceiver{}, l2)
+	if l1.sender == nil || l2.sender == nil {
+		t.Fatalf("stream is not created, failure reason: %s, %s", l1.reason, l2.reason)
+	}
+	l1.sender.GetStream().DestroyStream()
+	l2.sender.GetStream().DestroyStream()
+	waitCount(t, p.host.HostStats().UpstreamConnectionCloseMaxIdle, 1)
+	if availableClients(p) != 1 {
+		t.Fatalf("expected 1 idle connection, but got %d", availableClients(p))
+	}
+}
@@ -0,0 +1,208 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/valyala/fasthttp"
+	"mosn.io/api"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/module/http2"
+	"mosn.io/mosn/pkg/mtls"
+	"mosn.io/mosn/pkg/protocol"
+	str "mosn.io/mosn/pkg/stream"
+	str2 "mosn.io/mosn/pkg/stream/http2"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// cleartext HTTP/2 on the HTTP/1 server connections, both the upgrade (RFC 7540 Section 3.2)
+// and the prior knowledge (RFC 7540 Section 3.4) are supported.
+// The switched connection dispatches the data received to a HTTP/2 server stream connection
+
+var (
+	strSwitchingProtocols = []byte("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: h2c\r\n\r\n")
+	http2Preface          = []byte(http2.ClientPreface)
+)
+
+// the headers only meaningful for a single HTTP/1 connection
+var upgradeHopHeaders = map[string]bool{
+	"connection":        true,
+	"upgrade":           true,
+	"http2-settings":    true,
+	"keep-alive":        true,
+	"proxy-connection":  true,
+	"transfer-encoding": true,
+	"host":              true,
+}
+
+// h2cEnabled returns true if the HTTP/1 server connection accepts cleartext HTTP/2
+func h2cEnabled(ctx context.Context, conn api.Connection) bool {
+	if ctx == nil {
+		return false
+	}
+	if enabled, _ := mosnctx.Get(ctx, types.ContextKeyH2C).(bool); !enabled {
+		return false
+	}
+	_, tls := conn.RawConn().(*mtls.TLSConn)
+	return !tls
+}
+
+// hasToken returns true if the comma separated header value contains the token
+func hasToken(value []byte, token string) bool {
+	for _, v := range strings.Split(string(value), ",") {
+		if strings.EqualFold(strings.TrimSpace(v), token) {
+			return true
+		}
+	}
+	return false
+}
+
+// h2cUpgradeSettings returns the HTTP2-Settings header if the request is a h2c upgrade request
+func h2cUpgradeSettings(header *fasthttp.RequestHeader) (string, bool) {
+	if !header.IsHTTP11() {
+		return "", false
+	}
+	var upgrade, connection, hasSettings bool
+	var settings string
+	header.VisitAll(func(key, value []byte) {
+		switch {
+		case bytes.EqualFold(key, []byte("Upgrade")):
+			upgrade = hasToken(value, "h2c")
+		case bytes.EqualFold(key, []byte("Connection")):
+			connection = hasToken(value, "Upgrade") && hasToken(value, "HTTP2-Settings")
+		case bytes.EqualFold(key, []byte("HTTP2-Settings")):
+			settings = string(value)
+			hasSettings = true
+		}
+	})
+	return settings, upgrade && connection && hasSettings
+}
+
+// convertUpgradeRequest converts the HTTP/1 upgrade request to the request of stream 1
+func convertUpgradeRequest(request *fasthttp.Request) *http.Request {
+	header := make(http.Header)
+	request.Header.VisitAll(func(key, value []byte) {
+		k := string(key)
+		if upgradeHopHeaders[strings.ToLower(k)] {
+			return
+		}
+		header.Add(k, string(value))
+	})
+	uri := request.URI()
+	return &http.Request{
+		Method: string(request.Header.Method()),
+		Host:   string(request.Host()),
+		URL: &url.URL{
+			Path:     string(uri.Path()),
+			RawQuery: string(uri.QueryString()),
+		},
+		Proto:         "HTTP/2.0",
+		ProtoMajor:    2,
+		Header:        header,
+		ContentLength: int64(len(request.Body())),
+	}
+}
+
+// hasHTTP2Preface returns true if the connection starts with the HTTP/2 client preface.
+// The data is peeked byte by byte, so a short HTTP/1 request would not be blocked
+func (conn *serverStreamConnection) hasHTTP2Preface() bool {
+	for n := 1; n <= len(http2Preface); n++ {
+		peek, err := conn.br.Peek(n)
+		if err != nil || !bytes.Equal(peek, http2Preface[:n]) {
+			return false
+		}
+	}
+	return true
+}
+
+// upgradeH2C switches the connection to HTTP/2 with the upgrade request served as stream 1
+func (conn *serverStreamConnection) upgradeH2C(request *fasthttp.Request, settingsHeader string) error {
+	settings, err := http2.DecodeSettings(settingsHeader)
+	if err != nil {
+		return err
+	}
+	// the body is sent before the connection switched, it is not read yet in streaming mode
+	if conn.useStream {
+		if err := request.ContinueReadBody(conn.br, defaultMaxRequestBodySize); err != nil {
+			return err
+		}
+	}
+	req := convertUpgradeRequest(request)
+	body := buffer.NewIoBufferBytes(append([]byte(nil), request.Body()...))
+
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(conn.context, "[stream] [http] upgrade to h2c, connection = %d", conn.conn.ID())
+	}
+	conn.conn.Write(buffer.NewIoBufferBytes(strSwitchingProtocols))
+
+	atomic.StoreUint32(&conn.switching, 1)
+	upgraded, err := str2.NewUpgradedServerStreamConnection(conn.context, conn.conn, conn.serverStreamConnListener, req, body, settings)
+	if err != nil {
+		return err
+	}
+	conn.serveSwitched(upgraded)
+	return nil
+}
+
+// servePriorKnowledge switches the connection starts with the HTTP/2 preface
+func (conn *serverStreamConnection) servePriorKnowledge() {
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(conn.context, "[stream] [http] h2c with prior knowledge, connection = %d", conn.conn.ID())
+	}
+	atomic.StoreUint32(&conn.switching, 1)
+	conn.serveSwitched(str.CreateServerStreamConnection(conn.context, protocol.HTTP2, conn.conn, conn.serverStreamConnListener))
+}
+
+// serveSwitched dispatches the data to the switched stream connection, until the connection closed.
+// The data read by the HTTP/1 reader already is dispatched first
+func (conn *serverStreamConnection) serveSwitched(switched types.ServerStreamConnection) {
+	conn.switched.Store(switched)
+
+	buf := buffer.GetIoBuffer(conn.br.Buffered())
+	if n := conn.br.Buffered(); n > 0 {
+		data, _ := conn.br.Peek(n)
+		buf.Write(data)
+		conn.br.Discard(n)
+	}
+	for {
+		if buf.Len() > 0 {
+			switched.Dispatch(buf)
+		}
+		data, ok := <-conn.bufChan
+		if !ok {
+			return
+		}
+		buf.Write(data.Bytes())
+		data.Drain(data.Len())
+		conn.bufChan <- nil
+	}
+}
+
+// switchedConnection returns the HTTP/2 stream connection if the connection is switched
+func (conn *serverStreamConnection) switchedConnection() types.ServerStreamConnection {
+	switched, _ := conn.switched.Load().(types.ServerStreamConnection)
+	return switched
+}
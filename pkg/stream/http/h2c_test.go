@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"mosn.io/mosn/pkg/module/http2"
+)
+
+func readRequest(t *testing.T, raw string) *fasthttp.Request {
+	request := &fasthttp.Request{}
+	if err := request.Read(bufio.NewReader(strings.NewReader(raw))); err != nil {
+		t.Fatalf("read request failed: %v", err)
+	}
+	return request
+}
+
+func TestH2CUpgradeSettings(t *testing.T) {
+	testCases := []struct {
+		name    string
+		raw     string
+		upgrade bool
+	}{
+		{"upgrade", "GET / HTTP/1.1\r\nHost: a\r\nConnection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: AAMAAABk\r\n\r\n", true},
+		{"case insensitive", "GET / HTTP/1.1\r\nHost: a\r\nconnection: http2-settings,upgrade\r\nupgrade: H2C\r\nhttp2-settings: AAMAAABk\r\n\r\n", true},
+		{"no settings", "GET / HTTP/1.1\r\nHost: a\r\nConnection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\n\r\n", false},
+		{"no connection", "GET / HTTP/1.1\r\nHost: a\r\nUpgrade: h2c\r\nHTTP2-Settings: AAMAAABk\r\n\r\n", false},
+		{"websocket", "GET / HTTP/1.1\r\nHost: a\r\nConnection: Upgrade, HTTP2-Settings\r\nUpgrade: websocket\r\nHTTP2-Settings: AAMAAABk\r\n\r\n", false},
+		{"http/1.0", "GET / HTTP/1.0\r\nHost: a\r\nConnection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: AAMAAABk\r\n\r\n", false},
+	}
+	for _, tc := range testCases {
+		request := readRequest(t, tc.raw)
+		settings, ok := h2cUpgradeSettings(&request.Header)
+		if ok != tc.upgrade {
+			t.Errorf("case %s: expected upgrade %v, got %v", tc.name, tc.upgrade, ok)
+		}
+		if ok && settings != "AAMAAABk" {
+			t.Errorf("case %s: unexpected settings %s", tc.name, settings)
+		}
+	}
+}
+
+func TestConvertUpgradeRequest(t *testing.T) {
+	request := readRequest(t, "POST /path?a=b HTTP/1.1\r\nHost: example.com\r\nConnection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: AAMAAABk\r\nX-Test: value\r\nContent-Length: 4\r\n\r\nbody")
+	req := convertUpgradeRequest(request)
+	if req.Method != "POST" || req.Host != "example.com" || req.URL.Path != "/path" || req.URL.RawQuery != "a=b" {
+		t.Fatalf("unexpected request: %s %s %s", req.Method, req.Host, req.URL)
+	}
+	if req.ProtoMajor != 2 || req.ContentLength != 4 {
+		t.Errorf("unexpected protocol %d or content length %d", req.ProtoMajor, req.ContentLength)
+	}
+	if req.Header.Get("X-Test") != "value" {
+		t.Errorf("header X-Test is not converted")
+	}
+	for _, h := range []string{"Connection", "Upgrade", "Http2-Settings", "Host"} {
+		if _, ok := req.Header[h]; ok {
+			t.Errorf("hop header %s should be removed", h)
+		}
+	}
+
+	settings, err := http2.DecodeSettings("AAMAAABk")
+	if err != nil || len(settings) != 1 || settings[0].ID != http2.SettingMaxConcurrentStreams || settings[0].Val != 100 {
+		t.Errorf("decode settings failed: %v, %v", settings, err)
+	}
+	if _, err := http2.DecodeSettings("AAMAAA"); err == nil {
+		t.Errorf("decode invalid settings should be failed")
+	}
+}
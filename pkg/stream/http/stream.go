@@ -34,6 +34,7 @@ import (
 	mbuffer "mosn.io/mosn/pkg/buffer"
 	mosnctx "mosn.io/mosn/pkg/context"
 	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/overload"
 	"mosn.io/mosn/pkg/protocol"
 	mosnhttp "mosn.io/mosn/pkg/protocol/http"
 	str "mosn.io/mosn/pkg/stream"
@@ -49,6 +50,9 @@ func init() {
 
 const defaultMaxRequestBodySize = 4 * 1024 * 1024
 
+// the request body is not held in memory in streaming mode, so the limit is larger
+const defaultMaxStreamRequestBodySize = 64 * 1024 * 1024
+
 var (
 	errConnClose = errors.New("connection closed")
 
@@ -232,8 +236,16 @@ func (conn *clientStreamConnection) serve() {
 		buffers := httpBuffersByContext(s.ctx)
 		s.response = &buffers.clientResponse
 
-		// 1. blocking read using fasthttp.Response.Read
-		err := s.response.Read(conn.br)
+		// 1. blocking read using fasthttp.Response.Read,
+		// only the headers are read in streaming mode
+		receiver, streaming := s.receiver.(types.StreamDataReceiveListener)
+		streaming = streaming && useStream(s.ctx)
+		var err error
+		if streaming {
+			err = s.readResponseHeader(conn.br)
+		} else {
+			err = s.response.Read(conn.br)
+		}
 		if err != nil {
 			if s != nil {
 				log.Proxy.Errorf(s.connection.context, "[stream] [http] client stream connection wait response error: %s", err)
@@ -262,6 +274,20 @@ func (conn *clientStreamConnection) serve() {
 			s.connection.streamConnectionEventListener.OnGoAway()
 		}
 
+		// 4. receive the response body in data frames
+		if streaming && s.hasResponseBody() {
+			if err := s.receiveResponseBody(receiver); err != nil {
+				log.Proxy.Errorf(s.connection.context, "[stream] [http] client stream connection read response body error: %s", err)
+				reason := conn.resetReason
+				if reason == "" {
+					reason = types.StreamRemoteReset
+				}
+				s.ResetStream(reason)
+				return
+			}
+			continue
+		}
+
 		if atomic.LoadInt32(&s.readDisableCount) <= 0 {
 			s.handleResponse()
 		}
@@ -275,10 +301,11 @@ func (conn *clientStreamConnection) NewStream(ctx context.Context, receiver type
 	buffers := httpBuffersByContext(ctx)
 	s := &buffers.clientStream
 	s.stream = stream{
-		id:       id,
-		ctx:      mosnctx.WithValue(ctx, types.ContextKeyStreamID, id),
-		request:  &buffers.clientRequest,
-		receiver: receiver,
+		id:         id,
+		ctx:        mosnctx.WithValue(ctx, types.ContextKeyStreamID, id),
+		request:    &buffers.clientRequest,
+		receiver:   receiver,
+		readEnable: make(chan struct{}, 1),
 	}
 	s.connection = conn
 
@@ -329,6 +356,13 @@ type serverStreamConnection struct {
 	contextManager *str.ContextManager
 
 	close bool
+	// the request bodies are received in data frames
+	useStream bool
+	// h2c is set if the connection can be switched to cleartext HTTP/2,
+	// switching is set once the connection decides to switch, and switched is the HTTP/2 stream connection
+	h2c       bool
+	switching uint32
+	switched  atomic.Value
 
 	stream                   *serverStream
 	mutex                    sync.RWMutex
@@ -346,6 +380,8 @@ func newServerStreamConnection(ctx context.Context, connection api.Connection,
 		},
 		contextManager:           str.NewContextManager(ctx),
 		serverStreamConnListener: callbacks,
+		useStream:                useStream(ctx),
+		h2c:                      h2cEnabled(ctx, connection),
 	}
 
 	// init first context
@@ -378,6 +414,11 @@ func (conn *serverStreamConnection) OnEvent(event api.ConnectionEvent) {
 }
 
 func (conn *serverStreamConnection) serve() {
+	if conn.h2c && conn.hasHTTP2Preface() {
+		conn.servePriorKnowledge()
+		return
+	}
+
 	for {
 		// 1. pre alloc stream-level ctx with bufferCtx
 		ctx := conn.contextManager.Get()
@@ -386,8 +427,14 @@ func (conn *serverStreamConnection) serve() {
 
 		request.Header.DisableNormalizing()
 
-		// 2. blocking read using fasthttp.Request.Read
-		err := request.ReadLimitBody(conn.br, defaultMaxRequestBodySize)
+		// 2. blocking read using fasthttp.Request.Read,
+		// only the headers are read in streaming mode, the body is read after the stream created
+		var err error
+		if conn.useStream {
+			err = request.Header.Read(conn.br)
+		} else {
+			err = request.ReadLimitBody(conn.br, defaultMaxRequestBodySize)
+		}
 		if err == nil {
 			// 3. 'Expect: 100-continue' request handling.
 			// See http://www.w3.org/Protocols/rfc2616/rfc2616-sec8.html for details.
@@ -396,12 +443,22 @@ func (conn *serverStreamConnection) serve() {
 				conn.conn.Write(buffer.NewIoBufferBytes(strResponseContinue))
 
 				// read request body
-				err = request.ContinueReadBody(conn.br, defaultMaxRequestBodySize)
+				if !conn.useStream {
+					err = request.ContinueReadBody(conn.br, defaultMaxRequestBodySize)
+				}
 
 				// remove 'Expect' header, so it would not be sent to the upstream
 				request.Header.Del("Expect")
 			}
 		}
+		if err == nil && conn.h2c {
+			if settings, ok := h2cUpgradeSettings(&request.Header); ok {
+				if err = conn.upgradeH2C(request, settings); err == nil {
+					return
+				}
+				log.DefaultLogger.Errorf("[stream] [http] upgrade to h2c failed, connection = %d, error = %v", conn.conn.ID(), err)
+			}
+		}
 		if err != nil {
 			// "read timeout with nothing read" is the error of returned by fasthttp v1.2.0
 			// if connection closed with nothing read.
@@ -420,10 +477,11 @@ func (conn *serverStreamConnection) serve() {
 
 		// 4. request processing
 		s.stream = stream{
-			id:       id,
-			ctx:      mosnctx.WithValue(ctx, types.ContextKeyStreamID, id),
-			request:  request,
-			response: &buffers.serverResponse,
+			id:         id,
+			ctx:        mosnctx.WithValue(ctx, types.ContextKeyStreamID, id),
+			request:    request,
+			response:   &buffers.serverResponse,
+			readEnable: make(chan struct{}, 1),
 		}
 		s.connection = conn
 		s.responseDoneChan = make(chan bool, 1)
@@ -448,7 +506,13 @@ func (conn *serverStreamConnection) serve() {
 		conn.stream = s
 		conn.mutex.Unlock()
 
-		if atomic.LoadInt32(&s.readDisableCount) <= 0 {
+		if conn.useStream {
+			if err := s.receiveRequest(); err != nil {
+				log.Proxy.Errorf(s.stream.ctx, "[stream] [http] server stream connection read request body error: %s", err)
+				conn.conn.Close(api.NoFlush, api.LocalClose)
+				return
+			}
+		} else if atomic.LoadInt32(&s.readDisableCount) <= 0 {
 			s.handleRequest()
 		}
 
@@ -463,7 +527,18 @@ func (conn *serverStreamConnection) serve() {
 	}
 }
 
+func (conn *serverStreamConnection) Protocol() types.ProtocolName {
+	if atomic.LoadUint32(&conn.switching) == 1 {
+		return protocol.HTTP2
+	}
+	return protocol.HTTP1
+}
+
 func (conn *serverStreamConnection) ActiveStreamsNum() int {
+	if switched := conn.switchedConnection(); switched != nil {
+		return switched.ActiveStreamsNum()
+	}
+
 	conn.mutex.RLock()
 	defer conn.mutex.RUnlock()
 
@@ -510,6 +585,15 @@ type stream struct {
 	response *fasthttp.Response
 
 	receiver types.StreamReceiveListener
+
+	// streaming mode
+	// readEnable wakes up the body reading blocked by ReadDisable
+	readEnable chan struct{}
+	// receivingBody is set if the body is received in data frames
+	receivingBody bool
+	// sendingBody is set if the body is sent in data frames, chunked is set if it is sent with chunked encoding
+	sendingBody bool
+	chunked     bool
 }
 
 // types.Stream
@@ -522,6 +606,8 @@ type clientStream struct {
 	stream
 
 	connection *clientStreamConnection
+	// bodySent is set after the last data frame of the request body sent
+	bodySent int32
 }
 
 // types.StreamSender
@@ -550,6 +636,10 @@ func (s *clientStream) AppendHeaders(context context.Context, headersIn types.He
 }
 
 func (s *clientStream) AppendData(context context.Context, data buffer.IoBuffer, endStream bool) error {
+	if s.sendingBody || (!endStream && useStream(context)) {
+		return s.appendDataFrame(data, endStream)
+	}
+
 	s.request.SetBody(data.Bytes())
 
 	if endStream {
@@ -560,21 +650,47 @@ func (s *clientStream) AppendData(context context.Context, data buffer.IoBuffer,
 }
 
 func (s *clientStream) AppendTrailers(context context.Context, trailers types.HeaderMap) error {
+	if s.sendingBody {
+		return s.appendDataFrame(nil, true)
+	}
 	s.endStream()
 	return nil
 }
 
+// appendDataFrame sends the request body in data frames, the headers are sent with the first frame
+func (s *clientStream) appendDataFrame(data buffer.IoBuffer, endStream bool) error {
+	if !s.sendingBody {
+		s.sendingBody = true
+		if s.request.Header.ContentLength() <= 0 {
+			s.request.Header.SetContentLength(-1)
+			s.chunked = true
+		}
+		if _, err := s.request.Header.WriteTo(s.connection); err != nil {
+			s.onSendError(err)
+			return err
+		}
+		// the response may be received before the request body finished
+		s.connection.requestSent <- true
+	}
+
+	if err := writeBodyFrame(s.connection, data, s.chunked, endStream); err != nil {
+		s.onSendError(err)
+		return err
+	}
+	if endStream {
+		atomic.StoreInt32(&s.bodySent, 1)
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(s.stream.ctx, "[stream] [http] send client request body, requestId = %v", s.stream.id)
+		}
+	}
+	return nil
+}
+
 func (s *clientStream) endStream() {
 	err := s.doSend()
 
 	if err != nil {
-		log.Proxy.Errorf(s.stream.ctx, "[stream] [http] send client request error: %+v", err)
-
-		if err == types.ErrConnectionHasClosed {
-			s.ResetStream(types.StreamConnectionFailed)
-		} else {
-			s.ResetStream(types.StreamLocalReset)
-		}
+		s.onSendError(err)
 		return
 	}
 
@@ -584,6 +700,16 @@ func (s *clientStream) endStream() {
 	s.connection.requestSent <- true
 }
 
+func (s *clientStream) onSendError(err error) {
+	log.Proxy.Errorf(s.stream.ctx, "[stream] [http] send client request error: %+v", err)
+
+	if err == types.ErrConnectionHasClosed {
+		s.ResetStream(types.StreamConnectionFailed)
+	} else {
+		s.ResetStream(types.StreamLocalReset)
+	}
+}
+
 func (s *clientStream) ReadDisable(disable bool) {
 	if disable {
 		atomic.AddInt32(&s.readDisableCount, 1)
@@ -591,7 +717,11 @@ func (s *clientStream) ReadDisable(disable bool) {
 		newCount := atomic.AddInt32(&s.readDisableCount, -1)
 
 		if newCount <= 0 {
-			s.handleResponse()
+			if s.receivingBody {
+				s.resumeRead()
+			} else {
+				s.handleResponse()
+			}
 		}
 	}
 }
@@ -603,21 +733,14 @@ func (s *clientStream) doSend() (err error) {
 
 func (s *clientStream) handleResponse() {
 	if s.response != nil {
-		header := mosnhttp.ResponseHeader{&s.response.Header, nil}
-
-		statusCode := header.StatusCode()
-		status := strconv.Itoa(statusCode)
-		// inherit upstream's response status
-		header.Set(types.HeaderStatus, status)
+		header := s.responseHeader()
 
 		hasData := true
 		if len(s.response.Body()) == 0 {
 			hasData = false
 		}
 
-		s.connection.mutex.Lock()
-		s.connection.stream = nil
-		s.connection.mutex.Unlock()
+		s.detach()
 
 		if hasData {
 			s.receiver.OnReceive(s.ctx, header, buffer.NewIoBufferBytes(s.response.Body()), nil)
@@ -631,6 +754,65 @@ func (s *clientStream) handleResponse() {
 	}
 }
 
+func (s *clientStream) responseHeader() mosnhttp.ResponseHeader {
+	header := mosnhttp.ResponseHeader{&s.response.Header, nil}
+
+	statusCode := header.StatusCode()
+	status := strconv.Itoa(statusCode)
+	// inherit upstream's response status
+	header.Set(types.HeaderStatus, status)
+
+	return header
+}
+
+// detach removes the stream from the connection after the response received,
+// the connection can not be reused if the request body is not sent completely
+func (s *clientStream) detach() {
+	if s.sendingBody && atomic.LoadInt32(&s.bodySent) == 0 {
+		s.connection.streamConnectionEventListener.OnGoAway()
+	}
+
+	s.connection.mutex.Lock()
+	s.connection.stream = nil
+	s.connection.mutex.Unlock()
+}
+
+// readResponseHeader reads the response headers only, the 'HTTP/1.1 100 Continue' response is skipped
+func (s *clientStream) readResponseHeader(br *bufio.Reader) error {
+	if err := s.response.Header.Read(br); err != nil {
+		return err
+	}
+	if s.response.Header.StatusCode() == fasthttp.StatusContinue {
+		return s.response.Header.Read(br)
+	}
+	return nil
+}
+
+func (s *clientStream) hasResponseBody() bool {
+	// From http/1.1 specs:
+	// All 1xx (informational), 204 (no content), and 304 (not modified) responses MUST NOT include a message-body
+	statusCode := s.response.Header.StatusCode()
+	if statusCode < fasthttp.StatusOK || statusCode == fasthttp.StatusNoContent || statusCode == fasthttp.StatusNotModified {
+		return false
+	}
+	if s.request.Header.IsHead() {
+		return false
+	}
+	return s.response.Header.ContentLength() != 0
+}
+
+// receiveResponseBody passes the response headers, and then the body in data frames to the receiver
+func (s *clientStream) receiveResponseBody(receiver types.StreamDataReceiveListener) error {
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(s.stream.ctx, "[stream] [http] receive response body in data frames, requestId = %v", s.stream.id)
+	}
+	s.receivingBody = true
+	receiver.OnReceiveHeaders(s.ctx, s.responseHeader(), false)
+
+	body := newBodyReader(s.connection.br, s.response.Header.ContentLength())
+	return s.readBody(body, receiver, s.connection.connClosed, 0, s.detach)
+}
+
 func (s *clientStream) GetStream() types.Stream {
 	return s
 }
@@ -671,6 +853,18 @@ func (s *serverStream) AppendHeaders(context context.Context, headersIn types.He
 		}
 
 		headers.CopyTo(&s.response.Header)
+	default:
+		// the direct response with the headers created by filters
+		if status, ok := headers.Get(types.HeaderStatus); ok {
+			statusCode, _ := strconv.Atoi(status)
+			s.response.SetStatusCode(statusCode)
+		}
+		headers.Range(func(key, value string) bool {
+			if key != types.HeaderStatus {
+				s.response.Header.Set(key, value)
+			}
+			return true
+		})
 	}
 
 	if endStream {
@@ -681,6 +875,10 @@ func (s *serverStream) AppendHeaders(context context.Context, headersIn types.He
 }
 
 func (s *serverStream) AppendData(context context.Context, data buffer.IoBuffer, endStream bool) error {
+	if s.sendingBody || (!endStream && useStream(context)) {
+		return s.appendDataFrame(data, endStream)
+	}
+
 	s.response.SetBody(data.Bytes())
 
 	if endStream {
@@ -691,25 +889,62 @@ func (s *serverStream) AppendData(context context.Context, data buffer.IoBuffer,
 }
 
 func (s *serverStream) AppendTrailers(context context.Context, trailers types.HeaderMap) error {
+	if s.sendingBody {
+		return s.appendDataFrame(nil, true)
+	}
 	s.endStream()
 	return nil
 }
 
-func (s *serverStream) endStream() {
-	resetConn := false
+// appendDataFrame sends the response body in data frames, the headers are sent with the first frame
+func (s *serverStream) appendDataFrame(data buffer.IoBuffer, endStream bool) error {
+	if !s.sendingBody {
+		s.sendingBody = true
+		s.setConnectionHeader()
+		if s.response.Header.ContentLength() <= 0 {
+			s.response.Header.SetContentLength(-1)
+			// the response without body can not be chunked
+			s.chunked = s.response.Header.ContentLength() == -1
+		}
+		if _, err := s.response.Header.WriteTo(s.connection); err != nil {
+			log.Proxy.Errorf(s.stream.ctx, "[stream] [http] send server response headers error: %+v", err)
+			return err
+		}
+	}
+
+	if err := writeBodyFrame(s.connection, data, s.chunked, endStream); err != nil {
+		log.Proxy.Errorf(s.stream.ctx, "[stream] [http] send server response body error: %+v", err)
+		return err
+	}
+	if endStream {
+		s.endStream()
+	}
+	return nil
+}
+
+// setConnectionHeader sets the connection header of the response, returns true if the connection should be closed
+func (s *serverStream) setConnectionHeader() bool {
 	// check if we need close connection
-	if s.connection.close || s.request.Header.ConnectionClose() {
+	if s.connection.close || s.request.Header.ConnectionClose() || overload.IsActive(overload.DisableHTTPKeepAlive) {
 		s.response.SetConnectionClose()
-		resetConn = true
+		return true
 	} else if !s.request.Header.IsHTTP11() {
 		// Set 'Connection: keep-alive' response header for non-HTTP/1.1 request.
 		// There is no need in setting this header for http/1.1, since in http/1.1
 		// connections are keep-alive by default.
 		s.response.Header.SetCanonical(HKConnection, HVKeepAlive)
 	}
+	return false
+}
+
+func (s *serverStream) endStream() {
+	resetConn := s.setConnectionHeader()
 	defer s.DestroyStream()
 
-	s.doSend()
+	// the response is sent in data frames already
+	if !s.sendingBody {
+		s.doSend()
+	}
 	s.responseDoneChan <- true
 
 	if resetConn {
@@ -730,7 +965,11 @@ func (s *serverStream) ReadDisable(disable bool) {
 		newCount := atomic.AddInt32(&s.readDisableCount, -1)
 
 		if newCount <= 0 {
-			s.handleRequest()
+			if s.receivingBody {
+				s.resumeRead()
+			} else {
+				s.handleRequest()
+			}
 		}
 	}
 }
@@ -763,6 +1002,39 @@ func (s *serverStream) handleRequest() {
 	}
 }
 
+// receiveRequest passes the request headers, and then the body in data frames to the receiver.
+// The whole body is read if the receiver can not receive data frames
+func (s *serverStream) receiveRequest() error {
+	receiver, ok := s.receiver.(types.StreamDataReceiveListener)
+	if !ok {
+		if err := s.request.ContinueReadBody(s.connection.br, defaultMaxRequestBodySize); err != nil {
+			return err
+		}
+		if atomic.LoadInt32(&s.readDisableCount) <= 0 {
+			s.handleRequest()
+		}
+		return nil
+	}
+
+	// set non-header info in request-line, like method, uri
+	injectInternalHeaders(s.header, s.request.URI())
+
+	// the request without 'Content-Length' and 'Transfer-Encoding' has no body
+	contentLength := s.request.Header.ContentLength()
+	if contentLength == 0 || contentLength == -2 {
+		receiver.OnReceiveHeaders(s.ctx, s.header, true)
+		return nil
+	}
+	if contentLength > defaultMaxStreamRequestBodySize {
+		return fasthttp.ErrBodyTooLarge
+	}
+
+	s.receivingBody = true
+	receiver.OnReceiveHeaders(s.ctx, s.header, false)
+
+	return s.readBody(newBodyReader(s.connection.br, contentLength), receiver, s.connection.connClosed, defaultMaxStreamRequestBodySize, nil)
+}
+
 func (s *serverStream) GetStream() types.Stream {
 	return s
 }
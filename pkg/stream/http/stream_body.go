@@ -0,0 +1,175 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http/httputil"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/valyala/fasthttp"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// the max size of a data frame in streaming mode
+const defaultStreamFrameSize = 16 * 1024
+
+var (
+	strCRLF      = []byte("\r\n")
+	strLastChunk = []byte("0\r\n\r\n")
+)
+
+// useStream returns true if the bodies of the stream should be forwarded in data frames
+func useStream(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	use, _ := mosnctx.Get(ctx, types.ContextKeyUseStream).(bool)
+	return use
+}
+
+// newBodyReader returns the reader of a body.
+// The contentLength is -1 for chunked body, and -2 for identity body which ends at connection close
+func newBodyReader(br *bufio.Reader, contentLength int) io.Reader {
+	switch {
+	case contentLength >= 0:
+		return io.LimitReader(br, int64(contentLength))
+	case contentLength == -1:
+		return &chunkedReader{
+			br:     br,
+			reader: httputil.NewChunkedReader(br),
+		}
+	default:
+		return br
+	}
+}
+
+// chunkedReader reads a chunked body, the trailers are discarded
+type chunkedReader struct {
+	br     *bufio.Reader
+	reader io.Reader
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if err == io.EOF {
+		for {
+			line, e := r.br.ReadSlice('\n')
+			if e != nil {
+				return n, e
+			}
+			// the empty line ends the trailers
+			if len(line) <= len(strCRLF) {
+				break
+			}
+		}
+	}
+	return n, err
+}
+
+// readBody reads the body in data frames, and passes them to the receiver.
+// The body size is not limited if maxBodySize is 0.
+// beforeEnd is called before the last frame is passed, if it is not nil
+func (s *stream) readBody(r io.Reader, receiver types.StreamDataReceiveListener, closed <-chan bool, maxBodySize int, beforeEnd func()) error {
+	// the frames are read into a pooled buffer, and copied to the pooled io buffers passed to the receiver
+	scratch := buffer.GetBytes(defaultStreamFrameSize)
+	defer buffer.PutBytes(scratch)
+	size := 0
+	for {
+		if err := s.waitReadEnable(closed); err != nil {
+			return err
+		}
+
+		n, err := r.Read(*scratch)
+		end := err == io.EOF
+		if err != nil && !end {
+			return err
+		}
+		size += n
+		if maxBodySize > 0 && size > maxBodySize {
+			return fasthttp.ErrBodyTooLarge
+		}
+		if end && beforeEnd != nil {
+			beforeEnd()
+		}
+		if n > 0 || end {
+			data := buffer.GetIoBuffer(n)
+			data.Write((*scratch)[:n])
+			receiver.OnReceiveData(s.ctx, data, end)
+		}
+		if end {
+			return nil
+		}
+	}
+}
+
+// waitReadEnable blocks until the stream is read enabled or the connection is closed
+func (s *stream) waitReadEnable(closed <-chan bool) error {
+	for atomic.LoadInt32(&s.readDisableCount) > 0 {
+		select {
+		case <-s.readEnable:
+		case <-closed:
+			return errConnClose
+		}
+	}
+	return nil
+}
+
+// resumeRead wakes up the body reading blocked by ReadDisable
+func (s *stream) resumeRead() {
+	select {
+	case s.readEnable <- struct{}{}:
+	default:
+	}
+}
+
+// writeBodyFrame writes a data frame, the last chunk is appended if the body is chunked and ends
+func writeBodyFrame(w io.Writer, data buffer.IoBuffer, chunked bool, endStream bool) error {
+	size := 0
+	if data != nil {
+		size = data.Len()
+	}
+	if !chunked {
+		if size == 0 {
+			return nil
+		}
+		_, err := w.Write(data.Bytes())
+		return err
+	}
+
+	frame := make([]byte, 0, size+32)
+	if size > 0 {
+		frame = strconv.AppendInt(frame, int64(size), 16)
+		frame = append(frame, strCRLF...)
+		frame = append(frame, data.Bytes()...)
+		frame = append(frame, strCRLF...)
+	}
+	if endStream {
+		frame = append(frame, strLastChunk...)
+	}
+	if len(frame) == 0 {
+		return nil
+	}
+	_, err := w.Write(frame)
+	return err
+}
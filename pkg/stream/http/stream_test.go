@@ -18,6 +18,8 @@
 package http
 
 import (
+	"bufio"
+	"context"
 	"testing"
 
 	"net"
@@ -31,6 +33,7 @@ import (
 	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/protocol/http"
 	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
 )
 
 func Test_clientStream_AppendHeaders(t *testing.T) {
@@ -277,3 +280,67 @@ func convertHeader(payload protocol.CommonHeader) http.RequestHeader {
 
 	return header
 }
+
+func Test_serverStream_AppendHeaders_DirectResponse(t *testing.T) {
+	s := &serverStream{
+		stream: stream{
+			response: fasthttp.AcquireResponse(),
+		},
+	}
+	headers := protocol.CommonHeader{
+		types.HeaderStatus:            "204",
+		"Access-Control-Allow-Origin": "https://a.example.com",
+	}
+	s.AppendHeaders(nil, headers, false)
+	if code := s.response.StatusCode(); code != 204 {
+		t.Errorf("expected status 204, but got %d", code)
+	}
+	if value := string(s.response.Header.Peek("Access-Control-Allow-Origin")); value != "https://a.example.com" {
+		t.Errorf("unexpected header value %s", value)
+	}
+	if value := s.response.Header.Peek(types.HeaderStatus); value != nil {
+		t.Errorf("unexpected internal header %s", value)
+	}
+}
+
+type mockDataReceiver struct {
+	size int
+	end  bool
+}
+
+func (r *mockDataReceiver) OnReceive(ctx context.Context, headers types.HeaderMap, data buffer.IoBuffer, trailers types.HeaderMap) {
+}
+
+func (r *mockDataReceiver) OnDecodeError(ctx context.Context, err error, headers types.HeaderMap) {
+}
+
+func (r *mockDataReceiver) OnReceiveHeaders(ctx context.Context, headers api.HeaderMap, endStream bool) {
+}
+
+func (r *mockDataReceiver) OnReceiveData(ctx context.Context, data buffer.IoBuffer, endStream bool) {
+	r.size += data.Len()
+	r.end = endStream
+}
+
+func Test_stream_readBody_limit(t *testing.T) {
+	body := bytes.Repeat([]byte("a"), 3*defaultStreamFrameSize)
+	s := &stream{readEnable: make(chan struct{}, 1)}
+
+	receiver := &mockDataReceiver{}
+	br := bufio.NewReader(bytes.NewReader(body))
+	if err := s.readBody(newBodyReader(br, -2), receiver, nil, len(body), nil); err != nil {
+		t.Fatalf("read body failed: %v", err)
+	}
+	if receiver.size != len(body) || !receiver.end {
+		t.Errorf("unexpected body received, size: %d, end: %v", receiver.size, receiver.end)
+	}
+
+	receiver = &mockDataReceiver{}
+	br = bufio.NewReader(bytes.NewReader(body))
+	if err := s.readBody(newBodyReader(br, -2), receiver, nil, len(body)-1, nil); err != fasthttp.ErrBodyTooLarge {
+		t.Errorf("expected body too large, but got %v", err)
+	}
+	if receiver.end {
+		t.Error("the body exceeds the limit should not be ended")
+	}
+}
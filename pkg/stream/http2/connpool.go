@@ -21,7 +21,9 @@ import (
 	"context"
 	"sync"
 	"sync/atomic"
+	"time"
 
+	metrics "github.com/rcrowley/go-metrics"
 	"mosn.io/api"
 	mosnctx "mosn.io/mosn/pkg/context"
 	"mosn.io/mosn/pkg/log"
@@ -31,26 +33,69 @@ import (
 	"mosn.io/mosn/pkg/types"
 )
 
+const (
+	Connected = iota
+	// Draining clients take no new streams, and are closed after the active streams finished
+	Draining
+	Closed
+)
+
+const defaultMaxConnectionsPerHost = 1
+
 func init() {
 	network.RegisterNewPoolFactory(protocol.HTTP2, NewConnPool)
 	types.RegisterConnPoolFactory(protocol.HTTP2, true)
 }
 
 // types.ConnectionPool
-// activeClient used as connected client
+// activeClients used as connected clients
 // host is the upstream
 type connPool struct {
-	activeClient *activeClient
-	host         types.Host
+	// clients is a copy-on-write []*activeClient modified with mux held,
+	// so the clients can be selected without lock
+	clients atomic.Value
+	host    types.Host
+
+	maxConnections uint32
+	maxStreams     uint32
+	maxAge         time.Duration
+	maxRequests    uint32
+	idleTimeout    time.Duration
+	maxIdle        uint32
 
 	mux sync.Mutex
 }
 
 // NewConnPool
 func NewConnPool(host types.Host) types.ConnectionPool {
-	return &connPool{
-		host: host,
+	p := &connPool{
+		host:           host,
+		maxConnections: defaultMaxConnectionsPerHost,
+	}
+	if info := host.ClusterInfo(); info != nil {
+		cfg := info.ConnPoolConfig()
+		if cfg.MaxConnectionsPerHost > 0 {
+			p.maxConnections = cfg.MaxConnectionsPerHost
+		}
+		p.maxStreams = cfg.MaxStreamsPerConnection
+		if cfg.MaxConnectionAge != nil {
+			p.maxAge = cfg.MaxConnectionAge.Duration
+		}
+		if cfg.IdleTimeout != nil {
+			p.idleTimeout = cfg.IdleTimeout.Duration
+		}
+		p.maxIdle = cfg.MaxIdleConnectionsPerHost
+		// the connections are not rotated by the requests if it is unlimited, 0 means no limit
+		if !cfg.UnlimitedRequestsPerConnection {
+			p.maxRequests = info.MaxRequestsPerConn()
+		}
 	}
+	return p
+}
+
+func (p *connPool) load() []*activeClient {
+	clients, _ := p.clients.Load().([]*activeClient)
+	return clients
 }
 
 func (p *connPool) SupportTLS() bool {
@@ -65,36 +110,121 @@ func (p *connPool) CheckAndInit(ctx context.Context) bool {
 	return true
 }
 
+// selectClient returns the connected client with least active streams,
+// or nil if all of the clients reach the max concurrent streams
+func (p *connPool) selectClient() *activeClient {
+	var selected *activeClient
+	var least uint32
+	for _, client := range p.load() {
+		if atomic.LoadUint32(&client.state) != Connected {
+			continue
+		}
+		streams := atomic.LoadUint32(&client.activeStreams)
+		if max := client.maxStreams(); max > 0 && streams >= max {
+			continue
+		}
+		if selected == nil || streams < least {
+			selected = client
+			least = streams
+		}
+	}
+	return selected
+}
+
+// getAvailableClient selects a client, a new connection is created if all of the clients are busy and the pool is not full.
+// The returned client is nil if the connection failed, and the pool is overflow if the returned client is nil and full is true
+func (p *connPool) getAvailableClient(ctx context.Context) (client *activeClient, full bool) {
+	if client = p.selectClient(); client != nil {
+		return client, false
+	}
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	// the clients may be changed before the lock held
+	if client = p.selectClient(); client != nil {
+		return client, false
+	}
+	clients := p.load()
+	if uint32(len(clients)) >= p.maxConnections {
+		return nil, true
+	}
+
+	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+		log.DefaultLogger.Debugf("[stream] [http2] [connpool] new connection to %s, active connections: %d", p.host.AddressString(), len(clients))
+	}
+	client = newActiveClient(ctx, p)
+	if client == nil {
+		return nil, false
+	}
+	newClients := make([]*activeClient, 0, len(clients)+1)
+	newClients = append(newClients, clients...)
+	p.clients.Store(append(newClients, client))
+	return client, false
+}
+
+// removeClient removes the client from the pool, new streams will not be created on it
+func (p *connPool) removeClient(client *activeClient) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	clients := p.load()
+	for i, c := range clients {
+		if c == client {
+			newClients := make([]*activeClient, 0, len(clients)-1)
+			newClients = append(newClients, clients[:i]...)
+			p.clients.Store(append(newClients, clients[i+1:]...))
+			return
+		}
+	}
+}
+
 func (p *connPool) NewStream(ctx context.Context,
 	responseDecoder types.StreamReceiveListener, listener types.PoolEventListener) {
 
-	activeClient := func() *activeClient {
-		p.mux.Lock()
-		defer p.mux.Unlock()
-		if p.activeClient == nil {
-			p.activeClient = newActiveClient(ctx, p)
+	var activeClient *activeClient
+	for {
+		var full bool
+		activeClient, full = p.getAvailableClient(ctx)
+		if activeClient == nil {
+			if full {
+				listener.OnFailure(types.Overflow, p.host)
+				p.host.HostStats().UpstreamConnectionPoolOverflow.Inc(1)
+				p.host.ClusterInfo().Stats().UpstreamConnectionPoolOverflow.Inc(1)
+			} else {
+				listener.OnFailure(types.ConnectionFailure, p.host)
+			}
+			return
 		}
-		return p.activeClient
-	}()
-
-	if activeClient == nil {
-		listener.OnFailure(types.ConnectionFailure, p.host)
-		return
+		// hold a stream before checking the state, so a draining client will not be closed with the new stream
+		atomic.AddUint32(&activeClient.activeStreams, 1)
+		if atomic.LoadUint32(&activeClient.state) == Connected {
+			break
+		}
+		activeClient.releaseStream()
 	}
 
-	if !p.host.ClusterInfo().ResourceManager().Requests().CanCreate() {
+	requests := str.RequestsResource(ctx, p.host)
+	if !requests.CanCreate() {
+		activeClient.releaseStream()
 		listener.OnFailure(types.Overflow, p.host)
 		p.host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
 	} else {
-		atomic.AddUint64(&activeClient.totalStream, 1)
+		total := atomic.AddUint64(&activeClient.totalStream, 1)
 		p.host.HostStats().UpstreamRequestTotal.Inc(1)
 		p.host.HostStats().UpstreamRequestActive.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamRequestTotal.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamRequestActive.Inc(1)
-		p.host.ClusterInfo().ResourceManager().Requests().Increase()
+		requests.Increase()
 		streamEncoder := activeClient.client.NewStream(ctx, responseDecoder)
 		streamEncoder.GetStream().AddEventListener(activeClient)
+		streamEncoder.GetStream().AddEventListener(&str.ResourceReleaser{Resource: requests})
+
+		// the client reaches the max requests is closed after the active streams finished
+		if p.maxRequests > 0 && total >= uint64(p.maxRequests) {
+			p.drain(activeClient, p.host.HostStats().UpstreamConnectionCloseMaxRequests, p.host.ClusterInfo().Stats().UpstreamConnectionCloseMaxRequests)
+		}
 
 		listener.OnReady(streamEncoder, p.host)
 	}
@@ -102,9 +232,43 @@ func (p *connPool) NewStream(ctx context.Context,
 	return
 }
 
+// drain stops new streams on the client, the client is closed after the active streams finished.
+// A new connection is created by the following streams if needed
+func (p *connPool) drain(client *activeClient, closed, clusterClosed metrics.Counter) {
+	if !atomic.CompareAndSwapUint32(&client.state, Connected, Draining) {
+		return
+	}
+	p.removeClient(client)
+	closed.Inc(1)
+	clusterClosed.Inc(1)
+	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+		log.DefaultLogger.Debugf("[stream] [http2] [connpool] drain connection to %s after %d streams",
+			p.host.AddressString(), atomic.LoadUint64(&client.totalStream))
+	}
+	if atomic.LoadUint32(&client.activeStreams) == 0 {
+		client.closeDrained()
+	}
+}
+
+// onClientIdle drains the idle client if there are more than max idle clients
+func (p *connPool) onClientIdle(client *activeClient) {
+	if p.maxIdle == 0 {
+		return
+	}
+	var idle uint32
+	for _, c := range p.load() {
+		if atomic.LoadUint32(&c.state) == Connected && atomic.LoadUint32(&c.activeStreams) == 0 {
+			idle++
+		}
+	}
+	if idle > p.maxIdle {
+		p.drain(client, p.host.HostStats().UpstreamConnectionCloseMaxIdle, p.host.ClusterInfo().Stats().UpstreamConnectionCloseMaxIdle)
+	}
+}
+
 func (p *connPool) Close() {
-	if p.activeClient != nil {
-		p.activeClient.client.Close()
+	for _, ac := range p.load() {
+		ac.client.Close()
 	}
 }
 
@@ -116,6 +280,11 @@ func (p *connPool) onConnectionEvent(client *activeClient, event api.ConnectionE
 	// event.ConnectFailure() contains types.ConnectTimeout and types.ConnectTimeout
 	log.DefaultLogger.Debugf("http2 connPool onConnectionEvent: %v", event)
 	if event.IsClose() {
+		p.host.HostStats().UpstreamConnectionClose.Inc(1)
+		p.host.HostStats().UpstreamConnectionActive.Dec(1)
+		p.host.ClusterInfo().Stats().UpstreamConnectionClose.Inc(1)
+		p.host.ClusterInfo().Stats().UpstreamConnectionActive.Dec(1)
+
 		if client.closeWithActiveReq {
 			if event == api.LocalClose {
 				p.host.HostStats().UpstreamConnectionLocalCloseWithActiveRequest.Inc(1)
@@ -125,23 +294,23 @@ func (p *connPool) onConnectionEvent(client *activeClient, event api.ConnectionE
 				p.host.ClusterInfo().Stats().UpstreamConnectionRemoteCloseWithActiveRequest.Inc(1)
 			}
 		}
-		p.activeClient = nil
+		atomic.StoreUint32(&client.state, Closed)
+		client.stopTimers()
+		p.removeClient(client)
 	} else if event == api.ConnectTimeout {
 		p.host.HostStats().UpstreamRequestTimeout.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamRequestTimeout.Inc(1)
 		client.client.Close()
-		p.activeClient = nil
 	} else if event == api.ConnectFailed {
 		p.host.HostStats().UpstreamConnectionConFail.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamConnectionConFail.Inc(1)
-		p.activeClient = nil
 	}
 }
 
 func (p *connPool) onStreamDestroy(client *activeClient) {
+	client.releaseStream()
 	p.host.HostStats().UpstreamRequestActive.Dec(1)
 	p.host.ClusterInfo().Stats().UpstreamRequestActive.Dec(1)
-	p.host.ClusterInfo().ResourceManager().Requests().Decrease()
 }
 
 func (p *connPool) onStreamReset(client *activeClient, reason types.StreamResetReason) {
@@ -171,11 +340,18 @@ type activeClient struct {
 	host               types.CreateConnectionData
 	closeWithActiveReq bool
 	totalStream        uint64
+	activeStreams      uint32
+	state              uint32
+	ageTimer           *time.Timer
+	// idleAt is the UnixNano time of the last stream finished
+	idleAt    int64
+	idleTimer *time.Timer
 }
 
 func newActiveClient(ctx context.Context, pool *connPool) *activeClient {
 	ac := &activeClient{
-		pool: pool,
+		pool:   pool,
+		idleAt: time.Now().UnixNano(),
 	}
 
 	data := pool.host.CreateConnection(ctx)
@@ -199,9 +375,77 @@ func newActiveClient(ctx context.Context, pool *connPool) *activeClient {
 	// bytes total adds all connections data together, but buffered data not
 	codecClient.SetConnectionCollector(pool.host.ClusterInfo().Stats().UpstreamBytesReadTotal, pool.host.ClusterInfo().Stats().UpstreamBytesWriteTotal)
 
+	if pool.maxAge > 0 {
+		ac.ageTimer = time.AfterFunc(pool.maxAge, func() {
+			pool.drain(ac, pool.host.HostStats().UpstreamConnectionCloseMaxAge, pool.host.ClusterInfo().Stats().UpstreamConnectionCloseMaxAge)
+		})
+	}
+	if pool.idleTimeout > 0 {
+		ac.idleTimer = time.AfterFunc(pool.idleTimeout, ac.onIdleTimeout)
+	}
+
 	return ac
 }
 
+// maxStreams returns the max concurrent streams of the client, limited by both the config and the peer's settings
+func (ac *activeClient) maxStreams() uint32 {
+	max := ac.pool.maxStreams
+	if limit, ok := ac.client.(types.StreamLimitConnection); ok {
+		if peer := limit.MaxConcurrentStreams(); peer > 0 && (max == 0 || peer < max) {
+			max = peer
+		}
+	}
+	return max
+}
+
+// releaseStream decreases the active streams, and closes the client if it is drained
+func (ac *activeClient) releaseStream() {
+	if atomic.AddUint32(&ac.activeStreams, ^uint32(0)) != 0 {
+		return
+	}
+	switch atomic.LoadUint32(&ac.state) {
+	case Draining:
+		ac.closeDrained()
+	case Connected:
+		atomic.StoreInt64(&ac.idleAt, time.Now().UnixNano())
+		ac.pool.onClientIdle(ac)
+	}
+}
+
+// onIdleTimeout drains the client without active streams for the idle timeout, or rearms the timer.
+// The timer is only reset here, so it is not reset concurrently
+func (ac *activeClient) onIdleTimeout() {
+	if atomic.LoadUint32(&ac.state) != Connected {
+		return
+	}
+	timeout := ac.pool.idleTimeout
+	if atomic.LoadUint32(&ac.activeStreams) > 0 {
+		ac.idleTimer.Reset(timeout)
+		return
+	}
+	if idle := time.Duration(time.Now().UnixNano() - atomic.LoadInt64(&ac.idleAt)); idle < timeout {
+		ac.idleTimer.Reset(timeout - idle)
+		return
+	}
+	ac.pool.drain(ac, ac.pool.host.HostStats().UpstreamConnectionCloseIdle, ac.pool.host.ClusterInfo().Stats().UpstreamConnectionCloseIdle)
+}
+
+// closeDrained closes a draining client, the pending writes are flushed
+func (ac *activeClient) closeDrained() {
+	if atomic.CompareAndSwapUint32(&ac.state, Draining, Closed) {
+		ac.host.Connection.Close(api.FlushWrite, api.LocalClose)
+	}
+}
+
+func (ac *activeClient) stopTimers() {
+	if ac.ageTimer != nil {
+		ac.ageTimer.Stop()
+	}
+	if ac.idleTimer != nil {
+		ac.idleTimer.Stop()
+	}
+}
+
 func (ac *activeClient) OnEvent(event api.ConnectionEvent) {
 	ac.pool.onConnectionEvent(ac, event)
 }
@@ -216,5 +460,7 @@ func (ac *activeClient) OnResetStream(reason types.StreamResetReason) {
 }
 
 // types.StreamConnectionEventListener
-// todo: support http2 goaway
-func (ac *activeClient) OnGoAway() {}
+// the connection received goaway takes no new streams, and is closed after the processed streams finished
+func (ac *activeClient) OnGoAway() {
+	ac.pool.drain(ac, ac.pool.host.HostStats().UpstreamConnectionCloseGoAway, ac.pool.host.ClusterInfo().Stats().UpstreamConnectionCloseGoAway)
+}
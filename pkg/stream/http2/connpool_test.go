@@ -0,0 +1,316 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http2
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/module/http2"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/buffer"
+)
+
+// rawServer is a http2 server sends the settings, and ignores the frames received
+type rawServer struct {
+	listener             net.Listener
+	maxConcurrentStreams uint32
+	framers              chan *http2.Framer
+}
+
+func newRawServer(t *testing.T, maxConcurrentStreams uint32) *rawServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &rawServer{
+		listener:             ln,
+		maxConcurrentStreams: maxConcurrentStreams,
+		framers:              make(chan *http2.Framer, 10),
+	}
+	go s.serve()
+	return s
+}
+
+func (s *rawServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			preface := make([]byte, len(http2.ClientPreface))
+			if _, err := io.ReadFull(conn, preface); err != nil {
+				conn.Close()
+				return
+			}
+			framer := http2.NewFramer(conn, conn)
+			framer.WriteSettings(http2.Setting{ID: http2.SettingMaxConcurrentStreams, Val: s.maxConcurrentStreams})
+			s.framers <- framer
+			for {
+				if _, err := framer.ReadFrame(); err != nil {
+					conn.Close()
+					return
+				}
+			}
+		}()
+	}
+}
+
+func (s *rawServer) Close() {
+	s.listener.Close()
+}
+
+type poolListener struct {
+	reason types.PoolFailureReason
+	sender types.StreamSender
+}
+
+func (l *poolListener) OnFailure(reason types.PoolFailureReason, host types.Host) {
+	l.reason = reason
+}
+
+func (l *poolListener) OnReady(sender types.StreamSender, host types.Host) {
+	l.sender = sender
+}
+
+type noopReceiver struct{}
+
+func (r *noopReceiver) OnReceive(ctx context.Context, headers api.HeaderMap, data buffer.IoBuffer, trailers api.HeaderMap) {
+}
+
+func (r *noopReceiver) OnDecodeError(ctx context.Context, err error, headers api.HeaderMap) {}
+
+func newTestPool(t *testing.T, cfg v2.Cluster, maxConcurrentStreams uint32) (*connPool, *rawServer) {
+	srv := newRawServer(t, maxConcurrentStreams)
+	cfg.Name = "test"
+	cfg.ClusterType = v2.SIMPLE_CLUSTER
+	cfg.LbType = v2.LB_ROUNDROBIN
+	c := cluster.NewCluster(cfg)
+	host := cluster.NewSimpleHost(v2.Host{
+		HostConfig: v2.HostConfig{
+			Address:    srv.listener.Addr().String(),
+			TLSDisable: true,
+		},
+	}, c.Snapshot().ClusterInfo())
+	return NewConnPool(host).(*connPool), srv
+}
+
+func newTestStream(t *testing.T, p *connPool) *poolListener {
+	l := &poolListener{}
+	p.NewStream(context.Background(), &noopReceiver{}, l)
+	if l.sender == nil {
+		t.Fatalf("stream is not created, failure reason: %s", l.reason)
+	}
+	return l
+}
+
+// waitSettings waits until the settings of the peer is received by all the clients
+func waitSettings(t *testing.T, p *connPool, maxConcurrentStreams uint32) {
+	for i := 0; i < 100; i++ {
+		received := true
+		for _, c := range p.load() {
+			if c.maxStreams() != maxConcurrentStreams {
+				received = false
+			}
+		}
+		if received {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("wait settings timeout")
+}
+
+func waitState(t *testing.T, client *activeClient, state uint32) {
+	for i := 0; i < 100; i++ {
+		if atomic.LoadUint32(&client.state) == state {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("wait client state %d timeout, got %d", state, atomic.LoadUint32(&client.state))
+}
+
+func TestConnPoolMaxConcurrentStreams(t *testing.T) {
+	p, srv := newTestPool(t, v2.Cluster{
+		ConnPool: v2.ConnPoolConfig{
+			MaxConnectionsPerHost: 2,
+		},
+	}, 1)
+	defer srv.Close()
+	defer p.Close()
+
+	newTestStream(t, p)
+	waitSettings(t, p, 1)
+	// the first connection reaches the peer's max concurrent streams
+	newTestStream(t, p)
+	if clients := p.load(); len(clients) != 2 {
+		t.Fatalf("expected 2 connections, but got %d", len(clients))
+	}
+	waitSettings(t, p, 1)
+	for _, c := range p.load() {
+		if streams := atomic.LoadUint32(&c.activeStreams); streams != 1 {
+			t.Fatalf("expected 1 active stream on each connection, but got %d", streams)
+		}
+	}
+	// all the connections are busy
+	l := &poolListener{}
+	p.NewStream(context.Background(), &noopReceiver{}, l)
+	if l.sender != nil || l.reason != types.Overflow {
+		t.Fatalf("expected overflow, but got %s", l.reason)
+	}
+	if cnt := p.host.HostStats().UpstreamConnectionPoolOverflow.Count(); cnt != 1 {
+		t.Fatalf("expected pool overflow count 1, but got %d", cnt)
+	}
+}
+
+func TestConnPoolGoAway(t *testing.T) {
+	p, srv := newTestPool(t, v2.Cluster{}, 100)
+	defer srv.Close()
+	defer p.Close()
+
+	l := newTestStream(t, p)
+	first := p.load()[0]
+	framer := <-srv.framers
+	framer.WriteGoAway(0, http2.ErrCodeNo, nil)
+
+	// the connection takes no new streams after goaway
+	waitState(t, first, Draining)
+	newTestStream(t, p)
+	if clients := p.load(); len(clients) != 1 || clients[0] == first {
+		t.Fatalf("expected a new connection after goaway, clients: %d", len(clients))
+	}
+	// the draining connection is closed after the active streams finished
+	l.sender.GetStream().ResetStream(types.StreamLocalReset)
+	waitState(t, first, Closed)
+	if cnt := p.host.HostStats().UpstreamConnectionCloseGoAway.Count(); cnt != 1 {
+		t.Fatalf("expected close goaway count 1, but got %d", cnt)
+	}
+}
+
+func TestConnPoolMaxConnectionAge(t *testing.T) {
+	p, srv := newTestPool(t, v2.Cluster{
+		ConnPool: v2.ConnPoolConfig{
+			MaxConnectionAge: &api.DurationConfig{Duration: 100 * time.Millisecond},
+		},
+	}, 100)
+	defer srv.Close()
+	defer p.Close()
+
+	l := newTestStream(t, p)
+	first := p.load()[0]
+	waitState(t, first, Draining)
+	newTestStream(t, p)
+	if clients := p.load(); len(clients) != 1 || clients[0] == first {
+		t.Fatalf("expected a new connection after max age, clients: %d", len(clients))
+	}
+	l.sender.GetStream().ResetStream(types.StreamLocalReset)
+	waitState(t, first, Closed)
+	if cnt := p.host.HostStats().UpstreamConnectionCloseMaxAge.Count(); cnt != 1 {
+		t.Fatalf("expected close max age count 1, but got %d", cnt)
+	}
+}
+
+func TestConnPoolMaxRequests(t *testing.T) {
+	p, srv := newTestPool(t, v2.Cluster{
+		MaxRequestPerConn: 2,
+	}, 100)
+	defer srv.Close()
+	defer p.Close()
+
+	l := newTestStream(t, p)
+	first := p.load()[0]
+	newTestStream(t, p)
+	// the connection reaches the max requests is draining
+	waitState(t, first, Draining)
+	newTestStream(t, p)
+	if clients := p.load(); len(clients) != 1 || clients[0] == first {
+		t.Fatalf("expected a new connection after max requests, clients: %d", len(clients))
+	}
+	if cnt := p.host.HostStats().UpstreamConnectionCloseMaxRequests.Count(); cnt != 1 {
+		t.Fatalf("expected close max requests count 1, but got %d", cnt)
+	}
+	l.sender.GetStream().ResetStream(types.StreamLocalReset)
+	if state := atomic.LoadUint32(&first.state); state != Draining {
+		t.Fatalf("expected the connection with active streams is draining, but got %d", state)
+	}
+}
+
+func TestConnPoolIdleTimeout(t *testing.T) {
+	p, srv := newTestPool(t, v2.Cluster{
+		ConnPool: v2.ConnPoolConfig{
+			IdleTimeout: &api.DurationConfig{Duration: 200 * time.Millisecond},
+		},
+	}, 100)
+	defer srv.Close()
+	defer p.Close()
+
+	l := newTestStream(t, p)
+	first := p.load()[0]
+	// the connection with active streams is not idle
+	time.Sleep(300 * time.Millisecond)
+	if state := atomic.LoadUint32(&first.state); state != Connected {
+		t.Fatalf("expected the connection is connected, but got %d", state)
+	}
+	l.sender.GetStream().ResetStream(types.StreamLocalReset)
+	waitState(t, first, Closed)
+	if cnt := p.host.HostStats().UpstreamConnectionCloseIdle.Count(); cnt != 1 {
+		t.Fatalf("expected close idle count 1, but got %d", cnt)
+	}
+	if clients := p.load(); len(clients) != 0 {
+		t.Fatalf("expected no connections after idle timeout, clients: %d", len(clients))
+	}
+}
+
+func TestConnPoolMaxIdle(t *testing.T) {
+	p, srv := newTestPool(t, v2.Cluster{
+		ConnPool: v2.ConnPoolConfig{
+			MaxConnectionsPerHost:     2,
+			MaxIdleConnectionsPerHost: 1,
+		},
+	}, 1)
+	defer srv.Close()
+	defer p.Close()
+
+	l1 := newTestStream(t, p)
+	waitSettings(t, p, 1)
+	l2 := newTestStream(t, p)
+	waitSettings(t, p, 1)
+	clients := p.load()
+	if len(clients) != 2 {
+		t.Fatalf("expected 2 connections, but got %d", len(clients))
+	}
+	l1.sender.GetStream().ResetStream(types.StreamLocalReset)
+	l2.sender.GetStream().ResetStream(types.StreamLocalReset)
+	// only one idle connection is kept
+	waitState(t, clients[1], Closed)
+	if state := atomic.LoadUint32(&clients[0].state); state != Connected {
+		t.Fatalf("expected the first idle connection is kept, but got %d", state)
+	}
+	if cnt := p.host.HostStats().UpstreamConnectionCloseMaxIdle.Count(); cnt != 1 {
+		t.Fatalf("expected close max idle count 1, but got %d", cnt)
+	}
+}
@@ -192,6 +192,39 @@ func newServerStreamConnection(ctx context.Context, connection api.Connection, s
 	return sc
 }
 
+// NewUpgradedServerStreamConnection creates a server stream connection on a connection upgraded from HTTP/1.1 by 'Upgrade: h2c'.
+// The upgrade request is served as stream 1, its body is read already and the settings are decoded from the HTTP2-Settings header.
+// The following data of the connection, begins with the client preface, should be dispatched to the returned stream connection
+func NewUpgradedServerStreamConnection(ctx context.Context, connection api.Connection, serverCallbacks types.ServerStreamConnectionEventListener,
+	req *http.Request, body types.IoBuffer, settings []http2.Setting) (types.ServerStreamConnection, error) {
+	conn := newServerStreamConnection(ctx, connection, serverCallbacks).(*serverStreamConnection)
+
+	// the server connection preface is the first frame sent after the 101 response
+	if err := conn.sc.Init(); err != nil {
+		return nil, err
+	}
+	h2s, err := conn.sc.UpgradeStream(req, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	streamCtx := conn.cm.Get()
+	stream, err := conn.onNewStreamDetect(streamCtx, h2s, true)
+	if err != nil {
+		return nil, err
+	}
+	header := conn.requestHeader(h2s)
+	conn.cm.Next()
+
+	log.Proxy.Debugf(stream.ctx, "http2 server upgrade header: %d, %+v", stream.id, h2s.Request.Header)
+	if body != nil && body.Len() == 0 {
+		body = nil
+	}
+	stream.receiver.OnReceive(streamCtx, header, body, nil)
+
+	return conn, nil
+}
+
 // types.StreamConnectionM
 func (conn *serverStreamConnection) Dispatch(buf types.IoBuffer) {
 	for {
@@ -276,28 +309,7 @@ func (conn *serverStreamConnection) handleFrame(ctx context.Context, i interface
 			return
 		}
 
-		header := mhttp2.NewReqHeader(h2s.Request)
-
-		scheme := "http"
-		if _, ok := conn.conn.RawConn().(*mtls.TLSConn); ok {
-			scheme = "https"
-		}
-		var URI string
-		if h2s.Request.URL.RawQuery == "" {
-			URI = fmt.Sprintf(scheme+"://%s%s", h2s.Request.Host, h2s.Request.URL.Path)
-		} else {
-			URI = fmt.Sprintf(scheme+"://%s%s?%s", h2s.Request.Host, h2s.Request.URL.Path, h2s.Request.URL.RawQuery)
-
-		}
-		URL, _ := url.Parse(URI)
-		h2s.Request.URL = URL
-
-		header.Set(protocol.MosnHeaderMethod, h2s.Request.Method)
-		header.Set(protocol.MosnHeaderHostKey, h2s.Request.Host)
-		header.Set(protocol.MosnHeaderPathKey, h2s.Request.URL.Path)
-		if h2s.Request.URL.RawQuery != "" {
-			header.Set(protocol.MosnHeaderQueryStringKey, h2s.Request.URL.RawQuery)
-		}
+		header := conn.requestHeader(h2s)
 
 		log.Proxy.Debugf(stream.ctx, "http2 server header: %d, %+v", id, h2s.Request.Header)
 
@@ -344,6 +356,33 @@ func (conn *serverStreamConnection) handleFrame(ctx context.Context, i interface
 	}
 }
 
+// requestHeader returns the headers of a new stream
+func (conn *serverStreamConnection) requestHeader(h2s *http2.MStream) api.HeaderMap {
+	header := mhttp2.NewReqHeader(h2s.Request)
+
+	scheme := "http"
+	if _, ok := conn.conn.RawConn().(*mtls.TLSConn); ok {
+		scheme = "https"
+	}
+	var URI string
+	if h2s.Request.URL.RawQuery == "" {
+		URI = fmt.Sprintf(scheme+"://%s%s", h2s.Request.Host, h2s.Request.URL.Path)
+	} else {
+		URI = fmt.Sprintf(scheme+"://%s%s?%s", h2s.Request.Host, h2s.Request.URL.Path, h2s.Request.URL.RawQuery)
+
+	}
+	URL, _ := url.Parse(URI)
+	h2s.Request.URL = URL
+
+	header.Set(protocol.MosnHeaderMethod, h2s.Request.Method)
+	header.Set(protocol.MosnHeaderHostKey, h2s.Request.Host)
+	header.Set(protocol.MosnHeaderPathKey, h2s.Request.URL.Path)
+	if h2s.Request.URL.RawQuery != "" {
+		header.Set(protocol.MosnHeaderQueryStringKey, h2s.Request.URL.RawQuery)
+	}
+	return header
+}
+
 func (conn *serverStreamConnection) handleError(ctx context.Context, f http2.Frame, err error) {
 	conn.sc.HandleError(ctx, f, err)
 	if err != nil {
@@ -588,6 +627,10 @@ func (conn *clientStreamConnection) Reset(reason types.StreamResetReason) {
 	}
 }
 
+func (conn *clientStreamConnection) MaxConcurrentStreams() uint32 {
+	return conn.mClientConn.MaxConcurrentStreams()
+}
+
 func (conn *clientStreamConnection) NewStream(ctx context.Context, receiver types.StreamReceiveListener) types.StreamSender {
 	stream := &clientStream{}
 
@@ -617,6 +660,11 @@ func (conn *clientStreamConnection) handleFrame(ctx context.Context, i interface
 		return
 	}
 
+	if _, ok := f.(*http2.GoAwayFrame); ok {
+		conn.handleGoAway(ctx)
+		return
+	}
+
 	if rsp == nil && trailer == nil && data == nil && !endStream {
 		return
 	}
@@ -681,6 +729,31 @@ func (conn *clientStreamConnection) handleFrame(ctx context.Context, i interface
 	}
 }
 
+// handleGoAway resets the streams not processed by the peer, they can be retried on other connections.
+// The streams processed by the peer are finished before the connection closed
+func (conn *clientStreamConnection) handleGoAway(ctx context.Context) {
+	lastStreamID, _ := conn.mClientConn.GoAwayLastStreamID()
+	log.Proxy.Infof(ctx, "http2 client receive goaway, last stream id = %d", lastStreamID)
+
+	var unprocessed []*clientStream
+	conn.mutex.Lock()
+	for id, stream := range conn.streams {
+		if id > lastStreamID {
+			delete(conn.streams, id)
+			unprocessed = append(unprocessed, stream)
+		}
+	}
+	conn.mutex.Unlock()
+
+	for _, stream := range unprocessed {
+		stream.ResetStream(types.StreamConnectionFailed)
+	}
+
+	if conn.streamConnectionEventListener != nil {
+		conn.streamConnectionEventListener.OnGoAway()
+	}
+}
+
 func (conn *clientStreamConnection) handleError(ctx context.Context, f http2.Frame, err error) {
 	conn.mClientConn.HandleError(ctx, f, err)
 	if err != nil {
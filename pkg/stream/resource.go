@@ -0,0 +1,44 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package stream
+
+import (
+	"context"
+
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/types"
+)
+
+// RequestsResource returns the requests resource of the host's cluster, for the routing priority in the context
+func RequestsResource(ctx context.Context, host types.Host) types.Resource {
+	rm := host.ClusterInfo().ResourceManager()
+	if priority, ok := mosnctx.Get(ctx, types.ContextKeyRoutePriority).(types.RoutingPriority); ok && priority != types.DefaultPriority {
+		return rm.ForPriority(priority).Requests()
+	}
+	return rm.Requests()
+}
+
+// ResourceReleaser is a types.StreamEventListener, which decreases the resource when the stream is destroyed
+type ResourceReleaser struct {
+	Resource types.Resource
+}
+
+func (r *ResourceReleaser) OnResetStream(reason types.StreamResetReason) {}
+
+func (r *ResourceReleaser) OnDestroyStream() {
+	r.Resource.Decrease()
+}
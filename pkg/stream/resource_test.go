@@ -0,0 +1,75 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package stream
+
+import (
+	"context"
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+)
+
+type mockClusterInfo struct {
+	types.ClusterInfo
+	rm types.ResourceManager
+}
+
+func (ci *mockClusterInfo) ResourceManager() types.ResourceManager {
+	return ci.rm
+}
+
+type mockHost struct {
+	types.Host
+	info types.ClusterInfo
+}
+
+func (h *mockHost) ClusterInfo() types.ClusterInfo {
+	return h.info
+}
+
+func TestRequestsResource(t *testing.T) {
+	rm := cluster.NewResourceManager(v2.CircuitBreakers{
+		Thresholds: []v2.Thresholds{
+			{MaxRequests: 1},
+			{Priority: v2.RoutingPriorityHigh, MaxRequests: 1},
+		},
+	})
+	host := &mockHost{
+		info: &mockClusterInfo{rm: rm},
+	}
+	if r := RequestsResource(context.Background(), host); r != rm.Requests() {
+		t.Error("expected the requests resource of the default priority")
+	}
+	ctx := mosnctx.WithValue(context.Background(), types.ContextKeyRoutePriority, types.HighPriority)
+	requests := RequestsResource(ctx, host)
+	if requests != rm.ForPriority(types.HighPriority).Requests() {
+		t.Fatal("expected the requests resource of the high priority")
+	}
+	requests.Increase()
+	releaser := &ResourceReleaser{Resource: requests}
+	releaser.OnResetStream(types.StreamLocalReset)
+	if requests.CanCreate() {
+		t.Fatal("expected the requests overflow")
+	}
+	releaser.OnDestroyStream()
+	if !requests.CanCreate() {
+		t.Fatal("expected the request is released when the stream is destroyed")
+	}
+}
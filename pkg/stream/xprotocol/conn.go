@@ -34,6 +34,7 @@ import (
 	"mosn.io/mosn/pkg/stream"
 	"mosn.io/mosn/pkg/trace"
 	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/variable"
 )
 
 // types.DecodeFilter
@@ -319,6 +320,9 @@ func (sc *streamConn) handleRequest(ctx context.Context, frame xprotocol.XFrame,
 
 		frame.GetHeader().Set(types.HeaderRPCService, serviceName)
 		frame.GetHeader().Set(types.HeaderRPCMethod, methodName)
+		// the variables are matched by the filters like rbac, the headers may be set by the clients
+		variable.SetVariableValue(serverStream.ctx, types.VarRPCService, serviceName)
+		variable.SetVariableValue(serverStream.ctx, types.VarRPCMethod, methodName)
 
 		if log.Proxy.GetLogLevel() >= log.DEBUG {
 			log.Proxy.Debugf(ctx, "[stream] [xprotocol] frame service aware, requestId = %v, serviceName = %v , methodName = %v", serverStream.id, serviceName, methodName)
@@ -37,8 +37,13 @@ const (
 	Init = iota
 	Connecting
 	Connected
+	// Draining clients take no new streams, and are closed after the active streams finished
+	Draining
+	Closed
 )
 
+const defaultMaxConnectionsPerHost = 1
+
 func init() {
 	network.RegisterNewPoolFactory(protocol.Xprotocol, NewConnPool)
 	types.RegisterConnPoolFactory(protocol.Xprotocol, true)
@@ -48,16 +53,47 @@ func init() {
 // activeClient used as connected client
 // host is the upstream
 type connPool struct {
-	activeClients sync.Map //sub protocol -> activeClient
+	activeClients sync.Map //sub protocol -> *clientGroup
 	host          types.Host
 
+	maxConnections uint32
+	maxStreams     uint32
+	maxRequests    uint32
+
 	mux sync.Mutex
 }
 
+// clientGroup is the clients of a sub protocol.
+// clients is a copy-on-write []*activeClient modified with the pool's mux held,
+// so the clients can be selected without lock
+type clientGroup struct {
+	subProtocol types.ProtocolName
+	clients     atomic.Value
+	// only one connection is being created at a time
+	connecting uint32
+}
+
+func (g *clientGroup) load() []*activeClient {
+	clients, _ := g.clients.Load().([]*activeClient)
+	return clients
+}
+
 // NewConnPool
 func NewConnPool(host types.Host) types.ConnectionPool {
 	p := &connPool{
-		host: host,
+		host:           host,
+		maxConnections: defaultMaxConnectionsPerHost,
+	}
+	if info := host.ClusterInfo(); info != nil {
+		cfg := info.ConnPoolConfig()
+		if cfg.MaxConnectionsPerHost > 0 {
+			p.maxConnections = cfg.MaxConnectionsPerHost
+		}
+		p.maxStreams = cfg.MaxStreamsPerConnection
+		// the connections are not rotated by the requests if it is unlimited, 0 means no limit
+		if !cfg.UnlimitedRequestsPerConnection {
+			p.maxRequests = info.MaxRequestsPerConn()
+		}
 	}
 	return p
 }
@@ -66,47 +102,128 @@ func (p *connPool) SupportTLS() bool {
 	return p.host.SupportTLS()
 }
 
-func (p *connPool) init(client *activeClient, sub types.ProtocolName) {
+func (p *connPool) group(sub types.ProtocolName) *clientGroup {
+	if v, ok := p.activeClients.Load(sub); ok {
+		return v.(*clientGroup)
+	}
+	g := &clientGroup{
+		subProtocol: sub,
+	}
+	v, _ := p.activeClients.LoadOrStore(sub, g)
+	return v.(*clientGroup)
+}
+
+// tryConnect creates a new connection in background if the group is not full and no connection is being created.
+// Expired clients are not counted, and one of them is drained when the new connection is connected
+func (p *connPool) tryConnect(g *clientGroup) {
+	var clients uint32
+	for _, client := range g.load() {
+		if atomic.LoadUint32(&client.expired) == 0 {
+			clients++
+		}
+	}
+	if clients >= p.maxConnections {
+		return
+	}
+	if !atomic.CompareAndSwapUint32(&g.connecting, 0, 1) {
+		return
+	}
 	utils.GoWithRecover(func() {
+		defer atomic.StoreUint32(&g.connecting, 0)
+
 		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
-			log.DefaultLogger.Debugf("[stream] [sofarpc] [connpool] init host %s", p.host.AddressString())
+			log.DefaultLogger.Debugf("[stream] [xprotocol] [connpool] init host %s", p.host.AddressString())
 		}
 
+		client := newActiveClient(context.Background(), g.subProtocol, p)
+		if client == nil {
+			return
+		}
+		var expired *activeClient
 		p.mux.Lock()
-		defer p.mux.Unlock()
-		client := newActiveClient(context.Background(), sub, p)
-		if client != nil {
-			client.state = Connected
-			p.activeClients.Store(sub, client)
-		} else {
-			p.activeClients.Delete(sub)
+		clients := g.load()
+		// the connection may be closed during connecting
+		if atomic.LoadUint32(&client.state) != Connected {
+			p.mux.Unlock()
+			return
+		}
+		newClients := make([]*activeClient, 0, len(clients)+1)
+		for _, c := range clients {
+			if expired == nil && atomic.LoadUint32(&c.expired) == 1 {
+				expired = c
+			}
+			newClients = append(newClients, c)
+		}
+		g.clients.Store(append(newClients, client))
+		p.mux.Unlock()
+
+		if expired != nil {
+			p.drain(expired)
 		}
 	}, nil)
 }
 
-func (p *connPool) CheckAndInit(ctx context.Context) bool {
-	var client *activeClient
-
-	subProtocol := getSubProtocol(ctx)
-
-	v, ok := p.activeClients.Load(subProtocol)
+// removeClient removes the client from its group, new streams will not be created on it
+func (p *connPool) removeClient(client *activeClient) {
+	v, ok := p.activeClients.Load(client.subProtocol)
 	if !ok {
-		fakeclient := &activeClient{}
-		fakeclient.state = Init
-		v, _ := p.activeClients.LoadOrStore(subProtocol, fakeclient)
-		client = v.(*activeClient)
-	} else {
-		client = v.(*activeClient)
+		return
 	}
-
-	if atomic.LoadUint32(&client.state) == Connected {
-		return true
+	g := v.(*clientGroup)
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	clients := g.load()
+	for i, c := range clients {
+		if c == client {
+			newClients := make([]*activeClient, 0, len(clients)-1)
+			newClients = append(newClients, clients[:i]...)
+			g.clients.Store(append(newClients, clients[i+1:]...))
+			return
+		}
 	}
+}
 
-	if atomic.CompareAndSwapUint32(&client.state, Init, Connecting) {
-		p.init(client, subProtocol)
+// selectClient returns the connected client with least active streams, or nil if all of the clients reach the max streams.
+// The expired clients are selected only if no other client is available
+func (p *connPool) selectClient(g *clientGroup) *activeClient {
+	var selected, selectedExpired *activeClient
+	var least, leastExpired uint32
+	for _, client := range g.load() {
+		if atomic.LoadUint32(&client.state) != Connected {
+			continue
+		}
+		streams := atomic.LoadUint32(&client.activeStreams)
+		if p.maxStreams > 0 && streams >= p.maxStreams {
+			continue
+		}
+		if atomic.LoadUint32(&client.expired) == 1 {
+			if selectedExpired == nil || streams < leastExpired {
+				selectedExpired = client
+				leastExpired = streams
+			}
+		} else if selected == nil || streams < least {
+			selected = client
+			least = streams
+		}
+	}
+	if selected == nil {
+		return selectedExpired
 	}
+	return selected
+}
+
+func (p *connPool) CheckAndInit(ctx context.Context) bool {
+	g := p.group(getSubProtocol(ctx))
+
+	// connections are created one by one until reach the max connections
+	p.tryConnect(g)
 
+	for _, client := range g.load() {
+		if atomic.LoadUint32(&client.state) == Connected {
+			return true
+		}
+	}
 	return false
 }
 
@@ -116,27 +233,41 @@ func (p *connPool) Protocol() types.ProtocolName {
 
 func (p *connPool) NewStream(ctx context.Context,
 	responseDecoder types.StreamReceiveListener, listener types.PoolEventListener) {
-	subProtocol := getSubProtocol(ctx)
-
-	client, _ := p.activeClients.Load(subProtocol)
+	g := p.group(getSubProtocol(ctx))
 
-	if client == nil {
+	if len(g.load()) == 0 {
+		p.tryConnect(g)
 		listener.OnFailure(types.ConnectionFailure, p.host)
 		return
 	}
 
-	activeClient := client.(*activeClient)
-	if atomic.LoadUint32(&activeClient.state) != Connected {
-		listener.OnFailure(types.ConnectionFailure, p.host)
-		return
+	var activeClient *activeClient
+	for {
+		activeClient = p.selectClient(g)
+		if activeClient == nil {
+			// all the connections are busy
+			p.tryConnect(g)
+			listener.OnFailure(types.Overflow, p.host)
+			p.host.HostStats().UpstreamConnectionPoolOverflow.Inc(1)
+			p.host.ClusterInfo().Stats().UpstreamConnectionPoolOverflow.Inc(1)
+			return
+		}
+		// hold a stream before checking the state, so a draining client will not be closed with the new stream
+		atomic.AddUint32(&activeClient.activeStreams, 1)
+		if atomic.LoadUint32(&activeClient.state) == Connected {
+			break
+		}
+		activeClient.releaseStream()
 	}
 
-	if !p.host.ClusterInfo().ResourceManager().Requests().CanCreate() {
+	requests := str.RequestsResource(ctx, p.host)
+	if !requests.CanCreate() {
+		activeClient.releaseStream()
 		listener.OnFailure(types.Overflow, p.host)
 		p.host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
 	} else {
-		atomic.AddUint64(&activeClient.totalStream, 1)
+		total := atomic.AddUint64(&activeClient.totalStream, 1)
 		p.host.HostStats().UpstreamRequestTotal.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamRequestTotal.Inc(1)
 
@@ -144,13 +275,21 @@ func (p *connPool) NewStream(ctx context.Context,
 		// oneway
 		if responseDecoder == nil {
 			streamEncoder = activeClient.client.NewStream(ctx, nil)
+			activeClient.releaseStream()
 		} else {
 			streamEncoder = activeClient.client.NewStream(ctx, responseDecoder)
 			streamEncoder.GetStream().AddEventListener(activeClient)
+			streamEncoder.GetStream().AddEventListener(&str.ResourceReleaser{Resource: requests})
 
 			p.host.HostStats().UpstreamRequestActive.Inc(1)
 			p.host.ClusterInfo().Stats().UpstreamRequestActive.Inc(1)
-			p.host.ClusterInfo().ResourceManager().Requests().Increase()
+			requests.Increase()
+		}
+
+		// rotate the connection after max requests, it is drained after a new connection is connected
+		if p.maxRequests > 0 && total >= uint64(p.maxRequests) {
+			atomic.StoreUint32(&activeClient.expired, 1)
+			p.tryConnect(g)
 		}
 
 		listener.OnReady(streamEncoder, p.host)
@@ -159,11 +298,30 @@ func (p *connPool) NewStream(ctx context.Context,
 	return
 }
 
+// drain stops new streams on the client, the client is closed after the active streams finished
+func (p *connPool) drain(client *activeClient) {
+	if !atomic.CompareAndSwapUint32(&client.state, Connected, Draining) {
+		return
+	}
+	p.removeClient(client)
+	p.host.HostStats().UpstreamConnectionCloseMaxRequests.Inc(1)
+	p.host.ClusterInfo().Stats().UpstreamConnectionCloseMaxRequests.Inc(1)
+	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+		log.DefaultLogger.Debugf("[stream] [xprotocol] [connpool] drain connection to %s after %d requests",
+			p.host.AddressString(), atomic.LoadUint64(&client.totalStream))
+	}
+	if atomic.LoadUint32(&client.activeStreams) == 0 {
+		client.closeDrained()
+	}
+}
+
 func (p *connPool) Close() {
 	f := func(k, v interface{}) bool {
-		ac, _ := v.(*activeClient)
-		if ac.client != nil {
-			ac.client.Close()
+		g, _ := v.(*clientGroup)
+		for _, ac := range g.load() {
+			if ac.client != nil {
+				ac.client.Close()
+			}
 		}
 		return true
 	}
@@ -174,9 +332,11 @@ func (p *connPool) Close() {
 // Shutdown stop the keepalive, so the connection will be idle after requests finished
 func (p *connPool) Shutdown() {
 	f := func(k, v interface{}) bool {
-		ac, _ := v.(*activeClient)
-		if ac.keepAlive != nil {
-			ac.keepAlive.keepAlive.Stop()
+		g, _ := v.(*clientGroup)
+		for _, ac := range g.load() {
+			if ac.keepAlive != nil {
+				ac.keepAlive.keepAlive.Stop()
+			}
 		}
 		return true
 	}
@@ -214,9 +374,8 @@ func (p *connPool) onConnectionEvent(client *activeClient, event api.ConnectionE
 		default:
 			// do nothing
 		}
-		p.mux.Lock()
-		p.activeClients.Delete(client.subProtocol)
-		p.mux.Unlock()
+		atomic.StoreUint32(&client.state, Closed)
+		p.removeClient(client)
 	} else if event == api.ConnectTimeout {
 		p.host.HostStats().UpstreamRequestTimeout.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamRequestTimeout.Inc(1)
@@ -228,9 +387,9 @@ func (p *connPool) onConnectionEvent(client *activeClient, event api.ConnectionE
 }
 
 func (p *connPool) onStreamDestroy(client *activeClient) {
+	client.releaseStream()
 	p.host.HostStats().UpstreamRequestActive.Dec(1)
 	p.host.ClusterInfo().Stats().UpstreamRequestActive.Dec(1)
-	p.host.ClusterInfo().ResourceManager().Requests().Decrease()
 }
 
 func (p *connPool) onStreamReset(client *activeClient, reason types.StreamResetReason) {
@@ -273,13 +432,17 @@ type activeClient struct {
 	host               types.CreateConnectionData
 	closeWithActiveReq bool
 	totalStream        uint64
+	activeStreams      uint32
 	state              uint32
+	// expired is set after max requests
+	expired uint32
 }
 
 func newActiveClient(ctx context.Context, subProtocol types.ProtocolName, pool *connPool) *activeClient {
 	ac := &activeClient{
 		subProtocol: subProtocol,
 		pool:        pool,
+		state:       Connecting,
 	}
 
 	data := pool.host.CreateConnection(ctx)
@@ -309,6 +472,10 @@ func newActiveClient(ctx context.Context, subProtocol types.ProtocolName, pool *
 	if err := ac.client.Connect(); err != nil {
 		return nil
 	}
+	// the connection may be closed already
+	if !atomic.CompareAndSwapUint32(&ac.state, Connecting, Connected) {
+		return nil
+	}
 
 	// stats
 	pool.host.HostStats().UpstreamConnectionTotal.Inc(1)
@@ -322,6 +489,20 @@ func newActiveClient(ctx context.Context, subProtocol types.ProtocolName, pool *
 	return ac
 }
 
+// releaseStream decreases the active streams, and closes the client if it is drained
+func (ac *activeClient) releaseStream() {
+	if atomic.AddUint32(&ac.activeStreams, ^uint32(0)) == 0 && atomic.LoadUint32(&ac.state) == Draining {
+		ac.closeDrained()
+	}
+}
+
+// closeDrained closes a draining client, the pending writes are flushed
+func (ac *activeClient) closeDrained() {
+	if atomic.CompareAndSwapUint32(&ac.state, Draining, Closed) {
+		ac.host.Connection.Close(api.FlushWrite, api.LocalClose)
+	}
+}
+
 func (ac *activeClient) OnEvent(event api.ConnectionEvent) {
 	ac.pool.onConnectionEvent(ac, event)
 }
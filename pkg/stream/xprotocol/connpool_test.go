@@ -0,0 +1,188 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xprotocol
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/configmanager"
+	"mosn.io/mosn/pkg/protocol/xprotocol/bolt"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/buffer"
+)
+
+type poolListener struct {
+	reason types.PoolFailureReason
+	sender types.StreamSender
+}
+
+func (l *poolListener) OnFailure(reason types.PoolFailureReason, host types.Host) {
+	l.reason = reason
+}
+
+func (l *poolListener) OnReady(sender types.StreamSender, host types.Host) {
+	l.sender = sender
+}
+
+type noopReceiver struct{}
+
+func (r *noopReceiver) OnReceive(ctx context.Context, headers api.HeaderMap, data buffer.IoBuffer, trailers api.HeaderMap) {
+}
+
+func (r *noopReceiver) OnDecodeError(ctx context.Context, err error, headers api.HeaderMap) {}
+
+func newTestPool(t *testing.T, cfg v2.Cluster) (*connPool, *mockServer) {
+	srv, err := newMockServer(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.GoServe()
+	cfg.Name = "test"
+	cfg.ClusterType = v2.SIMPLE_CLUSTER
+	cfg.LbType = v2.LB_ROUNDROBIN
+	c := cluster.NewCluster(cfg)
+	host := cluster.NewSimpleHost(v2.Host{
+		HostConfig: v2.HostConfig{
+			Address:    srv.AddrString(),
+			TLSDisable: true,
+		},
+	}, c.Snapshot().ClusterInfo())
+	return NewConnPool(host).(*connPool), srv
+}
+
+func testPoolContext() context.Context {
+	return context.WithValue(context.Background(), types.ContextSubProtocol, string(bolt.ProtocolName))
+}
+
+// waitConnected waits until the pool has n connected clients
+func waitConnected(t *testing.T, p *connPool, n int) *clientGroup {
+	ctx := testPoolContext()
+	g := p.group(getSubProtocol(ctx))
+	for i := 0; i < 100; i++ {
+		p.CheckAndInit(ctx)
+		connected := 0
+		for _, c := range g.load() {
+			if atomic.LoadUint32(&c.state) == Connected {
+				connected++
+			}
+		}
+		if connected == n {
+			return g
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("wait %d connected clients timeout, got %d", n, len(g.load()))
+	return nil
+}
+
+func TestConnPoolMultipleConnections(t *testing.T) {
+	p, srv := newTestPool(t, v2.Cluster{
+		ConnPool: v2.ConnPoolConfig{
+			MaxConnectionsPerHost:   2,
+			MaxStreamsPerConnection: 1,
+		},
+	})
+	defer srv.Close()
+	defer p.Close()
+
+	g := waitConnected(t, p, 2)
+	// streams are created on the client with least active streams
+	for i := 0; i < 2; i++ {
+		l := &poolListener{}
+		p.NewStream(testPoolContext(), &noopReceiver{}, l)
+		if l.sender == nil {
+			t.Fatalf("stream %d is not created, failure reason: %s", i, l.reason)
+		}
+	}
+	for _, c := range g.load() {
+		if streams := atomic.LoadUint32(&c.activeStreams); streams != 1 {
+			t.Fatalf("expected 1 active stream on each connection, but got %d", streams)
+		}
+	}
+	// all the connections reach the max streams
+	l := &poolListener{}
+	p.NewStream(testPoolContext(), &noopReceiver{}, l)
+	if l.sender != nil || l.reason != types.Overflow {
+		t.Fatalf("expected overflow, but got %s", l.reason)
+	}
+	if cnt := p.host.HostStats().UpstreamConnectionPoolOverflow.Count(); cnt != 1 {
+		t.Fatalf("expected pool overflow count 1, but got %d", cnt)
+	}
+}
+
+func TestConnPoolUnlimitedRequests(t *testing.T) {
+	// the parsed cluster has the default max requests
+	p, srv := newTestPool(t, configmanager.ParseClusterDefaultValue(v2.Cluster{}))
+	p.Close()
+	srv.Close()
+	if p.maxRequests != configmanager.DefaultMaxRequestPerConn {
+		t.Fatalf("expected the default max requests, but got %d", p.maxRequests)
+	}
+	// the connections are not rotated if the requests are unlimited
+	p, srv = newTestPool(t, configmanager.ParseClusterDefaultValue(v2.Cluster{
+		ConnPool: v2.ConnPoolConfig{UnlimitedRequestsPerConnection: true},
+	}))
+	defer srv.Close()
+	defer p.Close()
+	if p.maxRequests != 0 {
+		t.Fatalf("expected no max requests, but got %d", p.maxRequests)
+	}
+}
+
+func TestConnPoolRotateAfterMaxRequests(t *testing.T) {
+	p, srv := newTestPool(t, v2.Cluster{
+		MaxRequestPerConn: 2,
+	})
+	defer srv.Close()
+	defer p.Close()
+
+	g := waitConnected(t, p, 1)
+	first := g.load()[0]
+	// oneway streams finish after created
+	for i := 0; i < 2; i++ {
+		l := &poolListener{}
+		p.NewStream(testPoolContext(), nil, l)
+		if l.sender == nil {
+			t.Fatalf("stream %d is not created, failure reason: %s", i, l.reason)
+		}
+	}
+	// the expired connection is closed after the new connection is connected
+	for i := 0; i < 100; i++ {
+		clients := g.load()
+		if len(clients) == 1 && clients[0] != first && atomic.LoadUint32(&first.state) == Closed {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	clients := g.load()
+	if len(clients) != 1 || clients[0] == first {
+		t.Fatalf("connection is not rotated, clients: %d", len(clients))
+	}
+	if state := atomic.LoadUint32(&first.state); state != Closed {
+		t.Fatalf("expired connection is not closed, state: %d", state)
+	}
+	if cnt := p.host.HostStats().UpstreamConnectionCloseMaxRequests.Count(); cnt != 1 {
+		t.Fatalf("expected close max requests count 1, but got %d", cnt)
+	}
+}
@@ -38,6 +38,14 @@ const (
 	HeaderXprotocolRespStatus      = "x-mosn-xprotocol-resp-status"
 	HeaderXprotocolRespIsException = "x-mosn-xprotocol-resp-is-exception"
 	HeaderXprotocolHeartbeat       = "x-protocol-heartbeat"
+	HeaderOverloaded               = "x-mosn-overloaded"
+)
+
+// Variable names of the rpc service and method, they are set by the xprotocol streams
+// from the decoded frames, so they can not be spoofed by the request headers of the clients
+const (
+	VarRPCService = "rpc_service"
+	VarRPCMethod  = "rpc_method"
 )
 
 // Error messages
@@ -66,6 +74,7 @@ const (
 	UnknownCode           = 2
 	DeserialExceptionCode = 3
 	SuccessCode           = 200
+	UnauthorizedCode      = 401
 	PermissionDeniedCode  = 403
 	RouterUnavailableCode = 404
 	NoHealthUpstreamCode  = 502
@@ -41,6 +41,10 @@ const (
 	ContextKeyActiveSpan
 	ContextKeyTraceId
 	ContextKeyVariables
+	ContextKeyUseStream
+	ContextKeyH2C
+	ContextKeyRoutePriority
+	ContextKeyDynamicMetadata
 	ContextKeyEnd
 )
 
@@ -106,6 +106,51 @@ type VirtualHost interface {
 	RemoveAllRoutes()
 }
 
+// HeaderFinalizer is an optional interface of api.RouteRule.
+// the stream context is used to evaluate the variables in the headers to add
+type HeaderFinalizer interface {
+	FinalizeRequestHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo)
+	FinalizeResponseHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo)
+}
+
+// NamedRouteRule is an optional interface of api.RouteRule, which returns the configured route name
+type NamedRouteRule interface {
+	RouteName() string
+}
+
+// StreamRouteRule is an optional interface of api.RouteRule, which returns whether the HTTP/1 bodies are streamed
+// to and from the upstream. The downstream request bodies are streamed by the proxy config only
+type StreamRouteRule interface {
+	Http1UseStream() bool
+}
+
+// PriorityRouteRule is an optional interface of api.RouteRule, which returns the routing priority of the route
+type PriorityRouteRule interface {
+	Priority() RoutingPriority
+}
+
+// CorsRouteRule is an optional interface of api.RouteRule, which returns the cors policy of the route or its virtual host.
+// nil is returned if there is no policy or it is disabled
+type CorsRouteRule interface {
+	CorsPolicy() CorsPolicy
+}
+
+// CorsPolicy is the cross-origin resource sharing policy
+type CorsPolicy interface {
+	// AllowOrigin returns true if the origin is allowed
+	AllowOrigin(origin string) bool
+	// AllowMethods returns the value of the Access-Control-Allow-Methods
+	AllowMethods() string
+	// AllowHeaders returns the value of the Access-Control-Allow-Headers
+	AllowHeaders() string
+	// ExposeHeaders returns the value of the Access-Control-Expose-Headers
+	ExposeHeaders() string
+	// MaxAge returns the value of the Access-Control-Max-Age
+	MaxAge() string
+	// AllowCredentials returns true if the credentials are allowed
+	AllowCredentials() bool
+}
+
 type HeaderFormat interface {
 	Format(info api.RequestInfo) string
 	Append() bool
@@ -129,6 +174,12 @@ type HeaderData struct {
 	Value        string
 	IsRegex      bool
 	RegexPattern *regexp.Regexp
+	// RangeMatch matches if the header value is an integer in the range
+	RangeMatch *v2.Int64Range
+	// PresentMatch matches if the header is present or absent, the Value is ignored
+	PresentMatch *bool
+	// InvertMatch inverts the match result
+	InvertMatch bool
 }
 
 // ConfigUtility is utility routines for loading route configuration and matching runtime request headers.
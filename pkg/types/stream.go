@@ -170,6 +170,36 @@ type StreamReceiveListener interface {
 	OnDecodeError(ctx context.Context, err error, headers api.HeaderMap)
 }
 
+// StreamDataReceiveListener is an optional interface of StreamReceiveListener for the streaming codecs.
+// The headers are received by OnReceiveHeaders first, then the body is received in data frames by OnReceiveData,
+// the last frame is received with endStream true
+type StreamDataReceiveListener interface {
+	// OnReceiveHeaders is called with decoded headers, endStream is true if there is no body
+	OnReceiveHeaders(ctx context.Context, headers api.HeaderMap, endStream bool)
+
+	// OnReceiveData is called with a decoded data frame
+	OnReceiveData(ctx context.Context, data buffer.IoBuffer, endStream bool)
+}
+
+// ReadDisableStream is an optional interface of Stream, the streams receiving data frames are read disabled for flow control
+type ReadDisableStream interface {
+	// ReadDisable stops or resumes receiving the data frames
+	ReadDisable(disable bool)
+}
+
+// StreamSenderDataFilter is an optional interface of api.StreamSenderFilter for the response body sent in data frames.
+// The Append is called with the headers and nil data, then the data frames are passed to AppendData
+type StreamSenderDataFilter interface {
+	// AppendData is called with a data frame of the response body, returns the data to be sent
+	AppendData(ctx context.Context, data buffer.IoBuffer, endStream bool) buffer.IoBuffer
+}
+
+// StreamResetHandler is an optional interface of api.StreamReceiverFilterHandler, which is used by filters to reset the downstream stream
+type StreamResetHandler interface {
+	// ResetStream resets the downstream stream, the request is not forwarded
+	ResetStream()
+}
+
 // StreamConnection is a connection runs multiple streams
 type StreamConnection interface {
 	// Dispatch incoming data
@@ -208,6 +238,13 @@ type ClientStreamConnection interface {
 	NewStream(ctx context.Context, responseReceiveListener StreamReceiveListener) StreamSender
 }
 
+// StreamLimitConnection is an optional interface of ClientStreamConnection,
+// the peer of a multiplexing connection may limit the concurrent streams
+type StreamLimitConnection interface {
+	// MaxConcurrentStreams returns the max concurrent streams allowed by the peer, 0 means no limit
+	MaxConcurrentStreams() uint32
+}
+
 // StreamConnectionEventListener is a stream connection event listener
 type StreamConnectionEventListener interface {
 	// OnGoAway is called on remote sends 'go away'
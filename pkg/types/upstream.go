@@ -19,6 +19,7 @@ package types
 
 import (
 	"context"
+	"errors"
 	"net"
 	"sort"
 	"time"
@@ -33,6 +34,9 @@ import (
 //           1              * | 1                          1 | 1          *
 //   clusterManager --------- cluster  --------- --------- hostSet------hosts
 
+// ErrConnPoolOverflow is returned if the connection pools of the cluster overflow
+var ErrConnPoolOverflow = errors.New("connection pools overflow")
+
 // ClusterManager manages connection pools and load balancing for upstream clusters.
 type ClusterManager interface {
 	// Add or update a cluster via API.
@@ -57,8 +61,9 @@ type ClusterManager interface {
 	// Get or Create tcp conn pool for a cluster
 	TCPConnForCluster(balancerContext LoadBalancerContext, snapshot ClusterSnapshot) CreateConnectionData
 
-	// ConnPoolForCluster used to get protocol related conn pool
-	ConnPoolForCluster(balancerContext LoadBalancerContext, snapshot ClusterSnapshot, protocol api.Protocol) ConnectionPool
+	// ConnPoolForCluster used to get protocol related conn pool,
+	// ErrConnPoolOverflow is returned if the connection pools of the cluster overflow
+	ConnPoolForCluster(balancerContext LoadBalancerContext, snapshot ClusterSnapshot, protocol api.Protocol) (ConnectionPool, error)
 
 	// RemovePrimaryCluster used to remove cluster from set
 	RemovePrimaryCluster(clusters ...string) error
@@ -193,6 +198,12 @@ type ClusterInfo interface {
 
 	// LbOriDstInfo returns the load balancer oridst config
 	LbOriDstInfo() LBOriDstInfo
+
+	// ConnPoolConfig returns the connection pool config
+	ConnPoolConfig() v2.ConnPoolConfig
+
+	// UpstreamProtocol returns the HTTP protocol to talk with the hosts, empty means not specified
+	UpstreamProtocol() ProtocolName
 }
 
 // ResourceManager manages different types of Resource
@@ -208,8 +219,23 @@ type ResourceManager interface {
 
 	// Retries resource to count retries
 	Retries() Resource
+
+	// ConnectionPools resource to count the connection pools of the hosts
+	ConnectionPools() Resource
+
+	// ForPriority returns the resources of the routing priority, the ResourceManager itself is the default priority
+	ForPriority(priority RoutingPriority) ResourceManager
 }
 
+// RoutingPriority is the priority of the route, the resources are limited separately by priority
+type RoutingPriority int
+
+// RoutingPriority enum
+const (
+	DefaultPriority RoutingPriority = iota
+	HighPriority
+)
+
 // Resource is a interface to statistics information
 type Resource interface {
 	CanCreate() bool
@@ -229,6 +255,12 @@ type HostStats struct {
 	UpstreamConnectionLocalCloseWithActiveRequest  metrics.Counter
 	UpstreamConnectionRemoteCloseWithActiveRequest metrics.Counter
 	UpstreamConnectionCloseNotify                  metrics.Counter
+	UpstreamConnectionCloseMaxRequests             metrics.Counter
+	UpstreamConnectionPoolOverflow                 metrics.Counter
+	UpstreamConnectionCloseGoAway                  metrics.Counter
+	UpstreamConnectionCloseMaxAge                  metrics.Counter
+	UpstreamConnectionCloseIdle                    metrics.Counter
+	UpstreamConnectionCloseMaxIdle                 metrics.Counter
 	UpstreamRequestTotal                           metrics.Counter
 	UpstreamRequestActive                          metrics.Counter
 	UpstreamRequestLocalReset                      metrics.Counter
@@ -254,6 +286,12 @@ type ClusterStats struct {
 	UpstreamConnectionLocalCloseWithActiveRequest  metrics.Counter
 	UpstreamConnectionRemoteCloseWithActiveRequest metrics.Counter
 	UpstreamConnectionCloseNotify                  metrics.Counter
+	UpstreamConnectionCloseMaxRequests             metrics.Counter
+	UpstreamConnectionPoolOverflow                 metrics.Counter
+	UpstreamConnectionCloseGoAway                  metrics.Counter
+	UpstreamConnectionCloseMaxAge                  metrics.Counter
+	UpstreamConnectionCloseIdle                    metrics.Counter
+	UpstreamConnectionCloseMaxIdle                 metrics.Counter
 	UpstreamBytesReadTotal                         metrics.Counter
 	UpstreamBytesWriteTotal                        metrics.Counter
 	UpstreamRequestTotal                           metrics.Counter
@@ -0,0 +1,121 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	admin "mosn.io/mosn/pkg/admin/server"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+)
+
+func init() {
+	admin.RegisterAdminHandleFunc("/api/v1/circuit_breakers", circuitBreakersDump)
+}
+
+// CircuitBreakersStatus is the usage of the circuit breakers resources of a cluster
+type CircuitBreakersStatus struct {
+	// Tripping is true if any of the resources is open
+	Tripping   bool                                 `json:"tripping"`
+	Priorities map[string]map[string]ResourceStatus `json:"priorities"`
+}
+
+// ResourceStatus is the usage of a resource, max 0 means no limit
+type ResourceStatus struct {
+	Current int64  `json:"current"`
+	Max     uint64 `json:"max"`
+	Open    bool   `json:"open,omitempty"`
+}
+
+// GetCircuitBreakersStatus returns the circuit breakers status of the clusters keyed by cluster name,
+// only the tripping clusters are returned if trippingOnly is true
+func GetCircuitBreakersStatus(trippingOnly bool) map[string]*CircuitBreakersStatus {
+	statuses := make(map[string]*CircuitBreakersStatus)
+	clusterMangerInstance.instanceMutex.Lock()
+	defer clusterMangerInstance.instanceMutex.Unlock()
+	if clusterMangerInstance.clusterManager == nil {
+		return statuses
+	}
+	clusterMangerInstance.clustersMap.Range(func(key, value interface{}) bool {
+		snapshot := value.(types.Cluster).Snapshot()
+		if snapshot == nil {
+			return true
+		}
+		rm, ok := snapshot.ClusterInfo().ResourceManager().(*resourcemanager)
+		if !ok {
+			return true
+		}
+		status := &CircuitBreakersStatus{
+			Priorities: map[string]map[string]ResourceStatus{
+				v2.RoutingPriorityDefault: rm.status(),
+				v2.RoutingPriorityHigh:    rm.high.status(),
+			},
+		}
+		for _, resources := range status.Priorities {
+			for _, r := range resources {
+				status.Tripping = status.Tripping || r.Open
+			}
+		}
+		if status.Tripping || !trippingOnly {
+			statuses[key.(string)] = status
+		}
+		return true
+	})
+	return statuses
+}
+
+func (rm *resourcemanager) status() map[string]ResourceStatus {
+	resources := map[string]resourceState{
+		"connections":      rm.connections,
+		"pending_requests": rm.pendingRequests,
+		"requests":         rm.requests,
+		"retries":          rm.retries,
+		"connection_pools": rm.connectionPools,
+	}
+	status := make(map[string]ResourceStatus, len(resources))
+	for name, r := range resources {
+		status[name] = ResourceStatus{
+			Current: r.Current(),
+			Max:     r.Max(),
+			Open:    r.Open(),
+		}
+	}
+	return status
+}
+
+// circuitBreakersDump returns the circuit breakers status of the clusters,
+// only the tripping clusters are returned with the query tripping=true
+func circuitBreakersDump(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "circuit breakers", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	buf, err := json.MarshalIndent(GetCircuitBreakersStatus(r.URL.Query().Get("tripping") == "true"), "", " ")
+	if err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: %v", "circuit breakers", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, `{"error": "%s"}`, "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf)
+}
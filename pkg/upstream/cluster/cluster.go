@@ -26,6 +26,7 @@ import (
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/mtls"
 	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/mosn/pkg/upstream/healthcheck"
 	"mosn.io/pkg/utils"
@@ -33,6 +34,9 @@ import (
 
 func NewCluster(clusterConfig v2.Cluster) types.Cluster {
 	// TODO: support cluster type registered
+	if isDNSCluster(clusterConfig.ClusterType) {
+		return newDNSCluster(clusterConfig)
+	}
 	return newSimpleCluster(clusterConfig)
 }
 
@@ -55,7 +59,10 @@ func newSimpleCluster(clusterConfig v2.Cluster) *simpleCluster {
 		lbSubsetInfo:         NewLBSubsetInfo(&clusterConfig.LBSubSetConfig), // new subset load balancer info
 		lbOriDstInfo:         NewLBOriDstInfo(&clusterConfig.LBOriDstConfig), // new oridst load balancer info
 		lbType:               types.LoadBalancerType(clusterConfig.LbType),
-		resourceManager:      NewResourceManager(clusterConfig.CirBreThresholds),
+		resourceManager:      newResourceManager(clusterConfig.Name, clusterConfig.CirBreThresholds),
+
+		overprovisioningFactor: clusterConfig.OverprovisioningFactor,
+		connPoolConfig:         clusterConfig.ConnPool,
 	}
 
 	// set ConnectTimeout
@@ -65,6 +72,15 @@ func newSimpleCluster(clusterConfig v2.Cluster) *simpleCluster {
 		info.connectTimeout = network.DefaultConnectTimeout
 	}
 
+	// only the HTTP protocols can be switched by the cluster
+	switch prot := types.ProtocolName(clusterConfig.UpstreamProtocol); prot {
+	case protocol.HTTP1, protocol.HTTP2:
+		info.upstreamProtocol = prot
+	case "":
+	default:
+		log.DefaultLogger.Errorf("[upstream] [cluster] [new cluster] cluster %s upstream protocol %s is not supported", clusterConfig.Name, prot)
+	}
+
 	// tls mng
 	mgr, err := mtls.NewTLSClientContextManager(&clusterConfig.TLS)
 	if err != nil {
@@ -103,6 +119,8 @@ func (sc *simpleCluster) UpdateHosts(newHosts []types.Host) {
 	var lb types.LoadBalancer
 	if info.lbSubsetInfo.IsEnabled() {
 		lb = NewSubsetLoadBalancer(info, hostSet)
+	} else if needPriorityLoadBalancer(hostSet) {
+		lb = newPriorityLoadBalancer(info, hostSet, nil)
 	} else {
 		lb = NewLoadBalancer(info.lbType, hostSet)
 	}
@@ -153,6 +171,10 @@ type clusterInfo struct {
 	lbOriDstInfo         types.LBOriDstInfo
 	tlsMng               types.TLSContextManager
 	connectTimeout       time.Duration
+	// overprovisioningFactor is used by priority load balancer
+	overprovisioningFactor uint32
+	connPoolConfig         v2.ConnPoolConfig
+	upstreamProtocol       types.ProtocolName
 }
 
 func (ci *clusterInfo) Name() string {
@@ -199,6 +221,14 @@ func (ci *clusterInfo) LbOriDstInfo() types.LBOriDstInfo {
 	return ci.lbOriDstInfo
 }
 
+func (ci *clusterInfo) ConnPoolConfig() v2.ConnPoolConfig {
+	return ci.connPoolConfig
+}
+
+func (ci *clusterInfo) UpstreamProtocol() types.ProtocolName {
+	return ci.upstreamProtocol
+}
+
 type clusterSnapshot struct {
 	info    types.ClusterInfo
 	hostSet types.HostSet
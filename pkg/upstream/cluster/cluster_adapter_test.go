@@ -236,7 +236,7 @@ func TestClusterRemoveHostWithSnapshot(t *testing.T) {
 func TestConnPoolForCluster(t *testing.T) {
 	_createClusterManager()
 	snap := GetClusterMngAdapterInstance().GetClusterSnapshot(nil, "test1")
-	connPool := GetClusterMngAdapterInstance().ConnPoolForCluster(newMockLbContext(nil), snap, mockProtocol)
+	connPool, _ := GetClusterMngAdapterInstance().ConnPoolForCluster(newMockLbContext(nil), snap, mockProtocol)
 	if connPool == nil {
 		t.Fatal("get conn pool failed")
 	}
@@ -262,7 +262,7 @@ func TestConnPoolUpdateTLS(t *testing.T) {
 		"test1": []v2.Host{host},
 	})
 	snap := GetClusterMngAdapterInstance().GetClusterSnapshot(nil, "test1")
-	if connPool := GetClusterMngAdapterInstance().ConnPoolForCluster(newMockLbContext(nil), snap, mockProtocol); connPool.SupportTLS() {
+	if connPool, _ := GetClusterMngAdapterInstance().ConnPoolForCluster(newMockLbContext(nil), snap, mockProtocol); connPool.SupportTLS() {
 		t.Fatal("conn pool support tls")
 	}
 	if err := GetClusterMngAdapterInstance().UpdateClusterHosts("test1", []v2.Host{
@@ -275,7 +275,7 @@ func TestConnPoolUpdateTLS(t *testing.T) {
 		t.Fatalf("update cluster hosts failed, %v", err)
 	}
 	newSnap := GetClusterMngAdapterInstance().GetClusterSnapshot(nil, "test1")
-	if connPool := GetClusterMngAdapterInstance().ConnPoolForCluster(newMockLbContext(nil), newSnap, mockProtocol); !connPool.SupportTLS() {
+	if connPool, _ := GetClusterMngAdapterInstance().ConnPoolForCluster(newMockLbContext(nil), newSnap, mockProtocol); !connPool.SupportTLS() {
 		t.Fatal("conn pool does not support tls")
 	}
 
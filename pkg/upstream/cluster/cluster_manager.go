@@ -53,6 +53,32 @@ func refreshHostsConfig(c types.Cluster) {
 	}
 }
 
+// dnsTargets returns the hosts config that a new dns cluster should resolve
+func dnsTargets(c types.Cluster) []v2.Host {
+	if dc, ok := c.(*dnsCluster); ok {
+		return dc.Targets()
+	}
+	hosts := c.Snapshot().HostSet().Hosts()
+	targets := make([]v2.Host, 0, len(hosts))
+	for _, h := range hosts {
+		targets = append(targets, h.Config())
+	}
+	return targets
+}
+
+// connPoolKey is the key of the connection pools of a protocol.
+// The pools are not shared between the clusters, so each of them is counted by the cluster created it
+type connPoolKey struct {
+	cluster string
+	addr    string
+}
+
+// connPoolEntry records the resource counted the pool, which is decreased after the pool removed
+type connPoolEntry struct {
+	pool     types.ConnectionPool
+	resource types.Resource
+}
+
 // types.ClusterManager
 type clusterManager struct {
 	clustersMap      sync.Map
@@ -110,6 +136,11 @@ func (cm *clusterManager) AddOrUpdatePrimaryCluster(cluster v2.Cluster) error {
 	}
 	// check update or new
 	clusterName := cluster.Name
+	if dc, ok := newCluster.(*dnsCluster); ok {
+		dc.hostsUpdated = func() {
+			cm.removeConnPools(clusterName)
+		}
+	}
 	// set config
 	store.SetClusterConfig(clusterName, cluster)
 	// add or update
@@ -120,7 +151,15 @@ func (cm *clusterManager) AddOrUpdatePrimaryCluster(cluster v2.Cluster) error {
 		hosts := c.Snapshot().HostSet().Hosts()
 		// update hosts, refresh
 		newCluster.UpdateHosts(hosts)
-		refreshHostsConfig(c)
+		if dc, ok := newCluster.(*dnsCluster); ok {
+			// keep resolving the same hostnames
+			dc.SetTargets(dnsTargets(c))
+		} else {
+			refreshHostsConfig(c)
+		}
+		if oldDC, ok := c.(*dnsCluster); ok {
+			oldDC.Stop()
+		}
 	}
 	cm.clustersMap.Store(clusterName, newCluster)
 	log.DefaultLogger.Infof("[cluster] [cluster manager] [AddOrUpdatePrimaryCluster] cluster %s updated", clusterName)
@@ -162,9 +201,13 @@ func (cm *clusterManager) RemovePrimaryCluster(clusterNames ...string) error {
 		}
 		c := v.(types.Cluster)
 		c.StopHealthChecking()
+		if dc, ok := c.(*dnsCluster); ok {
+			dc.Stop()
+		}
 
 		cm.clustersMap.Delete(clusterName)
 		store.RemoveClusterConfig(clusterName)
+		cm.removeConnPools(clusterName)
 		if log.DefaultLogger.GetLogLevel() >= log.INFO {
 			log.DefaultLogger.Infof("[upstream] [cluster manager] Remove Primary Cluster, Cluster Name = %s", clusterName)
 		}
@@ -180,6 +223,12 @@ func (cm *clusterManager) UpdateClusterHosts(clusterName string, hostConfigs []v
 		return fmt.Errorf("cluster %s is not exists", clusterName)
 	}
 	c := ci.(types.Cluster)
+	// the hosts of dns cluster are updated after resolved
+	if dc, ok := c.(*dnsCluster); ok {
+		dc.SetTargets(hostConfigs)
+		store.SetHosts(clusterName, hostConfigs)
+		return nil
+	}
 	snap := c.Snapshot()
 	hosts := make([]types.Host, 0, len(hostConfigs))
 	for _, hc := range hostConfigs {
@@ -187,6 +236,7 @@ func (cm *clusterManager) UpdateClusterHosts(clusterName string, hostConfigs []v
 	}
 	c.UpdateHosts(hosts)
 	refreshHostsConfig(c)
+	cm.removeConnPools(clusterName)
 	return nil
 }
 
@@ -198,6 +248,14 @@ func (cm *clusterManager) AppendClusterHosts(clusterName string, hostConfigs []v
 		return fmt.Errorf("cluster %s is not exists", clusterName)
 	}
 	c := ci.(types.Cluster)
+	if dc, ok := c.(*dnsCluster); ok {
+		targets := make([]v2.Host, 0, len(hostConfigs)+len(dc.Targets()))
+		targets = append(targets, hostConfigs...)
+		targets = append(targets, dc.Targets()...)
+		dc.SetTargets(targets)
+		store.SetHosts(clusterName, targets)
+		return nil
+	}
 	snap := c.Snapshot()
 	hosts := make([]types.Host, 0, len(hostConfigs))
 	for _, hc := range hostConfigs {
@@ -217,6 +275,21 @@ func (cm *clusterManager) RemoveClusterHosts(clusterName string, addrs []string)
 		return fmt.Errorf("cluster %s is not exists", clusterName)
 	}
 	c := ci.(types.Cluster)
+	if dc, ok := c.(*dnsCluster); ok {
+		removed := make(map[string]bool, len(addrs))
+		for _, addr := range addrs {
+			removed[addr] = true
+		}
+		targets := make([]v2.Host, 0, len(dc.Targets()))
+		for _, target := range dc.Targets() {
+			if !removed[target.Address] {
+				targets = append(targets, target)
+			}
+		}
+		dc.SetTargets(targets)
+		store.SetHosts(clusterName, targets)
+		return nil
+	}
 	snap := c.Snapshot()
 	hosts := snap.HostSet().Hosts()
 	newHosts := make([]types.Host, len(hosts))
@@ -234,9 +307,39 @@ func (cm *clusterManager) RemoveClusterHosts(clusterName string, addrs []string)
 	}
 	c.UpdateHosts(sortedHosts)
 	refreshHostsConfig(c)
+	cm.removeConnPools(clusterName)
 	return nil
 }
 
+// removeConnPools shuts down the connection pools of the hosts not in the cluster,
+// all the pools of the cluster are removed if the cluster is removed
+func (cm *clusterManager) removeConnPools(clusterName string) {
+	addrs := make(map[string]bool)
+	if ci, ok := cm.clustersMap.Load(clusterName); ok {
+		for _, host := range ci.(types.Cluster).Snapshot().HostSet().Hosts() {
+			addrs[host.AddressString()] = true
+		}
+	}
+	cm.mux.Lock()
+	defer cm.mux.Unlock()
+	cm.protocolConnPool.Range(func(_, value interface{}) bool {
+		connectionPool := value.(*sync.Map)
+		connectionPool.Range(func(key, value interface{}) bool {
+			if k := key.(connPoolKey); k.cluster == clusterName && !addrs[k.addr] {
+				connectionPool.Delete(key)
+				entry := value.(*connPoolEntry)
+				entry.pool.Shutdown()
+				entry.resource.Decrease()
+				if log.DefaultLogger.GetLogLevel() >= log.INFO {
+					log.DefaultLogger.Infof("[upstream] [cluster manager] remove the connection pool of host %s, cluster name = %s", k.addr, clusterName)
+				}
+			}
+			return true
+		})
+		return true
+	})
+}
+
 // GetClusterSnapshot returns cluster snap
 // do not needs PutClusterSnapshot any more
 func (cm *clusterManager) GetClusterSnapshot(ctx context.Context, clusterName string) types.ClusterSnapshot {
@@ -261,16 +364,16 @@ func (cm *clusterManager) TCPConnForCluster(lbCtx types.LoadBalancerContext, sna
 	return host.CreateConnection(context.Background())
 }
 
-func (cm *clusterManager) ConnPoolForCluster(balancerContext types.LoadBalancerContext, snapshot types.ClusterSnapshot, protocol types.ProtocolName) types.ConnectionPool {
+func (cm *clusterManager) ConnPoolForCluster(balancerContext types.LoadBalancerContext, snapshot types.ClusterSnapshot, protocol types.ProtocolName) (types.ConnectionPool, error) {
 	if snapshot == nil || reflect.ValueOf(snapshot).IsNil() {
 		log.DefaultLogger.Errorf("[upstream] [cluster manager]  %s ConnPool For Cluster is nil", protocol)
-		return nil
+		return nil, errNilSnapshot
 	}
 	pool, err := cm.getActiveConnectionPool(balancerContext, snapshot, protocol)
 	if err != nil {
 		log.DefaultLogger.Errorf("[upstream] [cluster manager] ConnPoolForCluster Failed; %v", err)
 	}
-	return pool
+	return pool, err
 }
 
 const (
@@ -279,6 +382,7 @@ const (
 )
 
 var (
+	errNilSnapshot     = errors.New("cluster snapshot is nil")
 	errNilHostChoose   = errors.New("cluster snapshot choose host is nil")
 	errUnknownProtocol = errors.New("protocol pool can not found protocol")
 	errNoHealthyHost   = errors.New("no health hosts")
@@ -291,6 +395,7 @@ func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBala
 	}
 
 	var pools [maxHostsCounts]types.ConnectionPool
+	available, overflow := 0, false
 
 	try := clusterSnapshot.HostNum(balancerContext.MetadataMatchCriteria())
 	if try == 0 {
@@ -315,24 +420,35 @@ func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBala
 		}
 
 		connectionPool := value.(*sync.Map)
+		key := connPoolKey{cluster: clusterSnapshot.ClusterInfo().Name(), addr: addr}
 		// we cannot use sync.Map.LoadOrStore directly, becasue we do not want to new a connpool every time
+		// nil is returned if the connection pools of the cluster overflow
 		loadOrStoreConnPool := func() (types.ConnectionPool, bool) {
 			// avoid locking if it is already exists
-			if connPool, ok := connectionPool.Load(addr); ok {
-				pool := connPool.(types.ConnectionPool)
-				return pool, true
+			if entry, ok := connectionPool.Load(key); ok {
+				return entry.(*connPoolEntry).pool, true
 			}
 			cm.mux.Lock()
 			defer cm.mux.Unlock()
-			if connPool, ok := connectionPool.Load(addr); ok {
-				pool := connPool.(types.ConnectionPool)
-				return pool, true
+			if entry, ok := connectionPool.Load(key); ok {
+				return entry.(*connPoolEntry).pool, true
+			}
+			poolResource := host.ClusterInfo().ResourceManager().ConnectionPools()
+			if !poolResource.CanCreate() {
+				host.ClusterInfo().Stats().UpstreamConnectionPoolOverflow.Inc(1)
+				return nil, false
 			}
+			poolResource.Increase()
 			pool := factory(host)
-			connectionPool.Store(addr, pool)
+			connectionPool.Store(key, &connPoolEntry{pool: pool, resource: poolResource})
 			return pool, false
 		}
 		pool, loaded := loadOrStoreConnPool()
+		if pool == nil {
+			log.DefaultLogger.Errorf("[upstream] [cluster manager] connection pools of cluster %s overflow, host %s is skipped", clusterSnapshot.ClusterInfo().Name(), addr)
+			overflow = true
+			continue
+		}
 		if loaded {
 			if pool.SupportTLS() != host.SupportTLS() {
 				if log.DefaultLogger.GetLogLevel() >= log.INFO {
@@ -343,15 +459,17 @@ func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBala
 					cm.mux.Lock()
 					defer cm.mux.Unlock()
 					// recheck whether the pool is changed
-					if connPool, ok := connectionPool.Load(addr); ok {
-						pool = connPool.(types.ConnectionPool)
+					if value, ok := connectionPool.Load(key); ok {
+						entry := value.(*connPoolEntry)
+						pool = entry.pool
 						if pool.SupportTLS() == host.SupportTLS() {
 							return
 						}
-						connectionPool.Delete(addr)
+						// the new pool replaces the old one, so it is still counted by the resource of the old one
+						connectionPool.Delete(key)
 						pool.Shutdown()
 						pool = factory(host)
-						connectionPool.Store(addr, pool)
+						connectionPool.Store(key, &connPoolEntry{pool: pool, resource: entry.resource})
 					}
 				}()
 			}
@@ -360,6 +478,10 @@ func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBala
 			return pool, nil
 		}
 		pools[i] = pool
+		available++
+	}
+	if available == 0 && overflow {
+		return nil, types.ErrConnPoolOverflow
 	}
 
 	// perhaps the first request, wait for tcp handshaking. total wait time is 1ms + 10ms + (100ms * 5)
@@ -379,5 +501,8 @@ func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBala
 			waitTime *= 10
 		}
 	}
+	if overflow {
+		return nil, types.ErrConnPoolOverflow
+	}
 	return nil, errNoHealthyHost
 }
@@ -0,0 +1,230 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
+)
+
+const defaultDNSRefreshRate = 5 * time.Second
+
+// dnsCluster is a cluster that resolves the hostnames of hosts periodically.
+// STRICT_DNS uses all the resolved addresses as hosts, LOGICAL_DNS uses the first address only.
+type dnsCluster struct {
+	*simpleCluster
+	resolver    *dnsResolver
+	strict      bool
+	family      v2.DnsLookupFamily
+	refreshRate time.Duration
+	respectTTL  bool
+	// hostsUpdated is called after the resolved hosts changed, if it is not nil
+	hostsUpdated func()
+
+	mutex    sync.Mutex
+	targets  []v2.Host // hosts config with hostnames
+	started  bool
+	refresh  chan struct{}
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+func newDNSCluster(clusterConfig v2.Cluster) *dnsCluster {
+	dc := &dnsCluster{
+		simpleCluster: newSimpleCluster(clusterConfig),
+		resolver:      newDNSResolver(clusterConfig.DnsResolvers),
+		strict:        clusterConfig.ClusterType == v2.STRICT_DNS_CLUSTER,
+		family:        clusterConfig.DnsLookupFamily,
+		refreshRate:   defaultDNSRefreshRate,
+		respectTTL:    clusterConfig.RespectDnsTTL,
+		refresh:       make(chan struct{}, 1),
+		stopChan:      make(chan struct{}),
+	}
+	if clusterConfig.DnsRefreshRate != nil && clusterConfig.DnsRefreshRate.Duration > 0 {
+		dc.refreshRate = clusterConfig.DnsRefreshRate.Duration
+	}
+	return dc
+}
+
+func isDNSCluster(clusterType v2.ClusterType) bool {
+	return clusterType == v2.STRICT_DNS_CLUSTER || clusterType == v2.LOGICAL_DNS_CLUSTER
+}
+
+// UpdateHosts updates the resolved hosts
+func (dc *dnsCluster) UpdateHosts(hosts []types.Host) {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	dc.simpleCluster.UpdateHosts(hosts)
+}
+
+// Targets returns the hosts config that should be resolved
+func (dc *dnsCluster) Targets() []v2.Host {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+	return dc.targets
+}
+
+// SetTargets sets the hosts config that should be resolved, and resolves them asynchronously
+func (dc *dnsCluster) SetTargets(targets []v2.Host) {
+	dc.mutex.Lock()
+	dc.targets = targets
+	if !dc.started {
+		dc.started = true
+		utils.GoWithRecover(func() {
+			dc.resolveLoop()
+		}, nil)
+	}
+	dc.mutex.Unlock()
+	select {
+	case dc.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// Stop stops resolving, the resolved hosts are kept
+func (dc *dnsCluster) Stop() {
+	dc.stopOnce.Do(func() {
+		close(dc.stopChan)
+	})
+}
+
+func (dc *dnsCluster) resolveLoop() {
+	timer := time.NewTimer(dc.refreshRate)
+	defer timer.Stop()
+	for {
+		select {
+		case <-dc.stopChan:
+			return
+		case <-dc.refresh:
+		case <-timer.C:
+		}
+		next := dc.resolve()
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(next)
+	}
+}
+
+// resolve resolves all the targets, and returns the duration before next resolving
+func (dc *dnsCluster) resolve() time.Duration {
+	targets := dc.Targets()
+	next := dc.refreshRate
+	configs := make([]v2.Host, 0, len(targets))
+	for _, target := range targets {
+		hostname, port, err := net.SplitHostPort(target.Address)
+		if err != nil {
+			log.DefaultLogger.Errorf("[upstream] [dns cluster] cluster %s host %s is invalid: %v", dc.info.name, target.Address, err)
+			continue
+		}
+		records, err := dc.resolver.resolve(hostname, dc.family)
+		if err != nil || len(records) == 0 {
+			// keep the hosts resolved last time, so the connections will not be dropped by temporary dns failures
+			log.DefaultLogger.Errorf("[upstream] [dns cluster] cluster %s resolve %s failed: %v", dc.info.name, hostname, err)
+			configs = append(configs, dc.resolvedConfigs(target)...)
+			continue
+		}
+		if !dc.strict {
+			records = records[:1]
+		}
+		for _, record := range records {
+			if dc.respectTTL && record.ttl > 0 && record.ttl < next {
+				next = record.ttl
+			}
+			config := target
+			config.Address = net.JoinHostPort(record.ip.String(), port)
+			if config.Hostname == "" && net.ParseIP(hostname) == nil {
+				config.Hostname = hostname
+			}
+			configs = append(configs, config)
+		}
+	}
+	dc.updateResolvedHosts(configs)
+	return next
+}
+
+// resolvedConfigs returns the hosts config resolved from the target last time
+func (dc *dnsCluster) resolvedConfigs(target v2.Host) []v2.Host {
+	hostname, port, _ := net.SplitHostPort(target.Address)
+	if target.Hostname != "" {
+		hostname = target.Hostname
+	}
+	var configs []v2.Host
+	for _, host := range dc.Snapshot().HostSet().Hosts() {
+		if _, p, _ := net.SplitHostPort(host.AddressString()); host.Hostname() == hostname && p == port {
+			configs = append(configs, host.Config())
+		}
+	}
+	return configs
+}
+
+// updateResolvedHosts updates the hosts if the resolved hosts changed,
+// the unchanged hosts are reused, so the health check states and stats are kept.
+func (dc *dnsCluster) updateResolvedHosts(configs []v2.Host) {
+	current := dc.Snapshot().HostSet().Hosts()
+	exists := make(map[string]types.Host, len(current))
+	for _, host := range current {
+		exists[host.AddressString()] = host
+	}
+	changed := len(configs) != len(current)
+	hosts := make([]types.Host, 0, len(configs))
+	for _, config := range configs {
+		if host, ok := exists[config.Address]; ok && sameHostConfig(host.Config(), config) {
+			hosts = append(hosts, host)
+			continue
+		}
+		changed = true
+		hosts = append(hosts, NewSimpleHost(config, dc.info))
+	}
+	if !changed {
+		return
+	}
+	dc.UpdateHosts(hosts)
+	if dc.hostsUpdated != nil {
+		dc.hostsUpdated()
+	}
+	if log.DefaultLogger.GetLogLevel() >= log.INFO {
+		addrs := make([]string, 0, len(hosts))
+		for _, host := range hosts {
+			addrs = append(addrs, host.AddressString())
+		}
+		log.DefaultLogger.Infof("[upstream] [dns cluster] cluster %s update resolved hosts: %s", dc.info.name, strings.Join(addrs, ","))
+	}
+}
+
+// sameHostConfig compares the configs that are used by hosts
+func sameHostConfig(h1, h2 v2.Host) bool {
+	return h1.Address == h2.Address &&
+		h1.Hostname == h2.Hostname &&
+		h1.TLSDisable == h2.TLSDisable &&
+		h1.Weight == h2.Weight &&
+		h1.Priority == h2.Priority &&
+		h1.Zone == h2.Zone &&
+		reflect.DeepEqual(h1.MetaData, h2.MetaData)
+}
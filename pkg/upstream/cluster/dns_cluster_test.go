@@ -0,0 +1,230 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+func hostAddresses(hosts []types.Host) string {
+	addrs := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		addrs = append(addrs, h.AddressString())
+	}
+	sort.Strings(addrs)
+	return strings.Join(addrs, ",")
+}
+
+// waitHosts waits the resolved hosts of the cluster
+func waitHosts(t *testing.T, c types.Cluster, expected string) []types.Host {
+	var hosts []types.Host
+	for i := 0; i < 300; i++ {
+		hosts = c.Snapshot().HostSet().Hosts()
+		if hostAddresses(hosts) == expected {
+			return hosts
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("hosts expected %s, but got %s", expected, hostAddresses(hosts))
+	return nil
+}
+
+func newTestDNSCluster(server *stubDNSServer, clusterType v2.ClusterType, respectTTL bool) *dnsCluster {
+	dc := newDNSCluster(v2.Cluster{
+		Name:            "dns_cluster",
+		ClusterType:     clusterType,
+		LbType:          v2.LB_ROUNDROBIN,
+		DnsLookupFamily: v2.DNS_V4_ONLY,
+		DnsRefreshRate:  &api.DurationConfig{Duration: time.Hour},
+		RespectDnsTTL:   respectTTL,
+		DnsResolvers:    []string{server.addr()},
+	})
+	dc.resolver.timeout = 100 * time.Millisecond
+	return dc
+}
+
+func TestStrictDNSCluster(t *testing.T) {
+	server := newStubDNSServer(t)
+	defer server.close()
+	server.setRecords("foo.example.com.",
+		dnsRecord{ip: net.ParseIP("10.0.0.1"), ttl: time.Second},
+		dnsRecord{ip: net.ParseIP("10.0.0.2"), ttl: time.Second},
+	)
+	server.setRecords("bar.example.com.", dnsRecord{ip: net.ParseIP("10.0.0.3"), ttl: time.Second})
+	dc := newTestDNSCluster(server, v2.STRICT_DNS_CLUSTER, true)
+	defer dc.Stop()
+	dc.SetTargets([]v2.Host{
+		{
+			HostConfig: v2.HostConfig{Address: "foo.example.com:8080", Weight: 10},
+			MetaData:   api.Metadata{"zone": "a"},
+		},
+		{
+			HostConfig: v2.HostConfig{Address: "bar.example.com:8080"},
+		},
+		{
+			HostConfig: v2.HostConfig{Address: "127.0.0.1:8080"},
+		},
+	})
+	hosts := waitHosts(t, dc, "10.0.0.1:8080,10.0.0.2:8080,10.0.0.3:8080,127.0.0.1:8080")
+	for _, h := range hosts {
+		switch h.AddressString() {
+		case "10.0.0.1:8080", "10.0.0.2:8080":
+			if h.Hostname() != "foo.example.com" || h.Weight() != 10 || h.Metadata()["zone"] != "a" {
+				t.Errorf("host %s config is not expected: %v", h.AddressString(), h.Config())
+			}
+		case "127.0.0.1:8080":
+			if h.Hostname() != "" {
+				t.Errorf("ip host should not have hostname, but got %s", h.Hostname())
+			}
+		}
+	}
+	var reused types.Host
+	for _, h := range hosts {
+		if h.AddressString() == "10.0.0.1:8080" {
+			reused = h
+		}
+	}
+	// the ttl is respected, and the unchanged hosts are reused
+	server.setRecords("foo.example.com.", dnsRecord{ip: net.ParseIP("10.0.0.1"), ttl: time.Second})
+	hosts = waitHosts(t, dc, "10.0.0.1:8080,10.0.0.3:8080,127.0.0.1:8080")
+	for _, h := range hosts {
+		if h.AddressString() == "10.0.0.1:8080" && h != reused {
+			t.Error("unchanged host expected to be reused")
+		}
+	}
+	// resolve failed, the hosts resolved last time are kept
+	server.setRecords("bar.example.com.")
+	count := server.queryCount()
+	for i := 0; i < 300 && server.queryCount() < count+4; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if server.queryCount() < count+4 {
+		t.Fatal("hosts expected to be resolved again")
+	}
+	waitHosts(t, dc, "10.0.0.1:8080,10.0.0.3:8080,127.0.0.1:8080")
+}
+
+func TestLogicalDNSCluster(t *testing.T) {
+	server := newStubDNSServer(t)
+	defer server.close()
+	server.setRecords("foo.example.com.",
+		dnsRecord{ip: net.ParseIP("10.0.0.1"), ttl: time.Second},
+		dnsRecord{ip: net.ParseIP("10.0.0.2"), ttl: time.Second},
+	)
+	dc := newTestDNSCluster(server, v2.LOGICAL_DNS_CLUSTER, false)
+	defer dc.Stop()
+	dc.SetTargets([]v2.Host{
+		{
+			HostConfig: v2.HostConfig{Address: "foo.example.com:8080"},
+		},
+	})
+	waitHosts(t, dc, "10.0.0.1:8080")
+	// the ttl is not respected, the refresh rate is used
+	count := server.queryCount()
+	time.Sleep(1500 * time.Millisecond)
+	if server.queryCount() != count {
+		t.Errorf("hosts expected not to be resolved before refresh rate, queries: %d", server.queryCount()-count)
+	}
+	// targets changed, resolve immediately
+	server.setRecords("bar.example.com.", dnsRecord{ip: net.ParseIP("10.0.0.3"), ttl: time.Second})
+	dc.SetTargets([]v2.Host{
+		{
+			HostConfig: v2.HostConfig{Address: "bar.example.com:8080"},
+		},
+	})
+	waitHosts(t, dc, "10.0.0.3:8080")
+}
+
+func TestClusterManagerDNSCluster(t *testing.T) {
+	server := newStubDNSServer(t)
+	defer server.close()
+	server.setRecords("foo.example.com.", dnsRecord{ip: net.ParseIP("10.0.0.1"), ttl: time.Second})
+	config := v2.Cluster{
+		Name:            "dns_cluster",
+		ClusterType:     v2.STRICT_DNS_CLUSTER,
+		LbType:          v2.LB_ROUNDROBIN,
+		DnsLookupFamily: v2.DNS_V4_ONLY,
+		DnsResolvers:    []string{server.addr()},
+	}
+	hosts := []v2.Host{
+		{
+			HostConfig: v2.HostConfig{Address: "foo.example.com:8080"},
+		},
+	}
+	cm := NewClusterManagerSingleton(nil, nil)
+	if err := cm.AddOrUpdatePrimaryCluster(config); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.UpdateClusterHosts("dns_cluster", hosts); err != nil {
+		t.Fatal(err)
+	}
+	getCluster := func() types.Cluster {
+		ci, _ := clusterMangerInstance.clustersMap.Load("dns_cluster")
+		return ci.(types.Cluster)
+	}
+	waitHosts(t, getCluster(), "10.0.0.1:8080")
+	// update cluster config, the targets are kept
+	server.setRecords("foo.example.com.", dnsRecord{ip: net.ParseIP("10.0.0.2"), ttl: time.Second})
+	config.LbType = v2.LB_RANDOM
+	old := getCluster().(*dnsCluster)
+	if err := cm.AddOrUpdatePrimaryCluster(config); err != nil {
+		t.Fatal(err)
+	}
+	waitHosts(t, getCluster(), "10.0.0.2:8080")
+	select {
+	case <-old.stopChan:
+	default:
+		t.Error("old dns cluster expected to be stopped")
+	}
+	// append and remove the targets
+	server.setRecords("bar.example.com.", dnsRecord{ip: net.ParseIP("10.0.0.3"), ttl: time.Second})
+	if err := cm.AppendClusterHosts("dns_cluster", []v2.Host{
+		{
+			HostConfig: v2.HostConfig{Address: "bar.example.com:8080"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	waitHosts(t, getCluster(), "10.0.0.2:8080,10.0.0.3:8080")
+	if err := cm.RemoveClusterHosts("dns_cluster", []string{"foo.example.com:8080"}); err != nil {
+		t.Fatal(err)
+	}
+	waitHosts(t, getCluster(), "10.0.0.3:8080")
+	snap := cm.GetClusterSnapshot(context.Background(), "dns_cluster")
+	if snap.LoadBalancer().ChooseHost(nil).AddressString() != "10.0.0.3:8080" {
+		t.Error("resolved host expected to be chosen")
+	}
+	dc := getCluster().(*dnsCluster)
+	if err := cm.RemovePrimaryCluster("dns_cluster"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-dc.stopChan:
+	default:
+		t.Error("removed dns cluster expected to be stopped")
+	}
+}
@@ -0,0 +1,333 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+const (
+	defaultDNSTimeout    = 5 * time.Second
+	defaultResolvConf    = "/etc/resolv.conf"
+	defaultDNSServer     = "127.0.0.1:53"
+	maxDNSUDPPayloadSize = 512
+)
+
+var (
+	errDNSNoSuchHost  = errors.New("no such host")
+	errDNSInvalidResp = errors.New("invalid dns response")
+)
+
+// dnsRecord is a resolved address, the ttl is zero if the address never expires
+type dnsRecord struct {
+	ip  net.IP
+	ttl time.Duration
+}
+
+// dnsResolver resolves the hostnames by the dns servers directly, so the ttl of records can be used.
+// The same as the system resolver, the search domains and ndots in resolv.conf are used for the relative names
+type dnsResolver struct {
+	servers []string
+	search  []string
+	ndots   int
+	timeout time.Duration
+}
+
+// newDNSResolver creates a dnsResolver, the servers in resolv.conf are used if no servers are configured
+func newDNSResolver(servers []string) *dnsResolver {
+	conf := readResolvConf(defaultResolvConf)
+	if len(servers) == 0 {
+		servers = conf.servers
+	}
+	return &dnsResolver{
+		servers: servers,
+		search:  conf.search,
+		ndots:   conf.ndots,
+		timeout: defaultDNSTimeout,
+	}
+}
+
+// resolvConf is the config in resolv.conf that the resolver uses
+type resolvConf struct {
+	servers []string
+	search  []string
+	ndots   int
+}
+
+// readResolvConf reads the nameservers, search domains and ndots option in resolv.conf
+func readResolvConf(path string) *resolvConf {
+	conf := &resolvConf{
+		ndots: 1,
+	}
+	if f, err := os.Open(path); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 2 {
+				continue
+			}
+			switch fields[0] {
+			case "nameserver":
+				conf.servers = append(conf.servers, net.JoinHostPort(fields[1], "53"))
+			case "domain":
+				// the last one of domain and search is used
+				conf.search = []string{ensureRooted(fields[1])}
+			case "search":
+				conf.search = nil
+				for _, domain := range fields[1:] {
+					conf.search = append(conf.search, ensureRooted(domain))
+				}
+			case "options":
+				for _, option := range fields[1:] {
+					if strings.HasPrefix(option, "ndots:") {
+						if n, err := strconv.Atoi(option[len("ndots:"):]); err == nil && n >= 0 {
+							// the same limit as the system resolver
+							if n > 15 {
+								n = 15
+							}
+							conf.ndots = n
+						}
+					}
+				}
+			}
+		}
+	}
+	if len(conf.servers) == 0 {
+		conf.servers = []string{defaultDNSServer}
+	}
+	return conf
+}
+
+func ensureRooted(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// nameList returns the names to query in order.
+// A relative name with at least ndots dots is queried as is first, otherwise it is queried with the search domains first
+func (r *dnsResolver) nameList(host string) []string {
+	if strings.HasSuffix(host, ".") {
+		return []string{host}
+	}
+	names := make([]string, 0, len(r.search)+1)
+	rooted := host + "."
+	if strings.Count(host, ".") >= r.ndots {
+		names = append(names, rooted)
+	}
+	for _, domain := range r.search {
+		names = append(names, rooted+domain)
+	}
+	if strings.Count(host, ".") < r.ndots {
+		names = append(names, rooted)
+	}
+	return names
+}
+
+// resolve resolves the hostname by the lookup family.
+// The names in the name list are tried in order until a name has records
+func (r *dnsResolver) resolve(host string, family v2.DnsLookupFamily) ([]dnsRecord, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []dnsRecord{{ip: ip}}, nil
+	}
+	var lastErr error
+	for _, name := range r.nameList(host) {
+		records, err := r.resolveName(name, family)
+		if err == nil && len(records) > 0 {
+			return records, nil
+		}
+		// the name exists but has no records of the family, try the next name too
+		if err != nil && err != errDNSNoSuchHost {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (r *dnsResolver) resolveName(name string, family v2.DnsLookupFamily) ([]dnsRecord, error) {
+	switch family {
+	case v2.DNS_V4_ONLY:
+		return r.lookup(name, dnsmessage.TypeA)
+	case v2.DNS_V6_ONLY:
+		return r.lookup(name, dnsmessage.TypeAAAA)
+	default:
+		records, err := r.lookup(name, dnsmessage.TypeAAAA)
+		if err == nil && len(records) > 0 {
+			return records, nil
+		}
+		return r.lookup(name, dnsmessage.TypeA)
+	}
+}
+
+// lookup queries the servers in order until a server responds
+func (r *dnsResolver) lookup(host string, qtype dnsmessage.Type) ([]dnsRecord, error) {
+	name, err := dnsmessage.NewName(host)
+	if err != nil {
+		return nil, err
+	}
+	var lastErr error
+	for _, server := range r.servers {
+		records, err := r.exchange(server, name, qtype)
+		if err == nil || err == errDNSNoSuchHost {
+			return records, err
+		}
+		lastErr = fmt.Errorf("query dns server %s failed: %v", server, err)
+	}
+	return nil, lastErr
+}
+
+func (r *dnsResolver) exchange(server string, name dnsmessage.Name, qtype dnsmessage.Type) ([]dnsRecord, error) {
+	id := uint16(rand.Uint32())
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:               id,
+			RecursionDesired: true,
+		},
+		Questions: []dnsmessage.Question{
+			{
+				Name:  name,
+				Type:  qtype,
+				Class: dnsmessage.ClassINET,
+			},
+		},
+	}
+	query, err := msg.Pack()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.roundTrip("udp", server, query)
+	if err != nil {
+		return nil, err
+	}
+	records, truncated, err := parseDNSResponse(resp, id, qtype)
+	// the response is too large for udp, retry by tcp
+	if err == nil && truncated {
+		if resp, err = r.roundTrip("tcp", server, query); err != nil {
+			return nil, err
+		}
+		records, _, err = parseDNSResponse(resp, id, qtype)
+	}
+	return records, err
+}
+
+func (r *dnsResolver) roundTrip(network, server string, query []byte) ([]byte, error) {
+	conn, err := net.DialTimeout(network, server, r.timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(r.timeout))
+	if network == "udp" {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, maxDNSUDPPayloadSize)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+	// dns over tcp has a two bytes length prefix
+	buf := make([]byte, 2+len(query))
+	binary.BigEndian.PutUint16(buf, uint16(len(query)))
+	copy(buf[2:], query)
+	if _, err := conn.Write(buf); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, binary.BigEndian.Uint16(buf[:2]))
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// parseDNSResponse returns the A or AAAA records in the answers
+func parseDNSResponse(resp []byte, id uint16, qtype dnsmessage.Type) ([]dnsRecord, bool, error) {
+	var p dnsmessage.Parser
+	header, err := p.Start(resp)
+	if err != nil {
+		return nil, false, err
+	}
+	if header.ID != id || !header.Response {
+		return nil, false, errDNSInvalidResp
+	}
+	switch header.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, false, errDNSNoSuchHost
+	default:
+		return nil, false, fmt.Errorf("dns server responds %v", header.RCode)
+	}
+	if header.Truncated {
+		return nil, true, nil
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil, false, err
+	}
+	var records []dnsRecord
+	for {
+		h, err := p.AnswerHeader()
+		if err == dnsmessage.ErrSectionDone {
+			break
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		ttl := time.Duration(h.TTL) * time.Second
+		switch {
+		case h.Type == dnsmessage.TypeA && qtype == dnsmessage.TypeA:
+			r, err := p.AResource()
+			if err != nil {
+				return nil, false, err
+			}
+			records = append(records, dnsRecord{ip: net.IP(r.A[:]), ttl: ttl})
+		case h.Type == dnsmessage.TypeAAAA && qtype == dnsmessage.TypeAAAA:
+			r, err := p.AAAAResource()
+			if err != nil {
+				return nil, false, err
+			}
+			records = append(records, dnsRecord{ip: net.IP(r.AAAA[:]), ttl: ttl})
+		default:
+			// CNAME records are skipped, the servers return the A or AAAA records of the canonical name
+			if err := p.SkipAnswer(); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+	return records, false, nil
+}
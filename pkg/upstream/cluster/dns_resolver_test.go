@@ -0,0 +1,247 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+// stubDNSServer is a dns server that responds the configured records
+type stubDNSServer struct {
+	conn    net.PacketConn
+	mutex   sync.Mutex
+	records map[string][]dnsRecord // name -> records, the A and AAAA records are distinguished by ip length
+	queries int32
+}
+
+func newStubDNSServer(t *testing.T) *stubDNSServer {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &stubDNSServer{
+		conn:    conn,
+		records: map[string][]dnsRecord{},
+	}
+	go s.serve()
+	return s
+}
+
+func (s *stubDNSServer) addr() string {
+	return s.conn.LocalAddr().String()
+}
+
+func (s *stubDNSServer) close() {
+	s.conn.Close()
+}
+
+func (s *stubDNSServer) setRecords(name string, records ...dnsRecord) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(records) == 0 {
+		delete(s.records, name)
+		return
+	}
+	s.records[name] = records
+}
+
+func (s *stubDNSServer) queryCount() int {
+	return int(atomic.LoadInt32(&s.queries))
+}
+
+func (s *stubDNSServer) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		atomic.AddInt32(&s.queries, 1)
+		if resp := s.response(buf[:n]); resp != nil {
+			s.conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (s *stubDNSServer) response(query []byte) []byte {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil || len(msg.Questions) != 1 {
+		return nil
+	}
+	q := msg.Questions[0]
+	s.mutex.Lock()
+	records, ok := s.records[q.Name.String()]
+	s.mutex.Unlock()
+	msg.Header.Response = true
+	if !ok {
+		msg.Header.RCode = dnsmessage.RCodeNameError
+	}
+	for _, record := range records {
+		h := dnsmessage.ResourceHeader{
+			Name:  q.Name,
+			Class: dnsmessage.ClassINET,
+			TTL:   uint32(record.ttl / time.Second),
+		}
+		if ip4 := record.ip.To4(); ip4 != nil && q.Type == dnsmessage.TypeA {
+			r := &dnsmessage.AResource{}
+			copy(r.A[:], ip4)
+			h.Type = dnsmessage.TypeA
+			msg.Answers = append(msg.Answers, dnsmessage.Resource{Header: h, Body: r})
+		} else if record.ip.To4() == nil && q.Type == dnsmessage.TypeAAAA {
+			r := &dnsmessage.AAAAResource{}
+			copy(r.AAAA[:], record.ip.To16())
+			h.Type = dnsmessage.TypeAAAA
+			msg.Answers = append(msg.Answers, dnsmessage.Resource{Header: h, Body: r})
+		}
+	}
+	resp, err := msg.Pack()
+	if err != nil {
+		return nil
+	}
+	return resp
+}
+
+func TestDNSResolver(t *testing.T) {
+	server := newStubDNSServer(t)
+	defer server.close()
+	server.setRecords("example.com.",
+		dnsRecord{ip: net.ParseIP("10.0.0.1"), ttl: 30 * time.Second},
+		dnsRecord{ip: net.ParseIP("10.0.0.2"), ttl: 60 * time.Second},
+		dnsRecord{ip: net.ParseIP("fd00::1"), ttl: 10 * time.Second},
+	)
+	server.setRecords("v4.example.com.", dnsRecord{ip: net.ParseIP("10.0.0.3"), ttl: time.Second})
+	r := newDNSResolver([]string{server.addr()})
+	r.timeout = time.Second
+
+	records, err := r.resolve("example.com", v2.DNS_V4_ONLY)
+	if err != nil || len(records) != 2 {
+		t.Fatalf("resolve v4 failed, records: %v, error: %v", records, err)
+	}
+	if !records[0].ip.Equal(net.ParseIP("10.0.0.1")) || records[0].ttl != 30*time.Second {
+		t.Errorf("unexpected record: %v", records[0])
+	}
+	records, err = r.resolve("example.com", v2.DNS_V6_ONLY)
+	if err != nil || len(records) != 1 || !records[0].ip.Equal(net.ParseIP("fd00::1")) {
+		t.Errorf("resolve v6 failed, records: %v, error: %v", records, err)
+	}
+	// auto prefers v6
+	records, err = r.resolve("example.com", v2.DNS_AUTO)
+	if err != nil || len(records) != 1 || !records[0].ip.Equal(net.ParseIP("fd00::1")) {
+		t.Errorf("resolve auto failed, records: %v, error: %v", records, err)
+	}
+	// auto falls back to v4
+	records, err = r.resolve("v4.example.com", v2.DNS_AUTO)
+	if err != nil || len(records) != 1 || !records[0].ip.Equal(net.ParseIP("10.0.0.3")) {
+		t.Errorf("resolve auto fallback failed, records: %v, error: %v", records, err)
+	}
+	if _, err := r.resolve("unknown.example.com", v2.DNS_V4_ONLY); err != errDNSNoSuchHost {
+		t.Errorf("resolve unknown host expected no such host, but got %v", err)
+	}
+	// ip is not resolved
+	count := server.queryCount()
+	records, err = r.resolve("127.0.0.1", v2.DNS_AUTO)
+	if err != nil || len(records) != 1 || records[0].ttl != 0 || server.queryCount() != count {
+		t.Errorf("resolve ip failed, records: %v, error: %v", records, err)
+	}
+}
+
+func TestDNSResolverServerFailover(t *testing.T) {
+	server := newStubDNSServer(t)
+	defer server.close()
+	server.setRecords("example.com.", dnsRecord{ip: net.ParseIP("10.0.0.1"), ttl: time.Second})
+	// a server that never responds
+	silent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+	r := newDNSResolver([]string{silent.LocalAddr().String(), server.addr()})
+	r.timeout = 100 * time.Millisecond
+	records, err := r.resolve("example.com", v2.DNS_V4_ONLY)
+	if err != nil || len(records) != 1 {
+		t.Errorf("resolve failed, records: %v, error: %v", records, err)
+	}
+}
+
+func TestDNSResolverSearch(t *testing.T) {
+	server := newStubDNSServer(t)
+	defer server.close()
+	server.setRecords("svc.default.svc.cluster.local.", dnsRecord{ip: net.ParseIP("10.0.0.1"), ttl: time.Second})
+	server.setRecords("svc.other.", dnsRecord{ip: net.ParseIP("10.0.0.2"), ttl: time.Second})
+	server.setRecords("example.com.", dnsRecord{ip: net.ParseIP("10.0.0.3"), ttl: time.Second})
+	r := newDNSResolver([]string{server.addr()})
+	r.timeout = time.Second
+	r.search = []string{"default.svc.cluster.local.", "svc.cluster.local."}
+	r.ndots = 2
+	// less than ndots, the search domains are tried first
+	records, err := r.resolve("svc", v2.DNS_V4_ONLY)
+	if err != nil || len(records) != 1 || !records[0].ip.Equal(net.ParseIP("10.0.0.1")) {
+		t.Errorf("resolve with search domains failed, records: %v, error: %v", records, err)
+	}
+	records, err = r.resolve("svc.other", v2.DNS_V4_ONLY)
+	if err != nil || len(records) != 1 || !records[0].ip.Equal(net.ParseIP("10.0.0.2")) {
+		t.Errorf("resolve as is after search domains failed, records: %v, error: %v", records, err)
+	}
+	// no less than ndots, the name is tried as is first
+	count := server.queryCount()
+	records, err = r.resolve("www.example.com", v2.DNS_V4_ONLY)
+	if err != errDNSNoSuchHost || server.queryCount()-count != 3 {
+		t.Errorf("resolve unknown host expected all names are tried, records: %v, error: %v", records, err)
+	}
+	count = server.queryCount()
+	records, err = r.resolve("example.com.", v2.DNS_V4_ONLY)
+	if err != nil || len(records) != 1 || server.queryCount()-count != 1 {
+		t.Errorf("resolve rooted name expected no search domains, records: %v, error: %v", records, err)
+	}
+	if names := r.nameList("a.b.c"); len(names) != 3 || names[0] != "a.b.c." {
+		t.Errorf("unexpected name list: %v", names)
+	}
+}
+
+func TestReadResolvConf(t *testing.T) {
+	f, err := ioutil.TempFile("", "resolv.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("domain local\nsearch default.svc.cluster.local svc.cluster.local\nnameserver 10.0.0.10\nnameserver fd00::10\noptions ndots:5 timeout:1\n")
+	f.Close()
+	conf := readResolvConf(f.Name())
+	if len(conf.servers) != 2 || conf.servers[0] != "10.0.0.10:53" || conf.servers[1] != "[fd00::10]:53" {
+		t.Errorf("unexpected servers: %v", conf.servers)
+	}
+	if len(conf.search) != 2 || conf.search[0] != "default.svc.cluster.local." || conf.search[1] != "svc.cluster.local." {
+		t.Errorf("unexpected search domains: %v", conf.search)
+	}
+	if conf.ndots != 5 {
+		t.Errorf("unexpected ndots: %d", conf.ndots)
+	}
+	conf = readResolvConf("/not/exists")
+	if len(conf.servers) != 1 || conf.servers[0] != defaultDNSServer || len(conf.search) != 0 || conf.ndots != 1 {
+		t.Errorf("unexpected default config: %v", conf)
+	}
+}
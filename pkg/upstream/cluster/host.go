@@ -26,6 +26,7 @@ import (
 	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/overload"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/pkg/utils"
 )
@@ -39,6 +40,8 @@ type simpleHost struct {
 	metaData      api.Metadata
 	tlsDisable    bool
 	weight        uint32
+	priority      uint32
+	zone          string
 	healthFlags   uint64
 }
 
@@ -54,6 +57,8 @@ func NewSimpleHost(config v2.Host, clusterInfo types.ClusterInfo) types.Host {
 		metaData:      config.MetaData,
 		tlsDisable:    config.TLSDisable,
 		weight:        config.Weight,
+		priority:      config.Priority,
+		zone:          config.Zone,
 	}
 }
 
@@ -93,6 +98,8 @@ func (sh *simpleHost) Config() v2.Host {
 			Hostname:   sh.hostname,
 			TLSDisable: sh.tlsDisable,
 			Weight:     sh.weight,
+			Priority:   sh.priority,
+			Zone:       sh.zone,
 		},
 		MetaData: sh.metaData,
 	}
@@ -109,7 +116,8 @@ func (sh *simpleHost) CreateConnection(context context.Context) types.CreateConn
 		tlsMng = sh.clusterInfo.TLSMng()
 	}
 	clientConn := network.NewClientConnection(nil, sh.clusterInfo.ConnectTimeout(), tlsMng, sh.Address(), nil)
-	clientConn.SetBufferLimit(sh.clusterInfo.ConnBufferLimitBytes())
+	// the buffer limit is shrunk if the process is overloaded
+	clientConn.SetBufferLimit(overload.BufferLimit(sh.clusterInfo.ConnBufferLimitBytes()))
 
 	return types.CreateConnectionData{
 		Connection: clientConn,
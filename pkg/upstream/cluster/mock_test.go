@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/network"
 	"mosn.io/mosn/pkg/router"
 	"mosn.io/mosn/pkg/types"
@@ -35,6 +36,15 @@ type mockHost struct {
 	types.Host
 }
 
+func (h *mockHost) Config() v2.Host {
+	return v2.Host{
+		HostConfig: v2.HostConfig{
+			Address:  h.addr,
+			Hostname: h.name,
+		},
+	}
+}
+
 func (h *mockHost) Hostname() string {
 	return h.name
 }
@@ -103,9 +113,13 @@ func makePool(size int) *ipPool {
 type mockConnPool struct {
 	h types.Host
 	types.ConnectionPool
+	shutdown bool
 }
 
-const mockProtocol = types.ProtocolName("mock")
+const (
+	mockProtocol      = types.ProtocolName("mock")
+	mockOtherProtocol = types.ProtocolName("mock_other")
+)
 
 func (p *mockConnPool) Protocol() types.ProtocolName {
 	return mockProtocol
@@ -120,15 +134,18 @@ func (p *mockConnPool) SupportTLS() bool {
 }
 
 func (p *mockConnPool) Shutdown() {
+	p.shutdown = true
 }
 
 func init() {
-	network.RegisterNewPoolFactory(mockProtocol, func(h types.Host) types.ConnectionPool {
-		return &mockConnPool{
-			h: h,
-		}
-	})
-	types.RegisterConnPoolFactory(mockProtocol, true)
+	for _, protocol := range []types.ProtocolName{mockProtocol, mockOtherProtocol} {
+		network.RegisterNewPoolFactory(protocol, func(h types.Host) types.ConnectionPool {
+			return &mockConnPool{
+				h: h,
+			}
+		})
+		types.RegisterConnPoolFactory(protocol, true)
+	}
 }
 
 type mockLbContext struct {
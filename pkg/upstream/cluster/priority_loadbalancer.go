@@ -0,0 +1,283 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"sort"
+	"sync/atomic"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/configmanager"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/utils"
+)
+
+// defaultOverprovisioningFactor is the same as envoy's default value
+const defaultOverprovisioningFactor = 140
+
+// localZone is the zone that MOSN deployed in
+var localZone atomic.Value
+
+func init() {
+	configmanager.RegisterConfigParsedListener(configmanager.ParseCallbackKeyServiceRgtInfo, func(data interface{}, endParsing bool) error {
+		if info, ok := data.(v2.ServiceRegistryInfo); ok {
+			SetLocalZone(info.ServiceAppInfo.Zone)
+		}
+		return nil
+	})
+}
+
+// SetLocalZone sets the zone that MOSN deployed in,
+// the load balancer prefers the hosts in the same zone if the hosts have zone configured
+func SetLocalZone(zone string) {
+	localZone.Store(zone)
+}
+
+// GetLocalZone returns the zone that MOSN deployed in
+func GetLocalZone() string {
+	zone, _ := localZone.Load().(string)
+	return zone
+}
+
+// needPriorityLoadBalancer returns true if any host have a priority or a zone
+func needPriorityLoadBalancer(hosts types.HostSet) bool {
+	for _, h := range hosts.Hosts() {
+		cfg := h.Config()
+		if cfg.Priority != 0 || cfg.Zone != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hostGroup is a group of hosts with a load balancer
+type hostGroup struct {
+	hosts types.HostSet
+	lb    types.LoadBalancer
+}
+
+func newHostGroup(lbType types.LoadBalancerType, hs *hostSet, predicate types.HostPredicate) *hostGroup {
+	sub := hs.createSubset(predicate)
+	return &hostGroup{
+		hosts: sub,
+		lb:    NewLoadBalancer(lbType, sub),
+	}
+}
+
+// health returns the percentage of the group can take the traffic, range [0, 100]
+func (g *hostGroup) health(factor uint32) uint32 {
+	total := len(g.hosts.Hosts())
+	if total == 0 {
+		return 0
+	}
+	health := uint32(len(g.hosts.HealthyHosts())) * factor / uint32(total)
+	if health > 100 {
+		return 100
+	}
+	return health
+}
+
+// priorityGroup contains the hosts with the same priority, and the hosts are grouped by zone
+type priorityGroup struct {
+	priority uint32
+	*hostGroup
+	zones     map[string]*hostGroup
+	zoneNames []string
+}
+
+// priorityLoadBalancer chooses a priority first, and then chooses a zone in the priority.
+// the traffic is spilled over to the lower priority if the higher priority is not healthy enough,
+// and the hosts in the same zone as MOSN are preferred in a priority.
+type priorityLoadBalancer struct {
+	factor     uint32
+	priorities []*priorityGroup // sorted by priority
+	hosts      types.HostSet
+	rand       *utils.FastRandom
+}
+
+// newPriorityLoadBalancer creates a priorityLoadBalancer of the hosts matched the predicate in the host set,
+// a nil predicate matches all the hosts. The subset load balancer uses the predicate to balance the hosts in a subset.
+func newPriorityLoadBalancer(info *clusterInfo, hs *hostSet, predicate types.HostPredicate) types.LoadBalancer {
+	factor := info.overprovisioningFactor
+	if factor == 0 {
+		factor = defaultOverprovisioningFactor
+	}
+	lb := &priorityLoadBalancer{
+		factor: factor,
+		hosts:  hs,
+		rand:   utils.NewFastRandom(),
+	}
+	if predicate == nil {
+		predicate = func(types.Host) bool {
+			return true
+		}
+	} else {
+		lb.hosts = hs.createSubset(predicate)
+	}
+	groups := map[uint32]*priorityGroup{}
+	for _, h := range lb.hosts.Hosts() {
+		cfg := h.Config()
+		pg, ok := groups[cfg.Priority]
+		if !ok {
+			priority := cfg.Priority
+			pg = &priorityGroup{
+				priority: priority,
+				hostGroup: newHostGroup(info.lbType, hs, func(host types.Host) bool {
+					return predicate(host) && host.Config().Priority == priority
+				}),
+				zones: map[string]*hostGroup{},
+			}
+			groups[priority] = pg
+			lb.priorities = append(lb.priorities, pg)
+		}
+		if _, ok := pg.zones[cfg.Zone]; !ok {
+			priority, zone := cfg.Priority, cfg.Zone
+			pg.zones[zone] = newHostGroup(info.lbType, hs, func(host types.Host) bool {
+				c := host.Config()
+				return predicate(host) && c.Priority == priority && c.Zone == zone
+			})
+			pg.zoneNames = append(pg.zoneNames, zone)
+		}
+	}
+	sort.Slice(lb.priorities, func(i, j int) bool {
+		return lb.priorities[i].priority < lb.priorities[j].priority
+	})
+	for _, pg := range lb.priorities {
+		sort.Strings(pg.zoneNames)
+	}
+	return lb
+}
+
+func (lb *priorityLoadBalancer) random(n uint32) uint32 {
+	return lb.rand.Uint32n(n)
+}
+
+// priorityLoads returns the percentage of traffic that each priority takes.
+// a priority takes its health percentage of traffic, and the remaining is spilled over to the next priority.
+// if the sum of all priorities' health is less than 100, the loads are normalized.
+func (lb *priorityLoadBalancer) priorityLoads() []uint32 {
+	loads := make([]uint32, len(lb.priorities))
+	var total uint32
+	for i, pg := range lb.priorities {
+		loads[i] = pg.health(lb.factor)
+		total += loads[i]
+	}
+	if total == 0 {
+		// no healthy hosts, all traffic goes to the highest priority
+		loads[0] = 100
+		return loads
+	}
+	if total < 100 {
+		var sum uint32
+		for i := range loads {
+			loads[i] = loads[i] * 100 / total
+			sum += loads[i]
+		}
+		// rounding remainder goes to the first priority that takes traffic
+		for i := range loads {
+			if loads[i] > 0 {
+				loads[i] += 100 - sum
+				break
+			}
+		}
+		return loads
+	}
+	remain := uint32(100)
+	for i := range loads {
+		if loads[i] > remain {
+			loads[i] = remain
+		}
+		remain -= loads[i]
+	}
+	return loads
+}
+
+func (lb *priorityLoadBalancer) choosePriority() *priorityGroup {
+	if len(lb.priorities) == 1 {
+		return lb.priorities[0]
+	}
+	loads := lb.priorityLoads()
+	r := lb.random(100)
+	for i, load := range loads {
+		if r < load {
+			return lb.priorities[i]
+		}
+		r -= load
+	}
+	return lb.priorities[0]
+}
+
+// chooseRemoteZone chooses a zone except the local zone, weighted by the healthy hosts number
+func (lb *priorityLoadBalancer) chooseRemoteZone(pg *priorityGroup, local string) *hostGroup {
+	var total uint32
+	for _, name := range pg.zoneNames {
+		if name != local {
+			total += uint32(len(pg.zones[name].hosts.HealthyHosts()))
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	r := lb.random(total)
+	for _, name := range pg.zoneNames {
+		if name == local {
+			continue
+		}
+		n := uint32(len(pg.zones[name].hosts.HealthyHosts()))
+		if r < n {
+			return pg.zones[name]
+		}
+		r -= n
+	}
+	return nil
+}
+
+func (lb *priorityLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	if len(lb.priorities) == 0 {
+		return nil
+	}
+	pg := lb.choosePriority()
+	zone := GetLocalZone()
+	if local, ok := pg.zones[zone]; ok && zone != "" && len(pg.zoneNames) > 1 {
+		// the local zone takes traffic as its health percentage, the others are spilled over to remote zones
+		if lb.random(100) < local.health(lb.factor) {
+			if host := local.lb.ChooseHost(context); host != nil {
+				return host
+			}
+		}
+		if remote := lb.chooseRemoteZone(pg, zone); remote != nil {
+			if host := remote.lb.ChooseHost(context); host != nil {
+				if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+					log.DefaultLogger.Debugf("[upstream] [priority lb] local zone %s is not healthy enough, choose host %s in remote zone", zone, host.AddressString())
+				}
+				return host
+			}
+		}
+	}
+	return pg.lb.ChooseHost(context)
+}
+
+func (lb *priorityLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
+	return len(lb.hosts.Hosts()) > 0
+}
+
+func (lb *priorityLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
+	return len(lb.hosts.Hosts())
+}
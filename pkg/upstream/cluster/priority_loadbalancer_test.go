@@ -0,0 +1,252 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+func createPriorityHostSet(info *clusterInfo, cfgs []v2.Host) *hostSet {
+	var hosts []types.Host
+	for _, cfg := range cfgs {
+		hosts = append(hosts, NewSimpleHost(cfg, info))
+	}
+	hs := &hostSet{}
+	hs.setFinalHost(hosts)
+	return hs
+}
+
+func makePriorityHosts(prefix string, num int, priority uint32, zone string) []v2.Host {
+	hosts := make([]v2.Host, 0, num)
+	for i := 0; i < num; i++ {
+		hosts = append(hosts, v2.Host{
+			HostConfig: v2.HostConfig{
+				Address:  fmt.Sprintf("%s.%d:80", prefix, i),
+				Priority: priority,
+				Zone:     zone,
+			},
+		})
+	}
+	return hosts
+}
+
+func setHostsUnhealthy(hs *hostSet, prefix string, num int) {
+	count := 0
+	for _, h := range hs.Hosts() {
+		if count >= num {
+			return
+		}
+		if h.Health() && h.AddressString()[:len(prefix)] == prefix {
+			h.SetHealthFlag(types.FAILED_ACTIVE_HC)
+			hs.refreshHealthHost(h)
+			count++
+		}
+	}
+}
+
+func countChosen(lb types.LoadBalancer, prefixes []string, times int) map[string]int {
+	result := map[string]int{}
+	for i := 0; i < times; i++ {
+		h := lb.ChooseHost(nil)
+		if h == nil {
+			result[""]++
+			continue
+		}
+		for _, prefix := range prefixes {
+			if h.AddressString()[:len(prefix)] == prefix {
+				result[prefix]++
+			}
+		}
+	}
+	return result
+}
+
+func TestPriorityLoads(t *testing.T) {
+	info := &clusterInfo{name: "priority", lbType: types.RoundRobin}
+	var cfgs []v2.Host
+	cfgs = append(cfgs, makePriorityHosts("127.0.0", 10, 0, "")...)
+	cfgs = append(cfgs, makePriorityHosts("127.0.1", 10, 1, "")...)
+	hs := createPriorityHostSet(info, cfgs)
+	if !needPriorityLoadBalancer(hs) {
+		t.Fatal("expected priority load balancer")
+	}
+	lb := newPriorityLoadBalancer(info, hs, nil).(*priorityLoadBalancer)
+	check := func(expected []uint32) {
+		loads := lb.priorityLoads()
+		for i := range expected {
+			if loads[i] != expected[i] {
+				t.Fatalf("expected loads %v, but got %v", expected, loads)
+			}
+		}
+	}
+	// all healthy
+	check([]uint32{100, 0})
+	// 8/10 healthy, 8 * 1.4 > 100%
+	setHostsUnhealthy(hs, "127.0.0", 2)
+	check([]uint32{100, 0})
+	// 5/10 healthy, 5 * 1.4 = 70%
+	setHostsUnhealthy(hs, "127.0.0", 3)
+	check([]uint32{70, 30})
+	// p1 is 2/10 healthy, sum is 70 + 28 = 98, normalized
+	setHostsUnhealthy(hs, "127.0.1", 8)
+	check([]uint32{72, 28})
+	// all unhealthy, the highest priority takes all the traffic
+	setHostsUnhealthy(hs, "127.0.0", 5)
+	setHostsUnhealthy(hs, "127.0.1", 2)
+	check([]uint32{100, 0})
+	if h := lb.ChooseHost(nil); h != nil {
+		t.Fatalf("expected no host chosen, but got %s", h.AddressString())
+	}
+}
+
+func TestPriorityChooseHost(t *testing.T) {
+	info := &clusterInfo{name: "priority", lbType: types.Random, overprovisioningFactor: 100}
+	var cfgs []v2.Host
+	cfgs = append(cfgs, makePriorityHosts("127.0.0", 10, 0, "")...)
+	cfgs = append(cfgs, makePriorityHosts("127.0.1", 10, 1, "")...)
+	hs := createPriorityHostSet(info, cfgs)
+	lb := newPriorityLoadBalancer(info, hs, nil)
+	prefixes := []string{"127.0.0", "127.0.1"}
+	result := countChosen(lb, prefixes, 1000)
+	if result["127.0.0"] != 1000 {
+		t.Fatalf("all traffic should be routed to priority 0, but got %v", result)
+	}
+	setHostsUnhealthy(hs, "127.0.0", 5)
+	result = countChosen(lb, prefixes, 1000)
+	if result["127.0.0"] < 400 || result["127.0.1"] < 400 || result[""] != 0 {
+		t.Fatalf("traffic should be spilled over to priority 1, but got %v", result)
+	}
+	setHostsUnhealthy(hs, "127.0.0", 5)
+	result = countChosen(lb, prefixes, 1000)
+	if result["127.0.1"] != 1000 {
+		t.Fatalf("all traffic should be routed to priority 1, but got %v", result)
+	}
+}
+
+func TestZoneAwareChooseHost(t *testing.T) {
+	defer SetLocalZone("")
+	info := &clusterInfo{name: "zone", lbType: types.RoundRobin}
+	var cfgs []v2.Host
+	cfgs = append(cfgs, makePriorityHosts("127.0.0", 10, 0, "zone-a")...)
+	cfgs = append(cfgs, makePriorityHosts("127.0.1", 10, 0, "zone-b")...)
+	cfgs = append(cfgs, makePriorityHosts("127.0.2", 10, 1, "zone-a")...)
+	hs := createPriorityHostSet(info, cfgs)
+	lb := newPriorityLoadBalancer(info, hs, nil)
+	prefixes := []string{"127.0.0", "127.0.1", "127.0.2"}
+	// no local zone, choose in all hosts of priority 0
+	result := countChosen(lb, prefixes, 1000)
+	if result["127.0.0"] != 500 || result["127.0.1"] != 500 {
+		t.Fatalf("traffic should be balanced in priority 0, but got %v", result)
+	}
+	SetLocalZone("zone-a")
+	result = countChosen(lb, prefixes, 1000)
+	if result["127.0.0"] != 1000 {
+		t.Fatalf("all traffic should be routed to local zone, but got %v", result)
+	}
+	// local zone is not healthy enough, spill over to zone b
+	setHostsUnhealthy(hs, "127.0.0", 5)
+	result = countChosen(lb, prefixes, 1000)
+	if result["127.0.0"] < 600 || result["127.0.1"] < 200 || result["127.0.2"] != 0 {
+		t.Fatalf("traffic should be spilled over to remote zone, but got %v", result)
+	}
+	// local zone is down, priority 0 takes 70% of traffic in remote zone, and the others are spilled over to priority 1
+	setHostsUnhealthy(hs, "127.0.0", 5)
+	result = countChosen(lb, prefixes, 1000)
+	if result["127.0.0"] != 0 || result["127.0.1"] < 600 || result["127.0.2"] < 200 {
+		t.Fatalf("traffic should be routed to remote zone and priority 1, but got %v", result)
+	}
+	// the local zone is not exists in the priority 1
+	SetLocalZone("zone-c")
+	result = countChosen(lb, prefixes, 1000)
+	if result["127.0.0"] != 0 || result[""] != 0 {
+		t.Fatalf("traffic should be routed to healthy hosts, but got %v", result)
+	}
+}
+
+func TestClusterUsePriorityLoadBalancer(t *testing.T) {
+	cluster := newSimpleCluster(v2.Cluster{
+		Name:   "priority_cluster",
+		LbType: v2.LB_ROUNDROBIN,
+	})
+	var hosts []types.Host
+	for _, cfg := range makePriorityHosts("127.0.0", 2, 0, "") {
+		hosts = append(hosts, NewSimpleHost(cfg, cluster.info))
+	}
+	cluster.UpdateHosts(hosts)
+	if _, ok := cluster.lbInstance.(*priorityLoadBalancer); ok {
+		t.Fatal("hosts without priority and zone should not use priority load balancer")
+	}
+	for _, cfg := range makePriorityHosts("127.0.1", 2, 1, "") {
+		hosts = append(hosts, NewSimpleHost(cfg, cluster.info))
+	}
+	cluster.UpdateHosts(hosts)
+	if _, ok := cluster.lbInstance.(*priorityLoadBalancer); !ok {
+		t.Fatal("hosts with priority should use priority load balancer")
+	}
+	for i := 0; i < 10; i++ {
+		h := cluster.Snapshot().LoadBalancer().ChooseHost(nil)
+		if h == nil || h.Config().Priority != 0 {
+			t.Fatalf("expected host in priority 0, but got %v", h)
+		}
+	}
+}
+
+func TestSubsetUsePriorityLoadBalancer(t *testing.T) {
+	info := &clusterInfo{
+		name:   "subset_priority",
+		lbType: types.RoundRobin,
+		stats:  newClusterStats("subset_priority"),
+		lbSubsetInfo: NewLBSubsetInfo(&v2.LBSubsetConfig{
+			FallBackPolicy:  uint8(types.DefaultSubset),
+			DefaultSubset:   map[string]string{"version": "1"},
+			SubsetSelectors: [][]string{{"version"}},
+		}),
+	}
+	var cfgs []v2.Host
+	cfgs = append(cfgs, makePriorityHosts("127.0.0", 10, 0, "")...)
+	cfgs = append(cfgs, makePriorityHosts("127.0.1", 10, 1, "")...)
+	cfgs = append(cfgs, makePriorityHosts("127.0.2", 10, 0, "")...)
+	for i := range cfgs {
+		version := "1"
+		if cfgs[i].Address[:7] == "127.0.2" {
+			version = "2"
+		}
+		cfgs[i].MetaData = api.Metadata{"version": version}
+	}
+	hs := createPriorityHostSet(info, cfgs)
+	lb := NewSubsetLoadBalancer(info, hs).(*subsetLoadBalancer)
+	if _, ok := lb.fallbackSubset.LoadBalancer().(*priorityLoadBalancer); !ok {
+		t.Fatal("subset with priority hosts should use priority load balancer")
+	}
+	prefixes := []string{"127.0.0", "127.0.1", "127.0.2"}
+	result := countChosen(lb, prefixes, 1000)
+	if result["127.0.0"] != 1000 {
+		t.Fatalf("all traffic should be routed to priority 0 in the subset, but got %v", result)
+	}
+	// the priorities are balanced in the subset only
+	setHostsUnhealthy(hs, "127.0.0", 5)
+	result = countChosen(lb, prefixes, 1000)
+	if result["127.0.0"] < 500 || result["127.0.1"] < 200 || result["127.0.2"] != 0 {
+		t.Fatalf("traffic should be spilled over to priority 1 in the subset, but got %v", result)
+	}
+}
@@ -20,7 +20,10 @@ package cluster
 import (
 	"sync/atomic"
 
+	metrics "github.com/rcrowley/go-metrics"
 	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	mosnmetrics "mosn.io/mosn/pkg/metrics"
 	"mosn.io/mosn/pkg/types"
 )
 
@@ -30,6 +33,13 @@ const (
 	DefaultMaxPendingRequests uint64 = 0
 	DefaultMaxRequests        uint64 = 0
 	DefaultMaxRetries         uint64 = 0
+	DefaultMaxConnectionPools uint64 = 0
+)
+
+// default value of the retry budget
+const (
+	DefaultRetryBudgetPercent  float64 = 20
+	DefaultMinRetryConcurrency uint64  = 3
 )
 
 // ResourceManager
@@ -37,37 +47,83 @@ type resourcemanager struct {
 	connections     *resource
 	pendingRequests *resource
 	requests        *resource
-	retries         *resource
+	retries         resourceState
+	connectionPools *resource
+	// high is the resources of the high priority, it is nil in the high priority itself
+	high *resourcemanager
+}
+
+// resourceState is implemented by the resources, which reports the usage of the resource
+type resourceState interface {
+	types.Resource
+	// Current returns the resources in use
+	Current() int64
+	// Open returns true if the resource is tripping, the new ones are overflow
+	Open() bool
 }
 
 func NewResourceManager(circuitBreakers v2.CircuitBreakers) types.ResourceManager {
+	return newResourceManager("", circuitBreakers)
+}
+
+// newResourceManager creates the resources of the default and high priority,
+// the gauges of the resources are registered if the cluster name is not empty
+func newResourceManager(clusterName string, circuitBreakers v2.CircuitBreakers) *resourcemanager {
+	var defaultThresholds, highThresholds *v2.Thresholds
+	for i := range circuitBreakers.Thresholds {
+		thresholds := &circuitBreakers.Thresholds[i]
+		switch thresholds.Priority {
+		case "", v2.RoutingPriorityDefault:
+			if defaultThresholds == nil {
+				defaultThresholds = thresholds
+			}
+		case v2.RoutingPriorityHigh:
+			if highThresholds == nil {
+				highThresholds = thresholds
+			}
+		default:
+			log.DefaultLogger.Errorf("[upstream] [resource manager] cluster %s circuit breakers priority %s is unknown, ignored", clusterName, thresholds.Priority)
+		}
+	}
+	rm := newPriorityResourceManager(clusterName, v2.RoutingPriorityDefault, defaultThresholds)
+	rm.high = newPriorityResourceManager(clusterName, v2.RoutingPriorityHigh, highThresholds)
+	return rm
+}
+
+func newPriorityResourceManager(clusterName, priority string, thresholds *v2.Thresholds) *resourcemanager {
 	maxConnections := DefaultMaxConnections
 	maxPendingRequests := DefaultMaxPendingRequests
 	maxRequests := DefaultMaxRequests
 	maxRetries := DefaultMaxRetries
+	maxConnectionPools := DefaultMaxConnectionPools
+	var budget *v2.RetryBudget
 
-	// note: we don't support group cb by priority
-	if circuitBreakers.Thresholds != nil && len(circuitBreakers.Thresholds) > 0 {
-		maxConnections = uint64(circuitBreakers.Thresholds[0].MaxConnections)
-		maxPendingRequests = uint64(circuitBreakers.Thresholds[0].MaxPendingRequests)
-		maxRequests = uint64(circuitBreakers.Thresholds[0].MaxRequests)
-		maxRetries = uint64(circuitBreakers.Thresholds[0].MaxRetries)
+	if thresholds != nil {
+		maxConnections = uint64(thresholds.MaxConnections)
+		maxPendingRequests = uint64(thresholds.MaxPendingRequests)
+		maxRequests = uint64(thresholds.MaxRequests)
+		maxRetries = uint64(thresholds.MaxRetries)
+		maxConnectionPools = uint64(thresholds.MaxConnectionPools)
+		budget = thresholds.RetryBudget
 	}
 
-	return &resourcemanager{
-		connections: &resource{
-			max: maxConnections,
-		},
-		pendingRequests: &resource{
-			max: maxPendingRequests,
-		},
-		requests: &resource{
-			max: maxRequests,
-		},
-		retries: &resource{
-			max: maxRetries,
-		},
+	var stats types.Metrics
+	if clusterName != "" {
+		stats = mosnmetrics.NewCircuitBreakersStats(clusterName, priority)
 	}
+
+	rm := &resourcemanager{
+		connections:     newResource(maxConnections, stats, mosnmetrics.CircuitBreakersRemainingConnections, mosnmetrics.CircuitBreakersConnectionsOpen),
+		pendingRequests: newResource(maxPendingRequests, stats, mosnmetrics.CircuitBreakersRemainingPendingRequests, mosnmetrics.CircuitBreakersPendingRequestsOpen),
+		requests:        newResource(maxRequests, stats, mosnmetrics.CircuitBreakersRemainingRequests, mosnmetrics.CircuitBreakersRequestsOpen),
+		connectionPools: newResource(maxConnectionPools, stats, mosnmetrics.CircuitBreakersRemainingConnectionPools, mosnmetrics.CircuitBreakersConnectionPoolsOpen),
+	}
+	if budget != nil {
+		rm.retries = newRetryBudget(budget, rm.requests, rm.pendingRequests, stats)
+	} else {
+		rm.retries = newResource(maxRetries, stats, mosnmetrics.CircuitBreakersRemainingRetries, mosnmetrics.CircuitBreakersRetriesOpen)
+	}
+	return rm
 }
 
 func (rm *resourcemanager) Connections() types.Resource {
@@ -86,10 +142,36 @@ func (rm *resourcemanager) Retries() types.Resource {
 	return rm.retries
 }
 
+func (rm *resourcemanager) ConnectionPools() types.Resource {
+	return rm.connectionPools
+}
+
+func (rm *resourcemanager) ForPriority(priority types.RoutingPriority) types.ResourceManager {
+	if priority == types.HighPriority && rm.high != nil {
+		return rm.high
+	}
+	return rm
+}
+
 // Resource
 type resource struct {
 	current int64
 	max     uint64
+	// remaining and open are the gauges of the resource, they are nil if the stats are not registered or there is no limit
+	remaining metrics.Gauge
+	open      metrics.Gauge
+}
+
+func newResource(max uint64, stats types.Metrics, remainingKey, openKey string) *resource {
+	r := &resource{
+		max: max,
+	}
+	if stats != nil && max != 0 {
+		r.remaining = stats.Gauge(remainingKey)
+		r.open = stats.Gauge(openKey)
+		updateGauges(r.remaining, r.open, 0, max)
+	}
+	return r
 }
 
 func (r *resource) CanCreate() bool {
@@ -105,18 +187,113 @@ func (r *resource) CanCreate() bool {
 	return uint64(curValue) < r.Max()
 }
 
+// Increase and Decrease always count the resource, so the usage is reported even if there is no limit
 func (r *resource) Increase() {
-	if r.max != 0 {
-		atomic.AddInt64(&r.current, 1)
+	current := atomic.AddInt64(&r.current, 1)
+	if r.remaining != nil {
+		updateGauges(r.remaining, r.open, current, r.max)
 	}
 }
 
 func (r *resource) Decrease() {
-	if r.max != 0 {
-		atomic.AddInt64(&r.current, -1)
+	current := atomic.AddInt64(&r.current, -1)
+	if r.remaining != nil {
+		updateGauges(r.remaining, r.open, current, r.max)
 	}
 }
 
 func (r *resource) Max() uint64 {
 	return r.max
 }
+
+func (r *resource) Current() int64 {
+	return atomic.LoadInt64(&r.current)
+}
+
+func (r *resource) Open() bool {
+	return r.max != 0 && atomic.LoadInt64(&r.current) >= int64(r.max)
+}
+
+// retryBudget is the retries resource, the max retries is a percentage of the active and pending requests
+type retryBudget struct {
+	current         int64
+	percent         float64
+	minConcurrency  uint64
+	requests        *resource
+	pendingRequests *resource
+	remaining       metrics.Gauge
+	open            metrics.Gauge
+}
+
+func newRetryBudget(budget *v2.RetryBudget, requests, pendingRequests *resource, stats types.Metrics) *retryBudget {
+	b := &retryBudget{
+		percent:         DefaultRetryBudgetPercent,
+		minConcurrency:  DefaultMinRetryConcurrency,
+		requests:        requests,
+		pendingRequests: pendingRequests,
+	}
+	if budget.BudgetPercent != nil {
+		b.percent = *budget.BudgetPercent
+	}
+	if budget.MinRetryConcurrency != nil {
+		b.minConcurrency = uint64(*budget.MinRetryConcurrency)
+	}
+	if stats != nil {
+		b.remaining = stats.Gauge(mosnmetrics.CircuitBreakersRemainingRetries)
+		b.open = stats.Gauge(mosnmetrics.CircuitBreakersRetriesOpen)
+		updateGauges(b.remaining, b.open, 0, b.Max())
+	}
+	return b
+}
+
+func (b *retryBudget) CanCreate() bool {
+	return atomic.LoadInt64(&b.current) < int64(b.Max())
+}
+
+func (b *retryBudget) Increase() {
+	current := atomic.AddInt64(&b.current, 1)
+	if b.remaining != nil {
+		updateGauges(b.remaining, b.open, current, b.Max())
+	}
+}
+
+func (b *retryBudget) Decrease() {
+	current := atomic.AddInt64(&b.current, -1)
+	if b.remaining != nil {
+		updateGauges(b.remaining, b.open, current, b.Max())
+	}
+}
+
+// Max returns the retries allowed by the active and pending requests, min retry concurrency at least
+func (b *retryBudget) Max() uint64 {
+	active := b.requests.Current() + b.pendingRequests.Current()
+	if active < 0 {
+		active = 0
+	}
+	max := uint64(float64(active) * b.percent / 100)
+	if max < b.minConcurrency {
+		max = b.minConcurrency
+	}
+	return max
+}
+
+func (b *retryBudget) Current() int64 {
+	return atomic.LoadInt64(&b.current)
+}
+
+func (b *retryBudget) Open() bool {
+	return !b.CanCreate()
+}
+
+func updateGauges(remaining, open metrics.Gauge, current int64, max uint64) {
+	left := int64(max) - current
+	if left < 0 {
+		left = 0
+	}
+	remaining.Update(left)
+	if left == 0 {
+		open.Update(1)
+	} else {
+		open.Update(0)
+	}
+}
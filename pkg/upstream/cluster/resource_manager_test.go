@@ -0,0 +1,237 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cluster
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestResourceManagerPriority(t *testing.T) {
+	rm := newResourceManager("", v2.CircuitBreakers{
+		Thresholds: []v2.Thresholds{
+			{MaxRequests: 1},
+			{Priority: v2.RoutingPriorityHigh, MaxRequests: 2},
+		},
+	})
+	high := rm.ForPriority(types.HighPriority)
+	if rm.ForPriority(types.DefaultPriority) != rm || high == types.ResourceManager(rm) {
+		t.Fatal("unexpected resource manager of the priorities")
+	}
+	rm.Requests().Increase()
+	if rm.Requests().CanCreate() {
+		t.Error("expected the default priority requests overflow")
+	}
+	high.Requests().Increase()
+	if !high.Requests().CanCreate() {
+		t.Error("expected the high priority requests are limited separately")
+	}
+	high.Requests().Increase()
+	if high.Requests().CanCreate() {
+		t.Error("expected the high priority requests overflow")
+	}
+	// the resources are counted without limit
+	rm.Connections().Increase()
+	if !rm.Connections().CanCreate() || rm.connections.Current() != 1 || rm.connections.Open() {
+		t.Error("unexpected connections without limit")
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	percent := float64(50)
+	minConcurrency := uint32(1)
+	rm := newResourceManager("", v2.CircuitBreakers{
+		Thresholds: []v2.Thresholds{
+			{
+				MaxRetries: 10,
+				RetryBudget: &v2.RetryBudget{
+					BudgetPercent:       &percent,
+					MinRetryConcurrency: &minConcurrency,
+				},
+			},
+		},
+	})
+	retries := rm.Retries()
+	if retries.Max() != 1 {
+		t.Fatalf("expected the min retry concurrency without active requests, but got %d", retries.Max())
+	}
+	retries.Increase()
+	if retries.CanCreate() {
+		t.Fatal("expected the retries overflow")
+	}
+	for i := 0; i < 4; i++ {
+		rm.Requests().Increase()
+	}
+	if retries.Max() != 2 || !retries.CanCreate() {
+		t.Fatalf("expected the budget is increased by the active requests, but got %d", retries.Max())
+	}
+	// the default budget
+	rm = newResourceManager("", v2.CircuitBreakers{
+		Thresholds: []v2.Thresholds{
+			{RetryBudget: &v2.RetryBudget{}},
+		},
+	})
+	for i := 0; i < 100; i++ {
+		rm.Requests().Increase()
+	}
+	if max := rm.Retries().Max(); max != 20 {
+		t.Errorf("expected the default budget 20 percent, but got %d", max)
+	}
+}
+
+func TestResourceGauges(t *testing.T) {
+	rm := newResourceManager("test_gauges", v2.CircuitBreakers{
+		Thresholds: []v2.Thresholds{
+			{MaxRequests: 2},
+		},
+	})
+	stats := metrics.NewCircuitBreakersStats("test_gauges", v2.RoutingPriorityDefault)
+	remaining := stats.Gauge(metrics.CircuitBreakersRemainingRequests)
+	open := stats.Gauge(metrics.CircuitBreakersRequestsOpen)
+	if remaining.Value() != 2 || open.Value() != 0 {
+		t.Fatalf("unexpected gauges, remaining %d, open %d", remaining.Value(), open.Value())
+	}
+	rm.Requests().Increase()
+	rm.Requests().Increase()
+	if remaining.Value() != 0 || open.Value() != 1 {
+		t.Fatalf("unexpected gauges, remaining %d, open %d", remaining.Value(), open.Value())
+	}
+	rm.Requests().Decrease()
+	if remaining.Value() != 1 || open.Value() != 0 {
+		t.Fatalf("unexpected gauges, remaining %d, open %d", remaining.Value(), open.Value())
+	}
+}
+
+func TestConnPoolOverflow(t *testing.T) {
+	clusterConfig := v2.Cluster{
+		Name:   "test_pools",
+		LbType: v2.LB_RANDOM,
+		CirBreThresholds: v2.CircuitBreakers{
+			Thresholds: []v2.Thresholds{
+				{MaxConnectionPools: 1},
+			},
+		},
+	}
+	clusterMangerInstance.Destroy() // Destroy for test
+	NewClusterManagerSingleton([]v2.Cluster{clusterConfig}, map[string][]v2.Host{
+		"test_pools": {{HostConfig: v2.HostConfig{Address: "127.0.0.1:10100"}}},
+	})
+	adapter := GetClusterMngAdapterInstance()
+	snap := adapter.GetClusterSnapshot(nil, "test_pools")
+	if pool, _ := adapter.ConnPoolForCluster(newMockLbContext(nil), snap, mockProtocol); pool == nil {
+		t.Fatal("get conn pool failed")
+	}
+	// the pools of all the protocols are counted by the cluster
+	if pool, err := adapter.ConnPoolForCluster(newMockLbContext(nil), snap, mockOtherProtocol); pool != nil || err != types.ErrConnPoolOverflow {
+		t.Fatalf("expected the connection pools overflow, but got %v", err)
+	}
+	if cnt := snap.ClusterInfo().Stats().UpstreamConnectionPoolOverflow.Count(); cnt != 1 {
+		t.Errorf("expected connection pool overflow 1, but got %d", cnt)
+	}
+
+	statuses := GetCircuitBreakersStatus(true)
+	status, ok := statuses["test_pools"]
+	if !ok || !status.Tripping {
+		t.Fatalf("expected the cluster is tripping, but got %+v", statuses)
+	}
+	if pools := status.Priorities[v2.RoutingPriorityDefault]["connection_pools"]; pools.Current != 1 || pools.Max != 1 || !pools.Open {
+		t.Errorf("unexpected connection pools status %+v", pools)
+	}
+
+	w := httptest.NewRecorder()
+	circuitBreakersDump(w, httptest.NewRequest(http.MethodGet, "/api/v1/circuit_breakers?tripping=true", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+	dump := map[string]*CircuitBreakersStatus{}
+	if err := json.Unmarshal(w.Body.Bytes(), &dump); err != nil {
+		t.Fatal(err)
+	}
+	if len(dump) != 1 || dump["test_pools"] == nil {
+		t.Errorf("unexpected circuit breakers dump %s", w.Body.String())
+	}
+}
+
+func TestConnPoolRemoved(t *testing.T) {
+	clusterConfig := v2.Cluster{
+		Name:   "test_pools",
+		LbType: v2.LB_RANDOM,
+		CirBreThresholds: v2.CircuitBreakers{
+			Thresholds: []v2.Thresholds{
+				{MaxConnectionPools: 1},
+			},
+		},
+	}
+	otherConfig := v2.Cluster{
+		Name:   "test_other",
+		LbType: v2.LB_RANDOM,
+	}
+	clusterMangerInstance.Destroy() // Destroy for test
+	NewClusterManagerSingleton([]v2.Cluster{clusterConfig, otherConfig}, map[string][]v2.Host{
+		"test_pools": {{HostConfig: v2.HostConfig{Address: "127.0.0.1:10100"}}},
+		"test_other": {{HostConfig: v2.HostConfig{Address: "127.0.0.1:10100"}}},
+	})
+	adapter := GetClusterMngAdapterInstance()
+	snap := adapter.GetClusterSnapshot(nil, "test_pools")
+	pool, _ := adapter.ConnPoolForCluster(newMockLbContext(nil), snap, mockProtocol)
+	if pool == nil {
+		t.Fatal("get conn pool failed")
+	}
+	// the pools are not shared by the clusters
+	otherSnap := adapter.GetClusterSnapshot(nil, "test_other")
+	otherPool, _ := adapter.ConnPoolForCluster(newMockLbContext(nil), otherSnap, mockProtocol)
+	if otherPool == nil || otherPool == pool {
+		t.Fatal("expected a new conn pool of the other cluster")
+	}
+	if current := snap.ClusterInfo().ResourceManager().ConnectionPools().(*resource).Current(); current != 1 {
+		t.Fatalf("expected the connection pools 1, but got %d", current)
+	}
+
+	// the pool of the removed host is shut down and released
+	if err := adapter.UpdateClusterHosts("test_pools", []v2.Host{
+		{HostConfig: v2.HostConfig{Address: "127.0.0.1:10101"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !pool.(*mockConnPool).shutdown {
+		t.Error("expected the pool of the removed host is shut down")
+	}
+	if otherPool.(*mockConnPool).shutdown {
+		t.Error("expected the pool of the other cluster is kept")
+	}
+	snap = adapter.GetClusterSnapshot(nil, "test_pools")
+	if pool, _ := adapter.ConnPoolForCluster(newMockLbContext(nil), snap, mockProtocol); pool == nil {
+		t.Fatal("expected the connection pool of the new host is created")
+	}
+	if current := snap.ClusterInfo().ResourceManager().ConnectionPools().(*resource).Current(); current != 1 {
+		t.Fatalf("expected the connection pools 1, but got %d", current)
+	}
+
+	// all the pools are released after the cluster removed
+	if err := adapter.RemovePrimaryCluster("test_pools"); err != nil {
+		t.Fatal(err)
+	}
+	if current := snap.ClusterInfo().ResourceManager().ConnectionPools().(*resource).Current(); current != 0 {
+		t.Fatalf("expected the connection pools 0, but got %d", current)
+	}
+}
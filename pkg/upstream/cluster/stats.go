@@ -35,6 +35,12 @@ func newHostStats(clustername string, addr string) types.HostStats {
 		UpstreamConnectionLocalCloseWithActiveRequest:  s.Counter(metrics.UpstreamConnectionLocalCloseWithActiveRequest),
 		UpstreamConnectionRemoteCloseWithActiveRequest: s.Counter(metrics.UpstreamConnectionRemoteCloseWithActiveRequest),
 		UpstreamConnectionCloseNotify:                  s.Counter(metrics.UpstreamConnectionCloseNotify),
+		UpstreamConnectionCloseMaxRequests:             s.Counter(metrics.UpstreamConnectionCloseMaxRequests),
+		UpstreamConnectionPoolOverflow:                 s.Counter(metrics.UpstreamConnectionPoolOverflow),
+		UpstreamConnectionCloseGoAway:                  s.Counter(metrics.UpstreamConnectionCloseGoAway),
+		UpstreamConnectionCloseMaxAge:                  s.Counter(metrics.UpstreamConnectionCloseMaxAge),
+		UpstreamConnectionCloseIdle:                    s.Counter(metrics.UpstreamConnectionCloseIdle),
+		UpstreamConnectionCloseMaxIdle:                 s.Counter(metrics.UpstreamConnectionCloseMaxIdle),
 		UpstreamRequestTotal:                           s.Counter(metrics.UpstreamRequestTotal),
 		UpstreamRequestActive:                          s.Counter(metrics.UpstreamRequestActive),
 		UpstreamRequestLocalReset:                      s.Counter(metrics.UpstreamRequestLocalReset),
@@ -62,6 +68,12 @@ func newClusterStats(clustername string) types.ClusterStats {
 		UpstreamConnectionLocalCloseWithActiveRequest:  s.Counter(metrics.UpstreamConnectionLocalCloseWithActiveRequest),
 		UpstreamConnectionRemoteCloseWithActiveRequest: s.Counter(metrics.UpstreamConnectionRemoteCloseWithActiveRequest),
 		UpstreamConnectionCloseNotify:                  s.Counter(metrics.UpstreamConnectionCloseNotify),
+		UpstreamConnectionCloseMaxRequests:             s.Counter(metrics.UpstreamConnectionCloseMaxRequests),
+		UpstreamConnectionPoolOverflow:                 s.Counter(metrics.UpstreamConnectionPoolOverflow),
+		UpstreamConnectionCloseGoAway:                  s.Counter(metrics.UpstreamConnectionCloseGoAway),
+		UpstreamConnectionCloseMaxAge:                  s.Counter(metrics.UpstreamConnectionCloseMaxAge),
+		UpstreamConnectionCloseIdle:                    s.Counter(metrics.UpstreamConnectionCloseIdle),
+		UpstreamConnectionCloseMaxIdle:                 s.Counter(metrics.UpstreamConnectionCloseMaxIdle),
 		UpstreamBytesReadTotal:                         s.Counter(metrics.UpstreamBytesReadTotal),
 		UpstreamBytesWriteTotal:                        s.Counter(metrics.UpstreamBytesWriteTotal),
 		UpstreamRequestTotal:                           s.Counter(metrics.UpstreamRequestTotal),
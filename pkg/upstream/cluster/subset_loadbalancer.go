@@ -28,6 +28,7 @@ import (
 )
 
 type subsetLoadBalancer struct {
+	info           *clusterInfo
 	lbType         types.LoadBalancerType
 	stats          types.ClusterStats
 	subSets        types.LbSubsetMap  // final trie-like structure used to stored easily searched subset
@@ -38,6 +39,7 @@ type subsetLoadBalancer struct {
 func NewSubsetLoadBalancer(info *clusterInfo, hostSet *hostSet) types.LoadBalancer {
 	subsetInfo := info.lbSubsetInfo
 	subsetLB := &subsetLoadBalancer{
+		info:    info,
 		lbType:  info.lbType,
 		stats:   info.stats,
 		subSets: make(map[string]types.ValueSubsetMap),
@@ -122,11 +124,10 @@ func (sslb *subsetLoadBalancer) createSubsets(subSetKeys []types.SortedStringSet
 			if len(kvs) > 0 {
 				entry := sslb.findOrCreateSubset(sslb.subSets, kvs, 0)
 				if !entry.Initialized() {
-					subHostset := sslb.hostSet.createSubset(func(host types.Host) bool {
+					subsSetCount += 1
+					sslb.createLoadBalancer(entry, func(host types.Host) bool {
 						return HostMatches(kvs, host)
 					})
-					subsSetCount += 1
-					entry.CreateLoadBalancer(sslb.lbType, subHostset)
 				}
 			}
 		}
@@ -136,7 +137,6 @@ func (sslb *subsetLoadBalancer) createSubsets(subSetKeys []types.SortedStringSet
 
 // createFallbackSubset creates a LBSubsetEntryImpl as fallbackSubset
 func (sslb *subsetLoadBalancer) createFallbackSubset(policy types.FallBackPolicy, meta types.SubsetMetadata) {
-	hostSet := sslb.hostSet
 	switch policy {
 	case types.NoFallBack:
 		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
@@ -147,18 +147,32 @@ func (sslb *subsetLoadBalancer) createFallbackSubset(policy types.FallBackPolicy
 		sslb.fallbackSubset = &LBSubsetEntryImpl{
 			children: nil, // no child
 		}
-		sslb.fallbackSubset.CreateLoadBalancer(sslb.lbType, hostSet)
+		sslb.createLoadBalancer(sslb.fallbackSubset, nil)
 	case types.DefaultSubset:
 		sslb.fallbackSubset = &LBSubsetEntryImpl{
 			children: nil, // no child
 		}
-		subHostset := hostSet.createSubset(func(host types.Host) bool {
+		sslb.createLoadBalancer(sslb.fallbackSubset, func(host types.Host) bool {
 			return HostMatches(meta, host)
 		})
-		sslb.fallbackSubset.CreateLoadBalancer(sslb.lbType, subHostset)
 	}
 }
 
+// createLoadBalancer creates the load balancer of the hosts matched the predicate, a nil predicate matches all the hosts.
+// The same as the cluster, the priority load balancer is used if the hosts have priorities or zones
+func (sslb *subsetLoadBalancer) createLoadBalancer(entry types.LBSubsetEntry, predicate types.HostPredicate) {
+	var hosts types.HostSet = sslb.hostSet
+	if predicate != nil {
+		hosts = sslb.hostSet.createSubset(predicate)
+	}
+	if impl, ok := entry.(*LBSubsetEntryImpl); ok && needPriorityLoadBalancer(hosts) {
+		impl.lb = newPriorityLoadBalancer(sslb.info, sslb.hostSet, predicate)
+		impl.hostSet = hosts
+		return
+	}
+	entry.CreateLoadBalancer(sslb.lbType, hosts)
+}
+
 func (sslb *subsetLoadBalancer) findSubset(matchCriteria []api.MetadataMatchCriterion) types.LBSubsetEntry {
 	subSets := sslb.subSets
 	for i, mcCriterion := range matchCriteria {
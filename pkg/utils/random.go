@@ -0,0 +1,49 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// FastRandom is a lock free random source, the numbers are the splitmix64 of an atomic counter.
+// It is safe for concurrent use, but it is not cryptographically secure
+type FastRandom struct {
+	state uint64
+}
+
+// NewFastRandom returns a FastRandom seeded by the current time
+func NewFastRandom() *FastRandom {
+	return &FastRandom{
+		state: uint64(time.Now().UnixNano()),
+	}
+}
+
+// Uint64 returns a random number
+func (r *FastRandom) Uint64() uint64 {
+	z := atomic.AddUint64(&r.state, 0x9e3779b97f4a7c15)
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Uint32n returns a random number in [0, n)
+func (r *FastRandom) Uint32n(n uint32) uint32 {
+	return uint32((r.Uint64() >> 32) * uint64(n) >> 32)
+}
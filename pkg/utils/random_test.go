@@ -0,0 +1,38 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import "testing"
+
+func TestFastRandomUint32n(t *testing.T) {
+	r := NewFastRandom()
+	counts := make([]int, 4)
+	for i := 0; i < 4000; i++ {
+		v := r.Uint32n(4)
+		if v >= 4 {
+			t.Fatalf("random number %d out of range", v)
+		}
+		counts[v]++
+	}
+	// the numbers are distributed roughly evenly
+	for i, c := range counts {
+		if c < 800 || c > 1200 {
+			t.Errorf("unexpected count %d of number %d", c, i)
+		}
+	}
+}
@@ -20,6 +20,8 @@ package conv
 import (
 	"fmt"
 	"net"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -58,6 +60,7 @@ var supportFilter = map[string]bool{
 	v2.RPC_PROXY:                  true,
 	v2.X_PROXY:                    true,
 	v2.MIXER:                      true,
+	IstioNetworkRBAC:              true,
 }
 
 var httpBaseConfig = map[string]bool{
@@ -101,7 +104,21 @@ func ConvertListenerConfig(xdsListener *xdsapi.Listener) *v2.Listener {
 	if listenerConfig.FilterChains != nil &&
 		len(listenerConfig.FilterChains) == 1 &&
 		listenerConfig.FilterChains[0].Filters != nil {
-		listenerConfig.StreamFilters = convertStreamFilters(&xdsListener.FilterChains[0].Filters[0])
+		// the stream filters are in the proxy filter, which may be after the filters like rbac
+		filters := xdsListener.FilterChains[0].Filters
+		index := 0
+		for i := range filters {
+			if httpBaseConfig[filters[i].GetName()] || filters[i].GetName() == v2.X_PROXY {
+				index = i
+				break
+			}
+		}
+		listenerConfig.StreamFilters = convertStreamFilters(&filters[index])
+	}
+
+	if invalidRBACFilter(listenerConfig) {
+		log.DefaultLogger.Errorf("listener %s contains an invalid rbac filter", listenerConfig.Name)
+		return nil
 	}
 
 	return listenerConfig
@@ -123,9 +140,17 @@ func ConvertClustersConfig(xdsClusters []*xdsapi.Cluster) []*v2.Cluster {
 			HealthCheck:          convertHealthChecks(xdsCluster.GetHealthChecks()),
 			CirBreThresholds:     convertCircuitBreakers(xdsCluster.GetCircuitBreakers()),
 			//OutlierDetection:     convertOutlierDetection(xdsCluster.GetOutlierDetection()),
-			Hosts: convertClusterHosts(xdsCluster.GetHosts()),
+			Hosts: convertClusterHostsConfig(xdsCluster),
 			Spec:  convertSpec(xdsCluster),
 			TLS:   convertTLS(xdsCluster.GetTlsContext()),
+
+			OverprovisioningFactor: xdsCluster.GetLoadAssignment().GetPolicy().GetOverprovisioningFactor().GetValue(),
+		}
+		if isDNSCluster(xdsCluster) {
+			convertDNSConfig(xdsCluster, cluster)
+		}
+		if idleTimeout := xdsCluster.GetCommonHttpProtocolOptions().GetIdleTimeout(); idleTimeout != nil {
+			cluster.ConnPool.IdleTimeout = &api.DurationConfig{Duration: *idleTimeout}
 		}
 
 		clusters = append(clusters, cluster)
@@ -134,6 +159,23 @@ func ConvertClustersConfig(xdsClusters []*xdsapi.Cluster) []*v2.Cluster {
 	return clusters
 }
 
+// convertClusterHostsConfig uses the load_assignment endpoints if the cluster hosts is empty,
+// the hosts field is removed in v3 api.
+func convertClusterHostsConfig(xdsCluster *xdsapi.Cluster) []v2.Host {
+	if len(xdsCluster.GetHosts()) > 0 || xdsCluster.GetLoadAssignment() == nil {
+		if isDNSCluster(xdsCluster) {
+			return convertDNSClusterHosts(xdsCluster.GetHosts())
+		}
+		return convertClusterHosts(xdsCluster.GetHosts())
+	}
+	var hosts []v2.Host
+	endpoints := xdsCluster.GetLoadAssignment().GetEndpoints()
+	for i := range endpoints {
+		hosts = append(hosts, ConvertEndpointsConfig(&endpoints[i])...)
+	}
+	return hosts
+}
+
 func ConvertEndpointsConfig(xdsEndpoint *xdsendpoint.LocalityLbEndpoints) []v2.Host {
 	if xdsEndpoint == nil {
 		return nil
@@ -159,7 +201,9 @@ func ConvertEndpointsConfig(xdsEndpoint *xdsendpoint.LocalityLbEndpoints) []v2.H
 		}
 		host := v2.Host{
 			HostConfig: v2.HostConfig{
-				Address: address,
+				Address:  address,
+				Priority: xdsEndpoint.GetPriority(),
+				Zone:     xdsEndpoint.GetLocality().GetZone(),
 			},
 			MetaData: convertMeta(xdsHost.Metadata),
 		}
@@ -180,17 +224,23 @@ func isSupport(xdsListener *xdsapi.Listener) bool {
 	if xdsListener == nil {
 		return false
 	}
+	return len(unsupportedFilters(xdsListener)) == 0
+}
+
+// unsupportedFilters returns the network filter names that mosn can not convert
+func unsupportedFilters(xdsListener *xdsapi.Listener) []string {
 	if xdsListener.Name == "virtual" {
-		return true
+		return nil
 	}
+	var filters []string
 	for _, filterChain := range xdsListener.GetFilterChains() {
 		for _, filter := range filterChain.GetFilters() {
 			if value, ok := supportFilter[filter.GetName()]; !ok || !value {
-				return false
+				filters = append(filters, filter.GetName())
 			}
 		}
 	}
-	return true
+	return filters
 }
 
 func convertBindToPort(xdsDeprecatedV1 *xdsapi.Listener_DeprecatedV1) bool {
@@ -315,6 +365,13 @@ func convertStreamFilter(name string, s *types.Struct) v2.Filter {
 				log.DefaultLogger.Errorf("convert fault inject config error: %v", err)
 			}
 		}
+	case IstioRBAC:
+		filter.Type = v2.RBACFilter
+		filter.Config, err = convertRBACConfig(s)
+		if err != nil {
+			// the filter without config makes the listener rejected
+			log.DefaultLogger.Errorf("convert rbac config error: %v", err)
+		}
 	case MosnPayloadLimit:
 		if featuregate.Enabled(featuregate.PayLoadLimitEnable) {
 			filter.Type = v2.PayloadLimit
@@ -540,6 +597,16 @@ func convertFilterConfig(name string, s *types.Struct) map[string]map[string]int
 	} else if name == v2.MIXER {
 		// support later
 		return nil
+	} else if name == IstioNetworkRBAC {
+		rbacConfig, err := convertRBACConfig(s)
+		if err != nil {
+			log.DefaultLogger.Errorf("convert network rbac config error: %v", err)
+			// the filter without config makes the listener rejected
+			filtersConfigParsed[v2.RBAC_NETWORK_FILTER] = nil
+			return filtersConfigParsed
+		}
+		filtersConfigParsed[v2.RBAC_NETWORK_FILTER] = rbacConfig
+		return filtersConfigParsed
 	} else {
 		log.DefaultLogger.Errorf("unsupported filter config, filter name: %s", name)
 		return nil
@@ -727,27 +794,66 @@ func convertPerRouteConfig(xdsPerRouteConfig map[string]*types.Struct) map[strin
 }
 
 func convertRouteMatch(xdsRouteMatch xdsroute.RouteMatch) v2.RouterMatch {
-	return v2.RouterMatch{
-		Prefix: xdsRouteMatch.GetPrefix(),
-		Path:   xdsRouteMatch.GetPath(),
-		Regex:  xdsRouteMatch.GetRegex(),
-		//CaseSensitive: xdsRouteMatch.GetCaseSensitive().GetValue(),
-		//Runtime:       convertRuntime(xdsRouteMatch.GetRuntime()),
-		Headers: convertHeaders(xdsRouteMatch.GetHeaders()),
+	match := v2.RouterMatch{
+		Prefix:          xdsRouteMatch.GetPrefix(),
+		Path:            xdsRouteMatch.GetPath(),
+		Regex:           xdsRouteMatch.GetRegex(),
+		Headers:         convertHeaders(xdsRouteMatch.GetHeaders()),
+		QueryParameters: convertQueryParameters(xdsRouteMatch.GetQueryParameters()),
+		RuntimeFraction: convertRuntimeFraction(xdsRouteMatch.GetRuntimeFraction()),
+	}
+	if caseSensitive := xdsRouteMatch.GetCaseSensitive(); caseSensitive != nil {
+		value := caseSensitive.GetValue()
+		match.CaseSensitive = &value
+	}
+	// method is matched by the pseudo header in xds
+	for i, header := range match.Headers {
+		if header.Name == "method" && isExactHeaderMatcher(header) {
+			match.Methods = []string{header.Value}
+			match.Headers = append(match.Headers[:i:i], match.Headers[i+1:]...)
+			break
+		}
 	}
+	return match
 }
 
-/*
-func convertRuntime(xdsRuntime *xdscore.RuntimeUInt32) v2.RuntimeUInt32 {
-	if xdsRuntime == nil {
-		return v2.RuntimeUInt32{}
+// convertRuntimeFraction uses the default value, the runtime key is not supported
+func convertRuntimeFraction(xdsRuntime *xdscore.RuntimeFractionalPercent) *v2.RuntimeFractionalPercent {
+	if xdsRuntime.GetDefaultValue() == nil {
+		return nil
 	}
-	return v2.RuntimeUInt32{
-		DefaultValue: xdsRuntime.GetDefaultValue(),
-		RuntimeKey:   xdsRuntime.GetRuntimeKey(),
+	fraction := &v2.RuntimeFractionalPercent{
+		Numerator: xdsRuntime.GetDefaultValue().GetNumerator(),
 	}
+	switch xdsRuntime.GetDefaultValue().GetDenominator() {
+	case xdstype.FractionalPercent_TEN_THOUSAND:
+		fraction.Denominator = v2.TEN_THOUSAND
+	case xdstype.FractionalPercent_MILLION:
+		fraction.Denominator = v2.MILLION
+	default:
+		fraction.Denominator = v2.HUNDRED
+	}
+	return fraction
+}
+
+func isExactHeaderMatcher(header v2.HeaderMatcher) bool {
+	return !header.Regex && header.RangeMatch == nil && header.PresentMatch == nil && !header.InvertMatch
+}
+
+func convertQueryParameters(xdsParams []*xdsroute.QueryParameterMatcher) []v2.HeaderMatcher {
+	if len(xdsParams) == 0 {
+		return nil
+	}
+	matchers := make([]v2.HeaderMatcher, 0, len(xdsParams))
+	for _, xdsParam := range xdsParams {
+		matchers = append(matchers, v2.HeaderMatcher{
+			Name:  xdsParam.GetName(),
+			Value: xdsParam.GetValue(),
+			Regex: xdsParam.GetRegex().GetValue(),
+		})
+	}
+	return matchers
 }
-*/
 
 func convertHeaders(xdsHeaders []*xdsroute.HeaderMatcher) []v2.HeaderMatcher {
 	if xdsHeaders == nil {
@@ -755,15 +861,30 @@ func convertHeaders(xdsHeaders []*xdsroute.HeaderMatcher) []v2.HeaderMatcher {
 	}
 	headerMatchers := make([]v2.HeaderMatcher, 0, len(xdsHeaders))
 	for _, xdsHeader := range xdsHeaders {
-		headerMatcher := v2.HeaderMatcher{}
-		if xdsHeader.GetRegexMatch() != "" {
-			headerMatcher.Name = xdsHeader.GetName()
-			headerMatcher.Value = xdsHeader.GetRegexMatch()
+		headerMatcher := v2.HeaderMatcher{
+			Name:        xdsHeader.GetName(),
+			InvertMatch: xdsHeader.GetInvertMatch(),
+		}
+		switch specifier := xdsHeader.GetHeaderMatchSpecifier().(type) {
+		case *xdsroute.HeaderMatcher_RegexMatch:
+			headerMatcher.Value = specifier.RegexMatch
 			headerMatcher.Regex = true
-		} else {
-			headerMatcher.Name = xdsHeader.GetName()
+		case *xdsroute.HeaderMatcher_PrefixMatch:
+			headerMatcher.Value = "^" + regexp.QuoteMeta(specifier.PrefixMatch)
+			headerMatcher.Regex = true
+		case *xdsroute.HeaderMatcher_SuffixMatch:
+			headerMatcher.Value = regexp.QuoteMeta(specifier.SuffixMatch) + "$"
+			headerMatcher.Regex = true
+		case *xdsroute.HeaderMatcher_RangeMatch:
+			headerMatcher.RangeMatch = &v2.Int64Range{
+				Start: specifier.RangeMatch.GetStart(),
+				End:   specifier.RangeMatch.GetEnd(),
+			}
+		case *xdsroute.HeaderMatcher_PresentMatch:
+			present := specifier.PresentMatch
+			headerMatcher.PresentMatch = &present
+		default:
 			headerMatcher.Value = xdsHeader.GetExactMatch()
-			headerMatcher.Regex = false
 		}
 
 		// as pseudo headers not support when Http1.x upgrade to Http2, change pseudo headers to normal headers
@@ -940,7 +1061,9 @@ func convertClusterType(xdsClusterType xdsapi.Cluster_DiscoveryType) v2.ClusterT
 	case xdsapi.Cluster_STATIC:
 		return v2.SIMPLE_CLUSTER
 	case xdsapi.Cluster_STRICT_DNS:
+		return v2.STRICT_DNS_CLUSTER
 	case xdsapi.Cluster_LOGICAL_DNS:
+		return v2.LOGICAL_DNS_CLUSTER
 	case xdsapi.Cluster_EDS:
 		return v2.EDS_CLUSTER
 	case xdsapi.Cluster_ORIGINAL_DST:
@@ -1028,6 +1151,9 @@ func convertCircuitBreakers(xdsCircuitBreaker *xdscluster.CircuitBreakers) v2.Ci
 			MaxRequests:        xdsThreshold.GetMaxRequests().GetValue(),
 			MaxRetries:         xdsThreshold.GetMaxRetries().GetValue(),
 		}
+		if xdsThreshold.GetPriority() == xdscore.RoutingPriority_HIGH {
+			threshold.Priority = v2.RoutingPriorityHigh
+		}
 		thresholds = append(thresholds, threshold)
 	}
 	return v2.CircuitBreakers{
@@ -1076,9 +1202,13 @@ func convertClusterHosts(xdsHosts []*xdscore.Address) []v2.Host {
 	}
 	hostsWithMetaData := make([]v2.Host, 0, len(xdsHosts))
 	for _, xdsHost := range xdsHosts {
+		addr := convertAddress(xdsHost)
+		if addr == nil {
+			continue
+		}
 		hostWithMetaData := v2.Host{
 			HostConfig: v2.HostConfig{
-				Address: convertAddress(xdsHost).String(),
+				Address: addr.String(),
 			},
 		}
 		hostsWithMetaData = append(hostsWithMetaData, hostWithMetaData)
@@ -1086,6 +1216,52 @@ func convertClusterHosts(xdsHosts []*xdscore.Address) []v2.Host {
 	return hostsWithMetaData
 }
 
+func isDNSCluster(xdsCluster *xdsapi.Cluster) bool {
+	return xdsCluster.GetType() == xdsapi.Cluster_STRICT_DNS || xdsCluster.GetType() == xdsapi.Cluster_LOGICAL_DNS
+}
+
+// convertDNSClusterHosts keeps the hostnames, the hosts of dns clusters are resolved by the cluster
+func convertDNSClusterHosts(xdsHosts []*xdscore.Address) []v2.Host {
+	hosts := make([]v2.Host, 0, len(xdsHosts))
+	for _, xdsHost := range xdsHosts {
+		socketAddress := xdsHost.GetSocketAddress()
+		if socketAddress == nil || socketAddress.GetAddress() == "" {
+			log.DefaultLogger.Warnf("only SocketAddress supported")
+			continue
+		}
+		xdsPort, ok := socketAddress.GetPortSpecifier().(*xdscore.SocketAddress_PortValue)
+		if !ok {
+			log.DefaultLogger.Warnf("only port value supported")
+			continue
+		}
+		hosts = append(hosts, v2.Host{
+			HostConfig: v2.HostConfig{
+				Address: net.JoinHostPort(socketAddress.GetAddress(), strconv.Itoa(int(xdsPort.PortValue))),
+			},
+		})
+	}
+	return hosts
+}
+
+func convertDNSConfig(xdsCluster *xdsapi.Cluster, cluster *v2.Cluster) {
+	if rate := xdsCluster.GetDnsRefreshRate(); rate != nil {
+		cluster.DnsRefreshRate = &api.DurationConfig{Duration: *rate}
+	}
+	switch xdsCluster.GetDnsLookupFamily() {
+	case xdsapi.Cluster_V4_ONLY:
+		cluster.DnsLookupFamily = v2.DNS_V4_ONLY
+	case xdsapi.Cluster_V6_ONLY:
+		cluster.DnsLookupFamily = v2.DNS_V6_ONLY
+	default:
+		cluster.DnsLookupFamily = v2.DNS_AUTO
+	}
+	for _, resolver := range xdsCluster.GetDnsResolvers() {
+		if addr := convertAddress(resolver); addr != nil {
+			cluster.DnsResolvers = append(cluster.DnsResolvers, addr.String())
+		}
+	}
+}
+
 func convertDuration(p *types.Duration) time.Duration {
 	if p == nil {
 		return time.Duration(0)
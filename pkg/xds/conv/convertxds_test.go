@@ -30,6 +30,7 @@ import (
 	"istio.io/api/mixer/v1/config/client"
 
 	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/configmanager"
 	"mosn.io/mosn/pkg/filter/stream/faultinject"
 	"mosn.io/mosn/pkg/router"
 	"mosn.io/mosn/pkg/server"
@@ -91,6 +92,44 @@ func Test_convertEndpointsConfig(t *testing.T) {
 			},
 			want: []v2.Host{},
 		},
+		{
+			name: "priority_and_zone",
+			args: args{
+				xdsEndpoint: &xdsendpoint.LocalityLbEndpoints{
+					Locality: &core.Locality{
+						Region: "cn",
+						Zone:   "zone-a",
+					},
+					LbEndpoints: []xdsendpoint.LbEndpoint{
+						{
+							HostIdentifier: &xdsendpoint.LbEndpoint_Endpoint{
+								Endpoint: &xdsendpoint.Endpoint{
+									Address: &core.Address{
+										Address: &core.Address_SocketAddress{
+											SocketAddress: &core.SocketAddress{
+												Address:       "127.0.0.1",
+												PortSpecifier: &core.SocketAddress_PortValue{PortValue: 8080},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					Priority: 1,
+				},
+			},
+			want: []v2.Host{
+				{
+					HostConfig: v2.HostConfig{
+						Address:  "127.0.0.1:8080",
+						Weight:   configmanager.MinHostWeight,
+						Priority: 1,
+						Zone:     "zone-a",
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -746,3 +785,47 @@ func Test_convertPerRouteConfig(t *testing.T) {
 	}
 
 }
+
+func Test_convertRouteMatch(t *testing.T) {
+	xdsMatch := xdsroute.RouteMatch{
+		PathSpecifier: &xdsroute.RouteMatch_Prefix{Prefix: "/api"},
+		CaseSensitive: &google_protobuf1.BoolValue{Value: false},
+		Headers: []*xdsroute.HeaderMatcher{
+			{Name: ":method", HeaderMatchSpecifier: &xdsroute.HeaderMatcher_ExactMatch{ExactMatch: "GET"}},
+			{Name: "x-canary", HeaderMatchSpecifier: &xdsroute.HeaderMatcher_PresentMatch{PresentMatch: true}},
+			{Name: "x-uid", HeaderMatchSpecifier: &xdsroute.HeaderMatcher_RangeMatch{RangeMatch: &xdstype.Int64Range{Start: 1, End: 10}}},
+			{Name: "x-env", HeaderMatchSpecifier: &xdsroute.HeaderMatcher_PrefixMatch{PrefixMatch: "prod."}, InvertMatch: true},
+		},
+		QueryParameters: []*xdsroute.QueryParameterMatcher{
+			{Name: "version", Value: "v2"},
+		},
+		RuntimeFraction: &xdscore.RuntimeFractionalPercent{
+			DefaultValue: &xdstype.FractionalPercent{Numerator: 50, Denominator: xdstype.FractionalPercent_TEN_THOUSAND},
+		},
+	}
+	match := convertRouteMatch(xdsMatch)
+	if match.Prefix != "/api" || match.CaseSensitive == nil || *match.CaseSensitive {
+		t.Errorf("unexpected path match: %+v", match)
+	}
+	if len(match.Methods) != 1 || match.Methods[0] != "GET" {
+		t.Errorf("unexpected methods: %v", match.Methods)
+	}
+	if len(match.Headers) != 3 {
+		t.Fatalf("unexpected headers: %+v", match.Headers)
+	}
+	if h := match.Headers[0]; h.Name != "x-canary" || h.PresentMatch == nil || !*h.PresentMatch {
+		t.Errorf("unexpected present match: %+v", h)
+	}
+	if h := match.Headers[1]; h.RangeMatch == nil || h.RangeMatch.Start != 1 || h.RangeMatch.End != 10 {
+		t.Errorf("unexpected range match: %+v", h)
+	}
+	if h := match.Headers[2]; !h.Regex || h.Value != `^prod\.` || !h.InvertMatch {
+		t.Errorf("unexpected prefix match: %+v", h)
+	}
+	if len(match.QueryParameters) != 1 || match.QueryParameters[0].Name != "version" || match.QueryParameters[0].Value != "v2" {
+		t.Errorf("unexpected query parameters: %+v", match.QueryParameters)
+	}
+	if f := match.RuntimeFraction; f == nil || f.Numerator != 50 || f.Denominator != v2.TEN_THOUSAND {
+		t.Errorf("unexpected runtime fraction: %+v", f)
+	}
+}
@@ -0,0 +1,402 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package conv
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	gogojsonpb "github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
+	jsoniter "github.com/json-iterator/go"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+)
+
+// istio rbac filter names
+const (
+	IstioRBAC        = "envoy.filters.http.rbac"
+	IstioNetworkRBAC = "envoy.filters.network.rbac"
+)
+
+// the rbac protos are not contained in the vendored go-control-plane,
+// so the config is decoded from the json of the struct with the types below
+type xdsRBAC struct {
+	Rules       *xdsRBACRules `json:"rules"`
+	ShadowRules *xdsRBACRules `json:"shadow_rules"`
+	StatPrefix  string        `json:"stat_prefix"`
+}
+
+type xdsRBACRules struct {
+	Action   interface{}               `json:"action"`
+	Policies map[string]*xdsRBACPolicy `json:"policies"`
+}
+
+type xdsRBACPolicy struct {
+	Permissions []*xdsRBACPermission `json:"permissions"`
+	Principals  []*xdsRBACPrincipal  `json:"principals"`
+}
+
+type xdsRBACPermission struct {
+	Any      bool `json:"any"`
+	AndRules *struct {
+		Rules []*xdsRBACPermission `json:"rules"`
+	} `json:"and_rules"`
+	OrRules *struct {
+		Rules []*xdsRBACPermission `json:"rules"`
+	} `json:"or_rules"`
+	NotRule *xdsRBACPermission `json:"not_rule"`
+	Header  *xdsHeaderMatcher  `json:"header"`
+	URLPath *struct {
+		Path *xdsStringMatcher `json:"path"`
+	} `json:"url_path"`
+	DestinationIP       *xdsCidrRange     `json:"destination_ip"`
+	DestinationPort     uint32            `json:"destination_port"`
+	RequestedServerName *xdsStringMatcher `json:"requested_server_name"`
+	Metadata            *xdsMetadata      `json:"metadata"`
+}
+
+type xdsRBACPrincipal struct {
+	Any    bool `json:"any"`
+	AndIDs *struct {
+		IDs []*xdsRBACPrincipal `json:"ids"`
+	} `json:"and_ids"`
+	OrIDs *struct {
+		IDs []*xdsRBACPrincipal `json:"ids"`
+	} `json:"or_ids"`
+	NotID         *xdsRBACPrincipal `json:"not_id"`
+	Authenticated *struct {
+		PrincipalName *xdsStringMatcher `json:"principal_name"`
+	} `json:"authenticated"`
+	SourceIP *xdsCidrRange     `json:"source_ip"`
+	Header   *xdsHeaderMatcher `json:"header"`
+	Metadata *xdsMetadata      `json:"metadata"`
+}
+
+type xdsStringMatcher struct {
+	Exact     string `json:"exact"`
+	Prefix    string `json:"prefix"`
+	Suffix    string `json:"suffix"`
+	Regex     string `json:"regex"`
+	SafeRegex *struct {
+		Regex string `json:"regex"`
+	} `json:"safe_regex"`
+}
+
+type xdsHeaderMatcher struct {
+	Name           string `json:"name"`
+	ExactMatch     string `json:"exact_match"`
+	RegexMatch     string `json:"regex_match"`
+	SafeRegexMatch *struct {
+		Regex string `json:"regex"`
+	} `json:"safe_regex_match"`
+	RangeMatch *struct {
+		Start jsoniter.Number `json:"start"`
+		End   jsoniter.Number `json:"end"`
+	} `json:"range_match"`
+	PresentMatch *bool  `json:"present_match"`
+	PrefixMatch  string `json:"prefix_match"`
+	SuffixMatch  string `json:"suffix_match"`
+	InvertMatch  bool   `json:"invert_match"`
+}
+
+type xdsCidrRange struct {
+	AddressPrefix string `json:"address_prefix"`
+	PrefixLen     uint32 `json:"prefix_len"`
+}
+
+type xdsMetadata struct {
+	Filter string `json:"filter"`
+	Path   []struct {
+		Key string `json:"key"`
+	} `json:"path"`
+	Value *struct {
+		StringMatch *xdsStringMatcher `json:"string_match"`
+	} `json:"value"`
+}
+
+// the pseudo headers are stored with the mosn keys in the request headers
+var rbacPseudoHeaders = map[string]string{
+	":path":      protocol.MosnHeaderPathKey,
+	":method":    protocol.MosnHeaderMethod,
+	":authority": protocol.MosnHeaderHostKey,
+}
+
+// the peer identity set by the istio authn filter, it is the spiffe uri SAN without the scheme
+const (
+	istioAuthnFilter     = "istio_authn"
+	istioSourcePrincipal = "source.principal"
+	spiffePrefix         = "spiffe://"
+)
+
+// The unsupported rules are converted to the rules always matched in the deny policies,
+// and never matched in the allow policies, so the policies fail closed.
+// The result is reversed in the not rules.
+func unsupportedPermission(match bool) *v2.RBACPermission {
+	if match {
+		return &v2.RBACPermission{Any: true}
+	}
+	return &v2.RBACPermission{NotRule: &v2.RBACPermission{Any: true}}
+}
+
+func unsupportedPrincipal(match bool) *v2.RBACPrincipal {
+	if match {
+		return &v2.RBACPrincipal{Any: true}
+	}
+	return &v2.RBACPrincipal{NotID: &v2.RBACPrincipal{Any: true}}
+}
+
+// invalidRBACFilter returns true if a rbac filter of the listener failed to convert, which has no config.
+// The listener should be rejected, as the rbac filter without config allows all
+func invalidRBACFilter(listener *v2.Listener) bool {
+	for _, filter := range listener.StreamFilters {
+		if filter.Type == v2.RBACFilter && filter.Config == nil {
+			return true
+		}
+	}
+	for _, chain := range listener.FilterChains {
+		for _, filter := range chain.Filters {
+			if filter.Type == v2.RBAC_NETWORK_FILTER && filter.Config == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func convertRBACConfig(s *types.Struct) (map[string]interface{}, error) {
+	rbacConfig, err := parseRBACConfig(s)
+	if err != nil {
+		return nil, err
+	}
+	return makeJsonMap(rbacConfig)
+}
+
+func parseRBACConfig(s *types.Struct) (*v2.RBAC, error) {
+	buf := &bytes.Buffer{}
+	if err := (&gogojsonpb.Marshaler{OrigName: true}).Marshal(buf, s); err != nil {
+		return nil, err
+	}
+	xdsConfig := &xdsRBAC{}
+	if err := json.Unmarshal(buf.Bytes(), xdsConfig); err != nil {
+		return nil, err
+	}
+	return &v2.RBAC{
+		Rules:       convertRBACRules(xdsConfig.Rules),
+		ShadowRules: convertRBACRules(xdsConfig.ShadowRules),
+		StatPrefix:  xdsConfig.StatPrefix,
+	}, nil
+}
+
+func convertRBACRules(xdsRules *xdsRBACRules) *v2.RBACRules {
+	if xdsRules == nil {
+		return nil
+	}
+	rules := &v2.RBACRules{
+		Action:   v2.RBACAllow,
+		Policies: make(map[string]*v2.RBACPolicy, len(xdsRules.Policies)),
+	}
+	// the action enum may be the name or the number
+	switch action := xdsRules.Action.(type) {
+	case string:
+		if action == v2.RBACDeny {
+			rules.Action = v2.RBACDeny
+		}
+	case float64:
+		if action == 1 {
+			rules.Action = v2.RBACDeny
+		}
+	}
+	// the unsupported rules match in the deny policies
+	match := rules.Action == v2.RBACDeny
+	for name, xdsPolicy := range xdsRules.Policies {
+		if xdsPolicy == nil {
+			continue
+		}
+		policy := &v2.RBACPolicy{
+			Permissions: make([]*v2.RBACPermission, 0, len(xdsPolicy.Permissions)),
+			Principals:  make([]*v2.RBACPrincipal, 0, len(xdsPolicy.Principals)),
+		}
+		for _, p := range xdsPolicy.Permissions {
+			policy.Permissions = append(policy.Permissions, convertRBACPermission(p, match))
+		}
+		for _, p := range xdsPolicy.Principals {
+			policy.Principals = append(policy.Principals, convertRBACPrincipal(p, match))
+		}
+		rules.Policies[name] = policy
+	}
+	return rules
+}
+
+// match is the result of the unsupported rules
+func convertRBACPermission(p *xdsRBACPermission, match bool) *v2.RBACPermission {
+	if p == nil {
+		return unsupportedPermission(match)
+	}
+	switch {
+	case p.Any:
+		return &v2.RBACPermission{Any: true}
+	case p.AndRules != nil:
+		permission := &v2.RBACPermission{}
+		for _, rule := range p.AndRules.Rules {
+			permission.AndRules = append(permission.AndRules, convertRBACPermission(rule, match))
+		}
+		return permission
+	case p.OrRules != nil:
+		permission := &v2.RBACPermission{}
+		for _, rule := range p.OrRules.Rules {
+			permission.OrRules = append(permission.OrRules, convertRBACPermission(rule, match))
+		}
+		return permission
+	case p.NotRule != nil:
+		return &v2.RBACPermission{NotRule: convertRBACPermission(p.NotRule, !match)}
+	case p.Header != nil:
+		return &v2.RBACPermission{Header: convertRBACHeader(p.Header)}
+	case p.URLPath != nil && p.URLPath.Path != nil:
+		return &v2.RBACPermission{Path: convertStringMatcher(p.URLPath.Path)}
+	case p.DestinationIP != nil:
+		return &v2.RBACPermission{DestinationIP: convertRBACCidr(p.DestinationIP)}
+	case p.DestinationPort != 0:
+		return &v2.RBACPermission{DestinationPort: p.DestinationPort}
+	case p.RequestedServerName != nil:
+		return &v2.RBACPermission{ServerName: convertStringMatcher(p.RequestedServerName)}
+	}
+	log.DefaultLogger.Warnf("[xds] [rbac] unsupported permission, the match result is %t", match)
+	return unsupportedPermission(match)
+}
+
+// match is the result of the unsupported rules
+func convertRBACPrincipal(p *xdsRBACPrincipal, match bool) *v2.RBACPrincipal {
+	if p == nil {
+		return unsupportedPrincipal(match)
+	}
+	switch {
+	case p.Any:
+		return &v2.RBACPrincipal{Any: true}
+	case p.AndIDs != nil:
+		principal := &v2.RBACPrincipal{}
+		for _, id := range p.AndIDs.IDs {
+			principal.AndIDs = append(principal.AndIDs, convertRBACPrincipal(id, match))
+		}
+		return principal
+	case p.OrIDs != nil:
+		principal := &v2.RBACPrincipal{}
+		for _, id := range p.OrIDs.IDs {
+			principal.OrIDs = append(principal.OrIDs, convertRBACPrincipal(id, match))
+		}
+		return principal
+	case p.NotID != nil:
+		return &v2.RBACPrincipal{NotID: convertRBACPrincipal(p.NotID, !match)}
+	case p.Authenticated != nil:
+		authenticated := &v2.RBACAuthenticated{}
+		if p.Authenticated.PrincipalName != nil {
+			authenticated.PrincipalName = convertStringMatcher(p.Authenticated.PrincipalName)
+		}
+		return &v2.RBACPrincipal{Authenticated: authenticated}
+	case p.SourceIP != nil:
+		return &v2.RBACPrincipal{SourceIP: convertRBACCidr(p.SourceIP)}
+	case p.Header != nil:
+		return &v2.RBACPrincipal{Header: convertRBACHeader(p.Header)}
+	case p.Metadata != nil:
+		if principal := convertIstioSourcePrincipal(p.Metadata); principal != nil {
+			return principal
+		}
+	}
+	log.DefaultLogger.Warnf("[xds] [rbac] unsupported principal, the match result is %t", match)
+	return unsupportedPrincipal(match)
+}
+
+// convertIstioSourcePrincipal converts the source.principal of istio authn filter to the
+// authenticated principal, as the principal is the spiffe uri of the peer certificate without the scheme
+func convertIstioSourcePrincipal(m *xdsMetadata) *v2.RBACPrincipal {
+	if m.Filter != istioAuthnFilter || len(m.Path) != 1 || m.Path[0].Key != istioSourcePrincipal ||
+		m.Value == nil || m.Value.StringMatch == nil {
+		return nil
+	}
+	name := convertStringMatcher(m.Value.StringMatch)
+	switch {
+	case name.Regex != "":
+		name.Regex = regexp.QuoteMeta(spiffePrefix) + "(?:" + name.Regex + ")"
+	case name.Suffix != "":
+		// the suffix matches the spiffe uri too
+	case name.Prefix != "":
+		name.Prefix = spiffePrefix + name.Prefix
+	default:
+		name.Exact = spiffePrefix + name.Exact
+	}
+	return &v2.RBACPrincipal{
+		Authenticated: &v2.RBACAuthenticated{PrincipalName: name},
+	}
+}
+
+func convertStringMatcher(m *xdsStringMatcher) *v2.StringMatcher {
+	matcher := &v2.StringMatcher{
+		Exact:  m.Exact,
+		Prefix: m.Prefix,
+		Suffix: m.Suffix,
+		Regex:  m.Regex,
+	}
+	if m.SafeRegex != nil {
+		matcher.Regex = m.SafeRegex.Regex
+	}
+	return matcher
+}
+
+func convertRBACCidr(c *xdsCidrRange) *v2.CidrRange {
+	return &v2.CidrRange{
+		Address: c.AddressPrefix,
+		Length:  c.PrefixLen,
+	}
+}
+
+func convertRBACHeader(h *xdsHeaderMatcher) *v2.HeaderMatcher {
+	matcher := &v2.HeaderMatcher{
+		Name:        h.Name,
+		InvertMatch: h.InvertMatch,
+	}
+	if key, ok := rbacPseudoHeaders[h.Name]; ok {
+		matcher.Name = key
+	} else if strings.HasPrefix(h.Name, ":") {
+		matcher.Name = h.Name[1:]
+	}
+	switch {
+	case h.RegexMatch != "":
+		matcher.Value = h.RegexMatch
+		matcher.Regex = true
+	case h.SafeRegexMatch != nil:
+		matcher.Value = h.SafeRegexMatch.Regex
+		matcher.Regex = true
+	case h.PrefixMatch != "":
+		matcher.Value = "^" + regexp.QuoteMeta(h.PrefixMatch)
+		matcher.Regex = true
+	case h.SuffixMatch != "":
+		matcher.Value = regexp.QuoteMeta(h.SuffixMatch) + "$"
+		matcher.Regex = true
+	case h.RangeMatch != nil:
+		start, _ := h.RangeMatch.Start.Int64()
+		end, _ := h.RangeMatch.End.Int64()
+		matcher.RangeMatch = &v2.Int64Range{Start: start, End: end}
+	case h.PresentMatch != nil:
+		present := *h.PresentMatch
+		matcher.PresentMatch = &present
+	default:
+		matcher.Value = h.ExactMatch
+	}
+	return matcher
+}
@@ -0,0 +1,187 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package conv
+
+import (
+	"reflect"
+	"testing"
+
+	gogojsonpb "github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+)
+
+const istioRBACConfig = `{
+	"rules": {
+		"action": "DENY",
+		"policies": {
+			"service-viewer": {
+				"permissions": [{
+					"and_rules": {
+						"rules": [
+							{"header": {"name": ":method", "exact_match": "GET"}},
+							{"header": {"name": ":path", "prefix_match": "/api"}},
+							{"destination_port": 8080}
+						]
+					}
+				}],
+				"principals": [{
+					"and_ids": {
+						"ids": [
+							{"metadata": {"filter": "istio_authn", "path": [{"key": "source.principal"}], "value": {"string_match": {"exact": "cluster.local/ns/default/sa/viewer"}}}},
+							{"source_ip": {"address_prefix": "10.0.0.0", "prefix_len": 8}},
+							{"metadata": {"filter": "istio_authn", "path": [{"key": "request.auth.claims"}]}}
+						]
+					}
+				}]
+			}
+		}
+	},
+	"shadow_rules": {
+		"policies": {
+			"any": {
+				"permissions": [{"url_path": {"path": {"safe_regex": {"regex": "/.*"}}}}],
+				"principals": [{"authenticated": {"principal_name": {"suffix": "/sa/viewer"}}}]
+			}
+		}
+	}
+}`
+
+func TestConvertRBACConfig(t *testing.T) {
+	s := &types.Struct{}
+	if err := gogojsonpb.UnmarshalString(istioRBACConfig, s); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := parseRBACConfig(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &v2.RBAC{
+		Rules: &v2.RBACRules{
+			Action: v2.RBACDeny,
+			Policies: map[string]*v2.RBACPolicy{
+				"service-viewer": {
+					Permissions: []*v2.RBACPermission{
+						{
+							AndRules: []*v2.RBACPermission{
+								{Header: &v2.HeaderMatcher{Name: protocol.MosnHeaderMethod, Value: "GET"}},
+								{Header: &v2.HeaderMatcher{Name: protocol.MosnHeaderPathKey, Value: "^/api", Regex: true}},
+								{DestinationPort: 8080},
+							},
+						},
+					},
+					Principals: []*v2.RBACPrincipal{
+						{
+							AndIDs: []*v2.RBACPrincipal{
+								{Authenticated: &v2.RBACAuthenticated{PrincipalName: &v2.StringMatcher{Exact: "spiffe://cluster.local/ns/default/sa/viewer"}}},
+								{SourceIP: &v2.CidrRange{Address: "10.0.0.0", Length: 8}},
+								// unsupported principal matches in the deny policy
+								{Any: true},
+							},
+						},
+					},
+				},
+			},
+		},
+		ShadowRules: &v2.RBACRules{
+			Action: v2.RBACAllow,
+			Policies: map[string]*v2.RBACPolicy{
+				"any": {
+					Permissions: []*v2.RBACPermission{{Path: &v2.StringMatcher{Regex: "/.*"}}},
+					Principals:  []*v2.RBACPrincipal{{Authenticated: &v2.RBACAuthenticated{PrincipalName: &v2.StringMatcher{Suffix: "/sa/viewer"}}}},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("unexpected rbac config: %+v", cfg)
+	}
+}
+
+func TestConvertRBACHeaderRange(t *testing.T) {
+	s := &types.Struct{}
+	// the int64 of range match is a string in the json of protobuf
+	if err := gogojsonpb.UnmarshalString(`{
+		"rules": {"policies": {"range": {"permissions": [{"header": {"name": "x-version", "range_match": {"start": "1", "end": "10"}}}], "principals": [{"any": true}]}}}
+	}`, s); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := parseRBACConfig(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := cfg.Rules.Policies["range"].Permissions[0].Header
+	if header == nil || header.RangeMatch == nil || header.RangeMatch.Start != 1 || header.RangeMatch.End != 10 {
+		t.Errorf("unexpected header matcher: %+v", header)
+	}
+	if cfg.ShadowRules != nil {
+		t.Errorf("expected no shadow rules, but got %+v", cfg.ShadowRules)
+	}
+}
+
+func TestConvertRBACUnsupportedRules(t *testing.T) {
+	unsupported := &xdsRBACPermission{Metadata: &xdsMetadata{Filter: "unknown"}}
+	for _, tc := range []struct {
+		action   string
+		expected *v2.RBACPermission
+	}{
+		// the unsupported rules never match in the allow policies
+		{v2.RBACAllow, &v2.RBACPermission{OrRules: []*v2.RBACPermission{
+			{NotRule: &v2.RBACPermission{Any: true}},
+			{NotRule: &v2.RBACPermission{Any: true}},
+		}}},
+		// and match in the deny policies
+		{v2.RBACDeny, &v2.RBACPermission{OrRules: []*v2.RBACPermission{
+			{Any: true},
+			{NotRule: &v2.RBACPermission{NotRule: &v2.RBACPermission{Any: true}}},
+		}}},
+	} {
+		permission := &xdsRBACPermission{}
+		permission.OrRules = &struct {
+			Rules []*xdsRBACPermission `json:"rules"`
+		}{Rules: []*xdsRBACPermission{unsupported, {NotRule: unsupported}}}
+		rules := convertRBACRules(&xdsRBACRules{
+			Action: tc.action,
+			Policies: map[string]*xdsRBACPolicy{
+				"unsupported": {
+					Permissions: []*xdsRBACPermission{permission},
+					Principals:  []*xdsRBACPrincipal{{Metadata: &xdsMetadata{Filter: "unknown"}}},
+				},
+			},
+		})
+		policy := rules.Policies["unsupported"]
+		if !reflect.DeepEqual(policy.Permissions[0], tc.expected) {
+			t.Errorf("%s: unexpected permission: %+v", tc.action, policy.Permissions[0])
+		}
+		if expected := unsupportedPrincipal(tc.action == v2.RBACDeny); !reflect.DeepEqual(policy.Principals[0], expected) {
+			t.Errorf("%s: unexpected principal: %+v", tc.action, policy.Principals[0])
+		}
+	}
+}
+
+func TestInvalidRBACFilter(t *testing.T) {
+	listener := &v2.Listener{}
+	listener.StreamFilters = []v2.Filter{{Type: v2.RBACFilter, Config: map[string]interface{}{}}}
+	if invalidRBACFilter(listener) {
+		t.Error("expected the rbac filter is valid")
+	}
+	listener.StreamFilters = append(listener.StreamFilters, v2.Filter{Type: v2.RBACFilter})
+	if !invalidRBACFilter(listener) {
+		t.Error("expected the rbac filter without config is invalid")
+	}
+}
@@ -158,26 +158,31 @@ func ConvertUpdateEndpoints(loadAssignments []*envoy_api_v2.ClusterLoadAssignmen
 	for _, loadAssignment := range loadAssignments {
 		clusterName := loadAssignment.ClusterName
 
-		for _, endpoints := range loadAssignment.Endpoints {
-			hosts := ConvertEndpointsConfig(&endpoints)
-			log.DefaultLogger.Debugf("xds client update endpoints: cluster: %s, priority: %d", loadAssignment.ClusterName, endpoints.Priority)
-			for index, host := range hosts {
-				log.DefaultLogger.Debugf("host[%d] is : %+v", index, host)
-			}
+		// the hosts of all localities and priorities are updated together,
+		// the priority and zone are kept in the host config
+		var hosts []v2.Host
+		for i := range loadAssignment.Endpoints {
+			endpoints := &loadAssignment.Endpoints[i]
+			log.DefaultLogger.Debugf("xds client update endpoints: cluster: %s, priority: %d, zone: %s", loadAssignment.ClusterName, endpoints.Priority, endpoints.GetLocality().GetZone())
+			hosts = append(hosts, ConvertEndpointsConfig(endpoints)...)
+		}
+		for index, host := range hosts {
+			log.DefaultLogger.Debugf("host[%d] is : %+v", index, host)
+		}
 
-			clusterMngAdapter := clusterAdapter.GetClusterMngAdapterInstance()
-			if clusterMngAdapter == nil {
-				log.DefaultLogger.Errorf("xds client update Error: clusterMngAdapter nil , hosts are %+v", hosts)
-				errGlobal = fmt.Errorf("xds client update Error: clusterMngAdapter nil , hosts are %+v", hosts)
-			}
+		clusterMngAdapter := clusterAdapter.GetClusterMngAdapterInstance()
+		if clusterMngAdapter == nil {
+			log.DefaultLogger.Errorf("xds client update Error: clusterMngAdapter nil , hosts are %+v", hosts)
+			errGlobal = fmt.Errorf("xds client update Error: clusterMngAdapter nil , hosts are %+v", hosts)
+			continue
+		}
 
-			if err := clusterAdapter.GetClusterMngAdapterInstance().TriggerClusterHostUpdate(clusterName, hosts); err != nil {
-				log.DefaultLogger.Errorf("xds client update Error = %s, hosts are %+v", err.Error(), hosts)
-				errGlobal = fmt.Errorf("xds client update Error = %s, hosts are %+v", err.Error(), hosts)
+		if err := clusterMngAdapter.TriggerClusterHostUpdate(clusterName, hosts); err != nil {
+			log.DefaultLogger.Errorf("xds client update Error = %s, hosts are %+v", err.Error(), hosts)
+			errGlobal = fmt.Errorf("xds client update Error = %s, hosts are %+v", err.Error(), hosts)
 
-			} else {
-				log.DefaultLogger.Debugf("xds client update host success,hosts are %+v", hosts)
-			}
+		} else {
+			log.DefaultLogger.Debugf("xds client update host success,hosts are %+v", hosts)
 		}
 	}
 
@@ -23,6 +23,7 @@ import (
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	xdsendpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
 	xdslistener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
 	xdsroute "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
 	xdshttpfault "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/fault/v2"
@@ -33,6 +34,7 @@ import (
 	"mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/router"
 	"mosn.io/mosn/pkg/server"
+	clusterAdapter "mosn.io/mosn/pkg/upstream/cluster"
 )
 
 func messageToStruct(t *testing.T, msg proto.Message) *types.Struct {
@@ -278,3 +280,68 @@ func Test_updateListener(t *testing.T) {
 	}
 
 }
+
+func TestConvertUpdateEndpoints(t *testing.T) {
+	clusterName := "eds_priority_cluster"
+	if err := clusterAdapter.GetClusterMngAdapterInstance().TriggerClusterAddOrUpdate(v2.Cluster{
+		Name:        clusterName,
+		ClusterType: v2.EDS_CLUSTER,
+		LbType:      v2.LB_RANDOM,
+	}); err != nil {
+		t.Fatalf("add cluster failed: %v", err)
+	}
+	endpoint := func(addr string, port uint32) xdsendpoint.LbEndpoint {
+		return xdsendpoint.LbEndpoint{
+			HostIdentifier: &xdsendpoint.LbEndpoint_Endpoint{
+				Endpoint: &xdsendpoint.Endpoint{
+					Address: socketAddress(addr, port),
+				},
+			},
+		}
+	}
+	assignment := &envoy_api_v2.ClusterLoadAssignment{
+		ClusterName: clusterName,
+		Endpoints: []xdsendpoint.LocalityLbEndpoints{
+			{
+				Locality:    &core.Locality{Zone: "zone-a"},
+				LbEndpoints: []xdsendpoint.LbEndpoint{endpoint("127.0.0.1", 80)},
+			},
+			{
+				Locality:    &core.Locality{Zone: "zone-b"},
+				LbEndpoints: []xdsendpoint.LbEndpoint{endpoint("127.0.0.2", 80)},
+				Priority:    1,
+			},
+		},
+	}
+	if err := ConvertUpdateEndpoints([]*envoy_api_v2.ClusterLoadAssignment{assignment}); err != nil {
+		t.Fatalf("update endpoints failed: %v", err)
+	}
+	snap := clusterAdapter.GetClusterMngAdapterInstance().GetClusterSnapshot(nil, clusterName)
+	defer clusterAdapter.GetClusterMngAdapterInstance().PutClusterSnapshot(snap)
+	// all localities are kept
+	hosts := snap.HostSet().Hosts()
+	if len(hosts) != 2 {
+		t.Fatalf("expected 2 hosts, but got %d", len(hosts))
+	}
+	for _, h := range hosts {
+		cfg := h.Config()
+		switch cfg.Address {
+		case "127.0.0.1:80":
+			if cfg.Priority != 0 || cfg.Zone != "zone-a" {
+				t.Errorf("unexpected host config: %+v", cfg)
+			}
+		case "127.0.0.2:80":
+			if cfg.Priority != 1 || cfg.Zone != "zone-b" {
+				t.Errorf("unexpected host config: %+v", cfg)
+			}
+		default:
+			t.Errorf("unexpected host: %s", cfg.Address)
+		}
+	}
+	// priority 0 is healthy, takes all the traffic
+	for i := 0; i < 10; i++ {
+		if h := snap.LoadBalancer().ChooseHost(nil); h == nil || h.AddressString() != "127.0.0.1:80" {
+			t.Fatalf("expected choose host in priority 0, but got %v", h)
+		}
+	}
+}
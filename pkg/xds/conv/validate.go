@@ -0,0 +1,134 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"mosn.io/mosn/pkg/router"
+)
+
+// ValidateXXX Function checks whether the xds resources can be converted into mosn config completely.
+// A resource that can not be converted should be rejected (NACK) instead of being dropped silently,
+// so the last good config keeps taking effect.
+
+// ValidateListeners validates the listeners in a lds response
+func ValidateListeners(listeners []*envoy_api_v2.Listener) error {
+	names := make(map[string]struct{}, len(listeners))
+	for _, listener := range listeners {
+		if listener == nil {
+			return errors.New("listener is nil")
+		}
+		name := listener.GetName()
+		if name == "" {
+			return errors.New("listener name is empty")
+		}
+		if _, ok := names[name]; ok {
+			return fmt.Errorf("duplicate listener %s", name)
+		}
+		names[name] = struct{}{}
+		if filters := unsupportedFilters(listener); len(filters) > 0 {
+			return fmt.Errorf("listener %s contains unsupported network filters: %s", name, strings.Join(filters, ","))
+		}
+		mosnListener := ConvertListenerConfig(listener)
+		if mosnListener == nil {
+			return fmt.Errorf("listener %s convert failed", name)
+		}
+		if mosnListener.Addr == nil {
+			return fmt.Errorf("listener %s has an invalid address", name)
+		}
+	}
+	return nil
+}
+
+// ValidateClusters validates the clusters in a cds response
+func ValidateClusters(clusters []*envoy_api_v2.Cluster) error {
+	names := make(map[string]struct{}, len(clusters))
+	for _, cluster := range clusters {
+		if cluster == nil {
+			return errors.New("cluster is nil")
+		}
+		name := cluster.GetName()
+		if name == "" {
+			return errors.New("cluster name is empty")
+		}
+		if _, ok := names[name]; ok {
+			return fmt.Errorf("duplicate cluster %s", name)
+		}
+		names[name] = struct{}{}
+		// the hosts of dns clusters are hostnames, they are resolved by the cluster
+		if isDNSCluster(cluster) {
+			if len(convertDNSClusterHosts(cluster.GetHosts())) != len(cluster.GetHosts()) {
+				return fmt.Errorf("cluster %s has an invalid dns host address", name)
+			}
+		} else {
+			for _, host := range cluster.GetHosts() {
+				if convertAddress(host) == nil {
+					return fmt.Errorf("cluster %s has an invalid host address: %s", name, host.String())
+				}
+			}
+		}
+		if cluster.GetTlsContext() != nil && !convertTLS(cluster.GetTlsContext()).Status {
+			return fmt.Errorf("cluster %s has an invalid tls context", name)
+		}
+	}
+	return nil
+}
+
+// ValidateEndpoints validates the cluster load assignments in an eds response
+func ValidateEndpoints(loadAssignments []*envoy_api_v2.ClusterLoadAssignment) error {
+	for _, loadAssignment := range loadAssignments {
+		if loadAssignment == nil {
+			return errors.New("cluster load assignment is nil")
+		}
+		if loadAssignment.GetClusterName() == "" {
+			return errors.New("cluster load assignment has an empty cluster name")
+		}
+		for i := range loadAssignment.Endpoints {
+			endpoints := &loadAssignment.Endpoints[i]
+			if hosts := ConvertEndpointsConfig(endpoints); len(hosts) != len(endpoints.GetLbEndpoints()) {
+				return fmt.Errorf("cluster %s contains unsupported endpoint address", loadAssignment.GetClusterName())
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateRouters validates the route configurations in a rds response
+func ValidateRouters(routers []*envoy_api_v2.RouteConfiguration) error {
+	for _, xdsRouter := range routers {
+		if xdsRouter == nil {
+			return errors.New("route configuration is nil")
+		}
+		mosnRouter, _ := ConvertRouterConf("", xdsRouter)
+		if mosnRouter == nil {
+			return fmt.Errorf("route configuration %s convert failed", xdsRouter.GetName())
+		}
+		// a route configuration without virtual hosts is valid, which is used as a placeholder
+		if len(mosnRouter.VirtualHosts) == 0 {
+			continue
+		}
+		if _, err := router.NewRouters(mosnRouter); err != nil {
+			return fmt.Errorf("route configuration %s is invalid: %v", xdsRouter.GetName(), err)
+		}
+	}
+	return nil
+}
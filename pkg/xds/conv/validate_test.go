@@ -0,0 +1,251 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conv
+
+import (
+	"testing"
+	"time"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	xdslistener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	xdsroute "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	xdsutil "github.com/envoyproxy/go-control-plane/pkg/util"
+	"github.com/gogo/protobuf/types"
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+func socketAddress(addr string, port uint32) *core.Address {
+	return &core.Address{
+		Address: &core.Address_SocketAddress{
+			SocketAddress: &core.SocketAddress{
+				Address: addr,
+				PortSpecifier: &core.SocketAddress_PortValue{
+					PortValue: port,
+				},
+			},
+		},
+	}
+}
+
+func TestValidateListeners(t *testing.T) {
+	good := &envoy_api_v2.Listener{
+		Name:    "good",
+		Address: *socketAddress("127.0.0.1", 8080),
+	}
+	if err := ValidateListeners([]*envoy_api_v2.Listener{good}); err != nil {
+		t.Fatalf("validate good listener failed: %v", err)
+	}
+	unsupported := &envoy_api_v2.Listener{
+		Name:    "unsupported",
+		Address: *socketAddress("127.0.0.1", 8081),
+		FilterChains: []xdslistener.FilterChain{
+			{
+				Filters: []xdslistener.Filter{
+					{Name: xdsutil.TCPProxy},
+					{Name: "envoy.unknown"},
+				},
+			},
+		},
+	}
+	pipe := &envoy_api_v2.Listener{
+		Name: "pipe",
+		Address: core.Address{
+			Address: &core.Address_Pipe{
+				Pipe: &core.Pipe{Path: "/tmp/pipe"},
+			},
+		},
+	}
+	// the invalid rbac config should not be converted to a filter allows all
+	invalidRBAC := &envoy_api_v2.Listener{
+		Name:    "invalid_rbac",
+		Address: *socketAddress("127.0.0.1", 8083),
+		FilterChains: []xdslistener.FilterChain{
+			{
+				Filters: []xdslistener.Filter{
+					{
+						Name: IstioNetworkRBAC,
+						ConfigType: &xdslistener.Filter_Config{
+							Config: &types.Struct{
+								Fields: map[string]*types.Value{
+									"rules": {Kind: &types.Value_StringValue{StringValue: "invalid"}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	for i, listeners := range [][]*envoy_api_v2.Listener{
+		{unsupported},
+		{invalidRBAC},
+		{pipe},
+		{good, good},
+		{{Address: *socketAddress("127.0.0.1", 8082)}},
+		{nil},
+	} {
+		if err := ValidateListeners(listeners); err == nil {
+			t.Errorf("#%d expected an error", i)
+		}
+	}
+}
+
+func TestValidateClusters(t *testing.T) {
+	good := &envoy_api_v2.Cluster{
+		Name:  "good",
+		Hosts: []*core.Address{socketAddress("127.0.0.1", 8080)},
+	}
+	if err := ValidateClusters([]*envoy_api_v2.Cluster{good}); err != nil {
+		t.Fatalf("validate good cluster failed: %v", err)
+	}
+	badHost := &envoy_api_v2.Cluster{
+		Name:  "bad_host",
+		Hosts: []*core.Address{socketAddress("127.0.0.1.1", 8080)},
+	}
+	for i, clusters := range [][]*envoy_api_v2.Cluster{
+		{badHost},
+		{good, good},
+		{{}},
+	} {
+		if err := ValidateClusters(clusters); err == nil {
+			t.Errorf("#%d expected an error", i)
+		}
+	}
+}
+
+func TestValidateEndpoints(t *testing.T) {
+	lbEndpoint := func(addr *core.Address) endpoint.LbEndpoint {
+		return endpoint.LbEndpoint{
+			HostIdentifier: &endpoint.LbEndpoint_Endpoint{
+				Endpoint: &endpoint.Endpoint{
+					Address: addr,
+				},
+			},
+		}
+	}
+	good := &envoy_api_v2.ClusterLoadAssignment{
+		ClusterName: "good",
+		Endpoints: []endpoint.LocalityLbEndpoints{
+			{LbEndpoints: []endpoint.LbEndpoint{lbEndpoint(socketAddress("127.0.0.1", 8080))}},
+		},
+	}
+	if err := ValidateEndpoints([]*envoy_api_v2.ClusterLoadAssignment{good}); err != nil {
+		t.Fatalf("validate good endpoints failed: %v", err)
+	}
+	bad := &envoy_api_v2.ClusterLoadAssignment{
+		ClusterName: "bad",
+		Endpoints: []endpoint.LocalityLbEndpoints{
+			{LbEndpoints: []endpoint.LbEndpoint{lbEndpoint(&core.Address{})}},
+		},
+	}
+	if err := ValidateEndpoints([]*envoy_api_v2.ClusterLoadAssignment{bad}); err == nil {
+		t.Error("expected an error for unsupported address")
+	}
+	if err := ValidateEndpoints([]*envoy_api_v2.ClusterLoadAssignment{{}}); err == nil {
+		t.Error("expected an error for empty cluster name")
+	}
+}
+
+func TestValidateRouters(t *testing.T) {
+	newRouter := func(regex string) *envoy_api_v2.RouteConfiguration {
+		return &envoy_api_v2.RouteConfiguration{
+			Name: "test",
+			VirtualHosts: []xdsroute.VirtualHost{
+				{
+					Name:    "test",
+					Domains: []string{"*"},
+					Routes: []xdsroute.Route{
+						{
+							Match: xdsroute.RouteMatch{
+								PathSpecifier: &xdsroute.RouteMatch_Regex{
+									Regex: regex,
+								},
+							},
+							Action: &xdsroute.Route_Route{
+								Route: &xdsroute.RouteAction{
+									ClusterSpecifier: &xdsroute.RouteAction_Cluster{
+										Cluster: "cluster",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	if err := ValidateRouters([]*envoy_api_v2.RouteConfiguration{newRouter("/.*")}); err != nil {
+		t.Fatalf("validate good router failed: %v", err)
+	}
+	// placeholder router without virtual hosts is valid
+	if err := ValidateRouters([]*envoy_api_v2.RouteConfiguration{{Name: "placeholder"}}); err != nil {
+		t.Fatalf("validate placeholder router failed: %v", err)
+	}
+	if err := ValidateRouters([]*envoy_api_v2.RouteConfiguration{newRouter("/[")}); err == nil {
+		t.Error("expected an error for invalid regex")
+	}
+}
+
+func TestConvertDNSCluster(t *testing.T) {
+	refreshRate := 10 * time.Second
+	cluster := &envoy_api_v2.Cluster{
+		Name: "dns",
+		ClusterDiscoveryType: &envoy_api_v2.Cluster_Type{
+			Type: envoy_api_v2.Cluster_STRICT_DNS,
+		},
+		Hosts:           []*core.Address{socketAddress("foo.example.com", 8080)},
+		DnsRefreshRate:  &refreshRate,
+		DnsLookupFamily: envoy_api_v2.Cluster_V4_ONLY,
+		DnsResolvers:    []*core.Address{socketAddress("127.0.0.1", 53)},
+	}
+	if err := ValidateClusters([]*envoy_api_v2.Cluster{cluster}); err != nil {
+		t.Fatalf("dns cluster expected to be valid, but got %v", err)
+	}
+	clusters := ConvertClustersConfig([]*envoy_api_v2.Cluster{cluster})
+	if len(clusters) != 1 {
+		t.Fatalf("convert dns cluster failed")
+	}
+	c := clusters[0]
+	if c.ClusterType != v2.STRICT_DNS_CLUSTER ||
+		c.DnsLookupFamily != v2.DNS_V4_ONLY ||
+		c.DnsRefreshRate.Duration != refreshRate ||
+		len(c.DnsResolvers) != 1 || c.DnsResolvers[0] != "127.0.0.1:53" {
+		t.Errorf("dns config is not expected: %+v", c)
+	}
+	// the hostname is kept
+	if len(c.Hosts) != 1 || c.Hosts[0].Address != "foo.example.com:8080" {
+		t.Errorf("dns cluster hosts is not expected: %v", c.Hosts)
+	}
+}
+
+func TestConvertClusterIdleTimeout(t *testing.T) {
+	idleTimeout := 30 * time.Second
+	cluster := &envoy_api_v2.Cluster{
+		Name:  "idle",
+		Hosts: []*core.Address{socketAddress("127.0.0.1", 8080)},
+		CommonHttpProtocolOptions: &core.HttpProtocolOptions{
+			IdleTimeout: &idleTimeout,
+		},
+	}
+	clusters := ConvertClustersConfig([]*envoy_api_v2.Cluster{cluster})
+	if len(clusters) != 1 || clusters[0].ConnPool.IdleTimeout == nil || clusters[0].ConnPool.IdleTimeout.Duration != idleTimeout {
+		t.Errorf("idle timeout is not converted: %+v", clusters)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"errors"
+	"time"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/gogo/googleapis/google/rpc"
+	"mosn.io/mosn/pkg/admin/store"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/xds/v2/rds"
+	v3 "mosn.io/mosn/pkg/xds/v3"
+)
+
+// ackResponse sends an ACK for the response if err is nil, otherwise sends a NACK with the error detail.
+// The NACK carries the last accepted version, which is the version still taking effect.
+func (adsClient *ADSClient) ackResponse(resp *envoy_api_v2.DiscoveryResponse, err error) error {
+	typeURL := resp.GetTypeUrl()
+	status, _ := store.GetXdsStatus(typeURL)
+	status.Nonce = resp.GetNonce()
+	status.LastUpdate = time.Now()
+	req := &envoy_api_v2.DiscoveryRequest{
+		ResourceNames: adsClient.subscribedResourceNames(typeURL),
+		TypeUrl:       typeURL,
+		ResponseNonce: resp.GetNonce(),
+		Node: &envoy_api_v2_core1.Node{
+			Id:       types.GetGlobalXdsInfo().ServiceNode,
+			Cluster:  types.GetGlobalXdsInfo().ServiceCluster,
+			Metadata: types.GetGlobalXdsInfo().Metadata,
+		},
+	}
+	if err == nil {
+		status.VersionInfo = resp.GetVersionInfo()
+		status.LastError = ""
+	} else {
+		log.DefaultLogger.Errorf("[xds] [ads client] reject %s version %s: %v, keep version %s",
+			typeURL, resp.GetVersionInfo(), err, status.VersionInfo)
+		status.LastError = err.Error()
+		req.ErrorDetail = &rpc.Status{
+			Code:    int32(rpc.INVALID_ARGUMENT),
+			Message: err.Error(),
+		}
+	}
+	req.VersionInfo = status.VersionInfo
+	store.SetXdsStatus(typeURL, status)
+
+	adsClient.StreamClientMutex.RLock()
+	streamClient := adsClient.StreamClient
+	adsClient.StreamClientMutex.RUnlock()
+	if streamClient == nil {
+		return errors.New("stream client is nil")
+	}
+	if err := streamClient.Send(req); err != nil {
+		log.DefaultLogger.Infof("[xds] [ads client] send ack of %s fail: %v", typeURL, err)
+		return err
+	}
+	return nil
+}
+
+// subscribedResourceNames returns the resource names requested by the type url.
+// lds and cds are wildcard subscriptions, so the names are empty
+func (adsClient *ADSClient) subscribedResourceNames(typeURL string) []string {
+	switch typeURL {
+	case EnvoyRouteConfiguration, v3.EnvoyRouteConfiguration:
+		return rds.GetRouterNames()
+	case EnvoyClusterLoadAssignment, v3.EnvoyClusterLoadAssignment:
+		if names, ok := adsClient.edsResourceNames.Load().([]string); ok {
+			return names
+		}
+	}
+	return []string{}
+}
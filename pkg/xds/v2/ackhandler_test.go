@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"testing"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"github.com/gogo/protobuf/types"
+	"mosn.io/mosn/pkg/admin/store"
+)
+
+type mockStreamClient struct {
+	ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient
+	requests []*envoy_api_v2.DiscoveryRequest
+}
+
+func (sc *mockStreamClient) Send(req *envoy_api_v2.DiscoveryRequest) error {
+	sc.requests = append(sc.requests, req)
+	return nil
+}
+
+func (sc *mockStreamClient) last() *envoy_api_v2.DiscoveryRequest {
+	return sc.requests[len(sc.requests)-1]
+}
+
+func TestClusterAckAndNack(t *testing.T) {
+	store.ResetXdsStatus()
+	defer store.ResetXdsStatus()
+	sc := &mockStreamClient{}
+	client := &ADSClient{
+		StreamClient: sc,
+	}
+	// an empty response is accepted
+	HandleEnvoyCluster(client, &envoy_api_v2.DiscoveryResponse{
+		VersionInfo: "1",
+		Nonce:       "nonce-1",
+		TypeUrl:     EnvoyCluster,
+	})
+	ack := sc.requests[0]
+	if ack.TypeUrl != EnvoyCluster || ack.VersionInfo != "1" || ack.ResponseNonce != "nonce-1" || ack.ErrorDetail != nil {
+		t.Fatalf("unexpected ack: %+v", ack)
+	}
+	// a cluster without name is rejected
+	cluster := &envoy_api_v2.Cluster{}
+	b, _ := cluster.Marshal()
+	HandleEnvoyCluster(client, &envoy_api_v2.DiscoveryResponse{
+		VersionInfo: "2",
+		Nonce:       "nonce-2",
+		TypeUrl:     EnvoyCluster,
+		Resources: []types.Any{
+			{TypeUrl: EnvoyCluster, Value: b},
+		},
+	})
+	nack := sc.last()
+	if nack.VersionInfo != "1" || nack.ResponseNonce != "nonce-2" || nack.ErrorDetail == nil || nack.ErrorDetail.Message == "" {
+		t.Fatalf("unexpected nack: %+v", nack)
+	}
+	status, ok := store.GetXdsStatus(EnvoyCluster)
+	if !ok || status.VersionInfo != "1" || status.Nonce != "nonce-2" || status.LastError == "" {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	// an invalid resource is rejected
+	HandleEnvoyCluster(client, &envoy_api_v2.DiscoveryResponse{
+		VersionInfo: "3",
+		Nonce:       "nonce-3",
+		TypeUrl:     EnvoyCluster,
+		Resources: []types.Any{
+			{TypeUrl: EnvoyCluster, Value: []byte("invalid")},
+		},
+	})
+	if nack := sc.last(); nack.VersionInfo != "1" || nack.ResponseNonce != "nonce-3" || nack.ErrorDetail == nil {
+		t.Fatalf("unexpected nack: %+v", nack)
+	}
+}
+
+func TestEndpointsAckResourceNames(t *testing.T) {
+	store.ResetXdsStatus()
+	defer store.ResetXdsStatus()
+	sc := &mockStreamClient{}
+	client := &ADSClient{
+		StreamClient: sc,
+	}
+	names := []string{"outbound|80||test"}
+	if err := client.reqEndpoints(sc, names); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.ackResponse(&envoy_api_v2.DiscoveryResponse{
+		VersionInfo: "1",
+		Nonce:       "nonce-1",
+		TypeUrl:     EnvoyClusterLoadAssignment,
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+	ack := sc.last()
+	if len(ack.ResourceNames) != 1 || ack.ResourceNames[0] != names[0] {
+		t.Fatalf("ack should carry the subscribed names, but got %v", ack.ResourceNames)
+	}
+}
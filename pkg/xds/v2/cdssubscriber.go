@@ -19,12 +19,14 @@ package v2
 
 import (
 	"errors"
+	"fmt"
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/types"
+	v3 "mosn.io/mosn/pkg/xds/v3"
 )
 
 func (c *ADSClient) reqClusters(streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient) error {
@@ -34,7 +36,7 @@ func (c *ADSClient) reqClusters(streamClient ads.AggregatedDiscoveryService_Stre
 	err := streamClient.Send(&envoy_api_v2.DiscoveryRequest{
 		VersionInfo:   "",
 		ResourceNames: []string{},
-		TypeUrl:       "type.googleapis.com/envoy.api.v2.Cluster",
+		TypeUrl:       c.typeURL(EnvoyCluster),
 		ResponseNonce: "",
 		ErrorDetail:   nil,
 		Node: &envoy_api_v2_core1.Node{
@@ -50,14 +52,22 @@ func (c *ADSClient) reqClusters(streamClient ads.AggregatedDiscoveryService_Stre
 	return nil
 }
 
-func (c *ADSClient) handleClustersResp(resp *envoy_api_v2.DiscoveryResponse) []*envoy_api_v2.Cluster {
+func (c *ADSClient) handleClustersResp(resp *envoy_api_v2.DiscoveryResponse) ([]*envoy_api_v2.Cluster, error) {
 	clusters := make([]*envoy_api_v2.Cluster, 0, len(resp.Resources))
 	for _, res := range resp.Resources {
-		cluster := envoy_api_v2.Cluster{}
-		if err := cluster.Unmarshal(res.GetValue()); err != nil {
+		var cluster *envoy_api_v2.Cluster
+		var err error
+		if res.GetTypeUrl() == v3.EnvoyCluster {
+			cluster, err = v3.ConvertCluster(res.GetValue())
+		} else {
+			cluster = &envoy_api_v2.Cluster{}
+			err = cluster.Unmarshal(res.GetValue())
+		}
+		if err != nil {
 			log.DefaultLogger.Errorf("ADSClient unmarshal cluster fail: %v", err)
+			return nil, fmt.Errorf("unmarshal cluster fail: %v", err)
 		}
-		clusters = append(clusters, &cluster)
+		clusters = append(clusters, cluster)
 	}
-	return clusters
+	return clusters, nil
 }
@@ -21,6 +21,7 @@ import (
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/xds/conv"
+	v3 "mosn.io/mosn/pkg/xds/v3"
 )
 
 // default type url mosn will handle
@@ -31,19 +32,49 @@ const (
 	EnvoyRouteConfiguration    = "type.googleapis.com/envoy.api.v2.RouteConfiguration"
 )
 
+// v3TypeURLs maps the v2 type urls to the v3 type urls
+var v3TypeURLs = map[string]string{
+	EnvoyListener:              v3.EnvoyListener,
+	EnvoyCluster:               v3.EnvoyCluster,
+	EnvoyClusterLoadAssignment: v3.EnvoyClusterLoadAssignment,
+	EnvoyRouteConfiguration:    v3.EnvoyRouteConfiguration,
+}
+
 func init() {
 	RegisterTypeURLHandleFunc(EnvoyListener, HandleEnvoyListener)
 	RegisterTypeURLHandleFunc(EnvoyCluster, HandleEnvoyCluster)
 	RegisterTypeURLHandleFunc(EnvoyClusterLoadAssignment, HandleEnvoyClusterLoadAssignment)
 	RegisterTypeURLHandleFunc(EnvoyRouteConfiguration, HandleEnvoyRouteConfiguration)
+	// the v3 resources are converted into v2 resources when they are unmarshaled
+	// so the v3 type urls use the same handle functions
+	RegisterTypeURLHandleFunc(v3.EnvoyListener, HandleEnvoyListener)
+	RegisterTypeURLHandleFunc(v3.EnvoyCluster, HandleEnvoyCluster)
+	RegisterTypeURLHandleFunc(v3.EnvoyClusterLoadAssignment, HandleEnvoyClusterLoadAssignment)
+	RegisterTypeURLHandleFunc(v3.EnvoyRouteConfiguration, HandleEnvoyRouteConfiguration)
+}
+
+// typeURL returns the type url of the transport api version that the client uses
+func (adsClient *ADSClient) typeURL(url string) string {
+	if adsClient.AdsConfig != nil && adsClient.AdsConfig.TransportAPIVersion == v3.TransportAPIV3 {
+		return v3TypeURLs[url]
+	}
+	return url
 }
 
 // HandleEnvoyListener parse envoy data to mosn listener config
 func HandleEnvoyListener(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse) {
 	log.DefaultLogger.Tracef("get lds resp,handle it")
-	listeners := client.handleListenersResp(resp)
+	listeners, err := client.handleListenersResp(resp)
+	if err == nil {
+		err = conv.ValidateListeners(listeners)
+	}
+	if err != nil {
+		client.ackResponse(resp, err)
+		return
+	}
 	log.DefaultLogger.Infof("get %d listeners from LDS", len(listeners))
 	conv.ConvertAddOrUpdateListeners(listeners)
+	client.ackResponse(resp, nil)
 	if err := client.reqRoutes(client.StreamClient); err != nil {
 		log.DefaultLogger.Warnf("send thread request rds fail!auto retry next period")
 	}
@@ -52,9 +83,17 @@ func HandleEnvoyListener(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse
 // HandleEnvoyCluster parse envoy data to mosn cluster config
 func HandleEnvoyCluster(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse) {
 	log.DefaultLogger.Tracef("get cds resp,handle it")
-	clusters := client.handleClustersResp(resp)
+	clusters, err := client.handleClustersResp(resp)
+	if err == nil {
+		err = conv.ValidateClusters(clusters)
+	}
+	if err != nil {
+		client.ackResponse(resp, err)
+		return
+	}
 	log.DefaultLogger.Infof("get %d clusters from CDS", len(clusters))
 	conv.ConvertUpdateClusters(clusters)
+	client.ackResponse(resp, nil)
 	clusterNames := make([]string, 0)
 
 	for _, cluster := range clusters {
@@ -77,9 +116,17 @@ func HandleEnvoyCluster(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse)
 // HandleEnvoyClusterLoadAssignment parse envoy data to mosn endpoint config
 func HandleEnvoyClusterLoadAssignment(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse) {
 	log.DefaultLogger.Tracef("get eds resp,handle it ")
-	endpoints := client.handleEndpointsResp(resp)
+	endpoints, err := client.handleEndpointsResp(resp)
+	if err == nil {
+		err = conv.ValidateEndpoints(endpoints)
+	}
+	if err != nil {
+		client.ackResponse(resp, err)
+		return
+	}
 	log.DefaultLogger.Infof("get %d endpoints from EDS", len(endpoints))
 	conv.ConvertUpdateEndpoints(endpoints)
+	client.ackResponse(resp, nil)
 
 	if err := client.reqListeners(client.StreamClient); err != nil {
 		log.DefaultLogger.Warnf("send thread request lds fail!auto retry next period")
@@ -89,7 +136,15 @@ func HandleEnvoyClusterLoadAssignment(client *ADSClient, resp *envoy_api_v2.Disc
 // HandleEnvoyRouteConfiguration parse envoy data to mosn route config
 func HandleEnvoyRouteConfiguration(client *ADSClient, resp *envoy_api_v2.DiscoveryResponse) {
 	log.DefaultLogger.Tracef("get rds resp,handle it")
-	routes := client.handleRoutesResp(resp)
+	routes, err := client.handleRoutesResp(resp)
+	if err == nil {
+		err = conv.ValidateRouters(routes)
+	}
+	if err != nil {
+		client.ackResponse(resp, err)
+		return
+	}
 	log.DefaultLogger.Infof("get %d routes from RDS", len(routes))
 	conv.ConvertAddOrUpdateRouters(routes)
+	client.ackResponse(resp, nil)
 }
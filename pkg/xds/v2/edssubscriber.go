@@ -19,12 +19,14 @@ package v2
 
 import (
 	"errors"
+	"fmt"
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/types"
+	v3 "mosn.io/mosn/pkg/xds/v3"
 )
 
 func (c *ADSClient) reqEndpoints(streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient, clusterNames []string) error {
@@ -34,7 +36,7 @@ func (c *ADSClient) reqEndpoints(streamClient ads.AggregatedDiscoveryService_Str
 	err := streamClient.Send(&envoy_api_v2.DiscoveryRequest{
 		VersionInfo:   "",
 		ResourceNames: clusterNames,
-		TypeUrl:       "type.googleapis.com/envoy.api.v2.ClusterLoadAssignment",
+		TypeUrl:       c.typeURL(EnvoyClusterLoadAssignment),
 		ResponseNonce: "",
 		ErrorDetail:   nil,
 		Node: &envoy_api_v2_core1.Node{
@@ -47,17 +49,26 @@ func (c *ADSClient) reqEndpoints(streamClient ads.AggregatedDiscoveryService_Str
 		log.DefaultLogger.Infof("get endpoints fail: %v", err)
 		return err
 	}
+	c.edsResourceNames.Store(clusterNames)
 	return nil
 }
 
-func (c *ADSClient) handleEndpointsResp(resp *envoy_api_v2.DiscoveryResponse) []*envoy_api_v2.ClusterLoadAssignment {
+func (c *ADSClient) handleEndpointsResp(resp *envoy_api_v2.DiscoveryResponse) ([]*envoy_api_v2.ClusterLoadAssignment, error) {
 	lbAssignments := make([]*envoy_api_v2.ClusterLoadAssignment, 0, len(resp.Resources))
 	for _, res := range resp.Resources {
-		lbAssignment := envoy_api_v2.ClusterLoadAssignment{}
-		if err := lbAssignment.Unmarshal(res.GetValue()); err != nil {
+		var lbAssignment *envoy_api_v2.ClusterLoadAssignment
+		var err error
+		if res.GetTypeUrl() == v3.EnvoyClusterLoadAssignment {
+			lbAssignment, err = v3.ConvertClusterLoadAssignment(res.GetValue())
+		} else {
+			lbAssignment = &envoy_api_v2.ClusterLoadAssignment{}
+			err = lbAssignment.Unmarshal(res.GetValue())
+		}
+		if err != nil {
 			log.DefaultLogger.Errorf("ADSClient unmarshal lbAssignment fail: %v", err)
+			return nil, fmt.Errorf("unmarshal lbAssignment fail: %v", err)
 		}
-		lbAssignments = append(lbAssignments, &lbAssignment)
+		lbAssignments = append(lbAssignments, lbAssignment)
 	}
-	return lbAssignments
+	return lbAssignments, nil
 }
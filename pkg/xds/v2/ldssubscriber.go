@@ -19,12 +19,14 @@ package v2
 
 import (
 	"errors"
+	"fmt"
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/types"
+	v3 "mosn.io/mosn/pkg/xds/v3"
 )
 
 func (c *ADSClient) reqListeners(streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient) error {
@@ -34,7 +36,7 @@ func (c *ADSClient) reqListeners(streamClient ads.AggregatedDiscoveryService_Str
 	err := streamClient.Send(&envoy_api_v2.DiscoveryRequest{
 		VersionInfo:   "",
 		ResourceNames: []string{},
-		TypeUrl:       "type.googleapis.com/envoy.api.v2.Listener",
+		TypeUrl:       c.typeURL(EnvoyListener),
 		ResponseNonce: "",
 		ErrorDetail:   nil,
 		Node: &envoy_api_v2_core1.Node{
@@ -50,14 +52,22 @@ func (c *ADSClient) reqListeners(streamClient ads.AggregatedDiscoveryService_Str
 	return nil
 }
 
-func (c *ADSClient) handleListenersResp(resp *envoy_api_v2.DiscoveryResponse) []*envoy_api_v2.Listener {
+func (c *ADSClient) handleListenersResp(resp *envoy_api_v2.DiscoveryResponse) ([]*envoy_api_v2.Listener, error) {
 	listeners := make([]*envoy_api_v2.Listener, 0, len(resp.Resources))
 	for _, res := range resp.Resources {
-		listener := envoy_api_v2.Listener{}
-		if err := listener.Unmarshal(res.GetValue()); err != nil {
+		var listener *envoy_api_v2.Listener
+		var err error
+		if res.GetTypeUrl() == v3.EnvoyListener {
+			listener, err = v3.ConvertListener(res.GetValue())
+		} else {
+			listener = &envoy_api_v2.Listener{}
+			err = listener.Unmarshal(res.GetValue())
+		}
+		if err != nil {
 			log.DefaultLogger.Errorf("ADSClient unmarshal listener fail: %v", err)
+			return nil, fmt.Errorf("unmarshal listener fail: %v", err)
 		}
-		listeners = append(listeners, &listener)
+		listeners = append(listeners, listener)
 	}
-	return listeners
+	return listeners, nil
 }
@@ -19,6 +19,7 @@ package v2
 
 import (
 	"errors"
+	"fmt"
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
@@ -26,6 +27,7 @@ import (
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/mosn/pkg/xds/v2/rds"
+	v3 "mosn.io/mosn/pkg/xds/v3"
 )
 
 func (c *ADSClient) reqRoutes(streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient) error {
@@ -41,7 +43,7 @@ func (c *ADSClient) reqRoutes(streamClient ads.AggregatedDiscoveryService_Stream
 	err := streamClient.Send(&envoy_api_v2.DiscoveryRequest{
 		VersionInfo:   "",
 		ResourceNames: routerNames,
-		TypeUrl:       "type.googleapis.com/envoy.api.v2.RouteConfiguration",
+		TypeUrl:       c.typeURL(EnvoyRouteConfiguration),
 		ResponseNonce: "",
 		ErrorDetail:   nil,
 		Node: &envoy_api_v2_core1.Node{
@@ -57,14 +59,22 @@ func (c *ADSClient) reqRoutes(streamClient ads.AggregatedDiscoveryService_Stream
 	return nil
 }
 
-func (c *ADSClient) handleRoutesResp(resp *envoy_api_v2.DiscoveryResponse) []*envoy_api_v2.RouteConfiguration {
+func (c *ADSClient) handleRoutesResp(resp *envoy_api_v2.DiscoveryResponse) ([]*envoy_api_v2.RouteConfiguration, error) {
 	routes := make([]*envoy_api_v2.RouteConfiguration, 0, len(resp.Resources))
 	for _, res := range resp.Resources {
-		route := envoy_api_v2.RouteConfiguration{}
-		if err := route.Unmarshal(res.GetValue()); err != nil {
+		var route *envoy_api_v2.RouteConfiguration
+		var err error
+		if res.GetTypeUrl() == v3.EnvoyRouteConfiguration {
+			route, err = v3.ConvertRouteConfiguration(res.GetValue())
+		} else {
+			route = &envoy_api_v2.RouteConfiguration{}
+			err = route.Unmarshal(res.GetValue())
+		}
+		if err != nil {
 			log.DefaultLogger.Errorf("ADSClient unmarshal route fail: %v", err)
+			return nil, fmt.Errorf("unmarshal route fail: %v", err)
 		}
-		routes = append(routes, &route)
+		routes = append(routes, route)
 	}
-	return routes
+	return routes, nil
 }
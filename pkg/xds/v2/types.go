@@ -19,6 +19,7 @@ package v2
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
@@ -28,6 +29,7 @@ import (
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	v2 "mosn.io/mosn/pkg/config/v2"
+	v3 "mosn.io/mosn/pkg/xds/v3"
 )
 
 // XDSConfig contains ADS config and clusters info
@@ -46,10 +48,11 @@ type ClusterConfig struct {
 
 // ADSConfig contains ADS config from dynamic resources
 type ADSConfig struct {
-	APIType      core.ApiConfigSource_ApiType
-	RefreshDelay *time.Duration
-	Services     []*ServiceConfig
-	StreamClient *StreamClient
+	APIType             core.ApiConfigSource_ApiType
+	TransportAPIVersion v3.TransportAPIVersion
+	RefreshDelay        *time.Duration
+	Services            []*ServiceConfig
+	StreamClient        *StreamClient
 }
 
 // ADSClient communicated with pilot
@@ -61,6 +64,8 @@ type ADSClient struct {
 	SendControlChan   chan int
 	RecvControlChan   chan int
 	StopChan          chan int
+	// edsResourceNames stores the cluster names of the latest eds request
+	edsResourceNames atomic.Value
 }
 
 // ServiceConfig for grpc service
@@ -36,6 +36,7 @@ import (
 	"google.golang.org/grpc/credentials"
 	"mosn.io/mosn/pkg/featuregate"
 	"mosn.io/mosn/pkg/log"
+	v3 "mosn.io/mosn/pkg/xds/v3"
 )
 
 //  Init parsed ds and clusters config for xds
@@ -140,8 +141,19 @@ func (c *XDSConfig) loadClusters(staticResources *bootstrap.Bootstrap_StaticReso
 		} else {
 			config.ConnectTimeout = &cluster.ConnectTimeout
 		}
-		config.Address = make([]string, 0, len(cluster.Hosts))
-		for _, host := range cluster.Hosts {
+		hosts := cluster.Hosts
+		// the v3 api removes hosts, the endpoints are set in load_assignment
+		if len(hosts) == 0 && cluster.LoadAssignment != nil {
+			for _, endpoints := range cluster.LoadAssignment.Endpoints {
+				for _, lbEndpoint := range endpoints.LbEndpoints {
+					if ep := lbEndpoint.GetEndpoint(); ep != nil && ep.Address != nil {
+						hosts = append(hosts, ep.Address)
+					}
+				}
+			}
+		}
+		config.Address = make([]string, 0, len(hosts))
+		for _, host := range hosts {
 			if address, ok := host.Address.(*core.Address_SocketAddress); ok {
 				if port, ok := address.SocketAddress.PortSpecifier.(*core.SocketAddress_PortValue); ok {
 					newAddress := fmt.Sprintf("%s:%d", address.SocketAddress.Address, port.PortValue)
@@ -225,11 +237,16 @@ func (c *ADSConfig) GetStreamClient() ads.AggregatedDiscoveryService_StreamAggre
 		log.DefaultLogger.Infof("mosn estab grpc connection to pilot at %v", endpoint)
 		sc.Conn = conn
 	}
-	client := ads.NewAggregatedDiscoveryServiceClient(sc.Conn)
-
 	ctx, cancel := context.WithCancel(context.Background())
 	sc.Cancel = cancel
-	streamClient, err := client.StreamAggregatedResources(ctx)
+	var streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient
+	var err error
+	if c.TransportAPIVersion == v3.TransportAPIV3 {
+		streamClient, err = v3.NewAggregatedDiscoveryStream(ctx, sc.Conn)
+	} else {
+		client := ads.NewAggregatedDiscoveryServiceClient(sc.Conn)
+		streamClient, err = client.StreamAggregatedResources(ctx)
+	}
 	if err != nil {
 		log.DefaultLogger.Infof("fail to create stream client: %v", err)
 		if sc.Conn != nil {
@@ -0,0 +1,51 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v3
+
+import (
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+)
+
+// ConvertCluster converts a v3 Cluster into a v2 Cluster
+func ConvertCluster(b []byte) (*envoy_api_v2.Cluster, error) {
+	cluster := &envoy_api_v2.Cluster{}
+	if err := cluster.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	// tls_context is replaced by transport_socket in v3
+	if socket := cluster.GetTransportSocket(); socket != nil && cluster.TlsContext == nil {
+		tlsContext, err := convertUpstreamTLSContext(socket.GetTypedConfig())
+		if err != nil {
+			return nil, err
+		}
+		cluster.TlsContext = tlsContext
+		cluster.TransportSocket = nil
+	}
+	// the v3 extension protocol options can not be resolved
+	cluster.TypedExtensionProtocolOptions = nil
+	return cluster, nil
+}
+
+// ConvertClusterLoadAssignment converts a v3 ClusterLoadAssignment into a v2 ClusterLoadAssignment
+func ConvertClusterLoadAssignment(b []byte) (*envoy_api_v2.ClusterLoadAssignment, error) {
+	loadAssignment := &envoy_api_v2.ClusterLoadAssignment{}
+	if err := loadAssignment.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	return loadAssignment, nil
+}
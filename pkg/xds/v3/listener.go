@@ -0,0 +1,88 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v3
+
+import (
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	xdslistener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	xdsutil "github.com/envoyproxy/go-control-plane/pkg/util"
+	"github.com/gogo/protobuf/types"
+)
+
+// v3 only field numbers
+const (
+	listenerBindToPort = 26 // Listener.bind_to_port
+	boolValueValue     = 1  // BoolValue.value
+)
+
+// ConvertListener converts a v3 Listener into a v2 Listener
+func ConvertListener(b []byte) (*envoy_api_v2.Listener, error) {
+	listener := &envoy_api_v2.Listener{}
+	if err := listener.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	// deprecated_v1 is removed in v3, use bind_to_port instead
+	if bindToPort, ok := bytesField(listener.XXX_unrecognized, listenerBindToPort); ok {
+		value, _ := varintField(bindToPort, boolValueValue)
+		listener.DeprecatedV1 = &envoy_api_v2.Listener_DeprecatedV1{
+			BindToPort: &types.BoolValue{Value: value != 0},
+		}
+	}
+	// use_original_dst is replaced by the original dst listener filter in v3
+	for _, listenerFilter := range listener.ListenerFilters {
+		listenerFilter.Name = ToV2Name(listenerFilter.Name)
+		listenerFilter.ConfigType = nil
+		if listenerFilter.Name == xdsutil.OriginalDestination {
+			listener.UseOriginalDst = &types.BoolValue{Value: true}
+		}
+	}
+	for i := range listener.FilterChains {
+		if err := convertFilterChain(&listener.FilterChains[i]); err != nil {
+			return nil, err
+		}
+	}
+	return listener, nil
+}
+
+func convertFilterChain(filterChain *xdslistener.FilterChain) error {
+	// tls_context is replaced by transport_socket in v3
+	if socket := filterChain.GetTransportSocket(); socket != nil && filterChain.TlsContext == nil {
+		tlsContext, err := convertDownstreamTLSContext(socket.GetTypedConfig())
+		if err != nil {
+			return err
+		}
+		filterChain.TlsContext = tlsContext
+		filterChain.TransportSocket = nil
+	}
+	for i := range filterChain.Filters {
+		filter := &filterChain.Filters[i]
+		filter.Name = ToV2Name(filter.Name)
+		if typed, ok := filter.ConfigType.(*xdslistener.Filter_TypedConfig); ok {
+			s, err := convertTypedConfig(typed.TypedConfig)
+			if err != nil {
+				return err
+			}
+			if s == nil {
+				filter.ConfigType = nil
+			} else {
+				filter.ConfigType = &xdslistener.Filter_Config{Config: s}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v3
+
+import (
+	"testing"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	xdsauth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	xdscore "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	xdslistener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	xdshttp "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	xdsutil "github.com/envoyproxy/go-control-plane/pkg/util"
+	"github.com/gogo/protobuf/types"
+)
+
+func TestConvertListener(t *testing.T) {
+	hcm := &xdshttp.HttpConnectionManager{
+		StatPrefix: "ingress",
+		HttpFilters: []*xdshttp.HttpFilter{
+			{
+				Name: Router,
+			},
+		},
+	}
+	hcmConfig, err := hcm.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsContext := &xdsauth.DownstreamTlsContext{
+		RequireClientCertificate: &types.BoolValue{Value: true},
+	}
+	tlsConfig, err := tlsContext.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := &envoy_api_v2.Listener{
+		Name: "test",
+		Address: xdscore.Address{
+			Address: &xdscore.Address_SocketAddress{
+				SocketAddress: &xdscore.SocketAddress{
+					Address:       "0.0.0.0",
+					PortSpecifier: &xdscore.SocketAddress_PortValue{PortValue: 80},
+				},
+			},
+		},
+		ListenerFilters: []xdslistener.ListenerFilter{
+			{
+				Name: OriginalDestination,
+			},
+		},
+		FilterChains: []xdslistener.FilterChain{
+			{
+				Filters: []xdslistener.Filter{
+					{
+						Name: HTTPConnectionManager,
+						ConfigType: &xdslistener.Filter_TypedConfig{
+							TypedConfig: &types.Any{
+								TypeUrl: HTTPConnectionManagerType,
+								Value:   hcmConfig,
+							},
+						},
+					},
+				},
+				TransportSocket: &xdscore.TransportSocket{
+					Name: "envoy.transport_sockets.tls",
+					ConfigType: &xdscore.TransportSocket_TypedConfig{
+						TypedConfig: &types.Any{
+							TypeUrl: DownstreamTlsContextType,
+							Value:   tlsConfig,
+						},
+					},
+				},
+			},
+		},
+		XXX_unrecognized: appendBytesField(nil, listenerBindToPort, appendVarintField(nil, boolValueValue, 0)),
+	}
+	b, err := listener.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ConvertListener(b)
+	if err != nil {
+		t.Fatalf("convert listener failed: %v", err)
+	}
+	if bindToPort := got.GetDeprecatedV1().GetBindToPort(); bindToPort == nil || bindToPort.Value {
+		t.Errorf("bind_to_port expected false, but got %v", bindToPort)
+	}
+	if !got.GetUseOriginalDst().GetValue() {
+		t.Error("original dst listener filter expected to be converted to use_original_dst")
+	}
+	filterChain := got.FilterChains[0]
+	if !filterChain.GetTlsContext().GetRequireClientCertificate().GetValue() {
+		t.Errorf("transport_socket expected to be converted to tls_context, but got %v", filterChain.TlsContext)
+	}
+	filter := filterChain.Filters[0]
+	if filter.Name != xdsutil.HTTPConnectionManager {
+		t.Errorf("filter name expected %s, but got %s", xdsutil.HTTPConnectionManager, filter.Name)
+	}
+	s := filter.GetConfig()
+	if s == nil {
+		t.Fatal("typed config expected to be converted to config")
+	}
+	gotHcm := &xdshttp.HttpConnectionManager{}
+	if err := xdsutil.StructToMessage(s, gotHcm); err != nil {
+		t.Fatal(err)
+	}
+	if gotHcm.StatPrefix != "ingress" || gotHcm.HttpFilters[0].Name != xdsutil.Router {
+		t.Errorf("http connection manager is not expected: %v", gotHcm)
+	}
+}
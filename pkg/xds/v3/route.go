@@ -0,0 +1,108 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v3
+
+import (
+	"regexp"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	xdsroute "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	"github.com/gogo/protobuf/types"
+)
+
+// v3 only field numbers
+const (
+	routeMatchSafeRegex         = 10 // RouteMatch.safe_regex
+	headerMatcherSafeRegex      = 11 // HeaderMatcher.safe_regex_match
+	queryParameterMatcherString = 5  // QueryParameterMatcher.string_match
+	regexMatcherRegex           = 2  // RegexMatcher.regex
+	stringMatcherExact          = 1  // StringMatcher.exact
+	stringMatcherPrefix         = 2  // StringMatcher.prefix
+	stringMatcherSuffix         = 3  // StringMatcher.suffix
+	stringMatcherSafeRegex      = 5  // StringMatcher.safe_regex
+)
+
+// ConvertRouteConfiguration converts a v3 RouteConfiguration into a v2 RouteConfiguration
+func ConvertRouteConfiguration(b []byte) (*envoy_api_v2.RouteConfiguration, error) {
+	routeConfig := &envoy_api_v2.RouteConfiguration{}
+	if err := routeConfig.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	convertRouteConfiguration(routeConfig)
+	return routeConfig, nil
+}
+
+func convertRouteConfiguration(routeConfig *envoy_api_v2.RouteConfiguration) {
+	for i := range routeConfig.VirtualHosts {
+		vh := &routeConfig.VirtualHosts[i]
+		// typed per filter config is not supported, and the v3 types can not be resolved
+		vh.TypedPerFilterConfig = nil
+		for j := range vh.Routes {
+			route := &vh.Routes[j]
+			route.TypedPerFilterConfig = nil
+			convertRouteMatch(&route.Match)
+			if action := route.GetRoute(); action != nil {
+				for _, cluster := range action.GetWeightedClusters().GetClusters() {
+					cluster.TypedPerFilterConfig = nil
+				}
+			}
+		}
+	}
+}
+
+func convertRouteMatch(match *xdsroute.RouteMatch) {
+	if regexMatcher, ok := bytesField(match.XXX_unrecognized, routeMatchSafeRegex); ok && match.PathSpecifier == nil {
+		if regex, ok := bytesField(regexMatcher, regexMatcherRegex); ok {
+			match.PathSpecifier = &xdsroute.RouteMatch_Regex{
+				Regex: string(regex),
+			}
+		}
+	}
+	for _, header := range match.Headers {
+		if regexMatcher, ok := bytesField(header.XXX_unrecognized, headerMatcherSafeRegex); ok && header.HeaderMatchSpecifier == nil {
+			if regex, ok := bytesField(regexMatcher, regexMatcherRegex); ok {
+				header.HeaderMatchSpecifier = &xdsroute.HeaderMatcher_RegexMatch{
+					RegexMatch: string(regex),
+				}
+			}
+		}
+	}
+	for _, query := range match.QueryParameters {
+		if stringMatcher, ok := bytesField(query.XXX_unrecognized, queryParameterMatcherString); ok {
+			convertStringMatcher(stringMatcher, query)
+		}
+	}
+}
+
+// convertStringMatcher converts the v3 StringMatcher into the v2 value and regex
+func convertStringMatcher(b []byte, query *xdsroute.QueryParameterMatcher) {
+	if exact, ok := bytesField(b, stringMatcherExact); ok {
+		query.Value = string(exact)
+	} else if prefix, ok := bytesField(b, stringMatcherPrefix); ok {
+		query.Value = "^" + regexp.QuoteMeta(string(prefix))
+		query.Regex = &types.BoolValue{Value: true}
+	} else if suffix, ok := bytesField(b, stringMatcherSuffix); ok {
+		query.Value = regexp.QuoteMeta(string(suffix)) + "$"
+		query.Regex = &types.BoolValue{Value: true}
+	} else if regexMatcher, ok := bytesField(b, stringMatcherSafeRegex); ok {
+		if regex, ok := bytesField(regexMatcher, regexMatcherRegex); ok {
+			query.Value = string(regex)
+			query.Regex = &types.BoolValue{Value: true}
+		}
+	}
+}
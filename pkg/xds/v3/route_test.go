@@ -0,0 +1,88 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v3
+
+import (
+	"testing"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	xdsroute "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+)
+
+func TestConvertRouteConfiguration(t *testing.T) {
+	regexMatcher := appendBytesField(nil, regexMatcherRegex, []byte("/foo/.*"))
+	headerRegexMatcher := appendBytesField(nil, regexMatcherRegex, []byte("v[0-9]"))
+	queryMatcher := appendBytesField(nil, stringMatcherPrefix, []byte("a.b"))
+	routeConfig := &envoy_api_v2.RouteConfiguration{
+		Name: "test",
+		VirtualHosts: []xdsroute.VirtualHost{
+			{
+				Name:    "vh",
+				Domains: []string{"*"},
+				Routes: []xdsroute.Route{
+					{
+						Match: xdsroute.RouteMatch{
+							Headers: []*xdsroute.HeaderMatcher{
+								{
+									Name:             "version",
+									XXX_unrecognized: appendBytesField(nil, headerMatcherSafeRegex, headerRegexMatcher),
+								},
+							},
+							QueryParameters: []*xdsroute.QueryParameterMatcher{
+								{
+									Name:             "key",
+									XXX_unrecognized: appendBytesField(nil, queryParameterMatcherString, queryMatcher),
+								},
+							},
+							XXX_unrecognized: appendBytesField(nil, routeMatchSafeRegex, regexMatcher),
+						},
+						Action: &xdsroute.Route_Route{
+							Route: &xdsroute.RouteAction{
+								ClusterSpecifier: &xdsroute.RouteAction_Cluster{
+									Cluster: "cluster",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	b, err := routeConfig.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ConvertRouteConfiguration(b)
+	if err != nil {
+		t.Fatalf("convert route configuration failed: %v", err)
+	}
+	match := got.VirtualHosts[0].Routes[0].Match
+	if match.GetRegex() != "/foo/.*" {
+		t.Errorf("safe_regex expected to be converted to regex, but got %v", match.PathSpecifier)
+	}
+	if match.Headers[0].GetRegexMatch() != "v[0-9]" {
+		t.Errorf("safe_regex_match expected to be converted to regex_match, but got %v", match.Headers[0].HeaderMatchSpecifier)
+	}
+	query := match.QueryParameters[0]
+	if query.Value != `^a\.b` || !query.GetRegex().GetValue() {
+		t.Errorf("string_match expected to be converted to regex, but got %v", query)
+	}
+	if got.VirtualHosts[0].Routes[0].GetRoute().GetCluster() != "cluster" {
+		t.Error("route action is not expected")
+	}
+}
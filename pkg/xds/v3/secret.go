@@ -0,0 +1,56 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v3
+
+import (
+	xdsauth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+)
+
+// v3 only field numbers
+const (
+	configSourceResourceAPIVersion     = 6 // ConfigSource.resource_api_version
+	apiConfigSourceTransportAPIVersion = 8 // ApiConfigSource.transport_api_version
+	apiVersionV3                       = 2 // ApiVersion.V3
+)
+
+// ConvertSecret converts a v3 Secret into a v2 Secret
+func ConvertSecret(b []byte) (*xdsauth.Secret, error) {
+	secret := &xdsauth.Secret{}
+	if err := secret.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// IsV3ConfigSource checks whether the config source requires the v3 transport api,
+// the sds config source in v3 resources keeps its v3 only fields as unrecognized bytes
+func IsV3ConfigSource(source *core.ConfigSource) bool {
+	if source == nil {
+		return false
+	}
+	if apiConfigSource := source.GetApiConfigSource(); apiConfigSource != nil {
+		if v, ok := varintField(apiConfigSource.XXX_unrecognized, apiConfigSourceTransportAPIVersion); ok {
+			return v == apiVersionV3
+		}
+	}
+	if v, ok := varintField(source.XXX_unrecognized, configSourceResourceAPIVersion); ok {
+		return v == apiVersionV3
+	}
+	return false
+}
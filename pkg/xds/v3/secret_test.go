@@ -0,0 +1,68 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v3
+
+import (
+	"testing"
+
+	xdscore "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+)
+
+func TestIsV3ConfigSource(t *testing.T) {
+	testCases := []struct {
+		source   *xdscore.ConfigSource
+		expected bool
+	}{
+		{
+			source:   nil,
+			expected: false,
+		},
+		{
+			source: &xdscore.ConfigSource{
+				ConfigSourceSpecifier: &xdscore.ConfigSource_ApiConfigSource{
+					ApiConfigSource: &xdscore.ApiConfigSource{
+						ApiType: xdscore.ApiConfigSource_GRPC,
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			source: &xdscore.ConfigSource{
+				ConfigSourceSpecifier: &xdscore.ConfigSource_ApiConfigSource{
+					ApiConfigSource: &xdscore.ApiConfigSource{
+						ApiType:          xdscore.ApiConfigSource_GRPC,
+						XXX_unrecognized: appendVarintField(nil, apiConfigSourceTransportAPIVersion, apiVersionV3),
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			source: &xdscore.ConfigSource{
+				XXX_unrecognized: appendVarintField(nil, configSourceResourceAPIVersion, apiVersionV3),
+			},
+			expected: true,
+		},
+	}
+	for i, tc := range testCases {
+		if got := IsV3ConfigSource(tc.source); got != tc.expected {
+			t.Errorf("case %d expected %v, but got %v", i, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v3
+
+import (
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// The v3 DiscoveryRequest and DiscoveryResponse are wire compatible with the v2 ones,
+// so the v3 discovery streams send and receive the v2 messages with the v3 grpc methods
+
+var discoveryStreamDesc = &grpc.StreamDesc{
+	ServerStreams: true,
+	ClientStreams: true,
+}
+
+// discoveryStream implements both AggregatedDiscoveryService_StreamAggregatedResourcesClient
+// and SecretDiscoveryService_StreamSecretsClient
+type discoveryStream struct {
+	grpc.ClientStream
+}
+
+func (s *discoveryStream) Send(req *envoy_api_v2.DiscoveryRequest) error {
+	return s.ClientStream.SendMsg(req)
+}
+
+func (s *discoveryStream) Recv() (*envoy_api_v2.DiscoveryResponse, error) {
+	resp := &envoy_api_v2.DiscoveryResponse{}
+	if err := s.ClientStream.RecvMsg(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func newDiscoveryStream(ctx context.Context, conn *grpc.ClientConn, method string) (*discoveryStream, error) {
+	stream, err := conn.NewStream(ctx, discoveryStreamDesc, method)
+	if err != nil {
+		return nil, err
+	}
+	return &discoveryStream{stream}, nil
+}
+
+// NewAggregatedDiscoveryStream creates a v3 ads stream
+func NewAggregatedDiscoveryStream(ctx context.Context, conn *grpc.ClientConn) (ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient, error) {
+	return newDiscoveryStream(ctx, conn, AggregatedDiscoveryMethod)
+}
+
+// NewSecretDiscoveryStream creates a v3 sds stream
+func NewSecretDiscoveryStream(ctx context.Context, conn *grpc.ClientConn) (ads.SecretDiscoveryService_StreamSecretsClient, error) {
+	return newDiscoveryStream(ctx, conn, SecretDiscoveryMethod)
+}
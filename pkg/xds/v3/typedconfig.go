@@ -0,0 +1,156 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v3
+
+import (
+	"fmt"
+
+	xdsauth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	xdsaccesslog "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v2"
+	xdsaccesslogfilter "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
+	xdshttpfault "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/fault/v2"
+	xdshttp "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	xdstcp "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/tcp_proxy/v2"
+	xdsutil "github.com/envoyproxy/go-control-plane/pkg/util"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+)
+
+// typedConfigConverter converts a v3 typed config into the v2 struct config
+type typedConfigConverter func(b []byte) (*types.Struct, error)
+
+// typedConfigConverters stores the v3 typed configs that mosn supports
+var typedConfigConverters map[string]typedConfigConverter
+
+func init() {
+	typedConfigConverters = map[string]typedConfigConverter{
+		HTTPConnectionManagerType: convertHTTPConnectionManager,
+		TCPProxyType:              convertTCPProxy,
+		HTTPFaultType:             convertMessage(func() proto.Message { return &xdshttpfault.HTTPFault{} }),
+		FileAccessLogType:         convertMessage(func() proto.Message { return &xdsaccesslog.FileAccessLog{} }),
+	}
+}
+
+// convertTypedConfig converts the typed config into struct config.
+// The config is dropped if the type is unknown, so the filter can be recognized by name only
+func convertTypedConfig(any *types.Any) (*types.Struct, error) {
+	if any == nil {
+		return nil, nil
+	}
+	if converter, ok := typedConfigConverters[any.GetTypeUrl()]; ok {
+		s, err := converter(any.GetValue())
+		if err != nil {
+			return nil, fmt.Errorf("convert typed config %s failed: %v", any.GetTypeUrl(), err)
+		}
+		return s, nil
+	}
+	return nil, nil
+}
+
+func convertMessage(newMessage func() proto.Message) typedConfigConverter {
+	return func(b []byte) (*types.Struct, error) {
+		msg := newMessage()
+		if err := proto.Unmarshal(b, msg); err != nil {
+			return nil, err
+		}
+		return xdsutil.MessageToStruct(msg)
+	}
+}
+
+func convertHTTPConnectionManager(b []byte) (*types.Struct, error) {
+	hcm := &xdshttp.HttpConnectionManager{}
+	if err := hcm.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	for _, filter := range hcm.HttpFilters {
+		filter.Name = ToV2Name(filter.Name)
+		if typed, ok := filter.ConfigType.(*xdshttp.HttpFilter_TypedConfig); ok {
+			s, err := convertTypedConfig(typed.TypedConfig)
+			if err != nil {
+				return nil, err
+			}
+			if s == nil {
+				filter.ConfigType = nil
+			} else {
+				filter.ConfigType = &xdshttp.HttpFilter_Config{Config: s}
+			}
+		}
+	}
+	if err := convertAccessLogs(hcm.AccessLog); err != nil {
+		return nil, err
+	}
+	if routeConfig := hcm.GetRouteConfig(); routeConfig != nil {
+		convertRouteConfiguration(routeConfig)
+	}
+	return xdsutil.MessageToStruct(hcm)
+}
+
+func convertTCPProxy(b []byte) (*types.Struct, error) {
+	tcpProxy := &xdstcp.TcpProxy{}
+	if err := tcpProxy.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	if err := convertAccessLogs(tcpProxy.AccessLog); err != nil {
+		return nil, err
+	}
+	return xdsutil.MessageToStruct(tcpProxy)
+}
+
+func convertAccessLogs(accessLogs []*xdsaccesslogfilter.AccessLog) error {
+	for _, accessLog := range accessLogs {
+		accessLog.Name = ToV2Name(accessLog.Name)
+		// access log filters are not supported
+		accessLog.Filter = nil
+		if typed, ok := accessLog.ConfigType.(*xdsaccesslogfilter.AccessLog_TypedConfig); ok {
+			s, err := convertTypedConfig(typed.TypedConfig)
+			if err != nil {
+				return err
+			}
+			if s == nil {
+				accessLog.ConfigType = nil
+			} else {
+				accessLog.ConfigType = &xdsaccesslogfilter.AccessLog_Config{Config: s}
+			}
+		}
+	}
+	return nil
+}
+
+// convertUpstreamTLSContext converts the typed config of a v3 tls transport socket
+func convertUpstreamTLSContext(any *types.Any) (*xdsauth.UpstreamTlsContext, error) {
+	if any.GetTypeUrl() != UpstreamTlsContextType {
+		return nil, nil
+	}
+	tlsContext := &xdsauth.UpstreamTlsContext{}
+	if err := tlsContext.Unmarshal(any.GetValue()); err != nil {
+		return nil, err
+	}
+	return tlsContext, nil
+}
+
+// convertDownstreamTLSContext converts the typed config of a v3 tls transport socket
+func convertDownstreamTLSContext(any *types.Any) (*xdsauth.DownstreamTlsContext, error) {
+	if any.GetTypeUrl() != DownstreamTlsContextType {
+		return nil, nil
+	}
+	tlsContext := &xdsauth.DownstreamTlsContext{}
+	if err := tlsContext.Unmarshal(any.GetValue()); err != nil {
+		return nil, err
+	}
+	return tlsContext, nil
+}
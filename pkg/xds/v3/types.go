@@ -0,0 +1,97 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package v3 supports the xds v3 api.
+// The v3 resources are wire compatible with the v2 resources except some renamed or moved fields,
+// so the v3 resources are converted into the v2 resources, and handled by the same converters as v2.
+package v3
+
+import (
+	xdsutil "github.com/envoyproxy/go-control-plane/pkg/util"
+)
+
+// the type url of v3 resources
+const (
+	EnvoyListener              = "type.googleapis.com/envoy.config.listener.v3.Listener"
+	EnvoyCluster               = "type.googleapis.com/envoy.config.cluster.v3.Cluster"
+	EnvoyClusterLoadAssignment = "type.googleapis.com/envoy.config.endpoint.v3.ClusterLoadAssignment"
+	EnvoyRouteConfiguration    = "type.googleapis.com/envoy.config.route.v3.RouteConfiguration"
+	EnvoySecret                = "type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.Secret"
+)
+
+// the type url of v3 typed configs
+const (
+	HTTPConnectionManagerType = "type.googleapis.com/envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager"
+	TCPProxyType              = "type.googleapis.com/envoy.extensions.filters.network.tcp_proxy.v3.TcpProxy"
+	HTTPFaultType             = "type.googleapis.com/envoy.extensions.filters.http.fault.v3.HTTPFault"
+	FileAccessLogType         = "type.googleapis.com/envoy.extensions.access_loggers.file.v3.FileAccessLog"
+	UpstreamTlsContextType    = "type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.UpstreamTlsContext"
+	DownstreamTlsContextType  = "type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.DownstreamTlsContext"
+)
+
+// grpc methods of v3 discovery services
+const (
+	AggregatedDiscoveryMethod = "/envoy.service.discovery.v3.AggregatedDiscoveryService/StreamAggregatedResources"
+	SecretDiscoveryMethod     = "/envoy.service.secret.v3.SecretDiscoveryService/StreamSecrets"
+)
+
+// v3 canonical extension names
+const (
+	HTTPConnectionManager = "envoy.filters.network.http_connection_manager"
+	TCPProxy              = "envoy.filters.network.tcp_proxy"
+	Fault                 = "envoy.filters.http.fault"
+	Router                = "envoy.filters.http.router"
+	CORS                  = "envoy.filters.http.cors"
+	FileAccessLog         = "envoy.access_loggers.file"
+	OriginalDestination   = "envoy.filters.listener.original_dst"
+)
+
+// v2Names maps the v3 extension names to the v2 names that the converters know
+var v2Names = map[string]string{
+	HTTPConnectionManager: xdsutil.HTTPConnectionManager,
+	TCPProxy:              xdsutil.TCPProxy,
+	Fault:                 xdsutil.Fault,
+	Router:                xdsutil.Router,
+	CORS:                  xdsutil.CORS,
+	FileAccessLog:         xdsutil.FileAccessLog,
+	OriginalDestination:   xdsutil.OriginalDestination,
+}
+
+// ToV2Name returns the v2 name of a v3 extension name, the name is returned directly if it is not a v3 name
+func ToV2Name(name string) string {
+	if v2Name, ok := v2Names[name]; ok {
+		return v2Name
+	}
+	return name
+}
+
+// TransportAPIVersion is the api version of xds transport
+type TransportAPIVersion string
+
+const (
+	TransportAPIV2 TransportAPIVersion = "V2"
+	TransportAPIV3 TransportAPIVersion = "V3"
+)
+
+// ParseTransportAPIVersion parses the transport_api_version in the bootstrap, v2 is the default value
+func ParseTransportAPIVersion(s string) TransportAPIVersion {
+	switch s {
+	case "V3", "2":
+		return TransportAPIV3
+	}
+	return TransportAPIV2
+}
@@ -0,0 +1,111 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v3
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+var errInvalidWireFormat = errors.New("invalid protobuf wire format")
+
+// rawField is a field in the protobuf wire format
+type rawField struct {
+	number   uint64
+	wireType uint64
+	varint   uint64
+	bytes    []byte
+}
+
+// parseRawFields parses the protobuf wire format bytes, the v3 only fields are stored
+// as unrecognized bytes when the v3 resources are unmarshaled as v2 resources
+func parseRawFields(b []byte) ([]rawField, error) {
+	var fields []rawField
+	for len(b) > 0 {
+		key, n := proto.DecodeVarint(b)
+		if n == 0 {
+			return nil, errInvalidWireFormat
+		}
+		b = b[n:]
+		field := rawField{
+			number:   key >> 3,
+			wireType: key & 0x7,
+		}
+		switch field.wireType {
+		case proto.WireVarint:
+			field.varint, n = proto.DecodeVarint(b)
+			if n == 0 {
+				return nil, errInvalidWireFormat
+			}
+		case proto.WireFixed64:
+			n = 8
+		case proto.WireFixed32:
+			n = 4
+		case proto.WireBytes:
+			var l uint64
+			l, n = proto.DecodeVarint(b)
+			if n == 0 || uint64(len(b)-n) < l {
+				return nil, errInvalidWireFormat
+			}
+			field.bytes = b[n : n+int(l)]
+			n += int(l)
+		default:
+			return nil, fmt.Errorf("unsupported wire type %d", field.wireType)
+		}
+		if len(b) < n {
+			return nil, errInvalidWireFormat
+		}
+		b = b[n:]
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// bytesField returns the last value of a length-delimited field
+func bytesField(b []byte, number uint64) ([]byte, bool) {
+	fields, err := parseRawFields(b)
+	if err != nil {
+		return nil, false
+	}
+	var value []byte
+	var found bool
+	for _, field := range fields {
+		if field.number == number && field.wireType == proto.WireBytes {
+			value, found = field.bytes, true
+		}
+	}
+	return value, found
+}
+
+// varintField returns the last value of a varint field
+func varintField(b []byte, number uint64) (uint64, bool) {
+	fields, err := parseRawFields(b)
+	if err != nil {
+		return 0, false
+	}
+	var value uint64
+	var found bool
+	for _, field := range fields {
+		if field.number == number && field.wireType == proto.WireVarint {
+			value, found = field.varint, true
+		}
+	}
+	return value, found
+}
@@ -0,0 +1,60 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v3
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+// appendBytesField appends a length-delimited field, used to build the v3 only fields
+func appendBytesField(b []byte, number uint64, value []byte) []byte {
+	b = append(b, proto.EncodeVarint(number<<3|proto.WireBytes)...)
+	b = append(b, proto.EncodeVarint(uint64(len(value)))...)
+	return append(b, value...)
+}
+
+// appendVarintField appends a varint field, used to build the v3 only fields
+func appendVarintField(b []byte, number uint64, value uint64) []byte {
+	b = append(b, proto.EncodeVarint(number<<3|proto.WireVarint)...)
+	return append(b, proto.EncodeVarint(value)...)
+}
+
+func TestParseRawFields(t *testing.T) {
+	b := appendVarintField(nil, 1, 300)
+	b = appendBytesField(b, 2, []byte("first"))
+	b = appendBytesField(b, 2, []byte("last"))
+	fields, err := parseRawFields(b)
+	if err != nil || len(fields) != 3 {
+		t.Fatalf("parse raw fields failed, fields: %v, error: %v", fields, err)
+	}
+	if v, ok := varintField(b, 1); !ok || v != 300 {
+		t.Errorf("varint field expected 300, but got %d", v)
+	}
+	if v, ok := bytesField(b, 2); !ok || string(v) != "last" {
+		t.Errorf("bytes field expected last, but got %s", v)
+	}
+	if _, ok := bytesField(b, 3); ok {
+		t.Error("field 3 should not be found")
+	}
+	// truncated bytes
+	if _, err := parseRawFields(b[:len(b)-1]); err == nil {
+		t.Error("parse truncated bytes should be failed")
+	}
+}
@@ -15,7 +15,7 @@
  * limitations under the License.
  */
 
-/* Package xds can be used to create an grpc (just support grpc and v2 api) client communicated with pilot
+/* Package xds can be used to create an grpc (just support grpc, v2 and v3 api) client communicated with pilot
    and fetch config in cycle
 */
 
@@ -36,6 +36,7 @@ import (
 	mv2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
 	v2 "mosn.io/mosn/pkg/xds/v2"
+	xdsv3 "mosn.io/mosn/pkg/xds/v3"
 )
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
@@ -55,6 +56,7 @@ func duration2String(duration *types.Duration) string {
 
 // UnmarshalResources used in order to convert bootstrap_v2 json to pb struct (go-control-plane), some fields must be exchanged format
 func UnmarshalResources(config *mv2.MOSNConfig) (dynamicResources *bootstrap.Bootstrap_DynamicResources, staticResources *bootstrap.Bootstrap_StaticResources, err error) {
+	unmarshaler := resourcesUnmarshaler(TransportAPIVersion(config))
 
 	if len(config.RawDynamicResources) > 0 {
 		dynamicResources = &bootstrap.Bootstrap_DynamicResources{}
@@ -72,6 +74,8 @@ func UnmarshalResources(config *mv2.MOSNConfig) (dynamicResources *bootstrap.Boo
 				log.DefaultLogger.Errorf("fail to unmarshal ads_config: %v", err)
 				return nil, nil, err
 			}
+			// transport_api_version is not a v2 field, it is parsed by TransportAPIVersion
+			delete(adsConfig, "transport_api_version")
 			if refreshDelayRaw, ok := adsConfig["refresh_delay"]; ok {
 				refreshDelay := types.Duration{}
 				err = json.Unmarshal([]byte(refreshDelayRaw), &refreshDelay)
@@ -96,7 +100,7 @@ func UnmarshalResources(config *mv2.MOSNConfig) (dynamicResources *bootstrap.Boo
 				return nil, nil, err
 			}
 
-			err = jsonpb.UnmarshalString(string(b), dynamicResources)
+			err = unmarshaler.Unmarshal(strings.NewReader(string(b)), dynamicResources)
 			if err != nil {
 				log.DefaultLogger.Errorf("fail to unmarshal dynamic_resources: %v", err)
 				return nil, nil, err
@@ -143,6 +147,12 @@ func UnmarshalResources(config *mv2.MOSNConfig) (dynamicResources *bootstrap.Boo
 					return nil, nil, err
 				}
 				cluster["circuit_breakers"] = jsoniter.RawMessage(b)
+				if err = convertTransportSocket(cluster); err != nil {
+					log.DefaultLogger.Errorf("fail to convert transport_socket: %v", err)
+					return nil, nil, err
+				}
+				// the v3 typed options can not be resolved, and mosn does not use them
+				delete(cluster, "typed_extension_protocol_options")
 
 				b, err = json.Marshal(&cluster)
 				if err != nil {
@@ -164,7 +174,7 @@ func UnmarshalResources(config *mv2.MOSNConfig) (dynamicResources *bootstrap.Boo
 			return nil, nil, err
 		}
 
-		err = jsonpb.UnmarshalString(string(b), staticResources)
+		err = unmarshaler.Unmarshal(strings.NewReader(string(b)), staticResources)
 		if err != nil {
 			log.DefaultLogger.Errorf("fail to unmarshal static_resources: %v", err)
 			return nil, nil, err
@@ -179,6 +189,76 @@ func UnmarshalResources(config *mv2.MOSNConfig) (dynamicResources *bootstrap.Boo
 	return dynamicResources, staticResources, nil
 }
 
+// resourcesUnmarshaler returns the unmarshaler of the bootstrap resources.
+// The v3 bootstrap contains the fields unknown to the v2 api, such as resource_api_version, which are ignored,
+// the v2 bootstrap is unmarshaled strictly
+func resourcesUnmarshaler(version xdsv3.TransportAPIVersion) *jsonpb.Unmarshaler {
+	return &jsonpb.Unmarshaler{
+		AllowUnknownFields: version == xdsv3.TransportAPIV3,
+	}
+}
+
+// tlsContextTypes are the tls transport socket types that can be converted into tls_context
+var tlsContextTypes = map[string]bool{
+	"type.googleapis.com/envoy.api.v2.auth.UpstreamTlsContext": true,
+	xdsv3.UpstreamTlsContextType:                               true,
+}
+
+// convertTransportSocket converts the tls transport_socket of a cluster into tls_context,
+// the typed config of the v3 transport_socket can not be resolved by the v2 api
+func convertTransportSocket(cluster map[string]jsoniter.RawMessage) error {
+	socketRaw, ok := cluster["transport_socket"]
+	if !ok {
+		return nil
+	}
+	socket := map[string]jsoniter.RawMessage{}
+	if err := json.Unmarshal([]byte(socketRaw), &socket); err != nil {
+		return err
+	}
+	typedConfig := map[string]jsoniter.RawMessage{}
+	if typedConfigRaw, ok := socket["typed_config"]; ok {
+		if err := json.Unmarshal([]byte(typedConfigRaw), &typedConfig); err != nil {
+			return err
+		}
+	}
+	var typeURL string
+	if typeRaw, ok := typedConfig["@type"]; ok {
+		if err := json.Unmarshal([]byte(typeRaw), &typeURL); err != nil {
+			return err
+		}
+	}
+	delete(cluster, "transport_socket")
+	if !tlsContextTypes[typeURL] {
+		log.DefaultLogger.Warnf("unsupported transport socket type: %s, ignore it", typeURL)
+		return nil
+	}
+	delete(typedConfig, "@type")
+	b, err := json.Marshal(&typedConfig)
+	if err != nil {
+		return err
+	}
+	cluster["tls_context"] = jsoniter.RawMessage(b)
+	return nil
+}
+
+// TransportAPIVersion returns the transport_api_version of the ads_config in the bootstrap
+func TransportAPIVersion(config *mv2.MOSNConfig) xdsv3.TransportAPIVersion {
+	resources := map[string]map[string]jsoniter.RawMessage{}
+	if err := json.Unmarshal([]byte(config.RawDynamicResources), &resources); err != nil {
+		return xdsv3.TransportAPIV2
+	}
+	versionRaw, ok := resources["ads_config"]["transport_api_version"]
+	if !ok {
+		return xdsv3.TransportAPIV2
+	}
+	// the version can be either an enum name or an enum number
+	var version interface{}
+	if err := json.Unmarshal([]byte(versionRaw), &version); err != nil {
+		return xdsv3.TransportAPIV2
+	}
+	return xdsv3.ParseTransportAPIVersion(fmt.Sprint(version))
+}
+
 // Start used to fetch listeners/clusters/clusterloadassignment config from pilot in cycle,
 // usually called when mosn start
 func (c *Client) Start(config *mv2.MOSNConfig) error {
@@ -197,6 +277,9 @@ func (c *Client) Start(config *mv2.MOSNConfig) error {
 		return errors.New("fail to init xds config")
 	}
 
+	xdsConfig.ADSConfig.TransportAPIVersion = TransportAPIVersion(config)
+	log.DefaultLogger.Infof("xds client use transport api version %s", xdsConfig.ADSConfig.TransportAPIVersion)
+
 	stopChan := make(chan int)
 	sendControlChan := make(chan int)
 	recvControlChan := make(chan int)
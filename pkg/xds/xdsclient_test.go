@@ -0,0 +1,54 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xds
+
+import (
+	"strings"
+	"testing"
+
+	bootstrap "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v2"
+
+	mv2 "mosn.io/mosn/pkg/config/v2"
+	xdsv3 "mosn.io/mosn/pkg/xds/v3"
+)
+
+func TestTransportAPIVersion(t *testing.T) {
+	for raw, expected := range map[string]xdsv3.TransportAPIVersion{
+		`{"ads_config":{"api_type":"GRPC","transport_api_version":"V3"}}`: xdsv3.TransportAPIV3,
+		`{"ads_config":{"api_type":"GRPC","transport_api_version":2}}`:    xdsv3.TransportAPIV3,
+		`{"ads_config":{"api_type":"GRPC"}}`:                              xdsv3.TransportAPIV2,
+		``:                                                                xdsv3.TransportAPIV2,
+	} {
+		config := &mv2.MOSNConfig{
+			RawDynamicResources: []byte(raw),
+		}
+		if got := TransportAPIVersion(config); got != expected {
+			t.Errorf("%s expected %s, but got %s", raw, expected, got)
+		}
+	}
+}
+
+func TestResourcesUnmarshalerUnknownFields(t *testing.T) {
+	raw := `{"ads_config":{"api_type":"GRPC","unknown_field":"V3"}}`
+	if err := resourcesUnmarshaler(xdsv3.TransportAPIV2).Unmarshal(strings.NewReader(raw), &bootstrap.Bootstrap_DynamicResources{}); err == nil {
+		t.Error("expected the unknown fields are rejected by v2")
+	}
+	if err := resourcesUnmarshaler(xdsv3.TransportAPIV3).Unmarshal(strings.NewReader(raw), &bootstrap.Bootstrap_DynamicResources{}); err != nil {
+		t.Errorf("expected the unknown fields are ignored by v3, but got %v", err)
+	}
+}
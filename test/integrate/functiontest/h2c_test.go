@@ -0,0 +1,168 @@
+package functiontest
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/mosn"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/test/util"
+)
+
+// protoHTTPHandler replies the protocol of the request
+type protoHTTPHandler struct{}
+
+func (h *protoHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprint(w, r.Proto)
+}
+
+// CreateH2CProxyMesh accepts h2c on a HTTP/1 listener, and the cluster talks h2c with the hosts
+func CreateH2CProxyMesh(addr string, hosts []string) *v2.MOSNConfig {
+	clusterName := "proxyCluster"
+	cluster := util.NewBasicCluster(clusterName, hosts)
+	cluster.UpstreamProtocol = string(protocol.HTTP2)
+	cmconfig := v2.ClusterManagerConfig{
+		Clusters: []v2.Cluster{cluster},
+	}
+	routers := []v2.Router{
+		util.NewPrefixRouter(clusterName, "/"),
+	}
+	chains := []v2.FilterChain{
+		util.NewH2CFilterChain("proxyVirtualHost", routers),
+	}
+	listener := util.NewListener("proxyListener", addr, chains)
+	return util.NewMOSNConfig([]v2.Listener{listener}, cmconfig)
+}
+
+func checkProtoResponse(resp *http.Response, proto string) error {
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response status: %d", resp.StatusCode)
+	}
+	if resp.Proto != proto {
+		return fmt.Errorf("response protocol %s is not %s", resp.Proto, proto)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	// the upstream is always HTTP/2
+	if string(data) != "HTTP/2.0" {
+		return fmt.Errorf("upstream protocol is %s", data)
+	}
+	return nil
+}
+
+// h2cUpgradeRequest sends a HTTP/1.1 request upgrades to h2c, and reads the response from stream 1
+func h2cUpgradeRequest(addr string) error {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// SETTINGS_MAX_CONCURRENT_STREAMS = 100
+	fmt.Fprintf(conn, "GET /upgrade HTTP/1.1\r\nHost: %s\r\nConnection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: AAMAAABk\r\n\r\n", addr)
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return fmt.Errorf("upgrade response status: %d", resp.StatusCode)
+	}
+
+	if _, err := conn.Write([]byte(http2.ClientPreface)); err != nil {
+		return err
+	}
+	framer := http2.NewFramer(conn, br)
+	framer.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	if err := framer.WriteSettings(); err != nil {
+		return err
+	}
+	var status string
+	var body []byte
+	for {
+		f, err := framer.ReadFrame()
+		if err != nil {
+			return err
+		}
+		switch f := f.(type) {
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				framer.WriteSettingsAck()
+			}
+		case *http2.MetaHeadersFrame:
+			if f.StreamID == 1 {
+				status = f.PseudoValue("status")
+				if f.StreamEnded() {
+					return fmt.Errorf("no response body, status: %s", status)
+				}
+			}
+		case *http2.DataFrame:
+			if f.StreamID == 1 {
+				body = append(body, f.Data()...)
+				if f.StreamEnded() {
+					if status != "200" || string(body) != "HTTP/2.0" {
+						return fmt.Errorf("unexpected response, status: %s, body: %s", status, body)
+					}
+					return nil
+				}
+			}
+		}
+	}
+}
+
+func TestH2C(t *testing.T) {
+	appaddr := "127.0.0.1:8080"
+	server := util.NewUpstreamHTTP2(t, appaddr, &protoHTTPHandler{})
+	server.GoServe()
+	defer server.Close()
+	addr := util.CurrentMeshAddr()
+	mesh := mosn.NewMosn(CreateH2CProxyMesh(addr, []string{appaddr}))
+	go mesh.Start()
+	defer mesh.Close()
+	time.Sleep(5 * time.Second) //wait server and mesh start
+
+	priorKnowledge := &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(netw, addr string, cfg *tls.Config) (net.Conn, error) {
+				return net.Dial(netw, addr)
+			},
+		},
+	}
+	for i := 0; i < 3; i++ {
+		// HTTP/1 downstream
+		resp, err := http.Get(fmt.Sprintf("http://%s/http1", addr))
+		if err == nil {
+			err = checkProtoResponse(resp, "HTTP/1.1")
+		}
+		if err != nil {
+			t.Errorf("http1 request #%d failed: %v", i, err)
+		}
+		// h2c with prior knowledge on the HTTP/1 listener
+		resp, err = priorKnowledge.Get(fmt.Sprintf("http://%s/prior", addr))
+		if err == nil {
+			err = checkProtoResponse(resp, "HTTP/2.0")
+		}
+		if err != nil {
+			t.Errorf("prior knowledge request #%d failed: %v", i, err)
+		}
+		// h2c upgrade
+		if err := h2cUpgradeRequest(addr); err != nil {
+			t.Errorf("upgrade request #%d failed: %v", i, err)
+		}
+	}
+}
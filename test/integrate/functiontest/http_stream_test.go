@@ -0,0 +1,126 @@
+package functiontest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/mosn"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/test/util"
+)
+
+// echoHTTPHandler replies the request body in small writes, so the response is chunked
+type echoHTTPHandler struct{}
+
+func (h *echoHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	for len(body) > 0 {
+		n := 32 * 1024
+		if n > len(body) {
+			n = len(body)
+		}
+		w.Write(body[:n])
+		w.(http.Flusher).Flush()
+		body = body[n:]
+	}
+}
+
+// CreateHTTP1StreamProxyMesh enables the streaming mode on the listener, or on the route if routeStream is true
+func CreateHTTP1StreamProxyMesh(addr string, hosts []string, routeStream bool) *v2.MOSNConfig {
+	clusterName := "proxyCluster"
+	cmconfig := v2.ClusterManagerConfig{
+		Clusters: []v2.Cluster{
+			util.NewBasicCluster(clusterName, hosts),
+		},
+	}
+	router := util.NewPrefixRouter(clusterName, "/")
+	var chain v2.FilterChain
+	if routeStream {
+		router.Route.Http1UseStream = true
+		chain = util.NewFilterChain("proxyVirtualHost", protocol.HTTP1, protocol.HTTP1, []v2.Router{router})
+	} else {
+		chain = util.NewHTTP1StreamFilterChain("proxyVirtualHost", []v2.Router{router})
+	}
+	listener := util.NewListener("proxyListener", addr, []v2.FilterChain{chain})
+	return util.NewMOSNConfig([]v2.Listener{listener}, cmconfig)
+}
+
+func echoRequest(addr string, body []byte, chunked bool) error {
+	var reader io.Reader = bytes.NewReader(body)
+	if chunked {
+		// the request body with unknown length is chunked
+		pr, pw := io.Pipe()
+		go func() {
+			pw.Write(body)
+			pw.Close()
+		}()
+		reader = pr
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://%s/echo", addr), reader)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response status: %d", resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(data, body) {
+		return fmt.Errorf("response body length %d is not the request body, length %d", len(data), len(body))
+	}
+	return nil
+}
+
+func TestHTTP1StreamBody(t *testing.T) {
+	// larger than the max request body size in buffering mode
+	largeBody := make([]byte, 5*1024*1024)
+	rand.Read(largeBody)
+	body := largeBody[:1024*1024]
+
+	testCases := []struct {
+		name        string
+		routeStream bool
+		body        []byte
+		chunked     bool
+	}{
+		{"listener content length", false, largeBody, false},
+		{"listener chunked", false, body, true},
+		// the request body is buffered, and the response body is streamed
+		{"route", true, body, false},
+	}
+	for _, tc := range testCases {
+		server := util.NewHTTPServer(t, &echoHTTPHandler{})
+		server.GoServe()
+		addr := util.CurrentMeshAddr()
+		mesh := mosn.NewMosn(CreateHTTP1StreamProxyMesh(addr, []string{server.Addr()}, tc.routeStream))
+		go mesh.Start()
+		time.Sleep(5 * time.Second) //wait server and mesh start
+
+		// the connections are reused by the following requests
+		for i := 0; i < 3; i++ {
+			if err := echoRequest(addr, tc.body, tc.chunked); err != nil {
+				t.Errorf("case %s request #%d failed: %v", tc.name, i, err)
+			}
+		}
+		mesh.Close()
+		server.Close()
+	}
+}
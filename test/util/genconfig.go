@@ -73,6 +73,32 @@ func NewXProtocolFilterChain(name string, subProtocol types.ProtocolName, router
 	return makeFilterChain(proxy, routers, name)
 }
 
+// NewHTTP1StreamFilterChain creates a HTTP/1 proxy which forwards the bodies in data frames
+func NewHTTP1StreamFilterChain(routerConfigName string, routers []v2.Router) v2.FilterChain {
+	proxy := NewProxyFilter(routerConfigName, protocol.HTTP1, protocol.HTTP1)
+	extendConfig := &v2.ProxyGeneralExtendConfig{
+		Http1UseStream: true,
+	}
+	extendMap := make(map[string]interface{})
+	data, _ := json.Marshal(extendConfig)
+	json.Unmarshal(data, &extendMap)
+	proxy.ExtendConfig = extendMap
+	return makeFilterChain(proxy, routers, routerConfigName)
+}
+
+// NewH2CFilterChain creates a HTTP/1 proxy which accepts cleartext HTTP/2 on the same port
+func NewH2CFilterChain(routerConfigName string, routers []v2.Router) v2.FilterChain {
+	proxy := NewProxyFilter(routerConfigName, protocol.HTTP1, protocol.HTTP1)
+	extendConfig := &v2.ProxyGeneralExtendConfig{
+		EnableH2C: true,
+	}
+	extendMap := make(map[string]interface{})
+	data, _ := json.Marshal(extendConfig)
+	json.Unmarshal(data, &extendMap)
+	proxy.ExtendConfig = extendMap
+	return makeFilterChain(proxy, routers, routerConfigName)
+}
+
 func NewBasicCluster(name string, hosts []string) v2.Cluster {
 	var vhosts []v2.Host
 	for _, addr := range hosts {